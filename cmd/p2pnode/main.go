@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -18,6 +20,7 @@ import (
 
 	p2papi "github.com/execution-hub/execution-hub/internal/p2p/api"
 	"github.com/execution-hub/execution-hub/internal/p2p/consensus"
+	"github.com/execution-hub/execution-hub/internal/p2p/scheduler"
 )
 
 type runtimeConfig struct {
@@ -31,6 +34,14 @@ type runtimeConfig struct {
 	JoinRetries       int
 	JoinRetryDelay    time.Duration
 	StartupWaitLeader time.Duration
+
+	SchedulerEnabled      bool
+	SchedulerPolicy       string
+	SchedulerInterval     time.Duration
+	SchedulerOfferTimeout time.Duration
+	SchedulerActor        string
+	SchedulerPrivateKey   string
+	SchedulerPublicKey    ed25519.PublicKey
 }
 
 func main() {
@@ -46,6 +57,7 @@ func main() {
 		Bootstrap:      cfg.Bootstrap,
 		SnapshotRetain: 2,
 		ApplyTimeout:   cfg.ApplyTimeout,
+		SchedulerKey:   cfg.SchedulerPublicKey,
 	})
 	if err != nil {
 		log.Fatalf("create raft node: %v", err)
@@ -68,6 +80,17 @@ func main() {
 		cancel()
 	}
 
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	if cfg.SchedulerEnabled {
+		sched, err := newScheduler(cfg, node)
+		if err != nil {
+			log.Fatalf("create scheduler: %v", err)
+		}
+		go sched.Run(schedCtx)
+		log.Printf("p2p scheduler enabled (policy=%s interval=%s offer_timeout=%s)", cfg.SchedulerPolicy, cfg.SchedulerInterval, cfg.SchedulerOfferTimeout)
+	}
+
 	apiServer := p2papi.NewServer(node)
 	httpServer := &http.Server{
 		Addr:         cfg.HTTPAddr,
@@ -88,6 +111,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	stopScheduler()
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_ = httpServer.Shutdown(shutdownCtx)
@@ -109,6 +133,20 @@ func loadConfig() (*runtimeConfig, error) {
 	joinRetryDelay := parseDuration(getenv("P2P_JOIN_RETRY_DELAY", "1s"), time.Second)
 	startupWait := parseDuration(getenv("P2P_STARTUP_WAIT_LEADER", "4s"), 4*time.Second)
 
+	schedulerEnabled := parseBool(getenv("P2P_SCHEDULER_ENABLED", "false"), false)
+	schedulerPolicy := getenv("P2P_SCHEDULER_POLICY", string(scheduler.PolicyLeastLoaded))
+	schedulerInterval := parseDuration(getenv("P2P_SCHEDULER_INTERVAL", "2s"), 2*time.Second)
+	schedulerOfferTimeout := parseDuration(getenv("P2P_SCHEDULER_OFFER_TIMEOUT", "60s"), 60*time.Second)
+	schedulerActor := getenv("P2P_SCHEDULER_ACTOR", "scheduler:"+nodeID)
+	schedulerPrivateKey := getenv("P2P_SCHEDULER_PRIVATE_KEY", "")
+	if schedulerEnabled && schedulerPrivateKey == "" {
+		return nil, errors.New("P2P_SCHEDULER_PRIVATE_KEY is required when the scheduler is enabled")
+	}
+	schedulerPublicKey, err := loadSchedulerPublicKey(getenv("P2P_SCHEDULER_PUBLIC_KEY", ""), schedulerPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
 	dataDir := strings.TrimSpace(getenv("P2P_DATA_DIR", ""))
 	if dataDir == "" {
 		dataDir = filepath.Join("tmp", "p2pnode", nodeID)
@@ -128,9 +166,83 @@ func loadConfig() (*runtimeConfig, error) {
 		JoinRetries:       joinRetries,
 		JoinRetryDelay:    joinRetryDelay,
 		StartupWaitLeader: startupWait,
+
+		SchedulerEnabled:      schedulerEnabled,
+		SchedulerPolicy:       schedulerPolicy,
+		SchedulerInterval:     schedulerInterval,
+		SchedulerOfferTimeout: schedulerOfferTimeout,
+		SchedulerActor:        schedulerActor,
+		SchedulerPrivateKey:   schedulerPrivateKey,
+		SchedulerPublicKey:    schedulerPublicKey,
 	}, nil
 }
 
+func newScheduler(cfg *runtimeConfig, node *consensus.Node) (*scheduler.Scheduler, error) {
+	policy, err := scheduler.ParsePolicy(cfg.SchedulerPolicy)
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadSchedulerKey(cfg.SchedulerPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.New(node, scheduler.Config{
+		Policy:       policy,
+		Interval:     cfg.SchedulerInterval,
+		OfferTimeout: cfg.SchedulerOfferTimeout,
+		Actor:        cfg.SchedulerActor,
+		PrivateKey:   key,
+	})
+}
+
+// loadSchedulerKey accepts a base64 32-byte seed or 64-byte private key.
+func loadSchedulerKey(raw string) (ed25519.PrivateKey, error) {
+	if raw == "" {
+		return nil, errors.New("P2P_SCHEDULER_PRIVATE_KEY is required")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid P2P_SCHEDULER_PRIVATE_KEY: %w", err)
+	}
+	switch len(decoded) {
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(decoded), nil
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(decoded), nil
+	default:
+		return nil, fmt.Errorf("invalid P2P_SCHEDULER_PRIVATE_KEY length: %d", len(decoded))
+	}
+}
+
+// loadSchedulerPublicKey resolves the cluster-wide scheduler identity from a
+// base64 public key, or derives it from the private key. Both empty leaves
+// STEP_ASSIGN disabled.
+func loadSchedulerPublicKey(raw, privateKey string) (ed25519.PublicKey, error) {
+	var derived ed25519.PublicKey
+	if privateKey != "" {
+		key, err := loadSchedulerKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		derived = key.Public().(ed25519.PublicKey)
+	}
+	if raw == "" {
+		return derived, nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid P2P_SCHEDULER_PUBLIC_KEY: %w", err)
+	}
+	if len(decoded) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid P2P_SCHEDULER_PUBLIC_KEY length: %d", len(decoded))
+	}
+	pub := ed25519.PublicKey(decoded)
+	if derived != nil && !pub.Equal(derived) {
+		return nil, errors.New("P2P_SCHEDULER_PUBLIC_KEY does not match P2P_SCHEDULER_PRIVATE_KEY")
+	}
+	return pub, nil
+}
+
 func joinCluster(cfg *runtimeConfig) error {
 	endpoint := strings.TrimRight(cfg.JoinEndpoint, "/") + "/v1/p2p/raft/join"
 	payload := map[string]string{
@@ -0,0 +1,326 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	appAudit "github.com/execution-hub/execution-hub/internal/application/audit"
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+func (s *Server) verifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	var from, to int64
+	for name, dst := range map[string]*int64{"from": &from, "to": &to} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid "+name)
+			return
+		}
+		*dst = n
+	}
+	report, err := s.auditSvc.VerifyChain(contextFromRequest(r), from, to)
+	if err != nil {
+		if errors.Is(err, appAudit.ErrInvalidChainRange) {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+func (s *Server) createAuditCheckpoint(w http.ResponseWriter, r *http.Request) {
+	checkpoint, err := s.auditSvc.CreateCheckpoint(contextFromRequest(r))
+	if err != nil {
+		if errors.Is(err, appAudit.ErrAuditChainEmpty) {
+			respondError(w, http.StatusConflict, "CONFLICT", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusCreated, checkpoint)
+}
+
+func (s *Server) listAuditCheckpoints(w http.ResponseWriter, r *http.Request) {
+	checkpoints, err := s.auditSvc.ListCheckpoints(contextFromRequest(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if checkpoints == nil {
+		checkpoints = []*audit.Checkpoint{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": checkpoints})
+}
+
+// exportAuditCheckpoint serves a checkpoint as a file to anchor off-site
+func (s *Server) exportAuditCheckpoint(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "checkpointId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid checkpointId")
+		return
+	}
+	export, err := s.auditSvc.ExportCheckpoint(contextFromRequest(r), id)
+	if err != nil {
+		if errors.Is(err, audit.ErrCheckpointNotFound) {
+			respondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-checkpoint-%d.json"`, id))
+	respondJSON(w, http.StatusOK, export)
+}
+
+// auditQueryParams parses the audit log filters of the query string
+func auditQueryParams(r *http.Request) appAudit.QueryParams {
+	params := appAudit.QueryParams{
+		Limit: 50,
+	}
+	if v := r.URL.Query().Get("entityType"); v != "" {
+		params.EntityType = &v
+	}
+	if v := r.URL.Query().Get("entityId"); v != "" {
+		params.EntityID = &v
+	}
+	if v := r.URL.Query().Get("action"); v != "" {
+		params.Action = &v
+	}
+	if v := r.URL.Query().Get("actor"); v != "" {
+		params.Actor = &v
+	}
+	if v := r.URL.Query().Get("riskLevel"); v != "" {
+		params.RiskLevel = &v
+	}
+	if v := r.URL.Query().Get("traceId"); v != "" {
+		params.TraceID = &v
+	}
+	return params
+}
+
+// exportAudit streams the audit logs matching the query filters in a SIEM
+// format, newest first
+func (s *Server) exportAudit(w http.ResponseWriter, r *http.Request) {
+	format, err := audit.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-export.%s"`, strings.ToLower(string(format))))
+	written, err := s.auditSvc.Export(contextFromRequest(r), w, format, auditQueryParams(r))
+	if err != nil && written == 0 {
+		w.Header().Del("Content-Disposition")
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+}
+
+type retentionPolicyRequest struct {
+	EntityType    *string `json:"entity_type,omitempty"`
+	RiskLevel     *string `json:"risk_level,omitempty"`
+	RetentionDays int     `json:"retention_days"`
+}
+
+type legalHoldRequest struct {
+	EntityType *string `json:"entity_type,omitempty"`
+	EntityID   *string `json:"entity_id,omitempty"`
+	TraceID    *string `json:"trace_id,omitempty"`
+	Reason     string  `json:"reason"`
+}
+
+func respondRetentionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, appAudit.ErrInvalidRetention):
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+	case errors.Is(err, audit.ErrRetentionPolicyNotFound), errors.Is(err, audit.ErrLegalHoldNotFound), errors.Is(err, audit.ErrArchiveNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, audit.ErrLegalHoldConflict), errors.Is(err, appAudit.ErrArchiveRestored), errors.Is(err, appAudit.ErrArchiveNotRestored):
+		respondError(w, http.StatusConflict, "CONFLICT", err.Error())
+	case errors.Is(err, appAudit.ErrArchiveInvalid), errors.Is(err, appAudit.ErrSigningKeyMissing), errors.Is(err, appAudit.ErrArchiveStoreMissing):
+		respondError(w, http.StatusConflict, "INVALID_STATE", err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+}
+
+func (s *Server) listRetentionPolicies(w http.ResponseWriter, r *http.Request) {
+	policies, err := s.auditSvc.ListRetentionPolicies(contextFromRequest(r))
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	if policies == nil {
+		policies = []*audit.RetentionPolicy{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": policies})
+}
+
+// saveRetentionPolicy creates or replaces the policy of an entity type and
+// risk level; omitting both sets the default policy
+func (s *Server) saveRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	var req retentionPolicyRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	policy := &audit.RetentionPolicy{RetentionDays: req.RetentionDays}
+	if req.EntityType != nil {
+		et := audit.EntityType(strings.ToUpper(*req.EntityType))
+		policy.EntityType = &et
+	}
+	if req.RiskLevel != nil {
+		rl := audit.RiskLevel(strings.ToUpper(*req.RiskLevel))
+		policy.RiskLevel = &rl
+	}
+	if err := s.auditSvc.SaveRetentionPolicy(contextFromRequest(r), policy, s.actorFromRequest(r)); err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, policy)
+}
+
+func (s *Server) deleteRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "policyId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid policyId")
+		return
+	}
+	if err := s.auditSvc.DeleteRetentionPolicy(contextFromRequest(r), id, s.actorFromRequest(r)); err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// runRetention archives and purges expired audit logs now
+func (s *Server) runRetention(w http.ResponseWriter, r *http.Request) {
+	run, err := s.auditSvc.ApplyRetention(contextFromRequest(r), s.actorFromRequest(r))
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+func (s *Server) listLegalHolds(w http.ResponseWriter, r *http.Request) {
+	includeReleased := r.URL.Query().Get("includeReleased") == "true"
+	holds, err := s.auditSvc.ListLegalHolds(contextFromRequest(r), includeReleased)
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	if holds == nil {
+		holds = []*audit.LegalHold{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": holds})
+}
+
+func (s *Server) createLegalHold(w http.ResponseWriter, r *http.Request) {
+	var req legalHoldRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	hold := &audit.LegalHold{
+		EntityID:  req.EntityID,
+		TraceID:   req.TraceID,
+		Reason:    req.Reason,
+		CreatedBy: s.actorFromRequest(r),
+	}
+	if req.EntityType != nil {
+		et := audit.EntityType(strings.ToUpper(*req.EntityType))
+		hold.EntityType = &et
+	}
+	if err := s.auditSvc.CreateLegalHold(contextFromRequest(r), hold); err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, hold)
+}
+
+func (s *Server) releaseLegalHold(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "holdId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid holdId")
+		return
+	}
+	hold, err := s.auditSvc.ReleaseLegalHold(contextFromRequest(r), id, s.actorFromRequest(r))
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, hold)
+}
+
+func (s *Server) listAuditArchives(w http.ResponseWriter, r *http.Request) {
+	archives, err := s.auditSvc.ListArchives(contextFromRequest(r))
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	if archives == nil {
+		archives = []*audit.Archive{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": archives})
+}
+
+func archiveIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "archiveId"), 10, 64)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid archiveId")
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) verifyAuditArchive(w http.ResponseWriter, r *http.Request) {
+	id, ok := archiveIDParam(w, r)
+	if !ok {
+		return
+	}
+	report, err := s.auditSvc.VerifyArchive(contextFromRequest(r), id)
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
+
+// restoreAuditArchive puts an archive's logs back for queries
+func (s *Server) restoreAuditArchive(w http.ResponseWriter, r *http.Request) {
+	id, ok := archiveIDParam(w, r)
+	if !ok {
+		return
+	}
+	archive, err := s.auditSvc.RestoreArchive(contextFromRequest(r), id, s.actorFromRequest(r))
+	if err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, archive)
+}
+
+// unrestoreAuditArchive removes the restored logs again
+func (s *Server) unrestoreAuditArchive(w http.ResponseWriter, r *http.Request) {
+	id, ok := archiveIDParam(w, r)
+	if !ok {
+		return
+	}
+	if err := s.auditSvc.UnrestoreArchive(contextFromRequest(r), id, s.actorFromRequest(r)); err != nil {
+		respondRetentionError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
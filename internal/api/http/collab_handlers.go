@@ -598,8 +598,13 @@ func (s *Server) collabSSEEndpoint(w http.ResponseWriter, r *http.Request) {
 		groups = append(groups, "role:"+strings.ToUpper(string(auth.Role)))
 	}
 
+	lastEventID, resuming, err := parseLastEventID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
 	client := notification.NewSSEClient(clientID, userID, groups)
-	s.sseHub.Register(client)
+	complete := s.connectSSEClient(client, lastEventID, resuming)
 	defer s.sseHub.Unregister(clientID)
 
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -612,6 +617,9 @@ func (s *Server) collabSSEEndpoint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	_, _ = w.Write([]byte(": connected\n\n"))
+	if !complete {
+		writeSSEResync(w, lastEventID)
+	}
 	flusher.Flush()
 
 	ctx := r.Context()
@@ -627,10 +635,7 @@ func (s *Server) collabSSEEndpoint(w http.ResponseWriter, r *http.Request) {
 			if sessionFilter != "" && !matchSessionFilter(msg.Data, sessionFilter) {
 				continue
 			}
-			payload, _ := json.Marshal(msg)
-			_, _ = w.Write([]byte("data: "))
-			_, _ = w.Write(payload)
-			_, _ = w.Write([]byte("\n\n"))
+			writeSSEMessage(w, msg)
 			flusher.Flush()
 		case <-ctx.Done():
 			return
@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	domainUser "github.com/execution-hub/execution-hub/internal/domain/user"
+)
+
+type throttleRequest struct {
+	Mode            string `json:"mode"`
+	WindowSeconds   int    `json:"window_seconds"`
+	MaxPerWindow    int    `json:"max_per_window"`
+	IncludeCritical bool   `json:"include_critical,omitempty"`
+}
+
+type quietHoursRequest struct {
+	Start           string `json:"start"`
+	End             string `json:"end"`
+	TimeZone        string `json:"time_zone,omitempty"`
+	IncludeCritical bool   `json:"include_critical,omitempty"`
+}
+
+type muteRequest struct {
+	RuleID     *uuid.UUID `json:"rule_id,omitempty"`
+	WorkflowID *uuid.UUID `json:"workflow_id,omitempty"`
+	Until      time.Time  `json:"until"`
+}
+
+type notificationPreferenceRequest struct {
+	Channels    []string           `json:"channels,omitempty"`
+	MinPriority map[string]string  `json:"min_priority,omitempty"`
+	QuietHours  *quietHoursRequest `json:"quiet_hours,omitempty"`
+	Mutes       []muteRequest      `json:"mutes,omitempty"`
+	Throttle    *throttleRequest   `json:"throttle,omitempty"`
+}
+
+// preferenceTarget reads the {targetType}/{targetId} path and checks that
+// the caller may manage it: admins manage any target, other users only
+// their own user preference, by user ID or username.
+func (s *Server) preferenceTarget(w http.ResponseWriter, r *http.Request) (notification.TargetType, string, bool) {
+	var targetType notification.TargetType
+	switch strings.ToLower(chi.URLParam(r, "targetType")) {
+	case "user":
+		targetType = notification.TargetTypeUser
+	case "group":
+		targetType = notification.TargetTypeGroup
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "targetType must be user or group")
+		return "", "", false
+	}
+	targetID := chi.URLParam(r, "targetId")
+	auth := authUserFromContext(r.Context())
+	if auth == nil {
+		respondError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing auth")
+		return "", "", false
+	}
+	if auth.Role != domainUser.RoleAdmin {
+		own := targetType == notification.TargetTypeUser &&
+			(targetID == auth.UserID.String() || targetID == auth.Username)
+		if !own {
+			respondError(w, http.StatusForbidden, "FORBIDDEN", "insufficient role")
+			return "", "", false
+		}
+	}
+	return targetType, targetID, true
+}
+
+func (s *Server) getNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	targetType, targetID, ok := s.preferenceTarget(w, r)
+	if !ok {
+		return
+	}
+	p, err := s.notificationSvc.GetPreference(contextFromRequest(r), targetType, targetID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if p == nil {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "preference not found")
+		return
+	}
+	respondJSON(w, http.StatusOK, p)
+}
+
+func (s *Server) putNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	targetType, targetID, ok := s.preferenceTarget(w, r)
+	if !ok {
+		return
+	}
+	var req notificationPreferenceRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid body")
+		return
+	}
+	p := &notification.Preference{TargetType: targetType, TargetID: targetID}
+	for _, c := range req.Channels {
+		p.Channels = append(p.Channels, notification.Channel(strings.ToUpper(c)))
+	}
+	if len(req.MinPriority) > 0 {
+		p.MinPriority = map[notification.Channel]notification.Priority{}
+		for c, priority := range req.MinPriority {
+			p.MinPriority[notification.Channel(strings.ToUpper(c))] = notification.Priority(strings.ToUpper(priority))
+		}
+	}
+	if req.QuietHours != nil {
+		p.QuietHours = &notification.QuietHours{
+			Start:           req.QuietHours.Start,
+			End:             req.QuietHours.End,
+			TimeZone:        req.QuietHours.TimeZone,
+			IncludeCritical: req.QuietHours.IncludeCritical,
+		}
+	}
+	for _, m := range req.Mutes {
+		p.Mutes = append(p.Mutes, notification.Mute{RuleID: m.RuleID, WorkflowID: m.WorkflowID, Until: m.Until.UTC()})
+	}
+	if req.Throttle != nil {
+		p.Throttle = &notification.ThrottleConfig{
+			Mode:            notification.ThrottleMode(strings.ToUpper(req.Throttle.Mode)),
+			WindowSeconds:   req.Throttle.WindowSeconds,
+			MaxPerWindow:    req.Throttle.MaxPerWindow,
+			IncludeCritical: req.Throttle.IncludeCritical,
+		}
+	}
+	if err := s.notificationSvc.ValidatePreference(p); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	saved, err := s.notificationSvc.SetPreference(contextFromRequest(r), p)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
+
+func (s *Server) deleteNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	targetType, targetID, ok := s.preferenceTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := s.notificationSvc.DeletePreference(contextFromRequest(r), targetType, targetID); err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"target_type": targetType, "target_id": targetID, "deleted": true})
+}
@@ -0,0 +1,53 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	appRule "github.com/execution-hub/execution-hub/internal/application/rule"
+)
+
+type backtestRuleRequest struct {
+	Version    int     `json:"version,omitempty"`
+	Since      string  `json:"since"`
+	Until      string  `json:"until"`
+	SourceID   *string `json:"source_id,omitempty"`
+	SampleSize int     `json:"sample_size,omitempty"`
+}
+
+func (s *Server) backtestRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := parseUUIDParam(r, "ruleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid ruleId")
+		return
+	}
+	var req backtestRuleRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	since, err := time.Parse(time.RFC3339, req.Since)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid since")
+		return
+	}
+	until, err := time.Parse(time.RFC3339, req.Until)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid until")
+		return
+	}
+
+	report, err := s.ruleSvc.Backtest(contextFromRequest(r), appRule.BacktestInput{
+		RuleID:     ruleID,
+		Version:    req.Version,
+		Since:      since,
+		Until:      until,
+		SourceID:   req.SourceID,
+		SampleSize: req.SampleSize,
+	})
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
+}
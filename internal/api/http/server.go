@@ -3,6 +3,8 @@ package httpapi
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -19,6 +21,7 @@ import (
 	appCollab "github.com/execution-hub/execution-hub/internal/application/collab"
 	appExecutor "github.com/execution-hub/execution-hub/internal/application/executor"
 	appNotification "github.com/execution-hub/execution-hub/internal/application/notification"
+	appRule "github.com/execution-hub/execution-hub/internal/application/rule"
 	appTask "github.com/execution-hub/execution-hub/internal/application/task"
 	appTrust "github.com/execution-hub/execution-hub/internal/application/trust"
 	appUser "github.com/execution-hub/execution-hub/internal/application/user"
@@ -27,6 +30,7 @@ import (
 	"github.com/execution-hub/execution-hub/internal/domain/executor"
 	"github.com/execution-hub/execution-hub/internal/domain/notification"
 	"github.com/execution-hub/execution-hub/internal/domain/task"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
 	domainUser "github.com/execution-hub/execution-hub/internal/domain/user"
 	"github.com/execution-hub/execution-hub/internal/domain/workflow"
 	"github.com/execution-hub/execution-hub/internal/infrastructure/sse"
@@ -45,6 +49,7 @@ type Server struct {
 	userSvc             *appUser.Service
 	approvalSvc         *appApproval.Service
 	collabSvc           *appCollab.Service
+	ruleSvc             *appRule.Service
 	sseHub              *sse.Hub
 	sessionCookieName   string
 	sessionCookieSecure bool
@@ -62,6 +67,7 @@ func NewServer(
 	userSvc *appUser.Service,
 	approvalSvc *appApproval.Service,
 	collabSvc *appCollab.Service,
+	ruleSvc *appRule.Service,
 	sseHub *sse.Hub,
 	sessionCookieName string,
 	sessionCookieSecure bool,
@@ -78,6 +84,7 @@ func NewServer(
 		userSvc:             userSvc,
 		approvalSvc:         approvalSvc,
 		collabSvc:           collabSvc,
+		ruleSvc:             ruleSvc,
 		sseHub:              sseHub,
 		sessionCookieName:   sessionCookieName,
 		sessionCookieSecure: sessionCookieSecure,
@@ -135,9 +142,17 @@ func (s *Server) Router() http.Handler {
 
 			r.Route("/notifications", func(r chi.Router) {
 				r.Get("/", s.listNotifications)
+				r.Get("/dead-letters", s.listDeadLetters)
+				r.Get("/dead-letters/{notificationId}", s.getDeadLetter)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/dead-letters/{notificationId}/replay", s.replayDeadLetter)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/dead-letters/{notificationId}/discard", s.discardDeadLetter)
+				r.Get("/preferences/{targetType}/{targetId}", s.getNotificationPreference)
+				r.Put("/preferences/{targetType}/{targetId}", s.putNotificationPreference)
+				r.Delete("/preferences/{targetType}/{targetId}", s.deleteNotificationPreference)
 				r.Get("/{notificationId}", s.getNotification)
 				r.Post("/{notificationId}/send", s.sendNotification)
 				r.Get("/sse", s.sseEndpoint)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/sse/stats", s.sseStats)
 			})
 
 			r.Route("/approvals", func(r chi.Router) {
@@ -161,14 +176,45 @@ func (s *Server) Router() http.Handler {
 				r.With(s.requireRole(string(domainUser.RoleAdmin))).Put("/{userId}/password", s.setUserPassword)
 			})
 
+			r.Route("/rules", func(r chi.Router) {
+				r.Post("/{ruleId}/backtest", s.backtestRule)
+			})
+
 			r.Route("/trust", func(r chi.Router) {
 				r.Post("/events", s.ingestEvent)
+				r.Post("/events/batch", s.ingestEventBatch)
+				r.Get("/events/{eventId}/proof", s.getInclusionProof)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/keys", s.createSourceKey)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/keys", s.listSourceKeys)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/keys/{keyId}/revoke", s.revokeSourceKey)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/schemas", s.createEventSchema)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/schemas", s.listEventSchemas)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/schemas/{eventType}/{schemaVersion}", s.getEventSchema)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/chains/coverage", s.getChainCoverage)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/chains/breaks", s.listChainBreaks)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/chains/verify", s.verifyChains)
 				r.Get("/evidence/{bundleType}/{subjectId}", s.getTrustEvidence)
 			})
 
 			r.Route("/admin", func(r chi.Router) {
 				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit", s.queryAudit)
 				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/{auditId}", s.getAudit)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/export", s.exportAudit)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/chain/verify", s.verifyAuditChain)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/audit/checkpoints", s.createAuditCheckpoint)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/checkpoints", s.listAuditCheckpoints)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/checkpoints/{checkpointId}/export", s.exportAuditCheckpoint)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/retention/policies", s.listRetentionPolicies)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Put("/audit/retention/policies", s.saveRetentionPolicy)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Delete("/audit/retention/policies/{policyId}", s.deleteRetentionPolicy)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/audit/retention/run", s.runRetention)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/legal-holds", s.listLegalHolds)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/audit/legal-holds", s.createLegalHold)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/audit/legal-holds/{holdId}/release", s.releaseLegalHold)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/archives", s.listAuditArchives)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Get("/audit/archives/{archiveId}/verify", s.verifyAuditArchive)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Post("/audit/archives/{archiveId}/restore", s.restoreAuditArchive)
+				r.With(s.requireRole(string(domainUser.RoleAdmin))).Delete("/audit/archives/{archiveId}/restore", s.unrestoreAuditArchive)
 			})
 		})
 	})
@@ -329,6 +375,10 @@ type trustEventIngestRequest struct {
 	SchemaVersion  string          `json:"schema_version"`
 }
 
+type trustEventBatchIngestRequest struct {
+	Events []trustEventIngestRequest `json:"events"`
+}
+
 type stepEvidenceResponse struct {
 	Step           *task.Step                      `json:"step"`
 	ActionEvidence *appAction.ActionEvidence       `json:"action_evidence,omitempty"`
@@ -921,6 +971,74 @@ func (s *Server) sendNotification(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]interface{}{"notification_id": id, "status": "SENT"})
 }
 
+func (s *Server) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var channel *notification.Channel
+	if ch := r.URL.Query().Get("channel"); ch != "" {
+		c := notification.Channel(ch)
+		channel = &c
+	}
+	limit, offset := parseLimitOffset(r, 100, 200)
+	ns, err := s.notificationSvc.ListDeadLetters(contextFromRequest(r), channel, limit, offset)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"notifications": ns})
+}
+
+func (s *Server) getDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "notificationId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid notificationId")
+		return
+	}
+	dl, err := s.notificationSvc.GetDeadLetter(contextFromRequest(r), id)
+	if err != nil {
+		respondDeadLetterError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, dl)
+}
+
+func (s *Server) replayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "notificationId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid notificationId")
+		return
+	}
+	n, err := s.notificationSvc.ReplayDeadLetter(contextFromRequest(r), id)
+	if err != nil {
+		respondDeadLetterError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, n)
+}
+
+func (s *Server) discardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUIDParam(r, "notificationId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid notificationId")
+		return
+	}
+	n, err := s.notificationSvc.DiscardDeadLetter(contextFromRequest(r), id)
+	if err != nil {
+		respondDeadLetterError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, n)
+}
+
+func respondDeadLetterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notification.ErrNotDeadLettered), errors.Is(err, notification.ErrExpired):
+		respondError(w, http.StatusBadRequest, "INVALID_STATE", err.Error())
+	case errors.Is(err, notification.ErrNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+	}
+}
+
 func (s *Server) sseEndpoint(w http.ResponseWriter, r *http.Request) {
 	clientID := r.URL.Query().Get("client_id")
 	if clientID == "" {
@@ -937,8 +1055,13 @@ func (s *Server) sseEndpoint(w http.ResponseWriter, r *http.Request) {
 	if userID != "" {
 		userPtr = &userID
 	}
+	lastEventID, resuming, err := parseLastEventID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
 	client := notification.NewSSEClient(clientID, userPtr, groups)
-	s.sseHub.Register(client)
+	complete := s.connectSSEClient(client, lastEventID, resuming)
 	defer s.sseHub.Unregister(clientID)
 
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -952,6 +1075,9 @@ func (s *Server) sseEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 	// Send an initial comment to flush headers and keep the connection alive.
 	_, _ = w.Write([]byte(": connected\n\n"))
+	if !complete {
+		writeSSEResync(w, lastEventID)
+	}
 	flusher.Flush()
 
 	ctx := r.Context()
@@ -961,10 +1087,7 @@ func (s *Server) sseEndpoint(w http.ResponseWriter, r *http.Request) {
 			if msg == nil {
 				return
 			}
-			payload, _ := json.Marshal(msg)
-			_, _ = w.Write([]byte("data: "))
-			_, _ = w.Write(payload)
-			_, _ = w.Write([]byte("\n\n"))
+			writeSSEMessage(w, msg)
 			flusher.Flush()
 		case <-ctx.Done():
 			return
@@ -972,6 +1095,60 @@ func (s *Server) sseEndpoint(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *Server) sseStats(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, s.sseHub.Stats())
+}
+
+// parseLastEventID reads the event ID a reconnecting client saw last, from
+// the Last-Event-ID header or, for clients that cannot set headers, the
+// last_event_id query parameter. ok is false for a fresh connection.
+func parseLastEventID(r *http.Request) (id uint64, ok bool, err error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err = strconv.ParseUint(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0, false, errors.New("invalid Last-Event-ID")
+	}
+	return id, true, nil
+}
+
+// connectSSEClient registers client, replaying what it missed when it is
+// resuming. It returns false when the replay is incomplete.
+func (s *Server) connectSSEClient(client *notification.SSEClient, lastEventID uint64, resuming bool) bool {
+	if !resuming {
+		s.sseHub.Register(client)
+		return true
+	}
+	_, complete := s.sseHub.Resume(client, lastEventID)
+	return complete
+}
+
+// writeSSEMessage writes msg as one SSE event; its id lets the client
+// resume after it
+func writeSSEMessage(w http.ResponseWriter, msg *notification.SSEMessage) {
+	payload, _ := json.Marshal(msg)
+	if msg.ID != "" {
+		_, _ = w.Write([]byte("id: " + msg.ID + "\n"))
+	}
+	_, _ = w.Write([]byte("data: "))
+	_, _ = w.Write(payload)
+	_, _ = w.Write([]byte("\n\n"))
+}
+
+// writeSSEResync tells a resuming client that messages after lastEventID
+// are no longer buffered and it should reload its state
+func writeSSEResync(w http.ResponseWriter, lastEventID uint64) {
+	data, _ := json.Marshal(map[string]interface{}{"lastEventId": strconv.FormatUint(lastEventID, 10)})
+	msg := notification.NewSSEMessage("resync", data)
+	msg.ID = ""
+	writeSSEMessage(w, msg)
+}
+
 // Trust handlers
 func (s *Server) ingestEvent(w http.ResponseWriter, r *http.Request) {
 	var req trustEventIngestRequest
@@ -979,33 +1156,84 @@ func (s *Server) ingestEvent(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
 		return
 	}
-	if req.SourceID == "" || req.SourceType == "" || req.EventType == "" || req.SchemaVersion == "" || len(req.Payload) == 0 {
-		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "source_type, source_id, event_type, schema_version and payload are required")
+	input, err := req.toInput()
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	entry, err := s.trustSvc.AddToHashChain(contextFromRequest(r), input)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, trustChainEntryResponse(entry))
+}
+
+// ingestEventBatch appends the events of one source to its hash chain as a
+// single extension: either all of them are stored or none is
+func (s *Server) ingestEventBatch(w http.ResponseWriter, r *http.Request) {
+	var req trustEventBatchIngestRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	if len(req.Events) == 0 {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "events are required")
+		return
+	}
+	if len(req.Events) > appTrust.MaxIngestBatch {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", fmt.Sprintf("at most %d events are allowed", appTrust.MaxIngestBatch))
+		return
+	}
+	inputs := make([]appTrust.HashChainInput, 0, len(req.Events))
+	for i, event := range req.Events {
+		input, err := event.toInput()
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAM", fmt.Sprintf("events[%d]: %s", i, err.Error()))
+			return
+		}
+		inputs = append(inputs, input)
+	}
+	entries, err := s.trustSvc.IngestBatch(contextFromRequest(r), inputs)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
 		return
 	}
+	items := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, trustChainEntryResponse(entry))
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"source_id": entries[0].SourceID,
+		"events":    items,
+	})
+}
+
+func (req trustEventIngestRequest) toInput() (appTrust.HashChainInput, error) {
+	if req.SourceID == "" || req.SourceType == "" || req.EventType == "" || req.SchemaVersion == "" || len(req.Payload) == 0 {
+		return appTrust.HashChainInput{}, errors.New("source_type, source_id, event_type, schema_version and payload are required")
+	}
 	var tsDevice *time.Time
 	if req.TsDevice != nil && *req.TsDevice != "" {
-		if t, err := time.Parse(time.RFC3339, *req.TsDevice); err == nil {
-			tsDevice = &t
-		} else {
-			respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid ts_device")
-			return
+		t, err := time.Parse(time.RFC3339, *req.TsDevice)
+		if err != nil {
+			return appTrust.HashChainInput{}, errors.New("invalid ts_device")
 		}
+		tsDevice = &t
 	}
 	var tsGateway *time.Time
 	if req.TsGateway != nil && *req.TsGateway != "" {
-		if t, err := time.Parse(time.RFC3339, *req.TsGateway); err == nil {
-			tsGateway = &t
-		} else {
-			respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid ts_gateway")
-			return
+		t, err := time.Parse(time.RFC3339, *req.TsGateway)
+		if err != nil {
+			return appTrust.HashChainInput{}, errors.New("invalid ts_gateway")
 		}
+		tsGateway = &t
 	}
 	eventID := uuid.Nil
 	if req.EventID != nil {
 		eventID = *req.EventID
 	}
-	entry, err := s.trustSvc.AddToHashChain(contextFromRequest(r), appTrust.HashChainInput{
+	return appTrust.HashChainInput{
 		EventID:        eventID,
 		SourceID:       req.SourceID,
 		ClientRecordID: req.ClientRecordID,
@@ -1016,18 +1244,17 @@ func (s *Server) ingestEvent(w http.ResponseWriter, r *http.Request) {
 		EventType:      req.EventType,
 		Payload:        req.Payload,
 		SchemaVersion:  req.SchemaVersion,
-	})
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
-		return
-	}
-	respondJSON(w, http.StatusOK, map[string]interface{}{
+	}, nil
+}
+
+func trustChainEntryResponse(entry *trust.HashChainEntry) map[string]interface{} {
+	return map[string]interface{}{
 		"event_id":     entry.EventID,
 		"source_id":    entry.SourceID,
 		"sequence_num": entry.SequenceNum,
 		"chain_hash":   entry.ChainHash,
 		"trust_level":  entry.TrustLevel.String(),
-	})
+	}
 }
 
 func (s *Server) getTrustEvidence(w http.ResponseWriter, r *http.Request) {
@@ -1048,27 +1275,7 @@ func (s *Server) getTrustEvidence(w http.ResponseWriter, r *http.Request) {
 
 // Audit handlers
 func (s *Server) queryAudit(w http.ResponseWriter, r *http.Request) {
-	params := appAudit.QueryParams{
-		Limit: 50,
-	}
-	if v := r.URL.Query().Get("entityType"); v != "" {
-		params.EntityType = &v
-	}
-	if v := r.URL.Query().Get("entityId"); v != "" {
-		params.EntityID = &v
-	}
-	if v := r.URL.Query().Get("action"); v != "" {
-		params.Action = &v
-	}
-	if v := r.URL.Query().Get("actor"); v != "" {
-		params.Actor = &v
-	}
-	if v := r.URL.Query().Get("riskLevel"); v != "" {
-		params.RiskLevel = &v
-	}
-	if v := r.URL.Query().Get("traceId"); v != "" {
-		params.TraceID = &v
-	}
+	params := auditQueryParams(r)
 	if v := r.URL.Query().Get("cursor"); v != "" {
 		params.Cursor = &v
 	}
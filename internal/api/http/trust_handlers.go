@@ -0,0 +1,251 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	appTrust "github.com/execution-hub/execution-hub/internal/application/trust"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+type sourceKeyRequest struct {
+	KeyID     string     `json:"key_id"`
+	SourceID  string     `json:"source_id"`
+	Algorithm string     `json:"algorithm"`
+	PublicKey string     `json:"public_key"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+}
+
+type sourceKeyRevokeRequest struct {
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Reason    string     `json:"reason"`
+}
+
+type eventSchemaRequest struct {
+	EventType     string          `json:"event_type"`
+	SchemaVersion string          `json:"schema_version"`
+	Schema        json.RawMessage `json:"schema"`
+	OnInvalid     string          `json:"on_invalid,omitempty"`
+	Compatibility string          `json:"compatibility,omitempty"`
+}
+
+func (s *Server) createSourceKey(w http.ResponseWriter, r *http.Request) {
+	var req sourceKeyRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	publicKey, err := decodePublicKey(req.PublicKey)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	key, err := s.trustSvc.RegisterSourceKey(contextFromRequest(r), appTrust.SourceKeyInput{
+		KeyID:     req.KeyID,
+		SourceID:  req.SourceID,
+		Algorithm: req.Algorithm,
+		PublicKey: publicKey,
+		NotBefore: req.NotBefore,
+		NotAfter:  req.NotAfter,
+	})
+	if err != nil {
+		respondSourceKeyError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, key)
+}
+
+func (s *Server) listSourceKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.trustSvc.ListSourceKeys(contextFromRequest(r), r.URL.Query().Get("source_id"))
+	if err != nil {
+		respondSourceKeyError(w, err)
+		return
+	}
+	if keys == nil {
+		keys = []*trust.SourceKey{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": keys})
+}
+
+func (s *Server) revokeSourceKey(w http.ResponseWriter, r *http.Request) {
+	var req sourceKeyRevokeRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	if req.Reason == "" {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "reason is required")
+		return
+	}
+	var at time.Time
+	if req.RevokedAt != nil {
+		at = *req.RevokedAt
+	}
+	key, err := s.trustSvc.RevokeSourceKey(contextFromRequest(r), chi.URLParam(r, "keyId"), at, req.Reason)
+	if err != nil {
+		respondSourceKeyError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, key)
+}
+
+// createEventSchema registers the payload schema of an event type version
+func (s *Server) createEventSchema(w http.ResponseWriter, r *http.Request) {
+	var req eventSchemaRequest
+	if err := decodeBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+		return
+	}
+	schema, err := s.trustSvc.RegisterSchema(contextFromRequest(r), appTrust.EventSchemaInput{
+		EventType:     req.EventType,
+		SchemaVersion: req.SchemaVersion,
+		Schema:        req.Schema,
+		OnInvalid:     trust.InvalidPayloadAction(strings.ToUpper(req.OnInvalid)),
+		Compatibility: trust.SchemaCompatibility(strings.ToUpper(req.Compatibility)),
+		CreatedBy:     s.actorFromRequest(r),
+	})
+	if err != nil {
+		respondEventSchemaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, schema)
+}
+
+func (s *Server) listEventSchemas(w http.ResponseWriter, r *http.Request) {
+	schemas, err := s.trustSvc.ListSchemas(contextFromRequest(r), r.URL.Query().Get("event_type"))
+	if err != nil {
+		respondEventSchemaError(w, err)
+		return
+	}
+	if schemas == nil {
+		schemas = []*trust.EventSchema{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": schemas})
+}
+
+func (s *Server) getEventSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := s.trustSvc.GetSchema(contextFromRequest(r), chi.URLParam(r, "eventType"), chi.URLParam(r, "schemaVersion"))
+	if err != nil {
+		respondEventSchemaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, schema)
+}
+
+// getChainCoverage reports per source how much of its hash chain the chain
+// verifier has checked
+func (s *Server) getChainCoverage(w http.ResponseWriter, r *http.Request) {
+	coverage, err := s.trustSvc.GetChainCoverage(contextFromRequest(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if coverage == nil {
+		coverage = []trust.ChainCoverage{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": coverage})
+}
+
+func (s *Server) listChainBreaks(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid limit")
+			return
+		}
+		limit = l
+	}
+	breaks, err := s.trustSvc.ListChainBreaks(contextFromRequest(r), r.URL.Query().Get("source_id"), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if breaks == nil {
+		breaks = []*trust.ChainBreak{}
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{"items": breaks})
+}
+
+// verifyChains runs a chain verifier sweep now instead of waiting for the
+// scheduled one
+func (s *Server) verifyChains(w http.ResponseWriter, r *http.Request) {
+	sweep, err := s.trustSvc.VerifyChains(contextFromRequest(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, sweep)
+}
+
+// getInclusionProof returns the proof that an event is part of its signed
+// batch, which auditors verify offline against the signed root
+func (s *Server) getInclusionProof(w http.ResponseWriter, r *http.Request) {
+	eventID, err := uuid.Parse(chi.URLParam(r, "eventId"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", "invalid eventId")
+		return
+	}
+	proof, err := s.trustSvc.GetInclusionProof(contextFromRequest(r), eventID)
+	if err != nil {
+		if errors.Is(err, trust.ErrBatchSignatureNotFound) {
+			respondError(w, http.StatusNotFound, "NOT_FOUND", "event is not part of a signed batch")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, proof)
+}
+
+// decodePublicKey accepts a PEM "PUBLIC KEY" block or base64 of PKIX DER,
+// or of a raw Ed25519 key
+func decodePublicKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, errors.New("public_key is required")
+	}
+	if block, _ := pem.Decode([]byte(value)); block != nil {
+		if block.Type != "PUBLIC KEY" {
+			return nil, errors.New("public_key must be a PUBLIC KEY PEM block")
+		}
+		return block.Bytes, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("public_key must be PEM or base64")
+	}
+	return der, nil
+}
+
+func respondSourceKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, trust.ErrKeyNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, trust.ErrKeyExists), errors.Is(err, trust.ErrKeyRevoked):
+		respondError(w, http.StatusConflict, "CONFLICT", err.Error())
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+	}
+}
+
+func respondEventSchemaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, trust.ErrSchemaNotFound):
+		respondError(w, http.StatusNotFound, "NOT_FOUND", err.Error())
+	case errors.Is(err, trust.ErrSchemaConflict), errors.Is(err, trust.ErrSchemaIncompatible):
+		respondError(w, http.StatusConflict, "CONFLICT", err.Error())
+	default:
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
+	}
+}
@@ -11,9 +11,10 @@ import (
 )
 
 type userCreateRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Role     string `json:"role"`
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	Role     string  `json:"role"`
+	Email    *string `json:"email,omitempty"`
 }
 
 type agentCreateRequest struct {
@@ -28,6 +29,7 @@ type userUpdateRequest struct {
 	Role        *string `json:"role,omitempty"`
 	Status      *string `json:"status,omitempty"`
 	OwnerUserID *string `json:"owner_user_id,omitempty"`
+	Email       *string `json:"email,omitempty"`
 }
 
 type passwordUpdateRequest struct {
@@ -50,6 +52,7 @@ func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
 		Password: req.Password,
 		Role:     role,
 		Type:     domainUser.TypeHuman,
+		Email:    req.Email,
 		Status:   domainUser.StatusActive,
 	})
 	if err != nil {
@@ -240,6 +243,7 @@ func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
 		Role:        role,
 		Status:      status,
 		OwnerUserID: ownerID,
+		Email:       req.Email,
 	})
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error())
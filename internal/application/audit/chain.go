@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+)
+
+const (
+	// MaxChainVerifyRange caps the entries one verification reads
+	MaxChainVerifyRange = 100000
+	chainPageSize       = 1000
+)
+
+var (
+	ErrSigningKeyMissing  = errors.New("audit signing key is not configured")
+	ErrInvalidChainRange  = errors.New("invalid audit chain range")
+	ErrAuditChainEmpty    = errors.New("audit chain is empty")
+	errCheckpointUpToDate = errors.New("latest checkpoint is at the chain head")
+)
+
+// VerifyChain verifies the audit chain from sequence number from to to,
+// which default to the start and the head of the chain. It reports gaps,
+// duplicates, edited entries, broken or reordered links, entries that
+// differ from signed checkpoints and checkpoints beyond the chain head.
+func (s *Service) VerifyChain(ctx context.Context, from, to int64) (*audit.ChainReport, error) {
+	head, err := s.repo.GetChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	var headSeq int64
+	if head != nil {
+		headSeq = head.SequenceNum
+	}
+	if from <= 0 {
+		from = 1
+	}
+	if to <= 0 || to > headSeq {
+		to = headSeq
+	}
+	if from > to && headSeq > 0 {
+		return nil, fmt.Errorf("%w: from %d is after to %d", ErrInvalidChainRange, from, to)
+	}
+	if to-from >= MaxChainVerifyRange {
+		return nil, fmt.Errorf("%w: at most %d entries can be verified at once", ErrInvalidChainRange, MaxChainVerifyRange)
+	}
+
+	var checkpoints []*audit.Checkpoint
+	if len(s.signKey) > 0 {
+		if checkpoints, err = s.repo.ListCheckpoints(ctx); err != nil {
+			return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+		}
+	}
+	var prev *audit.AuditLog
+	if from > 1 {
+		logs, err := s.repo.ListChain(ctx, from-1, from-1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit chain: %w", err)
+		}
+		if len(logs) > 0 {
+			prev = logs[0]
+		}
+	}
+
+	verifier := audit.NewChainVerifier(from, to, prev, checkpoints, s.signKey, s.tsaRoots)
+	for next := from; next <= to; {
+		logs, err := s.repo.ListChain(ctx, next, to, chainPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list audit chain: %w", err)
+		}
+		verifier.Add(logs)
+		if len(logs) < chainPageSize {
+			break
+		}
+		next = logs[len(logs)-1].SequenceNum + 1
+	}
+	report := verifier.Report(headSeq)
+
+	if !report.Valid {
+		s.logger.Warn().
+			Int64("from", report.FromSequence).
+			Int64("to", report.ToSequence).
+			Int("issues", len(report.Issues)).
+			Msg("audit chain verification failed - possible tampering detected")
+	}
+	return report, nil
+}
+
+// CreateCheckpoint signs the current chain head. It returns the latest
+// checkpoint when that is already at the head.
+func (s *Service) CreateCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	checkpoint, err := s.createCheckpoint(ctx)
+	if errors.Is(err, errCheckpointUpToDate) {
+		return checkpoint, nil
+	}
+	return checkpoint, err
+}
+
+func (s *Service) createCheckpoint(ctx context.Context) (*audit.Checkpoint, error) {
+	if len(s.signKey) == 0 {
+		return nil, ErrSigningKeyMissing
+	}
+	head, err := s.repo.GetChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+	if head == nil {
+		return nil, ErrAuditChainEmpty
+	}
+	checkpoints, err := s.repo.ListCheckpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+	}
+	if n := len(checkpoints); n > 0 && checkpoints[n-1].SequenceNum == head.SequenceNum && checkpoints[n-1].EntryHash == head.EntryHash {
+		return checkpoints[n-1], errCheckpointUpToDate
+	}
+
+	checkpoint, err := audit.NewCheckpoint(head, s.signKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit checkpoint: %w", err)
+	}
+	s.timestampCheckpoint(ctx, checkpoint)
+	if err := s.repo.CreateCheckpoint(ctx, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to save audit checkpoint: %w", err)
+	}
+	s.logger.Info().
+		Int64("checkpointId", checkpoint.ID).
+		Int64("sequenceNum", checkpoint.SequenceNum).
+		Str("entryHash", checkpoint.EntryHash).
+		Bool("timestamped", len(checkpoint.TimestampToken) > 0).
+		Msg("audit checkpoint created")
+	return checkpoint, nil
+}
+
+// Timestamper obtains RFC 3161 timestamp tokens over SHA-256 digests from a
+// time-stamping authority. tsp.Client implements it.
+type Timestamper interface {
+	Timestamp(ctx context.Context, digest []byte) (*tsp.Token, error)
+}
+
+// SetTimestamper has every checkpoint timestamped, which attests when it
+// existed independently of the server clock
+func (s *Service) SetTimestamper(timestamper Timestamper) {
+	s.timestamper = timestamper
+}
+
+// SetTimestampRoots sets the CA certificates of the time-stamping
+// authorities trusted when verifying checkpoint timestamp tokens. Without
+// them, VerifyChain reports timestamp tokens as untrusted.
+func (s *Service) SetTimestampRoots(roots *x509.CertPool) {
+	s.tsaRoots = roots
+}
+
+// timestampCheckpoint attaches a timestamp token over the checkpoint's
+// digest. Failures are logged: the checkpoint is still signed.
+func (s *Service) timestampCheckpoint(ctx context.Context, checkpoint *audit.Checkpoint) {
+	if s.timestamper == nil {
+		return
+	}
+	token, err := s.timestamper.Timestamp(ctx, checkpoint.Digest())
+	if err != nil {
+		s.logger.Warn().Err(err).
+			Int64("sequenceNum", checkpoint.SequenceNum).
+			Msg("failed to timestamp audit checkpoint")
+		return
+	}
+	checkpoint.TimestampToken = token.Raw
+}
+
+// ListCheckpoints retrieves all checkpoints in sequence order
+func (s *Service) ListCheckpoints(ctx context.Context) ([]*audit.Checkpoint, error) {
+	checkpoints, err := s.repo.ListCheckpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// ExportCheckpoint returns a checkpoint for off-site anchoring
+func (s *Service) ExportCheckpoint(ctx context.Context, id int64) (*audit.CheckpointExport, error) {
+	checkpoint, err := s.repo.GetCheckpoint(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit checkpoint: %w", err)
+	}
+	return checkpoint.Export(), nil
+}
+
+// RunCheckpoints signs the chain head every interval, when it has moved,
+// until ctx is cancelled.
+func (s *Service) RunCheckpoints(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.createCheckpoint(ctx)
+			if err != nil && !errors.Is(err, errCheckpointUpToDate) && !errors.Is(err, ErrAuditChainEmpty) {
+				s.logger.Error().Err(err).Msg("audit checkpoint failed")
+			}
+		}
+	}
+}
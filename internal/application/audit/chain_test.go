@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
+)
+
+// checkpointRepository keeps an audit chain and its checkpoints in memory
+type checkpointRepository struct {
+	audit.Repository
+	head        *audit.AuditLog
+	logs        []*audit.AuditLog
+	checkpoints []*audit.Checkpoint
+}
+
+func (r *checkpointRepository) GetChainHead(_ context.Context) (*audit.AuditLog, error) {
+	return r.head, nil
+}
+
+func (r *checkpointRepository) ListChain(_ context.Context, fromSeq, toSeq int64, limit int) ([]*audit.AuditLog, error) {
+	var out []*audit.AuditLog
+	for _, log := range r.logs {
+		if log.SequenceNum >= fromSeq && log.SequenceNum <= toSeq && len(out) < limit {
+			out = append(out, log)
+		}
+	}
+	return out, nil
+}
+
+func (r *checkpointRepository) ListCheckpoints(_ context.Context) ([]*audit.Checkpoint, error) {
+	return r.checkpoints, nil
+}
+
+func (r *checkpointRepository) CreateCheckpoint(_ context.Context, checkpoint *audit.Checkpoint) error {
+	checkpoint.ID = int64(len(r.checkpoints) + 1)
+	r.checkpoints = append(r.checkpoints, checkpoint)
+	return nil
+}
+
+func (r *checkpointRepository) advance(t *testing.T) {
+	t.Helper()
+	log, err := audit.NewAuditLog(&audit.AuditEntry{EntityType: audit.EntityTypeRule, EntityID: "rule-a", Action: audit.ActionUpdate, Actor: "alice"})
+	require.NoError(t, err)
+	require.NoError(t, log.LinkTo(r.head))
+	r.head = log
+	r.logs = append(r.logs, log)
+}
+
+func TestService_CreateCheckpointTimestamped(t *testing.T) {
+	ctx := context.Background()
+	tsa, err := tsptest.NewAuthority()
+	require.NoError(t, err)
+	srv := httptest.NewServer(tsa)
+	defer srv.Close()
+
+	repo := &checkpointRepository{}
+	repo.advance(t)
+	svc := NewService(repo, zerolog.Nop(), []byte("checkpoint-key"))
+	svc.SetTimestamper(tsp.NewClient(srv.URL, tsa.Roots(), 0))
+
+	checkpoint, err := svc.CreateCheckpoint(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, checkpoint.TimestampToken)
+	_, err = checkpoint.VerifyTimestamp(tsa.Roots())
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{checkpoint.Digest()}, tsa.Digests())
+
+	t.Run("verifies checkpoint timestamps against the trusted roots", func(t *testing.T) {
+		report, err := svc.VerifyChain(ctx, 0, 0)
+		require.NoError(t, err)
+		assert.True(t, report.Valid, report.Issues)
+		assert.Equal(t, 1, report.TimestampsUntrusted)
+
+		svc.SetTimestampRoots(tsa.Roots())
+		report, err = svc.VerifyChain(ctx, 0, 0)
+		require.NoError(t, err)
+		assert.True(t, report.Valid, report.Issues)
+		assert.Equal(t, 1, report.TimestampsVerified)
+		assert.Zero(t, report.TimestampsUntrusted)
+	})
+
+	t.Run("checkpoints without a token when the TSA fails", func(t *testing.T) {
+		tsa.SetStatus(2)
+		repo.advance(t)
+
+		checkpoint, err := svc.CreateCheckpoint(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, checkpoint.TimestampToken)
+		assert.Len(t, repo.checkpoints, 2)
+	})
+}
@@ -0,0 +1,143 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+const (
+	exportPageSize = 200
+	sinkBatchSize  = 500
+)
+
+// Sink receives the audit log continuously. Write must return only once
+// the logs are durably delivered: the sink's persisted cursor then moves
+// past them, and the logs of a failed Write are written again, so delivery
+// is at least once.
+type Sink interface {
+	// Name identifies the sink's cursor and must be unique and stable
+	Name() string
+	Write(ctx context.Context, logs []*audit.AuditLog) error
+}
+
+// AddSink registers a sink for DeliverSinks. Register sinks before
+// starting RunSinks.
+func (s *Service) AddSink(sink Sink) error {
+	for _, existing := range s.sinks {
+		if existing.Name() == sink.Name() {
+			return fmt.Errorf("audit sink %q already registered", sink.Name())
+		}
+	}
+	s.sinks = append(s.sinks, sink)
+	return nil
+}
+
+// Export writes the logs matching params to w in format, newest first,
+// and returns the number of logs written. params.Cursor and params.Limit
+// are ignored.
+func (s *Service) Export(ctx context.Context, w io.Writer, format audit.ExportFormat, params QueryParams) (int, error) {
+	written, err := s.export(ctx, w, format, params.filter())
+	if err != nil {
+		s.logger.Error().Err(err).
+			Str("format", string(format)).
+			Int("written", written).
+			Msg("audit export failed")
+	}
+	return written, err
+}
+
+func (s *Service) export(ctx context.Context, w io.Writer, format audit.ExportFormat, filter audit.QueryFilter) (int, error) {
+	var cursor *audit.Cursor
+	written := 0
+	for {
+		logs, next, err := s.repo.Query(ctx, filter, cursor, exportPageSize)
+		if err != nil {
+			return written, fmt.Errorf("failed to query audit logs: %w", err)
+		}
+		for _, log := range logs {
+			line, err := audit.Encode(format, log)
+			if err != nil {
+				return written, fmt.Errorf("failed to encode audit log %s: %w", log.AuditID, err)
+			}
+			if _, err := w.Write(line); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if next == nil {
+			return written, nil
+		}
+		cursor = next
+	}
+}
+
+// DeliverSinks writes the logs each registered sink has not received yet.
+// A failing sink does not hold up the others.
+func (s *Service) DeliverSinks(ctx context.Context) error {
+	var errs []error
+	for _, sink := range s.sinks {
+		delivered, err := s.deliverSink(ctx, sink)
+		if delivered > 0 {
+			s.logger.Debug().
+				Str("sink", sink.Name()).
+				Int("delivered", delivered).
+				Msg("audit logs delivered to sink")
+		}
+		if err != nil {
+			s.logger.Error().Err(err).
+				Str("sink", sink.Name()).
+				Msg("audit sink delivery failed")
+			errs = append(errs, fmt.Errorf("sink %s: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Service) deliverSink(ctx context.Context, sink Sink) (int, error) {
+	cursor, err := s.repo.GetSinkCursor(ctx, sink.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get sink cursor: %w", err)
+	}
+	delivered := 0
+	for {
+		logs, err := s.repo.ListAfter(ctx, cursor, sinkBatchSize)
+		if err != nil {
+			return delivered, fmt.Errorf("failed to list audit logs: %w", err)
+		}
+		if len(logs) == 0 {
+			return delivered, nil
+		}
+		if err := sink.Write(ctx, logs); err != nil {
+			return delivered, err
+		}
+		last := logs[len(logs)-1]
+		cursor = &audit.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		if err := s.repo.SaveSinkCursor(ctx, sink.Name(), cursor); err != nil {
+			return delivered, fmt.Errorf("failed to save sink cursor: %w", err)
+		}
+		delivered += len(logs)
+		if len(logs) < sinkBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// RunSinks delivers to the registered sinks every interval until ctx is
+// cancelled.
+func (s *Service) RunSinks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = s.DeliverSinks(ctx)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+const (
+	// MaxArchiveEntries caps the logs one retention run moves to an archive
+	MaxArchiveEntries = 10000
+	retentionPageSize = 1000
+	// RetentionActor is the actor of purges run by RunRetention
+	RetentionActor = "system:retention"
+)
+
+var (
+	ErrArchiveStoreMissing = errors.New("audit archive store is not configured")
+	ErrArchiveInvalid      = errors.New("audit archive failed verification")
+	ErrArchiveRestored     = errors.New("audit archive is already restored")
+	ErrArchiveNotRestored  = errors.New("audit archive is not restored")
+	ErrInvalidRetention    = errors.New("invalid retention request")
+)
+
+// ArchiveStore keeps archive files. Files are written once and never
+// modified.
+type ArchiveStore interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// DirArchiveStore keeps archive files in a local directory
+type DirArchiveStore struct {
+	dir string
+}
+
+// NewDirArchiveStore creates the directory if it does not exist
+func NewDirArchiveStore(dir string) (*DirArchiveStore, error) {
+	if dir == "" {
+		return nil, errors.New("archive directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &DirArchiveStore{dir: dir}, nil
+}
+
+func (s *DirArchiveStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid archive name %q", name)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Put writes the file and syncs it; an existing file is not overwritten
+func (s *DirArchiveStore) Put(_ context.Context, name string, data []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o400)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *DirArchiveStore) Get(_ context.Context, name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (s *DirArchiveStore) Delete(_ context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// SetArchiveStore enables ApplyRetention, which moves expired logs to
+// archive files in store
+func (s *Service) SetArchiveStore(store ArchiveStore) {
+	s.archives = store
+}
+
+// ListRetentionPolicies retrieves all retention policies
+func (s *Service) ListRetentionPolicies(ctx context.Context) ([]*audit.RetentionPolicy, error) {
+	policies, err := s.repo.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SaveRetentionPolicy creates or replaces the policy of the policy's entity
+// type and risk level
+func (s *Service) SaveRetentionPolicy(ctx context.Context, policy *audit.RetentionPolicy, actor string) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+	}
+	if err := s.repo.SaveRetentionPolicy(ctx, policy); err != nil {
+		return fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeConfig,
+		EntityID:   retentionPolicyEntityID(policy),
+		Action:     audit.ActionUpdate,
+		Actor:      actor,
+		NewValues:  policy,
+	})
+	return nil
+}
+
+// DeleteRetentionPolicy deletes a retention policy; logs it matched fall
+// back to less specific policies
+func (s *Service) DeleteRetentionPolicy(ctx context.Context, id int64, actor string) error {
+	if err := s.repo.DeleteRetentionPolicy(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeConfig,
+		EntityID:   fmt.Sprintf("audit_retention:%d", id),
+		Action:     audit.ActionDelete,
+		Actor:      actor,
+	})
+	return nil
+}
+
+func retentionPolicyEntityID(p *audit.RetentionPolicy) string {
+	entityType, riskLevel := "*", "*"
+	if p.EntityType != nil {
+		entityType = string(*p.EntityType)
+	}
+	if p.RiskLevel != nil {
+		riskLevel = string(*p.RiskLevel)
+	}
+	return "audit_retention:" + entityType + "/" + riskLevel
+}
+
+// CreateLegalHold places a legal hold; the logs it covers are not purged
+// until it is released
+func (s *Service) CreateLegalHold(ctx context.Context, hold *audit.LegalHold) error {
+	if err := hold.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRetention, err)
+	}
+	hold.ID = uuid.New()
+	hold.CreatedAt = time.Now().UTC()
+	hold.ReleasedAt = nil
+	hold.ReleasedBy = ""
+	if err := s.repo.CreateLegalHold(ctx, hold); err != nil {
+		return fmt.Errorf("failed to create legal hold: %w", err)
+	}
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeLegalHold,
+		EntityID:   hold.ID.String(),
+		Action:     audit.ActionCreate,
+		Actor:      hold.CreatedBy,
+		NewValues:  hold,
+		Reason:     hold.Reason,
+	})
+	return nil
+}
+
+// ReleaseLegalHold releases an active legal hold
+func (s *Service) ReleaseLegalHold(ctx context.Context, id uuid.UUID, actor string) (*audit.LegalHold, error) {
+	hold, err := s.repo.ReleaseLegalHold(ctx, id, actor, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to release legal hold: %w", err)
+	}
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeLegalHold,
+		EntityID:   hold.ID.String(),
+		Action:     audit.ActionRelease,
+		Actor:      actor,
+		NewValues:  hold,
+	})
+	return hold, nil
+}
+
+// ListLegalHolds retrieves the active legal holds, and the released ones
+// when includeReleased is set
+func (s *Service) ListLegalHolds(ctx context.Context, includeReleased bool) ([]*audit.LegalHold, error) {
+	holds, err := s.repo.ListLegalHolds(ctx, includeReleased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	return holds, nil
+}
+
+// RetentionRun is the result of applying the retention policies
+type RetentionRun struct {
+	// Archive is the archive the expired logs were moved to, nil if none
+	Archive *audit.Archive `json:"archive,omitempty"`
+	// Purged is the number of logs moved out of the database
+	Purged int `json:"purged"`
+	// Held is the number of expired logs kept for legal holds
+	Held int `json:"held"`
+	// More is set when further expired logs remain for the next run
+	More bool `json:"more"`
+}
+
+// ApplyRetention moves up to MaxArchiveEntries logs past the retention of
+// their most specific policy into a signed archive file and deletes them.
+// Logs under an active legal hold, logs restored from an archive and the
+// chain head are kept. The purge is itself audited, in the same transaction
+// as the delete.
+func (s *Service) ApplyRetention(ctx context.Context, actor string) (*RetentionRun, error) {
+	if len(s.signKey) == 0 {
+		return nil, ErrSigningKeyMissing
+	}
+	if s.archives == nil {
+		return nil, ErrArchiveStoreMissing
+	}
+	policies, err := s.repo.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	run := &RetentionRun{}
+	if len(policies) == 0 {
+		return run, nil
+	}
+	holds, err := s.repo.ListLegalHolds(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legal holds: %w", err)
+	}
+	head, err := s.repo.GetChainHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit chain head: %w", err)
+	}
+
+	// No log created after the shortest retention period can be expired
+	now := time.Now().UTC()
+	shortest := policies[0].RetentionDays
+	for _, p := range policies {
+		if p.RetentionDays < shortest {
+			shortest = p.RetentionDays
+		}
+	}
+	before := now.AddDate(0, 0, -shortest)
+
+	var expired []*audit.AuditLog
+	var afterID int64
+	for len(expired) < MaxArchiveEntries {
+		logs, err := s.repo.ListExpiring(ctx, before, afterID, retentionPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list expiring audit logs: %w", err)
+		}
+		for _, log := range logs {
+			policy := audit.ResolveRetention(policies, log)
+			if policy == nil || policy.ExpiresAt(log).After(now) {
+				continue
+			}
+			if head != nil && log.SequenceNum == head.SequenceNum {
+				continue
+			}
+			if audit.HeldBy(holds, log) != nil {
+				run.Held++
+				continue
+			}
+			if len(expired) == MaxArchiveEntries {
+				run.More = true
+				break
+			}
+			expired = append(expired, log)
+		}
+		if len(logs) < retentionPageSize || run.More {
+			break
+		}
+		afterID = logs[len(logs)-1].ID
+	}
+	if len(expired) == 0 {
+		return run, nil
+	}
+
+	archive, err := s.archive(ctx, expired, actor)
+	if err != nil {
+		return nil, err
+	}
+	run.Archive = archive
+	run.Purged = len(expired)
+	s.logger.Info().
+		Int64("archiveId", archive.ID).
+		Str("archive", archive.Name).
+		Int("purged", run.Purged).
+		Int("held", run.Held).
+		Msg("expired audit logs archived")
+	return run, nil
+}
+
+// archive writes the logs to a new archive file, then deletes them and
+// audits the purge
+func (s *Service) archive(ctx context.Context, logs []*audit.AuditLog, actor string) (*audit.Archive, error) {
+	data, err := audit.EncodeArchive(logs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit archive: %w", err)
+	}
+	name := fmt.Sprintf("audit-%s-%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z"), uuid.NewString()[:8])
+	archive, err := audit.NewArchive(name, logs, data, actor, s.signKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit archive: %w", err)
+	}
+	purge, err := s.newAuditLog(&audit.AuditEntry{
+		EntityType: audit.EntityTypeAuditLog,
+		EntityID:   archive.Name,
+		Action:     audit.ActionPurge,
+		Actor:      actor,
+		Reason:     "retention policy",
+		NewValues:  archive,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to audit purge: %w", err)
+	}
+	if err := s.archives.Put(ctx, name, data); err != nil {
+		return nil, fmt.Errorf("failed to store audit archive: %w", err)
+	}
+	if err := s.repo.ArchiveLogs(ctx, archive, logs, purge); err != nil {
+		if delErr := s.archives.Delete(ctx, name); delErr != nil {
+			s.logger.Warn().Err(delErr).Str("archive", name).Msg("failed to delete unused audit archive")
+		}
+		return nil, fmt.Errorf("failed to purge archived audit logs: %w", err)
+	}
+	return archive, nil
+}
+
+// RunRetention applies the retention policies every interval until ctx is
+// cancelled, running again at once while expired logs remain
+func (s *Service) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				run, err := s.ApplyRetention(ctx, RetentionActor)
+				if err != nil {
+					if !errors.Is(err, audit.ErrLegalHoldConflict) {
+						s.logger.Error().Err(err).Msg("audit retention failed")
+					}
+					break
+				}
+				if !run.More {
+					break
+				}
+			}
+		}
+	}
+}
+
+// ListArchives retrieves all archives, newest first
+func (s *Service) ListArchives(ctx context.Context) ([]*audit.Archive, error) {
+	archives, err := s.repo.ListArchives(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit archives: %w", err)
+	}
+	return archives, nil
+}
+
+// VerifyArchive checks an archive file against its signed manifest and
+// its entries against the audit chain
+func (s *Service) VerifyArchive(ctx context.Context, id int64) (*audit.ArchiveReport, error) {
+	report, _, _, err := s.verifyArchive(ctx, id)
+	return report, err
+}
+
+func (s *Service) verifyArchive(ctx context.Context, id int64) (*audit.ArchiveReport, *audit.Archive, []*audit.AuditLog, error) {
+	if len(s.signKey) == 0 {
+		return nil, nil, nil, ErrSigningKeyMissing
+	}
+	if s.archives == nil {
+		return nil, nil, nil, ErrArchiveStoreMissing
+	}
+	archive, err := s.repo.GetArchive(ctx, id)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get audit archive: %w", err)
+	}
+	chain, err := s.repo.ListArchiveChain(ctx, id)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list archived chain entries: %w", err)
+	}
+	data, err := s.archives.Get(ctx, archive.Name)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read audit archive: %w", err)
+	}
+	report, logs := audit.VerifyArchive(archive, data, s.signKey, chain)
+	if !report.Valid {
+		s.logger.Warn().
+			Int64("archiveId", id).
+			Str("archive", archive.Name).
+			Int("issues", len(report.Issues)).
+			Msg("audit archive verification failed - possible tampering detected")
+	}
+	return report, archive, logs, nil
+}
+
+// RestoreArchive verifies an archive and inserts its logs back so they can
+// be queried. Restored logs are not purged again until UnrestoreArchive.
+func (s *Service) RestoreArchive(ctx context.Context, id int64, actor string) (*audit.Archive, error) {
+	report, archive, logs, err := s.verifyArchive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if archive.RestoredAt != nil {
+		return nil, ErrArchiveRestored
+	}
+	if !report.Valid {
+		return nil, ErrArchiveInvalid
+	}
+	if err := s.repo.RestoreArchive(ctx, id, logs); err != nil {
+		return nil, fmt.Errorf("failed to restore audit archive: %w", err)
+	}
+	restoredAt := time.Now().UTC()
+	archive.RestoredAt = &restoredAt
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeAuditLog,
+		EntityID:   archive.Name,
+		Action:     audit.ActionRestore,
+		Actor:      actor,
+	})
+	return archive, nil
+}
+
+// UnrestoreArchive deletes the logs restored from an archive; the archive
+// file keeps them
+func (s *Service) UnrestoreArchive(ctx context.Context, id int64, actor string) error {
+	archive, err := s.repo.GetArchive(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get audit archive: %w", err)
+	}
+	if archive.RestoredAt == nil {
+		return ErrArchiveNotRestored
+	}
+	if err := s.repo.UnrestoreArchive(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete restored audit logs: %w", err)
+	}
+	s.Log(ctx, &audit.AuditEntry{
+		EntityType: audit.EntityTypeAuditLog,
+		EntityID:   archive.Name,
+		Action:     audit.ActionDelete,
+		Actor:      actor,
+		Reason:     "restored archive released",
+	})
+	return nil
+}
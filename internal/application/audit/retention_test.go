@@ -0,0 +1,268 @@
+package audit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+// retentionRepository keeps the audit chain, archives and holds in memory
+type retentionRepository struct {
+	audit.Repository
+	mu       sync.Mutex
+	logs     []*audit.AuditLog
+	policies []*audit.RetentionPolicy
+	holds    []*audit.LegalHold
+	archives []*audit.Archive
+	chain    map[int64]map[int64]string
+	restored map[int64][]*audit.AuditLog
+	// archiveErr fails ArchiveLogs, rolling it back
+	archiveErr error
+}
+
+func newRetentionRepository(t *testing.T, ages ...int) *retentionRepository {
+	t.Helper()
+	repo := &retentionRepository{chain: map[int64]map[int64]string{}, restored: map[int64][]*audit.AuditLog{}}
+	for i, days := range ages {
+		log, err := audit.NewAuditLog(&audit.AuditEntry{EntityType: audit.EntityTypeRule, EntityID: "rule-" + string(rune('a'+i)), Action: audit.ActionUpdate, Actor: "alice"})
+		require.NoError(t, err)
+		log.CreatedAt = log.CreatedAt.AddDate(0, 0, -days)
+		require.NoError(t, repo.Create(context.Background(), log))
+	}
+	return repo
+}
+
+func (r *retentionRepository) Create(_ context.Context, log *audit.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.appendLocked(log)
+}
+
+func (r *retentionRepository) appendLocked(log *audit.AuditLog) error {
+	if err := log.LinkTo(r.head()); err != nil {
+		return err
+	}
+	log.ID = int64(len(r.logs) + 1)
+	for _, archive := range r.archives {
+		log.ID += int64(archive.EntryCount)
+	}
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *retentionRepository) GetChainHead(_ context.Context) (*audit.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.head(), nil
+}
+
+func (r *retentionRepository) head() *audit.AuditLog {
+	var head *audit.AuditLog
+	for _, log := range r.logs {
+		if head == nil || log.SequenceNum > head.SequenceNum {
+			head = log
+		}
+	}
+	return head
+}
+
+func (r *retentionRepository) ListRetentionPolicies(_ context.Context) ([]*audit.RetentionPolicy, error) {
+	return r.policies, nil
+}
+
+func (r *retentionRepository) ListLegalHolds(_ context.Context, _ bool) ([]*audit.LegalHold, error) {
+	return r.holds, nil
+}
+
+func (r *retentionRepository) ListExpiring(_ context.Context, before time.Time, afterID int64, limit int) ([]*audit.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*audit.AuditLog
+	for _, log := range r.logs {
+		if log.CreatedAt.Before(before) && log.ID > afterID && len(out) < limit {
+			out = append(out, log)
+		}
+	}
+	return out, nil
+}
+
+func (r *retentionRepository) ArchiveLogs(_ context.Context, archive *audit.Archive, logs []*audit.AuditLog, purge *audit.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.archiveErr != nil {
+		return r.archiveErr
+	}
+	archive.ID = int64(len(r.archives) + 1)
+	r.archives = append(r.archives, archive)
+	r.chain[archive.ID] = map[int64]string{}
+	purged := map[int64]bool{}
+	for _, log := range logs {
+		purged[log.ID] = true
+		r.chain[archive.ID][log.SequenceNum] = log.EntryHash
+	}
+	var kept []*audit.AuditLog
+	for _, log := range r.logs {
+		if !purged[log.ID] {
+			kept = append(kept, log)
+		}
+	}
+	r.logs = kept
+	return r.appendLocked(purge)
+}
+
+func (r *retentionRepository) GetArchive(_ context.Context, id int64) (*audit.Archive, error) {
+	for _, archive := range r.archives {
+		if archive.ID == id {
+			return archive, nil
+		}
+	}
+	return nil, audit.ErrArchiveNotFound
+}
+
+func (r *retentionRepository) ListArchiveChain(_ context.Context, id int64) (map[int64]string, error) {
+	return r.chain[id], nil
+}
+
+func (r *retentionRepository) RestoreArchive(_ context.Context, id int64, logs []*audit.AuditLog) error {
+	now := time.Now()
+	r.archives[id-1].RestoredAt = &now
+	r.restored[id] = logs
+	return nil
+}
+
+// memoryArchiveStore keeps archive files in memory
+type memoryArchiveStore map[string][]byte
+
+func (s memoryArchiveStore) Put(_ context.Context, name string, data []byte) error {
+	s[name] = data
+	return nil
+}
+
+func (s memoryArchiveStore) Get(_ context.Context, name string) ([]byte, error) {
+	return s[name], nil
+}
+
+func (s memoryArchiveStore) Delete(_ context.Context, name string) error {
+	delete(s, name)
+	return nil
+}
+
+func TestService_ApplyRetention(t *testing.T) {
+	// Rule updates 400, 200 and 100 days old, a held one 300 days old and
+	// the chain head 200 days old
+	repo := newRetentionRepository(t, 400, 200, 100, 300, 200)
+	rule, high := audit.EntityTypeRule, audit.RiskLevelHigh
+	repo.policies = []*audit.RetentionPolicy{
+		{ID: 1, EntityType: &rule, RetentionDays: 150},
+		{ID: 2, RiskLevel: &high, RetentionDays: 30},
+	}
+	held := repo.logs[3]
+	repo.holds = []*audit.LegalHold{{ID: uuid.New(), EntityType: &held.EntityType, EntityID: &held.EntityID, Reason: "litigation"}}
+	store := memoryArchiveStore{}
+	svc := NewService(repo, zerolog.Nop(), []byte("audit-key"))
+
+	_, err := svc.ApplyRetention(context.Background(), "alice")
+	assert.ErrorIs(t, err, ErrArchiveStoreMissing)
+	svc.SetArchiveStore(store)
+
+	run, err := svc.ApplyRetention(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 2, run.Purged)
+	assert.Equal(t, 1, run.Held)
+	require.NotNil(t, run.Archive)
+	assert.Equal(t, int64(1), run.Archive.FirstSequence)
+	assert.Equal(t, int64(2), run.Archive.LastSequence)
+	assert.Contains(t, store, run.Archive.Name)
+
+	repo.mu.Lock()
+	var remaining []int64
+	for _, log := range repo.logs {
+		remaining = append(remaining, log.SequenceNum)
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+	assert.Equal(t, []int64{3, 4, 5, 6}, remaining, "recent and held logs and the head stay, the purge is audited")
+	purge := repo.logs[len(repo.logs)-1]
+	repo.mu.Unlock()
+	assert.Equal(t, audit.ActionPurge, purge.Action)
+	assert.Equal(t, audit.EntityTypeAuditLog, purge.EntityType)
+	assert.Equal(t, run.Archive.Name, purge.EntityID)
+	assert.Equal(t, audit.RiskLevelHigh, purge.RiskLevel)
+
+	report, err := svc.VerifyArchive(context.Background(), run.Archive.ID)
+	require.NoError(t, err)
+	assert.True(t, report.Valid, report.Issues)
+
+	archive, err := svc.RestoreArchive(context.Background(), run.Archive.ID, "alice")
+	require.NoError(t, err)
+	assert.NotNil(t, archive.RestoredAt)
+	require.Len(t, repo.restored[run.Archive.ID], 2)
+	assert.Equal(t, int64(1), repo.restored[run.Archive.ID][0].SequenceNum)
+	_, err = svc.RestoreArchive(context.Background(), run.Archive.ID, "alice")
+	assert.ErrorIs(t, err, ErrArchiveRestored)
+
+	// The purge entry is the head now, so the former head expires too
+	run, err = svc.ApplyRetention(context.Background(), "alice")
+	require.NoError(t, err)
+	require.NotNil(t, run.Archive)
+	assert.Equal(t, 1, run.Purged)
+	assert.Equal(t, int64(5), run.Archive.FirstSequence)
+}
+
+func TestService_RestoreArchiveRejectsTamperedFile(t *testing.T) {
+	repo := newRetentionRepository(t, 400, 1)
+	repo.policies = []*audit.RetentionPolicy{{ID: 1, RetentionDays: 30}}
+	store := memoryArchiveStore{}
+	svc := NewService(repo, zerolog.Nop(), []byte("audit-key"))
+	svc.SetArchiveStore(store)
+
+	run, err := svc.ApplyRetention(context.Background(), RetentionActor)
+	require.NoError(t, err)
+	require.NotNil(t, run.Archive)
+	store[run.Archive.Name] = append([]byte{}, store[run.Archive.Name][:10]...)
+
+	report, err := svc.VerifyArchive(context.Background(), run.Archive.ID)
+	require.NoError(t, err)
+	assert.False(t, report.Valid)
+	_, err = svc.RestoreArchive(context.Background(), run.Archive.ID, "alice")
+	assert.ErrorIs(t, err, ErrArchiveInvalid)
+}
+
+func TestService_ApplyRetentionRollsBackWithPurgeEntry(t *testing.T) {
+	repo := newRetentionRepository(t, 400, 1)
+	repo.policies = []*audit.RetentionPolicy{{ID: 1, RetentionDays: 30}}
+	repo.archiveErr = audit.ErrLegalHoldConflict
+	store := memoryArchiveStore{}
+	svc := NewService(repo, zerolog.Nop(), []byte("audit-key"))
+	svc.SetArchiveStore(store)
+
+	_, err := svc.ApplyRetention(context.Background(), RetentionActor)
+	assert.ErrorIs(t, err, audit.ErrLegalHoldConflict)
+	assert.Len(t, repo.logs, 2, "nothing purged, and no purge entry written")
+	assert.Empty(t, store, "the unused archive file is removed")
+}
+
+func TestDirArchiveStore(t *testing.T) {
+	store, err := NewDirArchiveStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "audit-1.jsonl.gz", []byte("data")))
+	assert.Error(t, store.Put(ctx, "audit-1.jsonl.gz", []byte("other")), "archives are written once")
+	data, err := store.Get(ctx, "audit-1.jsonl.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	for _, name := range []string{"../escape", "dir/file", ".hidden", ""} {
+		assert.Error(t, store.Put(ctx, name, []byte("x")), name)
+	}
+	require.NoError(t, store.Delete(ctx, "audit-1.jsonl.gz"))
+}
@@ -2,6 +2,7 @@ package audit
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -18,6 +19,10 @@ type Service struct {
 	repo   audit.Repository
 	logger zerolog.Logger
 	signKey []byte
+	sinks  []Sink
+	archives ArchiveStore
+	timestamper Timestamper
+	tsaRoots *x509.CertPool
 }
 
 // NewService creates a new audit service
@@ -44,17 +49,9 @@ func (s *Service) Log(ctx context.Context, entry *audit.AuditEntry) {
 
 // LogSync creates a new audit log entry synchronously
 func (s *Service) LogSync(ctx context.Context, entry *audit.AuditEntry) error {
-	auditLog, err := audit.NewAuditLog(entry)
+	auditLog, err := s.newAuditLog(entry)
 	if err != nil {
-		return fmt.Errorf("failed to create audit log: %w", err)
-	}
-
-	if len(s.signKey) > 0 {
-		sig, err := audit.SignAuditLog(auditLog, s.signKey)
-		if err != nil {
-			return fmt.Errorf("failed to sign audit log: %w", err)
-		}
-		auditLog.Signature = sig
+		return err
 	}
 
 	if err := s.repo.Create(ctx, auditLog); err != nil {
@@ -85,6 +82,23 @@ func (s *Service) LogSync(ctx context.Context, entry *audit.AuditEntry) error {
 	return nil
 }
 
+// newAuditLog creates an audit log from entry, signed when a key is set
+func (s *Service) newAuditLog(entry *audit.AuditEntry) (*audit.AuditLog, error) {
+	auditLog, err := audit.NewAuditLog(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	if len(s.signKey) > 0 {
+		sig, err := audit.SignAuditLog(auditLog, s.signKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign audit log: %w", err)
+		}
+		auditLog.Signature = sig
+	}
+	return auditLog, nil
+}
+
 // QueryParams represents query parameters for audit logs
 type QueryParams struct {
 	EntityType *string
@@ -100,6 +114,31 @@ type QueryParams struct {
 	Limit      int
 }
 
+// filter builds the repository filter of the parameters
+func (params QueryParams) filter() audit.QueryFilter {
+	filter := audit.QueryFilter{
+		Tags:      params.Tags,
+		EntityID:  params.EntityID,
+		Actor:     params.Actor,
+		StartTime: params.StartTime,
+		EndTime:   params.EndTime,
+		TraceID:   params.TraceID,
+	}
+	if params.EntityType != nil {
+		et := audit.EntityType(*params.EntityType)
+		filter.EntityType = &et
+	}
+	if params.Action != nil {
+		a := audit.Action(*params.Action)
+		filter.Action = &a
+	}
+	if params.RiskLevel != nil {
+		rl := audit.RiskLevel(*params.RiskLevel)
+		filter.RiskLevel = &rl
+	}
+	return filter
+}
+
 // QueryResult represents the result of an audit log query
 type QueryResult struct {
 	Logs       []*audit.AuditLog `json:"logs"`
@@ -134,38 +173,7 @@ func (s *Service) Query(ctx context.Context, params QueryParams, traceID string)
 		cursor = c
 	}
 
-	// Build filter
-	filter := audit.QueryFilter{
-		Tags:    params.Tags,
-	}
-
-	if params.EntityType != nil {
-		et := audit.EntityType(*params.EntityType)
-		filter.EntityType = &et
-	}
-	if params.EntityID != nil {
-		filter.EntityID = params.EntityID
-	}
-	if params.Action != nil {
-		a := audit.Action(*params.Action)
-		filter.Action = &a
-	}
-	if params.Actor != nil {
-		filter.Actor = params.Actor
-	}
-	if params.RiskLevel != nil {
-		rl := audit.RiskLevel(*params.RiskLevel)
-		filter.RiskLevel = &rl
-	}
-	if params.StartTime != nil {
-		filter.StartTime = params.StartTime
-	}
-	if params.EndTime != nil {
-		filter.EndTime = params.EndTime
-	}
-	if params.TraceID != nil {
-		filter.TraceID = params.TraceID
-	}
+	filter := params.filter()
 
 	// Execute query
 	logs, nextCursor, err := s.repo.Query(ctx, filter, cursor, params.Limit)
@@ -0,0 +1,417 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+const (
+	defaultSinkTimeout = 30 * time.Second
+	sinkUserAgent      = "Execution-Hub-Audit/1.0"
+)
+
+// FileSinkConfig configures a FileSink
+type FileSinkConfig struct {
+	Name   string // Default "file"
+	Path   string
+	Format audit.ExportFormat // Default JSONL
+	// MaxBytes rotates the file before a write would grow it past the
+	// limit; 0 never rotates
+	MaxBytes int64
+	// MaxBackups is the number of rotated files kept; 0 keeps all
+	MaxBackups int
+}
+
+// FileSink appends records to a local file, synced after every write, and
+// renames it to Path.<timestamp> when it reaches MaxBytes
+type FileSink struct {
+	cfg  FileSinkConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens the file sink's file for appending
+func NewFileSink(cfg FileSinkConfig) (*FileSink, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("file sink path is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "file"
+	}
+	if cfg.Format == "" {
+		cfg.Format = audit.ExportFormatJSONL
+	}
+	if _, err := audit.ParseExportFormat(string(cfg.Format)); err != nil {
+		return nil, err
+	}
+	s := &FileSink{cfg: cfg}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) Name() string { return s.cfg.Name }
+
+func (s *FileSink) Write(_ context.Context, logs []*audit.AuditLog) error {
+	data, err := encodeRecords(s.cfg.Format, logs)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.cfg.MaxBytes > 0 && s.size > 0 && s.size+int64(len(data)) > s.cfg.MaxBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit file: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.cfg.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat audit file: %w", err)
+	}
+	s.file, s.size = f, info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit file: %w", err)
+	}
+	s.file = nil
+	rotated := s.cfg.Path + "." + time.Now().UTC().Format("20060102T150405.000000000Z")
+	if err := os.Rename(s.cfg.Path, rotated); err != nil {
+		return fmt.Errorf("failed to rotate audit file: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.pruneBackups()
+}
+
+func (s *FileSink) pruneBackups() error {
+	if s.cfg.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := filepath.Glob(s.cfg.Path + ".*")
+	if err != nil {
+		return err
+	}
+	// Timestamp suffixes sort chronologically
+	sort.Strings(backups)
+	for len(backups) > s.cfg.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return fmt.Errorf("failed to remove rotated audit file: %w", err)
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Syslog transports
+const (
+	SyslogUDP = "udp"
+	SyslogTCP = "tcp"
+	SyslogTLS = "tls"
+)
+
+// syslogFacilityLogAudit is RFC 5424 facility 13, log audit
+const syslogFacilityLogAudit = 13
+
+// syslogSDID is the structured data ID of the audit fields, under the
+// example enterprise number of RFC 5612
+const syslogSDID = "audit@32473"
+
+// SyslogSinkConfig configures a SyslogSink
+type SyslogSinkConfig struct {
+	Name      string // Default "syslog"
+	Network   string // SyslogUDP (default), SyslogTCP or SyslogTLS
+	Address   string // host:port
+	TLSConfig *tls.Config
+	Format    audit.ExportFormat // Message format, default CEF
+	Facility  *int               // Default 13, log audit
+	Hostname  string             // Default os.Hostname
+	AppName   string             // Default "execution-hub"
+	Timeout   time.Duration
+}
+
+// SyslogSink sends RFC 5424 messages, octet-counted over TCP and TLS (RFC
+// 6587, RFC 5425) and one per datagram over UDP. UDP gives no delivery
+// guarantee: a write succeeds once the datagrams are sent.
+type SyslogSink struct {
+	cfg      SyslogSinkConfig
+	facility int
+	mu       sync.Mutex
+	conn     net.Conn
+}
+
+// NewSyslogSink creates a syslog sink; it connects on the first write
+func NewSyslogSink(cfg SyslogSinkConfig) (*SyslogSink, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("syslog sink address is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "syslog"
+	}
+	switch cfg.Network {
+	case "":
+		cfg.Network = SyslogUDP
+	case SyslogUDP, SyslogTCP, SyslogTLS:
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q", cfg.Network)
+	}
+	if cfg.Format == "" {
+		cfg.Format = audit.ExportFormatCEF
+	}
+	if _, err := audit.ParseExportFormat(string(cfg.Format)); err != nil {
+		return nil, err
+	}
+	facility := syslogFacilityLogAudit
+	if cfg.Facility != nil {
+		facility = *cfg.Facility
+	}
+	if facility < 0 || facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", facility)
+	}
+	if cfg.Hostname == "" {
+		cfg.Hostname, _ = os.Hostname()
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "execution-hub"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSinkTimeout
+	}
+	return &SyslogSink{cfg: cfg, facility: facility}, nil
+}
+
+func (s *SyslogSink) Name() string { return s.cfg.Name }
+
+func (s *SyslogSink) Write(ctx context.Context, logs []*audit.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return err
+		}
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.cfg.Timeout))
+	for _, log := range logs {
+		msg, err := s.format(log)
+		if err != nil {
+			return err
+		}
+		if s.cfg.Network != SyslogUDP {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := s.conn.Write(msg); err != nil {
+			_ = s.conn.Close()
+			s.conn = nil
+			return fmt.Errorf("failed to send syslog message: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) dial(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+	var conn net.Conn
+	var err error
+	if s.cfg.Network == SyslogTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.cfg.TLSConfig}).DialContext(ctx, "tcp", s.cfg.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, s.cfg.Network, s.cfg.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	s.conn = conn
+	return nil
+}
+
+// format returns the RFC 5424 message of the log
+func (s *SyslogSink) format(log *audit.AuditLog) ([]byte, error) {
+	body, err := audit.Encode(s.cfg.Format, log)
+	if err != nil {
+		return nil, err
+	}
+	sd := `[` + syslogSDID + ` auditId="` + log.AuditID.String() + `"`
+	if log.SequenceNum > 0 {
+		sd += ` sequenceNum="` + strconv.FormatInt(log.SequenceNum, 10) + `"`
+	}
+	sd += ` riskLevel="` + sdEscaper.Replace(string(log.RiskLevel)) + `"]`
+	header := fmt.Sprintf("<%d>1 %s %s %s - AUDIT %s ",
+		s.facility*8+syslogSeverity(log.RiskLevel),
+		log.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderField(s.cfg.Hostname, 255),
+		syslogHeaderField(s.cfg.AppName, 48),
+		sd)
+	return append([]byte(header), bytes.TrimRight(body, "\n")...), nil
+}
+
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogSeverity maps the risk level to an RFC 5424 severity
+func syslogSeverity(level audit.RiskLevel) int {
+	switch level {
+	case audit.RiskLevelCritical:
+		return 2
+	case audit.RiskLevelHigh:
+		return 4
+	case audit.RiskLevelMedium:
+		return 5
+	}
+	return 6
+}
+
+// syslogHeaderField keeps the printable ASCII of value, at most limit
+// characters, or "-" when empty
+func syslogHeaderField(value string, limit int) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r > 32 && r < 127 && b.Len() < limit {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "-"
+	}
+	return b.String()
+}
+
+// HTTPSinkConfig configures an HTTPSink
+type HTTPSinkConfig struct {
+	Name    string // Default "http"
+	URL     string
+	Format  audit.ExportFormat // Default JSONL
+	Headers map[string]string  // E.g. an Authorization header
+	Timeout time.Duration
+}
+
+// HTTPSink posts each batch of records as one request body; any non-2xx
+// response fails the batch
+type HTTPSink struct {
+	cfg    HTTPSinkConfig
+	client *http.Client
+}
+
+// NewHTTPSink creates an HTTP batch sink
+func NewHTTPSink(cfg HTTPSinkConfig) (*HTTPSink, error) {
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, errors.New("http sink url must be an absolute http or https URL")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "http"
+	}
+	if cfg.Format == "" {
+		cfg.Format = audit.ExportFormatJSONL
+	}
+	if _, err := audit.ParseExportFormat(string(cfg.Format)); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSinkTimeout
+	}
+	return &HTTPSink{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+func (s *HTTPSink) Name() string { return s.cfg.Name }
+
+func (s *HTTPSink) Write(ctx context.Context, logs []*audit.AuditLog) error {
+	body, err := encodeRecords(s.cfg.Format, logs)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", s.cfg.Format.ContentType())
+	req.Header.Set("User-Agent", sinkUserAgent)
+	for key, value := range s.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http sink failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+func encodeRecords(format audit.ExportFormat, logs []*audit.AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, log := range logs {
+		line, err := audit.Encode(format, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit log %s: %w", log.AuditID, err)
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes(), nil
+}
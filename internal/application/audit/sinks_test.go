@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/audit"
+)
+
+// sinkRepository serves the audit log and sink cursors from memory
+type sinkRepository struct {
+	audit.Repository
+	logs    []*audit.AuditLog
+	cursors map[string]*audit.Cursor
+}
+
+func newSinkRepository(n int) *sinkRepository {
+	repo := &sinkRepository{cursors: map[string]*audit.Cursor{}}
+	for i := 1; i <= n; i++ {
+		repo.logs = append(repo.logs, &audit.AuditLog{
+			ID:         int64(i),
+			AuditID:    uuid.New(),
+			EntityType: audit.EntityTypeRule,
+			EntityID:   "rule-" + strconv.Itoa(i),
+			Action:     audit.ActionUpdate,
+			Actor:      "alice",
+			RiskLevel:  audit.RiskLevelMedium,
+			CreatedAt:  time.Date(2026, 5, 1, 12, 0, i, 0, time.UTC),
+		})
+	}
+	return repo
+}
+
+func (r *sinkRepository) ListAfter(_ context.Context, cursor *audit.Cursor, limit int) ([]*audit.AuditLog, error) {
+	var out []*audit.AuditLog
+	for _, log := range r.logs {
+		if (cursor == nil || log.ID > cursor.ID) && len(out) < limit {
+			out = append(out, log)
+		}
+	}
+	return out, nil
+}
+
+func (r *sinkRepository) GetSinkCursor(_ context.Context, sink string) (*audit.Cursor, error) {
+	return r.cursors[sink], nil
+}
+
+func (r *sinkRepository) SaveSinkCursor(_ context.Context, sink string, cursor *audit.Cursor) error {
+	r.cursors[sink] = cursor
+	return nil
+}
+
+// recordingSink fails while failing is set and records delivered logs
+type recordingSink struct {
+	name      string
+	failing   bool
+	delivered []int64
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Write(_ context.Context, logs []*audit.AuditLog) error {
+	if s.failing {
+		return errors.New("sink unavailable")
+	}
+	for _, log := range logs {
+		s.delivered = append(s.delivered, log.ID)
+	}
+	return nil
+}
+
+func TestService_DeliverSinks(t *testing.T) {
+	repo := newSinkRepository(3)
+	svc := NewService(repo, zerolog.Nop(), nil)
+	healthy := &recordingSink{name: "healthy"}
+	down := &recordingSink{name: "down", failing: true}
+	require.NoError(t, svc.AddSink(healthy))
+	require.NoError(t, svc.AddSink(down))
+	assert.Error(t, svc.AddSink(&recordingSink{name: "healthy"}))
+
+	err := svc.DeliverSinks(context.Background())
+	assert.ErrorContains(t, err, "sink down")
+	assert.Equal(t, []int64{1, 2, 3}, healthy.delivered)
+	assert.Equal(t, int64(3), repo.cursors["healthy"].ID)
+	assert.Nil(t, repo.cursors["down"], "cursor must not move past undelivered logs")
+
+	repo.logs = append(repo.logs, newSinkRepository(4).logs[3])
+	down.failing = false
+	require.NoError(t, svc.DeliverSinks(context.Background()))
+	assert.Equal(t, []int64{1, 2, 3, 4}, healthy.delivered)
+	assert.Equal(t, []int64{1, 2, 3, 4}, down.delivered)
+	assert.Equal(t, int64(4), repo.cursors["down"].ID)
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	sink, err := NewFileSink(FileSinkConfig{Path: path, MaxBytes: 600, MaxBackups: 1})
+	require.NoError(t, err)
+	defer sink.Close()
+	logs := newSinkRepository(6).logs
+
+	require.NoError(t, sink.Write(context.Background(), logs[:2]))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "\n"))
+
+	for i := 2; i < 6; i++ {
+		require.NoError(t, sink.Write(context.Background(), logs[i:i+1]))
+	}
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1, "older rotated files are pruned")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, info.Size(), int64(600))
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, err = NewFileSink(FileSinkConfig{Path: path, Format: "XML"})
+	assert.Error(t, err)
+}
+
+func TestSyslogSink_TCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	received := make(chan string, 2)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			length, err := r.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			msg := make([]byte, n)
+			if _, err := io.ReadFull(r, msg); err != nil {
+				return
+			}
+			received <- string(msg)
+		}
+	}()
+
+	sink, err := NewSyslogSink(SyslogSinkConfig{Network: SyslogTCP, Address: ln.Addr().String(), Hostname: "hub 1", Timeout: time.Second})
+	require.NoError(t, err)
+	defer sink.Close()
+	logs := newSinkRepository(2).logs
+	logs[0].RiskLevel = audit.RiskLevelCritical
+	logs[0].SequenceNum = 9
+	require.NoError(t, sink.Write(context.Background(), logs))
+
+	first := <-received
+	// facility 13 (log audit) * 8 + severity 2 (critical)
+	assert.True(t, strings.HasPrefix(first, "<106>1 2026-05-01T12:00:01.000000Z hub1 execution-hub - AUDIT "), first)
+	assert.Contains(t, first, `[audit@32473 auditId="`+logs[0].AuditID.String()+`" sequenceNum="9" riskLevel="CRITICAL"] CEF:0|`)
+	second := <-received
+	assert.True(t, strings.HasPrefix(second, "<109>1 "), second)
+}
+
+func TestSyslogSink_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sink, err := NewSyslogSink(SyslogSinkConfig{Address: conn.LocalAddr().String(), Format: audit.ExportFormatJSONL})
+	require.NoError(t, err)
+	defer sink.Close()
+	require.NoError(t, sink.Write(context.Background(), newSinkRepository(1).logs))
+
+	buf := make([]byte, 4096)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	msg := string(buf[:n])
+	assert.True(t, strings.HasPrefix(msg, "<109>1 "), msg)
+	assert.True(t, strings.HasSuffix(msg, "}"), "one message per datagram without trailing newline")
+
+	_, err = NewSyslogSink(SyslogSinkConfig{Address: "localhost:514", Network: "sctp"})
+	assert.Error(t, err)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var bodies []string
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink, err := NewHTTPSink(HTTPSinkConfig{URL: srv.URL, Format: audit.ExportFormatOCSF, Headers: map[string]string{"Authorization": "Bearer token"}})
+	require.NoError(t, err)
+	logs := newSinkRepository(3).logs
+	require.NoError(t, sink.Write(context.Background(), logs))
+	require.Len(t, bodies, 1)
+	assert.Equal(t, 3, strings.Count(bodies[0], "\n"))
+	assert.Contains(t, bodies[0], `"class_uid":6003`)
+
+	status = http.StatusServiceUnavailable
+	assert.ErrorContains(t, sink.Write(context.Background(), logs), "status 503")
+
+	_, err = NewHTTPSink(HTTPSinkConfig{URL: "ftp://siem"})
+	assert.Error(t, err)
+}
@@ -13,6 +13,7 @@ import (
 
 	appAudit "github.com/execution-hub/execution-hub/internal/application/audit"
 	"github.com/execution-hub/execution-hub/internal/domain/audit"
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
 	"github.com/execution-hub/execution-hub/internal/domain/collab"
 	"github.com/execution-hub/execution-hub/internal/domain/notification"
 	"github.com/execution-hub/execution-hub/internal/domain/task"
@@ -133,6 +134,7 @@ func (s *Service) CreateSession(ctx context.Context, in CreateSessionInput) (*co
 			Name:                 ws.Name,
 			Status:               collab.StepStatusOpen,
 			RequiredCapabilities: extractRequiredCapabilities(ws),
+			MinTrustScore:        extractMinTrustScore(ws),
 			DependsOn:            deps[ws.StepKey],
 			LeaseTTLSeconds:      defaultLeaseTTL(ws.TimeoutSeconds),
 			ConsensusPolicy:      extractConsensusPolicy(ws),
@@ -183,6 +185,9 @@ func (s *Service) JoinSession(ctx context.Context, in JoinInput) (*collab.Partic
 	if in.Type != collab.ParticipantTypeHuman && in.Type != collab.ParticipantTypeAgent {
 		return nil, fmt.Errorf("type must be HUMAN or AGENT")
 	}
+	if err := capability.ValidateGrants(in.Capabilities); err != nil {
+		return nil, err
+	}
 
 	session, err := s.repo.GetSessionByID(ctx, in.SessionID)
 	if err != nil {
@@ -285,9 +290,6 @@ func (s *Service) ListOpenSteps(ctx context.Context, filter OpenStepFilter) ([]*
 		if !depsResolved(st, stepByKey) {
 			continue
 		}
-		if participant != nil && !hasCapabilities(participant.Capabilities, st.RequiredCapabilities) {
-			continue
-		}
 		active, err := s.repo.GetActiveClaimByStep(ctx, st.StepID, now)
 		if err != nil {
 			return nil, err
@@ -297,6 +299,9 @@ func (s *Service) ListOpenSteps(ctx context.Context, filter OpenStepFilter) ([]*
 		}
 		out = append(out, st)
 	}
+	if participant != nil {
+		out = rankSteps(out, participant)
+	}
 
 	return pageSteps(out, filter.Limit, filter.Offset), nil
 }
@@ -334,9 +339,12 @@ func (s *Service) ClaimStep(ctx context.Context, in ClaimInput) (*collab.Claim,
 	if !depsResolved(step, stepByKey) {
 		return nil, fmt.Errorf("step dependencies are not resolved")
 	}
-	if !hasCapabilities(participant.Capabilities, step.RequiredCapabilities) {
+	if !capability.Satisfies(participant.Capabilities, step.RequiredCapabilities) {
 		return nil, fmt.Errorf("participant capabilities do not satisfy step requirements")
 	}
+	if participant.TrustScore < step.MinTrustScore {
+		return nil, fmt.Errorf("participant trust score below step minimum")
+	}
 
 	now := time.Now().UTC()
 	existing, err := s.repo.GetActiveClaimByStep(ctx, step.StepID, now)
@@ -1070,6 +1078,16 @@ func extractRequiredCapabilities(step workflow.Step) []string {
 	return uniqNonEmpty(out)
 }
 
+func extractMinTrustScore(step workflow.Step) int {
+	var cfg struct {
+		MinTrustScore int `json:"min_trust_score"`
+	}
+	if len(step.ActionConfig) > 0 && json.Unmarshal(step.ActionConfig, &cfg) == nil && cfg.MinTrustScore > 0 {
+		return cfg.MinTrustScore
+	}
+	return 0
+}
+
 func extractConsensusPolicy(step workflow.Step) json.RawMessage {
 	var cfg struct {
 		ConsensusPolicy json.RawMessage `json:"consensus_policy"`
@@ -1091,6 +1109,32 @@ func extractConsensusPolicy(step workflow.Step) json.RawMessage {
 	return nil
 }
 
+// rankSteps keeps the steps the participant may claim, best-fit first:
+// higher capability match score, then the higher trust requirement the
+// participant meets, then step order.
+func rankSteps(steps []*collab.Step, participant *collab.Participant) []*collab.Step {
+	out := make([]*collab.Step, 0, len(steps))
+	scores := make(map[uuid.UUID]int, len(steps))
+	for _, st := range steps {
+		if participant.TrustScore < st.MinTrustScore {
+			continue
+		}
+		match := capability.Match(participant.Capabilities, st.RequiredCapabilities)
+		if !match.Satisfied {
+			continue
+		}
+		scores[st.StepID] = match.Score
+		out = append(out, st)
+	}
+	slices.SortStableFunc(out, func(a, b *collab.Step) int {
+		if scores[a.StepID] != scores[b.StepID] {
+			return scores[b.StepID] - scores[a.StepID]
+		}
+		return b.MinTrustScore - a.MinTrustScore
+	})
+	return out
+}
+
 func depsResolved(step *collab.Step, byKey map[string]*collab.Step) bool {
 	if len(step.DependsOn) == 0 {
 		return true
@@ -1104,21 +1148,6 @@ func depsResolved(step *collab.Step, byKey map[string]*collab.Step) bool {
 	return true
 }
 
-func hasCapabilities(actual, required []string) bool {
-	if len(required) == 0 {
-		return true
-	}
-	if len(actual) == 0 {
-		return false
-	}
-	for _, req := range required {
-		if !slices.Contains(actual, req) {
-			return false
-		}
-	}
-	return true
-}
-
 func pageSteps(in []*collab.Step, limit, offset int) []*collab.Step {
 	if offset < 0 {
 		offset = 0
@@ -2,8 +2,11 @@ package collab
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/google/uuid"
+
 	"github.com/execution-hub/execution-hub/internal/domain/collab"
 )
 
@@ -52,3 +55,21 @@ func TestEvaluateDecisionPendingWithQuorum(t *testing.T) {
 		t.Fatalf("expected PENDING, got %s", status)
 	}
 }
+
+func TestRankStepsByMatchAndTrust(t *testing.T) {
+	generic := &collab.Step{StepID: uuid.New(), StepKey: "generic"}
+	exact := &collab.Step{StepID: uuid.New(), StepKey: "exact", RequiredCapabilities: []string{"code.review"}}
+	trusted := &collab.Step{StepID: uuid.New(), StepKey: "trusted", RequiredCapabilities: []string{"code.review"}, MinTrustScore: 50}
+	gated := &collab.Step{StepID: uuid.New(), StepKey: "gated", MinTrustScore: 90}
+	missing := &collab.Step{StepID: uuid.New(), StepKey: "missing", RequiredCapabilities: []string{"gpu"}}
+	participant := &collab.Participant{Capabilities: []string{"code.review"}, TrustScore: 60}
+
+	ranked := rankSteps([]*collab.Step{generic, exact, trusted, gated, missing}, participant)
+	var keys []string
+	for _, st := range ranked {
+		keys = append(keys, st.StepKey)
+	}
+	if strings.Join(keys, ",") != "trusted,exact,generic" {
+		t.Fatalf("unexpected ranking: %v", keys)
+	}
+}
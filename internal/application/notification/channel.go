@@ -0,0 +1,119 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// ChannelSender delivers notifications on one channel. Senders return
+// *DeliveryError values so permanent failures are not retried.
+type ChannelSender interface {
+	// ValidateConfig checks the channel-specific fields of an action config.
+	// It runs when the workflow or rule carrying the config is created.
+	ValidateConfig(actionConfig json.RawMessage) error
+	Send(ctx context.Context, n *notification.Notification) error
+}
+
+// Registry maps channel names to their senders
+type Registry struct {
+	mu      sync.RWMutex
+	senders map[notification.Channel]ChannelSender
+}
+
+// NewRegistry creates an empty channel registry
+func NewRegistry() *Registry {
+	return &Registry{senders: make(map[notification.Channel]ChannelSender)}
+}
+
+// Register adds sender for channel. A channel can only be registered once.
+func (r *Registry) Register(channel notification.Channel, sender ChannelSender) error {
+	if strings.TrimSpace(string(channel)) == "" {
+		return fmt.Errorf("channel name is required")
+	}
+	if sender == nil {
+		return fmt.Errorf("sender for channel %s is nil", channel)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.senders[channel]; exists {
+		return fmt.Errorf("channel already registered: %s", channel)
+	}
+	r.senders[channel] = sender
+	return nil
+}
+
+// Get returns the sender for channel
+func (r *Registry) Get(channel notification.Channel) (ChannelSender, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sender, ok := r.senders[channel]
+	return sender, ok
+}
+
+// Channels returns the registered channel names, sorted
+func (r *Registry) Channels() []notification.Channel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channels := make([]notification.Channel, 0, len(r.senders))
+	for channel := range r.senders {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
+
+// ValidateActionConfig checks a notification action config: its channel
+// (SSE when omitted) must be registered and accept the config.
+func (r *Registry) ValidateActionConfig(actionConfig json.RawMessage) error {
+	var cfg struct {
+		Channel string `json:"channel"`
+	}
+	if err := decodeConfig(actionConfig, &cfg); err != nil {
+		return err
+	}
+	channel := notification.ChannelSSE
+	if cfg.Channel != "" {
+		channel = notification.Channel(cfg.Channel)
+	}
+	sender, ok := r.Get(channel)
+	if !ok {
+		names := make([]string, 0)
+		for _, registered := range r.Channels() {
+			names = append(names, string(registered))
+		}
+		return fmt.Errorf("unsupported channel %q: registered channels are %s", channel, strings.Join(names, ", "))
+	}
+	if err := sender.ValidateConfig(actionConfig); err != nil {
+		return fmt.Errorf("invalid %s channel config: %w", channel, err)
+	}
+	return nil
+}
+
+// IsNotificationAction reports whether actions of type t are delivered as
+// notifications
+func IsNotificationAction(t domainAction.Type) bool {
+	switch t {
+	case domainAction.TypeNotify, domainAction.TypeWebhook, domainAction.TypeEscalate:
+		return true
+	default:
+		return false
+	}
+}
+
+// decodeConfig unmarshals an action config, treating an empty one as {}.
+func decodeConfig(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid action config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package notification
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	actionMocks "github.com/execution-hub/execution-hub/internal/domain/action/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	notificationMocks "github.com/execution-hub/execution-hub/internal/domain/notification/mocks"
+	ruleMocks "github.com/execution-hub/execution-hub/internal/domain/rule/mocks"
+	"github.com/execution-hub/execution-hub/internal/infrastructure/keystore"
+	"github.com/execution-hub/execution-hub/pkg/webhooksig"
+)
+
+// capturingServer records request bodies and answers with status
+type capturingServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	status int
+	bodies []map[string]interface{}
+}
+
+func newCapturingServer(t *testing.T) *capturingServer {
+	s := &capturingServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]interface{}
+		_ = json.Unmarshal(data, &body)
+		s.mu.Lock()
+		s.bodies = append(s.bodies, body)
+		status := s.status
+		s.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *capturingServer) setStatus(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func (s *capturingServer) last() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bodies[len(s.bodies)-1]
+}
+
+func newTestService(t *testing.T) (*Service, *notificationMocks.MockRepository, *actionMocks.MockRepository) {
+	ctrl := gomock.NewController(t)
+	notificationRepo := notificationMocks.NewMockRepository(ctrl)
+	actionRepo := actionMocks.NewMockRepository(ctrl)
+	service := NewService(notificationRepo, actionRepo, ruleMocks.NewMockRepository(ctrl),
+		notificationMocks.NewMockSSEHub(ctrl), zerolog.Nop())
+	return service, notificationRepo, actionRepo
+}
+
+func TestRegistry(t *testing.T) {
+	service, _, _ := newTestService(t)
+	assert.Equal(t, []notification.Channel{notification.ChannelSSE, notification.ChannelWebhook}, service.Channels())
+
+	err := service.RegisterChannel(notification.ChannelSSE, NewSSESender(nil))
+	assert.EqualError(t, err, "channel already registered: SSE")
+
+	tests := []struct {
+		name       string
+		actionType domainAction.Type
+		config     string
+		errText    string
+	}{
+		{name: "default channel", actionType: domainAction.TypeNotify, config: `{"title":"t"}`},
+		{name: "non-notification action", actionType: domainAction.TypeAgentRun, config: `{"channel":"PAGER"}`},
+		{name: "unknown channel", actionType: domainAction.TypeNotify, config: `{"channel":"PAGER"}`, errText: `unsupported channel "PAGER": registered channels are SSE, WEBHOOK`},
+		{name: "webhook without url", actionType: domainAction.TypeWebhook, config: `{"channel":"WEBHOOK"}`, errText: "invalid WEBHOOK channel config: webhook URL not configured in action"},
+		{name: "webhook with bad url", actionType: domainAction.TypeWebhook, config: `{"channel":"WEBHOOK","webhookUrl":"ftp://x"}`, errText: "invalid webhookUrl"},
+		{name: "webhook ok", actionType: domainAction.TypeWebhook, config: `{"channel":"WEBHOOK","webhookUrl":"https://hooks.example.com/x","timeout":5}`},
+		{name: "sse with wrong target type", actionType: domainAction.TypeNotify, config: `{"userId":42}`, errText: "invalid SSE channel config: invalid action config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateActionConfig(tt.actionType, json.RawMessage(tt.config))
+			if tt.errText == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.errText)
+		})
+	}
+
+	t.Run("email targets", func(t *testing.T) {
+		sender, err := NewEmailSender(EmailConfig{Host: "smtp", From: "alerts@example.com"}, &fakeUserRepo{})
+		require.NoError(t, err)
+		require.NoError(t, service.RegisterChannel(notification.ChannelEmail, sender))
+
+		assert.NoError(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"channel":"EMAIL","group":"role:OPERATOR"}`)))
+		assert.NoError(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"channel":"EMAIL","userId":"`+uuid.NewString()+`"}`)))
+		assert.ErrorContains(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"channel":"EMAIL"}`)), "require userId or group")
+		assert.ErrorContains(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"channel":"EMAIL","group":"ops"}`)), "unsupported target group")
+	})
+}
+
+func TestService_RegisterConfiguredChannels(t *testing.T) {
+	t.Run("invalid configs register nothing", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+		tests := []struct {
+			cfg     ChannelConfig
+			errText string
+		}{
+			{ChannelConfig{Channel: "OPS", Type: "pager", Settings: json.RawMessage(`{}`)}, `unknown sender type "pager": supported types are chatops, slack, teams`},
+			{ChannelConfig{Channel: "OPS", Type: SenderTypeSlack}, "invalid slack settings for channel OPS: settings are required"},
+			{ChannelConfig{Channel: "OPS", Type: SenderTypeSlack, Settings: json.RawMessage(`{"url":"https://x"}`)}, "unknown field"},
+			{ChannelConfig{Channel: "OPS", Type: SenderTypeTeams, Settings: json.RawMessage(`{"webhookUrl":"not a url"}`)}, "invalid webhookUrl"},
+			{ChannelConfig{Channel: "OPS", Type: SenderTypeChatOps, Settings: json.RawMessage(`{"webhookUrl":"https://x"}`)}, "template is required"},
+			{ChannelConfig{Channel: "OPS", Type: SenderTypeChatOps, Settings: json.RawMessage(`{"webhookUrl":"https://x","template":"text: {{.Title}}"}`)}, "did not render valid JSON"},
+			{ChannelConfig{Channel: "SSE", Type: SenderTypeSlack, Settings: json.RawMessage(`{"webhookUrl":"https://x"}`)}, "channel already registered: SSE"},
+		}
+		for _, tt := range tests {
+			err := service.RegisterConfiguredChannels([]ChannelConfig{
+				{Channel: "OK", Type: SenderTypeTeams, Settings: json.RawMessage(`{"webhookUrl":"https://x"}`)},
+				tt.cfg,
+			})
+			assert.ErrorContains(t, err, tt.errText)
+		}
+		assert.Len(t, service.Channels(), 2)
+	})
+
+	t.Run("configured senders deliver", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		server := newCapturingServer(t)
+		require.NoError(t, service.RegisterConfiguredChannels([]ChannelConfig{
+			{Channel: "OPS_SLACK", Type: SenderTypeSlack, Settings: json.RawMessage(`{"webhookUrl":"` + server.URL + `","username":"hub"}`)},
+			{Channel: "OPS_TEAMS", Type: SenderTypeTeams, Settings: json.RawMessage(`{"webhookUrl":"` + server.URL + `"}`)},
+			{Channel: "OPS_BOT", Type: SenderTypeChatOps, Settings: json.RawMessage(`{"webhookUrl":"` + server.URL + `","template":"{\"msg\": {{json .Title}}, \"line\": {{json .Payload.line}}}"}`)},
+		}))
+		assert.Len(t, service.Channels(), 5)
+		require.NoError(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"channel":"OPS_TEAMS"}`)))
+
+		ctx := context.Background()
+		send := func(channel notification.Channel) *notification.Notification {
+			n := notification.NewNotification(uuid.New(), channel, notification.PriorityCritical, "Line down", "Conveyor halted", []byte(`{"line":"L2"}`))
+			notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+			notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+			notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil)
+			_ = service.SendNotification(ctx, n.NotificationID)
+			return n
+		}
+
+		n := send("OPS_SLACK")
+		assert.Equal(t, notification.StatusDelivered, n.Status)
+		assert.Equal(t, "*[CRITICAL] Line down*\nConveyor halted", server.last()["text"])
+		assert.Equal(t, "hub", server.last()["username"])
+
+		send("OPS_TEAMS")
+		assert.Equal(t, "MessageCard", server.last()["@type"])
+		assert.Equal(t, "C50F1F", server.last()["themeColor"])
+
+		send("OPS_BOT")
+		assert.Equal(t, map[string]interface{}{"msg": "Line down", "line": "L2"}, server.last())
+
+		server.setStatus(http.StatusBadRequest)
+		n = send("OPS_SLACK")
+		assert.Equal(t, notification.StatusDeadLetter, n.Status)
+		assert.False(t, n.CanRetry())
+
+		server.setStatus(http.StatusTooManyRequests)
+		n = send("OPS_TEAMS")
+		assert.Equal(t, notification.StatusFailed, n.Status)
+		assert.True(t, n.CanRetry())
+	})
+}
+
+func TestWebhookSender_Send(t *testing.T) {
+	server := newCapturingServer(t)
+	service, notificationRepo, actionRepo := newTestService(t)
+	ctx := context.Background()
+
+	action := domainAction.NewAction(uuid.New(), 1, uuid.New(), domainAction.TypeWebhook,
+		json.RawMessage(`{"channel":"WEBHOOK","webhookUrl":"`+server.URL+`"}`))
+	actionRepo.EXPECT().GetByID(ctx, action.ActionID).Return(action, nil).AnyTimes()
+
+	send := func() *notification.Notification {
+		n := notification.NewNotification(action.ActionID, notification.ChannelWebhook, notification.PriorityHigh, "Title", "Body", []byte(`{"k":"v"}`))
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+		notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil)
+		_ = service.SendNotification(ctx, n.NotificationID)
+		return n
+	}
+
+	n := send()
+	assert.Equal(t, notification.StatusDelivered, n.Status)
+	assert.Equal(t, n.NotificationID.String(), server.last()["notification_id"])
+	assert.Equal(t, map[string]interface{}{"k": "v"}, server.last()["payload"])
+
+	server.setStatus(http.StatusNotFound)
+	n = send()
+	assert.Equal(t, notification.StatusDeadLetter, n.Status)
+	assert.Contains(t, *n.LastError, "status 404")
+
+	server.setStatus(http.StatusBadGateway)
+	n = send()
+	assert.True(t, n.CanRetry())
+	assert.Contains(t, *n.LastError, "status 502")
+}
+
+func TestWebhookSender_Signing(t *testing.T) {
+	t.Setenv("SIGNING_KEYS", "wh-new:"+hex.EncodeToString([]byte("new-secret"))+",wh-old:"+hex.EncodeToString([]byte("old-secret")))
+	keys, err := keystore.NewFromEnv()
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var verifyErrs []error
+	var keyIDs []string
+	oldOnly := &webhooksig.Verifier{Secrets: [][]byte{[]byte("old-secret")}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := oldOnly.VerifyRequest(r)
+		mu.Lock()
+		verifyErrs = append(verifyErrs, err)
+		keyIDs = append(keyIDs, r.Header.Get(webhooksig.HeaderKeyID))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	service, notificationRepo, actionRepo := newTestService(t)
+	service.SetWebhookKeyStore(keys)
+	ctx := context.Background()
+
+	send := func(signing string) {
+		cfg := `{"channel":"WEBHOOK","webhookUrl":"` + server.URL + `","headers":{"x-webhook-signature":"spoofed"},"signing":` + signing + `}`
+		require.NoError(t, service.ValidateActionConfig(domainAction.TypeWebhook, json.RawMessage(cfg)))
+		action := domainAction.NewAction(uuid.New(), 1, uuid.New(), domainAction.TypeWebhook, json.RawMessage(cfg))
+		n := notification.NewNotification(action.ActionID, notification.ChannelWebhook, notification.PriorityHigh, "Title", "Body", nil)
+		actionRepo.EXPECT().GetByID(ctx, action.ActionID).Return(action, nil)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+		notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil)
+		require.NoError(t, service.SendNotification(ctx, n.NotificationID))
+	}
+
+	// During the overlap both secrets sign, so a receiver still holding
+	// only the old secret verifies.
+	send(`{"keyId":"wh-new","previousKeyId":"wh-old","previousKeyUntil":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`)
+	// After the overlap only the new secret signs.
+	send(`{"keyId":"wh-new","previousKeyId":"wh-old","previousKeyUntil":"` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}`)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, verifyErrs, 2)
+	assert.NoError(t, verifyErrs[0])
+	assert.ErrorIs(t, verifyErrs[1], webhooksig.ErrNoValidSignature)
+	assert.Equal(t, []string{"wh-new", "wh-new"}, keyIDs)
+
+	err = service.ValidateActionConfig(domainAction.TypeWebhook, json.RawMessage(`{"channel":"WEBHOOK","webhookUrl":"https://x","signing":{"keyId":"missing"}}`))
+	assert.ErrorContains(t, err, `unknown signing key "missing"`)
+	err = service.ValidateActionConfig(domainAction.TypeWebhook, json.RawMessage(`{"channel":"WEBHOOK","webhookUrl":"https://x","signing":{}}`))
+	assert.ErrorContains(t, err, "signing.keyId is required")
+}
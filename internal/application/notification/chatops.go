@@ -0,0 +1,267 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// Configurable sender types
+const (
+	SenderTypeSlack   = "slack"
+	SenderTypeTeams   = "teams"
+	SenderTypeChatOps = "chatops"
+)
+
+// ChannelConfig registers a configured sender under a channel name, e.g.
+// {"channel": "OPS_SLACK", "type": "slack", "settings": {"webhookUrl": "..."}}.
+type ChannelConfig struct {
+	Channel  notification.Channel `json:"channel"`
+	Type     string               `json:"type"`
+	Settings json.RawMessage      `json:"settings"`
+}
+
+// senderFactories build configured senders from their settings
+var senderFactories = map[string]func(settings json.RawMessage) (ChannelSender, error){
+	SenderTypeSlack:   newSlackSender,
+	SenderTypeTeams:   newTeamsSender,
+	SenderTypeChatOps: newChatOpsSender,
+}
+
+// NewConfiguredSender builds the sender described by cfg, validating its
+// settings
+func NewConfiguredSender(cfg ChannelConfig) (ChannelSender, error) {
+	factory, ok := senderFactories[cfg.Type]
+	if !ok {
+		types := make([]string, 0, len(senderFactories))
+		for name := range senderFactories {
+			types = append(types, name)
+		}
+		sort.Strings(types)
+		return nil, fmt.Errorf("unknown sender type %q: supported types are %s", cfg.Type, strings.Join(types, ", "))
+	}
+	sender, err := factory(cfg.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s settings for channel %s: %w", cfg.Type, cfg.Channel, err)
+	}
+	return sender, nil
+}
+
+// chatSettings are the settings shared by configured chat senders
+type chatSettings struct {
+	WebhookURL string            `json:"webhookUrl"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Timeout    int               `json:"timeout,omitempty"` // seconds
+}
+
+func (c *chatSettings) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return time.Duration(c.Timeout) * time.Second
+	}
+	return defaultHTTPTimeout
+}
+
+func decodeSettings(settings json.RawMessage, v interface{}) error {
+	if len(settings) == 0 {
+		return errors.New("settings are required")
+	}
+	dec := json.NewDecoder(bytes.NewReader(settings))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func (c *chatSettings) validate() error {
+	if c.WebhookURL == "" {
+		return errors.New("webhookUrl is required")
+	}
+	if err := validateHTTPURL(c.WebhookURL); err != nil {
+		return fmt.Errorf("invalid webhookUrl: %w", err)
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+func (c *chatSettings) post(ctx context.Context, name string, body []byte) error {
+	status, respBody, err := postJSON(ctx, c.timeout(), c.WebhookURL, c.Headers, body)
+	if err != nil {
+		return fmt.Errorf("%s %w", name, err)
+	}
+	return statusError(name, status, respBody)
+}
+
+// slackSender posts to a Slack-compatible incoming webhook
+type slackSender struct {
+	settings struct {
+		chatSettings
+		Username  string `json:"username,omitempty"`
+		IconEmoji string `json:"iconEmoji,omitempty"`
+	}
+}
+
+func newSlackSender(settings json.RawMessage) (ChannelSender, error) {
+	s := &slackSender{}
+	if err := decodeSettings(settings, &s.settings); err != nil {
+		return nil, err
+	}
+	if err := s.settings.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateConfig accepts any action config; the webhook fixes the channel
+func (s *slackSender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg map[string]interface{}
+	return decodeConfig(actionConfig, &cfg)
+}
+
+func (s *slackSender) Send(ctx context.Context, n *notification.Notification) error {
+	msg := map[string]interface{}{
+		"text": fmt.Sprintf("*[%s] %s*\n%s", n.Priority, n.Title, n.Body),
+	}
+	if s.settings.Username != "" {
+		msg["username"] = s.settings.Username
+	}
+	if s.settings.IconEmoji != "" {
+		msg["icon_emoji"] = s.settings.IconEmoji
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return permanentErrorf("failed to marshal slack message: %w", err)
+	}
+	return s.settings.post(ctx, "slack webhook", body)
+}
+
+// teamsSender posts Microsoft Teams MessageCards
+type teamsSender struct {
+	settings chatSettings
+}
+
+func newTeamsSender(settings json.RawMessage) (ChannelSender, error) {
+	s := &teamsSender{}
+	if err := decodeSettings(settings, &s.settings); err != nil {
+		return nil, err
+	}
+	if err := s.settings.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateConfig accepts any action config; Teams cards take no options
+func (s *teamsSender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg map[string]interface{}
+	return decodeConfig(actionConfig, &cfg)
+}
+
+var teamsThemeColors = map[notification.Priority]string{
+	notification.PriorityCritical: "C50F1F",
+	notification.PriorityHigh:     "F7630C",
+	notification.PriorityMedium:   "FFB900",
+	notification.PriorityLow:      "0078D4",
+}
+
+func (s *teamsSender) Send(ctx context.Context, n *notification.Notification) error {
+	facts := []map[string]string{
+		{"name": "Priority", "value": string(n.Priority)},
+		{"name": "Notification", "value": n.NotificationID.String()},
+	}
+	if n.TraceID != nil {
+		facts = append(facts, map[string]string{"name": "Trace", "value": *n.TraceID})
+	}
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    n.Title,
+		"title":      n.Title,
+		"text":       n.Body,
+		"themeColor": teamsThemeColors[n.Priority],
+		"sections":   []map[string]interface{}{{"facts": facts}},
+	}
+	body, err := json.Marshal(card)
+	if err != nil {
+		return permanentErrorf("failed to marshal teams card: %w", err)
+	}
+	return s.settings.post(ctx, "teams webhook", body)
+}
+
+// chatOpsSender posts a templated JSON body to a generic chat-ops webhook
+type chatOpsSender struct {
+	settings struct {
+		chatSettings
+		// Template renders the request body with the same data as email
+		// templates; the result must be valid JSON. Use the json function
+		// to quote strings, e.g. {"text": {{json .Title}}}.
+		Template string `json:"template"`
+	}
+	tmpl *template.Template
+}
+
+var chatOpsFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func newChatOpsSender(settings json.RawMessage) (ChannelSender, error) {
+	s := &chatOpsSender{}
+	if err := decodeSettings(settings, &s.settings); err != nil {
+		return nil, err
+	}
+	if err := s.settings.validate(); err != nil {
+		return nil, err
+	}
+	if s.settings.Template == "" {
+		return nil, errors.New("template is required")
+	}
+	tmpl, err := template.New("chatops").Funcs(chatOpsFuncs).Parse(s.settings.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	s.tmpl = tmpl
+
+	// Render a sample so template mistakes surface at registration.
+	sample := notification.NewNotification(uuid.New(), notification.Channel("CHATOPS"), notification.PriorityMedium, "Sample", "Sample body", []byte(`{}`))
+	if _, err := s.render(sample); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ValidateConfig accepts any action config; the template decides the body
+func (s *chatOpsSender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg map[string]interface{}
+	return decodeConfig(actionConfig, &cfg)
+}
+
+func (s *chatOpsSender) render(n *notification.Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.tmpl.Execute(&buf, newTemplateData(n)); err != nil {
+		return nil, fmt.Errorf("template failed: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("template did not render valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *chatOpsSender) Send(ctx context.Context, n *notification.Notification) error {
+	body, err := s.render(n)
+	if err != nil {
+		return &DeliveryError{Permanent: true, Err: err}
+	}
+	return s.settings.post(ctx, "chatops webhook", body)
+}
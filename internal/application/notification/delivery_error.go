@@ -0,0 +1,50 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeliveryError is a send failure classified by whether another attempt can
+// succeed. Permanent failures are dead-lettered without further retries.
+type DeliveryError struct {
+	Permanent bool
+	// StatusCode is the HTTP status or SMTP reply code returned by the
+	// remote end, or 0 when no response was received.
+	StatusCode int
+	// ResponseBody is the start of the remote response, if any.
+	ResponseBody string
+	Err          error
+}
+
+func (e *DeliveryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a permanent delivery failure
+func IsPermanent(err error) bool {
+	var deliveryErr *DeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent
+}
+
+// AsDeliveryError returns the *DeliveryError in err's chain. Unclassified
+// errors are reported as transient.
+func AsDeliveryError(err error) *DeliveryError {
+	var deliveryErr *DeliveryError
+	if errors.As(err, &deliveryErr) {
+		return deliveryErr
+	}
+	return &DeliveryError{Err: err}
+}
+
+func permanentErrorf(format string, args ...interface{}) error {
+	return &DeliveryError{Permanent: true, Err: fmt.Errorf(format, args...)}
+}
+
+func transientErrorf(format string, args ...interface{}) error {
+	return &DeliveryError{Err: fmt.Errorf(format, args...)}
+}
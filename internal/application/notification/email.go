@@ -0,0 +1,408 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	"github.com/execution-hub/execution-hub/internal/domain/user"
+)
+
+// SMTP TLS modes
+const (
+	// SMTPTLSStartTLS requires the server to offer STARTTLS (default)
+	SMTPTLSStartTLS = "starttls"
+	// SMTPTLSOpportunistic upgrades with STARTTLS when the server offers it
+	SMTPTLSOpportunistic = "opportunistic"
+	// SMTPTLSNone never upgrades the connection
+	SMTPTLSNone = "none"
+)
+
+const (
+	defaultSMTPPort    = 587
+	defaultSMTPTimeout = 30 * time.Second
+	recipientPageSize  = 200
+
+	defaultSubjectTemplate = `[{{.Priority}}] {{.Title}}`
+	defaultBodyTemplate    = `{{.Body}}
+
+Priority: {{.Priority}}
+Notification: {{.NotificationID}}
+{{- if .TraceID}}
+Trace: {{.TraceID}}{{end}}
+{{- range $key, $value := .Payload}}
+{{$key}}: {{$value}}{{end}}
+`
+)
+
+// EmailConfig configures SMTP delivery for the email channel
+type EmailConfig struct {
+	Host     string
+	Port     int // default 587
+	Username string
+	Password string
+	// From is the sender address, e.g. "Execution Hub <alerts@example.com>".
+	From    string
+	TLSMode string // starttls (default), opportunistic or none
+	// TLSConfig overrides the STARTTLS client config; ServerName defaults
+	// to Host.
+	TLSConfig *tls.Config
+	Timeout   time.Duration // default 30s
+	// SubjectTemplate and BodyTemplate are text/template sources rendered
+	// with the notification's Title, Body, Priority, Channel, NotificationID,
+	// ActionID, TraceID, CreatedAt and decoded Payload.
+	SubjectTemplate string
+	BodyTemplate    string
+}
+
+// EmailSender delivers notifications over SMTP. Recipients are resolved
+// from the notification target: a user ID, or a "role:<ROLE>" group.
+type EmailSender struct {
+	cfg      EmailConfig
+	from     *mail.Address
+	subject  *template.Template
+	body     *template.Template
+	userRepo user.Repository
+}
+
+// NewEmailSender validates cfg and creates an SMTP sender
+func NewEmailSender(cfg EmailConfig, userRepo user.Repository) (*EmailSender, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = defaultSMTPPort
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	switch cfg.TLSMode {
+	case "":
+		cfg.TLSMode = SMTPTLSStartTLS
+	case SMTPTLSStartTLS, SMTPTLSOpportunistic, SMTPTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid smtp tls mode %q", cfg.TLSMode)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	if cfg.SubjectTemplate == "" {
+		cfg.SubjectTemplate = defaultSubjectTemplate
+	}
+	if cfg.BodyTemplate == "" {
+		cfg.BodyTemplate = defaultBodyTemplate
+	}
+	subject, err := template.New("subject").Parse(cfg.SubjectTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(cfg.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return &EmailSender{
+		cfg:      cfg,
+		from:     from,
+		subject:  subject,
+		body:     body,
+		userRepo: userRepo,
+	}, nil
+}
+
+// templateData is the context for email and chat-ops templates
+type templateData struct {
+	NotificationID string
+	ActionID       string
+	Channel        string
+	Priority       string
+	Title          string
+	Body           string
+	TraceID        string
+	CreatedAt      time.Time
+	Payload        map[string]interface{}
+}
+
+func newTemplateData(n *notification.Notification) templateData {
+	data := templateData{
+		NotificationID: n.NotificationID.String(),
+		ActionID:       n.ActionID.String(),
+		Channel:        string(n.Channel),
+		Priority:       string(n.Priority),
+		Title:          n.Title,
+		Body:           n.Body,
+		CreatedAt:      n.CreatedAt,
+	}
+	if n.TraceID != nil {
+		data.TraceID = *n.TraceID
+	}
+	if len(n.Payload) > 0 {
+		if err := json.Unmarshal(n.Payload, &data.Payload); err != nil {
+			data.Payload = nil
+		}
+	}
+	return data
+}
+
+// ValidateConfig requires a userId or a role:<ROLE> group target
+func (e *EmailSender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg targetConfig
+	if err := decodeConfig(actionConfig, &cfg); err != nil {
+		return err
+	}
+	switch {
+	case cfg.UserID != nil:
+		if _, err := uuid.Parse(*cfg.UserID); err != nil {
+			return fmt.Errorf("invalid userId %q", *cfg.UserID)
+		}
+	case cfg.Group != nil:
+		if _, err := groupRole(*cfg.Group); err != nil {
+			return err
+		}
+	default:
+		return errors.New("email notifications require userId or group")
+	}
+	return nil
+}
+
+// groupRole parses a "role:<ROLE>" target group
+func groupRole(group string) (user.Role, error) {
+	role := user.Role(strings.ToUpper(strings.TrimPrefix(group, "role:")))
+	if err := user.ValidateRole(role); err != nil {
+		return "", fmt.Errorf("unsupported target group %q: expected role:<ROLE>", group)
+	}
+	return role, nil
+}
+
+// Send renders and delivers n. Failures are *DeliveryError values: SMTP 5xx
+// replies and unresolvable recipients are permanent, connection errors and
+// 4xx replies are transient.
+func (e *EmailSender) Send(ctx context.Context, n *notification.Notification) error {
+	recipients, err := e.recipients(ctx, n)
+	if err != nil {
+		return err
+	}
+	msg, err := e.render(n, recipients)
+	if err != nil {
+		return permanentErrorf("failed to render email: %w", err)
+	}
+	return e.deliver(ctx, recipients, msg)
+}
+
+// recipients resolves the notification target to email addresses.
+func (e *EmailSender) recipients(ctx context.Context, n *notification.Notification) ([]string, error) {
+	switch {
+	case n.TargetUserID != nil:
+		id, err := uuid.Parse(*n.TargetUserID)
+		if err != nil {
+			return nil, permanentErrorf("invalid target user id %q", *n.TargetUserID)
+		}
+		u, err := e.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, transientErrorf("failed to get target user: %w", err)
+		}
+		if u == nil {
+			return nil, permanentErrorf("target user not found: %s", id)
+		}
+		if !u.IsActive() {
+			return nil, permanentErrorf("target user %s is disabled", u.Username)
+		}
+		if u.Email == nil || *u.Email == "" {
+			return nil, permanentErrorf("target user %s has no email address", u.Username)
+		}
+		return []string{*u.Email}, nil
+	case n.TargetGroup != nil:
+		role, err := groupRole(*n.TargetGroup)
+		if err != nil {
+			return nil, &DeliveryError{Permanent: true, Err: err}
+		}
+		status := user.StatusActive
+		filter := user.Filter{Role: &role, Status: &status}
+		seen := map[string]bool{}
+		var emails []string
+		for offset := 0; ; offset += recipientPageSize {
+			users, err := e.userRepo.List(ctx, filter, recipientPageSize, offset)
+			if err != nil {
+				return nil, transientErrorf("failed to list group members: %w", err)
+			}
+			for _, u := range users {
+				if u.Email == nil || *u.Email == "" || seen[*u.Email] {
+					continue
+				}
+				seen[*u.Email] = true
+				emails = append(emails, *u.Email)
+			}
+			if len(users) < recipientPageSize {
+				break
+			}
+		}
+		if len(emails) == 0 {
+			return nil, permanentErrorf("no active users with an email address in group %s", *n.TargetGroup)
+		}
+		return emails, nil
+	default:
+		return nil, permanentErrorf("email notification requires a target user or group")
+	}
+}
+
+func (e *EmailSender) render(n *notification.Notification, recipients []string) ([]byte, error) {
+	data := newTemplateData(n)
+	var subject, body bytes.Buffer
+	if err := e.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("subject template: %w", err)
+	}
+	if err := e.body.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("body template: %w", err)
+	}
+
+	// Group members are not shown each other's addresses.
+	to := "undisclosed-recipients:;"
+	if len(recipients) == 1 {
+		to = recipients[0]
+	}
+	domain := e.from.Address[strings.LastIndexByte(e.from.Address, '@')+1:]
+
+	var msg bytes.Buffer
+	writeHeader := func(key, value string) {
+		msg.WriteString(key + ": " + value + "\r\n")
+	}
+	writeHeader("From", e.from.String())
+	writeHeader("To", to)
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", headerValue(subject.String())))
+	writeHeader("Date", time.Now().UTC().Format(time.RFC1123Z))
+	writeHeader("Message-ID", "<"+n.NotificationID.String()+"."+strconv.Itoa(n.RetryCount)+"@"+domain+">")
+	writeHeader("X-Notification-ID", n.NotificationID.String())
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", "text/plain; charset=UTF-8")
+	writeHeader("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write(body.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func (e *EmailSender) deliver(ctx context.Context, recipients []string, msg []byte) error {
+	dialer := net.Dialer{Timeout: e.cfg.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port)))
+	if err != nil {
+		return transientErrorf("failed to connect to smtp server: %w", err)
+	}
+	deadline := time.Now().Add(e.cfg.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return transientErrorf("failed to set smtp deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return smtpError("smtp greeting failed", err)
+	}
+	defer c.Close()
+
+	if e.cfg.TLSMode != SMTPTLSNone {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			tlsConfig := &tls.Config{ServerName: e.cfg.Host}
+			if e.cfg.TLSConfig != nil {
+				tlsConfig = e.cfg.TLSConfig.Clone()
+				if tlsConfig.ServerName == "" {
+					tlsConfig.ServerName = e.cfg.Host
+				}
+			}
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return smtpError("starttls failed", err)
+			}
+		} else if e.cfg.TLSMode == SMTPTLSStartTLS {
+			return permanentErrorf("smtp server %s does not support STARTTLS", e.cfg.Host)
+		}
+	}
+
+	if e.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return permanentErrorf("smtp server %s does not support AUTH", e.cfg.Host)
+		}
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			// Credentials or an unencrypted connection will not fix
+			// themselves; only explicit 4xx replies are retried.
+			var tpErr *textproto.Error
+			if errors.As(err, &tpErr) && tpErr.Code < 500 {
+				return transientErrorf("smtp auth failed: %w", err)
+			}
+			return permanentErrorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(e.from.Address); err != nil {
+		return smtpError("smtp MAIL FROM rejected", err)
+	}
+	accepted := 0
+	var rejected []error
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			var tpErr *textproto.Error
+			if errors.As(err, &tpErr) && tpErr.Code >= 500 {
+				rejected = append(rejected, fmt.Errorf("%s: %w", rcpt, err))
+				continue
+			}
+			return smtpError("smtp RCPT TO failed", err)
+		}
+		accepted++
+	}
+	if accepted == 0 {
+		return permanentErrorf("all recipients rejected: %w", errors.Join(rejected...))
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return smtpError("smtp DATA rejected", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpError("failed to write message", err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("smtp message rejected", err)
+	}
+	_ = c.Quit()
+	return nil
+}
+
+// smtpError classifies err by its SMTP reply code: 5xx is permanent,
+// anything else is retried.
+func smtpError(op string, err error) error {
+	deliveryErr := &DeliveryError{Err: fmt.Errorf("%s: %w", op, err)}
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		deliveryErr.StatusCode = tpErr.Code
+		deliveryErr.Permanent = tpErr.Code >= 500
+	}
+	return deliveryErr
+}
+
+// headerValue folds a rendered template onto one header line.
+func headerValue(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,491 @@
+package notification
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	actionMocks "github.com/execution-hub/execution-hub/internal/domain/action/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	notificationMocks "github.com/execution-hub/execution-hub/internal/domain/notification/mocks"
+	ruleMocks "github.com/execution-hub/execution-hub/internal/domain/rule/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/user"
+)
+
+// fakeSMTPServer speaks enough SMTP for net/smtp: EHLO, STARTTLS, AUTH
+// PLAIN, MAIL, RCPT, DATA and QUIT.
+type fakeSMTPServer struct {
+	ln        net.Listener
+	tlsConfig *tls.Config // offers STARTTLS when set
+	// replies overrides the reply to "RCPT:<addr>" or "DATA".
+	replies map[string]string
+
+	mu       sync.Mutex
+	messages []fakeSMTPMessage
+}
+
+type fakeSMTPMessage struct {
+	from string
+	to   []string
+	data string
+	tls  bool
+	auth string
+}
+
+func newFakeSMTPServer(t *testing.T, tlsConfig *tls.Config) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{ln: ln, tlsConfig: tlsConfig, replies: map[string]string{}}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTPServer) setReply(key, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reply == "" {
+		delete(s.replies, key)
+		return
+	}
+	s.replies[key] = reply
+}
+
+func (s *fakeSMTPServer) reply(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reply, ok := s.replies[key]
+	return reply, ok
+}
+
+func (s *fakeSMTPServer) received() []fakeSMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]fakeSMTPMessage(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer func() { conn.Close() }()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+	var msg fakeSMTPMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake")
+			if s.tlsConfig != nil && !msg.tls {
+				tp.PrintfLine("250-STARTTLS")
+			}
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			msg.tls = true
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			if string(decoded) != "\x00mailer\x00secret" {
+				tp.PrintfLine("535 authentication failed")
+				continue
+			}
+			msg.auth = "mailer"
+			tp.PrintfLine("235 ok")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if reply, ok := s.reply("RCPT:" + rcpt); ok {
+				tp.PrintfLine("%s", reply)
+				continue
+			}
+			msg.to = append(msg.to, rcpt)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			if reply, ok := s.reply("DATA"); ok {
+				tp.PrintfLine("%s", reply)
+				continue
+			}
+			msg.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			return
+		case "RSET", "NOOP":
+			tp.PrintfLine("250 ok")
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+// selfSignedTLS returns a server config for 127.0.0.1 and a client config
+// trusting it.
+func selfSignedTLS(t *testing.T) (*tls.Config, *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake-smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server := &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	return server, &tls.Config{RootCAs: pool}
+}
+
+// fakeUserRepo serves users from memory
+type fakeUserRepo struct {
+	users []*user.User
+}
+
+func (r *fakeUserRepo) Create(context.Context, *user.User) error { return nil }
+func (r *fakeUserRepo) Update(context.Context, *user.User) error { return nil }
+func (r *fakeUserRepo) Count(context.Context) (int, error)       { return len(r.users), nil }
+
+func (r *fakeUserRepo) GetByID(_ context.Context, id uuid.UUID) (*user.User, error) {
+	for _, u := range r.users {
+		if u.UserID == id {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) GetByUsername(_ context.Context, username string) (*user.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) List(_ context.Context, filter user.Filter, limit, offset int) ([]*user.User, error) {
+	var out []*user.User
+	for _, u := range r.users {
+		if filter.Role != nil && u.Role != *filter.Role {
+			continue
+		}
+		if filter.Status != nil && u.Status != *filter.Status {
+			continue
+		}
+		out = append(out, u)
+	}
+	if offset >= len(out) {
+		return nil, nil
+	}
+	out = out[offset:]
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func testUser(username string, role user.Role, email string) *user.User {
+	u := &user.User{UserID: uuid.New(), Username: username, Role: role, Type: user.TypeHuman, Status: user.StatusActive}
+	if email != "" {
+		u.Email = &email
+	}
+	return u
+}
+
+func parseMessage(t *testing.T, data string) (*mail.Message, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	require.NoError(t, err)
+	return msg, string(body)
+}
+
+func TestEmailSender_Send(t *testing.T) {
+	ctx := context.Background()
+	serverTLS, clientTLS := selfSignedTLS(t)
+	alice := testUser("alice", user.RoleOperator, "alice@example.com")
+	bob := testUser("bob", user.RoleOperator, "bob@example.com")
+	users := &fakeUserRepo{users: []*user.User{
+		alice,
+		bob,
+		testUser("carol", user.RoleOperator, ""),
+		testUser("dave", user.RoleViewer, "dave@example.com"),
+	}}
+
+	t.Run("user target over STARTTLS with auth", func(t *testing.T) {
+		server := newFakeSMTPServer(t, serverTLS)
+		sender, err := NewEmailSender(EmailConfig{
+			Host:      "127.0.0.1",
+			Port:      server.port(),
+			Username:  "mailer",
+			Password:  "secret",
+			From:      "Execution Hub <alerts@example.com>",
+			TLSConfig: clientTLS,
+		}, users)
+		require.NoError(t, err)
+
+		target := alice.UserID.String()
+		n := notification.NewNotification(uuid.New(), notification.ChannelEmail, notification.PriorityHigh,
+			"Pump überhitzt", "Temperature exceeded 90C.", []byte(`{"ruleId":"r-1","value":93}`))
+		n.SetTarget(&target, nil)
+		n.SetTraceID("trace-1")
+
+		require.NoError(t, sender.Send(ctx, n))
+
+		received := server.received()
+		require.Len(t, received, 1)
+		assert.True(t, received[0].tls)
+		assert.Equal(t, "mailer", received[0].auth)
+		assert.Equal(t, "alerts@example.com", received[0].from)
+		assert.Equal(t, []string{"alice@example.com"}, received[0].to)
+
+		msg, body := parseMessage(t, received[0].data)
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, "[HIGH] Pump überhitzt", subject)
+		assert.Equal(t, "alice@example.com", msg.Header.Get("To"))
+		assert.Equal(t, n.NotificationID.String(), msg.Header.Get("X-Notification-ID"))
+		assert.Contains(t, body, "Temperature exceeded 90C.")
+		assert.Contains(t, body, "Trace: trace-1")
+		assert.Contains(t, body, "ruleId: r-1")
+		assert.Contains(t, body, "value: 93")
+	})
+
+	t.Run("role group with custom templates", func(t *testing.T) {
+		server := newFakeSMTPServer(t, nil)
+		sender, err := NewEmailSender(EmailConfig{
+			Host:            "127.0.0.1",
+			Port:            server.port(),
+			From:            "alerts@example.com",
+			TLSMode:         SMTPTLSOpportunistic,
+			SubjectTemplate: "{{.Title}} ({{.Payload.line}})",
+			BodyTemplate:    "{{.Body}} on line {{.Payload.line}}",
+		}, users)
+		require.NoError(t, err)
+
+		group := "role:OPERATOR"
+		n := notification.NewNotification(uuid.New(), notification.ChannelEmail, notification.PriorityMedium,
+			"Line stopped", "Conveyor halted", []byte(`{"line":"L2"}`))
+		n.SetTarget(nil, &group)
+
+		require.NoError(t, sender.Send(ctx, n))
+
+		received := server.received()
+		require.Len(t, received, 1)
+		assert.False(t, received[0].tls)
+		assert.ElementsMatch(t, []string{"alice@example.com", "bob@example.com"}, received[0].to)
+		msg, body := parseMessage(t, received[0].data)
+		assert.Equal(t, "Line stopped (L2)", msg.Header.Get("Subject"))
+		assert.Equal(t, "undisclosed-recipients:;", msg.Header.Get("To"))
+		assert.Equal(t, "Conveyor halted on line L2", strings.TrimSpace(body))
+	})
+
+	t.Run("failure classification", func(t *testing.T) {
+		target := alice.UserID.String()
+		noEmail := users.users[2].UserID.String()
+		unknown := uuid.New().String()
+		group := "ops-team"
+		closed, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		closedPort := closed.Addr().(*net.TCPAddr).Port
+		closed.Close()
+
+		tests := []struct {
+			name      string
+			tlsMode   string
+			replies   map[string]string
+			username  string
+			port      int
+			user      *string
+			group     *string
+			permanent bool
+			errText   string
+		}{
+			{name: "recipient rejected", replies: map[string]string{"RCPT:alice@example.com": "550 no such mailbox"}, user: &target, permanent: true, errText: "all recipients rejected"},
+			{name: "recipient greylisted", replies: map[string]string{"RCPT:alice@example.com": "451 try again later"}, user: &target, errText: "451"},
+			{name: "message too large", replies: map[string]string{"DATA": "552 message size exceeds limit"}, user: &target, permanent: true, errText: "552"},
+			{name: "mailbox busy", replies: map[string]string{"DATA": "450 mailbox busy"}, user: &target, errText: "450"},
+			{name: "bad credentials", username: "intruder", user: &target, permanent: true, errText: "smtp auth failed"},
+			{name: "starttls required", tlsMode: SMTPTLSStartTLS, user: &target, permanent: true, errText: "does not support STARTTLS"},
+			{name: "connection refused", port: closedPort, user: &target, errText: "failed to connect"},
+			{name: "user without email", user: &noEmail, permanent: true, errText: "has no email address"},
+			{name: "unknown user", user: &unknown, permanent: true, errText: "target user not found"},
+			{name: "unsupported group", group: &group, permanent: true, errText: "unsupported target group"},
+			{name: "no target", permanent: true, errText: "requires a target"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := newFakeSMTPServer(t, nil)
+				for key, reply := range tt.replies {
+					server.setReply(key, reply)
+				}
+				port := tt.port
+				if port == 0 {
+					port = server.port()
+				}
+				tlsMode := tt.tlsMode
+				if tlsMode == "" {
+					tlsMode = SMTPTLSNone
+				}
+				cfg := EmailConfig{Host: "127.0.0.1", Port: port, From: "alerts@example.com", TLSMode: tlsMode, Timeout: 5 * time.Second}
+				if tt.username != "" {
+					cfg.Username, cfg.Password = tt.username, "wrong"
+				}
+				sender, err := NewEmailSender(cfg, users)
+				require.NoError(t, err)
+
+				n := notification.NewNotification(uuid.New(), notification.ChannelEmail, notification.PriorityLow, "T", "B", nil)
+				n.SetTarget(tt.user, tt.group)
+				err = sender.Send(ctx, n)
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errText)
+				assert.Equal(t, tt.permanent, IsPermanent(err))
+				assert.Empty(t, server.received())
+			})
+		}
+	})
+}
+
+func TestNewEmailSender_InvalidConfig(t *testing.T) {
+	_, err := NewEmailSender(EmailConfig{From: "alerts@example.com"}, &fakeUserRepo{})
+	assert.EqualError(t, err, "smtp host is required")
+
+	_, err = NewEmailSender(EmailConfig{Host: "smtp", From: "not an address"}, &fakeUserRepo{})
+	assert.ErrorContains(t, err, "invalid from address")
+
+	_, err = NewEmailSender(EmailConfig{Host: "smtp", From: "alerts@example.com", TLSMode: "ssl"}, &fakeUserRepo{})
+	assert.EqualError(t, err, `invalid smtp tls mode "ssl"`)
+
+	_, err = NewEmailSender(EmailConfig{Host: "smtp", From: "alerts@example.com", BodyTemplate: "{{.Body"}, &fakeUserRepo{})
+	assert.ErrorContains(t, err, "invalid body template")
+}
+
+func TestService_SendNotificationEmail(t *testing.T) {
+	alice := testUser("alice", user.RoleOperator, "alice@example.com")
+	server := newFakeSMTPServer(t, nil)
+	sender, err := NewEmailSender(EmailConfig{
+		Host:    "127.0.0.1",
+		Port:    server.port(),
+		From:    "alerts@example.com",
+		TLSMode: SMTPTLSNone,
+	}, &fakeUserRepo{users: []*user.User{alice}})
+	require.NoError(t, err)
+
+	send := func(t *testing.T, rcptReply string) *notification.Notification {
+		ctrl := gomock.NewController(t)
+		notificationRepo := notificationMocks.NewMockRepository(ctrl)
+		service := NewService(notificationRepo, actionMocks.NewMockRepository(ctrl), ruleMocks.NewMockRepository(ctrl),
+			notificationMocks.NewMockSSEHub(ctrl), zerolog.Nop())
+		require.NoError(t, service.RegisterChannel(notification.ChannelEmail, sender))
+
+		server.setReply("RCPT:alice@example.com", rcptReply)
+
+		ctx := context.Background()
+		target := alice.UserID.String()
+		n := notification.NewNotification(uuid.New(), notification.ChannelEmail, notification.PriorityHigh, "Title", "Body", nil)
+		n.SetTarget(&target, nil)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+		notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil)
+
+		_ = service.SendNotification(ctx, n.NotificationID)
+		return n
+	}
+
+	t.Run("delivered", func(t *testing.T) {
+		n := send(t, "")
+		assert.Equal(t, notification.StatusDelivered, n.Status)
+	})
+
+	t.Run("transient failure stays retryable", func(t *testing.T) {
+		n := send(t, "452 insufficient storage")
+		assert.Equal(t, notification.StatusFailed, n.Status)
+		assert.Equal(t, 1, n.RetryCount)
+		assert.True(t, n.CanRetry())
+	})
+
+	t.Run("permanent failure is not retried", func(t *testing.T) {
+		n := send(t, "550 mailbox unavailable")
+		assert.Equal(t, notification.StatusDeadLetter, n.Status)
+		assert.False(t, n.CanRetry())
+	})
+
+	t.Run("channel not configured", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		notificationRepo := notificationMocks.NewMockRepository(ctrl)
+		service := NewService(notificationRepo, actionMocks.NewMockRepository(ctrl), ruleMocks.NewMockRepository(ctrl),
+			notificationMocks.NewMockSSEHub(ctrl), zerolog.Nop())
+		ctx := context.Background()
+		n := notification.NewNotification(uuid.New(), notification.ChannelEmail, notification.PriorityHigh, "Title", "Body", nil)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+		notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil)
+
+		err := service.SendNotification(ctx, n.NotificationID)
+		assert.ErrorContains(t, err, "unsupported channel: EMAIL")
+		assert.False(t, n.CanRetry())
+	})
+}
@@ -0,0 +1,126 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// ErrPreferencesNotConfigured is returned by preference operations when no
+// PreferenceRepository is set
+var ErrPreferencesNotConfigured = errors.New("notification preferences are not configured")
+
+// SetPreferenceRepository enables per-user and per-group preferences
+func (s *Service) SetPreferenceRepository(repo notification.PreferenceRepository) {
+	s.preferences = repo
+}
+
+// GetPreference returns the preference of a target, or nil if it has none
+func (s *Service) GetPreference(ctx context.Context, targetType notification.TargetType, targetID string) (*notification.Preference, error) {
+	if s.preferences == nil {
+		return nil, ErrPreferencesNotConfigured
+	}
+	p, err := s.preferences.Get(ctx, targetType, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return p, nil
+}
+
+// ValidatePreference checks p and that its channels are registered
+func (s *Service) ValidatePreference(p *notification.Preference) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	for _, c := range p.Channels {
+		if !s.hasChannel(c) {
+			return fmt.Errorf("unsupported channel %s", c)
+		}
+	}
+	for c := range p.MinPriority {
+		if !s.hasChannel(c) {
+			return fmt.Errorf("unsupported channel %s", c)
+		}
+	}
+	return nil
+}
+
+// SetPreference validates and stores p, replacing any existing preference
+// of the same target
+func (s *Service) SetPreference(ctx context.Context, p *notification.Preference) (*notification.Preference, error) {
+	if s.preferences == nil {
+		return nil, ErrPreferencesNotConfigured
+	}
+	if err := s.ValidatePreference(p); err != nil {
+		return nil, err
+	}
+	now := s.now().UTC()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	if err := s.preferences.Upsert(ctx, p); err != nil {
+		return nil, fmt.Errorf("failed to save notification preference: %w", err)
+	}
+
+	s.logger.Info().
+		Str("target_type", string(p.TargetType)).
+		Str("target_id", p.TargetID).
+		Msg("notification preference saved")
+
+	return p, nil
+}
+
+// DeletePreference removes the preference of a target
+func (s *Service) DeletePreference(ctx context.Context, targetType notification.TargetType, targetID string) error {
+	if s.preferences == nil {
+		return ErrPreferencesNotConfigured
+	}
+	if err := s.preferences.Delete(ctx, targetType, targetID); err != nil {
+		return fmt.Errorf("failed to delete notification preference: %w", err)
+	}
+	return nil
+}
+
+// targetPreference returns the preference of n's target user, or of its
+// group when it targets a group. It returns nil when preferences are not
+// configured.
+func (s *Service) targetPreference(ctx context.Context, n *notification.Notification) (*notification.Preference, error) {
+	if s.preferences == nil {
+		return nil, nil
+	}
+	var p *notification.Preference
+	var err error
+	switch {
+	case n.TargetUserID != nil:
+		p, err = s.preferences.Get(ctx, notification.TargetTypeUser, *n.TargetUserID)
+	case n.TargetGroup != nil:
+		p, err = s.preferences.Get(ctx, notification.TargetTypeGroup, *n.TargetGroup)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+	return p, nil
+}
+
+// applyPreference routes n to a channel its target accepts and defers it
+// through the target's mutes and quiet hours. Nothing is dropped: a
+// notification no channel accepts goes to the SSE feed. n must not be
+// persisted yet.
+func (s *Service) applyPreference(n *notification.Notification, pref *notification.Preference) error {
+	if pref == nil {
+		return nil
+	}
+	n.Reroute(pref.Route(n.Channel, n.Priority, s.hasChannel))
+	if until, reason, ok := pref.DeferUntil(n, s.now()); ok {
+		return n.Defer(until, reason)
+	}
+	return nil
+}
+
+func (s *Service) hasChannel(channel notification.Channel) bool {
+	_, ok := s.channels.Get(channel)
+	return ok
+}
@@ -0,0 +1,112 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	notificationMocks "github.com/execution-hub/execution-hub/internal/domain/notification/mocks"
+)
+
+func TestService_CreateFromActionPreferences(t *testing.T) {
+	// 23:00 in Berlin
+	now := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	setup := func(t *testing.T, pref *notification.Preference) (*Service, *notificationMocks.MockRepository) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		prefs := notificationMocks.NewMockPreferenceRepository(gomock.NewController(t))
+		service.SetPreferenceRepository(prefs)
+		prefs.EXPECT().Get(ctx, notification.TargetTypeUser, "alice").Return(pref, nil)
+		return service, notificationRepo
+	}
+	create := func(t *testing.T, service *Service, notificationRepo *notificationMocks.MockRepository, action *domainAction.Action) *notification.Notification {
+		notificationRepo.EXPECT().GetByActionID(ctx, action.ActionID).Return(nil, nil)
+		notificationRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		n, err := service.CreateFromAction(ctx, action)
+		require.NoError(t, err)
+		return n
+	}
+	newAction := func(priority domainAction.Priority, cfg string) *domainAction.Action {
+		action := domainAction.NewAction(uuid.New(), 1, uuid.New(), domainAction.TypeNotify, json.RawMessage(cfg))
+		action.Priority = priority
+		return action
+	}
+
+	t.Run("reroutes below minimum priority", func(t *testing.T) {
+		service, notificationRepo := setup(t, &notification.Preference{
+			TargetType:  notification.TargetTypeUser,
+			TargetID:    "alice",
+			Channels:    []notification.Channel{notification.ChannelWebhook, notification.ChannelSSE},
+			MinPriority: map[notification.Channel]notification.Priority{notification.ChannelWebhook: notification.PriorityHigh},
+		})
+		n := create(t, service, notificationRepo, newAction(domainAction.PriorityLow, `{"userId":"alice","channel":"WEBHOOK"}`))
+		assert.Equal(t, notification.ChannelSSE, n.Channel)
+		assert.Equal(t, notification.ChannelWebhook, *n.RoutedFrom)
+		assert.Equal(t, notification.StatusPending, n.Status)
+	})
+
+	t.Run("defers during quiet hours", func(t *testing.T) {
+		service, notificationRepo := setup(t, &notification.Preference{
+			TargetType: notification.TargetTypeUser,
+			TargetID:   "alice",
+			QuietHours: &notification.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"},
+		})
+		n := create(t, service, notificationRepo, newAction(domainAction.PriorityHigh, `{"userId":"alice"}`))
+		assert.Equal(t, notification.StatusPending, n.Status)
+		assert.Equal(t, notification.DeferReasonQuietHours, *n.DeferReason)
+		assert.Equal(t, time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), *n.NextAttemptAt)
+
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		assert.ErrorIs(t, service.SendNotification(ctx, n.NotificationID), notification.ErrDeferred)
+	})
+
+	t.Run("defers muted rule", func(t *testing.T) {
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice"}`)
+		service, notificationRepo := setup(t, &notification.Preference{
+			TargetType: notification.TargetTypeUser,
+			TargetID:   "alice",
+			Mutes:      []notification.Mute{{RuleID: &action.RuleID, Until: now.Add(time.Hour)}},
+		})
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, action.RuleID, *n.RuleID)
+		assert.Equal(t, notification.DeferReasonMuted, *n.DeferReason)
+		assert.Equal(t, now.Add(time.Hour), *n.NextAttemptAt)
+	})
+
+	t.Run("defers muted workflow escalation", func(t *testing.T) {
+		workflowID := uuid.New()
+		service, notificationRepo := setup(t, &notification.Preference{
+			TargetType: notification.TargetTypeUser,
+			TargetID:   "alice",
+			Mutes:      []notification.Mute{{WorkflowID: &workflowID, Until: now.Add(time.Hour)}},
+		})
+		notificationRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		user := "alice"
+		n, err := service.CreateEscalation(ctx, uuid.New(), notification.ChannelSSE, "", "", &user, nil, &workflowID, nil)
+		require.NoError(t, err)
+		assert.Equal(t, workflowID, *n.WorkflowID)
+		assert.Equal(t, notification.DeferReasonMuted, *n.DeferReason)
+	})
+}
+
+func TestService_ValidatePreference(t *testing.T) {
+	service, _, _ := newTestService(t)
+	p := &notification.Preference{
+		TargetType: notification.TargetTypeGroup,
+		TargetID:   "role:ADMIN",
+		Channels:   []notification.Channel{notification.ChannelSSE},
+	}
+	assert.NoError(t, service.ValidatePreference(p))
+
+	p.MinPriority = map[notification.Channel]notification.Priority{notification.ChannelEmail: notification.PriorityHigh}
+	assert.ErrorContains(t, service.ValidatePreference(p), "unsupported channel EMAIL")
+}
@@ -0,0 +1,315 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+	"github.com/execution-hub/execution-hub/pkg/webhooksig"
+)
+
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	userAgent          = "Industrial-Data-Source-Notification/1.0"
+)
+
+// targetConfig holds the target fields shared by notification action configs
+type targetConfig struct {
+	UserID *string `json:"userId,omitempty"`
+	Group  *string `json:"group,omitempty"`
+}
+
+// SSESender broadcasts notifications to connected SSE clients
+type SSESender struct {
+	hub notification.SSEHub
+}
+
+// NewSSESender creates the built-in SSE sender
+func NewSSESender(hub notification.SSEHub) *SSESender {
+	return &SSESender{hub: hub}
+}
+
+// ValidateConfig checks the optional userId and group targets
+func (s *SSESender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg targetConfig
+	return decodeConfig(actionConfig, &cfg)
+}
+
+// Send routes the notification to its target user, group or everyone
+func (s *SSESender) Send(_ context.Context, n *notification.Notification) error {
+	msg := notification.NewSSEMessage("notification", n.Payload)
+	if n.TargetUserID != nil {
+		s.hub.BroadcastToUser(*n.TargetUserID, msg)
+	} else if n.TargetGroup != nil {
+		s.hub.BroadcastToGroup(*n.TargetGroup, msg)
+	} else {
+		s.hub.BroadcastToAll(msg)
+	}
+	return nil
+}
+
+// webhookConfig is the webhook section of an action config
+type webhookConfig struct {
+	WebhookURL string            `json:"webhookUrl"`
+	Headers    map[string]string `json:"headers"`
+	Timeout    int               `json:"timeout"` // seconds, optional
+	Signing    *webhookSigning   `json:"signing,omitempty"`
+}
+
+// webhookSigning selects the key store secrets used to sign deliveries.
+// During rotation both keys sign until PreviousKeyUntil, or until
+// PreviousKeyID is removed when no end is set.
+type webhookSigning struct {
+	KeyID            string     `json:"keyId"`
+	PreviousKeyID    string     `json:"previousKeyId,omitempty"`
+	PreviousKeyUntil *time.Time `json:"previousKeyUntil,omitempty"`
+}
+
+// keyIDs returns the keys that sign at now, current key first.
+func (c *webhookSigning) keyIDs(now time.Time) []string {
+	ids := []string{c.KeyID}
+	if c.PreviousKeyID != "" && (c.PreviousKeyUntil == nil || now.Before(*c.PreviousKeyUntil)) {
+		ids = append(ids, c.PreviousKeyID)
+	}
+	return ids
+}
+
+func (c webhookConfig) validate() error {
+	if c.WebhookURL == "" {
+		return fmt.Errorf("webhook URL not configured in action")
+	}
+	if err := validateHTTPURL(c.WebhookURL); err != nil {
+		return fmt.Errorf("invalid webhookUrl: %w", err)
+	}
+	if c.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if c.Signing != nil {
+		if c.Signing.KeyID == "" {
+			return fmt.Errorf("signing.keyId is required")
+		}
+		if c.Signing.PreviousKeyID == c.Signing.KeyID {
+			return fmt.Errorf("signing.previousKeyId must differ from signing.keyId")
+		}
+	}
+	return nil
+}
+
+// WebhookSender posts notifications to the webhookUrl of their action.
+// Actions with a signing config are signed with HMAC-SHA256 as described
+// in package webhooksig.
+type WebhookSender struct {
+	actionRepo domainAction.Repository
+	keys       trust.KeyStore
+	now        func() time.Time
+	logger     zerolog.Logger
+}
+
+// NewWebhookSender creates the built-in webhook sender
+func NewWebhookSender(actionRepo domainAction.Repository, logger zerolog.Logger) *WebhookSender {
+	return &WebhookSender{actionRepo: actionRepo, now: time.Now, logger: logger}
+}
+
+// SetKeyStore sets the store holding webhook signing secrets
+func (s *WebhookSender) SetKeyStore(keys trust.KeyStore) {
+	s.keys = keys
+}
+
+// ValidateConfig requires an http(s) webhookUrl and, when signing is
+// configured, signing keys that exist in the key store
+func (s *WebhookSender) ValidateConfig(actionConfig json.RawMessage) error {
+	var cfg webhookConfig
+	if err := decodeConfig(actionConfig, &cfg); err != nil {
+		return err
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if cfg.Signing == nil {
+		return nil
+	}
+	if s.keys == nil {
+		return fmt.Errorf("webhook signing is not configured")
+	}
+	for _, keyID := range []string{cfg.Signing.KeyID, cfg.Signing.PreviousKeyID} {
+		if keyID == "" {
+			continue
+		}
+		if _, err := s.keys.GetKey(context.Background(), keyID); err != nil {
+			return fmt.Errorf("unknown signing key %q", keyID)
+		}
+	}
+	return nil
+}
+
+// signatureHeaders signs body with every active signing key.
+func (s *WebhookSender) signatureHeaders(ctx context.Context, signing *webhookSigning, body []byte) (map[string]string, error) {
+	if s.keys == nil {
+		return nil, fmt.Errorf("webhook signing is not configured")
+	}
+	now := s.now()
+	var secrets [][]byte
+	for _, keyID := range signing.keyIDs(now) {
+		secret, err := s.keys.GetKey(ctx, keyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signing key %s: %w", keyID, err)
+		}
+		secrets = append(secrets, secret)
+	}
+	timestamp := now.Unix()
+	return map[string]string{
+		webhooksig.HeaderTimestamp: strconv.FormatInt(timestamp, 10),
+		webhooksig.HeaderSignature: webhooksig.SignatureHeader(timestamp, body, secrets...),
+		webhooksig.HeaderKeyID:     signing.KeyID,
+	}, nil
+}
+
+// Send posts the notification as JSON
+func (s *WebhookSender) Send(ctx context.Context, n *notification.Notification) error {
+	// Get the action to retrieve webhook configuration
+	action, err := s.actionRepo.GetByID(ctx, n.ActionID)
+	if err != nil {
+		return transientErrorf("failed to get action for webhook: %w", err)
+	}
+	if action == nil {
+		return permanentErrorf("action not found: %s", n.ActionID)
+	}
+
+	var cfg webhookConfig
+	if err := json.Unmarshal(action.ActionConfig, &cfg); err != nil {
+		return permanentErrorf("failed to parse webhook config: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return &DeliveryError{Permanent: true, Err: err}
+	}
+
+	timeout := defaultHTTPTimeout
+	if cfg.Timeout > 0 {
+		timeout = time.Duration(cfg.Timeout) * time.Second
+	}
+
+	// Build webhook payload
+	webhookPayload := map[string]interface{}{
+		"notification_id": n.NotificationID.String(),
+		"action_id":       n.ActionID.String(),
+		"channel":         string(n.Channel),
+		"priority":        string(n.Priority),
+		"title":           n.Title,
+		"body":            n.Body,
+		"created_at":      n.CreatedAt.Format(time.RFC3339),
+	}
+	if len(n.Payload) > 0 {
+		var payloadData interface{}
+		if err := json.Unmarshal(n.Payload, &payloadData); err == nil {
+			webhookPayload["payload"] = payloadData
+		}
+	}
+	if n.TargetUserID != nil {
+		webhookPayload["target_user_id"] = *n.TargetUserID
+	}
+	if n.TargetGroup != nil {
+		webhookPayload["target_group"] = *n.TargetGroup
+	}
+	if n.TraceID != nil {
+		webhookPayload["trace_id"] = *n.TraceID
+	}
+
+	body, err := json.Marshal(webhookPayload)
+	if err != nil {
+		return permanentErrorf("failed to marshal webhook payload: %w", err)
+	}
+
+	headers := map[string]string{"X-Notification-ID": n.NotificationID.String()}
+	for key, value := range cfg.Headers {
+		headers[key] = value
+	}
+	if cfg.Signing != nil {
+		// Signature headers are set last so configured headers cannot
+		// replace them.
+		signed, err := s.signatureHeaders(ctx, cfg.Signing, body)
+		if err != nil {
+			return &DeliveryError{Permanent: true, Err: err}
+		}
+		for key := range headers {
+			if _, reserved := signed[http.CanonicalHeaderKey(key)]; reserved {
+				delete(headers, key)
+			}
+		}
+		for key, value := range signed {
+			headers[key] = value
+		}
+	}
+	status, respBody, err := postJSON(ctx, timeout, cfg.WebhookURL, headers, body)
+	if err != nil {
+		return fmt.Errorf("webhook %w", err)
+	}
+
+	s.logger.Debug().
+		Str("notification_id", n.NotificationID.String()).
+		Str("webhook_url", cfg.WebhookURL).
+		Int("status_code", status).
+		Str("response_body", string(respBody)).
+		Msg("webhook delivery attempted")
+
+	return statusError("webhook", status, respBody)
+}
+
+// postJSON posts body and returns the status code and up to 1KB of the
+// response body. Transport failures are transient.
+func postJSON(ctx context.Context, timeout time.Duration, target string, headers map[string]string, body []byte) (int, []byte, error) {
+	client := &http.Client{Timeout: timeout}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, permanentErrorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, transientErrorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return resp.StatusCode, respBody, nil
+}
+
+// statusError classifies an HTTP response: 2xx succeeds, 408, 429 and 5xx
+// are retried, other 4xx are permanent.
+func statusError(name string, status int, respBody []byte) error {
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	permanent := status >= 400 && status < 500 && status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+	return &DeliveryError{
+		Permanent:    permanent,
+		StatusCode:   status,
+		ResponseBody: string(respBody),
+		Err:          fmt.Errorf("%s failed with status %d: %s", name, status, string(respBody)),
+	}
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+	return nil
+}
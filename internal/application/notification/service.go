@@ -1,13 +1,11 @@
 package notification
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"math/rand"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +14,7 @@ import (
 	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
 	"github.com/execution-hub/execution-hub/internal/domain/notification"
 	"github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
 )
 
 // Service handles notification operations
@@ -24,6 +23,12 @@ type Service struct {
 	actionRepo       domainAction.Repository
 	ruleRepo         rule.Repository
 	sseHub           notification.SSEHub
+	channels         *Registry
+	webhook          *WebhookSender
+	retryPolicies    map[notification.Channel]notification.RetryPolicy
+	preferences      notification.PreferenceRepository
+	now              func() time.Time
+	random           func() float64
 	logger           zerolog.Logger
 }
 
@@ -35,17 +40,123 @@ func NewService(
 	sseHub notification.SSEHub,
 	logger zerolog.Logger,
 ) *Service {
-	return &Service{
+	s := &Service{
 		notificationRepo: notificationRepo,
 		actionRepo:       actionRepo,
 		ruleRepo:         ruleRepo,
 		sseHub:           sseHub,
+		channels:         NewRegistry(),
+		retryPolicies:    map[notification.Channel]notification.RetryPolicy{},
+		now:              time.Now,
+		random:           rand.Float64,
 		logger:           logger.With().Str("service", "notification").Logger(),
 	}
+	s.webhook = NewWebhookSender(actionRepo, s.logger)
+	// Built-in channels; email needs SMTP settings and is registered by the caller.
+	_ = s.channels.Register(notification.ChannelSSE, NewSSESender(sseHub))
+	_ = s.channels.Register(notification.ChannelWebhook, s.webhook)
+	return s
+}
+
+// SetWebhookKeyStore enables signed webhook deliveries with secrets from keys
+func (s *Service) SetWebhookKeyStore(keys trust.KeyStore) {
+	s.webhook.SetKeyStore(keys)
+}
+
+// RegisterChannel adds a sender for channel, e.g. an *EmailSender for
+// notification.ChannelEmail
+func (s *Service) RegisterChannel(channel notification.Channel, sender ChannelSender) error {
+	return s.channels.Register(channel, sender)
+}
+
+// RegisterConfiguredChannels builds and registers configured senders such
+// as Slack, Teams or generic chat-ops webhooks. Nothing is registered if
+// any config is invalid.
+func (s *Service) RegisterConfiguredChannels(configs []ChannelConfig) error {
+	senders := make([]ChannelSender, len(configs))
+	seen := map[notification.Channel]bool{}
+	for i, cfg := range configs {
+		if _, exists := s.channels.Get(cfg.Channel); exists || seen[cfg.Channel] {
+			return fmt.Errorf("channel already registered: %s", cfg.Channel)
+		}
+		seen[cfg.Channel] = true
+		sender, err := NewConfiguredSender(cfg)
+		if err != nil {
+			return err
+		}
+		senders[i] = sender
+	}
+	for i, cfg := range configs {
+		if err := s.channels.Register(cfg.Channel, senders[i]); err != nil {
+			return err
+		}
+		s.logger.Info().
+			Str("channel", string(cfg.Channel)).
+			Str("type", cfg.Type).
+			Msg("notification channel registered")
+	}
+	return nil
+}
+
+// SetRetryPolicy sets the backoff schedule and retry budget for channel.
+// Channels without a policy use notification.DefaultRetryPolicy.
+func (s *Service) SetRetryPolicy(channel notification.Channel, policy notification.RetryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid retry policy for channel %s: %w", channel, err)
+	}
+	s.retryPolicies[channel] = policy
+	return nil
+}
+
+func (s *Service) retryPolicy(channel notification.Channel) notification.RetryPolicy {
+	if policy, ok := s.retryPolicies[channel]; ok {
+		return policy
+	}
+	return notification.DefaultRetryPolicy()
+}
+
+// Channels returns the registered channel names
+func (s *Service) Channels() []notification.Channel {
+	return s.channels.Channels()
+}
+
+// ValidateActionConfig checks the channel config of notification actions.
+// Other action types are not delivered as notifications and always pass.
+func (s *Service) ValidateActionConfig(actionType domainAction.Type, actionConfig json.RawMessage) error {
+	if !IsNotificationAction(actionType) {
+		return nil
+	}
+	if err := s.channels.ValidateActionConfig(actionConfig); err != nil {
+		return err
+	}
+	var cfg struct {
+		Throttle *notification.ThrottleConfig `json:"throttle"`
+	}
+	if len(actionConfig) > 0 {
+		if err := json.Unmarshal(actionConfig, &cfg); err != nil {
+			return fmt.Errorf("invalid throttle: %w", err)
+		}
+	}
+	if cfg.Throttle != nil {
+		if err := cfg.Throttle.Validate(); err != nil {
+			return fmt.Errorf("invalid throttle: %w", err)
+		}
+	}
+	return nil
 }
 
 // CreateFromAction creates a notification from an action
 func (s *Service) CreateFromAction(ctx context.Context, action *domainAction.Action) (*notification.Notification, error) {
+	return s.createFromAction(ctx, action, nil)
+}
+
+// CreateFromWorkflowAction creates a notification from the action of a
+// workflow step, so that the target can mute the workflow
+func (s *Service) CreateFromWorkflowAction(ctx context.Context, action *domainAction.Action, workflowID uuid.UUID) (*notification.Notification, error) {
+	return s.createFromAction(ctx, action, &workflowID)
+}
+
+func (s *Service) createFromAction(ctx context.Context, action *domainAction.Action, workflowID *uuid.UUID) (*notification.Notification, error) {
 	// Idempotency: avoid duplicate notifications for the same action
 	existingByAction, err := s.notificationRepo.GetByActionID(ctx, action.ActionID)
 	if err != nil {
@@ -62,12 +173,13 @@ func (s *Service) CreateFromAction(ctx context.Context, action *domainAction.Act
 
 	// Parse action config for notification settings
 	var actionCfg struct {
-		Title    string  `json:"title"`
-		Body     string  `json:"body"`
-		Channel  string  `json:"channel"`
-		UserID   *string `json:"userId,omitempty"`
-		Group    *string `json:"group,omitempty"`
-		TTLHours *int    `json:"ttlHours,omitempty"`
+		Title    string                       `json:"title"`
+		Body     string                       `json:"body"`
+		Channel  string                       `json:"channel"`
+		UserID   *string                      `json:"userId,omitempty"`
+		Group    *string                      `json:"group,omitempty"`
+		TTLHours *int                         `json:"ttlHours,omitempty"`
+		Throttle *notification.ThrottleConfig `json:"throttle,omitempty"`
 	}
 	if err := json.Unmarshal(action.ActionConfig, &actionCfg); err != nil {
 		return nil, fmt.Errorf("failed to parse action config: %w", err)
@@ -149,9 +261,15 @@ func (s *Service) CreateFromAction(ctx context.Context, action *domainAction.Act
 	if dedupeKey != nil {
 		n.DedupeKey = dedupeKey
 	}
+	n.MaxRetries = s.retryPolicy(channel).MaxRetries
 
-	// Set target
+	// Set target and source
 	n.SetTarget(actionCfg.UserID, actionCfg.Group)
+	var ruleID *uuid.UUID
+	if action.RuleID != uuid.Nil {
+		ruleID = &action.RuleID
+	}
+	n.SetSource(ruleID, workflowID)
 
 	// Set expiry if TTL specified
 	if actionCfg.TTLHours != nil && *actionCfg.TTLHours > 0 {
@@ -163,6 +281,19 @@ func (s *Service) CreateFromAction(ctx context.Context, action *domainAction.Act
 		n.SetTraceID(*action.TraceID)
 	}
 
+	// Apply the target's channel, quiet hours and mute preferences, then
+	// the target's or action's rate limit or digest
+	pref, err := s.targetPreference(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPreference(n, pref); err != nil {
+		return nil, err
+	}
+	if err := s.applyThrottle(ctx, n, throttleFor(pref, actionCfg.Throttle)); err != nil {
+		return nil, err
+	}
+
 	// Save notification
 	if err := s.notificationRepo.Create(ctx, n); err != nil {
 		return nil, fmt.Errorf("failed to create notification: %w", err)
@@ -173,6 +304,7 @@ func (s *Service) CreateFromAction(ctx context.Context, action *domainAction.Act
 		Str("action_id", action.ActionID.String()).
 		Str("channel", string(n.Channel)).
 		Str("priority", string(n.Priority)).
+		Str("status", string(n.Status)).
 		Msg("notification created")
 
 	return n, nil
@@ -201,6 +333,7 @@ func (s *Service) CreateEscalation(
 	body string,
 	targetUser *string,
 	targetGroup *string,
+	workflowID *uuid.UUID,
 	traceID *string,
 ) (*notification.Notification, error) {
 	if channel == "" {
@@ -214,11 +347,25 @@ func (s *Service) CreateEscalation(
 	}
 
 	n := notification.NewNotification(actionID, channel, notification.PriorityHigh, title, body, []byte("{}"))
+	n.MaxRetries = s.retryPolicy(channel).MaxRetries
 	n.SetTarget(targetUser, targetGroup)
+	n.SetSource(nil, workflowID)
 	if traceID != nil {
 		n.SetTraceID(*traceID)
 	}
 
+	// Escalations follow the target's preferences, including its throttle
+	pref, err := s.targetPreference(ctx, n)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPreference(n, pref); err != nil {
+		return nil, err
+	}
+	if err := s.applyThrottle(ctx, n, throttleFor(pref, nil)); err != nil {
+		return nil, err
+	}
+
 	if err := s.notificationRepo.Create(ctx, n); err != nil {
 		return nil, fmt.Errorf("failed to create escalation notification: %w", err)
 	}
@@ -227,6 +374,7 @@ func (s *Service) CreateEscalation(
 		Str("notification_id", n.NotificationID.String()).
 		Str("action_id", n.ActionID.String()).
 		Str("channel", string(n.Channel)).
+		Str("status", string(n.Status)).
 		Msg("escalation notification created")
 
 	return n, nil
@@ -241,6 +389,9 @@ func (s *Service) SendNotification(ctx context.Context, notificationID uuid.UUID
 	if n == nil {
 		return fmt.Errorf("notification not found: %s", notificationID)
 	}
+	if n.Status == notification.StatusPending && !n.IsDue(s.now()) {
+		return fmt.Errorf("%w until %s", notification.ErrDeferred, n.NextAttemptAt.Format(time.RFC3339))
+	}
 
 	// Record attempt start
 	attempt := notification.NewDeliveryAttempt(notificationID, n.RetryCount+1)
@@ -266,33 +417,48 @@ func (s *Service) SendNotification(ctx context.Context, notificationID uuid.UUID
 		return fmt.Errorf("failed to persist sent status: %w", err)
 	}
 
-	// Send based on channel
+	// Send through the channel's registered sender
 	var sendErr error
-	switch n.Channel {
-	case notification.ChannelSSE:
-		sendErr = s.sendViaSSE(n)
-	case notification.ChannelWebhook:
-		sendErr = s.sendViaWebhook(ctx, n)
-	default:
-		sendErr = fmt.Errorf("unsupported channel: %s", n.Channel)
+	if sender, ok := s.channels.Get(n.Channel); ok {
+		sendErr = sender.Send(ctx, n)
+	} else {
+		sendErr = permanentErrorf("unsupported channel: %s", n.Channel)
 	}
 
 	// Record attempt result
 	attempt.DurationMs = int(time.Since(startTime).Milliseconds())
 
 	if sendErr != nil {
+		deliveryErr := AsDeliveryError(sendErr)
 		attempt.Status = notification.StatusFailed
+		attempt.Permanent = deliveryErr.Permanent
+		if deliveryErr.StatusCode != 0 {
+			code := deliveryErr.StatusCode
+			attempt.ResponseCode = &code
+		}
+		if deliveryErr.ResponseBody != "" {
+			respBody := deliveryErr.ResponseBody
+			attempt.ResponseBody = &respBody
+		}
 		errMsg := sendErr.Error()
 		attempt.ErrorMessage = &errMsg
-		n.MarkFailed(errMsg)
+		decision := s.handleFailure(n, errMsg, deliveryErr.Permanent, attempt)
 
-		s.logger.Warn().
+		event := s.logger.Warn()
+		if decision == notification.DecisionDeadLetter {
+			event = s.logger.Error()
+		}
+		event.
 			Str("notification_id", n.NotificationID.String()).
 			Err(sendErr).
 			Int("retry_count", n.RetryCount).
+			Bool("permanent", deliveryErr.Permanent).
+			Str("decision", string(decision)).
 			Msg("notification send failed")
 	} else {
 		attempt.Status = notification.StatusDelivered
+		decision := notification.DecisionDelivered
+		attempt.Decision = &decision
 		n.MarkDelivered()
 
 		s.logger.Info().
@@ -333,142 +499,25 @@ func (s *Service) SendNotification(ctx context.Context, notificationID uuid.UUID
 	return persistErr
 }
 
-// sendViaSSE sends notification via Server-Sent Events
-func (s *Service) sendViaSSE(n *notification.Notification) error {
-	// Build SSE message
-	msg := notification.NewSSEMessage("notification", n.Payload)
-
-	// Route based on target
-	if n.TargetUserID != nil {
-		s.sseHub.BroadcastToUser(*n.TargetUserID, msg)
-	} else if n.TargetGroup != nil {
-		s.sseHub.BroadcastToGroup(*n.TargetGroup, msg)
+// handleFailure marks n failed and either schedules its next attempt using
+// the channel's retry policy or dead-letters it when the failure is
+// permanent or retries are exhausted. The decision is recorded on attempt.
+func (s *Service) handleFailure(n *notification.Notification, errMsg string, permanent bool, attempt *notification.DeliveryAttempt) notification.Decision {
+	decision := notification.DecisionDeadLetter
+	if err := n.MarkFailed(errMsg); errors.Is(err, notification.ErrExpired) {
+		decision = notification.DecisionExpired
+	} else if !permanent && n.CanRetry() {
+		backoff := s.retryPolicy(n.Channel).Backoff(n.RetryCount, s.random())
+		_ = n.ScheduleRetry(s.now().Add(backoff))
+		backoffMs := backoff.Milliseconds()
+		attempt.BackoffMs = &backoffMs
+		attempt.NextAttemptAt = n.NextAttemptAt
+		decision = notification.DecisionRetry
 	} else {
-		s.sseHub.BroadcastToAll(msg)
-	}
-
-	return nil
-}
-
-// sendViaWebhook sends notification via webhook
-func (s *Service) sendViaWebhook(ctx context.Context, n *notification.Notification) error {
-	// Get the action to retrieve webhook configuration
-	action, err := s.actionRepo.GetByID(ctx, n.ActionID)
-	if err != nil {
-		return fmt.Errorf("failed to get action for webhook: %w", err)
-	}
-	if action == nil {
-		return fmt.Errorf("action not found: %s", n.ActionID)
-	}
-
-	// Parse webhook configuration from action config
-	var webhookConfig struct {
-		WebhookURL string            `json:"webhookUrl"`
-		Headers    map[string]string `json:"headers"`
-		Timeout    int               `json:"timeout"` // seconds, optional
-	}
-	if err := json.Unmarshal(action.ActionConfig, &webhookConfig); err != nil {
-		return fmt.Errorf("failed to parse webhook config: %w", err)
-	}
-
-	if webhookConfig.WebhookURL == "" {
-		return fmt.Errorf("webhook URL not configured in action")
-	}
-
-	// Set default timeout
-	timeout := 30 * time.Second
-	if webhookConfig.Timeout > 0 {
-		timeout = time.Duration(webhookConfig.Timeout) * time.Second
-	}
-
-	// Build webhook payload
-	webhookPayload := map[string]interface{}{
-		"notification_id": n.NotificationID.String(),
-		"action_id":       n.ActionID.String(),
-		"channel":         string(n.Channel),
-		"priority":        string(n.Priority),
-		"title":           n.Title,
-		"body":            n.Body,
-		"created_at":      n.CreatedAt.Format(time.RFC3339),
-	}
-
-	// Include notification payload if present
-	if len(n.Payload) > 0 {
-		var payloadData interface{}
-		if err := json.Unmarshal(n.Payload, &payloadData); err == nil {
-			webhookPayload["payload"] = payloadData
-		}
-	}
-
-	// Include target info if present
-	if n.TargetUserID != nil {
-		webhookPayload["target_user_id"] = *n.TargetUserID
-	}
-	if n.TargetGroup != nil {
-		webhookPayload["target_group"] = *n.TargetGroup
-	}
-	if n.TraceID != nil {
-		webhookPayload["trace_id"] = *n.TraceID
-	}
-
-	// Marshal payload
-	body, err := json.Marshal(webhookPayload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
-	}
-
-	// Create HTTP client with timeout
-	client := &http.Client{Timeout: timeout}
-
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookConfig.WebhookURL, bytes.NewReader(body))
-	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "Industrial-Data-Source-Notification/1.0")
-	req.Header.Set("X-Notification-ID", n.NotificationID.String())
-	for key, value := range webhookConfig.Headers {
-		req.Header.Set(key, value)
-	}
-
-	// Execute request
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("webhook request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body (limited to 1KB for logging)
-	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-
-	// Log the attempt
-	s.logger.Debug().
-		Str("notification_id", n.NotificationID.String()).
-		Str("webhook_url", webhookConfig.WebhookURL).
-		Int("status_code", resp.StatusCode).
-		Str("response_body", string(respBody)).
-		Msg("webhook delivery attempted")
-
-	// Check response status
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		s.logger.Info().
-			Str("notification_id", n.NotificationID.String()).
-			Str("webhook_url", webhookConfig.WebhookURL).
-			Int("status_code", resp.StatusCode).
-			Msg("webhook delivery succeeded")
-		return nil
-	}
-
-	// 4xx errors are permanent failures (client error, don't retry)
-	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-		return fmt.Errorf("webhook rejected with status %d: %s (permanent failure)", resp.StatusCode, string(respBody))
+		_ = n.MarkDeadLetter()
 	}
-
-	// 5xx errors are temporary failures (server error, can retry)
-	return fmt.Errorf("webhook failed with status %d: %s (retryable)", resp.StatusCode, string(respBody))
+	attempt.Decision = &decision
+	return decision
 }
 
 // GetNotification retrieves a notification by ID
@@ -478,7 +527,7 @@ func (s *Service) GetNotification(ctx context.Context, notificationID uuid.UUID)
 		return nil, fmt.Errorf("failed to get notification: %w", err)
 	}
 	if n == nil {
-		return nil, fmt.Errorf("notification not found: %s", notificationID)
+		return nil, fmt.Errorf("%w: %s", notification.ErrNotFound, notificationID)
 	}
 	return n, nil
 }
@@ -506,7 +555,11 @@ func (s *Service) ProcessPendingNotifications(ctx context.Context, limit int) (i
 	}
 
 	processed := 0
+	now := s.now()
 	for _, n := range notifications {
+		if !n.IsDue(now) {
+			continue
+		}
 		if err := s.SendNotification(ctx, n.NotificationID); err != nil {
 			s.logger.Warn().
 				Str("notification_id", n.NotificationID.String()).
@@ -528,7 +581,12 @@ func (s *Service) ProcessRetryableNotifications(ctx context.Context, limit int)
 	}
 
 	retried := 0
+	now := s.now()
 	for _, n := range notifications {
+		if !n.IsDue(now) {
+			continue
+		}
+
 		// Reset for retry
 		if err := n.ResetForRetry(); err != nil {
 			s.logger.Warn().
@@ -562,6 +620,91 @@ func (s *Service) ProcessRetryableNotifications(ctx context.Context, limit int)
 	return retried, nil
 }
 
+// DeadLetter is a dead-lettered notification with its delivery history
+type DeadLetter struct {
+	Notification *notification.Notification      `json:"notification"`
+	Attempts     []*notification.DeliveryAttempt `json:"attempts"`
+}
+
+// ListDeadLetters lists dead-lettered notifications, newest first,
+// optionally restricted to one channel
+func (s *Service) ListDeadLetters(ctx context.Context, channel *notification.Channel, limit, offset int) ([]*notification.Notification, error) {
+	status := notification.StatusDeadLetter
+	ns, err := s.notificationRepo.List(ctx, notification.Filter{Channel: channel, Status: &status}, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+	return ns, nil
+}
+
+// GetDeadLetter returns a dead-lettered notification and its attempts
+func (s *Service) GetDeadLetter(ctx context.Context, notificationID uuid.UUID) (*DeadLetter, error) {
+	n, err := s.getDeadLetter(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := s.notificationRepo.GetAttempts(ctx, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery attempts: %w", err)
+	}
+	return &DeadLetter{Notification: n, Attempts: attempts}, nil
+}
+
+// ReplayDeadLetter requeues a dead-lettered notification as PENDING with
+// a fresh retry budget from its channel's policy. It is delivered by the
+// next ProcessPendingNotifications run.
+func (s *Service) ReplayDeadLetter(ctx context.Context, notificationID uuid.UUID) (*notification.Notification, error) {
+	n, err := s.getDeadLetter(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Replay(s.retryPolicy(n.Channel).MaxRetries); err != nil {
+		return nil, err
+	}
+	if err := s.notificationRepo.Update(ctx, n); err != nil {
+		return nil, fmt.Errorf("failed to persist replayed notification: %w", err)
+	}
+
+	s.logger.Info().
+		Str("notification_id", n.NotificationID.String()).
+		Str("channel", string(n.Channel)).
+		Msg("dead letter replayed")
+
+	return n, nil
+}
+
+// DiscardDeadLetter marks a dead-lettered notification DISCARDED
+func (s *Service) DiscardDeadLetter(ctx context.Context, notificationID uuid.UUID) (*notification.Notification, error) {
+	n, err := s.getDeadLetter(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Discard(); err != nil {
+		return nil, err
+	}
+	if err := s.notificationRepo.Update(ctx, n); err != nil {
+		return nil, fmt.Errorf("failed to persist discarded notification: %w", err)
+	}
+
+	s.logger.Info().
+		Str("notification_id", n.NotificationID.String()).
+		Str("channel", string(n.Channel)).
+		Msg("dead letter discarded")
+
+	return n, nil
+}
+
+func (s *Service) getDeadLetter(ctx context.Context, notificationID uuid.UUID) (*notification.Notification, error) {
+	n, err := s.GetNotification(ctx, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if n.Status != notification.StatusDeadLetter {
+		return nil, notification.ErrNotDeadLettered
+	}
+	return n, nil
+}
+
 // ExpireNotifications expires old notifications
 func (s *Service) ExpireNotifications(ctx context.Context) (int64, error) {
 	return s.notificationRepo.ExpireNotifications(ctx)
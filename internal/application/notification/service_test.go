@@ -740,3 +740,192 @@ func TestService_GetNotificationEvidence(t *testing.T) {
 		assert.Nil(t, result)
 	})
 }
+
+// stubSender fails every send with err
+type stubSender struct {
+	err error
+}
+
+func (s *stubSender) ValidateConfig(json.RawMessage) error { return nil }
+
+func (s *stubSender) Send(context.Context, *notification.Notification) error { return s.err }
+
+func TestService_SendNotificationBackoff(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	policy := notification.RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     3,
+		Jitter:         0.1,
+	}
+	sender := &stubSender{}
+	newService := func(t *testing.T) (*Service, *notificationMocks.MockRepository) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		service.random = func() float64 { return 0.5 }
+		require.NoError(t, service.RegisterChannel("PAGER", sender))
+		require.NoError(t, service.SetRetryPolicy("PAGER", policy))
+		return service, notificationRepo
+	}
+	ctx := context.Background()
+	send := func(service *Service, notificationRepo *notificationMocks.MockRepository, n *notification.Notification) *notification.DeliveryAttempt {
+		var attempt *notification.DeliveryAttempt
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil).Times(2)
+		notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, a *notification.DeliveryAttempt) error {
+				attempt = a
+				return nil
+			})
+		_ = service.SendNotification(ctx, n.NotificationID)
+		return attempt
+	}
+
+	t.Run("transient failures back off then dead-letter", func(t *testing.T) {
+		service, notificationRepo := newService(t)
+		sender.err = &DeliveryError{StatusCode: 503, ResponseBody: "busy", Err: errors.New("pager failed with status 503")}
+		n := notification.NewNotification(uuid.New(), "PAGER", notification.PriorityHigh, "Title", "Body", nil)
+		n.MaxRetries = policy.MaxRetries
+
+		attempt := send(service, notificationRepo, n)
+		assert.Equal(t, notification.StatusFailed, n.Status)
+		require.NotNil(t, n.NextAttemptAt)
+		assert.Equal(t, now.Add(10*time.Second), *n.NextAttemptAt)
+		require.NotNil(t, attempt.Decision)
+		assert.Equal(t, notification.DecisionRetry, *attempt.Decision)
+		assert.Equal(t, int64(10000), *attempt.BackoffMs)
+		assert.Equal(t, n.NextAttemptAt, attempt.NextAttemptAt)
+		assert.Equal(t, 503, *attempt.ResponseCode)
+		assert.Equal(t, "busy", *attempt.ResponseBody)
+		assert.False(t, attempt.Permanent)
+
+		// Not yet due: the retry pass leaves it alone
+		notificationRepo.EXPECT().ListRetryableNotifications(ctx, 10).Return([]*notification.Notification{n}, nil)
+		retried, err := service.ProcessRetryableNotifications(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 0, retried)
+
+		service.now = func() time.Time { return now.Add(time.Minute) }
+		require.NoError(t, n.ResetForRetry())
+		attempt = send(service, notificationRepo, n)
+		assert.Equal(t, notification.StatusDeadLetter, n.Status)
+		assert.Nil(t, n.NextAttemptAt)
+		assert.Equal(t, notification.DecisionDeadLetter, *attempt.Decision)
+		assert.Nil(t, attempt.BackoffMs)
+	})
+
+	t.Run("permanent failure dead-letters immediately", func(t *testing.T) {
+		service, notificationRepo := newService(t)
+		sender.err = &DeliveryError{Permanent: true, StatusCode: 410, Err: errors.New("pager failed with status 410")}
+		n := notification.NewNotification(uuid.New(), "PAGER", notification.PriorityHigh, "Title", "Body", nil)
+
+		attempt := send(service, notificationRepo, n)
+		assert.Equal(t, notification.StatusDeadLetter, n.Status)
+		assert.Equal(t, 1, n.RetryCount)
+		assert.Equal(t, notification.DecisionDeadLetter, *attempt.Decision)
+		assert.True(t, attempt.Permanent)
+	})
+
+	t.Run("unclassified errors are retried", func(t *testing.T) {
+		service, notificationRepo := newService(t)
+		sender.err = errors.New("connection reset")
+		n := notification.NewNotification(uuid.New(), "PAGER", notification.PriorityHigh, "Title", "Body", nil)
+
+		attempt := send(service, notificationRepo, n)
+		assert.Equal(t, notification.StatusFailed, n.Status)
+		assert.Equal(t, notification.DecisionRetry, *attempt.Decision)
+		assert.Nil(t, attempt.ResponseCode)
+	})
+
+	t.Run("invalid policy rejected", func(t *testing.T) {
+		service, _ := newService(t)
+		err := service.SetRetryPolicy("PAGER", notification.RetryPolicy{})
+		assert.ErrorContains(t, err, "invalid retry policy for channel PAGER")
+	})
+}
+
+func TestService_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	deadLetter := func(t *testing.T) *notification.Notification {
+		n := notification.NewNotification(uuid.New(), notification.ChannelWebhook, notification.PriorityHigh, "Title", "Body", nil)
+		n.Status = notification.StatusSent
+		require.NoError(t, n.MarkFailed("gone"))
+		require.NoError(t, n.MarkDeadLetter())
+		return n
+	}
+
+	t.Run("list", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		channel := notification.ChannelWebhook
+		status := notification.StatusDeadLetter
+		n := deadLetter(t)
+		notificationRepo.EXPECT().
+			List(ctx, notification.Filter{Channel: &channel, Status: &status}, 50, 0).
+			Return([]*notification.Notification{n}, nil)
+
+		ns, err := service.ListDeadLetters(ctx, &channel, 50, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []*notification.Notification{n}, ns)
+	})
+
+	t.Run("inspect includes attempts", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		n := deadLetter(t)
+		attempts := []*notification.DeliveryAttempt{notification.NewDeliveryAttempt(n.NotificationID, 1)}
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().GetAttempts(ctx, n.NotificationID).Return(attempts, nil)
+
+		dl, err := service.GetDeadLetter(ctx, n.NotificationID)
+		require.NoError(t, err)
+		assert.Equal(t, n, dl.Notification)
+		assert.Equal(t, attempts, dl.Attempts)
+	})
+
+	t.Run("replay uses channel policy", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		policy := notification.DefaultRetryPolicy()
+		policy.MaxRetries = 6
+		require.NoError(t, service.SetRetryPolicy(notification.ChannelWebhook, policy))
+		n := deadLetter(t)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil)
+
+		replayed, err := service.ReplayDeadLetter(ctx, n.NotificationID)
+		require.NoError(t, err)
+		assert.Equal(t, notification.StatusPending, replayed.Status)
+		assert.Equal(t, 6, replayed.MaxRetries)
+		assert.Equal(t, 0, replayed.RetryCount)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		n := deadLetter(t)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil)
+		notificationRepo.EXPECT().Update(ctx, n).Return(nil)
+
+		discarded, err := service.DiscardDeadLetter(ctx, n.NotificationID)
+		require.NoError(t, err)
+		assert.Equal(t, notification.StatusDiscarded, discarded.Status)
+	})
+
+	t.Run("rejects notifications that are not dead-lettered", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		n := notification.NewNotification(uuid.New(), notification.ChannelWebhook, notification.PriorityHigh, "Title", "Body", nil)
+		notificationRepo.EXPECT().GetByID(ctx, n.NotificationID).Return(n, nil).Times(2)
+
+		_, err := service.ReplayDeadLetter(ctx, n.NotificationID)
+		assert.ErrorIs(t, err, notification.ErrNotDeadLettered)
+		_, err = service.DiscardDeadLetter(ctx, n.NotificationID)
+		assert.ErrorIs(t, err, notification.ErrNotDeadLettered)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		id := uuid.New()
+		notificationRepo.EXPECT().GetByID(ctx, id).Return(nil, nil)
+
+		_, err := service.GetDeadLetter(ctx, id)
+		assert.ErrorIs(t, err, notification.ErrNotFound)
+	})
+}
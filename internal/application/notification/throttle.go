@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// throttleFor returns the throttle that applies to a notification: the
+// target's preference if it sets one, otherwise the action's.
+func throttleFor(pref *notification.Preference, actionThrottle *notification.ThrottleConfig) *notification.ThrottleConfig {
+	if pref != nil && pref.Throttle != nil {
+		return pref.Throttle
+	}
+	return actionThrottle
+}
+
+// applyThrottle holds or suppresses n when its target already received
+// MaxPerWindow notifications of the same channel and priority in the
+// current window. n must not be persisted yet.
+func (s *Service) applyThrottle(ctx context.Context, n *notification.Notification, cfg *notification.ThrottleConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	key := notification.ThrottleKey(n)
+	n.ThrottleKey = &key
+	if !cfg.Applies(n.Priority) {
+		return nil
+	}
+
+	now := s.now()
+	count, err := s.notificationRepo.CountByThrottleKey(ctx, key, now.Add(-cfg.Window()))
+	if err != nil {
+		return fmt.Errorf("failed to count notifications for throttle: %w", err)
+	}
+	if count < cfg.MaxPerWindow {
+		return nil
+	}
+
+	switch cfg.Mode {
+	case notification.ThrottleModeRateLimit:
+		return n.Suppress()
+	case notification.ThrottleModeDigest:
+		// Join the open digest window for this key, or start one.
+		held, err := s.notificationRepo.ListHeld(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to list held notifications: %w", err)
+		}
+		until := now.Add(cfg.Window())
+		if len(held) > 0 && held[0].HeldUntil != nil {
+			until = *held[0].HeldUntil
+		}
+		return n.Hold(until)
+	}
+	return nil
+}
+
+// ProcessDigests delivers one digest notification per throttle key whose
+// digest window has closed and marks the held notifications DIGESTED. It
+// returns the number of digests sent; digests deferred by the target's
+// quiet hours are sent by ProcessPendingNotifications once due.
+func (s *Service) ProcessDigests(ctx context.Context, limit int) (int, error) {
+	held, err := s.notificationRepo.ListDueHeld(ctx, s.now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list held notifications: %w", err)
+	}
+
+	var keys []string
+	groups := map[string][]*notification.Notification{}
+	for _, n := range held {
+		if n.ThrottleKey == nil {
+			continue
+		}
+		key := *n.ThrottleKey
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+
+	sent := 0
+	for _, key := range keys {
+		digest, err := s.createDigest(ctx, groups[key])
+		if err != nil {
+			s.logger.Error().
+				Str("throttle_key", key).
+				Err(err).
+				Msg("failed to create digest")
+			continue
+		}
+		if !digest.IsDue(s.now()) {
+			continue
+		}
+		if err := s.SendNotification(ctx, digest.NotificationID); err != nil {
+			s.logger.Warn().
+				Str("notification_id", digest.NotificationID.String()).
+				Err(err).
+				Msg("failed to send digest")
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// createDigest persists the digest notification for held, which share a
+// throttle key, and links the held notifications to it.
+func (s *Service) createDigest(ctx context.Context, held []*notification.Notification) (*notification.Notification, error) {
+	d := notification.NewDigest(held)
+	payload, err := json.Marshal(map[string]interface{}{"digest": d})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal digest: %w", err)
+	}
+
+	// The latest action supplies channel settings such as the webhook URL.
+	last := held[len(held)-1]
+	digest := notification.NewNotification(last.ActionID, last.Channel, last.Priority, d.Title(), d.Body(), payload)
+	digest.MaxRetries = s.retryPolicy(digest.Channel).MaxRetries
+	digest.SetTarget(last.TargetUserID, last.TargetGroup)
+	pref, err := s.targetPreference(ctx, digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPreference(digest, pref); err != nil {
+		return nil, err
+	}
+	if err := s.notificationRepo.Create(ctx, digest); err != nil {
+		return nil, fmt.Errorf("failed to create digest notification: %w", err)
+	}
+
+	for _, n := range held {
+		if err := n.MarkDigested(digest.NotificationID); err != nil {
+			return nil, fmt.Errorf("failed to mark notification %s digested: %w", n.NotificationID, err)
+		}
+		if err := s.notificationRepo.Update(ctx, n); err != nil {
+			return nil, fmt.Errorf("failed to persist digested notification %s: %w", n.NotificationID, err)
+		}
+	}
+
+	s.logger.Info().
+		Str("notification_id", digest.NotificationID.String()).
+		Str("throttle_key", *last.ThrottleKey).
+		Int("count", d.Count).
+		Msg("digest notification created")
+
+	return digest, nil
+}
@@ -0,0 +1,187 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	notificationMocks "github.com/execution-hub/execution-hub/internal/domain/notification/mocks"
+)
+
+func TestService_CreateFromActionThrottle(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	newAction := func(priority domainAction.Priority, cfg string) *domainAction.Action {
+		action := domainAction.NewAction(uuid.New(), 1, uuid.New(), domainAction.TypeNotify, json.RawMessage(cfg))
+		action.Priority = priority
+		return action
+	}
+	create := func(t *testing.T, service *Service, notificationRepo *notificationMocks.MockRepository, action *domainAction.Action) *notification.Notification {
+		notificationRepo.EXPECT().GetByActionID(ctx, action.ActionID).Return(nil, nil)
+		notificationRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+		n, err := service.CreateFromAction(ctx, action)
+		require.NoError(t, err)
+		return n
+	}
+	const key = "SSE|user:alice|HIGH"
+
+	t.Run("under the limit is delivered", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice","throttle":{"mode":"RATE_LIMIT","windowSeconds":60,"maxPerWindow":3}}`)
+		notificationRepo.EXPECT().CountByThrottleKey(ctx, key, now.Add(-time.Minute)).Return(2, nil)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, notification.StatusPending, n.Status)
+		assert.Equal(t, key, *n.ThrottleKey)
+	})
+
+	t.Run("rate limit suppresses", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice","throttle":{"mode":"RATE_LIMIT","windowSeconds":60,"maxPerWindow":3}}`)
+		notificationRepo.EXPECT().CountByThrottleKey(ctx, key, gomock.Any()).Return(3, nil)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, notification.StatusSuppressed, n.Status)
+	})
+
+	t.Run("digest starts a window", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice","throttle":{"mode":"DIGEST","windowSeconds":300}}`)
+		notificationRepo.EXPECT().CountByThrottleKey(ctx, key, gomock.Any()).Return(0, nil)
+		notificationRepo.EXPECT().ListHeld(ctx, key).Return(nil, nil)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, notification.StatusHeld, n.Status)
+		assert.Equal(t, now.Add(5*time.Minute), *n.HeldUntil)
+	})
+
+	t.Run("digest joins the open window", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice","throttle":{"mode":"DIGEST","windowSeconds":300}}`)
+		open := notification.NewNotification(uuid.New(), notification.ChannelSSE, notification.PriorityHigh, "Earlier", "Body", nil)
+		require.NoError(t, open.Hold(now.Add(time.Minute)))
+		notificationRepo.EXPECT().CountByThrottleKey(ctx, key, gomock.Any()).Return(0, nil)
+		notificationRepo.EXPECT().ListHeld(ctx, key).Return([]*notification.Notification{open}, nil)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, now.Add(time.Minute), *n.HeldUntil)
+	})
+
+	t.Run("critical bypasses", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		action := newAction(domainAction.PriorityCritical, `{"userId":"alice","throttle":{"mode":"DIGEST","windowSeconds":300}}`)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, notification.StatusPending, n.Status)
+		assert.Equal(t, "SSE|user:alice|CRITICAL", *n.ThrottleKey)
+	})
+
+	t.Run("target preference overrides action", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		service.now = func() time.Time { return now }
+		prefs := notificationMocks.NewMockPreferenceRepository(gomock.NewController(t))
+		service.SetPreferenceRepository(prefs)
+		action := newAction(domainAction.PriorityHigh, `{"userId":"alice","throttle":{"mode":"DIGEST","windowSeconds":300}}`)
+		prefs.EXPECT().Get(ctx, notification.TargetTypeUser, "alice").Return(&notification.Preference{
+			TargetType: notification.TargetTypeUser,
+			TargetID:   "alice",
+			Throttle:   &notification.ThrottleConfig{Mode: notification.ThrottleModeRateLimit, WindowSeconds: 60, MaxPerWindow: 10},
+		}, nil)
+		notificationRepo.EXPECT().CountByThrottleKey(ctx, key, now.Add(-time.Minute)).Return(1, nil)
+
+		n := create(t, service, notificationRepo, action)
+		assert.Equal(t, notification.StatusPending, n.Status)
+	})
+
+	t.Run("no throttle configured", func(t *testing.T) {
+		service, notificationRepo, _ := newTestService(t)
+		n := create(t, service, notificationRepo, newAction(domainAction.PriorityHigh, `{"userId":"alice"}`))
+		assert.Equal(t, notification.StatusPending, n.Status)
+		assert.Nil(t, n.ThrottleKey)
+	})
+}
+
+func TestService_ValidateActionConfigThrottle(t *testing.T) {
+	service, _, _ := newTestService(t)
+	assert.NoError(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"throttle":{"mode":"DIGEST","windowSeconds":60}}`)))
+	assert.ErrorContains(t, service.ValidateActionConfig(domainAction.TypeNotify, json.RawMessage(`{"throttle":{"mode":"DIGEST"}}`)), "invalid throttle: windowSeconds")
+}
+
+func TestService_ProcessDigests(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 5, 0, 0, time.UTC)
+	ctx := context.Background()
+	service, notificationRepo, _ := newTestService(t)
+	service.now = func() time.Time { return now }
+	sseHub := notificationMocks.NewMockSSEHub(gomock.NewController(t))
+	service.channels = NewRegistry()
+	require.NoError(t, service.RegisterChannel(notification.ChannelSSE, NewSSESender(sseHub)))
+
+	alice, ops := "alice", "role:OPERATOR"
+	hold := func(target *string, group *string, title string) *notification.Notification {
+		n := notification.NewNotification(uuid.New(), notification.ChannelSSE, notification.PriorityHigh, title, "Body", nil)
+		n.SetTarget(target, group)
+		key := notification.ThrottleKey(n)
+		n.ThrottleKey = &key
+		require.NoError(t, n.Hold(now))
+		return n
+	}
+	held := []*notification.Notification{
+		hold(&alice, nil, "Temperature high"),
+		hold(&alice, nil, "Temperature high"),
+		hold(nil, &ops, "Line stopped"),
+	}
+	notificationRepo.EXPECT().ListDueHeld(ctx, now, 100).Return(held, nil)
+
+	var digests []*notification.Notification
+	notificationRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, n *notification.Notification) error {
+			digests = append(digests, n)
+			return nil
+		}).Times(2)
+	notificationRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil).AnyTimes()
+	notificationRepo.EXPECT().GetByID(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, id uuid.UUID) (*notification.Notification, error) {
+			for _, d := range digests {
+				if d.NotificationID == id {
+					return d, nil
+				}
+			}
+			return nil, nil
+		}).Times(2)
+	notificationRepo.EXPECT().RecordAttempt(ctx, gomock.Any()).Return(nil).Times(2)
+	sseHub.EXPECT().BroadcastToUser("alice", gomock.Any())
+	sseHub.EXPECT().BroadcastToGroup("role:OPERATOR", gomock.Any())
+
+	sent, err := service.ProcessDigests(ctx, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	require.Len(t, digests, 2)
+	assert.Equal(t, "2 HIGH notifications", digests[0].Title)
+	assert.Equal(t, "2× Temperature high", digests[0].Body)
+	assert.Equal(t, &alice, digests[0].TargetUserID)
+	assert.Nil(t, digests[0].ThrottleKey)
+	assert.Equal(t, notification.StatusDelivered, digests[0].Status)
+	var payload struct {
+		Digest notification.Digest `json:"digest"`
+	}
+	require.NoError(t, json.Unmarshal(digests[0].Payload, &payload))
+	assert.Equal(t, 2, payload.Digest.Count)
+
+	for i, n := range held {
+		assert.Equal(t, notification.StatusDigested, n.Status)
+		assert.Equal(t, digests[i/2].NotificationID, *n.DigestID)
+	}
+}
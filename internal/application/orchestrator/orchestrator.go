@@ -346,15 +346,15 @@ func (o *Orchestrator) dispatchStep(ctx context.Context, t *task.Task, step *tas
 		}
 		return o.resolveStep(ctx, step, "agent", output)
 	case domainAction.TypeNotify, domainAction.TypeWebhook, domainAction.TypeEscalate:
-		n, err := o.notifySvc.CreateFromAction(ctx, action)
+		n, err := o.notifySvc.CreateFromWorkflowAction(ctx, action, t.WorkflowID)
 		if err != nil {
 			return err
 		}
-		if n.Status != notification.StatusPending {
+		if n.Status != notification.StatusPending || !n.IsDue(time.Now()) {
 			o.logger.Info().
 				Str("notification_id", n.NotificationID.String()).
 				Str("status", string(n.Status)).
-				Msg("skip send for non-pending notification")
+				Msg("skip send for non-pending or deferred notification")
 			return nil
 		}
 		_ = o.notifySvc.SendNotification(ctx, n.NotificationID)
@@ -445,8 +445,14 @@ func (o *Orchestrator) handleOnFail(ctx context.Context, step *task.Step) {
 	if step.TraceID != "" {
 		tracePtr = &step.TraceID
 	}
+	// The workflow lets targets mute its escalations; without it the
+	// escalation is still raised.
+	var workflowID *uuid.UUID
+	if t, err := o.taskRepo.GetByID(ctx, step.TaskID); err == nil && t != nil {
+		workflowID = &t.WorkflowID
+	}
 
-	n, err := o.notifySvc.CreateEscalation(ctx, step.ActionID, channel, cfg.Title, cfg.Body, targetUser, targetGroup, tracePtr)
+	n, err := o.notifySvc.CreateEscalation(ctx, step.ActionID, channel, cfg.Title, cfg.Body, targetUser, targetGroup, workflowID, tracePtr)
 	if err != nil {
 		o.logger.Warn().Err(err).Str("step_id", step.StepID.String()).Msg("failed to create escalation notification")
 		return
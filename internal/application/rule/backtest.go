@@ -0,0 +1,353 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+const (
+	defaultBacktestSample = 10
+	maxBacktestSample     = 100
+	backtestPageSize      = 1000
+	// maxBacktestEvents bounds one replay; results over larger ranges are
+	// marked truncated.
+	maxBacktestEvents = 200000
+)
+
+// BacktestInput selects the rule version and event range to replay
+type BacktestInput struct {
+	RuleID   uuid.UUID
+	Version  int
+	Since    time.Time
+	Until    time.Time
+	SourceID *string
+	// SampleSize caps the evidence samples and would-be actions returned.
+	SampleSize int
+}
+
+// BacktestMatch is one evaluation the rule would have recorded
+type BacktestMatch struct {
+	At       time.Time       `json:"at"`
+	EventIDs []uuid.UUID     `json:"eventIds"`
+	Evidence json.RawMessage `json:"evidence"`
+}
+
+// WouldBeAction is an action the rule would have created
+type WouldBeAction struct {
+	At         time.Time             `json:"at"`
+	ActionType domainAction.Type     `json:"actionType"`
+	Priority   domainAction.Priority `json:"priority"`
+	DedupeKey  *string               `json:"dedupeKey,omitempty"`
+	EventIDs   []uuid.UUID           `json:"eventIds"`
+}
+
+// BacktestResult summarizes the replay of one rule version
+type BacktestResult struct {
+	RuleID            uuid.UUID             `json:"ruleId"`
+	Version           int                   `json:"version"`
+	Status            domainRule.RuleStatus `json:"status"`
+	EventsScanned     int                   `json:"eventsScanned"`
+	Matched           int                   `json:"matched"`
+	Actions           int                   `json:"actions"`
+	SuppressedActions int                   `json:"suppressedActions"`
+	Samples           []BacktestMatch       `json:"samples"`
+	WouldBeActions    []WouldBeAction       `json:"wouldBeActions"`
+
+	// triggers holds the last event of every match, in match order, for
+	// diffing.
+	triggers     map[uuid.UUID]bool
+	triggerOrder []uuid.UUID
+}
+
+// BacktestDiff compares a candidate version with the active version.
+// Matches are keyed by the event that triggered them.
+type BacktestDiff struct {
+	MatchedDelta  int         `json:"matchedDelta"`
+	ActionsDelta  int         `json:"actionsDelta"`
+	OnlyCandidate int         `json:"onlyCandidate"`
+	OnlyActive    int         `json:"onlyActive"`
+	Both          int         `json:"both"`
+	SampleNewIDs  []uuid.UUID `json:"sampleNewEventIds,omitempty"`
+	SampleGoneIDs []uuid.UUID `json:"sampleGoneEventIds,omitempty"`
+}
+
+// BacktestReport is the result of a backtest
+type BacktestReport struct {
+	Since     time.Time       `json:"since"`
+	Until     time.Time       `json:"until"`
+	Truncated bool            `json:"truncated"`
+	Candidate *BacktestResult `json:"candidate"`
+	Active    *BacktestResult `json:"active,omitempty"`
+	Diff      *BacktestDiff   `json:"diff,omitempty"`
+}
+
+// Backtest replays stored hash-chained events through a rule version and,
+// when a different version is active, through the active version as well.
+// Nothing is persisted: evaluations, actions and cooldowns are simulated.
+// Status and effective windows are ignored so unreleased versions can be
+// tested; scope filters still apply.
+func (s *Service) Backtest(ctx context.Context, input BacktestInput) (*BacktestReport, error) {
+	if input.RuleID == uuid.Nil {
+		return nil, errors.New("ruleId is required")
+	}
+	if input.Since.IsZero() || input.Until.IsZero() || !input.Since.Before(input.Until) {
+		return nil, errors.New("since must be before until")
+	}
+	sample := input.SampleSize
+	if sample <= 0 {
+		sample = defaultBacktestSample
+	}
+	if sample > maxBacktestSample {
+		sample = maxBacktestSample
+	}
+
+	var candidate *domainRule.Rule
+	var err error
+	if input.Version > 0 {
+		candidate, err = s.ruleRepo.GetByRuleIDAndVersion(ctx, input.RuleID, input.Version)
+	} else {
+		candidate, err = s.ruleRepo.GetByRuleID(ctx, input.RuleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+	if candidate == nil {
+		return nil, fmt.Errorf("rule not found: %s", input.RuleID)
+	}
+	active, err := s.activeVersion(ctx, input.RuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []*replay{}
+	candidateRun, err := newReplay(candidate, sample)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule config: %w", err)
+	}
+	runs = append(runs, candidateRun)
+	var activeRun *replay
+	if active != nil && active.Version != candidate.Version {
+		if activeRun, err = newReplay(active, sample); err != nil {
+			s.logger.Warn().Err(err).
+				Str("rule_id", active.RuleID.String()).
+				Int("version", active.Version).
+				Msg("active version has invalid config; skipping diff")
+			activeRun = nil
+		} else {
+			runs = append(runs, activeRun)
+		}
+	}
+
+	report := &BacktestReport{Since: input.Since, Until: input.Until}
+	filter := trust.EventRangeFilter{Since: input.Since, Until: input.Until, SourceID: input.SourceID}
+	scanned := 0
+	for {
+		events, err := s.trustRepo.ListChainedEvents(ctx, filter, backtestPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		for i := range events {
+			if scanned == maxBacktestEvents {
+				report.Truncated = true
+				break
+			}
+			scanned++
+			event := &events[i]
+			payload := decodePayload(event.Payload)
+			for _, run := range runs {
+				if err := run.observe(event, payload); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if report.Truncated || len(events) < backtestPageSize {
+			break
+		}
+		last := events[len(events)-1]
+		filter.After = &trust.EventCursor{TsServer: last.TsServer, EventID: last.EventID}
+	}
+
+	end := input.Until
+	for _, run := range runs {
+		if err := run.finish(end); err != nil {
+			return nil, err
+		}
+		run.result.EventsScanned = scanned
+	}
+
+	report.Candidate = candidateRun.result
+	if activeRun != nil {
+		report.Active = activeRun.result
+		report.Diff = diffResults(candidateRun.result, activeRun.result, sample)
+	}
+	return report, nil
+}
+
+// activeVersion returns the newest active version of ruleID, if any.
+func (s *Service) activeVersion(ctx context.Context, ruleID uuid.UUID) (*domainRule.Rule, error) {
+	versions, err := s.ruleRepo.ListVersions(ctx, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rule versions: %w", err)
+	}
+	var active *domainRule.Rule
+	for _, version := range versions {
+		if version.Status != domainRule.RuleStatusActive {
+			continue
+		}
+		if active == nil || version.Version > active.Version {
+			active = version
+		}
+	}
+	return active, nil
+}
+
+// replay runs one rule version over the event stream with simulated
+// dedupe and cooldown.
+type replay struct {
+	rule     *domainRule.Rule
+	state    *ruleState
+	dedupe   *domainRule.DedupeConfig
+	priority domainAction.Priority
+	sample   int
+	// cooldownUntil is the event time until which the dedupe key is held.
+	cooldownUntil time.Time
+	result        *BacktestResult
+}
+
+func newReplay(r *domainRule.Rule, sample int) (*replay, error) {
+	st, err := newRuleState(r)
+	if err != nil {
+		return nil, err
+	}
+	probe := domainAction.NewAction(r.RuleID, r.Version, uuid.Nil, domainAction.Type(r.ActionType), r.ActionConfig)
+	var actionCfg struct {
+		Severity string `json:"severity"`
+	}
+	if err := json.Unmarshal(r.ActionConfig, &actionCfg); err == nil {
+		probe.SetPriority(actionCfg.Severity)
+	}
+	return &replay{
+		rule:     r,
+		state:    st,
+		dedupe:   dedupeConfig(r),
+		priority: probe.Priority,
+		sample:   sample,
+		result: &BacktestResult{
+			RuleID:         r.RuleID,
+			Version:        r.Version,
+			Status:         r.Status,
+			Samples:        []BacktestMatch{},
+			WouldBeActions: []WouldBeAction{},
+			triggers:       map[uuid.UUID]bool{},
+		},
+	}, nil
+}
+
+func (p *replay) observe(event *trust.EventEvidence, payload map[string]interface{}) error {
+	// Disconnect gaps that closed before this event are detected first,
+	// as the periodic check would have.
+	for _, m := range p.state.checkDisconnects(event.TsServer) {
+		if err := p.record(event.TsServer, m); err != nil {
+			return err
+		}
+	}
+	if !inScope(p.rule, payload) || !admits(p.rule, event.TrustLevel) {
+		return nil
+	}
+	m, err := p.state.observe(event, payload)
+	if err != nil {
+		// The live engine logs and skips evaluation errors; so does the replay.
+		return nil
+	}
+	if m == nil {
+		return nil
+	}
+	return p.record(event.TsServer, *m)
+}
+
+func (p *replay) finish(end time.Time) error {
+	for _, m := range p.state.checkDisconnects(end) {
+		if err := p.record(end, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *replay) record(at time.Time, m match) error {
+	evidence, err := json.Marshal(m.evidence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal evidence: %w", err)
+	}
+	res := p.result
+	res.Matched++
+	if len(m.eventIDs) > 0 {
+		trigger := m.eventIDs[len(m.eventIDs)-1]
+		if !res.triggers[trigger] {
+			res.triggers[trigger] = true
+			res.triggerOrder = append(res.triggerOrder, trigger)
+		}
+	}
+	if len(res.Samples) < p.sample {
+		res.Samples = append(res.Samples, BacktestMatch{At: at, EventIDs: m.eventIDs, Evidence: evidence})
+	}
+
+	if p.dedupe != nil {
+		if at.Before(p.cooldownUntil) {
+			res.SuppressedActions++
+			return nil
+		}
+		p.cooldownUntil = at.Add(time.Duration(p.dedupe.CooldownSeconds) * time.Second)
+	}
+	res.Actions++
+	if len(res.WouldBeActions) < p.sample {
+		action := WouldBeAction{
+			At:         at,
+			ActionType: domainAction.Type(p.rule.ActionType),
+			Priority:   p.priority,
+			EventIDs:   m.eventIDs,
+		}
+		if p.dedupe != nil {
+			key := p.dedupe.Key
+			action.DedupeKey = &key
+		}
+		res.WouldBeActions = append(res.WouldBeActions, action)
+	}
+	return nil
+}
+
+func diffResults(candidate, active *BacktestResult, sample int) *BacktestDiff {
+	diff := &BacktestDiff{
+		MatchedDelta: candidate.Matched - active.Matched,
+		ActionsDelta: candidate.Actions - active.Actions,
+	}
+	for _, id := range candidate.triggerOrder {
+		if active.triggers[id] {
+			diff.Both++
+			continue
+		}
+		diff.OnlyCandidate++
+		if len(diff.SampleNewIDs) < sample {
+			diff.SampleNewIDs = append(diff.SampleNewIDs, id)
+		}
+	}
+	for _, id := range active.triggerOrder {
+		if candidate.triggers[id] {
+			continue
+		}
+		diff.OnlyActive++
+		if len(diff.SampleGoneIDs) < sample {
+			diff.SampleGoneIDs = append(diff.SampleGoneIDs, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,116 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	ruleMocks "github.com/execution-hub/execution-hub/internal/domain/rule/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// fakeEventStore serves ListChainedEvents from memory; other methods panic.
+type fakeEventStore struct {
+	trust.Repository
+	events []trust.EventEvidence
+}
+
+func (f *fakeEventStore) ListChainedEvents(_ context.Context, filter trust.EventRangeFilter, limit int) ([]trust.EventEvidence, error) {
+	out := []trust.EventEvidence{}
+	for _, event := range f.events {
+		if event.TsServer.Before(filter.Since) || !event.TsServer.Before(filter.Until) {
+			continue
+		}
+		if filter.After != nil && !event.TsServer.After(filter.After.TsServer) {
+			continue
+		}
+		if len(out) == limit {
+			break
+		}
+		out = append(out, event)
+	}
+	return out, nil
+}
+
+func TestService_Backtest(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	v1 := testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80}`, `{"severity":"MEDIUM"}`)
+	v1.ID = 1
+	v2 := v1.CreateNewVersion()
+	v2.ID = 2
+	v2.Status = domainRule.RuleStatusInactive
+	v2.Config = json.RawMessage(`{"field":"temperature","operator":">","threshold":90}`)
+	v2.ActionConfig = json.RawMessage(`{"severity":"CRITICAL","dedupe":{"key":"hot","cooldownSeconds":60}}`)
+
+	store := &fakeEventStore{}
+	for i, reading := range []struct {
+		offset time.Duration
+		temp   float64
+	}{{0, 85}, {10 * time.Second, 95}, {20 * time.Second, 97}, {2 * time.Minute, 99}, {3 * time.Minute, 50}} {
+		store.events = append(store.events, *testEvent(base.Add(reading.offset), "SENSOR_READING", fmt.Sprintf(`{"temperature":%v,"seq":%d}`, reading.temp, i)))
+	}
+
+	ctrl := gomock.NewController(t)
+	ruleRepo := ruleMocks.NewMockRepository(ctrl)
+	ruleRepo.EXPECT().GetByRuleIDAndVersion(ctx, v1.RuleID, 2).Return(v2, nil)
+	ruleRepo.EXPECT().ListVersions(ctx, v1.RuleID).Return([]*domainRule.Rule{v2, v1}, nil)
+	svc := NewService(ruleRepo, store, zerolog.Nop())
+
+	report, err := svc.Backtest(ctx, BacktestInput{
+		RuleID:  v1.RuleID,
+		Version: 2,
+		Since:   base,
+		Until:   base.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	candidate := report.Candidate
+	assert.Equal(t, 2, candidate.Version)
+	assert.Equal(t, 5, candidate.EventsScanned)
+	assert.Equal(t, 3, candidate.Matched)
+	assert.Equal(t, 2, candidate.Actions)
+	assert.Equal(t, 1, candidate.SuppressedActions)
+	require.Len(t, candidate.WouldBeActions, 2)
+	assert.Equal(t, domainAction.PriorityCritical, candidate.WouldBeActions[0].Priority)
+	assert.Equal(t, base.Add(2*time.Minute), candidate.WouldBeActions[1].At)
+	require.Len(t, candidate.Samples, 3)
+	var evidence domainRule.ThresholdEvidence
+	require.NoError(t, json.Unmarshal(candidate.Samples[0].Evidence, &evidence))
+	assert.Equal(t, 95.0, evidence.ActualValue)
+
+	require.NotNil(t, report.Active)
+	assert.Equal(t, 1, report.Active.Version)
+	assert.Equal(t, 4, report.Active.Matched)
+	assert.Equal(t, 4, report.Active.Actions)
+
+	require.NotNil(t, report.Diff)
+	assert.Equal(t, -1, report.Diff.MatchedDelta)
+	assert.Equal(t, -2, report.Diff.ActionsDelta)
+	assert.Equal(t, 3, report.Diff.Both)
+	assert.Equal(t, 0, report.Diff.OnlyCandidate)
+	assert.Equal(t, []uuid.UUID{store.events[0].EventID}, report.Diff.SampleGoneIDs)
+}
+
+func TestService_BacktestValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	svc := NewService(ruleMocks.NewMockRepository(ctrl), &fakeEventStore{}, zerolog.Nop())
+	now := time.Now().UTC()
+
+	_, err := svc.Backtest(context.Background(), BacktestInput{RuleID: uuid.New(), Since: now, Until: now.Add(-time.Hour)})
+	assert.EqualError(t, err, "since must be before until")
+
+	_, err = svc.Backtest(context.Background(), BacktestInput{Since: now, Until: now.Add(time.Hour)})
+	assert.EqualError(t, err, "ruleId is required")
+}
@@ -0,0 +1,386 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// ActionCreator creates actions for matched evaluations.
+type ActionCreator interface {
+	CreateFromEvaluation(ctx context.Context, eval *domainRule.Evaluation, r *domainRule.Rule) (*domainAction.Action, error)
+}
+
+// Engine evaluates active rules against ingested trust events. It keeps
+// per-rule sliding windows in memory, persists an evaluation for every match
+// and creates an action unless the rule's dedupe key is cooling down.
+type Engine struct {
+	ruleRepo   domainRule.Repository
+	actionRepo domainAction.Repository
+	actions    ActionCreator
+	trust      TrustLookup
+	logger     zerolog.Logger
+	now        func() time.Time
+
+	mu sync.Mutex
+	// states is keyed by rule ID and version so a new version starts with
+	// empty windows.
+	states map[string]*ruleState
+	// cooldowns remembers dedupe keys fired by this engine until their
+	// cooldown ends, covering actions not yet visible to FindByDedupeKey.
+	cooldowns map[string]time.Time
+}
+
+// NewEngine creates a new rule engine
+func NewEngine(
+	ruleRepo domainRule.Repository,
+	actionRepo domainAction.Repository,
+	actions ActionCreator,
+	logger zerolog.Logger,
+) *Engine {
+	return &Engine{
+		ruleRepo:   ruleRepo,
+		actionRepo: actionRepo,
+		actions:    actions,
+		logger:     logger.With().Str("service", "rule_engine").Logger(),
+		now:        func() time.Time { return time.Now().UTC() },
+		states:     map[string]*ruleState{},
+		cooldowns:  map[string]time.Time{},
+	}
+}
+
+// SetTrustLookup sets where the engine reads event trust levels. Rules that
+// tag low-trust events need it to judge the older events of a match;
+// without it those count as low-trust.
+func (e *Engine) SetTrustLookup(lookup TrustLookup) {
+	e.trust = lookup
+}
+
+type firing struct {
+	rule  *domainRule.Rule
+	match match
+	event *trust.EventEvidence
+}
+
+// HandleEvents evaluates the events of one ingested batch. It implements
+// the trust service's EventListener so rules run on the ingest path; active
+// rules are loaded once for the whole batch.
+func (e *Engine) HandleEvents(ctx context.Context, events []*trust.EventEvidence) error {
+	if len(events) == 0 {
+		return nil
+	}
+	rules, err := e.listActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, event := range events {
+		err := e.evaluate(ctx, rules, event, func(r *domainRule.Rule) bool {
+			return admits(r, event.TrustLevel)
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HandleEvent evaluates a single ingested event.
+func (e *Engine) HandleEvent(ctx context.Context, event *trust.EventEvidence) error {
+	return e.HandleEvents(ctx, []*trust.EventEvidence{event})
+}
+
+// evaluate feeds event into the rules admit accepts among the newest
+// effective versions of rules and fires the resulting matches.
+func (e *Engine) evaluate(ctx context.Context, rules []*domainRule.Rule, event *trust.EventEvidence, admit func(*domainRule.Rule) bool) error {
+	at := event.TsServer
+	if at.IsZero() {
+		at = e.now()
+	}
+	rules = e.effectiveRules(rules, at)
+	payload := decodePayload(event.Payload)
+
+	var firings []firing
+	e.mu.Lock()
+	for _, r := range rules {
+		if !inScope(r, payload) || !admit(r) {
+			continue
+		}
+		st := e.stateLocked(r)
+		if st == nil {
+			continue
+		}
+		m, err := st.observe(event, payload)
+		if err != nil {
+			e.logger.Warn().Err(err).
+				Str("rule_id", r.RuleID.String()).
+				Str("event_id", event.EventID.String()).
+				Msg("rule evaluation failed")
+			continue
+		}
+		if m != nil {
+			firings = append(firings, firing{rule: r, match: *m, event: event})
+		}
+	}
+	e.mu.Unlock()
+
+	return e.fire(ctx, firings)
+}
+
+// CheckDisconnects evaluates STREAM_DISCONNECT rules against the current time.
+func (e *Engine) CheckDisconnects(ctx context.Context) error {
+	now := e.now()
+	rules, err := e.listActiveRules(ctx)
+	if err != nil {
+		return err
+	}
+	rules = e.effectiveRules(rules, now)
+	var firings []firing
+	e.mu.Lock()
+	for _, r := range rules {
+		if r.RuleType != domainRule.RuleTypeStreamDisconnect {
+			continue
+		}
+		if st := e.stateLocked(r); st != nil {
+			for _, m := range st.checkDisconnects(now) {
+				firings = append(firings, firing{rule: r, match: m})
+			}
+		}
+	}
+	e.mu.Unlock()
+	return e.fire(ctx, firings)
+}
+
+// Run checks stream disconnects every interval until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.CheckDisconnects(ctx); err != nil {
+				e.logger.Error().Err(err).Msg("stream disconnect check failed")
+			}
+		}
+	}
+}
+
+// listActiveRules loads every version of the active rules.
+func (e *Engine) listActiveRules(ctx context.Context) ([]*domainRule.Rule, error) {
+	status := domainRule.RuleStatusActive
+	rules, err := e.ruleRepo.ListActiveRules(ctx, domainRule.Filter{Status: &status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active rules: %w", err)
+	}
+	return rules, nil
+}
+
+// effectiveRules returns the newest version of each rule effective at at and
+// drops window state for versions that are no longer active.
+func (e *Engine) effectiveRules(rules []*domainRule.Rule, at time.Time) []*domainRule.Rule {
+	latest := make(map[string]*domainRule.Rule, len(rules))
+	order := make([]string, 0, len(rules))
+	for _, r := range rules {
+		if !r.IsEffective(at) {
+			continue
+		}
+		id := r.RuleID.String()
+		current, ok := latest[id]
+		if !ok {
+			order = append(order, id)
+		}
+		if !ok || r.Version > current.Version {
+			latest[id] = r
+		}
+	}
+	out := make([]*domainRule.Rule, 0, len(order))
+	keep := make(map[string]bool, len(order))
+	for _, id := range order {
+		out = append(out, latest[id])
+		keep[stateKey(latest[id])] = true
+	}
+
+	e.mu.Lock()
+	for key := range e.states {
+		if !keep[key] {
+			delete(e.states, key)
+		}
+	}
+	e.mu.Unlock()
+	return out
+}
+
+func (e *Engine) stateLocked(r *domainRule.Rule) *ruleState {
+	key := stateKey(r)
+	if st, ok := e.states[key]; ok {
+		return st
+	}
+	st, err := newRuleState(r)
+	if err != nil {
+		e.logger.Warn().Err(err).
+			Str("rule_id", r.RuleID.String()).
+			Int("version", r.Version).
+			Msg("skipping rule with invalid config")
+		return nil
+	}
+	e.states[key] = st
+	return st
+}
+
+func (e *Engine) fire(ctx context.Context, firings []firing) error {
+	var errs []error
+	for _, f := range firings {
+		if err := e.record(ctx, f); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// record persists the evaluation and creates the rule's action. The action
+// of an evaluation tagged with low-trust events waits for them.
+func (e *Engine) record(ctx context.Context, f firing) error {
+	r, m := f.rule, f.match
+	evidence, err := json.Marshal(m.evidence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal evidence: %w", err)
+	}
+	eval := domainRule.NewEvaluation(r, true, evidence, m.eventIDs)
+	if r.TagsLowTrust() {
+		var known map[uuid.UUID]trust.TrustLevel
+		if f.event != nil {
+			known = map[uuid.UUID]trust.TrustLevel{f.event.EventID: f.event.TrustLevel}
+		}
+		lowTrust, err := e.lowTrustEvents(ctx, r, m.eventIDs, known)
+		if err != nil {
+			return err
+		}
+		if len(lowTrust) > 0 {
+			eval.TagLowTrust(lowTrust)
+		}
+	}
+	if err := e.ruleRepo.CreateEvaluation(ctx, eval); err != nil {
+		return fmt.Errorf("failed to create evaluation: %w", err)
+	}
+	if eval.TrustStatus == domainRule.TrustStatusPending {
+		e.logger.Info().
+			Str("rule_id", r.RuleID.String()).
+			Str("evaluation_id", eval.EvaluationID.String()).
+			Int("low_trust_events", len(eval.LowTrustEventIDs)).
+			Msg("action held until events are trusted")
+		return nil
+	}
+	return e.act(ctx, r, eval)
+}
+
+// act creates the action of a matched evaluation unless its dedupe key is
+// cooling down.
+func (e *Engine) act(ctx context.Context, r *domainRule.Rule, eval *domainRule.Evaluation) error {
+	dedupe := dedupeConfig(r)
+	if dedupe != nil {
+		suppressed, err := e.cooling(ctx, dedupe)
+		if err != nil {
+			return err
+		}
+		if suppressed {
+			e.logger.Debug().
+				Str("rule_id", r.RuleID.String()).
+				Str("evaluation_id", eval.EvaluationID.String()).
+				Str("dedupe_key", dedupe.Key).
+				Msg("action suppressed by cooldown")
+			return nil
+		}
+	}
+
+	if _, err := e.actions.CreateFromEvaluation(ctx, eval, r); err != nil {
+		if dedupe != nil {
+			e.mu.Lock()
+			delete(e.cooldowns, dedupe.Key)
+			e.mu.Unlock()
+		}
+		return fmt.Errorf("failed to create action: %w", err)
+	}
+	return nil
+}
+
+// cooling reports whether dedupe.Key fired within its cooldown. When it did
+// not, the key is reserved so concurrent matches do not create duplicates.
+func (e *Engine) cooling(ctx context.Context, dedupe *domainRule.DedupeConfig) (bool, error) {
+	now := e.now()
+	cooldown := time.Duration(dedupe.CooldownSeconds) * time.Second
+
+	e.mu.Lock()
+	if until, ok := e.cooldowns[dedupe.Key]; ok && now.Before(until) {
+		e.mu.Unlock()
+		return true, nil
+	}
+	e.cooldowns[dedupe.Key] = now.Add(cooldown)
+	e.mu.Unlock()
+
+	existing, err := e.actionRepo.FindByDedupeKey(ctx, dedupe.Key, now.Add(-cooldown))
+	if err != nil {
+		e.mu.Lock()
+		delete(e.cooldowns, dedupe.Key)
+		e.mu.Unlock()
+		return false, fmt.Errorf("failed to check dedupe key: %w", err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+	e.mu.Lock()
+	e.cooldowns[dedupe.Key] = existing.CreatedAt.Add(cooldown)
+	e.mu.Unlock()
+	return true, nil
+}
+
+func stateKey(r *domainRule.Rule) string {
+	return fmt.Sprintf("%s:%d", r.RuleID, r.Version)
+}
+
+// dedupeConfig returns the rule's dedupe settings when a cooldown applies.
+func dedupeConfig(r *domainRule.Rule) *domainRule.DedupeConfig {
+	var cfg struct {
+		Dedupe *domainRule.DedupeConfig `json:"dedupe,omitempty"`
+	}
+	if err := json.Unmarshal(r.ActionConfig, &cfg); err != nil {
+		return nil
+	}
+	if cfg.Dedupe == nil || cfg.Dedupe.Key == "" || cfg.Dedupe.CooldownSeconds <= 0 {
+		return nil
+	}
+	return cfg.Dedupe
+}
+
+// inScope matches the rule's factory and line scope against the event
+// payload's factoryId/lineId (or factory_id/line_id). Unscoped rules match
+// every event.
+func inScope(r *domainRule.Rule, payload map[string]interface{}) bool {
+	if r.ScopeFactoryID != nil && *r.ScopeFactoryID != "" && payloadString(payload, "factoryId", "factory_id") != *r.ScopeFactoryID {
+		return false
+	}
+	if r.ScopeLineID != nil && *r.ScopeLineID != "" && payloadString(payload, "lineId", "line_id") != *r.ScopeLineID {
+		return false
+	}
+	return true
+}
+
+func payloadString(payload map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := payload[key].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
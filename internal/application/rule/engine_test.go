@@ -0,0 +1,407 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	actionMocks "github.com/execution-hub/execution-hub/internal/domain/action/mocks"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	ruleMocks "github.com/execution-hub/execution-hub/internal/domain/rule/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+type fakeActions struct {
+	created []*domainRule.Evaluation
+}
+
+func (f *fakeActions) CreateFromEvaluation(_ context.Context, eval *domainRule.Evaluation, r *domainRule.Rule) (*domainAction.Action, error) {
+	f.created = append(f.created, eval)
+	return domainAction.NewAction(r.RuleID, r.Version, eval.EvaluationID, domainAction.Type(r.ActionType), r.ActionConfig), nil
+}
+
+func newTestEngine(t *testing.T, rules ...*domainRule.Rule) (*Engine, *ruleMocks.MockRepository, *actionMocks.MockRepository, *fakeActions, *[]*domainRule.Evaluation) {
+	ctrl := gomock.NewController(t)
+	ruleRepo := ruleMocks.NewMockRepository(ctrl)
+	actionRepo := actionMocks.NewMockRepository(ctrl)
+	actions := &fakeActions{}
+	evals := []*domainRule.Evaluation{}
+
+	ruleRepo.EXPECT().ListActiveRules(gomock.Any(), gomock.Any()).Return(rules, nil).AnyTimes()
+	ruleRepo.EXPECT().CreateEvaluation(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, eval *domainRule.Evaluation) error {
+			evals = append(evals, eval)
+			return nil
+		}).AnyTimes()
+
+	engine := NewEngine(ruleRepo, actionRepo, actions, zerolog.Nop())
+	return engine, ruleRepo, actionRepo, actions, &evals
+}
+
+func testRule(ruleType domainRule.RuleType, config, actionConfig string) *domainRule.Rule {
+	r := domainRule.NewRule("test", ruleType, json.RawMessage(config), domainRule.ActionTypeNotify, json.RawMessage(actionConfig))
+	r.EffectiveFrom = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return r
+}
+
+func testEvent(at time.Time, eventType, payload string) *trust.EventEvidence {
+	return &trust.EventEvidence{
+		EventID:   uuid.New(),
+		SourceID:  "gateway-001",
+		TsServer:  at,
+		EventType: eventType,
+		Payload:   json.RawMessage(payload),
+	}
+}
+
+func TestEngine_Threshold(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	factory := "factory-1"
+
+	r := testRule(domainRule.RuleTypeThreshold, `{"field":"metrics.temperature","operator":">","threshold":80,"eventType":"SENSOR_READING"}`, `{"severity":"HIGH"}`)
+	r.ScopeFactoryID = &factory
+	engine, _, _, actions, evals := newTestEngine(t, r)
+
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base, "SENSOR_READING", `{"factoryId":"factory-1","metrics":{"temperature":75}}`)))
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base, "OTHER", `{"factoryId":"factory-1","metrics":{"temperature":95}}`)))
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base, "SENSOR_READING", `{"factoryId":"factory-2","metrics":{"temperature":95}}`)))
+	assert.Empty(t, *evals)
+
+	event := testEvent(base, "SENSOR_READING", `{"factoryId":"factory-1","metrics":{"temperature":"92.5"}}`)
+	require.NoError(t, engine.HandleEvent(ctx, event))
+
+	require.Len(t, *evals, 1)
+	eval := (*evals)[0]
+	assert.True(t, eval.Matched)
+	assert.Equal(t, []uuid.UUID{event.EventID}, eval.EventIDs)
+	var evidence domainRule.ThresholdEvidence
+	require.NoError(t, json.Unmarshal(eval.Evidence, &evidence))
+	assert.Equal(t, 92.5, evidence.ActualValue)
+	assert.Equal(t, "metrics.temperature", evidence.Field)
+	assert.Len(t, actions.created, 1)
+}
+
+func TestEngine_HandleEventsLoadsRulesOncePerBatch(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeThreshold, `{"field":"metrics.temperature","operator":">","threshold":80}`, `{}`)
+	ctrl := gomock.NewController(t)
+	ruleRepo := ruleMocks.NewMockRepository(ctrl)
+	ruleRepo.EXPECT().ListActiveRules(gomock.Any(), gomock.Any()).Return([]*domainRule.Rule{r}, nil).Times(1)
+	evals := 0
+	ruleRepo.EXPECT().CreateEvaluation(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, *domainRule.Evaluation) error {
+			evals++
+			return nil
+		}).Times(2)
+	actions := &fakeActions{}
+	engine := NewEngine(ruleRepo, actionMocks.NewMockRepository(ctrl), actions, zerolog.Nop())
+
+	require.NoError(t, engine.HandleEvents(ctx, []*trust.EventEvidence{
+		testEvent(base, "SENSOR_READING", `{"metrics":{"temperature":95}}`),
+		testEvent(base, "SENSOR_READING", `{"metrics":{"temperature":70}}`),
+		testEvent(base, "SENSOR_READING", `{"metrics":{"temperature":85}}`),
+	}))
+	assert.Equal(t, 2, evals)
+	assert.Len(t, actions.created, 2)
+}
+
+func TestEngine_SkipsIneffectiveAndOlderVersions(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	future := testRule(domainRule.RuleTypeMissingField, `{"field":"operator"}`, `{}`)
+	future.EffectiveFrom = base.Add(time.Hour)
+
+	v1 := testRule(domainRule.RuleTypeMissingField, `{"field":"operator"}`, `{}`)
+	v2 := v1.CreateNewVersion()
+	v2.EffectiveFrom = v1.EffectiveFrom
+	v2.Config = json.RawMessage(`{"field":"shift"}`)
+
+	engine, _, _, _, evals := newTestEngine(t, future, v1, v2)
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base, "SENSOR_READING", `{"operator":"alice"}`)))
+
+	require.Len(t, *evals, 1)
+	assert.Equal(t, v2.RuleID, (*evals)[0].RuleID)
+	assert.Equal(t, 2, (*evals)[0].RuleVersion)
+}
+
+func TestEngine_RepeatedSlidingWindow(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeRepeated, `{"field":"code","count":3,"windowSeconds":60}`, `{}`)
+	engine, _, _, actions, evals := newTestEngine(t, r)
+
+	for _, offset := range []time.Duration{0, 30 * time.Second, 70 * time.Second} {
+		require.NoError(t, engine.HandleEvent(ctx, testEvent(base.Add(offset), "ALARM", `{"code":"E42"}`)))
+	}
+	assert.Empty(t, *evals, "first event left the window before the third arrived")
+
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base.Add(80*time.Second), "ALARM", `{"code":"E7"}`)))
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base.Add(85*time.Second), "ALARM", `{"code":"E42"}`)))
+
+	require.Len(t, *evals, 1)
+	var evidence domainRule.RepeatedEvidence
+	require.NoError(t, json.Unmarshal((*evals)[0].Evidence, &evidence))
+	assert.Equal(t, "E42", evidence.Value)
+	assert.Equal(t, 3, evidence.Count)
+	assert.Equal(t, base.Add(30*time.Second), evidence.WindowStart)
+	assert.Len(t, evidence.EventIDs, 3)
+	assert.Len(t, actions.created, 1)
+
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base.Add(86*time.Second), "ALARM", `{"code":"E42"}`)))
+	assert.Len(t, *evals, 1, "window resets after firing")
+}
+
+func TestEngine_TimeWindowCondition(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeTimeWindow, `{"windowSize":"5m","condition":"event_count >= 4 && failure_rate > 0.5","eventType":"JOB"}`, `{}`)
+	engine, _, _, _, evals := newTestEngine(t, r)
+
+	payloads := []string{`{"status":"FAILED"}`, `{"success":true}`, `{"status":"ERROR"}`, `{"success":false}`}
+	for i, payload := range payloads {
+		require.NoError(t, engine.HandleEvent(ctx, testEvent(base.Add(time.Duration(i)*time.Minute), "JOB", payload)))
+	}
+
+	require.Len(t, *evals, 1)
+	var evidence domainRule.TimeWindowEvidence
+	require.NoError(t, json.Unmarshal((*evals)[0].Evidence, &evidence))
+	assert.Equal(t, 4, evidence.EventCount)
+	assert.Equal(t, 3, evidence.FailureCount)
+	assert.InDelta(t, 0.75, evidence.FailureRate, 1e-9)
+	assert.Len(t, evidence.EventSample, 4)
+}
+
+func TestEngine_DedupeCooldown(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeMissingField, `{"field":"operator"}`, `{"dedupe":{"key":"missing-operator","cooldownSeconds":300}}`)
+	engine, _, actionRepo, actions, evals := newTestEngine(t, r)
+	now := base
+	engine.now = func() time.Time { return now }
+
+	actionRepo.EXPECT().FindByDedupeKey(gomock.Any(), "missing-operator", base.Add(-5*time.Minute)).Return(nil, nil)
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(base, "JOB", `{}`)))
+
+	now = base.Add(time.Minute)
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(now, "JOB", `{}`)))
+
+	assert.Len(t, *evals, 2, "evaluations are recorded even when the action is suppressed")
+	assert.Len(t, actions.created, 1)
+
+	now = base.Add(10 * time.Minute)
+	existing := domainAction.NewAction(r.RuleID, r.Version, uuid.New(), domainAction.TypeNotify, nil)
+	existing.CreatedAt = now.Add(-time.Minute)
+	actionRepo.EXPECT().FindByDedupeKey(gomock.Any(), "missing-operator", now.Add(-5*time.Minute)).Return(existing, nil)
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(now, "JOB", `{}`)))
+
+	assert.Len(t, *evals, 3)
+	assert.Len(t, actions.created, 1, "an action created elsewhere also holds the cooldown")
+}
+
+func TestEngine_StreamDisconnect(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeStreamDisconnect, `{"sourceId":"gateway-001","timeoutSeconds":60}`, `{}`)
+	engine, _, _, actions, evals := newTestEngine(t, r)
+	now := base
+	engine.now = func() time.Time { return now }
+
+	event := testEvent(base, "HEARTBEAT", `{}`)
+	require.NoError(t, engine.HandleEvent(ctx, event))
+
+	now = base.Add(30 * time.Second)
+	require.NoError(t, engine.CheckDisconnects(ctx))
+	assert.Empty(t, *evals)
+
+	now = base.Add(90 * time.Second)
+	require.NoError(t, engine.CheckDisconnects(ctx))
+	require.NoError(t, engine.CheckDisconnects(ctx))
+
+	require.Len(t, *evals, 1, "a gap fires once")
+	var evidence domainRule.StreamDisconnectEvidence
+	require.NoError(t, json.Unmarshal((*evals)[0].Evidence, &evidence))
+	assert.Equal(t, "gateway-001", evidence.SourceID)
+	assert.Equal(t, 90.0, evidence.ActualGapSecs)
+	assert.Equal(t, []uuid.UUID{event.EventID}, (*evals)[0].EventIDs)
+	assert.Len(t, actions.created, 1)
+
+	require.NoError(t, engine.HandleEvent(ctx, testEvent(now, "HEARTBEAT", `{}`)))
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, engine.CheckDisconnects(ctx))
+	assert.Len(t, *evals, 2, "a new event re-arms the rule")
+}
+
+func TestEngine_Expression(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeExpression, `{
+		"expression": "[metrics.temperature] > 80 && avg('metrics.temperature') > 70 && distinct('operator') >= 2",
+		"fields": ["metrics.temperature", "operator"],
+		"window": "10m",
+		"groupBy": "source",
+		"eventType": "SENSOR_READING"
+	}`, `{}`)
+	engine, _, _, _, evals := newTestEngine(t, r)
+
+	emit := func(offset time.Duration, source, payload string) {
+		event := testEvent(base.Add(offset), "SENSOR_READING", payload)
+		event.SourceID = source
+		require.NoError(t, engine.HandleEvent(ctx, event))
+	}
+	emit(0, "gw-a", `{"metrics":{"temperature":60},"operator":"alice"}`)
+	emit(time.Minute, "gw-b", `{"metrics":{"temperature":95},"operator":"bob"}`)
+	assert.Empty(t, *evals, "gw-b has a single operator in its window")
+	emit(2*time.Minute, "gw-a", `{"metrics":{"temperature":90}}`)
+	assert.Empty(t, *evals, "events missing a referenced field are skipped")
+	emit(3*time.Minute, "gw-a", `{"metrics":{"temperature":91},"operator":"carol"}`)
+
+	require.Len(t, *evals, 1)
+	var evidence domainRule.ExpressionEvidence
+	require.NoError(t, json.Unmarshal((*evals)[0].Evidence, &evidence))
+	assert.Equal(t, "gw-a", evidence.Group)
+	assert.Equal(t, 91.0, evidence.Values["metrics.temperature"])
+	assert.InDelta(t, 80.333, evidence.Aggregates["avg(metrics.temperature)"], 0.001)
+	assert.Equal(t, 2.0, evidence.Aggregates["distinct(operator)"])
+	assert.Len(t, evidence.EventSample, 3)
+}
+
+func TestEngine_TrustGateSkipsUntilVerified(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80}`, `{}`)
+	r.MinTrustLevel = int(trust.TrustLevelT3)
+	open := testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80}`, `{}`)
+	engine, ruleRepo, _, actions, evals := newTestEngine(t, r, open)
+	ruleRepo.EXPECT().ListPendingEvaluations(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	event := testEvent(base, "SENSOR_READING", `{"temperature":95}`)
+	event.TrustLevel = trust.TrustLevelT2
+	require.NoError(t, engine.HandleEvent(ctx, event))
+	require.Len(t, *evals, 1, "only the ungated rule sees the chained event")
+	assert.Equal(t, open.RuleID, (*evals)[0].RuleID)
+
+	verified := *event
+	verified.TrustLevel = trust.TrustLevelT3
+	batch := &trust.BatchVerification{
+		Batch:          &trust.BatchSignature{BatchID: uuid.New(), EventIDs: []uuid.UUID{event.EventID}},
+		Verified:       true,
+		Events:         []trust.EventEvidence{verified},
+		PreviousLevels: map[uuid.UUID]trust.TrustLevel{event.EventID: trust.TrustLevelT2},
+	}
+	require.NoError(t, engine.HandleBatchVerification(ctx, batch))
+	require.Len(t, *evals, 2, "the verified event reaches the gated rule only")
+	assert.Equal(t, r.RuleID, (*evals)[1].RuleID)
+	assert.Empty(t, (*evals)[1].TrustStatus)
+	assert.Len(t, actions.created, 2)
+
+	batch.PreviousLevels[event.EventID] = trust.TrustLevelT3
+	require.NoError(t, engine.HandleBatchVerification(ctx, batch), "re-verified events are not evaluated twice")
+	assert.Len(t, *evals, 2)
+}
+
+func TestEngine_BatchesVerifiedOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeRepeated, `{"field":"code","count":3,"windowSeconds":60}`, `{}`)
+	r.MinTrustLevel = int(trust.TrustLevelT3)
+	engine, ruleRepo, _, _, evals := newTestEngine(t, r)
+	ruleRepo.EXPECT().ListPendingEvaluations(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	verify := func(offsets ...time.Duration) {
+		t.Helper()
+		batch := &trust.BatchVerification{
+			Batch:          &trust.BatchSignature{BatchID: uuid.New()},
+			Verified:       true,
+			PreviousLevels: map[uuid.UUID]trust.TrustLevel{},
+		}
+		for _, offset := range offsets {
+			event := testEvent(base.Add(offset), "ALARM", `{"code":"E42"}`)
+			event.TrustLevel = trust.TrustLevelT3
+			batch.Batch.EventIDs = append(batch.Batch.EventIDs, event.EventID)
+			batch.Events = append(batch.Events, *event)
+			batch.PreviousLevels[event.EventID] = trust.TrustLevelT2
+		}
+		require.NoError(t, engine.HandleBatchVerification(ctx, batch))
+	}
+
+	// The later batch is verified first; the earlier one's events are more
+	// than a window older and must not join it.
+	verify(100 * time.Second)
+	verify(0, 10*time.Second)
+	assert.Empty(t, *evals)
+
+	for _, offset := range []time.Duration{120 * time.Second, 130 * time.Second} {
+		event := testEvent(base.Add(offset), "ALARM", `{"code":"E42"}`)
+		event.TrustLevel = trust.TrustLevelT3
+		require.NoError(t, engine.HandleEvent(ctx, event))
+	}
+	require.Len(t, *evals, 1)
+	var evidence domainRule.RepeatedEvidence
+	require.NoError(t, json.Unmarshal((*evals)[0].Evidence, &evidence))
+	assert.Equal(t, base.Add(100*time.Second), evidence.WindowStart)
+	assert.Equal(t, base.Add(130*time.Second), evidence.WindowEnd)
+}
+
+func TestEngine_TrustGateTagsAndHoldsAction(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	r := testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80}`, `{}`)
+	r.MinTrustLevel = int(trust.TrustLevelT3)
+	r.LowTrustAction = domainRule.LowTrustActionTag
+
+	for _, verified := range []bool{true, false} {
+		engine, ruleRepo, _, actions, evals := newTestEngine(t, r)
+		event := testEvent(base, "SENSOR_READING", `{"temperature":95}`)
+		event.TrustLevel = trust.TrustLevelT2
+		require.NoError(t, engine.HandleEvent(ctx, event))
+
+		require.Len(t, *evals, 1)
+		eval := (*evals)[0]
+		assert.Equal(t, domainRule.TrustStatusPending, eval.TrustStatus)
+		assert.Equal(t, []uuid.UUID{event.EventID}, eval.LowTrustEventIDs)
+		assert.Empty(t, actions.created, "the action waits for trusted events")
+
+		settled := *event
+		want := domainRule.TrustStatusRejected
+		if verified {
+			settled.TrustLevel = trust.TrustLevelT3
+			want = domainRule.TrustStatusTrusted
+		}
+		ruleRepo.EXPECT().ListPendingEvaluations(gomock.Any(), []uuid.UUID{event.EventID}).Return([]*domainRule.Evaluation{eval}, nil)
+		ruleRepo.EXPECT().GetByRuleIDAndVersion(gomock.Any(), r.RuleID, r.Version).Return(r, nil)
+		ruleRepo.EXPECT().ResolveEvaluationTrust(gomock.Any(), eval.EvaluationID, want).Return(true, nil)
+
+		require.NoError(t, engine.HandleBatchVerification(ctx, &trust.BatchVerification{
+			Batch:          &trust.BatchSignature{BatchID: uuid.New(), EventIDs: []uuid.UUID{event.EventID}},
+			Verified:       verified,
+			Events:         []trust.EventEvidence{settled},
+			PreviousLevels: map[uuid.UUID]trust.TrustLevel{event.EventID: trust.TrustLevelT2},
+		}))
+		assert.Len(t, *evals, 1, "held evaluations are resolved, not re-evaluated")
+		if verified {
+			assert.Len(t, actions.created, 1)
+		} else {
+			assert.Empty(t, actions.created)
+		}
+	}
+}
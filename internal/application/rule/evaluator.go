@@ -0,0 +1,457 @@
+package rule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/google/uuid"
+
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// maxEventSample caps the event IDs recorded as time window evidence.
+const maxEventSample = 10
+
+// match is a positive evaluation produced by a rule state.
+type match struct {
+	evidence interface{}
+	eventIDs []uuid.UUID
+}
+
+type windowEntry struct {
+	at      time.Time
+	eventID uuid.UUID
+	failed  bool
+}
+
+type sourceState struct {
+	lastEventAt time.Time
+	eventID     uuid.UUID
+	alerted     bool
+}
+
+// ruleState holds the parsed config and sliding-window state of one rule
+// version. It is not safe for concurrent use; the engine serializes access.
+type ruleState struct {
+	rule *domainRule.Rule
+
+	threshold    *domainRule.ThresholdConfig
+	repeated     *domainRule.RepeatedConfig
+	missingField *domainRule.MissingFieldConfig
+	disconnect   *domainRule.StreamDisconnectConfig
+	timeWindow   *domainRule.TimeWindowConfig
+	expression   *domainRule.ParsedExpression
+
+	window    time.Duration
+	condition *govaluate.EvaluableExpression
+
+	// buckets holds REPEATED windows keyed by field value and the single
+	// TIME_WINDOW window under "".
+	buckets map[string][]windowEntry
+	values  map[string]interface{}
+	sources map[string]*sourceState
+
+	// exprBuckets holds EXPRESSION windows keyed by group; scope is the
+	// window the aggregate functions read during one evaluation.
+	exprBuckets map[string][]exprEntry
+	scope       *aggregateScope
+}
+
+func newRuleState(r *domainRule.Rule) (*ruleState, error) {
+	st := &ruleState{
+		rule:        r,
+		buckets:     map[string][]windowEntry{},
+		values:      map[string]interface{}{},
+		sources:     map[string]*sourceState{},
+		exprBuckets: map[string][]exprEntry{},
+	}
+	switch r.RuleType {
+	case domainRule.RuleTypeThreshold:
+		var cfg domainRule.ThresholdConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid threshold config: %w", err)
+		}
+		if cfg.Field == "" {
+			return nil, errors.New("threshold field is required")
+		}
+		if _, err := compare(0, cfg.Operator, 0); err != nil {
+			return nil, err
+		}
+		st.threshold = &cfg
+	case domainRule.RuleTypeRepeated:
+		var cfg domainRule.RepeatedConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid repeated config: %w", err)
+		}
+		if cfg.Count <= 0 || cfg.WindowSeconds <= 0 {
+			return nil, errors.New("repeated count and windowSeconds must be positive")
+		}
+		st.repeated = &cfg
+		st.window = time.Duration(cfg.WindowSeconds) * time.Second
+	case domainRule.RuleTypeMissingField:
+		var cfg domainRule.MissingFieldConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid missing field config: %w", err)
+		}
+		if cfg.Field == "" {
+			return nil, errors.New("missing field rule requires field")
+		}
+		st.missingField = &cfg
+	case domainRule.RuleTypeStreamDisconnect:
+		var cfg domainRule.StreamDisconnectConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid stream disconnect config: %w", err)
+		}
+		if cfg.TimeoutSeconds <= 0 {
+			return nil, errors.New("stream disconnect timeoutSeconds must be positive")
+		}
+		st.disconnect = &cfg
+		st.window = time.Duration(cfg.TimeoutSeconds) * time.Second
+	case domainRule.RuleTypeTimeWindow:
+		var cfg domainRule.TimeWindowConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid time window config: %w", err)
+		}
+		window, err := time.ParseDuration(cfg.WindowSize)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid windowSize: %q", cfg.WindowSize)
+		}
+		expr, err := govaluate.NewEvaluableExpression(cfg.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition: %w", err)
+		}
+		st.timeWindow = &cfg
+		st.window = window
+		st.condition = expr
+	case domainRule.RuleTypeExpression:
+		var cfg domainRule.ExpressionConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return nil, fmt.Errorf("invalid expression config: %w", err)
+		}
+		parsed, err := domainRule.ParseExpressionConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := domainRule.CompileExpression(cfg.Expression, st.aggregateFunctions())
+		if err != nil {
+			return nil, err
+		}
+		st.expression = parsed
+		st.window = parsed.Window
+		st.condition = expr
+	default:
+		return nil, fmt.Errorf("unsupported rule type: %s", r.RuleType)
+	}
+	return st, nil
+}
+
+// observe feeds one event into the rule and returns a match if the rule
+// fired. Event time is the server timestamp so windows follow ingest order.
+func (st *ruleState) observe(event *trust.EventEvidence, payload map[string]interface{}) (*match, error) {
+	at := event.TsServer
+	switch {
+	case st.threshold != nil:
+		cfg := st.threshold
+		if !eventTypeMatches(cfg.EventType, event.EventType) {
+			return nil, nil
+		}
+		raw, ok := lookupField(payload, cfg.Field)
+		if !ok {
+			return nil, nil
+		}
+		actual, ok := toFloat(raw)
+		if !ok {
+			return nil, nil
+		}
+		matched, err := compare(actual, cfg.Operator, cfg.Threshold)
+		if err != nil || !matched {
+			return nil, err
+		}
+		return &match{
+			evidence: domainRule.ThresholdEvidence{
+				EventID:           event.EventID,
+				Field:             cfg.Field,
+				ActualValue:       actual,
+				ExpectedCondition: fmt.Sprintf("%s %s %v", cfg.Field, cfg.Operator, cfg.Threshold),
+				Matched:           true,
+			},
+			eventIDs: []uuid.UUID{event.EventID},
+		}, nil
+
+	case st.missingField != nil:
+		cfg := st.missingField
+		if !eventTypeMatches(cfg.EventType, event.EventType) {
+			return nil, nil
+		}
+		if _, ok := lookupField(payload, cfg.Field); ok {
+			return nil, nil
+		}
+		return &match{
+			evidence: domainRule.MissingFieldEvidence{
+				EventID:      event.EventID,
+				Field:        cfg.Field,
+				FieldPresent: false,
+				Matched:      true,
+			},
+			eventIDs: []uuid.UUID{event.EventID},
+		}, nil
+
+	case st.repeated != nil:
+		cfg := st.repeated
+		if !eventTypeMatches(cfg.EventType, event.EventType) {
+			return nil, nil
+		}
+		var value interface{}
+		key := ""
+		if cfg.Field != "" {
+			raw, ok := lookupField(payload, cfg.Field)
+			if !ok {
+				return nil, nil
+			}
+			value = raw
+			key = valueKey(raw)
+		}
+		entries, inWindow := insertInWindow(st.buckets[key], windowEntry{at: at, eventID: event.EventID}, windowEntryAt, st.window)
+		st.buckets[key] = entries
+		st.values[key] = value
+		if !inWindow || len(entries) < cfg.Count {
+			return nil, nil
+		}
+		// A burst fires once; the next match needs a fresh set of events.
+		delete(st.buckets, key)
+		delete(st.values, key)
+		return &match{
+			evidence: domainRule.RepeatedEvidence{
+				Field:       cfg.Field,
+				Value:       value,
+				Count:       len(entries),
+				Threshold:   cfg.Count,
+				WindowStart: entries[0].at,
+				WindowEnd:   entries[len(entries)-1].at,
+				EventIDs:    entryIDs(entries, 0),
+				Matched:     true,
+			},
+			eventIDs: entryIDs(entries, 0),
+		}, nil
+
+	case st.timeWindow != nil:
+		cfg := st.timeWindow
+		if !eventTypeMatches(cfg.EventType, event.EventType) {
+			return nil, nil
+		}
+		entries, inWindow := insertInWindow(st.buckets[""], windowEntry{at: at, eventID: event.EventID, failed: isFailure(payload)}, windowEntryAt, st.window)
+		st.buckets[""] = entries
+		if !inWindow {
+			return nil, nil
+		}
+		end := entries[len(entries)-1].at
+		failures := 0
+		for _, entry := range entries {
+			if entry.failed {
+				failures++
+			}
+		}
+		rate := float64(failures) / float64(len(entries))
+		result, err := st.condition.Evaluate(map[string]interface{}{
+			"event_count":   float64(len(entries)),
+			"failure_count": float64(failures),
+			"success_count": float64(len(entries) - failures),
+			"failure_rate":  rate,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate condition: %w", err)
+		}
+		matched, ok := result.(bool)
+		if !ok {
+			return nil, errors.New("condition did not evaluate to boolean")
+		}
+		if !matched {
+			return nil, nil
+		}
+		sample := entryIDs(entries, maxEventSample)
+		return &match{
+			evidence: domainRule.TimeWindowEvidence{
+				WindowStart:  end.Add(-st.window),
+				WindowEnd:    end,
+				EventCount:   len(entries),
+				FailureCount: failures,
+				FailureRate:  rate,
+				Condition:    cfg.Condition,
+				EventSample:  sample,
+				Matched:      true,
+			},
+			eventIDs: sample,
+		}, nil
+
+	case st.expression != nil:
+		return st.observeExpression(event, payload)
+
+	case st.disconnect != nil:
+		if st.disconnect.SourceID != "" && st.disconnect.SourceID != event.SourceID {
+			return nil, nil
+		}
+		st.sources[event.SourceID] = &sourceState{lastEventAt: at, eventID: event.EventID}
+	}
+	return nil, nil
+}
+
+// checkDisconnects reports sources that have been silent longer than the
+// configured timeout. Each gap fires once until the source sends again.
+func (st *ruleState) checkDisconnects(now time.Time) []match {
+	if st.disconnect == nil {
+		return nil
+	}
+	var out []match
+	for sourceID, src := range st.sources {
+		gap := now.Sub(src.lastEventAt)
+		if src.alerted || gap <= st.window {
+			continue
+		}
+		src.alerted = true
+		out = append(out, match{
+			evidence: domainRule.StreamDisconnectEvidence{
+				SourceID:       sourceID,
+				LastEventAt:    src.lastEventAt,
+				TimeoutSeconds: st.disconnect.TimeoutSeconds,
+				ActualGapSecs:  gap.Seconds(),
+				Matched:        true,
+			},
+			eventIDs: []uuid.UUID{src.eventID},
+		})
+	}
+	return out
+}
+
+func eventTypeMatches(want, got string) bool {
+	return want == "" || strings.EqualFold(want, got)
+}
+
+// insertInWindow adds entry to entries, which are in time order, and drops
+// the entries older than window before the newest one. Events re-evaluated
+// after batch verification can be older than entries already buffered, so
+// entries are inserted at their time rather than appended. inWindow is
+// false when entry itself is already outside the window.
+func insertInWindow[T any](entries []T, entry T, at func(T) time.Time, window time.Duration) (out []T, inWindow bool) {
+	i := len(entries)
+	for i > 0 && at(entries[i-1]).After(at(entry)) {
+		i--
+	}
+	entries = slices.Insert(entries, i, entry)
+	cutoff := at(entries[len(entries)-1]).Add(-window)
+	start := 0
+	for start < len(entries) && at(entries[start]).Before(cutoff) {
+		start++
+	}
+	return entries[start:], !at(entry).Before(cutoff)
+}
+
+func windowEntryAt(e windowEntry) time.Time { return e.at }
+
+// entryIDs returns event IDs, keeping the newest limit entries when limit > 0.
+func entryIDs(entries []windowEntry, limit int) []uuid.UUID {
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.eventID)
+	}
+	return ids
+}
+
+func decodePayload(raw json.RawMessage) map[string]interface{} {
+	payload := map[string]interface{}{}
+	if len(raw) == 0 {
+		return payload
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return map[string]interface{}{}
+	}
+	return payload
+}
+
+// lookupField resolves a dotted path such as "metrics.temperature".
+func lookupField(payload map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = payload
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func valueKey(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func compare(actual float64, operator string, threshold float64) (bool, error) {
+	switch operator {
+	case ">":
+		return actual > threshold, nil
+	case ">=":
+		return actual >= threshold, nil
+	case "<":
+		return actual < threshold, nil
+	case "<=":
+		return actual <= threshold, nil
+	case "==":
+		return actual == threshold, nil
+	case "!=":
+		return actual != threshold, nil
+	default:
+		return false, fmt.Errorf("unsupported operator: %q", operator)
+	}
+}
+
+// isFailure treats success=false or a failing status/result as a failure.
+func isFailure(payload map[string]interface{}) bool {
+	if success, ok := payload["success"].(bool); ok {
+		return !success
+	}
+	for _, field := range []string{"status", "result"} {
+		if value, ok := payload[field].(string); ok {
+			switch strings.ToUpper(value) {
+			case "FAIL", "FAILED", "FAILURE", "ERROR":
+				return true
+			}
+		}
+	}
+	return false
+}
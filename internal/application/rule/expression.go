@@ -0,0 +1,234 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/google/uuid"
+
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+type exprEntry struct {
+	at      time.Time
+	eventID uuid.UUID
+	values  map[string]interface{}
+}
+
+// aggregateScope is the window visible to aggregate functions while one
+// event is evaluated. Computed values are kept as evidence.
+type aggregateScope struct {
+	entries    []exprEntry
+	aggregates map[string]float64
+}
+
+// aggregateFunctions binds the expression functions to the current scope.
+func (st *ruleState) aggregateFunctions() map[string]govaluate.ExpressionFunction {
+	fns := make(map[string]govaluate.ExpressionFunction, len(domainRule.ExpressionFunctions))
+	for _, name := range domainRule.ExpressionFunctions {
+		name := name
+		fns[name] = func(args ...interface{}) (interface{}, error) {
+			call := domainRule.ExpressionCall{Function: name}
+			if len(args) > 0 {
+				field, ok := args[0].(string)
+				if !ok {
+					return nil, fmt.Errorf("%s() takes a quoted field name", name)
+				}
+				call.Field = field
+			}
+			if st.scope == nil {
+				return nil, errors.New("aggregate used outside a window")
+			}
+			value := aggregate(call, st.scope.entries, st.window)
+			if !math.IsNaN(value) {
+				st.scope.aggregates[call.Label()] = value
+			}
+			return value, nil
+		}
+	}
+	return fns
+}
+
+func aggregate(call domainRule.ExpressionCall, entries []exprEntry, window time.Duration) float64 {
+	switch call.Function {
+	case "count":
+		return float64(len(entries))
+	case "rate":
+		if call.Field == "" {
+			return float64(len(entries)) / window.Seconds()
+		}
+		if len(entries) == 0 {
+			return 0
+		}
+		hits := 0
+		for _, entry := range entries {
+			if v, ok := entry.values[call.Field].(bool); ok && v {
+				hits++
+			}
+		}
+		return float64(hits) / float64(len(entries))
+	case "distinct":
+		seen := map[string]bool{}
+		for _, entry := range entries {
+			if v, ok := entry.values[call.Field]; ok {
+				seen[valueKey(v)] = true
+			}
+		}
+		return float64(len(seen))
+	}
+
+	// avg, min and max of no values are NaN so every comparison is false.
+	var sum, lowest, highest float64
+	n := 0
+	for _, entry := range entries {
+		v, ok := toFloat(entry.values[call.Field])
+		if !ok {
+			continue
+		}
+		if n == 0 || v < lowest {
+			lowest = v
+		}
+		if n == 0 || v > highest {
+			highest = v
+		}
+		sum += v
+		n++
+	}
+	switch call.Function {
+	case "sum":
+		return sum
+	case "avg":
+		if n == 0 {
+			return math.NaN()
+		}
+		return sum / float64(n)
+	case "min":
+		if n == 0 {
+			return math.NaN()
+		}
+		return lowest
+	case "max":
+		if n == 0 {
+			return math.NaN()
+		}
+		return highest
+	}
+	return 0
+}
+
+// observeExpression evaluates an EXPRESSION rule. Events missing a field the
+// expression reads are skipped rather than matched.
+func (st *ruleState) observeExpression(event *trust.EventEvidence, payload map[string]interface{}) (*match, error) {
+	parsed := st.expression
+	cfg := parsed.Config
+	if !eventTypeMatches(cfg.EventType, event.EventType) {
+		return nil, nil
+	}
+	key := ""
+	if event.Key != nil {
+		key = *event.Key
+	}
+	params := map[string]interface{}{
+		"event_type": event.EventType,
+		"source_id":  event.SourceID,
+		"key":        key,
+	}
+	values := map[string]interface{}{}
+	for _, name := range parsed.Vars {
+		if _, builtin := params[name]; builtin {
+			continue
+		}
+		raw, ok := lookupField(payload, name)
+		if !ok {
+			return nil, nil
+		}
+		values[name] = normalizeValue(raw)
+		params[name] = values[name]
+	}
+
+	at := event.TsServer
+	group := ""
+	var entries []exprEntry
+	if st.window > 0 {
+		switch cfg.GroupBy {
+		case domainRule.GroupBySource:
+			group = event.SourceID
+		case domainRule.GroupByKey:
+			group = key
+		}
+		entry := exprEntry{at: at, eventID: event.EventID, values: map[string]interface{}{}}
+		for _, call := range parsed.Calls {
+			if call.Field == "" {
+				continue
+			}
+			if raw, ok := lookupField(payload, call.Field); ok {
+				entry.values[call.Field] = normalizeValue(raw)
+			}
+		}
+		entries, _ = insertInWindow(st.exprBuckets[group], entry, exprEntryAt, st.window)
+		st.exprBuckets[group] = entries
+	}
+
+	st.scope = &aggregateScope{entries: entries, aggregates: map[string]float64{}}
+	defer func() { st.scope = nil }()
+	result, err := st.condition.Evaluate(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+	matched, ok := result.(bool)
+	if !ok {
+		return nil, errors.New("expression did not evaluate to boolean")
+	}
+	if !matched {
+		return nil, nil
+	}
+
+	evidence := domainRule.ExpressionEvidence{
+		EventID:    event.EventID,
+		Expression: cfg.Expression,
+		GroupBy:    cfg.GroupBy,
+		Group:      group,
+		Values:     values,
+		Matched:    true,
+	}
+	eventIDs := []uuid.UUID{event.EventID}
+	if st.window > 0 && len(entries) > 0 {
+		end := entries[len(entries)-1].at
+		start := end.Add(-st.window)
+		evidence.WindowStart = &start
+		evidence.WindowEnd = &end
+		evidence.EventSample = exprEntryIDs(entries, maxEventSample)
+		eventIDs = evidence.EventSample
+	}
+	if len(st.scope.aggregates) > 0 {
+		evidence.Aggregates = st.scope.aggregates
+	}
+	return &match{evidence: evidence, eventIDs: eventIDs}, nil
+}
+
+func exprEntryAt(e exprEntry) time.Time { return e.at }
+
+func exprEntryIDs(entries []exprEntry, limit int) []uuid.UUID {
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	ids := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.eventID)
+	}
+	return ids
+}
+
+// normalizeValue converts JSON numbers to float64 for govaluate.
+func normalizeValue(v interface{}) interface{} {
+	if f, ok := toFloat(v); ok {
+		if _, isString := v.(string); !isString {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,96 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// fieldUse is a payload field a rule config reads and the types it can read
+// it as. No types means any.
+type fieldUse struct {
+	path  string
+	types []string
+}
+
+var numericTypes = []string{"number", "integer"}
+
+// fieldUses returns the event type a rule reads and the payload fields it
+// reads from it
+func (st *ruleState) fieldUses() (string, []fieldUse) {
+	switch {
+	case st.threshold != nil:
+		return st.threshold.EventType, []fieldUse{{path: st.threshold.Field, types: numericTypes}}
+	case st.repeated != nil:
+		if st.repeated.Field == "" {
+			return st.repeated.EventType, nil
+		}
+		return st.repeated.EventType, []fieldUse{{path: st.repeated.Field}}
+	case st.missingField != nil:
+		return st.missingField.EventType, []fieldUse{{path: st.missingField.Field}}
+	case st.expression != nil:
+		cfg := st.expression.Config
+		uses := make([]fieldUse, 0, len(cfg.Fields))
+		for _, field := range cfg.Fields {
+			uses = append(uses, fieldUse{path: strings.TrimSpace(field)})
+		}
+		for _, call := range st.expression.Calls {
+			switch call.Function {
+			case "sum", "avg", "min", "max":
+				uses = append(uses, fieldUse{path: call.Field, types: numericTypes})
+			case "rate":
+				if call.Field != "" {
+					uses = append(uses, fieldUse{path: call.Field, types: []string{"boolean"}})
+				}
+			}
+		}
+		return cfg.EventType, uses
+	}
+	return "", nil
+}
+
+// checkSchemaFields type-checks the payload fields st reads against every
+// registered schema version of its event type. Rules for all event types
+// and event types without schemas are not checked.
+func (s *Service) checkSchemaFields(ctx context.Context, st *ruleState) error {
+	eventType, uses := st.fieldUses()
+	if s.schemas == nil || eventType == "" || len(uses) == 0 {
+		return nil
+	}
+	versions, err := s.schemas.ListEventSchemas(ctx, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to list event schemas: %w", err)
+	}
+	for _, version := range versions {
+		schema, err := version.Compile()
+		if err != nil {
+			return fmt.Errorf("failed to compile schema %s: %w", version.SchemaVersion, err)
+		}
+		for _, use := range uses {
+			types, ok := schema.FieldTypes(use.path)
+			if !ok {
+				return fmt.Errorf("invalid rule config: field %q is not in the %s schema %s", use.path, eventType, version.SchemaVersion)
+			}
+			if !typesOverlap(types, use.types) {
+				return fmt.Errorf("invalid rule config: field %q is %s in the %s schema %s, expected %s", use.path, strings.Join(types, " or "), eventType, version.SchemaVersion, strings.Join(use.types, " or "))
+			}
+		}
+	}
+	return nil
+}
+
+// typesOverlap reports whether a field of schema types can be read as one of
+// want; empty lists allow any type
+func typesOverlap(types, want []string) bool {
+	if len(types) == 0 || len(want) == 0 {
+		return true
+	}
+	for _, t := range types {
+		for _, w := range want {
+			if t == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// Service handles rule operations that do not run on the ingest path
+type Service struct {
+	ruleRepo  domainRule.Repository
+	trustRepo trust.Repository
+	schemas   trust.SchemaRegistry
+	validator domainAction.ConfigValidator
+	logger    zerolog.Logger
+}
+
+// NewService creates a new rule service
+func NewService(ruleRepo domainRule.Repository, trustRepo trust.Repository, logger zerolog.Logger) *Service {
+	return &Service{
+		ruleRepo:  ruleRepo,
+		trustRepo: trustRepo,
+		logger:    logger.With().Str("service", "rule").Logger(),
+	}
+}
+
+// SetActionConfigValidator checks rule action configs on creation, e.g.
+// against the registered notification channels.
+func (s *Service) SetActionConfigValidator(validator domainAction.ConfigValidator) {
+	s.validator = validator
+}
+
+// SetSchemaRegistry type-checks the payload fields rule configs read against
+// the registered schemas of their event type on creation.
+func (s *Service) SetSchemaRegistry(schemas trust.SchemaRegistry) {
+	s.schemas = schemas
+}
+
+// CreateRule validates r, including its type-specific config and action
+// config, and stores it. A zero RuleID starts a new rule at version 1.
+func (s *Service) CreateRule(ctx context.Context, r *domainRule.Rule) error {
+	now := time.Now().UTC()
+	if r.RuleID == uuid.Nil {
+		r.RuleID = uuid.New()
+	}
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	if r.Status == "" {
+		r.Status = domainRule.RuleStatusActive
+	}
+	if r.LowTrustAction == "" {
+		r.LowTrustAction = domainRule.LowTrustActionSkip
+	}
+	if r.EffectiveFrom.IsZero() {
+		r.EffectiveFrom = now
+	}
+	r.CreatedAt = now
+	r.UpdatedAt = now
+
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	st, err := newRuleState(r)
+	if err != nil {
+		return fmt.Errorf("invalid rule config: %w", err)
+	}
+	if err := s.checkSchemaFields(ctx, st); err != nil {
+		return err
+	}
+	if s.validator != nil {
+		if err := s.validator.ValidateActionConfig(domainAction.Type(r.ActionType), r.ActionConfig); err != nil {
+			return fmt.Errorf("invalid actionConfig: %w", err)
+		}
+	}
+
+	if err := s.ruleRepo.Create(ctx, r); err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	s.logger.Info().
+		Str("rule_id", r.RuleID.String()).
+		Int("version", r.Version).
+		Str("rule_type", string(r.RuleType)).
+		Msg("rule created")
+	return nil
+}
@@ -0,0 +1,127 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	ruleMocks "github.com/execution-hub/execution-hub/internal/domain/rule/mocks"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// channelValidator accepts only the SSE channel
+type channelValidator struct{}
+
+func (channelValidator) ValidateActionConfig(_ domainAction.Type, config json.RawMessage) error {
+	var cfg struct {
+		Channel string `json:"channel"`
+	}
+	_ = json.Unmarshal(config, &cfg)
+	if cfg.Channel != "" && cfg.Channel != "SSE" {
+		return errors.New("unsupported channel")
+	}
+	return nil
+}
+
+func TestService_CreateRule(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	ruleRepo := ruleMocks.NewMockRepository(ctrl)
+	svc := NewService(ruleRepo, &fakeEventStore{}, zerolog.Nop())
+	svc.SetActionConfigValidator(channelValidator{})
+
+	r := &domainRule.Rule{
+		Name:         "hot",
+		RuleType:     domainRule.RuleTypeThreshold,
+		Config:       json.RawMessage(`{"field":"temperature","operator":">","threshold":80}`),
+		ActionType:   domainRule.ActionTypeNotify,
+		ActionConfig: json.RawMessage(`{"channel":"SSE"}`),
+	}
+	ruleRepo.EXPECT().Create(ctx, r).Return(nil)
+	require.NoError(t, svc.CreateRule(ctx, r))
+	assert.NotEqual(t, uuid.Nil, r.RuleID)
+	assert.Equal(t, 1, r.Version)
+	assert.Equal(t, domainRule.RuleStatusActive, r.Status)
+
+	badChannel := testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80}`, `{"channel":"PAGER"}`)
+	assert.EqualError(t, svc.CreateRule(ctx, badChannel), "invalid actionConfig: unsupported channel")
+
+	badConfig := testRule(domainRule.RuleTypeThreshold, `{"operator":">","threshold":80}`, `{}`)
+	assert.ErrorContains(t, svc.CreateRule(ctx, badConfig), "invalid rule config")
+}
+
+// schemaList serves fixed event schemas
+type schemaList []*trust.EventSchema
+
+func (l schemaList) CreateEventSchema(context.Context, *trust.EventSchema) error { return nil }
+
+func (l schemaList) GetEventSchema(context.Context, string, string) (*trust.EventSchema, error) {
+	return nil, nil
+}
+
+func (l schemaList) ListEventSchemas(_ context.Context, eventType string) ([]*trust.EventSchema, error) {
+	var out []*trust.EventSchema
+	for _, schema := range l {
+		if schema.EventType == eventType {
+			out = append(out, schema)
+		}
+	}
+	return out, nil
+}
+
+func TestService_CreateRuleChecksSchemaFields(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	ruleRepo := ruleMocks.NewMockRepository(ctrl)
+	svc := NewService(ruleRepo, &fakeEventStore{}, zerolog.Nop())
+	schema, err := trust.NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(`{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"temperature": {"type": "number"},
+			"unit": {"type": "string"},
+			"alarm": {"type": "boolean"},
+			"meta": {"type": "object"}
+		}
+	}`), "", "", "admin")
+	require.NoError(t, err)
+	svc.SetSchemaRegistry(schemaList{schema})
+
+	accepted := []*domainRule.Rule{
+		testRule(domainRule.RuleTypeThreshold, `{"field":"temperature","operator":">","threshold":80,"eventType":"SENSOR_READING"}`, `{}`),
+		testRule(domainRule.RuleTypeMissingField, `{"field":"meta.calibration","eventType":"SENSOR_READING"}`, `{}`),
+		testRule(domainRule.RuleTypeExpression, `{"expression":"avg('temperature') > 50 && rate('alarm') > 0.5","fields":["temperature","alarm"],"window":"5m","eventType":"SENSOR_READING"}`, `{}`),
+		// Rules for other or all event types are not checked
+		testRule(domainRule.RuleTypeThreshold, `{"field":"unit","operator":">","threshold":80,"eventType":"OTHER"}`, `{}`),
+		testRule(domainRule.RuleTypeThreshold, `{"field":"unit","operator":">","threshold":80}`, `{}`),
+	}
+	for _, r := range accepted {
+		ruleRepo.EXPECT().Create(ctx, r).Return(nil)
+		assert.NoError(t, svc.CreateRule(ctx, r), string(r.Config))
+	}
+
+	rejected := []struct {
+		message string
+		rule    *domainRule.Rule
+	}{
+		{`field "unit" is string`, testRule(domainRule.RuleTypeThreshold, `{"field":"unit","operator":">","threshold":80,"eventType":"SENSOR_READING"}`, `{}`)},
+		{`field "humidity" is not`, testRule(domainRule.RuleTypeRepeated, `{"field":"humidity","count":3,"windowSeconds":60,"eventType":"SENSOR_READING"}`, `{}`)},
+		{`field "unit" is string`, testRule(domainRule.RuleTypeExpression, `{"expression":"max('unit') > 1","fields":["unit"],"window":"5m","eventType":"SENSOR_READING"}`, `{}`)},
+		{`field "temperature" is number`, testRule(domainRule.RuleTypeExpression, `{"expression":"rate('temperature') > 0.5","fields":["temperature"],"window":"5m","eventType":"SENSOR_READING"}`, `{}`)},
+		{`field "temperature.raw" is not`, testRule(domainRule.RuleTypeMissingField, `{"field":"temperature.raw","eventType":"SENSOR_READING"}`, `{}`)},
+	}
+	for _, c := range rejected {
+		err := svc.CreateRule(ctx, c.rule)
+		assert.ErrorContains(t, err, "invalid rule config", string(c.rule.Config))
+		assert.ErrorContains(t, err, c.message, string(c.rule.Config))
+	}
+}
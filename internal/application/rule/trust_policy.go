@@ -0,0 +1,180 @@
+package rule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// TrustLookup reads the current trust level of events.
+type TrustLookup interface {
+	BatchGetTrustMetadata(ctx context.Context, eventIDs []uuid.UUID) (map[uuid.UUID]*trust.TrustMetadata, error)
+}
+
+// admits reports whether r evaluates an event at level: events below the
+// rule's minimum are skipped unless the rule tags them.
+func admits(r *domainRule.Rule, level trust.TrustLevel) bool {
+	return r.Trusts(int(level)) || r.TagsLowTrust()
+}
+
+// HandleBatchVerification re-evaluates a batch's events once its signature
+// has been checked. It implements the trust service's VerificationListener.
+// Events that now meet the minimum of a rule that skipped them are fed to
+// it, and evaluations held for the batch's events fire their action when
+// all their events are trusted or are rejected when the batch failed.
+// Windowed rules place the events at their own time, so batches may be
+// verified in any order.
+func (e *Engine) HandleBatchVerification(ctx context.Context, v *trust.BatchVerification) error {
+	events := make([]*trust.EventEvidence, len(v.Events))
+	for i := range v.Events {
+		events[i] = &v.Events[i]
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].TsServer.Before(events[j].TsServer) })
+
+	var (
+		errs   []error
+		rules  []*domainRule.Rule
+		loaded bool
+	)
+	for _, event := range events {
+		previous, ok := v.PreviousLevels[event.EventID]
+		if !ok || previous >= event.TrustLevel {
+			continue
+		}
+		if !loaded {
+			var err error
+			if rules, err = e.listActiveRules(ctx); err != nil {
+				errs = append(errs, err)
+				break
+			}
+			loaded = true
+		}
+		err := e.evaluate(ctx, rules, event, func(r *domainRule.Rule) bool {
+			return !r.TagsLowTrust() && r.Trusts(int(event.TrustLevel)) && !r.Trusts(int(previous))
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := e.resolvePending(ctx, v); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// resolvePending settles the held evaluations with low-trust events in v's
+// batch.
+func (e *Engine) resolvePending(ctx context.Context, v *trust.BatchVerification) error {
+	if len(v.Batch.EventIDs) == 0 {
+		return nil
+	}
+	pending, err := e.ruleRepo.ListPendingEvaluations(ctx, v.Batch.EventIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list pending evaluations: %w", err)
+	}
+	known := make(map[uuid.UUID]trust.TrustLevel, len(v.Events))
+	for _, event := range v.Events {
+		known[event.EventID] = event.TrustLevel
+	}
+
+	var errs []error
+	for _, eval := range pending {
+		r, err := e.ruleRepo.GetByRuleIDAndVersion(ctx, eval.RuleID, eval.RuleVersion)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get rule: %w", err))
+			continue
+		}
+		if r == nil {
+			continue
+		}
+
+		var status domainRule.TrustStatus
+		if v.Verified {
+			remaining, err := e.lowTrustEvents(ctx, r, eval.LowTrustEventIDs, known)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if len(remaining) > 0 {
+				continue
+			}
+			status = domainRule.TrustStatusTrusted
+		} else {
+			// The failed batch rejects the evaluation only when it leaves
+			// one of its events below the rule's minimum
+			rejected := false
+			for _, id := range eval.LowTrustEventIDs {
+				if level, ok := known[id]; ok && !r.Trusts(int(level)) {
+					rejected = true
+					break
+				}
+			}
+			if !rejected {
+				continue
+			}
+			status = domainRule.TrustStatusRejected
+		}
+
+		resolved, err := e.ruleRepo.ResolveEvaluationTrust(ctx, eval.EvaluationID, status)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve evaluation trust: %w", err))
+			continue
+		}
+		if !resolved {
+			continue
+		}
+		e.logger.Info().
+			Str("rule_id", r.RuleID.String()).
+			Str("evaluation_id", eval.EvaluationID.String()).
+			Str("batch_id", v.Batch.BatchID.String()).
+			Str("trust_status", string(status)).
+			Msg("held evaluation resolved")
+		if status == domainRule.TrustStatusTrusted {
+			eval.TrustStatus = status
+			if err := e.act(ctx, r, eval); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lowTrustEvents returns the eventIDs below r's minimum trust level. Levels
+// missing from known are read from the trust lookup; events whose level
+// cannot be read count as low-trust.
+func (e *Engine) lowTrustEvents(ctx context.Context, r *domainRule.Rule, eventIDs []uuid.UUID, known map[uuid.UUID]trust.TrustLevel) ([]uuid.UUID, error) {
+	levels := make(map[uuid.UUID]trust.TrustLevel, len(eventIDs))
+	var unknown []uuid.UUID
+	for _, id := range eventIDs {
+		if level, ok := known[id]; ok {
+			levels[id] = level
+		} else {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 && e.trust != nil {
+		metadata, err := e.trust.BatchGetTrustMetadata(ctx, unknown)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get event trust levels: %w", err)
+		}
+		for id, m := range metadata {
+			levels[id] = m.TrustLevel
+		}
+	}
+
+	var low []uuid.UUID
+	for _, id := range eventIDs {
+		level, ok := levels[id]
+		if !ok || !r.Trusts(int(level)) {
+			low = append(low, id)
+		}
+	}
+	return low, nil
+}
@@ -0,0 +1,364 @@
+package trust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+const (
+	// chainVerifyPage bounds the chain entries read at once
+	chainVerifyPage = 1000
+	// MaxChainVerifyEntries bounds the entries one sweep checks per source;
+	// longer backlogs continue in the next sweep
+	MaxChainVerifyEntries = 10000
+	// DefaultChainBreakLimit is the default number of breaks listed
+	DefaultChainBreakLimit = 100
+)
+
+var errChainMonitorNotConfigured = errors.New("chain monitor not configured")
+
+// ChainBreakEscalator raises escalation notifications. The notification
+// service implements it.
+type ChainBreakEscalator interface {
+	CreateEscalation(ctx context.Context, actionID uuid.UUID, channel notification.Channel, title, body string, targetUser, targetGroup *string, workflowID *uuid.UUID, traceID *string) (*notification.Notification, error)
+	SendNotification(ctx context.Context, notificationID uuid.UUID) error
+}
+
+// EscalationTarget is who chain break escalations go to and how
+type EscalationTarget struct {
+	Channel notification.Channel
+	User    *string
+	Group   *string
+}
+
+// ChainSweep is the outcome of one pass of the chain verifier
+type ChainSweep struct {
+	Sources        int                 `json:"sources"`
+	EntriesChecked int                 `json:"entriesChecked"`
+	Breaks         []*trust.ChainBreak `json:"breaks,omitempty"`
+	// More is set when a source has entries left beyond this sweep's bound
+	More bool `json:"more"`
+}
+
+// SetChainMonitor sets where the chain verifier keeps its progress and the
+// breaks it finds
+func (s *Service) SetChainMonitor(monitor trust.ChainMonitor) {
+	s.monitor = monitor
+}
+
+// SetChainBreakEscalation raises an ESCALATE notification to target for the
+// breaks each sweep finds in a source's chain
+func (s *Service) SetChainBreakEscalation(escalator ChainBreakEscalator, target EscalationTarget) {
+	s.escalator = escalator
+	s.escalationTarget = target
+}
+
+// VerifyChains checks every source's hash chain from its last verified
+// sequence onwards and records the breaks it finds, including chains whose
+// head fell below the verified sequence or which have no entries left.
+// Sources are checked independently: a failing source does not stop the
+// others.
+func (s *Service) VerifyChains(ctx context.Context) (*ChainSweep, error) {
+	if s.monitor == nil {
+		return nil, errChainMonitorNotConfigured
+	}
+	heads, err := s.monitor.ListChainHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain heads: %w", err)
+	}
+	states, err := s.monitor.ListChainVerificationStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain verification states: %w", err)
+	}
+	bySource := make(map[string]*trust.ChainVerificationState, len(states))
+	for _, state := range states {
+		bySource[state.SourceID] = state
+	}
+
+	sweep := &ChainSweep{}
+	var errs []error
+	hasHead := make(map[string]bool, len(heads))
+	for _, head := range heads {
+		hasHead[head.SourceID] = true
+		state := bySource[head.SourceID]
+		if state == nil {
+			state = &trust.ChainVerificationState{SourceID: head.SourceID}
+		}
+		if state.VerifiedThrough > head.SequenceNum {
+			sweep.Sources++
+			breaks, err := s.recordTruncation(ctx, state, head.SequenceNum)
+			sweep.Breaks = append(sweep.Breaks, breaks...)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("source %s: %w", head.SourceID, err))
+			}
+			continue
+		}
+		if state.VerifiedThrough == head.SequenceNum {
+			continue
+		}
+		sweep.Sources++
+		checked, breaks, err := s.verifySource(ctx, state, head.SequenceNum)
+		sweep.EntriesChecked += checked
+		sweep.Breaks = append(sweep.Breaks, breaks...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", head.SourceID, err))
+			continue
+		}
+		if state.VerifiedThrough < head.SequenceNum {
+			sweep.More = true
+		}
+	}
+	// Sources with a verified prefix but no entries left were emptied
+	for _, state := range states {
+		if hasHead[state.SourceID] || state.VerifiedThrough == 0 {
+			continue
+		}
+		sweep.Sources++
+		breaks, err := s.recordTruncation(ctx, state, 0)
+		sweep.Breaks = append(sweep.Breaks, breaks...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", state.SourceID, err))
+		}
+	}
+	s.escalatePendingBreaks(ctx, sweep.Breaks)
+
+	s.logger.Info().
+		Int("sources", sweep.Sources).
+		Int("entriesChecked", sweep.EntriesChecked).
+		Int("breaksFound", len(sweep.Breaks)).
+		Bool("more", sweep.More).
+		Msg("hash chain sweep completed")
+
+	return sweep, errors.Join(errs...)
+}
+
+// verifySource advances state towards head page by page, saving it with
+// the page's breaks after each page. It returns the entries checked and
+// the newly recorded breaks.
+func (s *Service) verifySource(ctx context.Context, state *trust.ChainVerificationState, head int64) (int, []*trust.ChainBreak, error) {
+	checked := 0
+	var recorded []*trust.ChainBreak
+	for state.VerifiedThrough < head && checked < MaxChainVerifyEntries {
+		from := state.VerifiedThrough + 1
+		to := from + chainVerifyPage - 1
+		if to > head {
+			to = head
+		}
+		entries, err := s.repo.GetChainEntriesForSource(ctx, state.SourceID, from, to)
+		if err != nil {
+			return checked, recorded, fmt.Errorf("failed to get chain entries: %w", err)
+		}
+		if len(entries) == 0 {
+			// The whole page is missing; the next entry reports the gap
+			entries, err = s.repo.GetChainEntriesForSource(ctx, state.SourceID, to+1, head)
+			if err != nil {
+				return checked, recorded, fmt.Errorf("failed to get chain entries: %w", err)
+			}
+			if len(entries) > 1 {
+				entries = entries[:1]
+			}
+		}
+		if len(entries) == 0 {
+			return checked, recorded, nil
+		}
+
+		next := *state
+		found := next.Advance(entries, time.Now().UTC())
+		breaks := make([]*trust.ChainBreak, len(found))
+		for i := range found {
+			breaks[i] = &found[i]
+		}
+		if err := s.monitor.SaveChainVerification(ctx, &next, breaks); err != nil {
+			return checked, recorded, fmt.Errorf("failed to save chain verification: %w", err)
+		}
+		*state = next
+		checked += len(entries)
+		for _, b := range breaks {
+			if b.ID == 0 {
+				continue
+			}
+			recorded = append(recorded, b)
+			s.logger.Error().
+				Str("sourceId", b.SourceID).
+				Int64("breakAt", b.BreakAt).
+				Str("kind", string(b.Kind)).
+				Msg("hash chain break detected")
+		}
+	}
+	return checked, recorded, nil
+}
+
+// recordTruncation records the break of a source whose chain head fell
+// below its verified sequence. The state is kept, so entries appended later
+// are checked against the deleted tail. It returns the break when it is
+// newly recorded.
+func (s *Service) recordTruncation(ctx context.Context, state *trust.ChainVerificationState, head int64) ([]*trust.ChainBreak, error) {
+	b := state.Truncation(head, time.Now().UTC())
+	if b == nil {
+		return nil, nil
+	}
+	if err := s.monitor.SaveChainVerification(ctx, state, []*trust.ChainBreak{b}); err != nil {
+		return nil, fmt.Errorf("failed to save chain verification: %w", err)
+	}
+	if b.ID == 0 {
+		return nil, nil
+	}
+	s.logger.Error().
+		Str("sourceId", b.SourceID).
+		Int64("breakAt", b.BreakAt).
+		Int64("verifiedThrough", state.VerifiedThrough).
+		Msg("hash chain truncation detected")
+	return []*trust.ChainBreak{b}, nil
+}
+
+// escalatePendingBreaks escalates every recorded break that is not linked
+// to an escalation yet: the ones found in this sweep and the ones an earlier
+// sweep failed to escalate. found is updated with the escalations raised.
+func (s *Service) escalatePendingBreaks(ctx context.Context, found []*trust.ChainBreak) {
+	if s.escalator == nil {
+		return
+	}
+	pending, err := s.monitor.ListUnescalatedChainBreaks(ctx, DefaultChainBreakLimit)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("failed to list unescalated chain breaks")
+		return
+	}
+	byID := make(map[int64]*trust.ChainBreak, len(found))
+	for _, b := range found {
+		byID[b.ID] = b
+	}
+	for i, b := range pending {
+		if f, ok := byID[b.ID]; ok {
+			pending[i] = f
+		}
+	}
+	for len(pending) > 0 {
+		n := 1
+		for n < len(pending) && pending[n].SourceID == pending[0].SourceID {
+			n++
+		}
+		s.escalateBreaks(ctx, pending[0].SourceID, pending[:n])
+		pending = pending[n:]
+	}
+}
+
+// escalateBreaks raises one escalation for the breaks of a source's chain.
+// Escalation failures are logged: breaks left without an escalation are
+// escalated again by the next sweep, while an escalation that was created
+// but failed to send is retried by the notification service.
+func (s *Service) escalateBreaks(ctx context.Context, sourceID string, breaks []*trust.ChainBreak) {
+	first := breaks[0]
+	lines := make([]string, len(breaks))
+	ids := make([]int64, len(breaks))
+	for i, b := range breaks {
+		lines[i] = b.Describe()
+		ids[i] = b.ID
+	}
+	title := fmt.Sprintf("Hash chain break in source %s", sourceID)
+	body := fmt.Sprintf("The chain verifier found %d break(s) in the hash chain of source %s; events from sequence %d on cannot be trusted until it is investigated.\n%s",
+		len(breaks), sourceID, first.BreakAt, strings.Join(lines, "\n"))
+	// Notifications belong to an action; chain breaks have none, so the
+	// escalation carries an ID derived from the first break instead.
+	actionID := uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("trust-chain-break:%d", first.ID)))
+	traceID := fmt.Sprintf("chain-break-%d", first.ID)
+
+	target := s.escalationTarget
+	n, err := s.escalator.CreateEscalation(ctx, actionID, target.Channel, title, body, target.User, target.Group, nil, &traceID)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("sourceId", sourceID).Msg("failed to create chain break escalation")
+		return
+	}
+	if err := s.monitor.SetChainBreakNotification(ctx, ids, n.NotificationID); err != nil {
+		s.logger.Warn().Err(err).Str("sourceId", sourceID).Msg("failed to link chain breaks to their escalation")
+	} else {
+		for _, b := range breaks {
+			b.NotificationID = &n.NotificationID
+		}
+	}
+	if err := s.escalator.SendNotification(ctx, n.NotificationID); err != nil {
+		s.logger.Warn().Err(err).Str("notificationId", n.NotificationID.String()).Msg("failed to send chain break escalation")
+	}
+}
+
+// RunChainVerification sweeps the hash chains every interval until ctx is
+// cancelled, sweeping again at once while sources have entries left
+func (s *Service) RunChainVerification(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				sweep, err := s.VerifyChains(ctx)
+				if err != nil {
+					s.logger.Error().Err(err).Msg("hash chain sweep failed")
+				}
+				if sweep == nil || !sweep.More {
+					break
+				}
+			}
+		}
+	}
+}
+
+// GetChainCoverage reports per source how much of its hash chain the chain
+// verifier has checked, including verified sources with no entries left
+func (s *Service) GetChainCoverage(ctx context.Context) ([]trust.ChainCoverage, error) {
+	if s.monitor == nil {
+		return nil, errChainMonitorNotConfigured
+	}
+	heads, err := s.monitor.ListChainHeads(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain heads: %w", err)
+	}
+	states, err := s.monitor.ListChainVerificationStates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain verification states: %w", err)
+	}
+	counts, err := s.monitor.CountChainBreaks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count chain breaks: %w", err)
+	}
+	bySource := make(map[string]*trust.ChainVerificationState, len(states))
+	for _, state := range states {
+		bySource[state.SourceID] = state
+	}
+	coverage := make([]trust.ChainCoverage, 0, len(heads))
+	for _, head := range heads {
+		coverage = append(coverage, trust.NewChainCoverage(head, bySource[head.SourceID], counts[head.SourceID]))
+		delete(bySource, head.SourceID)
+	}
+	// Verified sources with no entries left lost their whole chain
+	for _, state := range states {
+		if bySource[state.SourceID] != nil && state.VerifiedThrough > 0 {
+			coverage = append(coverage, trust.NewChainCoverage(trust.ChainHead{SourceID: state.SourceID}, state, counts[state.SourceID]))
+		}
+	}
+	return coverage, nil
+}
+
+// ListChainBreaks lists the latest recorded breaks of a source, or of all
+// sources when sourceID is empty
+func (s *Service) ListChainBreaks(ctx context.Context, sourceID string, limit int) ([]*trust.ChainBreak, error) {
+	if s.monitor == nil {
+		return nil, errChainMonitorNotConfigured
+	}
+	if limit <= 0 {
+		limit = DefaultChainBreakLimit
+	}
+	breaks, err := s.monitor.ListChainBreaks(ctx, sourceID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chain breaks: %w", err)
+	}
+	return breaks, nil
+}
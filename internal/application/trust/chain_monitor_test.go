@@ -0,0 +1,329 @@
+package trust
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// memoryChainMonitor keeps chain verification progress and breaks in memory
+type memoryChainMonitor struct {
+	mu     sync.Mutex
+	heads  []trust.ChainHead
+	states map[string]trust.ChainVerificationState
+	breaks []*trust.ChainBreak
+}
+
+func newMemoryChainMonitor(heads ...trust.ChainHead) *memoryChainMonitor {
+	return &memoryChainMonitor{heads: heads, states: map[string]trust.ChainVerificationState{}}
+}
+
+func (m *memoryChainMonitor) ListChainHeads(_ context.Context) ([]trust.ChainHead, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]trust.ChainHead(nil), m.heads...), nil
+}
+
+func (m *memoryChainMonitor) ListChainVerificationStates(_ context.Context) ([]*trust.ChainVerificationState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*trust.ChainVerificationState
+	for _, state := range m.states {
+		state := state
+		out = append(out, &state)
+	}
+	return out, nil
+}
+
+func (m *memoryChainMonitor) SaveChainVerification(_ context.Context, state *trust.ChainVerificationState, breaks []*trust.ChainBreak) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if saved, ok := m.states[state.SourceID]; ok && saved.VerifiedThrough > state.VerifiedThrough {
+		return nil
+	}
+	m.states[state.SourceID] = *state
+	for _, b := range breaks {
+		duplicate := false
+		for _, existing := range m.breaks {
+			if existing.SourceID == b.SourceID && existing.BreakAt == b.BreakAt && existing.Kind == b.Kind {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			b.ID = int64(len(m.breaks) + 1)
+			m.breaks = append(m.breaks, b)
+		}
+	}
+	return nil
+}
+
+func (m *memoryChainMonitor) SetChainBreakNotification(_ context.Context, breakIDs []int64, notificationID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range breakIDs {
+		m.breaks[id-1].NotificationID = &notificationID
+	}
+	return nil
+}
+
+func (m *memoryChainMonitor) ListChainBreaks(_ context.Context, sourceID string, limit int) ([]*trust.ChainBreak, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*trust.ChainBreak
+	for i := len(m.breaks) - 1; i >= 0 && len(out) < limit; i-- {
+		if sourceID == "" || m.breaks[i].SourceID == sourceID {
+			out = append(out, m.breaks[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryChainMonitor) ListUnescalatedChainBreaks(_ context.Context, limit int) ([]*trust.ChainBreak, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*trust.ChainBreak
+	for _, b := range m.breaks {
+		if b.NotificationID == nil {
+			out = append(out, b)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].SourceID != out[j].SourceID {
+			return out[i].SourceID < out[j].SourceID
+		}
+		return out[i].BreakAt < out[j].BreakAt
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryChainMonitor) CountChainBreaks(_ context.Context) (map[string]int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := map[string]int{}
+	for _, b := range m.breaks {
+		counts[b.SourceID]++
+	}
+	return counts, nil
+}
+
+// recordingEscalator records the escalations it is asked to raise, failing
+// with createErr while it is set
+type recordingEscalator struct {
+	created   []*notification.Notification
+	sent      []uuid.UUID
+	createErr error
+}
+
+func (e *recordingEscalator) CreateEscalation(_ context.Context, actionID uuid.UUID, channel notification.Channel, title, body string, _, _ *string, _ *uuid.UUID, _ *string) (*notification.Notification, error) {
+	if e.createErr != nil {
+		return nil, e.createErr
+	}
+	n := &notification.Notification{NotificationID: uuid.New(), ActionID: actionID, Channel: channel, Title: title, Body: body}
+	e.created = append(e.created, n)
+	return n, nil
+}
+
+func (e *recordingEscalator) SendNotification(_ context.Context, notificationID uuid.UUID) error {
+	e.sent = append(e.sent, notificationID)
+	return nil
+}
+
+func testChain(sourceID string, n int) []*trust.HashChainEntry {
+	links := make([]trust.ChainLink, n)
+	for i := range links {
+		links[i] = trust.ChainLink{Event: &trust.EventEvidence{EventID: uuid.New()}, EventHash: trust.ComputeChainHash(uuid.NewString(), "")}
+	}
+	return trust.ExtendChain(nil, sourceID, links)
+}
+
+func TestService_VerifyChains(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := NewService(repo, new(MockKeyStore), zerolog.Nop())
+
+	_, err := svc.VerifyChains(ctx)
+	assert.ErrorIs(t, err, errChainMonitorNotConfigured)
+
+	intact := testChain("gateway-001", 3)
+	broken := testChain("gateway-002", 4)
+	broken[2].EventHash = "tampered"
+	monitor := newMemoryChainMonitor(
+		trust.ChainHead{SourceID: "gateway-001", SequenceNum: 3},
+		trust.ChainHead{SourceID: "gateway-002", SequenceNum: 4},
+	)
+	escalator := &recordingEscalator{}
+	svc.SetChainMonitor(monitor)
+	svc.SetChainBreakEscalation(escalator, EscalationTarget{Channel: notification.ChannelSSE})
+	repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(1), int64(3)).Return(intact, nil).Once()
+	repo.On("GetChainEntriesForSource", ctx, "gateway-002", int64(1), int64(4)).Return(broken, nil).Once()
+
+	sweep, err := svc.VerifyChains(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, sweep.Sources)
+	assert.Equal(t, 7, sweep.EntriesChecked)
+	assert.False(t, sweep.More)
+	require.Len(t, sweep.Breaks, 1)
+	assert.Equal(t, trust.ChainBreakHashMismatch, sweep.Breaks[0].Kind)
+	assert.Equal(t, int64(3), sweep.Breaks[0].BreakAt)
+
+	require.Len(t, escalator.created, 1)
+	assert.Contains(t, escalator.created[0].Title, "gateway-002")
+	assert.Contains(t, escalator.created[0].Body, "entry 3 of source gateway-002 does not match its chain hash")
+	assert.Equal(t, []uuid.UUID{escalator.created[0].NotificationID}, escalator.sent)
+	require.NotNil(t, monitor.breaks[0].NotificationID)
+	assert.Equal(t, escalator.created[0].NotificationID, *monitor.breaks[0].NotificationID)
+
+	t.Run("continues from the verified sequence", func(t *testing.T) {
+		more := trust.ExtendChain(intact[2], "gateway-001", []trust.ChainLink{{Event: &trust.EventEvidence{EventID: uuid.New()}, EventHash: "next"}})
+		monitor.heads[0].SequenceNum = 4
+		repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(4), int64(4)).Return(more, nil).Once()
+
+		sweep, err := svc.VerifyChains(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sweep.Sources)
+		assert.Equal(t, 1, sweep.EntriesChecked)
+		assert.Empty(t, sweep.Breaks)
+		assert.Len(t, escalator.created, 1, "known breaks are not escalated again")
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("reports coverage and breaks", func(t *testing.T) {
+		monitor.heads = append(monitor.heads, trust.ChainHead{SourceID: "gateway-003", SequenceNum: 2})
+
+		coverage, err := svc.GetChainCoverage(ctx)
+		require.NoError(t, err)
+		require.Len(t, coverage, 3)
+		sort.Slice(coverage, func(i, j int) bool { return coverage[i].SourceID < coverage[j].SourceID })
+		assert.Equal(t, 1.0, coverage[0].Coverage)
+		assert.Equal(t, 1, coverage[1].BreakCount)
+		assert.Equal(t, int64(2), coverage[2].UnverifiedEntries)
+
+		breaks, err := svc.ListChainBreaks(ctx, "gateway-002", 0)
+		require.NoError(t, err)
+		assert.Len(t, breaks, 1)
+		breaks, err = svc.ListChainBreaks(ctx, "gateway-001", 0)
+		require.NoError(t, err)
+		assert.Empty(t, breaks)
+	})
+}
+
+func TestService_VerifyChainsRetriesFailedEscalations(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := NewService(repo, new(MockKeyStore), zerolog.Nop())
+	broken := testChain("gateway-001", 3)
+	broken[1].EventHash = "tampered"
+	monitor := newMemoryChainMonitor(trust.ChainHead{SourceID: "gateway-001", SequenceNum: 3})
+	escalator := &recordingEscalator{createErr: errors.New("notification store unavailable")}
+	svc.SetChainMonitor(monitor)
+	svc.SetChainBreakEscalation(escalator, EscalationTarget{Channel: notification.ChannelSSE})
+	repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(1), int64(3)).Return(broken, nil).Once()
+
+	sweep, err := svc.VerifyChains(ctx)
+	require.NoError(t, err)
+	require.Len(t, sweep.Breaks, 1)
+	assert.Nil(t, sweep.Breaks[0].NotificationID)
+	assert.Empty(t, escalator.created)
+
+	escalator.createErr = nil
+	sweep, err = svc.VerifyChains(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sweep.Sources, "the chain itself is not checked again")
+	require.Len(t, escalator.created, 1)
+	assert.Contains(t, escalator.created[0].Body, "entry 2 of source gateway-001")
+	require.NotNil(t, monitor.breaks[0].NotificationID)
+	assert.Equal(t, escalator.created[0].NotificationID, *monitor.breaks[0].NotificationID)
+
+	_, err = svc.VerifyChains(ctx)
+	require.NoError(t, err)
+	assert.Len(t, escalator.created, 1, "escalated breaks are not escalated again")
+	repo.AssertExpectations(t)
+}
+
+func TestService_VerifyChainsReportsTruncation(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := NewService(repo, new(MockKeyStore), zerolog.Nop())
+	monitor := newMemoryChainMonitor(trust.ChainHead{SourceID: "gateway-001", SequenceNum: 5})
+	escalator := &recordingEscalator{}
+	svc.SetChainMonitor(monitor)
+	svc.SetChainBreakEscalation(escalator, EscalationTarget{Channel: notification.ChannelSSE})
+	repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(1), int64(5)).Return(testChain("gateway-001", 5), nil).Once()
+	_, err := svc.VerifyChains(ctx)
+	require.NoError(t, err)
+
+	t.Run("head below the verified sequence", func(t *testing.T) {
+		monitor.heads[0].SequenceNum = 3
+
+		sweep, err := svc.VerifyChains(ctx)
+		require.NoError(t, err)
+		require.Len(t, sweep.Breaks, 1)
+		assert.Equal(t, trust.ChainBreakTruncated, sweep.Breaks[0].Kind)
+		assert.Equal(t, int64(4), sweep.Breaks[0].BreakAt)
+		require.Len(t, escalator.created, 1)
+		assert.Contains(t, escalator.created[0].Body, "entries of source gateway-001 from sequence 4 on were deleted")
+
+		sweep, err = svc.VerifyChains(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, sweep.Breaks, "the truncation is recorded once")
+		assert.Len(t, escalator.created, 1)
+
+		coverage, err := svc.GetChainCoverage(ctx)
+		require.NoError(t, err)
+		require.Len(t, coverage, 1)
+		assert.Equal(t, int64(5), coverage[0].VerifiedThrough)
+		assert.Equal(t, int64(2), coverage[0].MissingEntries)
+		assert.Less(t, coverage[0].Coverage, 1.0)
+	})
+
+	t.Run("source with no entries left", func(t *testing.T) {
+		monitor.heads = nil
+
+		sweep, err := svc.VerifyChains(ctx)
+		require.NoError(t, err)
+		require.Len(t, sweep.Breaks, 1)
+		assert.Equal(t, trust.ChainBreakTruncated, sweep.Breaks[0].Kind)
+		assert.Equal(t, int64(1), sweep.Breaks[0].BreakAt)
+		assert.Len(t, escalator.created, 2)
+
+		coverage, err := svc.GetChainCoverage(ctx)
+		require.NoError(t, err)
+		require.Len(t, coverage, 1)
+		assert.Equal(t, "gateway-001", coverage[0].SourceID)
+		assert.Equal(t, int64(5), coverage[0].MissingEntries)
+		assert.Zero(t, coverage[0].Coverage)
+	})
+	repo.AssertExpectations(t)
+}
+
+func TestService_VerifyChainsReportsMissingPages(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepository)
+	svc := NewService(repo, new(MockKeyStore), zerolog.Nop())
+	monitor := newMemoryChainMonitor(trust.ChainHead{SourceID: "gateway-001", SequenceNum: chainVerifyPage + 1})
+	svc.SetChainMonitor(monitor)
+	tail := testChain("gateway-001", 1)
+	tail[0].SequenceNum = chainVerifyPage + 1
+	repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(1), int64(chainVerifyPage)).Return([]*trust.HashChainEntry{}, nil).Once()
+	repo.On("GetChainEntriesForSource", ctx, "gateway-001", int64(chainVerifyPage+1), int64(chainVerifyPage+1)).Return(tail, nil).Once()
+
+	sweep, err := svc.VerifyChains(ctx)
+	require.NoError(t, err)
+	require.Len(t, sweep.Breaks, 1)
+	assert.Equal(t, trust.ChainBreakSequenceGap, sweep.Breaks[0].Kind)
+	assert.Equal(t, int64(1), sweep.Breaks[0].BreakAt)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,141 @@
+package trust
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// EventSchemaInput represents input for registering an event payload schema
+type EventSchemaInput struct {
+	EventType     string
+	SchemaVersion string
+	Schema        json.RawMessage
+	OnInvalid     trust.InvalidPayloadAction
+	Compatibility trust.SchemaCompatibility
+	CreatedBy     string
+}
+
+// RegisterSchema adds a schema version for an event type. Unless its
+// compatibility is NONE it must accept every payload the latest registered
+// version accepts. Registering an existing version again with the same
+// document returns the stored schema.
+func (s *Service) RegisterSchema(ctx context.Context, input EventSchemaInput) (*trust.EventSchema, error) {
+	if s.schemas == nil {
+		return nil, errSchemaRegistryNotConfigured
+	}
+	schema, err := trust.NewEventSchema(input.EventType, input.SchemaVersion, input.Schema, input.OnInvalid, input.Compatibility, input.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.schemas.GetEventSchema(ctx, schema.EventType, schema.SchemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event schema: %w", err)
+	}
+	if existing != nil {
+		if existing.SameDocument(schema) {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("%w: %s %s", trust.ErrSchemaConflict, schema.EventType, schema.SchemaVersion)
+	}
+
+	if schema.Compatibility == trust.SchemaCompatibilityBackward {
+		versions, err := s.schemas.ListEventSchemas(ctx, schema.EventType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list event schemas: %w", err)
+		}
+		if len(versions) > 0 {
+			latest := versions[len(versions)-1]
+			prev, err := latest.Compile()
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile schema %s: %w", latest.SchemaVersion, err)
+			}
+			next, err := schema.Compile()
+			if err != nil {
+				return nil, err
+			}
+			if err := trust.CheckCompatible(prev, next); err != nil {
+				return nil, fmt.Errorf("version %s: %w", latest.SchemaVersion, err)
+			}
+		}
+	}
+
+	if err := s.schemas.CreateEventSchema(ctx, schema); err != nil {
+		return nil, fmt.Errorf("failed to create event schema: %w", err)
+	}
+
+	s.logger.Info().
+		Str("eventType", schema.EventType).
+		Str("schemaVersion", schema.SchemaVersion).
+		Str("onInvalid", string(schema.OnInvalid)).
+		Str("createdBy", schema.CreatedBy).
+		Msg("event schema registered")
+
+	return schema, nil
+}
+
+// ListSchemas lists the registered schemas of an event type, or of all event
+// types when eventType is empty
+func (s *Service) ListSchemas(ctx context.Context, eventType string) ([]*trust.EventSchema, error) {
+	if s.schemas == nil {
+		return nil, errSchemaRegistryNotConfigured
+	}
+	schemas, err := s.schemas.ListEventSchemas(ctx, eventType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+// GetSchema returns the schema registered for an event type and version
+func (s *Service) GetSchema(ctx context.Context, eventType, schemaVersion string) (*trust.EventSchema, error) {
+	if s.schemas == nil {
+		return nil, errSchemaRegistryNotConfigured
+	}
+	schema, err := s.schemas.GetEventSchema(ctx, eventType, schemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event schema: %w", err)
+	}
+	if schema == nil {
+		return nil, trust.ErrSchemaNotFound
+	}
+	return schema, nil
+}
+
+// lookupSchema returns the compiled schema of an event type and version, or
+// nil when none is registered
+func (s *Service) lookupSchema(ctx context.Context, eventType, schemaVersion string) (*compiledSchema, error) {
+	if s.schemas == nil {
+		return nil, nil
+	}
+	key := schemaKey(eventType, schemaVersion)
+	s.mu.Lock()
+	cached, ok := s.compiled[key]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	entry, err := s.schemas.GetEventSchema(ctx, eventType, schemaVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event schema: %w", err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+	schema, err := entry.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s %s: %w", eventType, schemaVersion, err)
+	}
+	cached = &compiledSchema{entry: entry, schema: schema}
+	s.mu.Lock()
+	s.compiled[key] = cached
+	s.mu.Unlock()
+	return cached, nil
+}
+
+func schemaKey(eventType, schemaVersion string) string {
+	return eventType + "\x00" + schemaVersion
+}
@@ -0,0 +1,180 @@
+package trust
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// memorySchemaRegistry keeps event schemas in memory, oldest first
+type memorySchemaRegistry struct {
+	mu      sync.Mutex
+	schemas []*trust.EventSchema
+	gets    int
+}
+
+func (r *memorySchemaRegistry) CreateEventSchema(_ context.Context, schema *trust.EventSchema) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.schemas {
+		if existing.EventType == schema.EventType && existing.SchemaVersion == schema.SchemaVersion {
+			return trust.ErrSchemaConflict
+		}
+	}
+	schema.ID = int64(len(r.schemas) + 1)
+	r.schemas = append(r.schemas, schema)
+	return nil
+}
+
+func (r *memorySchemaRegistry) GetEventSchema(_ context.Context, eventType, schemaVersion string) (*trust.EventSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gets++
+	for _, schema := range r.schemas {
+		if schema.EventType == eventType && schema.SchemaVersion == schemaVersion {
+			return schema, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memorySchemaRegistry) ListEventSchemas(_ context.Context, eventType string) ([]*trust.EventSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*trust.EventSchema
+	for _, schema := range r.schemas {
+		if eventType == "" || schema.EventType == eventType {
+			out = append(out, schema)
+		}
+	}
+	return out, nil
+}
+
+const sensorSchema = `{"type": "object", "required": ["reading"], "properties": {"reading": {"type": "number"}}}`
+
+func TestService_RegisterSchema(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(new(MockRepository), new(MockKeyStore), zerolog.Nop())
+	input := EventSchemaInput{
+		EventType:     "SENSOR_READING",
+		SchemaVersion: "1.0.0",
+		Schema:        json.RawMessage(sensorSchema),
+		CreatedBy:     "admin",
+	}
+
+	_, err := svc.RegisterSchema(ctx, input)
+	assert.ErrorIs(t, err, errSchemaRegistryNotConfigured)
+	registry := &memorySchemaRegistry{}
+	svc.SetSchemaRegistry(registry)
+
+	first, err := svc.RegisterSchema(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, trust.InvalidPayloadReject, first.OnInvalid)
+	again, err := svc.RegisterSchema(ctx, input)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID, "registering the same document is idempotent")
+
+	changed := input
+	changed.Schema = json.RawMessage(`{"type": "object"}`)
+	_, err = svc.RegisterSchema(ctx, changed)
+	assert.ErrorIs(t, err, trust.ErrSchemaConflict)
+
+	next := input
+	next.SchemaVersion = "2.0.0"
+	next.Schema = json.RawMessage(`{"type": "object", "required": ["reading", "unit"], "properties": {"reading": {"type": "number"}, "unit": {"type": "string"}}}`)
+	_, err = svc.RegisterSchema(ctx, next)
+	assert.ErrorIs(t, err, trust.ErrSchemaIncompatible)
+
+	next.Compatibility = trust.SchemaCompatibilityNone
+	_, err = svc.RegisterSchema(ctx, next)
+	require.NoError(t, err)
+
+	schemas, err := svc.ListSchemas(ctx, "SENSOR_READING")
+	require.NoError(t, err)
+	assert.Len(t, schemas, 2)
+	got, err := svc.GetSchema(ctx, "SENSOR_READING", "2.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, trust.SchemaCompatibilityNone, got.Compatibility)
+	_, err = svc.GetSchema(ctx, "SENSOR_READING", "3.0.0")
+	assert.ErrorIs(t, err, trust.ErrSchemaNotFound)
+}
+
+func TestService_IngestBatchValidatesPayloads(t *testing.T) {
+	ctx := context.Background()
+	newInput := func(version, payload string) HashChainInput {
+		return HashChainInput{
+			EventID:       uuid.New(),
+			SourceID:      "gateway-001",
+			SourceType:    "GW",
+			EventType:     "SENSOR_READING",
+			Payload:       json.RawMessage(payload),
+			SchemaVersion: version,
+		}
+	}
+	newService := func(t *testing.T, onInvalid trust.InvalidPayloadAction) (*Service, *MockRepository, *memorySchemaRegistry) {
+		t.Helper()
+		repo := new(MockRepository)
+		svc := NewService(repo, new(MockKeyStore), zerolog.Nop())
+		registry := &memorySchemaRegistry{}
+		svc.SetSchemaRegistry(registry)
+		_, err := svc.RegisterSchema(ctx, EventSchemaInput{
+			EventType:     "SENSOR_READING",
+			SchemaVersion: "1.0.0",
+			Schema:        json.RawMessage(sensorSchema),
+			OnInvalid:     onInvalid,
+		})
+		require.NoError(t, err)
+		return svc, repo, registry
+	}
+
+	t.Run("rejects the batch of an invalid payload", func(t *testing.T) {
+		svc, repo, _ := newService(t, trust.InvalidPayloadReject)
+
+		_, err := svc.IngestBatch(ctx, []HashChainInput{
+			newInput("1.0.0", `{"reading": 1}`),
+			newInput("1.0.0", `{"reading": "high"}`),
+		})
+		require.ErrorIs(t, err, trust.ErrPayloadInvalid)
+		assert.Contains(t, err.Error(), "event 1")
+		assert.Contains(t, err.Error(), "$.reading: expected number, got string")
+		repo.AssertNotCalled(t, "AppendToChain", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("flags an invalid payload and caps its trust", func(t *testing.T) {
+		svc, repo, registry := newService(t, trust.InvalidPayloadFlag)
+		listener := &recordingListener{}
+		svc.AddEventListener(listener)
+		repo.On("AppendToChain", ctx, "gateway-001", mock.AnythingOfType("[]trust.ChainLink")).Return(nil, nil).Once()
+
+		_, err := svc.IngestBatch(ctx, []HashChainInput{
+			newInput("1.0.0", `{"reading": 1}`),
+			newInput("1.0.0", `{}`),
+			newInput("9.9.9", `{"anything": true}`),
+		})
+		require.NoError(t, err)
+		require.Len(t, listener.events, 3)
+		assert.Equal(t, 1, listener.batches)
+		assert.Empty(t, listener.events[0].ValidationErrors)
+		assert.Equal(t, trust.TrustLevelT2, listener.events[0].TrustLevel)
+		assert.Equal(t, []string{`$: missing required property "reading"`}, listener.events[1].ValidationErrors)
+		assert.Equal(t, trust.TrustLevelT1, listener.events[1].TrustLevel)
+		assert.Empty(t, listener.events[2].ValidationErrors, "unregistered versions are not validated")
+		assert.Equal(t, trust.TrustLevelT2, listener.events[2].TrustLevel)
+
+		// Registered schemas are compiled once
+		gets := registry.gets
+		repo.On("AppendToChain", ctx, "gateway-001", mock.AnythingOfType("[]trust.ChainLink")).Return(nil, nil).Once()
+		_, err = svc.IngestBatch(ctx, []HashChainInput{newInput("1.0.0", `{"reading": 2}`)})
+		require.NoError(t, err)
+		assert.Equal(t, gets, registry.gets)
+	})
+}
@@ -2,8 +2,12 @@ package trust
 
 import (
 	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,9 +18,43 @@ import (
 
 // Service handles trust operations
 type Service struct {
-	repo     trust.Repository
-	keyStore trust.KeyStore
-	logger   zerolog.Logger
+	repo      trust.Repository
+	keyStore  trust.KeyStore
+	keys      trust.KeyRegistry
+	schemas   trust.SchemaRegistry
+	monitor   trust.ChainMonitor
+	logger    zerolog.Logger
+	listeners []EventListener
+	verifiers []VerificationListener
+
+	escalator        ChainBreakEscalator
+	escalationTarget EscalationTarget
+	timestamper      Timestamper
+	tsaRoots         *x509.CertPool
+
+	mu       sync.Mutex
+	compiled map[string]*compiledSchema
+}
+
+// compiledSchema caches a registered schema with its parsed document.
+// Registered versions never change, so entries stay valid.
+type compiledSchema struct {
+	entry  *trust.EventSchema
+	schema *trust.Schema
+}
+
+// EventListener receives the events of each ingested batch, in chain order,
+// after they have been appended to their source's hash chain. Listener
+// errors are logged and never fail ingestion.
+type EventListener interface {
+	HandleEvents(ctx context.Context, events []*trust.EventEvidence) error
+}
+
+// VerificationListener receives a batch's events once its signature has
+// been checked, so decisions made at their earlier trust level can be
+// revisited. Listener errors are logged and never fail verification.
+type VerificationListener interface {
+	HandleBatchVerification(ctx context.Context, v *trust.BatchVerification) error
 }
 
 // NewService creates a new trust service
@@ -25,9 +63,32 @@ func NewService(repo trust.Repository, keyStore trust.KeyStore, logger zerolog.L
 		repo:     repo,
 		keyStore: keyStore,
 		logger:   logger.With().Str("service", "trust").Logger(),
+		compiled: map[string]*compiledSchema{},
 	}
 }
 
+// SetKeyRegistry sets the registry of source public keys. Without it only
+// HMAC-SHA256 batch signatures with secrets from the key store verify.
+func (s *Service) SetKeyRegistry(keys trust.KeyRegistry) {
+	s.keys = keys
+}
+
+// SetSchemaRegistry sets the registry of event payload schemas. Without it
+// payloads are stored unvalidated.
+func (s *Service) SetSchemaRegistry(schemas trust.SchemaRegistry) {
+	s.schemas = schemas
+}
+
+// AddEventListener registers a listener for ingested events.
+func (s *Service) AddEventListener(listener EventListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// AddVerificationListener registers a listener for batch signature checks.
+func (s *Service) AddVerificationListener(listener VerificationListener) {
+	s.verifiers = append(s.verifiers, listener)
+}
+
 // HashChainInput represents input for adding an event to the hash chain
 type HashChainInput struct {
 	EventID        uuid.UUID
@@ -42,86 +103,145 @@ type HashChainInput struct {
 	SchemaVersion  string
 }
 
+var (
+	errKeyRegistryNotConfigured    = errors.New("key registry not configured")
+	errSchemaRegistryNotConfigured = errors.New("schema registry not configured")
+)
+
+// MaxIngestBatch bounds the events of one IngestBatch call
+const MaxIngestBatch = 1000
+
 // AddToHashChain adds an event to its source's hash chain
 func (s *Service) AddToHashChain(ctx context.Context, input HashChainInput) (*trust.HashChainEntry, error) {
-	if input.EventID == uuid.Nil {
-		input.EventID = uuid.New()
-	}
-
-	// Persist event evidence (T1 trust: server timestamp)
-	event := &trust.EventEvidence{
-		EventID:        input.EventID,
-		ClientRecordID: input.ClientRecordID,
-		SourceType:     input.SourceType,
-		SourceID:       input.SourceID,
-		TsDevice:       input.TsDevice,
-		TsGateway:      input.TsGateway,
-		TsServer:       time.Now().UTC(),
-		Key:            input.Key,
-		EventType:      input.EventType,
-		Payload:        input.Payload,
-		SchemaVersion:  input.SchemaVersion,
-		TrustLevel:     trust.TrustLevelT1,
-	}
-
-	if err := s.repo.InsertEvent(ctx, event); err != nil {
-		return nil, fmt.Errorf("failed to insert event: %w", err)
-	}
-
-	// Compute event hash
-	hashInput := &trust.EventHashInput{
-		ClientRecordID: input.ClientRecordID,
-		SourceType:     input.SourceType,
-		SourceID:       input.SourceID,
-		TsDevice:       input.TsDevice,
-		TsGateway:      input.TsGateway,
-		Key:            input.Key,
-		EventType:      input.EventType,
-		Payload:        input.Payload,
-		SchemaVersion:  input.SchemaVersion,
-	}
-
-	eventHash, err := trust.ComputeEventHash(hashInput)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute event hash: %w", err)
-	}
-
-	// Get the latest chain entry for this source
-	latestEntry, err := s.repo.GetLatestChainEntry(ctx, input.SourceID)
+	entries, err := s.IngestBatch(ctx, []HashChainInput{input})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest chain entry: %w", err)
-	}
-
-	// Determine previous hash and sequence number
-	prevHash := ""
-	sequenceNum := int64(1)
-	if latestEntry != nil {
-		prevHash = latestEntry.ChainHash
-		sequenceNum = latestEntry.SequenceNum + 1
+		return nil, err
 	}
+	return entries[0], nil
+}
 
-	// Create and store the new chain entry
-	entry := trust.NewHashChainEntry(input.EventID, input.SourceID, sequenceNum, eventHash, prevHash)
+// IngestBatch stores events of one source and appends them to its hash
+// chain, in order, as a single atomic extension. Concurrent calls for the
+// same source are serialised by the repository and never fork the chain.
+// Payloads with a registered schema are validated first: an invalid payload
+// fails the whole batch when its schema rejects, or is stored flagged at T1.
+func (s *Service) IngestBatch(ctx context.Context, inputs []HashChainInput) ([]*trust.HashChainEntry, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("batch is empty")
+	}
+	if len(inputs) > MaxIngestBatch {
+		return nil, fmt.Errorf("batch has %d events, at most %d are allowed", len(inputs), MaxIngestBatch)
+	}
+	sourceID := inputs[0].SourceID
+	if sourceID == "" {
+		return nil, fmt.Errorf("sourceId is required")
+	}
+
+	now := time.Now().UTC()
+	schemas := map[string]*compiledSchema{}
+	seen := make(map[uuid.UUID]bool, len(inputs))
+	links := make([]trust.ChainLink, 0, len(inputs))
+	for i, input := range inputs {
+		if input.SourceID != sourceID {
+			return nil, fmt.Errorf("event %d: all events of a batch must share source %q", i, sourceID)
+		}
+		if input.EventID == uuid.Nil {
+			input.EventID = uuid.New()
+		}
+		if seen[input.EventID] {
+			return nil, fmt.Errorf("event %d: duplicate event ID %s", i, input.EventID)
+		}
+		seen[input.EventID] = true
+
+		// Compute event hash
+		eventHash, err := trust.ComputeEventHash(&trust.EventHashInput{
+			ClientRecordID: input.ClientRecordID,
+			SourceType:     input.SourceType,
+			SourceID:       input.SourceID,
+			TsDevice:       input.TsDevice,
+			TsGateway:      input.TsGateway,
+			Key:            input.Key,
+			EventType:      input.EventType,
+			Payload:        input.Payload,
+			SchemaVersion:  input.SchemaVersion,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute event hash: %w", err)
+		}
 
-	if err := s.repo.InsertHashChainEntry(ctx, entry); err != nil {
-		return nil, fmt.Errorf("failed to insert hash chain entry: %w", err)
+		// Event evidence carries the server timestamp (T1) and is raised to
+		// T2 by the chain append.
+		links = append(links, trust.ChainLink{
+			Event: &trust.EventEvidence{
+				EventID:        input.EventID,
+				ClientRecordID: input.ClientRecordID,
+				SourceType:     input.SourceType,
+				SourceID:       input.SourceID,
+				TsDevice:       input.TsDevice,
+				TsGateway:      input.TsGateway,
+				TsServer:       now,
+				Key:            input.Key,
+				EventType:      input.EventType,
+				Payload:        input.Payload,
+				SchemaVersion:  input.SchemaVersion,
+				TrustLevel:     trust.TrustLevelT1,
+			},
+			EventHash: eventHash,
+		})
+
+		key := schemaKey(input.EventType, input.SchemaVersion)
+		schema, ok := schemas[key]
+		if !ok {
+			if schema, err = s.lookupSchema(ctx, input.EventType, input.SchemaVersion); err != nil {
+				return nil, err
+			}
+			schemas[key] = schema
+		}
+		if schema == nil {
+			continue
+		}
+		if errs := schema.schema.ValidatePayload(input.Payload); len(errs) > 0 {
+			if schema.entry.OnInvalid == trust.InvalidPayloadReject {
+				return nil, fmt.Errorf("event %d: %w: %s", i, trust.ErrPayloadInvalid, strings.Join(errs, "; "))
+			}
+			links[len(links)-1].Event.ValidationErrors = errs
+			s.logger.Warn().
+				Str("eventId", input.EventID.String()).
+				Str("eventType", input.EventType).
+				Str("schemaVersion", input.SchemaVersion).
+				Strs("errors", errs).
+				Msg("event payload flagged by schema validation")
+		}
 	}
 
-	// Update event trust level to T2
-	if err := s.repo.UpdateEventTrustLevel(ctx, input.EventID, trust.TrustLevelT2); err != nil {
-		s.logger.Warn().Err(err).
-			Str("eventId", input.EventID.String()).
-			Msg("failed to update event trust level")
+	entries, err := s.repo.AppendToChain(ctx, sourceID, links)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append to hash chain: %w", err)
 	}
 
+	last := entries[len(entries)-1]
 	s.logger.Debug().
-		Str("eventId", input.EventID.String()).
-		Str("sourceId", input.SourceID).
-		Int64("sequenceNum", sequenceNum).
-		Str("chainHash", entry.ChainHash).
-		Msg("event added to hash chain")
+		Str("sourceId", sourceID).
+		Int("events", len(entries)).
+		Int64("sequenceNum", last.SequenceNum).
+		Str("chainHash", last.ChainHash).
+		Msg("events added to hash chain")
+
+	events := make([]*trust.EventEvidence, len(links))
+	for i, link := range links {
+		link.Event.TrustLevel = link.Event.CapTrust(trust.TrustLevelT2)
+		events[i] = link.Event
+	}
+	for _, listener := range s.listeners {
+		if err := listener.HandleEvents(ctx, events); err != nil {
+			s.logger.Warn().Err(err).
+				Str("sourceId", sourceID).
+				Int("events", len(events)).
+				Msg("event listener failed")
+		}
+	}
 
-	return entry, nil
+	return entries, nil
 }
 
 // BatchSignatureInput represents input for registering a batch signature
@@ -129,6 +249,7 @@ type BatchSignatureInput struct {
 	SourceID     string
 	EventIDs     []uuid.UUID
 	BatchHash    string
+	HashScheme   string
 	Signature    string
 	SignatureAlg string
 	KeyID        string
@@ -137,6 +258,18 @@ type BatchSignatureInput struct {
 
 // RegisterBatchSignature registers a batch signature from a gateway
 func (s *Service) RegisterBatchSignature(ctx context.Context, input BatchSignatureInput) (*trust.BatchSignature, error) {
+	if input.SignatureAlg == "" {
+		input.SignatureAlg = trust.SignatureAlgHMACSHA256
+	}
+	if !trust.IsSupportedSignatureAlg(input.SignatureAlg) {
+		return nil, fmt.Errorf("%w: %q", trust.ErrUnsupportedSignatureAlg, input.SignatureAlg)
+	}
+	if input.HashScheme == "" {
+		input.HashScheme = trust.BatchHashSchemeMerkle
+	}
+	if !trust.IsSupportedBatchHashScheme(input.HashScheme) {
+		return nil, fmt.Errorf("%w: %q", trust.ErrUnsupportedBatchHashScheme, input.HashScheme)
+	}
 	sig := trust.NewBatchSignature(
 		input.SourceID,
 		input.EventIDs,
@@ -146,6 +279,16 @@ func (s *Service) RegisterBatchSignature(ctx context.Context, input BatchSignatu
 		input.KeyID,
 		input.SignedAt,
 	)
+	sig.HashScheme = input.HashScheme
+
+	// Events not chained yet are checked when the batch is verified
+	entries, err := s.repo.GetChainEntriesForEvents(ctx, sig.EventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch chain entries: %w", err)
+	}
+	if err := sig.CheckEventSources(entries); err != nil {
+		return nil, err
+	}
 
 	if err := s.repo.InsertBatchSignature(ctx, sig); err != nil {
 		return nil, fmt.Errorf("failed to insert batch signature: %w", err)
@@ -180,7 +323,10 @@ func (s *Service) GetBatchSignature(ctx context.Context, batchID uuid.UUID) (*tr
 	return sig, nil
 }
 
-// VerifyBatchSignature verifies a batch signature
+// VerifyBatchSignature verifies a batch signature. The batch events must
+// be in the signing source's chain, the signed batch hash must be the root
+// of their hashes under the batch's hash scheme, and the signature is
+// checked with the verifier of its algorithm.
 func (s *Service) VerifyBatchSignature(ctx context.Context, batchID uuid.UUID) (*trust.TrustVerificationResult, error) {
 	sig, err := s.repo.GetBatchSignature(ctx, batchID)
 	if err != nil {
@@ -190,18 +336,29 @@ func (s *Service) VerifyBatchSignature(ctx context.Context, batchID uuid.UUID) (
 		return nil, fmt.Errorf("batch signature not found")
 	}
 
-	// Get signing key
-	key, err := s.keyStore.GetKey(ctx, sig.KeyID)
+	verify, err := s.verifierFor(ctx, sig)
 	if err != nil {
 		errMsg := err.Error()
 		if updateErr := s.repo.UpdateBatchSignatureStatus(ctx, batchID, trust.VerificationStatusFailed, &errMsg); updateErr != nil {
 			s.logger.Warn().Err(updateErr).Msg("failed to update batch signature status")
 		}
+		s.settleBatch(ctx, sig, false)
 		return nil, fmt.Errorf("failed to get signing key: %w", err)
 	}
+	eventHashes, err := s.batchEventHashes(ctx, sig)
+	if err != nil && !errors.Is(err, trust.ErrBatchSourceMismatch) {
+		return nil, err
+	}
 
-	// Verify signature
-	isValid := trust.VerifyHMAC(sig.BatchHash, sig.Signature, key)
+	// Verify the event sources, the batch hash, then the signature
+	verifyErr := err
+	if verifyErr == nil {
+		verifyErr = sig.CheckBatchHash(eventHashes)
+	}
+	if verifyErr == nil {
+		verifyErr = verify()
+	}
+	isValid := verifyErr == nil
 
 	if isValid {
 		sig.MarkVerified()
@@ -210,20 +367,14 @@ func (s *Service) VerifyBatchSignature(ctx context.Context, batchID uuid.UUID) (
 		}
 
 		// Upgrade all events in batch to T3
-		for _, eventID := range sig.EventIDs {
-			if err := s.repo.UpdateEventTrustLevel(ctx, eventID, trust.TrustLevelT3); err != nil {
-				s.logger.Warn().Err(err).
-					Str("eventId", eventID.String()).
-					Msg("failed to update event trust level to T3")
-			}
-		}
+		s.settleBatch(ctx, sig, true)
 
 		s.logger.Info().
 			Str("batchId", batchID.String()).
 			Int("eventCount", len(sig.EventIDs)).
 			Msg("batch signature verified successfully")
 	} else {
-		errMsg := "signature verification failed"
+		errMsg := verifyErr.Error()
 		sig.MarkFailed(errMsg)
 		if err := s.repo.UpdateBatchSignatureStatus(ctx, batchID, trust.VerificationStatusFailed, &errMsg); err != nil {
 			s.logger.Warn().Err(err).Msg("failed to update batch signature status")
@@ -231,7 +382,10 @@ func (s *Service) VerifyBatchSignature(ctx context.Context, batchID uuid.UUID) (
 
 		s.logger.Warn().
 			Str("batchId", batchID.String()).
+			Str("keyId", sig.KeyID).
+			Str("reason", errMsg).
 			Msg("batch signature verification failed")
+		s.settleBatch(ctx, sig, false)
 	}
 
 	result := &trust.TrustVerificationResult{
@@ -244,12 +398,250 @@ func (s *Service) VerifyBatchSignature(ctx context.Context, batchID uuid.UUID) (
 	if isValid {
 		result.TrustLevel = trust.TrustLevelT3
 	} else {
-		result.Errors = append(result.Errors, "signature verification failed")
+		result.Errors = append(result.Errors, verifyErr.Error())
 	}
 
 	return result, nil
 }
 
+// settleBatch sets the trust level of sig's events after its check: T3, or
+// their lower trust ceiling, when verified and at most T2 when not. Events
+// of other sources are left alone. The events are then handed to the
+// verification listeners for re-evaluation.
+func (s *Service) settleBatch(ctx context.Context, sig *trust.BatchSignature, verified bool) {
+	loaded, err := s.repo.GetEvents(ctx, sig.EventIDs)
+	if err != nil {
+		s.logger.Warn().Err(err).
+			Str("batchId", sig.BatchID.String()).
+			Msg("failed to load batch events")
+		return
+	}
+	events := loaded[:0]
+	for _, event := range loaded {
+		if event.SourceID == sig.SourceID {
+			events = append(events, event)
+		}
+	}
+	v := &trust.BatchVerification{
+		Batch:          sig,
+		Verified:       verified,
+		Events:         events,
+		PreviousLevels: make(map[uuid.UUID]trust.TrustLevel, len(events)),
+	}
+	for i := range v.Events {
+		event := &v.Events[i]
+		v.PreviousLevels[event.EventID] = event.TrustLevel
+		level := event.TrustLevel
+		if verified {
+			level = event.CapTrust(trust.TrustLevelT3)
+		} else if level > trust.TrustLevelT2 {
+			level = trust.TrustLevelT2
+		}
+		if level == event.TrustLevel {
+			continue
+		}
+		if err := s.repo.UpdateEventTrustLevel(ctx, event.EventID, level); err != nil {
+			s.logger.Warn().Err(err).
+				Str("eventId", event.EventID.String()).
+				Int("trustLevel", int(level)).
+				Msg("failed to update event trust level")
+			continue
+		}
+		event.TrustLevel = level
+	}
+
+	for _, listener := range s.verifiers {
+		if err := listener.HandleBatchVerification(ctx, v); err != nil {
+			s.logger.Warn().Err(err).
+				Str("batchId", sig.BatchID.String()).
+				Msg("verification listener failed")
+		}
+	}
+}
+
+// verifierFor returns the check of sig's signature for its algorithm. Keys
+// in the registry take precedence; HMAC-SHA256 keys missing from it are
+// looked up in the key store.
+func (s *Service) verifierFor(ctx context.Context, sig *trust.BatchSignature) (func() error, error) {
+	alg := sig.SignatureAlg
+	if alg == "" {
+		alg = trust.SignatureAlgHMACSHA256
+	}
+	if !trust.IsSupportedSignatureAlg(alg) {
+		return func() error { return fmt.Errorf("%w: %q", trust.ErrUnsupportedSignatureAlg, alg) }, nil
+	}
+
+	if s.keys != nil {
+		key, err := s.keys.GetSourceKey(ctx, sig.KeyID)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			return func() error { return sig.VerifyWith(key) }, nil
+		}
+	}
+
+	if alg != trust.SignatureAlgHMACSHA256 || s.keyStore == nil {
+		return nil, fmt.Errorf("%w: %s", trust.ErrKeyNotFound, sig.KeyID)
+	}
+	secret, err := s.keyStore.GetKey(ctx, sig.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		if !trust.VerifyHMAC(sig.BatchHash, sig.Signature, secret) {
+			return trust.ErrSignatureInvalid
+		}
+		return nil
+	}, nil
+}
+
+// batchEventHashes returns the chained event hashes of sig's events in
+// batch order. Events chained by another source fail with
+// trust.ErrBatchSourceMismatch.
+func (s *Service) batchEventHashes(ctx context.Context, sig *trust.BatchSignature) ([]string, error) {
+	entries, err := s.repo.GetChainEntriesForEvents(ctx, sig.EventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch chain entries: %w", err)
+	}
+	if err := sig.CheckEventSources(entries); err != nil {
+		return nil, err
+	}
+	byEvent := make(map[uuid.UUID]string, len(entries))
+	for _, entry := range entries {
+		byEvent[entry.EventID] = entry.EventHash
+	}
+	hashes := make([]string, len(sig.EventIDs))
+	for i, eventID := range sig.EventIDs {
+		h, ok := byEvent[eventID]
+		if !ok {
+			return nil, fmt.Errorf("event %s of batch %s is not in the hash chain", eventID, sig.BatchID)
+		}
+		hashes[i] = h
+	}
+	return hashes, nil
+}
+
+// GetInclusionProof returns the proof that an event is part of its signed
+// batch, with the source's public key when it is registered
+func (s *Service) GetInclusionProof(ctx context.Context, eventID uuid.UUID) (*trust.InclusionProof, error) {
+	sig, err := s.repo.GetBatchSignatureForEvent(ctx, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get batch signature: %w", err)
+	}
+	if sig == nil {
+		return nil, trust.ErrBatchSignatureNotFound
+	}
+	eventHashes, err := s.batchEventHashes(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, id := range sig.EventIDs {
+		if id == eventID {
+			index = i
+			break
+		}
+	}
+	proof, err := trust.NewInclusionProof(sig, eventHashes, index)
+	if err != nil {
+		return nil, err
+	}
+	if s.keys != nil {
+		key, err := s.keys.GetSourceKey(ctx, sig.KeyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get source key: %w", err)
+		}
+		proof.SourceKey = key
+	}
+	return proof, nil
+}
+
+// SourceKeyInput represents input for registering a source public key
+type SourceKeyInput struct {
+	KeyID     string
+	SourceID  string
+	Algorithm string
+	PublicKey []byte
+	NotBefore *time.Time
+	NotAfter  *time.Time
+}
+
+// RegisterSourceKey adds a public key to the registry. It becomes active at
+// NotBefore, or immediately when that is not set.
+func (s *Service) RegisterSourceKey(ctx context.Context, input SourceKeyInput) (*trust.SourceKey, error) {
+	if s.keys == nil {
+		return nil, errKeyRegistryNotConfigured
+	}
+	notBefore := time.Now().UTC()
+	if input.NotBefore != nil {
+		notBefore = *input.NotBefore
+	}
+	key, err := trust.NewSourceKey(input.KeyID, input.SourceID, input.Algorithm, input.PublicKey, notBefore, input.NotAfter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.keys.CreateSourceKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to create source key: %w", err)
+	}
+
+	s.logger.Info().
+		Str("keyId", key.KeyID).
+		Str("sourceId", key.SourceID).
+		Str("algorithm", key.Algorithm).
+		Time("notBefore", key.NotBefore).
+		Msg("source key registered")
+
+	return key, nil
+}
+
+// ListSourceKeys lists the registered keys of a source, or of all sources
+// when sourceID is empty
+func (s *Service) ListSourceKeys(ctx context.Context, sourceID string) ([]*trust.SourceKey, error) {
+	if s.keys == nil {
+		return nil, errKeyRegistryNotConfigured
+	}
+	keys, err := s.keys.ListSourceKeys(ctx, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeSourceKey revokes a key as of at, or now when at is zero. Batches
+// signed from then on fail verification; a key can be revoked retroactively
+// when it was compromised earlier.
+func (s *Service) RevokeSourceKey(ctx context.Context, keyID string, at time.Time, reason string) (*trust.SourceKey, error) {
+	if s.keys == nil {
+		return nil, errKeyRegistryNotConfigured
+	}
+	key, err := s.keys.GetSourceKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source key: %w", err)
+	}
+	if key == nil {
+		return nil, trust.ErrKeyNotFound
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if err := key.Revoke(at, reason); err != nil {
+		return nil, err
+	}
+	if err := s.keys.RevokeSourceKey(ctx, keyID, *key.RevokedAt, reason); err != nil {
+		return nil, fmt.Errorf("failed to revoke source key: %w", err)
+	}
+
+	s.logger.Warn().
+		Str("keyId", keyID).
+		Str("sourceId", key.SourceID).
+		Time("revokedAt", *key.RevokedAt).
+		Str("reason", reason).
+		Msg("source key revoked")
+
+	return key, nil
+}
+
 // VerifyHashChain verifies the integrity of a hash chain for a source
 func (s *Service) VerifyHashChain(ctx context.Context, sourceID string, fromSeq, toSeq int64) (*ChainVerificationResult, error) {
 	startTime := time.Now()
@@ -282,6 +674,7 @@ func (s *Service) VerifyHashChain(ctx context.Context, sourceID string, fromSeq,
 			result.Breaks = append(result.Breaks, trust.ChainBreak{
 				SourceID:     sourceID,
 				BreakAt:      entry.SequenceNum,
+				Kind:         trust.ChainBreakHashMismatch,
 				ExpectedHash: trust.ComputeChainHash(entry.EventHash, entry.PrevHash),
 				ActualHash:   entry.ChainHash,
 				DetectedAt:   time.Now().UTC(),
@@ -296,6 +689,7 @@ func (s *Service) VerifyHashChain(ctx context.Context, sourceID string, fromSeq,
 				result.Breaks = append(result.Breaks, trust.ChainBreak{
 					SourceID:     sourceID,
 					BreakAt:      entry.SequenceNum,
+					Kind:         trust.ChainBreakLinkMismatch,
 					ExpectedHash: prevEntry.ChainHash,
 					ActualHash:   entry.PrevHash,
 					DetectedAt:   time.Now().UTC(),
@@ -353,6 +747,23 @@ func (s *Service) GenerateEvidenceBundle(ctx context.Context, bundleType string,
 		bundle.Signatures = append(bundle.Signatures, *sig)
 	}
 
+	// Prove the bundle's events are part of their signed batches, which the
+	// bundle rarely holds in full
+	bundleEvents := make(map[uuid.UUID]bool, len(data.Events))
+	for _, event := range data.Events {
+		bundleEvents[event.EventID] = true
+	}
+	for _, sig := range data.Signatures {
+		proofs, err := s.inclusionProofs(ctx, sig, bundleEvents)
+		if err != nil {
+			s.logger.Warn().Err(err).
+				Str("batchId", sig.BatchID.String()).
+				Msg("failed to build inclusion proofs")
+			continue
+		}
+		bundle.InclusionProofs = append(bundle.InclusionProofs, proofs...)
+	}
+
 	bundle.Rules = data.Rules
 	bundle.Actions = data.Actions
 
@@ -363,6 +774,7 @@ func (s *Service) GenerateEvidenceBundle(ctx context.Context, bundleType string,
 	if err := bundle.Finalize(); err != nil {
 		return nil, fmt.Errorf("failed to finalize bundle: %w", err)
 	}
+	s.timestampBundle(ctx, bundle)
 
 	s.logger.Info().
 		Str("bundleId", bundle.BundleID.String()).
@@ -371,12 +783,34 @@ func (s *Service) GenerateEvidenceBundle(ctx context.Context, bundleType string,
 		Int("eventsCount", len(bundle.Events)).
 		Int("chainEntriesCount", len(bundle.HashChain)).
 		Int("signaturesCount", len(bundle.Signatures)).
+		Int("inclusionProofsCount", len(bundle.InclusionProofs)).
 		Str("trustLevel", bundle.Verification.OverallTrustLevel.String()).
+		Bool("timestamped", len(bundle.TimestampToken) > 0).
 		Msg("evidence bundle generated")
 
 	return bundle, nil
 }
 
+// inclusionProofs builds the proofs of sig's events that are in events
+func (s *Service) inclusionProofs(ctx context.Context, sig *trust.BatchSignature, events map[uuid.UUID]bool) ([]trust.InclusionProof, error) {
+	eventHashes, err := s.batchEventHashes(ctx, sig)
+	if err != nil {
+		return nil, err
+	}
+	var proofs []trust.InclusionProof
+	for i, eventID := range sig.EventIDs {
+		if !events[eventID] {
+			continue
+		}
+		proof, err := trust.NewInclusionProof(sig, eventHashes, i)
+		if err != nil {
+			return nil, err
+		}
+		proofs = append(proofs, *proof)
+	}
+	return proofs, nil
+}
+
 // computeVerificationSummary computes the verification summary for a bundle
 func (s *Service) computeVerificationSummary(bundle *trust.EvidenceBundle) trust.VerificationSummary {
 	summary := trust.VerificationSummary{
@@ -441,6 +875,24 @@ func (s *Service) computeVerificationSummary(bundle *trust.EvidenceBundle) trust
 		summary.SignatureValid = &allVerified
 	}
 
+	// Flag inputs that are not signature verified
+	ruleMinimum := trust.TrustLevelT0
+	for _, r := range bundle.Rules {
+		if r.MinTrustLevel > ruleMinimum {
+			ruleMinimum = r.MinTrustLevel
+		}
+	}
+	for _, event := range bundle.Events {
+		if event.TrustLevel < trust.TrustLevelT3 {
+			summary.LowTrustInputs = append(summary.LowTrustInputs, trust.LowTrustInput{
+				EventID:          event.EventID,
+				SourceID:         event.SourceID,
+				TrustLevel:       event.TrustLevel,
+				BelowRuleMinimum: event.TrustLevel < ruleMinimum,
+			})
+		}
+	}
+
 	// Downgrade trust level if verification failed
 	if !summary.HashChainValid && summary.OverallTrustLevel >= trust.TrustLevelT2 {
 		summary.OverallTrustLevel = trust.TrustLevelT1
@@ -2,7 +2,15 @@ package trust
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +21,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/execution-hub/execution-hub/internal/domain/trust"
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
 )
 
 // MockRepository is a mock implementation of trust.Repository
@@ -41,11 +51,30 @@ func (m *MockRepository) GetEvents(ctx context.Context, eventIDs []uuid.UUID) ([
 	return args.Get(0).([]trust.EventEvidence), args.Error(1)
 }
 
+func (m *MockRepository) ListChainedEvents(ctx context.Context, filter trust.EventRangeFilter, limit int) ([]trust.EventEvidence, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]trust.EventEvidence), args.Error(1)
+}
+
 func (m *MockRepository) InsertHashChainEntry(ctx context.Context, entry *trust.HashChainEntry) error {
 	args := m.Called(ctx, entry)
 	return args.Error(0)
 }
 
+// AppendToChain extends the chain head configured with Return, or an empty
+// chain when it is nil
+func (m *MockRepository) AppendToChain(ctx context.Context, sourceID string, links []trust.ChainLink) ([]*trust.HashChainEntry, error) {
+	args := m.Called(ctx, sourceID, links)
+	if err := args.Error(1); err != nil {
+		return nil, err
+	}
+	latest, _ := args.Get(0).(*trust.HashChainEntry)
+	return trust.ExtendChain(latest, sourceID, links), nil
+}
+
 func (m *MockRepository) GetHashChainEntry(ctx context.Context, eventID uuid.UUID) (*trust.HashChainEntry, error) {
 	args := m.Called(ctx, eventID)
 	if args.Get(0) == nil {
@@ -162,6 +191,37 @@ func (m *MockKeyStore) GetKeyForSource(ctx context.Context, sourceID string) (st
 	return args.String(0), args.Get(1).([]byte), args.Error(2)
 }
 
+// MockKeyRegistry is a mock implementation of trust.KeyRegistry
+type MockKeyRegistry struct {
+	mock.Mock
+}
+
+func (m *MockKeyRegistry) CreateSourceKey(ctx context.Context, key *trust.SourceKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockKeyRegistry) GetSourceKey(ctx context.Context, keyID string) (*trust.SourceKey, error) {
+	args := m.Called(ctx, keyID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*trust.SourceKey), args.Error(1)
+}
+
+func (m *MockKeyRegistry) ListSourceKeys(ctx context.Context, sourceID string) ([]*trust.SourceKey, error) {
+	args := m.Called(ctx, sourceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*trust.SourceKey), args.Error(1)
+}
+
+func (m *MockKeyRegistry) RevokeSourceKey(ctx context.Context, keyID string, revokedAt time.Time, reason string) error {
+	args := m.Called(ctx, keyID, revokedAt, reason)
+	return args.Error(0)
+}
+
 func TestService_AddToHashChain(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -185,10 +245,7 @@ func TestService_AddToHashChain(t *testing.T) {
 		}
 
 		// No previous entry (genesis)
-		mockRepo.On("InsertEvent", ctx, mock.AnythingOfType("*trust.EventEvidence")).Return(nil)
-		mockRepo.On("GetLatestChainEntry", ctx, sourceID).Return(nil, nil)
-		mockRepo.On("InsertHashChainEntry", ctx, mock.AnythingOfType("*trust.HashChainEntry")).Return(nil)
-		mockRepo.On("UpdateEventTrustLevel", ctx, eventID, trust.TrustLevelT2).Return(nil)
+		mockRepo.On("AppendToChain", ctx, sourceID, mock.AnythingOfType("[]trust.ChainLink")).Return(nil, nil)
 
 		entry, err := svc.AddToHashChain(ctx, input)
 
@@ -234,10 +291,7 @@ func TestService_AddToHashChain(t *testing.T) {
 			SchemaVersion:  "1.0.0",
 		}
 
-		mockRepo.On("GetLatestChainEntry", ctx, sourceID).Return(prevEntry, nil)
-		mockRepo.On("InsertEvent", ctx, mock.AnythingOfType("*trust.EventEvidence")).Return(nil)
-		mockRepo.On("InsertHashChainEntry", ctx, mock.AnythingOfType("*trust.HashChainEntry")).Return(nil)
-		mockRepo.On("UpdateEventTrustLevel", ctx, eventID, trust.TrustLevelT2).Return(nil)
+		mockRepo.On("AppendToChain", ctx, sourceID, mock.AnythingOfType("[]trust.ChainLink")).Return(prevEntry, nil)
 
 		entry, err := svc.AddToHashChain(ctx, input)
 
@@ -248,6 +302,210 @@ func TestService_AddToHashChain(t *testing.T) {
 
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("notifies listeners and ignores their errors", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		mockKeyStore := new(MockKeyStore)
+		svc := NewService(mockRepo, mockKeyStore, logger)
+		listener := &recordingListener{err: assert.AnError}
+		svc.AddEventListener(listener)
+
+		eventID := uuid.New()
+		mockRepo.On("AppendToChain", ctx, "gateway-001", mock.AnythingOfType("[]trust.ChainLink")).Return(nil, nil)
+
+		_, err := svc.AddToHashChain(ctx, HashChainInput{
+			EventID:       eventID,
+			SourceID:      "gateway-001",
+			SourceType:    "GW",
+			EventType:     "SENSOR_READING",
+			Payload:       json.RawMessage(`{"temperature": 30.0}`),
+			SchemaVersion: "1.0.0",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, listener.events, 1)
+		assert.Equal(t, eventID, listener.events[0].EventID)
+		assert.Equal(t, trust.TrustLevelT2, listener.events[0].TrustLevel)
+	})
+}
+
+func TestService_IngestBatch(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	newInput := func(sourceID string, i int) HashChainInput {
+		return HashChainInput{
+			EventID:       uuid.New(),
+			SourceID:      sourceID,
+			SourceType:    "GW",
+			EventType:     "SENSOR_READING",
+			Payload:       json.RawMessage(fmt.Sprintf(`{"reading": %d}`, i)),
+			SchemaVersion: "1.0.0",
+		}
+	}
+
+	t.Run("appends batch as one chain extension", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		svc := NewService(mockRepo, new(MockKeyStore), logger)
+		prevEntry := trust.NewHashChainEntry(uuid.New(), "gateway-001", 7, "eventHash7", "")
+		mockRepo.On("AppendToChain", ctx, "gateway-001", mock.AnythingOfType("[]trust.ChainLink")).Return(prevEntry, nil).Once()
+
+		inputs := []HashChainInput{newInput("gateway-001", 1), newInput("gateway-001", 2), newInput("gateway-001", 3)}
+		entries, err := svc.IngestBatch(ctx, inputs)
+
+		require.NoError(t, err)
+		require.Len(t, entries, 3)
+		prevHash := prevEntry.ChainHash
+		for i, entry := range entries {
+			assert.Equal(t, inputs[i].EventID, entry.EventID)
+			assert.Equal(t, int64(8+i), entry.SequenceNum)
+			assert.Equal(t, prevHash, entry.PrevHash)
+			assert.True(t, entry.Verify())
+			prevHash = entry.ChainHash
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid batches", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		svc := NewService(mockRepo, new(MockKeyStore), logger)
+		duplicate := newInput("gateway-001", 1)
+
+		cases := map[string][]HashChainInput{
+			"empty":            nil,
+			"missing source":   {newInput("", 1)},
+			"mixed sources":    {newInput("gateway-001", 1), newInput("gateway-002", 2)},
+			"duplicate events": {duplicate, duplicate},
+			"too large":        make([]HashChainInput, MaxIngestBatch+1),
+		}
+		for name, inputs := range cases {
+			_, err := svc.IngestBatch(ctx, inputs)
+			assert.Error(t, err, name)
+		}
+		mockRepo.AssertNotCalled(t, "AppendToChain", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("concurrent appends keep every chain contiguous", func(t *testing.T) {
+		repo := newChainRepository()
+		svc := NewService(repo, new(MockKeyStore), logger)
+		sources := []string{"gateway-001", "gateway-002"}
+		const workers, rounds = 16, 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*rounds)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				sourceID := sources[w%len(sources)]
+				for r := 0; r < rounds; r++ {
+					// Mix single appends with batches of up to three events.
+					inputs := make([]HashChainInput, r%3+1)
+					for i := range inputs {
+						inputs[i] = newInput(sourceID, r)
+					}
+					if _, err := svc.IngestBatch(ctx, inputs); err != nil {
+						errs <- err
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		var perWorker int64
+		for r := 0; r < rounds; r++ {
+			perWorker += int64(r%3 + 1)
+		}
+		perSource := perWorker * workers / int64(len(sources))
+		for _, sourceID := range sources {
+			entries := repo.chains[sourceID]
+			require.Len(t, entries, int(perSource))
+			for i, entry := range entries {
+				assert.Equal(t, int64(i+1), entry.SequenceNum)
+			}
+			result, err := svc.VerifyHashChain(ctx, sourceID, 1, perSource)
+			require.NoError(t, err)
+			assert.True(t, result.IsValid, sourceID)
+			assert.Equal(t, int(perSource), result.EntriesChecked)
+		}
+	})
+}
+
+// chainRepository keeps hash chains in memory and serialises appends per
+// source, as the Postgres repository does with advisory locks
+type chainRepository struct {
+	MockRepository
+	mu     sync.Mutex
+	locks  map[string]*sync.Mutex
+	chains map[string][]*trust.HashChainEntry
+}
+
+func newChainRepository() *chainRepository {
+	return &chainRepository{
+		locks:  make(map[string]*sync.Mutex),
+		chains: make(map[string][]*trust.HashChainEntry),
+	}
+}
+
+func (r *chainRepository) lock(sourceID string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, ok := r.locks[sourceID]
+	if !ok {
+		l = &sync.Mutex{}
+		r.locks[sourceID] = l
+	}
+	return l
+}
+
+func (r *chainRepository) AppendToChain(_ context.Context, sourceID string, links []trust.ChainLink) ([]*trust.HashChainEntry, error) {
+	l := r.lock(sourceID)
+	l.Lock()
+	defer l.Unlock()
+
+	r.mu.Lock()
+	chain := r.chains[sourceID]
+	r.mu.Unlock()
+	var latest *trust.HashChainEntry
+	if len(chain) > 0 {
+		latest = chain[len(chain)-1]
+	}
+	// Yield between reading the head and writing so that an unserialised
+	// implementation would fork the chain.
+	runtime.Gosched()
+	entries := trust.ExtendChain(latest, sourceID, links)
+
+	r.mu.Lock()
+	r.chains[sourceID] = append(r.chains[sourceID], entries...)
+	r.mu.Unlock()
+	return entries, nil
+}
+
+func (r *chainRepository) GetChainEntriesForSource(_ context.Context, sourceID string, fromSeq, toSeq int64) ([]*trust.HashChainEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []*trust.HashChainEntry
+	for _, entry := range r.chains[sourceID] {
+		if entry.SequenceNum >= fromSeq && entry.SequenceNum <= toSeq {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+type recordingListener struct {
+	events  []*trust.EventEvidence
+	batches int
+	err     error
+}
+
+func (l *recordingListener) HandleEvents(_ context.Context, events []*trust.EventEvidence) error {
+	l.events = append(l.events, events...)
+	l.batches++
+	return l.err
 }
 
 func TestService_RegisterBatchSignature(t *testing.T) {
@@ -269,7 +527,10 @@ func TestService_RegisterBatchSignature(t *testing.T) {
 		SignedAt:     time.Now().UTC(),
 	}
 
-	mockRepo.On("InsertBatchSignature", ctx, mock.AnythingOfType("*trust.BatchSignature")).Return(nil)
+	mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return([]*trust.HashChainEntry{
+		trust.NewHashChainEntry(eventIDs[0], "gateway-001", 1, "eventHash1", ""),
+	}, nil).Once()
+	mockRepo.On("InsertBatchSignature", ctx, mock.AnythingOfType("*trust.BatchSignature")).Return(nil).Once()
 
 	sig, err := svc.RegisterBatchSignature(ctx, input)
 
@@ -279,6 +540,16 @@ func TestService_RegisterBatchSignature(t *testing.T) {
 	assert.Equal(t, input.EventIDs, sig.EventIDs)
 	assert.Equal(t, trust.VerificationStatusPending, sig.VerificationStatus)
 
+	t.Run("rejects events of another source", func(t *testing.T) {
+		mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return([]*trust.HashChainEntry{
+			trust.NewHashChainEntry(eventIDs[0], "gateway-001", 1, "eventHash1", ""),
+			trust.NewHashChainEntry(eventIDs[1], "gateway-002", 1, "eventHash2", ""),
+		}, nil).Once()
+
+		_, err := svc.RegisterBatchSignature(ctx, input)
+		assert.ErrorIs(t, err, trust.ErrBatchSourceMismatch)
+	})
+
 	mockRepo.AssertExpectations(t)
 }
 
@@ -293,8 +564,13 @@ func TestService_VerifyBatchSignature(t *testing.T) {
 
 		batchID := uuid.New()
 		eventIDs := []uuid.UUID{uuid.New(), uuid.New()}
+		entries := []*trust.HashChainEntry{
+			trust.NewHashChainEntry(eventIDs[0], "gateway-001", 1, "eventHash1", ""),
+			trust.NewHashChainEntry(eventIDs[1], "gateway-001", 2, "eventHash2", ""),
+		}
 		key := []byte("test-secret-key")
-		batchHash := "testBatchHash"
+		// Legacy flat batch hash
+		batchHash := trust.ComputeBatchHash([]string{"eventHash1", "eventHash2"})
 		signature := trust.CreateHMAC(batchHash, key)
 
 		sig := &trust.BatchSignature{
@@ -311,7 +587,9 @@ func TestService_VerifyBatchSignature(t *testing.T) {
 
 		mockRepo.On("GetBatchSignature", ctx, batchID).Return(sig, nil)
 		mockKeyStore.On("GetKey", ctx, "key-001").Return(key, nil)
+		mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return(entries, nil)
 		mockRepo.On("UpdateBatchSignatureStatus", ctx, batchID, trust.VerificationStatusVerified, (*string)(nil)).Return(nil)
+		mockRepo.On("GetEvents", ctx, eventIDs).Return(chainedEvents(eventIDs...), nil)
 		mockRepo.On("UpdateEventTrustLevel", ctx, mock.AnythingOfType("uuid.UUID"), trust.TrustLevelT3).Return(nil)
 
 		result, err := svc.VerifyBatchSignature(ctx, batchID)
@@ -334,12 +612,14 @@ func TestService_VerifyBatchSignature(t *testing.T) {
 
 		batchID := uuid.New()
 		key := []byte("test-secret-key")
+		eventID := uuid.New()
+		entry := trust.NewHashChainEntry(eventID, "gateway-001", 1, "eventHash1", "")
 
 		sig := &trust.BatchSignature{
 			BatchID:            batchID,
 			SourceID:           "gateway-001",
-			EventIDs:           []uuid.UUID{uuid.New()},
-			BatchHash:          "batchHash",
+			EventIDs:           []uuid.UUID{eventID},
+			BatchHash:          trust.ComputeBatchHash([]string{"eventHash1"}),
 			Signature:          "invalidSignature",
 			SignatureAlg:       "HMAC-SHA256",
 			KeyID:              "key-001",
@@ -349,7 +629,9 @@ func TestService_VerifyBatchSignature(t *testing.T) {
 
 		mockRepo.On("GetBatchSignature", ctx, batchID).Return(sig, nil)
 		mockKeyStore.On("GetKey", ctx, "key-001").Return(key, nil)
+		mockRepo.On("GetChainEntriesForEvents", ctx, []uuid.UUID{eventID}).Return([]*trust.HashChainEntry{entry}, nil)
 		mockRepo.On("UpdateBatchSignatureStatus", ctx, batchID, trust.VerificationStatusFailed, mock.AnythingOfType("*string")).Return(nil)
+		mockRepo.On("GetEvents", ctx, []uuid.UUID{eventID}).Return(chainedEvents(eventID), nil)
 
 		result, err := svc.VerifyBatchSignature(ctx, batchID)
 
@@ -357,13 +639,417 @@ func TestService_VerifyBatchSignature(t *testing.T) {
 		assert.Equal(t, trust.TrustLevelT2, result.TrustLevel)
 		assert.NotNil(t, result.SignatureValid)
 		assert.False(t, *result.SignatureValid)
-		assert.NotEmpty(t, result.Errors)
+		assert.Equal(t, []string{trust.ErrSignatureInvalid.Error()}, result.Errors)
 
 		mockRepo.AssertExpectations(t)
 		mockKeyStore.AssertExpectations(t)
 	})
 }
 
+func TestService_VerifyBatchSignatureRejectsOtherSourcesEvents(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockRepository)
+	mockKeyStore := new(MockKeyStore)
+	svc := NewService(mockRepo, mockKeyStore, zerolog.Nop())
+
+	// gateway-001 signs a batch of gateway-002's events with its own key
+	batchID := uuid.New()
+	eventIDs := []uuid.UUID{uuid.New()}
+	key := []byte("gateway-001-secret")
+	batchHash := trust.ComputeBatchHash([]string{"eventHash1"})
+	sig := &trust.BatchSignature{
+		BatchID:            batchID,
+		SourceID:           "gateway-001",
+		EventIDs:           eventIDs,
+		BatchHash:          batchHash,
+		Signature:          trust.CreateHMAC(batchHash, key),
+		SignatureAlg:       "HMAC-SHA256",
+		KeyID:              "key-001",
+		SignedAt:           time.Now().UTC(),
+		VerificationStatus: trust.VerificationStatusPending,
+	}
+	victim := chainedEvents(eventIDs...)
+	victim[0].SourceID = "gateway-002"
+	victim[0].TrustLevel = trust.TrustLevelT3
+
+	mockRepo.On("GetBatchSignature", ctx, batchID).Return(sig, nil)
+	mockKeyStore.On("GetKey", ctx, "key-001").Return(key, nil)
+	mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return([]*trust.HashChainEntry{
+		trust.NewHashChainEntry(eventIDs[0], "gateway-002", 1, "eventHash1", ""),
+	}, nil)
+	mockRepo.On("UpdateBatchSignatureStatus", ctx, batchID, trust.VerificationStatusFailed, mock.AnythingOfType("*string")).Return(nil)
+	mockRepo.On("GetEvents", ctx, eventIDs).Return(victim, nil)
+
+	result, err := svc.VerifyBatchSignature(ctx, batchID)
+	require.NoError(t, err)
+	assert.False(t, *result.SignatureValid)
+	assert.Contains(t, result.Errors[0], trust.ErrBatchSourceMismatch.Error())
+	// The other source's events keep their trust level
+	mockRepo.AssertNotCalled(t, "UpdateEventTrustLevel", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+// chainedEvents returns events at T2, as stored by the chain append
+func chainedEvents(eventIDs ...uuid.UUID) []trust.EventEvidence {
+	events := make([]trust.EventEvidence, len(eventIDs))
+	for i, eventID := range eventIDs {
+		events[i] = trust.EventEvidence{EventID: eventID, SourceID: "gateway-001", TrustLevel: trust.TrustLevelT2}
+	}
+	return events
+}
+
+// recordingVerifier records the batch verifications it receives
+type recordingVerifier struct {
+	received []*trust.BatchVerification
+}
+
+func (r *recordingVerifier) HandleBatchVerification(_ context.Context, v *trust.BatchVerification) error {
+	r.received = append(r.received, v)
+	return errors.New("listener failure")
+}
+
+func TestService_VerifyBatchSignatureNotifiesListeners(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("test-secret-key")
+	eventIDs := []uuid.UUID{uuid.New(), uuid.New()}
+	entries := []*trust.HashChainEntry{
+		trust.NewHashChainEntry(eventIDs[0], "gateway-001", 1, "eventHash1", ""),
+		trust.NewHashChainEntry(eventIDs[1], "gateway-001", 2, "eventHash2", ""),
+	}
+	batchHash := trust.ComputeMerkleRoot([]string{"eventHash1", "eventHash2"})
+	newSig := func(signature string) *trust.BatchSignature {
+		return &trust.BatchSignature{
+			BatchID:            uuid.New(),
+			SourceID:           "gateway-001",
+			EventIDs:           eventIDs,
+			BatchHash:          batchHash,
+			HashScheme:         trust.BatchHashSchemeMerkle,
+			Signature:          signature,
+			SignatureAlg:       trust.SignatureAlgHMACSHA256,
+			KeyID:              "key-001",
+			SignedAt:           time.Now().UTC(),
+			VerificationStatus: trust.VerificationStatusPending,
+		}
+	}
+	setup := func(sig *trust.BatchSignature, events []trust.EventEvidence) (*Service, *MockRepository, *recordingVerifier) {
+		mockRepo := new(MockRepository)
+		mockKeyStore := new(MockKeyStore)
+		svc := NewService(mockRepo, mockKeyStore, zerolog.Nop())
+		verifier := &recordingVerifier{}
+		svc.AddVerificationListener(verifier)
+		mockRepo.On("GetBatchSignature", ctx, sig.BatchID).Return(sig, nil)
+		mockKeyStore.On("GetKey", ctx, "key-001").Return(key, nil)
+		mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return(entries, nil)
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("GetEvents", ctx, eventIDs).Return(events, nil)
+		return svc, mockRepo, verifier
+	}
+
+	t.Run("verified batch is raised to T3", func(t *testing.T) {
+		sig := newSig(trust.CreateHMAC(batchHash, key))
+		svc, mockRepo, verifier := setup(sig, chainedEvents(eventIDs...))
+		mockRepo.On("UpdateEventTrustLevel", ctx, mock.AnythingOfType("uuid.UUID"), trust.TrustLevelT3).Return(nil).Times(2)
+
+		_, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+		require.NoError(t, err, "listener errors do not fail verification")
+
+		require.Len(t, verifier.received, 1)
+		v := verifier.received[0]
+		assert.True(t, v.Verified)
+		assert.Equal(t, sig.BatchID, v.Batch.BatchID)
+		for _, event := range v.Events {
+			assert.Equal(t, trust.TrustLevelT3, event.TrustLevel)
+			assert.Equal(t, trust.TrustLevelT2, v.PreviousLevels[event.EventID])
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("failed batch drops verified events to T2", func(t *testing.T) {
+		sig := newSig("forged")
+		events := chainedEvents(eventIDs...)
+		events[0].TrustLevel = trust.TrustLevelT3
+		svc, mockRepo, verifier := setup(sig, events)
+		mockRepo.On("UpdateEventTrustLevel", ctx, eventIDs[0], trust.TrustLevelT2).Return(nil).Once()
+
+		result, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+		require.NoError(t, err)
+		assert.False(t, *result.SignatureValid)
+
+		require.Len(t, verifier.received, 1)
+		v := verifier.received[0]
+		assert.False(t, v.Verified)
+		assert.Equal(t, trust.TrustLevelT2, v.Events[0].TrustLevel)
+		assert.Equal(t, trust.TrustLevelT3, v.PreviousLevels[eventIDs[0]])
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("verified batch keeps schema-flagged events at T1", func(t *testing.T) {
+		sig := newSig(trust.CreateHMAC(batchHash, key))
+		events := chainedEvents(eventIDs...)
+		events[1].TrustLevel = trust.TrustLevelT1
+		events[1].ValidationErrors = []string{`$: missing required property "reading"`}
+		svc, mockRepo, verifier := setup(sig, events)
+		mockRepo.On("UpdateEventTrustLevel", ctx, eventIDs[0], trust.TrustLevelT3).Return(nil).Once()
+
+		_, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+		require.NoError(t, err)
+
+		require.Len(t, verifier.received, 1)
+		assert.Equal(t, trust.TrustLevelT1, verifier.received[0].Events[1].TrustLevel)
+		mockRepo.AssertNotCalled(t, "UpdateEventTrustLevel", ctx, eventIDs[1], mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestService_VerifyBatchSignatureWithKeyRegistry(t *testing.T) {
+	ctx := context.Background()
+	logger := zerolog.Nop()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	eventIDs := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	var entries []*trust.HashChainEntry
+	var eventHashes []string
+	for i, eventID := range eventIDs {
+		entry := trust.NewHashChainEntry(eventID, "gateway-001", int64(i+1), fmt.Sprintf("eventHash%d", i+1), "")
+		entries = append(entries, entry)
+		eventHashes = append(eventHashes, entry.EventHash)
+	}
+	batchHash := trust.ComputeMerkleRoot(eventHashes)
+	signature := hex.EncodeToString(ed25519.Sign(priv, []byte(batchHash)))
+	signedAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	newKey := func(t *testing.T) *trust.SourceKey {
+		key, err := trust.NewSourceKey("key-ed", "gateway-001", trust.SignatureAlgEd25519, pub, signedAt.Add(-24*time.Hour), nil)
+		require.NoError(t, err)
+		return key
+	}
+	newSig := func(alg string) *trust.BatchSignature {
+		return &trust.BatchSignature{
+			BatchID:            uuid.New(),
+			SourceID:           "gateway-001",
+			EventIDs:           eventIDs,
+			BatchHash:          batchHash,
+			HashScheme:         trust.BatchHashSchemeMerkle,
+			Signature:          signature,
+			SignatureAlg:       alg,
+			KeyID:              "key-ed",
+			SignedAt:           signedAt,
+			VerificationStatus: trust.VerificationStatusPending,
+		}
+	}
+	setup := func(sig *trust.BatchSignature, key *trust.SourceKey) (*Service, *MockRepository) {
+		mockRepo := new(MockRepository)
+		registry := new(MockKeyRegistry)
+		svc := NewService(mockRepo, new(MockKeyStore), logger)
+		svc.SetKeyRegistry(registry)
+		mockRepo.On("GetBatchSignature", ctx, sig.BatchID).Return(sig, nil)
+		registry.On("GetSourceKey", ctx, sig.KeyID).Return(key, nil)
+		mockRepo.On("GetChainEntriesForEvents", ctx, sig.EventIDs).Return(entries, nil).Maybe()
+		mockRepo.On("GetEvents", ctx, sig.EventIDs).Return(chainedEvents(sig.EventIDs...), nil).Maybe()
+		return svc, mockRepo
+	}
+	expectFailure := func(t *testing.T, sig *trust.BatchSignature, key *trust.SourceKey, reason string) {
+		svc, mockRepo := setup(sig, key)
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, trust.VerificationStatusFailed, mock.AnythingOfType("*string")).Return(nil)
+
+		result, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+
+		require.NoError(t, err)
+		assert.Equal(t, trust.TrustLevelT2, result.TrustLevel)
+		assert.False(t, *result.SignatureValid)
+		require.Len(t, result.Errors, 1)
+		assert.Contains(t, result.Errors[0], reason)
+		mockRepo.AssertNotCalled(t, "UpdateEventTrustLevel", mock.Anything, mock.Anything, mock.Anything)
+	}
+
+	t.Run("verifies Ed25519 signature", func(t *testing.T) {
+		sig := newSig(trust.SignatureAlgEd25519)
+		svc, mockRepo := setup(sig, newKey(t))
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, trust.VerificationStatusVerified, (*string)(nil)).Return(nil)
+		mockRepo.On("UpdateEventTrustLevel", ctx, mock.AnythingOfType("uuid.UUID"), trust.TrustLevelT3).Return(nil).Times(len(eventIDs))
+
+		result, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+
+		require.NoError(t, err)
+		assert.Equal(t, trust.TrustLevelT3, result.TrustLevel)
+		assert.True(t, *result.SignatureValid)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("accepts signature made before revocation", func(t *testing.T) {
+		key := newKey(t)
+		require.NoError(t, key.Revoke(signedAt.Add(time.Minute), "rotated"))
+		sig := newSig(trust.SignatureAlgEd25519)
+		svc, mockRepo := setup(sig, key)
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, trust.VerificationStatusVerified, (*string)(nil)).Return(nil)
+		mockRepo.On("UpdateEventTrustLevel", ctx, mock.AnythingOfType("uuid.UUID"), trust.TrustLevelT3).Return(nil)
+
+		result, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+
+		require.NoError(t, err)
+		assert.True(t, *result.SignatureValid)
+	})
+
+	t.Run("rejects key revoked as of signing time", func(t *testing.T) {
+		key := newKey(t)
+		require.NoError(t, key.Revoke(signedAt.Add(-time.Minute), "compromised"))
+		expectFailure(t, newSig(trust.SignatureAlgEd25519), key, "signing key revoked")
+	})
+
+	t.Run("rejects signature outside activation window", func(t *testing.T) {
+		key := newKey(t)
+		key.NotBefore = signedAt.Add(time.Hour)
+		expectFailure(t, newSig(trust.SignatureAlgEd25519), key, "signing key not active")
+	})
+
+	t.Run("rejects algorithm mismatch", func(t *testing.T) {
+		expectFailure(t, newSig(trust.SignatureAlgECDSAP256), newKey(t), "is for Ed25519")
+	})
+
+	t.Run("rejects key of another source", func(t *testing.T) {
+		key := newKey(t)
+		key.SourceID = "gateway-002"
+		expectFailure(t, newSig(trust.SignatureAlgEd25519), key, "belongs to source gateway-002")
+	})
+
+	t.Run("rejects forged signature", func(t *testing.T) {
+		sig := newSig(trust.SignatureAlgEd25519)
+		sig.Signature = hex.EncodeToString(make([]byte, ed25519.SignatureSize))
+		expectFailure(t, sig, newKey(t), "signature verification failed")
+	})
+
+	t.Run("rejects batch hash not matching events", func(t *testing.T) {
+		sig := newSig(trust.SignatureAlgEd25519)
+		sig.BatchHash = trust.ComputeMerkleRoot(eventHashes[:2])
+		sig.Signature = hex.EncodeToString(ed25519.Sign(priv, []byte(sig.BatchHash)))
+		expectFailure(t, sig, newKey(t), trust.ErrBatchHashMismatch.Error())
+	})
+
+	t.Run("verifies legacy flat batch", func(t *testing.T) {
+		sig := newSig(trust.SignatureAlgEd25519)
+		sig.HashScheme = ""
+		sig.BatchHash = trust.ComputeBatchHash(eventHashes)
+		sig.Signature = hex.EncodeToString(ed25519.Sign(priv, []byte(sig.BatchHash)))
+		svc, mockRepo := setup(sig, newKey(t))
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, trust.VerificationStatusVerified, (*string)(nil)).Return(nil)
+		mockRepo.On("UpdateEventTrustLevel", ctx, mock.AnythingOfType("uuid.UUID"), trust.TrustLevelT3).Return(nil)
+
+		result, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+
+		require.NoError(t, err)
+		assert.True(t, *result.SignatureValid)
+	})
+
+	t.Run("fails when asymmetric key is not registered", func(t *testing.T) {
+		sig := newSig(trust.SignatureAlgEd25519)
+		svc, mockRepo := setup(sig, nil)
+		mockRepo.On("UpdateBatchSignatureStatus", ctx, sig.BatchID, trust.VerificationStatusFailed, mock.AnythingOfType("*string")).Return(nil)
+
+		_, err := svc.VerifyBatchSignature(ctx, sig.BatchID)
+
+		assert.ErrorIs(t, err, trust.ErrKeyNotFound)
+	})
+}
+
+func TestService_GetInclusionProof(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	key, err := trust.NewSourceKey("key-ed", "gateway-001", trust.SignatureAlgEd25519, pub, time.Now().Add(-time.Hour), nil)
+	require.NoError(t, err)
+
+	eventIDs := make([]uuid.UUID, 5)
+	entries := make([]*trust.HashChainEntry, 5)
+	eventHashes := make([]string, 5)
+	for i := range eventIDs {
+		eventIDs[i] = uuid.New()
+		entries[i] = trust.NewHashChainEntry(eventIDs[i], "gateway-001", int64(i+1), fmt.Sprintf("eventHash%d", i), "")
+		eventHashes[i] = entries[i].EventHash
+	}
+	sig := trust.NewBatchSignature("gateway-001", eventIDs, trust.ComputeMerkleRoot(eventHashes), "", trust.SignatureAlgEd25519, "key-ed", time.Now())
+	sig.Signature = hex.EncodeToString(ed25519.Sign(priv, []byte(sig.BatchHash)))
+
+	mockRepo := new(MockRepository)
+	registry := new(MockKeyRegistry)
+	svc := NewService(mockRepo, new(MockKeyStore), zerolog.Nop())
+	svc.SetKeyRegistry(registry)
+	mockRepo.On("GetBatchSignatureForEvent", ctx, eventIDs[3]).Return(sig, nil)
+	// Entries come back in chain order, not batch order.
+	mockRepo.On("GetChainEntriesForEvents", ctx, eventIDs).Return([]*trust.HashChainEntry{entries[4], entries[2], entries[0], entries[3], entries[1]}, nil)
+	registry.On("GetSourceKey", ctx, "key-ed").Return(key, nil)
+
+	proof, err := svc.GetInclusionProof(ctx, eventIDs[3])
+
+	require.NoError(t, err)
+	assert.Equal(t, eventHashes[3], proof.EventHash)
+	assert.Equal(t, 3, proof.Merkle.LeafIndex)
+	assert.Len(t, proof.Merkle.Path, 3)
+	assert.Empty(t, proof.BatchEventHashes)
+	require.NoError(t, proof.Verify(proof.SourceKey))
+}
+
+func TestService_SourceKeys(t *testing.T) {
+	ctx := context.Background()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("registers key", func(t *testing.T) {
+		registry := new(MockKeyRegistry)
+		svc := NewService(new(MockRepository), new(MockKeyStore), zerolog.Nop())
+		svc.SetKeyRegistry(registry)
+		registry.On("CreateSourceKey", ctx, mock.AnythingOfType("*trust.SourceKey")).Return(nil)
+
+		key, err := svc.RegisterSourceKey(ctx, SourceKeyInput{
+			KeyID:     "key-ed",
+			SourceID:  "gateway-001",
+			Algorithm: trust.SignatureAlgEd25519,
+			PublicKey: pub,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "key-ed", key.KeyID)
+		assert.NoError(t, key.ValidAt(time.Now().Add(time.Second)))
+		registry.AssertExpectations(t)
+	})
+
+	t.Run("rejects HMAC key", func(t *testing.T) {
+		svc := NewService(new(MockRepository), new(MockKeyStore), zerolog.Nop())
+		svc.SetKeyRegistry(new(MockKeyRegistry))
+
+		_, err := svc.RegisterSourceKey(ctx, SourceKeyInput{
+			KeyID:     "key-hmac",
+			SourceID:  "gateway-001",
+			Algorithm: trust.SignatureAlgHMACSHA256,
+			PublicKey: []byte("secret"),
+		})
+
+		assert.ErrorIs(t, err, trust.ErrUnsupportedSignatureAlg)
+	})
+
+	t.Run("revokes key retroactively", func(t *testing.T) {
+		registry := new(MockKeyRegistry)
+		svc := NewService(new(MockRepository), new(MockKeyStore), zerolog.Nop())
+		svc.SetKeyRegistry(registry)
+		key, err := trust.NewSourceKey("key-ed", "gateway-001", trust.SignatureAlgEd25519, pub, time.Now().Add(-48*time.Hour), nil)
+		require.NoError(t, err)
+		at := time.Now().Add(-time.Hour).UTC()
+		registry.On("GetSourceKey", ctx, "key-ed").Return(key, nil)
+		registry.On("RevokeSourceKey", ctx, "key-ed", at, "compromised").Return(nil)
+
+		revoked, err := svc.RevokeSourceKey(ctx, "key-ed", at, "compromised")
+
+		require.NoError(t, err)
+		assert.Equal(t, at, *revoked.RevokedAt)
+		registry.AssertExpectations(t)
+	})
+
+	t.Run("requires registry", func(t *testing.T) {
+		svc := NewService(new(MockRepository), new(MockKeyStore), zerolog.Nop())
+		_, err := svc.ListSourceKeys(ctx, "")
+		assert.Error(t, err)
+	})
+}
+
 func TestService_VerifyHashChain(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
@@ -450,6 +1136,7 @@ func TestService_GenerateEvidenceBundle(t *testing.T) {
 	bundleData := &trust.EvidenceBundleData{
 		Events:    []trust.EventEvidence{eventEvidence},
 		HashChain: []*trust.HashChainEntry{chainEntry},
+		Rules:     []trust.RuleEvidence{{RuleID: uuid.New(), Version: 1, MinTrustLevel: trust.TrustLevelT3}},
 	}
 
 	mockRepo.On("GetEvidenceBundleData", ctx, "EVENT", eventID).Return(bundleData, nil)
@@ -463,13 +1150,61 @@ func TestService_GenerateEvidenceBundle(t *testing.T) {
 	assert.Len(t, bundle.Events, 1)
 	assert.Len(t, bundle.HashChain, 1)
 	assert.NotEmpty(t, bundle.BundleHash)
-	assert.True(t, bundle.VerifyIntegrity())
+	assert.True(t, bundle.VerifyIntegrity(nil))
 	assert.Equal(t, trust.TrustLevelT2, bundle.Verification.OverallTrustLevel)
 	assert.True(t, bundle.Verification.HashChainValid)
+	assert.Equal(t, []trust.LowTrustInput{{
+		EventID:          eventID,
+		SourceID:         sourceID,
+		TrustLevel:       trust.TrustLevelT2,
+		BelowRuleMinimum: true,
+	}}, bundle.Verification.LowTrustInputs)
 
 	mockRepo.AssertExpectations(t)
 }
 
+func TestService_GenerateEvidenceBundleTimestamped(t *testing.T) {
+	ctx := context.Background()
+	tsa, err := tsptest.NewAuthority()
+	require.NoError(t, err)
+	srv := httptest.NewServer(tsa)
+	defer srv.Close()
+
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo, new(MockKeyStore), zerolog.Nop())
+	svc.SetTimestamper(tsp.NewClient(srv.URL, tsa.Roots(), 0))
+	eventID := uuid.New()
+	mockRepo.On("GetEvidenceBundleData", ctx, "EVENT", eventID).Return(&trust.EvidenceBundleData{
+		Events: []trust.EventEvidence{{EventID: eventID, SourceID: "gateway-001", TsServer: time.Now().UTC(), TrustLevel: trust.TrustLevelT1}},
+	}, nil)
+
+	bundle, err := svc.GenerateEvidenceBundle(ctx, "EVENT", eventID)
+	require.NoError(t, err)
+	require.NotEmpty(t, bundle.TimestampToken)
+	assert.True(t, bundle.VerifyIntegrity(tsa.Roots()))
+	token, err := bundle.VerifyTimestamp(tsa.Roots())
+	require.NoError(t, err)
+	assert.Equal(t, bundle.BundleHash, hex.EncodeToString(token.HashedMessage))
+
+	report := svc.VerifyEvidenceBundle(bundle, nil)
+	require.NotNil(t, report.Timestamp)
+	assert.Equal(t, trust.TimestampUntrusted, report.Timestamp.Status)
+	svc.SetTimestampRoots(tsa.Roots())
+	report = svc.VerifyEvidenceBundle(bundle, nil)
+	assert.True(t, report.Valid, report.Errors)
+	require.NotNil(t, report.Timestamp)
+	assert.Equal(t, trust.TimestampVerified, report.Timestamp.Status)
+
+	t.Run("generates the bundle without a token when the TSA fails", func(t *testing.T) {
+		tsa.SetStatus(2)
+
+		bundle, err := svc.GenerateEvidenceBundle(ctx, "EVENT", eventID)
+		require.NoError(t, err)
+		assert.Empty(t, bundle.TimestampToken)
+		assert.True(t, bundle.VerifyIntegrity(nil))
+	})
+}
+
 func TestService_GetTrustMetadata(t *testing.T) {
 	ctx := context.Background()
 	logger := zerolog.Nop()
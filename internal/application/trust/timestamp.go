@@ -0,0 +1,56 @@
+package trust
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+)
+
+// Timestamper obtains RFC 3161 timestamp tokens over SHA-256 digests from a
+// time-stamping authority. tsp.Client implements it.
+type Timestamper interface {
+	Timestamp(ctx context.Context, digest []byte) (*tsp.Token, error)
+}
+
+// SetTimestamper has every generated evidence bundle's hash timestamped,
+// so its time no longer rests on the server clock alone
+func (s *Service) SetTimestamper(timestamper Timestamper) {
+	s.timestamper = timestamper
+}
+
+// SetTimestampRoots sets the CA certificates of the time-stamping
+// authorities trusted when verifying evidence bundle timestamp tokens
+func (s *Service) SetTimestampRoots(roots *x509.CertPool) {
+	s.tsaRoots = roots
+}
+
+// VerifyEvidenceBundle verifies an evidence bundle as an auditor would,
+// with the source public keys given and the trusted time-stamping
+// authorities. Without trusted authorities, timestamp tokens are reported
+// as untrusted.
+func (s *Service) VerifyEvidenceBundle(bundle *trust.EvidenceBundle, keys []*trust.SourceKey) *trust.BundleReport {
+	return trust.VerifyEvidenceBundle(bundle, keys, s.tsaRoots)
+}
+
+// timestampBundle attaches a timestamp token over the finalized bundle's
+// hash. Failures are logged: without a token the bundle is still evidence,
+// timed by the server clock.
+func (s *Service) timestampBundle(ctx context.Context, bundle *trust.EvidenceBundle) {
+	if s.timestamper == nil {
+		return
+	}
+	digest, err := hex.DecodeString(bundle.BundleHash)
+	if err == nil {
+		var token *tsp.Token
+		if token, err = s.timestamper.Timestamp(ctx, digest); err == nil {
+			bundle.TimestampToken = token.Raw
+			return
+		}
+	}
+	s.logger.Warn().Err(err).
+		Str("bundleId", bundle.BundleID.String()).
+		Msg("failed to timestamp evidence bundle")
+}
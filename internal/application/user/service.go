@@ -32,6 +32,7 @@ type CreateInput struct {
 	Role        domain.Role
 	Type        domain.Type
 	OwnerUserID *uuid.UUID
+	Email       *string
 	Status      domain.Status
 }
 
@@ -41,6 +42,8 @@ type UpdateInput struct {
 	Role        *domain.Role
 	Status      *domain.Status
 	OwnerUserID *uuid.UUID
+	// Email sets the notification address; an empty string clears it.
+	Email *string
 }
 
 func (s *Service) CreateUser(ctx context.Context, input CreateInput) (*domain.User, error) {
@@ -70,6 +73,11 @@ func (s *Service) CreateUser(ctx context.Context, input CreateInput) (*domain.Us
 		return nil, fmt.Errorf("owner_user_id is not allowed for human user")
 	}
 
+	email, err := normalizeEmail(input.Email)
+	if err != nil {
+		return nil, err
+	}
+
 	hash, err := domain.HashPassword(input.Password)
 	if err != nil {
 		return nil, err
@@ -82,6 +90,7 @@ func (s *Service) CreateUser(ctx context.Context, input CreateInput) (*domain.Us
 		Role:         input.Role,
 		Type:         input.Type,
 		OwnerUserID:  input.OwnerUserID,
+		Email:        email,
 		Status:       input.Status,
 		CreatedAt:    time.Now().UTC(),
 		UpdatedAt:    time.Now().UTC(),
@@ -122,6 +131,13 @@ func (s *Service) UpdateUser(ctx context.Context, userID uuid.UUID, input Update
 		}
 		u.Status = *input.Status
 	}
+	if input.Email != nil {
+		email, err := normalizeEmail(input.Email)
+		if err != nil {
+			return nil, err
+		}
+		u.Email = email
+	}
 	if u.Type == domain.TypeAgent {
 		if input.OwnerUserID != nil {
 			u.OwnerUserID = input.OwnerUserID
@@ -174,3 +190,18 @@ func (s *Service) ListUsers(ctx context.Context, filter domain.Filter, limit, of
 func (s *Service) Count(ctx context.Context) (int, error) {
 	return s.repo.Count(ctx)
 }
+
+// normalizeEmail returns nil for an absent or empty address.
+func normalizeEmail(email *string) (*string, error) {
+	if email == nil {
+		return nil, nil
+	}
+	normalized := domain.NormalizeEmail(*email)
+	if normalized == "" {
+		return nil, nil
+	}
+	if err := domain.ValidateEmail(normalized); err != nil {
+		return nil, err
+	}
+	return &normalized, nil
+}
@@ -9,13 +9,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
+	domainAction "github.com/execution-hub/execution-hub/internal/domain/action"
 	"github.com/execution-hub/execution-hub/internal/domain/workflow"
 )
 
 // Service handles workflow definition operations.
 type Service struct {
-	repo   workflow.Repository
-	logger zerolog.Logger
+	repo      workflow.Repository
+	validator domainAction.ConfigValidator
+	logger    zerolog.Logger
 }
 
 // NewService creates a workflow service.
@@ -26,11 +28,24 @@ func NewService(repo workflow.Repository, logger zerolog.Logger) *Service {
 	}
 }
 
+// SetActionConfigValidator checks step action configs on creation, e.g.
+// against the registered notification channels.
+func (s *Service) SetActionConfigValidator(validator domainAction.ConfigValidator) {
+	s.validator = validator
+}
+
 // CreateDefinition creates a new workflow definition version.
 func (s *Service) CreateDefinition(ctx context.Context, spec workflow.Spec, createdBy *string) (*workflow.Definition, error) {
 	if err := workflow.ValidateSpec(&spec); err != nil {
 		return nil, err
 	}
+	if s.validator != nil {
+		for _, step := range spec.Steps {
+			if err := s.validator.ValidateActionConfig(step.ActionType, step.ActionConfig); err != nil {
+				return nil, fmt.Errorf("invalid action_config for step %s: %w", step.StepKey, err)
+			}
+		}
+	}
 
 	data, err := json.Marshal(spec)
 	if err != nil {
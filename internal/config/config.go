@@ -1,10 +1,13 @@
 package config
 
 import (
+	"crypto/x509"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
 )
 
 // Config holds service configuration.
@@ -14,6 +17,16 @@ type Config struct {
 	SessionTTL          time.Duration
 	SessionCookieName   string
 	SessionCookieSecure bool
+	// TSAURL is the RFC 3161 time-stamping authority evidence bundles and
+	// audit checkpoints are timestamped by; empty disables timestamping
+	TSAURL string
+	// TSARootsFile is a PEM file of the CA certificates TSA certificates
+	// must chain to; without it timestamp tokens are reported as untrusted
+	TSARootsFile string
+	// TSARoots is the pool loaded from TSARootsFile, for the services'
+	// SetTimestampRoots
+	TSARoots   *x509.CertPool
+	TSATimeout time.Duration
 }
 
 // Load reads configuration from environment.
@@ -32,6 +45,15 @@ func Load() (*Config, error) {
 	ttl := parseDuration(getenv("SESSION_TTL", "24h"), 24*time.Hour)
 	cookieName := getenv("SESSION_COOKIE_NAME", "exec_hub_session")
 	cookieSecure := parseBool(getenv("SESSION_COOKIE_SECURE", "false"), false)
+	tsaTimeout := parseDuration(getenv("TSA_TIMEOUT", "10s"), 10*time.Second)
+	tsaRootsFile := os.Getenv("TSA_ROOTS_FILE")
+	var tsaRoots *x509.CertPool
+	if tsaRootsFile != "" {
+		var err error
+		if tsaRoots, err = tsp.LoadRoots(tsaRootsFile); err != nil {
+			return nil, fmt.Errorf("failed to load TSA roots: %w", err)
+		}
+	}
 
 	return &Config{
 		DatabaseURL:         dsn,
@@ -39,6 +61,10 @@ func Load() (*Config, error) {
 		SessionTTL:          ttl,
 		SessionCookieName:   cookieName,
 		SessionCookieSecure: cookieSecure,
+		TSAURL:              os.Getenv("TSA_URL"),
+		TSARootsFile:        tsaRootsFile,
+		TSARoots:            tsaRoots,
+		TSATimeout:          tsaTimeout,
 	}, nil
 }
 
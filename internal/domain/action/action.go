@@ -40,6 +40,12 @@ const (
 	TypeAgentRun Type = "AGENT_RUN"
 )
 
+// ConfigValidator checks an action config when the workflow or rule that
+// carries it is created
+type ConfigValidator interface {
+	ValidateActionConfig(actionType Type, config json.RawMessage) error
+}
+
 var (
 	ErrInvalidTransition = errors.New("invalid status transition")
 	ErrCannotRetry       = errors.New("cannot retry: max retries exceeded or not in failed state")
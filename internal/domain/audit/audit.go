@@ -27,6 +27,8 @@ const (
 	EntityTypeStep         EntityType = "STEP"
 	EntityTypeExecutor     EntityType = "EXECUTOR"
 	EntityTypeApproval     EntityType = "APPROVAL"
+	EntityTypeAuditLog     EntityType = "AUDIT_LOG"
+	EntityTypeLegalHold    EntityType = "LEGAL_HOLD"
 )
 
 // Action represents the type of action being audited
@@ -46,6 +48,9 @@ const (
 	ActionReject   Action = "REJECT"
 	ActionActivate Action = "ACTIVATE"
 	ActionSuspend  Action = "SUSPEND"
+	ActionPurge    Action = "PURGE"
+	ActionRestore  Action = "RESTORE"
+	ActionRelease  Action = "RELEASE"
 )
 
 // RiskLevel represents the risk classification of an operation
@@ -83,6 +88,14 @@ type AuditLog struct {
 	ResponseStatus int             `json:"responseStatus,omitempty"`
 	DurationMs     int             `json:"durationMs,omitempty"`
 	CreatedAt      time.Time       `json:"createdAt"`
+	// SequenceNum, PrevHash and EntryHash link the entry into the audit
+	// chain; entries written before the chain existed have none
+	SequenceNum int64  `json:"sequenceNum,omitempty"`
+	PrevHash    string `json:"prevHash,omitempty"`
+	EntryHash   string `json:"entryHash,omitempty"`
+	// ArchiveID is set on chain entries that were moved to an archive; only
+	// their audit ID and chain fields are kept in the database
+	ArchiveID *int64 `json:"archiveId,omitempty"`
 }
 
 // AuditEntry represents an entry to be logged (input for creating audit logs)
@@ -144,6 +157,80 @@ type Repository interface {
 
 	// VerifySignature verifies the signature of an audit log entry
 	VerifySignature(ctx context.Context, auditID uuid.UUID, key []byte) (bool, error)
+
+	// GetChainHead retrieves the last entry of the audit chain, nil if empty
+	GetChainHead(ctx context.Context) (*AuditLog, error)
+
+	// ListChain retrieves chained entries with fromSeq <= sequence number <=
+	// toSeq in sequence order, at most limit
+	ListChain(ctx context.Context, fromSeq, toSeq int64, limit int) ([]*AuditLog, error)
+
+	// CreateCheckpoint persists a signed checkpoint of the chain head
+	CreateCheckpoint(ctx context.Context, checkpoint *Checkpoint) error
+
+	// GetCheckpoint retrieves a checkpoint by ID
+	GetCheckpoint(ctx context.Context, id int64) (*Checkpoint, error)
+
+	// ListCheckpoints retrieves all checkpoints in sequence order
+	ListCheckpoints(ctx context.Context) ([]*Checkpoint, error)
+
+	// ListAfter retrieves up to limit logs written after cursor, oldest
+	// first; a nil cursor starts at the first log
+	ListAfter(ctx context.Context, cursor *Cursor, limit int) ([]*AuditLog, error)
+
+	// GetSinkCursor retrieves the persisted position of a sink, nil if none
+	GetSinkCursor(ctx context.Context, sink string) (*Cursor, error)
+
+	// SaveSinkCursor persists the position of a sink
+	SaveSinkCursor(ctx context.Context, sink string, cursor *Cursor) error
+
+	// ListRetentionPolicies retrieves all retention policies
+	ListRetentionPolicies(ctx context.Context) ([]*RetentionPolicy, error)
+
+	// SaveRetentionPolicy creates or replaces the policy of its entity type
+	// and risk level
+	SaveRetentionPolicy(ctx context.Context, policy *RetentionPolicy) error
+
+	// DeleteRetentionPolicy deletes a retention policy
+	DeleteRetentionPolicy(ctx context.Context, id int64) error
+
+	// CreateLegalHold persists a new legal hold
+	CreateLegalHold(ctx context.Context, hold *LegalHold) error
+
+	// ReleaseLegalHold marks an active legal hold released
+	ReleaseLegalHold(ctx context.Context, id uuid.UUID, releasedBy string, releasedAt time.Time) (*LegalHold, error)
+
+	// ListLegalHolds retrieves legal holds, newest first, only active ones
+	// unless includeReleased is set
+	ListLegalHolds(ctx context.Context, includeReleased bool) ([]*LegalHold, error)
+
+	// ListExpiring retrieves up to limit logs created before the given time
+	// with an id after afterID, in id order. Logs restored from an archive
+	// are skipped.
+	ListExpiring(ctx context.Context, before time.Time, afterID int64, limit int) ([]*AuditLog, error)
+
+	// ArchiveLogs persists the archive and its chain entries, deletes the
+	// logs and appends purge, the entry auditing the purge, to the chain,
+	// atomically. It fails with ErrLegalHoldConflict when an active legal
+	// hold covers any of them.
+	ArchiveLogs(ctx context.Context, archive *Archive, logs []*AuditLog, purge *AuditLog) error
+
+	// GetArchive retrieves an archive by ID
+	GetArchive(ctx context.Context, id int64) (*Archive, error)
+
+	// ListArchives retrieves all archives, newest first
+	ListArchives(ctx context.Context) ([]*Archive, error)
+
+	// ListArchiveChain retrieves the sequence numbers and entry hashes the
+	// audit chain recorded for an archive's entries
+	ListArchiveChain(ctx context.Context, id int64) (map[int64]string, error)
+
+	// RestoreArchive inserts an archive's logs back for queries and marks
+	// it restored
+	RestoreArchive(ctx context.Context, id int64, logs []*AuditLog) error
+
+	// UnrestoreArchive deletes the logs restored from an archive
+	UnrestoreArchive(ctx context.Context, id int64) error
 }
 
 // DetermineRiskLevel determines the risk level based on entity type and action
@@ -161,6 +248,11 @@ func DetermineRiskLevel(entityType EntityType, action Action) RiskLevel {
 		return RiskLevelHigh
 	}
 
+	// High: Audit log retention and legal holds
+	if entityType == EntityTypeAuditLog || entityType == EntityTypeLegalHold {
+		return RiskLevelHigh
+	}
+
 	// High: Deletion operations
 	if action == ActionDelete {
 		return RiskLevelHigh
@@ -202,7 +294,9 @@ func NewAuditLog(entry *AuditEntry) (*AuditLog, error) {
 		ResponseStatus: entry.ResponseStatus,
 		DurationMs:     entry.DurationMs,
 		RiskLevel:      DetermineRiskLevel(entry.EntityType, entry.Action),
-		CreatedAt:      time.Now().UTC(),
+		// Truncated to the database's precision so hashes and signatures
+		// can be recomputed from stored entries
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
 	}
 
 	// Marshal old values if present
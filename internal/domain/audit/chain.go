@@ -0,0 +1,373 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+)
+
+// ChainIssueType classifies a problem found when verifying the audit chain
+type ChainIssueType string
+
+const (
+	// ChainIssueGap means sequence numbers are missing: rows were deleted
+	ChainIssueGap ChainIssueType = "GAP"
+	// ChainIssueDuplicate means a sequence number appears more than once
+	ChainIssueDuplicate ChainIssueType = "DUPLICATE"
+	// ChainIssueTampered means an entry no longer matches its entry hash
+	ChainIssueTampered ChainIssueType = "TAMPERED"
+	// ChainIssueReordered means an entry links to another entry of the
+	// range than its predecessor
+	ChainIssueReordered ChainIssueType = "REORDERED"
+	// ChainIssueBrokenLink means an entry does not link to its predecessor
+	ChainIssueBrokenLink ChainIssueType = "BROKEN_LINK"
+	// ChainIssueCheckpointMismatch means an entry differs from the head a
+	// signed checkpoint recorded
+	ChainIssueCheckpointMismatch ChainIssueType = "CHECKPOINT_MISMATCH"
+	// ChainIssueTruncated means a signed checkpoint is beyond the chain head
+	ChainIssueTruncated ChainIssueType = "TRUNCATED"
+	// ChainIssueBadCheckpoint means a checkpoint's signature or timestamp
+	// token is invalid
+	ChainIssueBadCheckpoint ChainIssueType = "BAD_CHECKPOINT"
+)
+
+// MaxChainIssues caps the issues a chain report lists
+const MaxChainIssues = 100
+
+var (
+	ErrCheckpointNotFound       = errors.New("audit checkpoint not found")
+	ErrCheckpointNotTimestamped = errors.New("audit checkpoint has no timestamp token")
+)
+
+// ChainIssue is a problem found at a sequence number of the audit chain
+type ChainIssue struct {
+	Type        ChainIssueType `json:"type"`
+	SequenceNum int64          `json:"sequenceNum"`
+	// ToSequenceNum ends the range of missing sequence numbers of a gap
+	ToSequenceNum int64  `json:"toSequenceNum,omitempty"`
+	AuditID       string `json:"auditId,omitempty"`
+	Message       string `json:"message"`
+}
+
+// ChainReport is the result of verifying a range of the audit chain
+type ChainReport struct {
+	FromSequence    int64        `json:"fromSequence"`
+	ToSequence      int64        `json:"toSequence"`
+	EntriesChecked  int64        `json:"entriesChecked"`
+	CheckpointsUsed int          `json:"checkpointsUsed"`
+	Valid           bool         `json:"valid"`
+	Issues          []ChainIssue `json:"issues,omitempty"`
+	// Truncated is set when more issues were found than the report lists
+	Truncated bool `json:"truncated,omitempty"`
+	// TimestampsVerified counts the checkpoint timestamp tokens whose
+	// authority chains to the trusted roots; TimestampsUntrusted those with
+	// a valid signature but no trusted roots to check their authority against
+	TimestampsVerified  int `json:"timestampsVerified"`
+	TimestampsUntrusted int `json:"timestampsUntrusted"`
+}
+
+func (r *ChainReport) addIssue(issue ChainIssue) {
+	r.Valid = false
+	if len(r.Issues) >= MaxChainIssues {
+		r.Truncated = true
+		return
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+type entryHashPayload struct {
+	SequenceNum int64            `json:"sequenceNum"`
+	PrevHash    string           `json:"prevHash"`
+	Entry       signaturePayload `json:"entry"`
+}
+
+// ComputeEntryHash returns the hex SHA-256 of the entry's content, sequence
+// number and previous entry hash. JSON values are hashed in canonical form
+// so the hash survives their normalisation by the database.
+func ComputeEntryHash(log *AuditLog) (string, error) {
+	payload := entryHashPayload{
+		SequenceNum: log.SequenceNum,
+		PrevHash:    log.PrevHash,
+		Entry:       buildSignaturePayload(log),
+	}
+	var err error
+	if payload.Entry.OldValues, err = canonicalJSON(log.OldValues); err != nil {
+		return "", fmt.Errorf("oldValues: %w", err)
+	}
+	if payload.Entry.NewValues, err = canonicalJSON(log.NewValues); err != nil {
+		return "", fmt.Errorf("newValues: %w", err)
+	}
+	if payload.Entry.Diff, err = canonicalJSON(log.Diff); err != nil {
+		return "", fmt.Errorf("diff: %w", err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// canonicalJSON re-encodes raw with sorted keys and no insignificant space
+func canonicalJSON(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// LinkTo appends the entry to the chain after head, which is nil for an
+// empty chain, and sets its entry hash
+func (l *AuditLog) LinkTo(head *AuditLog) error {
+	l.SequenceNum = 1
+	l.PrevHash = ""
+	if head != nil {
+		l.SequenceNum = head.SequenceNum + 1
+		l.PrevHash = head.EntryHash
+	}
+	hash, err := ComputeEntryHash(l)
+	if err != nil {
+		return fmt.Errorf("failed to hash audit log: %w", err)
+	}
+	l.EntryHash = hash
+	return nil
+}
+
+// Checkpoint is a signed record of the audit chain head. Exported
+// checkpoints are anchored off-site, so a later rewrite of the chain up to
+// the head is detectable even by someone holding the database.
+type Checkpoint struct {
+	ID           int64     `json:"id"`
+	SequenceNum  int64     `json:"sequenceNum"`
+	EntryHash    string    `json:"entryHash"`
+	CreatedAt    time.Time `json:"createdAt"`
+	SignatureAlg string    `json:"signatureAlg"`
+	Signature    string    `json:"signature"` // hex
+	// TimestampToken is an RFC 3161 timestamp token (DER) over Digest, set
+	// when a time-stamping authority is configured. It attests when the
+	// checkpoint existed without relying on the server clock.
+	TimestampToken []byte `json:"timestampToken,omitempty"`
+}
+
+// CheckpointSignatureAlg is the algorithm checkpoints are signed with
+const CheckpointSignatureAlg = "HMAC-SHA256"
+
+// NewCheckpoint signs the chain head with key
+func NewCheckpoint(head *AuditLog, key []byte) (*Checkpoint, error) {
+	if head == nil || head.SequenceNum == 0 {
+		return nil, errors.New("audit chain is empty")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("signing key is required")
+	}
+	c := &Checkpoint{
+		SequenceNum:  head.SequenceNum,
+		EntryHash:    head.EntryHash,
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+		SignatureAlg: CheckpointSignatureAlg,
+	}
+	c.Signature = hex.EncodeToString(c.sign(key))
+	return c, nil
+}
+
+// SignedPayload is the string the checkpoint signature covers
+func (c *Checkpoint) SignedPayload() string {
+	return "audit-checkpoint:v1|" + strconv.FormatInt(c.SequenceNum, 10) + "|" + c.EntryHash + "|" + c.CreatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+func (c *Checkpoint) sign(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(c.SignedPayload()))
+	return mac.Sum(nil)
+}
+
+// Verify checks the checkpoint signature with key
+func (c *Checkpoint) Verify(key []byte) bool {
+	sig, err := hex.DecodeString(c.Signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(c.sign(key), sig)
+}
+
+// Digest is the SHA-256 of the signed payload, which timestamp tokens stamp
+func (c *Checkpoint) Digest() []byte {
+	sum := sha256.Sum256([]byte(c.SignedPayload()))
+	return sum[:]
+}
+
+// VerifyTimestamp checks that the checkpoint's timestamp token stamps its
+// digest and is signed by its time-stamping authority, whose certificate
+// must chain to roots unless roots is nil. A token with a valid signature
+// by an untrusted authority is returned with an error wrapping
+// tsp.ErrUntrustedAuthority.
+func (c *Checkpoint) VerifyTimestamp(roots *x509.CertPool) (*tsp.Token, error) {
+	if len(c.TimestampToken) == 0 {
+		return nil, ErrCheckpointNotTimestamped
+	}
+	token, err := tsp.ParseToken(c.TimestampToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.Verify(c.Digest(), roots); err != nil {
+		if errors.Is(err, tsp.ErrUntrustedAuthority) {
+			return token, err
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+// CheckpointExport is a checkpoint as handed over for off-site anchoring
+type CheckpointExport struct {
+	Checkpoint
+	SignedPayload string `json:"signedPayload"`
+}
+
+// Export returns the checkpoint with the payload its signature covers
+func (c *Checkpoint) Export() *CheckpointExport {
+	return &CheckpointExport{Checkpoint: *c, SignedPayload: c.SignedPayload()}
+}
+
+// ChainVerifier verifies consecutive pages of the audit chain in sequence
+// order, carrying the last entry between pages
+type ChainVerifier struct {
+	report      *ChainReport
+	prev        *AuditLog
+	hashes      map[string]int64
+	checkpoints []*Checkpoint
+	inRange     map[int64]*Checkpoint
+}
+
+// NewChainVerifier verifies the range from..to. prev is the entry before
+// from, or nil when from is the start of the chain. checkpoints, ordered by
+// sequence number, are checked with key and their timestamp tokens against
+// roots, the CA certificates of the trusted time-stamping authorities;
+// those with a valid signature in the range are compared with the entries.
+// With nil roots, timestamp tokens are only checked for a valid signature.
+func NewChainVerifier(from, to int64, prev *AuditLog, checkpoints []*Checkpoint, key []byte, roots *x509.CertPool) *ChainVerifier {
+	v := &ChainVerifier{
+		report:  &ChainReport{FromSequence: from, ToSequence: to, Valid: true},
+		prev:    prev,
+		hashes:  make(map[string]int64),
+		inRange: make(map[int64]*Checkpoint),
+	}
+	for _, c := range checkpoints {
+		if !c.Verify(key) {
+			v.report.addIssue(ChainIssue{Type: ChainIssueBadCheckpoint, SequenceNum: c.SequenceNum, Message: fmt.Sprintf("checkpoint %d has an invalid signature", c.ID)})
+			continue
+		}
+		if len(c.TimestampToken) > 0 {
+			token, err := c.VerifyTimestamp(roots)
+			switch {
+			case token != nil && err != nil:
+				v.report.addIssue(ChainIssue{Type: ChainIssueBadCheckpoint, SequenceNum: c.SequenceNum, Message: fmt.Sprintf("checkpoint %d has a timestamp token of an untrusted authority: %v", c.ID, err)})
+				continue
+			case err != nil:
+				v.report.addIssue(ChainIssue{Type: ChainIssueBadCheckpoint, SequenceNum: c.SequenceNum, Message: fmt.Sprintf("checkpoint %d has an invalid timestamp token: %v", c.ID, err)})
+				continue
+			case roots == nil:
+				v.report.TimestampsUntrusted++
+			default:
+				v.report.TimestampsVerified++
+			}
+		}
+		v.checkpoints = append(v.checkpoints, c)
+		if c.SequenceNum >= from && c.SequenceNum <= to {
+			v.inRange[c.SequenceNum] = c
+			v.report.CheckpointsUsed++
+		}
+	}
+	return v
+}
+
+// Add verifies the next entries, ordered by sequence number
+func (v *ChainVerifier) Add(logs []*AuditLog) {
+	for _, log := range logs {
+		v.add(log)
+	}
+}
+
+func (v *ChainVerifier) add(log *AuditLog) {
+	r := v.report
+	r.EntriesChecked++
+	auditID := log.AuditID.String()
+
+	expectedSeq := r.FromSequence
+	if v.prev != nil {
+		expectedSeq = v.prev.SequenceNum + 1
+	}
+	switch {
+	case v.prev != nil && log.SequenceNum == v.prev.SequenceNum:
+		r.addIssue(ChainIssue{Type: ChainIssueDuplicate, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "sequence number is used more than once"})
+	case log.SequenceNum > expectedSeq:
+		r.addIssue(ChainIssue{Type: ChainIssueGap, SequenceNum: expectedSeq, ToSequenceNum: log.SequenceNum - 1, Message: fmt.Sprintf("%d entries are missing", log.SequenceNum-expectedSeq)})
+	}
+
+	// Archived entries are checked against their archive file
+	if log.ArchiveID == nil {
+		if hash, err := ComputeEntryHash(log); err != nil || hash != log.EntryHash {
+			r.addIssue(ChainIssue{Type: ChainIssueTampered, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "entry does not match its entry hash"})
+		}
+	}
+
+	if v.prev != nil && log.SequenceNum == v.prev.SequenceNum+1 && log.PrevHash != v.prev.EntryHash {
+		if seq, ok := v.hashes[log.PrevHash]; ok {
+			r.addIssue(ChainIssue{Type: ChainIssueReordered, SequenceNum: log.SequenceNum, AuditID: auditID, Message: fmt.Sprintf("entry links to sequence %d, not its predecessor", seq)})
+		} else {
+			r.addIssue(ChainIssue{Type: ChainIssueBrokenLink, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "entry does not link to its predecessor"})
+		}
+	} else if v.prev == nil && log.SequenceNum == 1 && log.PrevHash != "" {
+		r.addIssue(ChainIssue{Type: ChainIssueBrokenLink, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "first entry has a previous hash"})
+	}
+
+	if c := v.inRange[log.SequenceNum]; c != nil && c.EntryHash != log.EntryHash {
+		r.addIssue(ChainIssue{Type: ChainIssueCheckpointMismatch, SequenceNum: log.SequenceNum, AuditID: auditID, Message: fmt.Sprintf("entry differs from checkpoint %d", c.ID)})
+	}
+
+	v.hashes[log.EntryHash] = log.SequenceNum
+	v.prev = log
+}
+
+// Report finishes the verification. head is the sequence number of the
+// chain head; checkpoints beyond it mean the chain's tail was deleted.
+func (v *ChainVerifier) Report(head int64) *ChainReport {
+	r := v.report
+	last := r.FromSequence - 1
+	if v.prev != nil {
+		last = v.prev.SequenceNum
+	}
+	if last < r.ToSequence && last < head {
+		end := r.ToSequence
+		if head < end {
+			end = head
+		}
+		r.addIssue(ChainIssue{Type: ChainIssueGap, SequenceNum: last + 1, ToSequenceNum: end, Message: fmt.Sprintf("%d entries are missing", end-last)})
+	}
+	for _, c := range v.checkpoints {
+		if c.SequenceNum > head {
+			r.addIssue(ChainIssue{Type: ChainIssueTruncated, SequenceNum: c.SequenceNum, Message: fmt.Sprintf("checkpoint %d records sequence %d beyond the chain head %d", c.ID, c.SequenceNum, head)})
+			break
+		}
+	}
+	return r
+}
@@ -0,0 +1,210 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
+)
+
+var checkpointKey = []byte("checkpoint-key")
+
+func buildChain(t *testing.T, n int) []*AuditLog {
+	t.Helper()
+	var chain []*AuditLog
+	var head *AuditLog
+	for i := 0; i < n; i++ {
+		log, err := NewAuditLog(&AuditEntry{
+			EntityType: EntityTypeRule,
+			EntityID:   fmt.Sprintf("rule-%d", i),
+			Action:     ActionUpdate,
+			Actor:      "alice",
+			NewValues:  map[string]interface{}{"version": i, "name": "rule"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, log.LinkTo(head))
+		chain = append(chain, log)
+		head = log
+	}
+	return chain
+}
+
+func verify(chain []*AuditLog, checkpoints ...*Checkpoint) *ChainReport {
+	v := NewChainVerifier(1, int64(len(chain)), nil, checkpoints, checkpointKey, nil)
+	v.Add(chain)
+	return v.Report(int64(len(chain)))
+}
+
+func issueTypes(r *ChainReport) []ChainIssueType {
+	var types []ChainIssueType
+	for _, issue := range r.Issues {
+		types = append(types, issue.Type)
+	}
+	return types
+}
+
+func TestLinkTo(t *testing.T) {
+	chain := buildChain(t, 3)
+	assert.Equal(t, int64(1), chain[0].SequenceNum)
+	assert.Empty(t, chain[0].PrevHash)
+	assert.Equal(t, int64(3), chain[2].SequenceNum)
+	assert.Equal(t, chain[1].EntryHash, chain[2].PrevHash)
+
+	t.Run("hash survives JSON normalisation", func(t *testing.T) {
+		stored := *chain[1]
+		stored.NewValues = json.RawMessage(`{"version": 1, "name": "rule"}`)
+		hash, err := ComputeEntryHash(&stored)
+		require.NoError(t, err)
+		assert.Equal(t, chain[1].EntryHash, hash)
+	})
+}
+
+func TestChainVerifier(t *testing.T) {
+	t.Run("valid chain", func(t *testing.T) {
+		report := verify(buildChain(t, 5))
+		assert.True(t, report.Valid)
+		assert.Equal(t, int64(5), report.EntriesChecked)
+		assert.Empty(t, report.Issues)
+	})
+
+	t.Run("detects deleted entry", func(t *testing.T) {
+		chain := buildChain(t, 5)
+		report := verify(append(chain[:2:2], chain[3:]...))
+		assert.False(t, report.Valid)
+		require.Equal(t, []ChainIssueType{ChainIssueGap}, issueTypes(report))
+		assert.Equal(t, int64(3), report.Issues[0].SequenceNum)
+		assert.Equal(t, int64(3), report.Issues[0].ToSequenceNum)
+	})
+
+	t.Run("detects deleted tail within the range", func(t *testing.T) {
+		chain := buildChain(t, 5)
+		v := NewChainVerifier(1, 5, nil, nil, checkpointKey, nil)
+		v.Add(chain[:3])
+		report := v.Report(5)
+		require.Equal(t, []ChainIssueType{ChainIssueGap}, issueTypes(report))
+		assert.Equal(t, int64(4), report.Issues[0].SequenceNum)
+		assert.Equal(t, int64(5), report.Issues[0].ToSequenceNum)
+	})
+
+	t.Run("detects edited entry", func(t *testing.T) {
+		chain := buildChain(t, 5)
+		chain[2].Actor = "mallory"
+		assert.Equal(t, []ChainIssueType{ChainIssueTampered}, issueTypes(verify(chain)))
+	})
+
+	t.Run("detects reordered entries", func(t *testing.T) {
+		chain := buildChain(t, 5)
+		chain[1].SequenceNum, chain[2].SequenceNum = 3, 2
+		report := verify([]*AuditLog{chain[0], chain[2], chain[1], chain[3], chain[4]})
+		assert.Contains(t, issueTypes(report), ChainIssueReordered)
+		assert.Contains(t, issueTypes(report), ChainIssueTampered)
+	})
+
+	t.Run("detects duplicate sequence number", func(t *testing.T) {
+		chain := buildChain(t, 3)
+		report := verify([]*AuditLog{chain[0], chain[1], chain[1], chain[2]})
+		assert.Contains(t, issueTypes(report), ChainIssueDuplicate)
+	})
+
+	t.Run("verifies a range from its predecessor", func(t *testing.T) {
+		chain := buildChain(t, 6)
+		v := NewChainVerifier(4, 6, chain[2], nil, checkpointKey, nil)
+		v.Add(chain[3:])
+		report := v.Report(6)
+		assert.True(t, report.Valid, report.Issues)
+		assert.Equal(t, int64(3), report.EntriesChecked)
+
+		chain[3].PrevHash = chain[0].EntryHash
+		v = NewChainVerifier(4, 6, chain[2], nil, checkpointKey, nil)
+		v.Add(chain[3:])
+		assert.Contains(t, issueTypes(v.Report(6)), ChainIssueBrokenLink)
+	})
+}
+
+func TestCheckpoint(t *testing.T) {
+	chain := buildChain(t, 5)
+	checkpoint, err := NewCheckpoint(chain[4], checkpointKey)
+	require.NoError(t, err)
+	checkpoint.ID = 1
+	assert.True(t, checkpoint.Verify(checkpointKey))
+	assert.False(t, checkpoint.Verify([]byte("other-key")))
+
+	export := checkpoint.Export()
+	assert.Contains(t, export.SignedPayload, checkpoint.EntryHash)
+	data, err := json.Marshal(export)
+	require.NoError(t, err)
+	var imported CheckpointExport
+	require.NoError(t, json.Unmarshal(data, &imported))
+	assert.True(t, imported.Checkpoint.Verify(checkpointKey))
+
+	t.Run("rejects empty chain", func(t *testing.T) {
+		_, err := NewCheckpoint(nil, checkpointKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("verifies timestamp token", func(t *testing.T) {
+		tsa, err := tsptest.NewAuthority()
+		require.NoError(t, err)
+		stamped := *checkpoint
+		stamped.TimestampToken, err = tsa.Token(stamped.Digest(), nil)
+		require.NoError(t, err)
+		token, err := stamped.VerifyTimestamp(tsa.Roots())
+		require.NoError(t, err)
+		assert.Equal(t, stamped.Digest(), token.HashedMessage)
+		report := verify(chain, &stamped)
+		assert.Empty(t, report.Issues)
+		assert.Equal(t, 1, report.TimestampsUntrusted, "nothing vouches for the authority without roots")
+
+		v := NewChainVerifier(1, int64(len(chain)), nil, []*Checkpoint{&stamped}, checkpointKey, tsa.Roots())
+		v.Add(chain)
+		report = v.Report(int64(len(chain)))
+		assert.Empty(t, report.Issues)
+		assert.Equal(t, 1, report.TimestampsVerified)
+		assert.Zero(t, report.TimestampsUntrusted)
+
+		untrusted, err := tsptest.NewAuthority()
+		require.NoError(t, err)
+		v = NewChainVerifier(1, int64(len(chain)), nil, []*Checkpoint{&stamped}, checkpointKey, untrusted.Roots())
+		v.Add(chain)
+		report = v.Report(int64(len(chain)))
+		assert.Equal(t, []ChainIssueType{ChainIssueBadCheckpoint}, issueTypes(report))
+		assert.Contains(t, report.Issues[0].Message, "untrusted authority")
+		assert.Zero(t, report.CheckpointsUsed)
+
+		// A token of another checkpoint does not vouch for this one
+		other, err := NewCheckpoint(chain[3], checkpointKey)
+		require.NoError(t, err)
+		stamped.TimestampToken, err = tsa.Token(other.Digest(), nil)
+		require.NoError(t, err)
+		report = verify(chain, &stamped)
+		assert.Equal(t, []ChainIssueType{ChainIssueBadCheckpoint}, issueTypes(report))
+		assert.Zero(t, report.CheckpointsUsed)
+
+		_, err = checkpoint.VerifyTimestamp(nil)
+		assert.ErrorIs(t, err, ErrCheckpointNotTimestamped)
+	})
+
+	t.Run("detects rewritten chain", func(t *testing.T) {
+		rewritten := buildChain(t, 5)
+		report := verify(rewritten, checkpoint)
+		assert.Equal(t, 1, report.CheckpointsUsed)
+		assert.Equal(t, []ChainIssueType{ChainIssueCheckpointMismatch}, issueTypes(report))
+	})
+
+	t.Run("detects truncated chain", func(t *testing.T) {
+		report := verify(chain[:3], checkpoint)
+		assert.Equal(t, []ChainIssueType{ChainIssueTruncated}, issueTypes(report))
+	})
+
+	t.Run("reports forged checkpoint", func(t *testing.T) {
+		forged := *checkpoint
+		forged.SequenceNum = 4
+		report := verify(chain, &forged)
+		assert.Equal(t, []ChainIssueType{ChainIssueBadCheckpoint}, issueTypes(report))
+		assert.Equal(t, 0, report.CheckpointsUsed)
+	})
+}
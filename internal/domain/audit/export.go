@@ -0,0 +1,339 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExportFormat is a SIEM format audit logs are exported in, one record per
+// line
+type ExportFormat string
+
+const (
+	// ExportFormatCEF is ArcSight Common Event Format
+	ExportFormatCEF ExportFormat = "CEF"
+	// ExportFormatJSONL is the AuditLog JSON, one object per line
+	ExportFormatJSONL ExportFormat = "JSONL"
+	// ExportFormatOCSF is an OCSF API Activity event, one object per line
+	ExportFormatOCSF ExportFormat = "OCSF"
+)
+
+const (
+	exportVendor  = "ExecutionHub"
+	exportProduct = "execution-hub"
+	exportVersion = "1.0"
+)
+
+// ParseExportFormat parses a format name, case-insensitively
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch f := ExportFormat(strings.ToUpper(s)); f {
+	case ExportFormatCEF, ExportFormatJSONL, ExportFormatOCSF:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", s)
+}
+
+// ContentType is the MIME type of a stream of records in the format
+func (f ExportFormat) ContentType() string {
+	if f == ExportFormatCEF {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Encode returns the log as one newline-terminated record in format
+func Encode(format ExportFormat, log *AuditLog) ([]byte, error) {
+	var line []byte
+	switch format {
+	case ExportFormatCEF:
+		line = []byte(FormatCEF(log))
+	case ExportFormatJSONL:
+		data, err := json.Marshal(log)
+		if err != nil {
+			return nil, err
+		}
+		line = data
+	case ExportFormatOCSF:
+		data, err := json.Marshal(ToOCSF(log))
+		if err != nil {
+			return nil, err
+		}
+		line = data
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	return append(line, '\n'), nil
+}
+
+// CEFSeverity maps the risk level to CEF severity 0-10
+func CEFSeverity(level RiskLevel) int {
+	switch level {
+	case RiskLevelCritical:
+		return 10
+	case RiskLevelHigh:
+		return 8
+	case RiskLevelMedium:
+		return 5
+	}
+	return 3
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+)
+
+// FormatCEF returns the log as a CEF:0 record without a trailing newline
+func FormatCEF(log *AuditLog) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		exportVendor, exportProduct, exportVersion,
+		cefHeaderEscaper.Replace(string(log.EntityType)+":"+string(log.Action)),
+		cefHeaderEscaper.Replace(string(log.EntityType)+" "+string(log.Action)),
+		CEFSeverity(log.RiskLevel))
+
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefExtensionEscaper.Replace(value))
+		}
+	}
+	add("rt", strconv.FormatInt(log.CreatedAt.UnixMilli(), 10))
+	add("externalId", log.AuditID.String())
+	add("act", string(log.Action))
+	add("suser", log.Actor)
+	if log.ActorIP != nil {
+		add("src", log.ActorIP.String())
+	}
+	add("requestMethod", log.RequestMethod)
+	add("request", log.RequestPath)
+	add("requestClientApplication", log.UserAgent)
+	add("msg", log.Reason)
+	add("cs1Label", "entityType")
+	add("cs1", string(log.EntityType))
+	add("cs2Label", "entityId")
+	add("cs2", log.EntityID)
+	if log.TraceID != "" {
+		add("cs3Label", "traceId")
+		add("cs3", log.TraceID)
+	}
+	if log.SessionID != "" {
+		add("cs4Label", "sessionId")
+		add("cs4", log.SessionID)
+	}
+	if log.EntryHash != "" {
+		add("cs5Label", "entryHash")
+		add("cs5", log.EntryHash)
+		add("cn1Label", "sequenceNum")
+		add("cn1", strconv.FormatInt(log.SequenceNum, 10))
+	}
+	if log.ResponseStatus != 0 {
+		add("cn2Label", "responseStatus")
+		add("cn2", strconv.Itoa(log.ResponseStatus))
+	}
+	b.WriteString(strings.Join(ext, " "))
+	return b.String()
+}
+
+// OCSF API Activity (class 6003, category 6 Application Activity)
+const (
+	ocsfVersion         = "1.1.0"
+	ocsfCategoryUID     = 6
+	ocsfClassUID        = 6003
+	ocsfActivityCreate  = 1
+	ocsfActivityRead    = 2
+	ocsfActivityUpdate  = 3
+	ocsfActivityDelete  = 4
+	ocsfActivityOther   = 99
+	ocsfStatusSuccess   = 1
+	ocsfStatusFailure   = 2
+	ocsfSeverityInfo    = 1
+	ocsfSeverityMedium  = 3
+	ocsfSeverityHigh    = 4
+	ocsfSeverityCrit    = 5
+	ocsfSeverityUnknown = 0
+)
+
+// OCSFEvent is an audit log as an OCSF API Activity event
+type OCSFEvent struct {
+	ActivityID   int                    `json:"activity_id"`
+	ActivityName string                 `json:"activity_name"`
+	CategoryUID  int                    `json:"category_uid"`
+	ClassUID     int                    `json:"class_uid"`
+	TypeUID      int                    `json:"type_uid"`
+	Time         int64                  `json:"time"`
+	SeverityID   int                    `json:"severity_id"`
+	Severity     string                 `json:"severity"`
+	StatusID     int                    `json:"status_id,omitempty"`
+	StatusCode   string                 `json:"status_code,omitempty"`
+	Message      string                 `json:"message,omitempty"`
+	Duration     int                    `json:"duration,omitempty"`
+	Metadata     OCSFMetadata           `json:"metadata"`
+	Actor        OCSFActor              `json:"actor"`
+	API          OCSFAPI                `json:"api"`
+	SrcEndpoint  *OCSFEndpoint          `json:"src_endpoint,omitempty"`
+	HTTPRequest  *OCSFHTTPRequest       `json:"http_request,omitempty"`
+	Resources    []OCSFResource         `json:"resources"`
+	Unmapped     map[string]interface{} `json:"unmapped,omitempty"`
+}
+
+// OCSFMetadata describes the event record and the product that logged it
+type OCSFMetadata struct {
+	Version        string      `json:"version"`
+	UID            string      `json:"uid"`
+	CorrelationUID string      `json:"correlation_uid,omitempty"`
+	LogName        string      `json:"log_name"`
+	Product        OCSFProduct `json:"product"`
+}
+
+// OCSFProduct is the product that logged the event
+type OCSFProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	Version    string `json:"version"`
+}
+
+// OCSFActor is the user who performed the operation
+type OCSFActor struct {
+	User    OCSFUser     `json:"user"`
+	Session *OCSFSession `json:"session,omitempty"`
+}
+
+// OCSFUser is the actor's user name and roles
+type OCSFUser struct {
+	Name   string   `json:"name"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// OCSFSession is the actor's authenticated session
+type OCSFSession struct {
+	UID string `json:"uid"`
+}
+
+// OCSFAPI is the audited operation
+type OCSFAPI struct {
+	Operation string `json:"operation"`
+}
+
+// OCSFEndpoint is the network endpoint the request came from
+type OCSFEndpoint struct {
+	IP string `json:"ip"`
+}
+
+// OCSFHTTPRequest is the HTTP request that performed the operation
+type OCSFHTTPRequest struct {
+	HTTPMethod string   `json:"http_method,omitempty"`
+	URL        *OCSFURL `json:"url,omitempty"`
+	UserAgent  string   `json:"user_agent,omitempty"`
+}
+
+// OCSFURL is the path of the HTTP request
+type OCSFURL struct {
+	Path string `json:"path"`
+}
+
+// OCSFResource is the audited entity
+type OCSFResource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+// ToOCSF maps the log to an OCSF API Activity event. Fields without an OCSF
+// attribute, such as old and new values, go to unmapped.
+func ToOCSF(log *AuditLog) *OCSFEvent {
+	activityID, activityName := ocsfActivity(log.Action)
+	severityID, severity := ocsfSeverity(log.RiskLevel)
+	e := &OCSFEvent{
+		ActivityID:   activityID,
+		ActivityName: activityName,
+		CategoryUID:  ocsfCategoryUID,
+		ClassUID:     ocsfClassUID,
+		TypeUID:      ocsfClassUID*100 + activityID,
+		Time:         log.CreatedAt.UnixMilli(),
+		SeverityID:   severityID,
+		Severity:     severity,
+		Message:      log.Reason,
+		Duration:     log.DurationMs,
+		Metadata: OCSFMetadata{
+			Version:        ocsfVersion,
+			UID:            log.AuditID.String(),
+			CorrelationUID: log.TraceID,
+			LogName:        "audit",
+			Product:        OCSFProduct{Name: exportProduct, VendorName: exportVendor, Version: exportVersion},
+		},
+		Actor:     OCSFActor{User: OCSFUser{Name: log.Actor, Groups: log.ActorRoles}},
+		API:       OCSFAPI{Operation: string(log.Action)},
+		Resources: []OCSFResource{{Type: string(log.EntityType), UID: log.EntityID}},
+	}
+	if log.SessionID != "" {
+		e.Actor.Session = &OCSFSession{UID: log.SessionID}
+	}
+	if log.ActorIP != nil {
+		e.SrcEndpoint = &OCSFEndpoint{IP: log.ActorIP.String()}
+	}
+	if log.RequestMethod != "" || log.RequestPath != "" || log.UserAgent != "" {
+		e.HTTPRequest = &OCSFHTTPRequest{HTTPMethod: log.RequestMethod, UserAgent: log.UserAgent}
+		if log.RequestPath != "" {
+			e.HTTPRequest.URL = &OCSFURL{Path: log.RequestPath}
+		}
+	}
+	if log.ResponseStatus != 0 {
+		e.StatusCode = strconv.Itoa(log.ResponseStatus)
+		e.StatusID = ocsfStatusSuccess
+		if log.ResponseStatus >= 400 {
+			e.StatusID = ocsfStatusFailure
+		}
+	}
+
+	unmapped := map[string]interface{}{}
+	if len(log.OldValues) > 0 {
+		unmapped["old_values"] = log.OldValues
+	}
+	if len(log.NewValues) > 0 {
+		unmapped["new_values"] = log.NewValues
+	}
+	if len(log.Diff) > 0 {
+		unmapped["diff"] = log.Diff
+	}
+	if len(log.Tags) > 0 {
+		unmapped["tags"] = log.Tags
+	}
+	if log.EntryHash != "" {
+		unmapped["sequence_num"] = log.SequenceNum
+		unmapped["entry_hash"] = log.EntryHash
+	}
+	if len(unmapped) > 0 {
+		e.Unmapped = unmapped
+	}
+	return e
+}
+
+func ocsfActivity(action Action) (int, string) {
+	switch action {
+	case ActionCreate, ActionRestore:
+		return ocsfActivityCreate, "Create"
+	case ActionExport:
+		return ocsfActivityRead, "Read"
+	case ActionUpdate, ActionCorrect, ActionRotate, ActionApprove, ActionReject, ActionActivate, ActionSuspend, ActionRelease:
+		return ocsfActivityUpdate, "Update"
+	case ActionDelete, ActionRevoke, ActionPurge:
+		return ocsfActivityDelete, "Delete"
+	}
+	return ocsfActivityOther, "Other"
+}
+
+func ocsfSeverity(level RiskLevel) (int, string) {
+	switch level {
+	case RiskLevelLow:
+		return ocsfSeverityInfo, "Informational"
+	case RiskLevelMedium:
+		return ocsfSeverityMedium, "Medium"
+	case RiskLevelHigh:
+		return ocsfSeverityHigh, "High"
+	case RiskLevelCritical:
+		return ocsfSeverityCrit, "Critical"
+	}
+	return ocsfSeverityUnknown, "Unknown"
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportLog() *AuditLog {
+	return &AuditLog{
+		ID:             7,
+		AuditID:        uuid.MustParse("6f1c2f4e-0d7a-4b4e-9b0e-2b8f3c1d5e6a"),
+		EntityType:     EntityTypeSecret,
+		EntityID:       "key|1",
+		Action:         ActionRotate,
+		Actor:          "alice",
+		ActorRoles:     []string{"admin"},
+		ActorIP:        net.ParseIP("10.0.0.5"),
+		RequestMethod:  "POST",
+		RequestPath:    "/v1/secrets/key/rotate",
+		Reason:         "scheduled=yes\nquarterly",
+		RiskLevel:      RiskLevelCritical,
+		NewValues:      json.RawMessage(`{"version":2}`),
+		TraceID:        "trace-1",
+		ResponseStatus: 200,
+		CreatedAt:      time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC),
+		SequenceNum:    42,
+		EntryHash:      "abc",
+	}
+}
+
+func TestParseExportFormat(t *testing.T) {
+	f, err := ParseExportFormat("ocsf")
+	require.NoError(t, err)
+	assert.Equal(t, ExportFormatOCSF, f)
+	assert.Equal(t, "application/x-ndjson", f.ContentType())
+
+	_, err = ParseExportFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestFormatCEF(t *testing.T) {
+	line := FormatCEF(exportLog())
+
+	assert.True(t, strings.HasPrefix(line, "CEF:0|ExecutionHub|execution-hub|1.0|SECRET:ROTATE|SECRET ROTATE|10|"), line)
+	assert.Contains(t, line, "rt=1777636800000")
+	assert.Contains(t, line, "suser=alice")
+	assert.Contains(t, line, "src=10.0.0.5")
+	assert.Contains(t, line, `msg=scheduled\=yes\nquarterly`)
+	assert.Contains(t, line, "cs2Label=entityId cs2=key|1")
+	assert.Contains(t, line, "cn1Label=sequenceNum cn1=42")
+	assert.NotContains(t, line, "\n")
+
+	log := exportLog()
+	log.EntityType = "A|B"
+	log.RiskLevel = RiskLevelLow
+	assert.Contains(t, FormatCEF(log), `|A\|B:ROTATE|A\|B ROTATE|3|`)
+}
+
+func TestToOCSF(t *testing.T) {
+	e := ToOCSF(exportLog())
+
+	assert.Equal(t, 6003, e.ClassUID)
+	assert.Equal(t, 3, e.ActivityID)
+	assert.Equal(t, 600303, e.TypeUID)
+	assert.Equal(t, 5, e.SeverityID)
+	assert.Equal(t, int64(1777636800000), e.Time)
+	assert.Equal(t, "6f1c2f4e-0d7a-4b4e-9b0e-2b8f3c1d5e6a", e.Metadata.UID)
+	assert.Equal(t, "trace-1", e.Metadata.CorrelationUID)
+	assert.Equal(t, "alice", e.Actor.User.Name)
+	assert.Equal(t, "10.0.0.5", e.SrcEndpoint.IP)
+	assert.Equal(t, "/v1/secrets/key/rotate", e.HTTPRequest.URL.Path)
+	assert.Equal(t, []OCSFResource{{Type: "SECRET", UID: "key|1"}}, e.Resources)
+	assert.Equal(t, 1, e.StatusID)
+	assert.Equal(t, int64(42), e.Unmapped["sequence_num"])
+
+	log := exportLog()
+	log.Action = ActionLogin
+	assert.Equal(t, 99, ToOCSF(log).ActivityID)
+}
+
+func TestEncode(t *testing.T) {
+	for _, format := range []ExportFormat{ExportFormatCEF, ExportFormatJSONL, ExportFormatOCSF} {
+		line, err := Encode(format, exportLog())
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(line), "\n"), format)
+		assert.True(t, strings.HasSuffix(string(line), "\n"), format)
+	}
+
+	line, err := Encode(ExportFormatJSONL, exportLog())
+	require.NoError(t, err)
+	var decoded AuditLog
+	require.NoError(t, json.Unmarshal(line, &decoded))
+	assert.Equal(t, exportLog().AuditID, decoded.AuditID)
+	assert.Equal(t, int64(42), decoded.SequenceNum)
+
+	var ocsf map[string]interface{}
+	line, err = Encode(ExportFormatOCSF, exportLog())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(line, &ocsf))
+	assert.Equal(t, float64(6003), ocsf["class_uid"])
+	assert.Equal(t, map[string]interface{}{"version": float64(2)}, ocsf["unmapped"].(map[string]interface{})["new_values"])
+}
@@ -0,0 +1,350 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRetentionPolicyNotFound = errors.New("audit retention policy not found")
+	ErrLegalHoldNotFound       = errors.New("legal hold not found")
+	ErrArchiveNotFound         = errors.New("audit archive not found")
+	// ErrLegalHoldConflict means a legal hold was placed on logs while they
+	// were being archived; they are kept
+	ErrLegalHoldConflict = errors.New("audit logs are under legal hold")
+)
+
+// RetentionPolicy keeps the audit logs of an entity type, a risk level or
+// both for RetentionDays. A nil EntityType or RiskLevel matches any.
+type RetentionPolicy struct {
+	ID            int64       `json:"id"`
+	EntityType    *EntityType `json:"entityType,omitempty"`
+	RiskLevel     *RiskLevel  `json:"riskLevel,omitempty"`
+	RetentionDays int         `json:"retentionDays"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+// Validate checks the retention period and risk level
+func (p *RetentionPolicy) Validate() error {
+	if p.RetentionDays <= 0 {
+		return errors.New("retention days must be positive")
+	}
+	if p.EntityType != nil && *p.EntityType == "" {
+		return errors.New("entity type must not be empty")
+	}
+	if p.RiskLevel != nil {
+		switch *p.RiskLevel {
+		case RiskLevelLow, RiskLevelMedium, RiskLevelHigh, RiskLevelCritical:
+		default:
+			return fmt.Errorf("invalid risk level %q", *p.RiskLevel)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the policy applies to the log
+func (p *RetentionPolicy) Matches(log *AuditLog) bool {
+	return (p.EntityType == nil || *p.EntityType == log.EntityType) &&
+		(p.RiskLevel == nil || *p.RiskLevel == log.RiskLevel)
+}
+
+// specificity ranks entity type matches above risk level matches
+func (p *RetentionPolicy) specificity() int {
+	n := 0
+	if p.EntityType != nil {
+		n += 2
+	}
+	if p.RiskLevel != nil {
+		n++
+	}
+	return n
+}
+
+// ExpiresAt is when the log leaves retention under the policy
+func (p *RetentionPolicy) ExpiresAt(log *AuditLog) time.Time {
+	return log.CreatedAt.AddDate(0, 0, p.RetentionDays)
+}
+
+// ResolveRetention returns the most specific policy matching the log: entity
+// type and risk level, then entity type, then risk level, then the default
+// policy. Logs no policy matches are kept forever and nil is returned.
+func ResolveRetention(policies []*RetentionPolicy, log *AuditLog) *RetentionPolicy {
+	var best *RetentionPolicy
+	for _, p := range policies {
+		if p.Matches(log) && (best == nil || p.specificity() > best.specificity()) {
+			best = p
+		}
+	}
+	return best
+}
+
+// LegalHold keeps the audit logs of an entity, or of a trace, from being
+// purged until it is released
+type LegalHold struct {
+	ID         uuid.UUID   `json:"id"`
+	EntityType *EntityType `json:"entityType,omitempty"`
+	EntityID   *string     `json:"entityId,omitempty"`
+	TraceID    *string     `json:"traceId,omitempty"`
+	Reason     string      `json:"reason"`
+	CreatedBy  string      `json:"createdBy"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	ReleasedAt *time.Time  `json:"releasedAt,omitempty"`
+	ReleasedBy string      `json:"releasedBy,omitempty"`
+}
+
+// Validate checks the hold names either an entity or a trace, and why
+func (h *LegalHold) Validate() error {
+	entity := h.EntityType != nil && *h.EntityType != "" && h.EntityID != nil && *h.EntityID != ""
+	trace := h.TraceID != nil && *h.TraceID != ""
+	if entity == trace {
+		return errors.New("a legal hold needs either an entity type and id or a trace id")
+	}
+	if h.Reason == "" {
+		return errors.New("a legal hold needs a reason")
+	}
+	return nil
+}
+
+// Active reports whether the hold has not been released
+func (h *LegalHold) Active() bool {
+	return h.ReleasedAt == nil
+}
+
+// Covers reports whether the hold is active and applies to the log
+func (h *LegalHold) Covers(log *AuditLog) bool {
+	if !h.Active() {
+		return false
+	}
+	if h.TraceID != nil {
+		return log.TraceID == *h.TraceID
+	}
+	return h.EntityType != nil && h.EntityID != nil &&
+		log.EntityType == *h.EntityType && log.EntityID == *h.EntityID
+}
+
+// HeldBy returns the first of holds that covers the log, nil if none
+func HeldBy(holds []*LegalHold, log *AuditLog) *LegalHold {
+	for _, h := range holds {
+		if h.Covers(log) {
+			return h
+		}
+	}
+	return nil
+}
+
+// ArchiveSignatureAlg is the algorithm archive manifests are signed with
+const ArchiveSignatureAlg = "HMAC-SHA256"
+
+// Archive describes a file of audit logs purged from the database: the
+// gzip-compressed JSON of the logs, one per line, in id order. The signed
+// manifest covers the file's SHA-256, and the chained entries keep their
+// entry hashes, so the file stays verifiable against the audit chain.
+type Archive struct {
+	ID            int64      `json:"id,omitempty"`
+	Name          string     `json:"name"`
+	EntryCount    int        `json:"entryCount"`
+	FirstSequence int64      `json:"firstSequence,omitempty"`
+	LastSequence  int64      `json:"lastSequence,omitempty"`
+	OldestAt      time.Time  `json:"oldestAt"`
+	NewestAt      time.Time  `json:"newestAt"`
+	SHA256        string     `json:"sha256"`
+	SignatureAlg  string     `json:"signatureAlg"`
+	Signature     string     `json:"signature"` // hex
+	CreatedBy     string     `json:"createdBy"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	RestoredAt    *time.Time `json:"restoredAt,omitempty"`
+}
+
+// NewArchive describes data, the encoded logs, and signs it with key
+func NewArchive(name string, logs []*AuditLog, data []byte, createdBy string, key []byte) (*Archive, error) {
+	if len(logs) == 0 {
+		return nil, errors.New("no audit logs to archive")
+	}
+	if len(key) == 0 {
+		return nil, errors.New("signing key is required")
+	}
+	sum := sha256.Sum256(data)
+	a := &Archive{
+		Name:         name,
+		EntryCount:   len(logs),
+		OldestAt:     logs[0].CreatedAt,
+		NewestAt:     logs[0].CreatedAt,
+		SHA256:       hex.EncodeToString(sum[:]),
+		SignatureAlg: ArchiveSignatureAlg,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+	for _, log := range logs {
+		if log.CreatedAt.Before(a.OldestAt) {
+			a.OldestAt = log.CreatedAt
+		}
+		if log.CreatedAt.After(a.NewestAt) {
+			a.NewestAt = log.CreatedAt
+		}
+		if log.SequenceNum == 0 {
+			continue
+		}
+		if a.FirstSequence == 0 || log.SequenceNum < a.FirstSequence {
+			a.FirstSequence = log.SequenceNum
+		}
+		if log.SequenceNum > a.LastSequence {
+			a.LastSequence = log.SequenceNum
+		}
+	}
+	a.Signature = hex.EncodeToString(a.sign(key))
+	return a, nil
+}
+
+// SignedPayload is the string the archive signature covers
+func (a *Archive) SignedPayload() string {
+	return "audit-archive:v1|" + a.Name +
+		"|" + strconv.Itoa(a.EntryCount) +
+		"|" + strconv.FormatInt(a.FirstSequence, 10) +
+		"|" + strconv.FormatInt(a.LastSequence, 10) +
+		"|" + a.OldestAt.UTC().Format(time.RFC3339Nano) +
+		"|" + a.NewestAt.UTC().Format(time.RFC3339Nano) +
+		"|" + a.SHA256 +
+		"|" + a.CreatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+func (a *Archive) sign(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(a.SignedPayload()))
+	return mac.Sum(nil)
+}
+
+// Verify checks the manifest signature with key
+func (a *Archive) Verify(key []byte) bool {
+	sig, err := hex.DecodeString(a.Signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(a.sign(key), sig)
+}
+
+// EncodeArchive returns the logs as gzip-compressed JSON lines
+func EncodeArchive(logs []*AuditLog) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, log := range logs {
+		line, err := Encode(ExportFormatJSONL, log)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode audit log %s: %w", log.AuditID, err)
+		}
+		if _, err := zw.Write(line); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeArchive reads the logs of an archive file
+func DecodeArchive(data []byte) ([]*AuditLog, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var logs []*AuditLog
+	scanner := bufio.NewScanner(zr)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var log AuditLog
+		if err := json.Unmarshal(scanner.Bytes(), &log); err != nil {
+			return nil, fmt.Errorf("line %d: %w", len(logs)+1, err)
+		}
+		logs = append(logs, &log)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// ArchiveReport is the result of verifying an archive file
+type ArchiveReport struct {
+	ArchiveID      int64        `json:"archiveId"`
+	Name           string       `json:"name"`
+	EntriesChecked int          `json:"entriesChecked"`
+	Valid          bool         `json:"valid"`
+	Issues         []ChainIssue `json:"issues,omitempty"`
+	Truncated      bool         `json:"truncated,omitempty"`
+}
+
+func (r *ArchiveReport) addIssue(issue ChainIssue) {
+	r.Valid = false
+	if len(r.Issues) >= MaxChainIssues {
+		r.Truncated = true
+		return
+	}
+	r.Issues = append(r.Issues, issue)
+}
+
+// ChainIssueBadArchive means an archive file does not match its signed
+// manifest
+const ChainIssueBadArchive ChainIssueType = "BAD_ARCHIVE"
+
+// VerifyArchive checks data against the archive's signed manifest and each
+// chained entry against its entry hash. chain maps the sequence numbers the
+// audit chain recorded for the archive to their entry hashes; archived
+// entries must match them.
+func VerifyArchive(a *Archive, data []byte, key []byte, chain map[int64]string) (*ArchiveReport, []*AuditLog) {
+	r := &ArchiveReport{ArchiveID: a.ID, Name: a.Name, Valid: true}
+	if !a.Verify(key) {
+		r.addIssue(ChainIssue{Type: ChainIssueBadArchive, Message: "manifest has an invalid signature"})
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != a.SHA256 {
+		r.addIssue(ChainIssue{Type: ChainIssueBadArchive, Message: "file does not match the manifest's SHA-256"})
+	}
+	logs, err := DecodeArchive(data)
+	if err != nil {
+		r.addIssue(ChainIssue{Type: ChainIssueBadArchive, Message: "file cannot be read: " + err.Error()})
+		return r, nil
+	}
+	if len(logs) != a.EntryCount {
+		r.addIssue(ChainIssue{Type: ChainIssueBadArchive, Message: fmt.Sprintf("file has %d entries, the manifest %d", len(logs), a.EntryCount)})
+	}
+
+	seen := make(map[int64]bool, len(chain))
+	for _, log := range logs {
+		r.EntriesChecked++
+		if log.SequenceNum == 0 {
+			continue
+		}
+		auditID := log.AuditID.String()
+		seen[log.SequenceNum] = true
+		if hash, err := ComputeEntryHash(log); err != nil || hash != log.EntryHash {
+			r.addIssue(ChainIssue{Type: ChainIssueTampered, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "entry does not match its entry hash"})
+		} else if recorded, ok := chain[log.SequenceNum]; ok && recorded != log.EntryHash {
+			r.addIssue(ChainIssue{Type: ChainIssueTampered, SequenceNum: log.SequenceNum, AuditID: auditID, Message: "entry differs from the audit chain"})
+		}
+	}
+	var missing []int64
+	for seq := range chain {
+		if !seen[seq] {
+			missing = append(missing, seq)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	for _, seq := range missing {
+		r.addIssue(ChainIssue{Type: ChainIssueGap, SequenceNum: seq, ToSequenceNum: seq, Message: "archived entry is missing from the file"})
+	}
+	return r, logs
+}
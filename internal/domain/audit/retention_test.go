@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRetention(t *testing.T) {
+	rule, high := EntityTypeRule, RiskLevelHigh
+	fallback := &RetentionPolicy{ID: 1, RetentionDays: 365}
+	byRisk := &RetentionPolicy{ID: 2, RiskLevel: &high, RetentionDays: 730}
+	byEntity := &RetentionPolicy{ID: 3, EntityType: &rule, RetentionDays: 90}
+	both := &RetentionPolicy{ID: 4, EntityType: &rule, RiskLevel: &high, RetentionDays: 1825}
+	policies := []*RetentionPolicy{fallback, byRisk, byEntity, both}
+
+	assert.Equal(t, both, ResolveRetention(policies, &AuditLog{EntityType: rule, RiskLevel: high}))
+	assert.Equal(t, byEntity, ResolveRetention(policies, &AuditLog{EntityType: rule, RiskLevel: RiskLevelLow}))
+	assert.Equal(t, byRisk, ResolveRetention(policies, &AuditLog{EntityType: EntityTypeUser, RiskLevel: high}))
+	assert.Equal(t, fallback, ResolveRetention(policies, &AuditLog{EntityType: EntityTypeUser, RiskLevel: RiskLevelLow}))
+	assert.Nil(t, ResolveRetention([]*RetentionPolicy{byEntity}, &AuditLog{EntityType: EntityTypeUser}))
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, created.AddDate(0, 0, 90), byEntity.ExpiresAt(&AuditLog{CreatedAt: created}))
+
+	bad := RiskLevel("SEVERE")
+	assert.Error(t, (&RetentionPolicy{RetentionDays: 0}).Validate())
+	assert.Error(t, (&RetentionPolicy{RetentionDays: 1, RiskLevel: &bad}).Validate())
+	assert.NoError(t, both.Validate())
+}
+
+func TestLegalHold(t *testing.T) {
+	rule, ruleID, trace := EntityTypeRule, "rule-1", "trace-1"
+	byEntity := &LegalHold{EntityType: &rule, EntityID: &ruleID, Reason: "litigation"}
+	byTrace := &LegalHold{TraceID: &trace, Reason: "incident"}
+	require.NoError(t, byEntity.Validate())
+	require.NoError(t, byTrace.Validate())
+	assert.Error(t, (&LegalHold{EntityType: &rule, Reason: "x"}).Validate(), "entity id is required")
+	assert.Error(t, (&LegalHold{EntityType: &rule, EntityID: &ruleID, TraceID: &trace, Reason: "x"}).Validate(), "entity and trace are exclusive")
+	assert.Error(t, (&LegalHold{TraceID: &trace}).Validate(), "reason is required")
+
+	log := &AuditLog{EntityType: rule, EntityID: ruleID, TraceID: "other"}
+	assert.True(t, byEntity.Covers(log))
+	assert.False(t, byTrace.Covers(log))
+	assert.Equal(t, byEntity, HeldBy([]*LegalHold{byTrace, byEntity}, log))
+	assert.True(t, byTrace.Covers(&AuditLog{EntityType: EntityTypeUser, TraceID: trace}))
+
+	released := time.Now()
+	byEntity.ReleasedAt = &released
+	assert.Nil(t, HeldBy([]*LegalHold{byTrace, byEntity}, log))
+}
+
+func archivedLogs(t *testing.T, n int) []*AuditLog {
+	t.Helper()
+	var logs []*AuditLog
+	var head *AuditLog
+	for i := 0; i < n; i++ {
+		log, err := NewAuditLog(&AuditEntry{EntityType: EntityTypeRule, EntityID: "rule-1", Action: ActionUpdate, Actor: "alice", NewValues: map[string]int{"version": i}})
+		require.NoError(t, err)
+		log.ID = int64(i + 1)
+		require.NoError(t, log.LinkTo(head))
+		logs = append(logs, log)
+		head = log
+	}
+	return logs
+}
+
+func TestArchive(t *testing.T) {
+	key := []byte("archive-key")
+	logs := archivedLogs(t, 3)
+	data, err := EncodeArchive(logs)
+	require.NoError(t, err)
+	archive, err := NewArchive("audit-1.jsonl.gz", logs, data, "system:retention", key)
+	require.NoError(t, err)
+	assert.Equal(t, 3, archive.EntryCount)
+	assert.Equal(t, int64(1), archive.FirstSequence)
+	assert.Equal(t, int64(3), archive.LastSequence)
+
+	chain := map[int64]string{}
+	for _, log := range logs {
+		chain[log.SequenceNum] = log.EntryHash
+	}
+	report, decoded := VerifyArchive(archive, data, key, chain)
+	assert.True(t, report.Valid, report.Issues)
+	assert.Equal(t, 3, report.EntriesChecked)
+	require.Len(t, decoded, 3)
+	assert.Equal(t, logs[1].AuditID, decoded[1].AuditID)
+	assert.JSONEq(t, string(logs[1].NewValues), string(decoded[1].NewValues))
+
+	report, _ = VerifyArchive(archive, data, []byte("other-key"), chain)
+	assert.False(t, report.Valid)
+	assert.Equal(t, ChainIssueBadArchive, report.Issues[0].Type)
+
+	// A rewritten file with a matching manifest is caught by the entry hashes
+	forged := archivedLogs(t, 3)
+	forged[1].Actor = "mallory"
+	forgedData, err := EncodeArchive(forged)
+	require.NoError(t, err)
+	forgedArchive, err := NewArchive("audit-1.jsonl.gz", forged, forgedData, "system:retention", key)
+	require.NoError(t, err)
+	report, _ = VerifyArchive(forgedArchive, forgedData, key, chain)
+	assert.False(t, report.Valid)
+	var types []ChainIssueType
+	for _, issue := range report.Issues {
+		types = append(types, issue.Type)
+	}
+	assert.Contains(t, types, ChainIssueTampered)
+
+	missing := map[int64]string{1: chain[1], 2: chain[2], 3: chain[3], 4: "abc"}
+	report, _ = VerifyArchive(archive, data, key, missing)
+	assert.False(t, report.Valid)
+	assert.Equal(t, ChainIssueGap, report.Issues[0].Type)
+	assert.Equal(t, int64(4), report.Issues[0].SequenceNum)
+}
+
+func TestChainVerifier_ArchivedEntries(t *testing.T) {
+	logs := archivedLogs(t, 3)
+	archiveID := int64(7)
+	stub := &AuditLog{AuditID: logs[1].AuditID, SequenceNum: 2, PrevHash: logs[1].PrevHash, EntryHash: logs[1].EntryHash, ArchiveID: &archiveID}
+
+	v := NewChainVerifier(1, 3, nil, nil, nil, nil)
+	v.Add([]*AuditLog{logs[0], stub, logs[2]})
+	report := v.Report(3)
+	assert.True(t, report.Valid, report.Issues)
+
+	broken := *stub
+	broken.EntryHash = "forged"
+	v = NewChainVerifier(1, 3, nil, nil, nil, nil)
+	v.Add([]*AuditLog{logs[0], &broken, logs[2]})
+	assert.False(t, v.Report(3).Valid, "a stub must still link the chain")
+}
@@ -0,0 +1,286 @@
+package capability
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Capability strings are dotted namespaces such as "code.review".
+//
+// Participants declare grants:
+//   - "code.review"  exact capability
+//   - "code.*"       every capability under the "code" namespace
+//   - "*"            every capability
+//   - "python@3.12"  capability with a version
+//
+// Steps declare requirements:
+//   - "code.review"  must be granted
+//   - "python>=3.11" must be granted with a version satisfying the constraint
+//     (operators: =, ==, !=, >, >=, <, <=; "python@3.12" means "python==3.12")
+//   - "?gpu"         preferred: never blocks a match but raises the score
+
+var (
+	namePattern    = regexp.MustCompile(`^[A-Za-z0-9_:/-]+(\.[A-Za-z0-9_:/-]+)*$`)
+	versionPattern = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+)
+
+// Score weights; exact matches beat namespace wildcards which beat "*".
+const (
+	scoreExact     = 4
+	scoreNamespace = 2
+	scoreAny       = 1
+	scoreVersioned = 1
+)
+
+// Grant is one parsed participant capability.
+type Grant struct {
+	Name     string
+	Wildcard bool
+	Version  string
+}
+
+// Requirement is one parsed step capability requirement.
+type Requirement struct {
+	Name      string
+	Op        string
+	Version   string
+	Preferred bool
+}
+
+// Result summarizes how a grant set satisfies a requirement set.
+type Result struct {
+	Satisfied bool
+	Score     int
+	Missing   []string
+}
+
+// ParseGrant parses a participant capability.
+func ParseGrant(raw string) (Grant, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return Grant{}, errors.New("capability is empty")
+	}
+	if raw == "*" {
+		return Grant{Wildcard: true}, nil
+	}
+	name, version, hasVersion := strings.Cut(raw, "@")
+	g := Grant{Name: name}
+	if hasVersion {
+		if !versionPattern.MatchString(version) {
+			return Grant{}, fmt.Errorf("invalid capability version: %s", raw)
+		}
+		g.Version = version
+	}
+	if prefix, ok := strings.CutSuffix(name, ".*"); ok {
+		if hasVersion {
+			return Grant{}, fmt.Errorf("wildcard capability cannot carry a version: %s", raw)
+		}
+		g.Name = prefix
+		g.Wildcard = true
+	}
+	if !namePattern.MatchString(g.Name) {
+		return Grant{}, fmt.Errorf("invalid capability: %s", raw)
+	}
+	return g, nil
+}
+
+// ParseRequirement parses a step capability requirement.
+func ParseRequirement(raw string) (Requirement, error) {
+	raw = strings.TrimSpace(raw)
+	r := Requirement{}
+	if rest, ok := strings.CutPrefix(raw, "?"); ok {
+		r.Preferred = true
+		raw = strings.TrimSpace(rest)
+	}
+	if raw == "" {
+		return Requirement{}, errors.New("capability requirement is empty")
+	}
+	name := raw
+	if i := strings.IndexAny(raw, "@<>=!"); i >= 0 {
+		name = raw[:i]
+		rest := raw[i:]
+		switch {
+		case strings.HasPrefix(rest, ">="), strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="):
+			r.Op, r.Version = rest[:2], rest[2:]
+		case strings.HasPrefix(rest, ">"), strings.HasPrefix(rest, "<"):
+			r.Op, r.Version = rest[:1], rest[1:]
+		case strings.HasPrefix(rest, "="), strings.HasPrefix(rest, "@"):
+			r.Op, r.Version = "==", rest[1:]
+		default:
+			return Requirement{}, fmt.Errorf("invalid capability requirement: %s", raw)
+		}
+		r.Version = strings.TrimSpace(r.Version)
+		if !versionPattern.MatchString(r.Version) {
+			return Requirement{}, fmt.Errorf("invalid capability version constraint: %s", raw)
+		}
+	}
+	r.Name = strings.TrimSpace(name)
+	if !namePattern.MatchString(r.Name) {
+		return Requirement{}, fmt.Errorf("invalid capability requirement: %s", raw)
+	}
+	return r, nil
+}
+
+// ValidateGrants reports the first malformed participant capability.
+func ValidateGrants(grants []string) error {
+	for _, raw := range grants {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if _, err := ParseGrant(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRequirements reports the first malformed step requirement.
+func ValidateRequirements(requirements []string) error {
+	for _, raw := range requirements {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if _, err := ParseRequirement(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// score returns how well g covers r, or 0 when it does not.
+func (g Grant) score(r Requirement) int {
+	base := 0
+	switch {
+	case !g.Wildcard && g.Name == r.Name:
+		base = scoreExact
+	case g.Wildcard && g.Name == "":
+		base = scoreAny
+	case g.Wildcard && strings.HasPrefix(r.Name, g.Name+"."):
+		base = scoreNamespace
+	default:
+		return 0
+	}
+	if r.Op == "" {
+		return base
+	}
+	if g.Version == "" || !versionSatisfies(g.Version, r.Op, r.Version) {
+		return 0
+	}
+	return base + scoreVersioned
+}
+
+// Match evaluates grants against requirements. Malformed entries fall back
+// to exact string comparison, so data written before validation existed
+// keeps its old behaviour.
+func Match(grants, requirements []string) Result {
+	parsed := make([]Grant, 0, len(grants))
+	literal := map[string]struct{}{}
+	for _, raw := range grants {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		literal[raw] = struct{}{}
+		g, err := ParseGrant(raw)
+		if err != nil {
+			g = Grant{Name: raw}
+		}
+		parsed = append(parsed, g)
+	}
+	res := Result{Satisfied: true}
+	for _, raw := range requirements {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		r, err := ParseRequirement(raw)
+		if err != nil {
+			if _, ok := literal[raw]; ok {
+				res.Score += scoreExact
+				continue
+			}
+			res.Satisfied = false
+			res.Missing = append(res.Missing, raw)
+			continue
+		}
+		best := 0
+		for _, g := range parsed {
+			if s := g.score(r); s > best {
+				best = s
+			}
+		}
+		if r.Preferred {
+			res.Score += best / 2
+			continue
+		}
+		if best == 0 {
+			res.Satisfied = false
+			res.Missing = append(res.Missing, raw)
+			continue
+		}
+		res.Score += best
+	}
+	if !res.Satisfied {
+		res.Score = 0
+	}
+	return res
+}
+
+// Satisfies reports whether grants cover every non-preferred requirement.
+func Satisfies(grants, requirements []string) bool {
+	return Match(grants, requirements).Satisfied
+}
+
+func versionSatisfies(have, op, want string) bool {
+	cmp := CompareVersions(have, want)
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return false
+	}
+}
+
+// CompareVersions compares dotted versions segment by segment. Numeric
+// segments compare numerically, others lexically; missing segments are 0.
+func CompareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		if xerr == nil && yerr == nil {
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+			continue
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
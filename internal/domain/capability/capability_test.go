@@ -0,0 +1,97 @@
+package capability
+
+import "testing"
+
+func TestParseGrant(t *testing.T) {
+	ok := []string{"code.review", "code.*", "*", "python@3.12", "lang:go"}
+	for _, v := range ok {
+		if _, err := ParseGrant(v); err != nil {
+			t.Fatalf("expected valid grant %q: %v", v, err)
+		}
+	}
+	bad := []string{"", "code.*@1", "co de", "python@", "code..review"}
+	for _, v := range bad {
+		if _, err := ParseGrant(v); err == nil {
+			t.Fatalf("expected invalid grant %q", v)
+		}
+	}
+}
+
+func TestParseRequirement(t *testing.T) {
+	r, err := ParseRequirement("python>=3.11")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if r.Name != "python" || r.Op != ">=" || r.Version != "3.11" {
+		t.Fatalf("unexpected requirement: %+v", r)
+	}
+	r, err = ParseRequirement("?gpu")
+	if err != nil || !r.Preferred || r.Name != "gpu" {
+		t.Fatalf("unexpected preferred requirement: %+v err=%v", r, err)
+	}
+	r, err = ParseRequirement("node@20")
+	if err != nil || r.Op != "==" || r.Version != "20" {
+		t.Fatalf("unexpected @ requirement: %+v err=%v", r, err)
+	}
+	bad := []string{"", "?", "python>=", "python=>3", "code.*"}
+	for _, v := range bad {
+		if _, err := ParseRequirement(v); err == nil {
+			t.Fatalf("expected invalid requirement %q", v)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		grants    []string
+		reqs      []string
+		satisfied bool
+	}{
+		{"no requirements", nil, nil, true},
+		{"exact", []string{"code.review"}, []string{"code.review"}, true},
+		{"namespace wildcard", []string{"code.*"}, []string{"code.review"}, true},
+		{"wildcard does not cover parent", []string{"code.*"}, []string{"code"}, false},
+		{"any wildcard", []string{"*"}, []string{"code.review", "docs"}, true},
+		{"version satisfied", []string{"python@3.12"}, []string{"python>=3.11"}, true},
+		{"version too old", []string{"python@3.10"}, []string{"python>=3.11"}, false},
+		{"unversioned grant fails constraint", []string{"python"}, []string{"python>=3.11"}, false},
+		{"versioned grant satisfies plain", []string{"python@3.12"}, []string{"python"}, true},
+		{"preferred does not block", []string{"code.review"}, []string{"code.review", "?gpu"}, true},
+		{"missing", []string{"docs"}, []string{"code.review"}, false},
+		{"legacy literal", []string{"a b"}, []string{"a b"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Match(tc.grants, tc.reqs)
+			if res.Satisfied != tc.satisfied {
+				t.Fatalf("expected satisfied=%t, got %+v", tc.satisfied, res)
+			}
+		})
+	}
+}
+
+func TestMatchScoreOrdering(t *testing.T) {
+	exact := Match([]string{"code.review"}, []string{"code.review"}).Score
+	namespace := Match([]string{"code.*"}, []string{"code.review"}).Score
+	any := Match([]string{"*"}, []string{"code.review"}).Score
+	if !(exact > namespace && namespace > any && any > 0) {
+		t.Fatalf("unexpected score ordering exact=%d namespace=%d any=%d", exact, namespace, any)
+	}
+	withPreferred := Match([]string{"code.review", "gpu"}, []string{"code.review", "?gpu"}).Score
+	if withPreferred <= exact {
+		t.Fatalf("expected preferred match to raise score, got %d <= %d", withPreferred, exact)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	if CompareVersions("3.10", "3.9") <= 0 {
+		t.Fatalf("expected numeric segment comparison")
+	}
+	if CompareVersions("3.11", "3.11.0") != 0 {
+		t.Fatalf("expected missing segments to compare as zero")
+	}
+	if CompareVersions("1.0-beta", "1.0-alpha") <= 0 {
+		t.Fatalf("expected lexical fallback")
+	}
+}
@@ -117,6 +117,7 @@ type Step struct {
 	Name                 string          `json:"name"`
 	Status               StepStatus      `json:"status"`
 	RequiredCapabilities []string        `json:"requiredCapabilities,omitempty"`
+	MinTrustScore        int             `json:"minTrustScore,omitempty"`
 	DependsOn            []string        `json:"dependsOn,omitempty"`
 	LeaseTTLSeconds      int             `json:"leaseTtlSeconds"`
 	ConsensusPolicy      json.RawMessage `json:"consensusPolicy,omitempty"`
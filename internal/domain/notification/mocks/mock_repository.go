@@ -227,6 +227,117 @@ func (mr *MockRepositoryMockRecorder) ExpireNotifications(ctx interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireNotifications", reflect.TypeOf((*MockRepository)(nil).ExpireNotifications), ctx)
 }
 
+// CountByThrottleKey mocks base method.
+func (m *MockRepository) CountByThrottleKey(ctx context.Context, throttleKey string, since time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByThrottleKey", ctx, throttleKey, since)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByThrottleKey indicates an expected call of CountByThrottleKey.
+func (mr *MockRepositoryMockRecorder) CountByThrottleKey(ctx, throttleKey, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByThrottleKey", reflect.TypeOf((*MockRepository)(nil).CountByThrottleKey), ctx, throttleKey, since)
+}
+
+// ListHeld mocks base method.
+func (m *MockRepository) ListHeld(ctx context.Context, throttleKey string) ([]*notification.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListHeld", ctx, throttleKey)
+	ret0, _ := ret[0].([]*notification.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListHeld indicates an expected call of ListHeld.
+func (mr *MockRepositoryMockRecorder) ListHeld(ctx, throttleKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListHeld", reflect.TypeOf((*MockRepository)(nil).ListHeld), ctx, throttleKey)
+}
+
+// ListDueHeld mocks base method.
+func (m *MockRepository) ListDueHeld(ctx context.Context, before time.Time, limit int) ([]*notification.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueHeld", ctx, before, limit)
+	ret0, _ := ret[0].([]*notification.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueHeld indicates an expected call of ListDueHeld.
+func (mr *MockRepositoryMockRecorder) ListDueHeld(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueHeld", reflect.TypeOf((*MockRepository)(nil).ListDueHeld), ctx, before, limit)
+}
+
+// MockPreferenceRepository is a mock of PreferenceRepository interface.
+type MockPreferenceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPreferenceRepositoryMockRecorder
+}
+
+// MockPreferenceRepositoryMockRecorder is the mock recorder for MockPreferenceRepository.
+type MockPreferenceRepositoryMockRecorder struct {
+	mock *MockPreferenceRepository
+}
+
+// NewMockPreferenceRepository creates a new mock instance.
+func NewMockPreferenceRepository(ctrl *gomock.Controller) *MockPreferenceRepository {
+	mock := &MockPreferenceRepository{ctrl: ctrl}
+	mock.recorder = &MockPreferenceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPreferenceRepository) EXPECT() *MockPreferenceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockPreferenceRepository) Get(ctx context.Context, targetType notification.TargetType, targetID string) (*notification.Preference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, targetType, targetID)
+	ret0, _ := ret[0].(*notification.Preference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockPreferenceRepositoryMockRecorder) Get(ctx, targetType, targetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPreferenceRepository)(nil).Get), ctx, targetType, targetID)
+}
+
+// Upsert mocks base method.
+func (m *MockPreferenceRepository) Upsert(ctx context.Context, preference *notification.Preference) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, preference)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockPreferenceRepositoryMockRecorder) Upsert(ctx, preference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockPreferenceRepository)(nil).Upsert), ctx, preference)
+}
+
+// Delete mocks base method.
+func (m *MockPreferenceRepository) Delete(ctx context.Context, targetType notification.TargetType, targetID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, targetType, targetID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockPreferenceRepositoryMockRecorder) Delete(ctx, targetType, targetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockPreferenceRepository)(nil).Delete), ctx, targetType, targetID)
+}
+
 // MockSSEHub is a mock of SSEHub interface.
 type MockSSEHub struct {
 	ctrl     *gomock.Controller
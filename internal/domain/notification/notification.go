@@ -17,6 +17,15 @@ const (
 	StatusDelivered Status = "DELIVERED"
 	StatusFailed    Status = "FAILED"
 	StatusExpired   Status = "EXPIRED"
+	// StatusDeadLetter holds notifications that failed permanently or ran
+	// out of retries until an operator replays or discards them.
+	StatusDeadLetter Status = "DEAD_LETTER"
+	StatusDiscarded  Status = "DISCARDED"
+	// StatusHeld, StatusDigested and StatusSuppressed are set by throttling;
+	// see ThrottleConfig.
+	StatusHeld       Status = "HELD"
+	StatusDigested   Status = "DIGESTED"
+	StatusSuppressed Status = "SUPPRESSED"
 )
 
 // Channel represents the notification delivery channel
@@ -39,12 +48,15 @@ const (
 )
 
 var (
+	ErrNotFound          = errors.New("notification not found")
 	ErrInvalidTransition = errors.New("invalid status transition")
 	ErrAlreadyDelivered  = errors.New("notification already delivered")
 	ErrExpired           = errors.New("notification has expired")
 	ErrClientNotFound    = errors.New("SSE client not found")
 	ErrChannelFull       = errors.New("SSE message channel full")
 	ErrCannotRetry       = errors.New("cannot retry notification")
+	ErrNotDeadLettered   = errors.New("notification is not dead-lettered")
+	ErrDeferred          = errors.New("notification is deferred")
 )
 
 // Notification represents a notification to be sent to users
@@ -52,8 +64,11 @@ type Notification struct {
 	ID               int64           `json:"id"`
 	NotificationID   uuid.UUID       `json:"notificationId"`
 	ActionID         uuid.UUID       `json:"actionId"`
+	RuleID           *uuid.UUID      `json:"ruleId,omitempty"`
+	WorkflowID       *uuid.UUID      `json:"workflowId,omitempty"`
 	DedupeKey        *string         `json:"dedupeKey,omitempty"`
 	Channel          Channel         `json:"channel"`
+	RoutedFrom       *Channel        `json:"routedFrom,omitempty"`
 	Priority         Priority        `json:"priority"`
 	Title            string          `json:"title"`
 	Body             string          `json:"body"`
@@ -69,6 +84,12 @@ type Notification struct {
 	SentAt           *time.Time      `json:"sentAt,omitempty"`
 	DeliveredAt      *time.Time      `json:"deliveredAt,omitempty"`
 	FailedAt         *time.Time      `json:"failedAt,omitempty"`
+	NextAttemptAt    *time.Time      `json:"nextAttemptAt,omitempty"`
+	DeadLetteredAt   *time.Time      `json:"deadLetteredAt,omitempty"`
+	DeferReason      *DeferReason    `json:"deferReason,omitempty"`
+	ThrottleKey      *string         `json:"throttleKey,omitempty"`
+	HeldUntil        *time.Time      `json:"heldUntil,omitempty"`
+	DigestID         *uuid.UUID      `json:"digestId,omitempty"`
 	TraceID          *string         `json:"traceId,omitempty"`
 }
 
@@ -111,6 +132,45 @@ func (n *Notification) SetTraceID(traceID string) {
 	n.TraceID = &traceID
 }
 
+// SetSource records the rule or workflow that raised the notification, which
+// target preferences can mute
+func (n *Notification) SetSource(ruleID *uuid.UUID, workflowID *uuid.UUID) {
+	n.RuleID = ruleID
+	n.WorkflowID = workflowID
+}
+
+// Reroute moves the notification to channel, remembering the channel it was
+// raised on
+func (n *Notification) Reroute(channel Channel) {
+	if channel == n.Channel {
+		return
+	}
+	if n.RoutedFrom == nil {
+		from := n.Channel
+		n.RoutedFrom = &from
+	}
+	n.Channel = channel
+}
+
+// DeferReason records why delivery of a pending notification waits
+type DeferReason string
+
+const (
+	DeferReasonQuietHours DeferReason = "QUIET_HOURS"
+	DeferReasonMuted      DeferReason = "MUTED"
+)
+
+// Defer postpones delivery of a pending notification until until
+func (n *Notification) Defer(until time.Time, reason DeferReason) error {
+	if n.Status != StatusPending {
+		return ErrInvalidTransition
+	}
+	until = until.UTC()
+	n.NextAttemptAt = &until
+	n.DeferReason = &reason
+	return nil
+}
+
 // IsExpired checks if the notification has expired
 func (n *Notification) IsExpired() bool {
 	if n.ExpiresAt == nil {
@@ -122,11 +182,16 @@ func (n *Notification) IsExpired() bool {
 // CanTransitionTo checks if a transition to the target status is valid
 func (n *Notification) CanTransitionTo(target Status) bool {
 	transitions := map[Status][]Status{
-		StatusPending:   {StatusSent, StatusFailed, StatusExpired},
-		StatusSent:      {StatusDelivered, StatusFailed},
-		StatusDelivered: {},
-		StatusFailed:    {StatusPending}, // Retry
-		StatusExpired:   {},
+		StatusPending:    {StatusSent, StatusFailed, StatusExpired, StatusHeld, StatusSuppressed},
+		StatusSent:       {StatusDelivered, StatusFailed},
+		StatusDelivered:  {},
+		StatusFailed:     {StatusPending, StatusDeadLetter}, // Retry or give up
+		StatusExpired:    {},
+		StatusDeadLetter: {StatusPending, StatusDiscarded}, // Replay or discard
+		StatusDiscarded:  {},
+		StatusHeld:       {StatusDigested, StatusExpired},
+		StatusDigested:   {},
+		StatusSuppressed: {},
 	}
 
 	allowed, ok := transitions[n.Status]
@@ -185,6 +250,67 @@ func (n *Notification) MarkFailed(errMsg string) error {
 	return nil
 }
 
+// MarkFailedPermanently marks the notification as failed and exhausts its
+// retries, for errors that another attempt cannot fix
+func (n *Notification) MarkFailedPermanently(errMsg string) error {
+	if err := n.MarkFailed(errMsg); err != nil {
+		return err
+	}
+	if n.RetryCount < n.MaxRetries {
+		n.RetryCount = n.MaxRetries
+	}
+	return nil
+}
+
+// ScheduleRetry records when the next delivery attempt is due
+func (n *Notification) ScheduleRetry(at time.Time) error {
+	if !n.CanRetry() {
+		return ErrCannotRetry
+	}
+	at = at.UTC()
+	n.NextAttemptAt = &at
+	return nil
+}
+
+// MarkDeadLetter moves a failed notification to the dead-letter queue
+func (n *Notification) MarkDeadLetter() error {
+	if !n.CanTransitionTo(StatusDeadLetter) {
+		return ErrInvalidTransition
+	}
+	n.Status = StatusDeadLetter
+	now := time.Now().UTC()
+	n.DeadLetteredAt = &now
+	n.NextAttemptAt = nil
+	return nil
+}
+
+// Replay returns a dead-lettered notification to PENDING with a fresh
+// retry budget of maxRetries
+func (n *Notification) Replay(maxRetries int) error {
+	if n.Status != StatusDeadLetter {
+		return ErrNotDeadLettered
+	}
+	if n.IsExpired() {
+		return ErrExpired
+	}
+	n.Status = StatusPending
+	n.RetryCount = 0
+	n.MaxRetries = maxRetries
+	n.FailedAt = nil
+	n.NextAttemptAt = nil
+	n.DeadLetteredAt = nil
+	return nil
+}
+
+// Discard drops a dead-lettered notification for good
+func (n *Notification) Discard() error {
+	if n.Status != StatusDeadLetter {
+		return ErrNotDeadLettered
+	}
+	n.Status = StatusDiscarded
+	return nil
+}
+
 // MarkExpired marks the notification as expired
 func (n *Notification) MarkExpired() error {
 	if !n.CanTransitionTo(StatusExpired) {
@@ -206,27 +332,54 @@ func (n *Notification) ResetForRetry() error {
 	}
 	n.Status = StatusPending
 	n.FailedAt = nil
+	n.NextAttemptAt = nil
 	return nil
 }
 
-// IsTerminal returns true if the notification is in a terminal state
+// IsDue reports whether a retry scheduled by ScheduleRetry, or a delivery
+// postponed by Defer, is due at now
+func (n *Notification) IsDue(now time.Time) bool {
+	return n.NextAttemptAt == nil || !n.NextAttemptAt.After(now)
+}
+
+// IsTerminal returns true if the notification is in a terminal state.
+// Dead-lettered notifications are terminal until replayed; digested and
+// suppressed ones were handled by throttling.
 func (n *Notification) IsTerminal() bool {
 	return n.Status == StatusDelivered ||
 		n.Status == StatusExpired ||
+		n.Status == StatusDeadLetter ||
+		n.Status == StatusDiscarded ||
+		n.Status == StatusDigested ||
+		n.Status == StatusSuppressed ||
 		(n.Status == StatusFailed && !n.CanRetry())
 }
 
+// Decision records what happened to a notification after an attempt
+type Decision string
+
+const (
+	DecisionDelivered  Decision = "DELIVERED"
+	DecisionRetry      Decision = "RETRY"
+	DecisionDeadLetter Decision = "DEAD_LETTER"
+	DecisionExpired    Decision = "EXPIRED"
+)
+
 // DeliveryAttempt represents a single delivery attempt
 type DeliveryAttempt struct {
-	ID             int64     `json:"id"`
-	NotificationID uuid.UUID `json:"notificationId"`
-	AttemptNumber  int       `json:"attemptNumber"`
-	Status         Status    `json:"status"`
-	AttemptedAt    time.Time `json:"attemptedAt"`
-	ResponseCode   *int      `json:"responseCode,omitempty"`
-	ResponseBody   *string   `json:"responseBody,omitempty"`
-	ErrorMessage   *string   `json:"errorMessage,omitempty"`
-	DurationMs     int       `json:"durationMs"`
+	ID             int64      `json:"id"`
+	NotificationID uuid.UUID  `json:"notificationId"`
+	AttemptNumber  int        `json:"attemptNumber"`
+	Status         Status     `json:"status"`
+	AttemptedAt    time.Time  `json:"attemptedAt"`
+	ResponseCode   *int       `json:"responseCode,omitempty"`
+	ResponseBody   *string    `json:"responseBody,omitempty"`
+	ErrorMessage   *string    `json:"errorMessage,omitempty"`
+	DurationMs     int        `json:"durationMs"`
+	Decision       *Decision  `json:"decision,omitempty"`
+	Permanent      bool       `json:"permanent"`
+	BackoffMs      *int64     `json:"backoffMs,omitempty"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
 }
 
 // NewDeliveryAttempt creates a new delivery attempt record
@@ -142,6 +142,15 @@ func TestNotification_CanTransitionTo(t *testing.T) {
 		// FAILED transitions (retry)
 		{name: "FAILED -> PENDING (retry)", from: StatusFailed, to: StatusPending, expected: true},
 		{name: "FAILED -> SENT (invalid)", from: StatusFailed, to: StatusSent, expected: false},
+		{name: "FAILED -> DEAD_LETTER", from: StatusFailed, to: StatusDeadLetter, expected: true},
+
+		// DEAD_LETTER transitions (replay or discard)
+		{name: "DEAD_LETTER -> PENDING (replay)", from: StatusDeadLetter, to: StatusPending, expected: true},
+		{name: "DEAD_LETTER -> DISCARDED", from: StatusDeadLetter, to: StatusDiscarded, expected: true},
+		{name: "DEAD_LETTER -> SENT (invalid)", from: StatusDeadLetter, to: StatusSent, expected: false},
+
+		// DISCARDED transitions (terminal)
+		{name: "DISCARDED -> PENDING (invalid)", from: StatusDiscarded, to: StatusPending, expected: false},
 
 		// EXPIRED transitions (terminal)
 		{name: "EXPIRED -> PENDING (invalid)", from: StatusExpired, to: StatusPending, expected: false},
@@ -283,6 +292,88 @@ func TestNotification_MarkFailed(t *testing.T) {
 	})
 }
 
+func TestNotification_MarkFailedPermanently(t *testing.T) {
+	notification := NewNotification(uuid.New(), ChannelEmail, PriorityMedium, "Title", "Body", nil)
+	notification.Status = StatusSent
+
+	err := notification.MarkFailedPermanently("mailbox unavailable")
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, notification.Status)
+	assert.Equal(t, notification.MaxRetries, notification.RetryCount)
+	assert.False(t, notification.CanRetry())
+	assert.True(t, notification.IsTerminal())
+}
+
+func TestNotification_ScheduleRetry(t *testing.T) {
+	notification := NewNotification(uuid.New(), ChannelWebhook, PriorityMedium, "Title", "Body", nil)
+	notification.Status = StatusSent
+	require.NoError(t, notification.MarkFailed("timeout"))
+
+	next := time.Now().Add(time.Minute)
+	require.NoError(t, notification.ScheduleRetry(next))
+	require.NotNil(t, notification.NextAttemptAt)
+	assert.False(t, notification.IsDue(time.Now()))
+	assert.True(t, notification.IsDue(next))
+
+	require.NoError(t, notification.ResetForRetry())
+	assert.Nil(t, notification.NextAttemptAt)
+
+	notification.Status = StatusFailed
+	notification.RetryCount = notification.MaxRetries
+	assert.ErrorIs(t, notification.ScheduleRetry(next), ErrCannotRetry)
+}
+
+func TestNotification_DeadLetter(t *testing.T) {
+	deadLettered := func() *Notification {
+		notification := NewNotification(uuid.New(), ChannelWebhook, PriorityMedium, "Title", "Body", nil)
+		notification.Status = StatusSent
+		require.NoError(t, notification.MarkFailed("gone"))
+		require.NoError(t, notification.ScheduleRetry(time.Now().Add(time.Minute)))
+		require.NoError(t, notification.MarkDeadLetter())
+		return notification
+	}
+
+	t.Run("mark dead letter", func(t *testing.T) {
+		notification := deadLettered()
+		assert.Equal(t, StatusDeadLetter, notification.Status)
+		assert.NotNil(t, notification.DeadLetteredAt)
+		assert.Nil(t, notification.NextAttemptAt)
+		assert.False(t, notification.CanRetry())
+	})
+
+	t.Run("only failed notifications are dead-lettered", func(t *testing.T) {
+		notification := NewNotification(uuid.New(), ChannelWebhook, PriorityMedium, "Title", "Body", nil)
+		assert.ErrorIs(t, notification.MarkDeadLetter(), ErrInvalidTransition)
+	})
+
+	t.Run("replay resets retry budget", func(t *testing.T) {
+		notification := deadLettered()
+		require.NoError(t, notification.Replay(5))
+		assert.Equal(t, StatusPending, notification.Status)
+		assert.Equal(t, 0, notification.RetryCount)
+		assert.Equal(t, 5, notification.MaxRetries)
+		assert.Nil(t, notification.DeadLetteredAt)
+		assert.Nil(t, notification.FailedAt)
+		require.NotNil(t, notification.LastError)
+	})
+
+	t.Run("expired notifications are not replayed", func(t *testing.T) {
+		notification := deadLettered()
+		notification.SetExpiry(time.Now().Add(-time.Minute))
+		assert.ErrorIs(t, notification.Replay(3), ErrExpired)
+		assert.Equal(t, StatusDeadLetter, notification.Status)
+	})
+
+	t.Run("discard", func(t *testing.T) {
+		notification := deadLettered()
+		require.NoError(t, notification.Discard())
+		assert.Equal(t, StatusDiscarded, notification.Status)
+		assert.ErrorIs(t, notification.Replay(3), ErrNotDeadLettered)
+		assert.ErrorIs(t, notification.Discard(), ErrNotDeadLettered)
+	})
+}
+
 func TestNotification_MarkExpired(t *testing.T) {
 	t.Run("success from PENDING", func(t *testing.T) {
 		notification := NewNotification(uuid.New(), ChannelSSE, PriorityMedium, "Title", "Body", nil)
@@ -482,6 +573,16 @@ func TestNotification_IsTerminal(t *testing.T) {
 			expired:    false,
 			expected:   false,
 		},
+		{
+			name:     "DEAD_LETTER is terminal",
+			status:   StatusDeadLetter,
+			expected: true,
+		},
+		{
+			name:     "DISCARDED is terminal",
+			status:   StatusDiscarded,
+			expected: true,
+		},
 		{
 			name:     "PENDING is not terminal",
 			status:   StatusPending,
@@ -592,6 +693,8 @@ func TestStatus_Constants(t *testing.T) {
 	assert.Equal(t, Status("DELIVERED"), StatusDelivered)
 	assert.Equal(t, Status("FAILED"), StatusFailed)
 	assert.Equal(t, Status("EXPIRED"), StatusExpired)
+	assert.Equal(t, Status("DEAD_LETTER"), StatusDeadLetter)
+	assert.Equal(t, Status("DISCARDED"), StatusDiscarded)
 }
 
 func TestPriority_Constants(t *testing.T) {
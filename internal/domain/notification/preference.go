@@ -0,0 +1,271 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TargetType is the kind of notification target a preference applies to
+type TargetType string
+
+const (
+	TargetTypeUser  TargetType = "USER"
+	TargetTypeGroup TargetType = "GROUP"
+)
+
+// Preference holds a user's or group's delivery settings. TargetID is
+// matched against the notification's targetUserId or targetGroup.
+type Preference struct {
+	ID         int64      `json:"id"`
+	TargetType TargetType `json:"targetType"`
+	TargetID   string     `json:"targetId"`
+	// Channels lists the channels the target receives, most preferred
+	// first. Empty accepts every channel.
+	Channels []Channel `json:"channels,omitempty"`
+	// MinPriority is the lowest priority delivered on a channel
+	MinPriority map[Channel]Priority `json:"minPriority,omitempty"`
+	QuietHours  *QuietHours          `json:"quietHours,omitempty"`
+	Mutes       []Mute               `json:"mutes,omitempty"`
+	Throttle    *ThrottleConfig      `json:"throttle,omitempty"`
+	CreatedAt   time.Time            `json:"createdAt"`
+	UpdatedAt   time.Time            `json:"updatedAt"`
+}
+
+// Validate checks the target and settings
+func (p *Preference) Validate() error {
+	if p.TargetType != TargetTypeUser && p.TargetType != TargetTypeGroup {
+		return fmt.Errorf("unsupported target type %q", p.TargetType)
+	}
+	if p.TargetID == "" {
+		return fmt.Errorf("targetId is required")
+	}
+	seen := map[Channel]bool{}
+	for _, c := range p.Channels {
+		if c == "" {
+			return errors.New("channel name is required")
+		}
+		if seen[c] {
+			return fmt.Errorf("duplicate channel %s", c)
+		}
+		seen[c] = true
+	}
+	for c, priority := range p.MinPriority {
+		if priority.rank() == 0 {
+			return fmt.Errorf("unsupported minimum priority %q for channel %s", priority, c)
+		}
+	}
+	if p.QuietHours != nil {
+		if err := p.QuietHours.Validate(); err != nil {
+			return fmt.Errorf("invalid quiet hours: %w", err)
+		}
+	}
+	for i, m := range p.Mutes {
+		if err := m.Validate(); err != nil {
+			return fmt.Errorf("invalid mute %d: %w", i, err)
+		}
+	}
+	if p.Throttle != nil {
+		if err := p.Throttle.Validate(); err != nil {
+			return fmt.Errorf("invalid throttle: %w", err)
+		}
+	}
+	return nil
+}
+
+// Accepts reports whether the target receives notifications of priority
+// on channel
+func (p *Preference) Accepts(channel Channel, priority Priority) bool {
+	if len(p.Channels) > 0 && !containsChannel(p.Channels, channel) {
+		return false
+	}
+	if min, ok := p.MinPriority[channel]; ok && priority.rank() < min.rank() {
+		return false
+	}
+	return true
+}
+
+// Route returns the channel for a notification requested on channel: the
+// requested one if the target accepts it, otherwise the first accepted
+// channel of Channels that is available. When no channel accepts it the
+// notification falls back to the SSE feed rather than being dropped.
+func (p *Preference) Route(channel Channel, priority Priority, available func(Channel) bool) Channel {
+	if p.Accepts(channel, priority) {
+		return channel
+	}
+	for _, c := range p.Channels {
+		if p.Accepts(c, priority) && available(c) {
+			return c
+		}
+	}
+	return ChannelSSE
+}
+
+// DeferUntil returns when n may be delivered under the target's mutes and
+// quiet hours, and why it waits. It reports false when n may be delivered
+// at now.
+func (p *Preference) DeferUntil(n *Notification, now time.Time) (time.Time, DeferReason, bool) {
+	var reason DeferReason
+	at := now
+	// A mute can end inside quiet hours and vice versa; the bound stops
+	// overlapping settings from looping.
+	for i := 0; i < 2*(len(p.Mutes)+1); i++ {
+		next, r, ok := p.deferOnce(n, at)
+		if !ok {
+			break
+		}
+		if reason == "" {
+			reason = r
+		}
+		at = next
+	}
+	if reason == "" {
+		return time.Time{}, "", false
+	}
+	return at, reason, true
+}
+
+func (p *Preference) deferOnce(n *Notification, at time.Time) (time.Time, DeferReason, bool) {
+	for _, m := range p.Mutes {
+		if m.Matches(n) && m.Until.After(at) {
+			return m.Until, DeferReasonMuted, true
+		}
+	}
+	if p.QuietHours != nil && p.QuietHours.Applies(n.Priority) {
+		if end, ok := p.QuietHours.Until(at); ok {
+			return end, DeferReasonQuietHours, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func containsChannel(channels []Channel, channel Channel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// rank orders priorities from LOW (1) to CRITICAL (4); unknown is 0
+func (p Priority) rank() int {
+	switch p {
+	case PriorityLow:
+		return 1
+	case PriorityMedium:
+		return 2
+	case PriorityHigh:
+		return 3
+	case PriorityCritical:
+		return 4
+	}
+	return 0
+}
+
+// QuietHours defers delivery between Start and End, "HH:MM" wall-clock
+// times in TimeZone (an IANA name, UTC when empty). A window whose End is
+// before its Start spans midnight.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone,omitempty"`
+	// IncludeCritical defers CRITICAL notifications too, which are
+	// delivered during quiet hours by default.
+	IncludeCritical bool `json:"includeCritical,omitempty"`
+}
+
+// Validate checks the times and time zone
+func (q QuietHours) Validate() error {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+	if start == end {
+		return errors.New("start and end must differ")
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q", q.TimeZone)
+	}
+	return nil
+}
+
+// Applies reports whether notifications of priority p wait for quiet hours
+func (q QuietHours) Applies(p Priority) bool {
+	return p != PriorityCritical || q.IncludeCritical
+}
+
+// Until returns when the quiet period containing t ends. It reports false
+// when t is outside quiet hours or the settings are invalid.
+func (q QuietHours) Until(t time.Time) (time.Time, bool) {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endDay := 0
+	switch {
+	case start < end && minute >= start && minute < end:
+	case start > end && minute >= start:
+		endDay = 1
+	case start > end && minute < end:
+	default:
+		return time.Time{}, false
+	}
+	y, m, d := local.Date()
+	return time.Date(y, m, d+endDay, end/60, end%60, 0, 0, loc).UTC(), true
+}
+
+// parseClock returns the minutes after midnight of an "HH:MM" time
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Mute defers the notifications raised by one rule or workflow until Until
+type Mute struct {
+	RuleID     *uuid.UUID `json:"ruleId,omitempty"`
+	WorkflowID *uuid.UUID `json:"workflowId,omitempty"`
+	Until      time.Time  `json:"until"`
+}
+
+// Validate checks that the mute names exactly one rule or workflow and ends
+func (m Mute) Validate() error {
+	if (m.RuleID == nil) == (m.WorkflowID == nil) {
+		return errors.New("exactly one of ruleId and workflowId is required")
+	}
+	if m.Until.IsZero() {
+		return errors.New("until is required")
+	}
+	return nil
+}
+
+// Matches reports whether n was raised by the muted rule or workflow
+func (m Mute) Matches(n *Notification) bool {
+	if m.RuleID != nil {
+		return n.RuleID != nil && *n.RuleID == *m.RuleID
+	}
+	if m.WorkflowID != nil {
+		return n.WorkflowID != nil && *n.WorkflowID == *m.WorkflowID
+	}
+	return false
+}
@@ -0,0 +1,166 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreference_Validate(t *testing.T) {
+	ruleID := uuid.New()
+	until := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	valid := func() *Preference {
+		return &Preference{
+			TargetType:  TargetTypeUser,
+			TargetID:    "alice",
+			Channels:    []Channel{ChannelEmail, ChannelSSE},
+			MinPriority: map[Channel]Priority{ChannelEmail: PriorityHigh},
+			QuietHours:  &QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"},
+			Mutes:       []Mute{{RuleID: &ruleID, Until: until}},
+		}
+	}
+	require.NoError(t, valid().Validate())
+
+	tests := []struct {
+		name   string
+		modify func(p *Preference)
+		err    string
+	}{
+		{"duplicate channel", func(p *Preference) { p.Channels = []Channel{ChannelSSE, ChannelSSE} }, "duplicate channel SSE"},
+		{"unknown priority", func(p *Preference) { p.MinPriority[ChannelSSE] = "URGENT" }, "unsupported minimum priority"},
+		{"bad clock", func(p *Preference) { p.QuietHours.Start = "25:00" }, "invalid quiet hours: invalid start"},
+		{"empty window", func(p *Preference) { p.QuietHours.End = "22:00" }, "start and end must differ"},
+		{"bad time zone", func(p *Preference) { p.QuietHours.TimeZone = "Mars/Olympus" }, "invalid time zone"},
+		{"mute without source", func(p *Preference) { p.Mutes[0].RuleID = nil }, "exactly one of ruleId and workflowId"},
+		{"mute without end", func(p *Preference) { p.Mutes[0].Until = time.Time{} }, "until is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid()
+			tt.modify(p)
+			assert.ErrorContains(t, p.Validate(), tt.err)
+		})
+	}
+}
+
+func TestPreference_Route(t *testing.T) {
+	all := func(Channel) bool { return true }
+	p := &Preference{
+		Channels:    []Channel{ChannelEmail, ChannelWebhook},
+		MinPriority: map[Channel]Priority{ChannelEmail: PriorityHigh},
+	}
+
+	assert.Equal(t, ChannelEmail, p.Route(ChannelEmail, PriorityCritical, all))
+	assert.Equal(t, ChannelEmail, p.Route(ChannelSSE, PriorityHigh, all), "disallowed channel is rerouted")
+	assert.Equal(t, ChannelWebhook, p.Route(ChannelEmail, PriorityLow, all), "below minimum priority is rerouted")
+	assert.Equal(t, ChannelWebhook, p.Route(ChannelSSE, PriorityHigh, func(c Channel) bool { return c != ChannelEmail }))
+	assert.Equal(t, ChannelSSE, p.Route(ChannelEmail, PriorityLow, func(c Channel) bool { return c == ChannelSSE }), "falls back to SSE")
+	assert.Equal(t, ChannelSSE, (&Preference{}).Route(ChannelSSE, PriorityLow, all))
+}
+
+func TestQuietHours_Until(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	overnight := QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Berlin"}
+	daytime := QuietHours{Start: "12:00", End: "13:30"}
+
+	tests := []struct {
+		name  string
+		q     QuietHours
+		at    time.Time
+		until time.Time
+		quiet bool
+	}{
+		{"before midnight", overnight, time.Date(2026, 3, 1, 23, 15, 0, 0, berlin), time.Date(2026, 3, 2, 7, 0, 0, 0, berlin), true},
+		{"after midnight", overnight, time.Date(2026, 3, 2, 3, 0, 0, 0, berlin), time.Date(2026, 3, 2, 7, 0, 0, 0, berlin), true},
+		{"end is exclusive", overnight, time.Date(2026, 3, 2, 7, 0, 0, 0, berlin), time.Time{}, false},
+		{"daytime outside", overnight, time.Date(2026, 3, 2, 12, 0, 0, 0, berlin), time.Time{}, false},
+		{"utc window", daytime, time.Date(2026, 3, 2, 12, 45, 0, 0, time.UTC), time.Date(2026, 3, 2, 13, 30, 0, 0, time.UTC), true},
+		{"utc window outside", daytime, time.Date(2026, 3, 2, 11, 59, 0, 0, time.UTC), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := tt.q.Until(tt.at)
+			assert.Equal(t, tt.quiet, quiet)
+			if tt.quiet {
+				assert.True(t, tt.until.Equal(until), "got %s", until)
+			}
+		})
+	}
+}
+
+func TestPreference_DeferUntil(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	ruleID := uuid.New()
+	workflowID := uuid.New()
+	n := NewNotification(uuid.New(), ChannelSSE, PriorityHigh, "Title", "Body", nil)
+	n.SetSource(&ruleID, nil)
+
+	t.Run("no settings", func(t *testing.T) {
+		_, _, ok := (&Preference{}).DeferUntil(n, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("muted rule", func(t *testing.T) {
+		p := &Preference{Mutes: []Mute{
+			{WorkflowID: &workflowID, Until: now.Add(5 * time.Hour)},
+			{RuleID: &ruleID, Until: now.Add(time.Hour)},
+		}}
+		until, reason, ok := p.DeferUntil(n, now)
+		require.True(t, ok)
+		assert.Equal(t, DeferReasonMuted, reason)
+		assert.Equal(t, now.Add(time.Hour), until)
+	})
+
+	t.Run("expired mute", func(t *testing.T) {
+		p := &Preference{Mutes: []Mute{{RuleID: &ruleID, Until: now.Add(-time.Minute)}}}
+		_, _, ok := p.DeferUntil(n, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("mute ending in quiet hours", func(t *testing.T) {
+		p := &Preference{
+			Mutes:      []Mute{{RuleID: &ruleID, Until: now.Add(3 * time.Hour)}},
+			QuietHours: &QuietHours{Start: "22:00", End: "06:00"},
+		}
+		until, reason, ok := p.DeferUntil(n, now)
+		require.True(t, ok)
+		assert.Equal(t, DeferReasonMuted, reason)
+		assert.Equal(t, time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC), until)
+	})
+
+	t.Run("critical skips quiet hours", func(t *testing.T) {
+		critical := NewNotification(uuid.New(), ChannelSSE, PriorityCritical, "Title", "Body", nil)
+		p := &Preference{QuietHours: &QuietHours{Start: "19:00", End: "06:00"}}
+		_, _, ok := p.DeferUntil(critical, now)
+		assert.False(t, ok)
+
+		p.QuietHours.IncludeCritical = true
+		_, reason, ok := p.DeferUntil(critical, now)
+		assert.True(t, ok)
+		assert.Equal(t, DeferReasonQuietHours, reason)
+	})
+}
+
+func TestNotification_DeferAndReroute(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	n := NewNotification(uuid.New(), ChannelEmail, PriorityLow, "Title", "Body", nil)
+
+	n.Reroute(ChannelEmail)
+	assert.Nil(t, n.RoutedFrom)
+	n.Reroute(ChannelSSE)
+	n.Reroute(ChannelWebhook)
+	assert.Equal(t, ChannelWebhook, n.Channel)
+	assert.Equal(t, ChannelEmail, *n.RoutedFrom)
+
+	require.NoError(t, n.Defer(now.Add(time.Hour), DeferReasonQuietHours))
+	assert.False(t, n.IsDue(now))
+	assert.True(t, n.IsDue(now.Add(time.Hour)))
+	assert.Equal(t, DeferReasonQuietHours, *n.DeferReason)
+
+	require.NoError(t, n.MarkSent())
+	assert.ErrorIs(t, n.Defer(now, DeferReasonMuted), ErrInvalidTransition)
+}
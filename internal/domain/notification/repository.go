@@ -1,6 +1,6 @@
 package notification
 
-//go:generate go run go.uber.org/mock/mockgen -destination=mocks/mock_repository.go -package=mocks . Repository,SSEHub
+//go:generate go run go.uber.org/mock/mockgen -destination=mocks/mock_repository.go -package=mocks . Repository,PreferenceRepository,SSEHub
 
 import (
 	"context"
@@ -33,6 +33,18 @@ type Repository interface {
 
 	// Expiration
 	ExpireNotifications(ctx context.Context) (int64, error)
+
+	// Throttling
+	CountByThrottleKey(ctx context.Context, throttleKey string, since time.Time) (int, error)
+	ListHeld(ctx context.Context, throttleKey string) ([]*Notification, error)
+	ListDueHeld(ctx context.Context, before time.Time, limit int) ([]*Notification, error)
+}
+
+// PreferenceRepository persists per-user and per-group preferences
+type PreferenceRepository interface {
+	Get(ctx context.Context, targetType TargetType, targetID string) (*Preference, error)
+	Upsert(ctx context.Context, preference *Preference) error
+	Delete(ctx context.Context, targetType TargetType, targetID string) error
 }
 
 // SSEHub defines the interface for managing SSE connections
@@ -0,0 +1,71 @@
+package notification
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// RetryPolicy schedules redelivery of failed notifications with
+// exponential backoff. The delay before retry n (1-based) is
+// InitialBackoff * Multiplier^(n-1), capped at MaxBackoff, then spread by
+// up to ±Jitter of itself so failed deliveries to the same endpoint do not
+// retry in lockstep.
+type RetryPolicy struct {
+	// MaxRetries is the number of failed attempts after which the
+	// notification is dead-lettered.
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is a fraction between 0 and 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is used for channels without their own policy
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     30 * time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Validate checks the policy bounds
+func (p RetryPolicy) Validate() error {
+	if p.MaxRetries < 1 {
+		return errors.New("maxRetries must be at least 1")
+	}
+	if p.InitialBackoff <= 0 {
+		return errors.New("initialBackoff must be positive")
+	}
+	if p.MaxBackoff < p.InitialBackoff {
+		return errors.New("maxBackoff must not be less than initialBackoff")
+	}
+	if p.Multiplier < 1 {
+		return errors.New("multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff returns the delay before the given retry. random is a sample in
+// [0, 1) that picks the jitter offset; 0.5 yields the unjittered delay.
+func (p RetryPolicy) Backoff(retry int, random float64) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	delay += delay * p.Jitter * (2*random - 1)
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxRetries:     5,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	tests := []struct {
+		name     string
+		retry    int
+		random   float64
+		expected time.Duration
+	}{
+		{name: "first retry without jitter", retry: 1, random: 0.5, expected: 10 * time.Second},
+		{name: "grows exponentially", retry: 3, random: 0.5, expected: 40 * time.Second},
+		{name: "capped at max backoff", retry: 5, random: 0.5, expected: time.Minute},
+		{name: "jitter lowers delay", retry: 2, random: 0, expected: 10 * time.Second},
+		{name: "jitter raises delay", retry: 2, random: 0.75, expected: 25 * time.Second},
+		{name: "jitter never exceeds max backoff", retry: 4, random: 0.99, expected: time.Minute},
+		{name: "retry below one treated as first", retry: 0, random: 0.5, expected: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.Backoff(tt.retry, tt.random))
+		})
+	}
+}
+
+func TestRetryPolicy_Validate(t *testing.T) {
+	assert.NoError(t, DefaultRetryPolicy().Validate())
+
+	tests := []struct {
+		name    string
+		mutate  func(p *RetryPolicy)
+		errText string
+	}{
+		{name: "no retries", mutate: func(p *RetryPolicy) { p.MaxRetries = 0 }, errText: "maxRetries"},
+		{name: "zero initial backoff", mutate: func(p *RetryPolicy) { p.InitialBackoff = 0 }, errText: "initialBackoff"},
+		{name: "max below initial", mutate: func(p *RetryPolicy) { p.MaxBackoff = time.Second }, errText: "maxBackoff"},
+		{name: "shrinking multiplier", mutate: func(p *RetryPolicy) { p.Multiplier = 0.5 }, errText: "multiplier"},
+		{name: "jitter above one", mutate: func(p *RetryPolicy) { p.Jitter = 1.5 }, errText: "jitter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultRetryPolicy()
+			tt.mutate(&policy)
+			assert.ErrorContains(t, policy.Validate(), tt.errText)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package notification
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThrottleMode selects what happens to notifications over a target's limit
+type ThrottleMode string
+
+const (
+	// ThrottleModeRateLimit suppresses notifications over the limit
+	ThrottleModeRateLimit ThrottleMode = "RATE_LIMIT"
+	// ThrottleModeDigest holds notifications over the limit and folds them
+	// into one digest notification when the window closes
+	ThrottleModeDigest ThrottleMode = "DIGEST"
+)
+
+// ThrottleConfig limits how many notifications one target receives per
+// channel and priority. It can be set in a notify action config under
+// "throttle" or in a target's Preference, which takes precedence.
+type ThrottleConfig struct {
+	Mode          ThrottleMode `json:"mode"`
+	WindowSeconds int          `json:"windowSeconds"`
+	// MaxPerWindow notifications are delivered individually in any window;
+	// in digest mode 0 digests every notification.
+	MaxPerWindow int `json:"maxPerWindow"`
+	// IncludeCritical applies the limit to CRITICAL notifications, which
+	// bypass it by default.
+	IncludeCritical bool `json:"includeCritical,omitempty"`
+}
+
+// Validate checks the mode and bounds
+func (c ThrottleConfig) Validate() error {
+	switch c.Mode {
+	case ThrottleModeRateLimit:
+		if c.MaxPerWindow < 1 {
+			return errors.New("maxPerWindow must be at least 1 in RATE_LIMIT mode")
+		}
+	case ThrottleModeDigest:
+		if c.MaxPerWindow < 0 {
+			return errors.New("maxPerWindow must not be negative")
+		}
+	default:
+		return fmt.Errorf("unsupported throttle mode %q", c.Mode)
+	}
+	if c.WindowSeconds < 1 {
+		return errors.New("windowSeconds must be at least 1")
+	}
+	return nil
+}
+
+// Window returns the throttle window
+func (c ThrottleConfig) Window() time.Duration {
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+// Applies reports whether notifications of priority p are throttled
+func (c ThrottleConfig) Applies(p Priority) bool {
+	return p != PriorityCritical || c.IncludeCritical
+}
+
+// ThrottleKey identifies the stream a throttle limit counts: one channel,
+// target and priority.
+func ThrottleKey(n *Notification) string {
+	target := "all"
+	if n.TargetUserID != nil {
+		target = "user:" + *n.TargetUserID
+	} else if n.TargetGroup != nil {
+		target = "group:" + *n.TargetGroup
+	}
+	return strings.Join([]string{string(n.Channel), target, string(n.Priority)}, "|")
+}
+
+// Hold parks a pending notification until its digest window closes
+func (n *Notification) Hold(until time.Time) error {
+	if !n.CanTransitionTo(StatusHeld) {
+		return ErrInvalidTransition
+	}
+	until = until.UTC()
+	n.Status = StatusHeld
+	n.HeldUntil = &until
+	return nil
+}
+
+// MarkDigested records that a held notification was delivered as part of
+// digest digestID
+func (n *Notification) MarkDigested(digestID uuid.UUID) error {
+	if !n.CanTransitionTo(StatusDigested) {
+		return ErrInvalidTransition
+	}
+	n.Status = StatusDigested
+	n.DigestID = &digestID
+	return nil
+}
+
+// Suppress drops a pending notification that is over its rate limit. The
+// record is kept for audit.
+func (n *Notification) Suppress() error {
+	if !n.CanTransitionTo(StatusSuppressed) {
+		return ErrInvalidTransition
+	}
+	n.Status = StatusSuppressed
+	return nil
+}
+
+// digestTopItems bounds the titles listed in a digest
+const digestTopItems = 5
+
+// DigestEntry counts the held notifications sharing a title
+type DigestEntry struct {
+	Title              string    `json:"title"`
+	Count              int       `json:"count"`
+	LastNotificationID uuid.UUID `json:"lastNotificationId"`
+	LastAt             time.Time `json:"lastAt"`
+}
+
+// Digest is the payload of a digest notification
+type Digest struct {
+	Count           int           `json:"count"`
+	Priority        Priority      `json:"priority"`
+	WindowStart     time.Time     `json:"windowStart"`
+	WindowEnd       time.Time     `json:"windowEnd"`
+	Top             []DigestEntry `json:"top"`
+	NotificationIDs []uuid.UUID   `json:"notificationIds"`
+}
+
+// NewDigest summarises held notifications of one throttle key, most
+// frequent titles first
+func NewDigest(held []*Notification) *Digest {
+	d := &Digest{Count: len(held)}
+	byTitle := map[string]*DigestEntry{}
+	for _, n := range held {
+		d.Priority = n.Priority
+		d.NotificationIDs = append(d.NotificationIDs, n.NotificationID)
+		if d.WindowStart.IsZero() || n.CreatedAt.Before(d.WindowStart) {
+			d.WindowStart = n.CreatedAt
+		}
+		if n.HeldUntil != nil && n.HeldUntil.After(d.WindowEnd) {
+			d.WindowEnd = *n.HeldUntil
+		}
+		entry, ok := byTitle[n.Title]
+		if !ok {
+			entry = &DigestEntry{Title: n.Title}
+			byTitle[n.Title] = entry
+		}
+		entry.Count++
+		if !n.CreatedAt.Before(entry.LastAt) {
+			entry.LastAt = n.CreatedAt
+			entry.LastNotificationID = n.NotificationID
+		}
+	}
+	for _, entry := range byTitle {
+		d.Top = append(d.Top, *entry)
+	}
+	sort.Slice(d.Top, func(i, j int) bool {
+		if d.Top[i].Count != d.Top[j].Count {
+			return d.Top[i].Count > d.Top[j].Count
+		}
+		return d.Top[i].LastAt.After(d.Top[j].LastAt)
+	})
+	if len(d.Top) > digestTopItems {
+		d.Top = d.Top[:digestTopItems]
+	}
+	return d
+}
+
+// Title returns the digest notification title
+func (d *Digest) Title() string {
+	return fmt.Sprintf("%d %s notifications", d.Count, d.Priority)
+}
+
+// Body lists the top titles with their counts
+func (d *Digest) Body() string {
+	var b strings.Builder
+	for _, entry := range d.Top {
+		fmt.Fprintf(&b, "%d× %s\n", entry.Count, entry.Title)
+	}
+	if shown := d.shown(); shown < d.Count {
+		fmt.Fprintf(&b, "and %d more\n", d.Count-shown)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func (d *Digest) shown() int {
+	total := 0
+	for _, entry := range d.Top {
+		total += entry.Count
+	}
+	return total
+}
@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ThrottleConfig
+		errText string
+	}{
+		{name: "rate limit", cfg: ThrottleConfig{Mode: ThrottleModeRateLimit, WindowSeconds: 60, MaxPerWindow: 5}},
+		{name: "digest everything", cfg: ThrottleConfig{Mode: ThrottleModeDigest, WindowSeconds: 300}},
+		{name: "unknown mode", cfg: ThrottleConfig{Mode: "DROP", WindowSeconds: 60}, errText: `unsupported throttle mode "DROP"`},
+		{name: "rate limit of zero", cfg: ThrottleConfig{Mode: ThrottleModeRateLimit, WindowSeconds: 60}, errText: "maxPerWindow must be at least 1"},
+		{name: "negative digest limit", cfg: ThrottleConfig{Mode: ThrottleModeDigest, WindowSeconds: 60, MaxPerWindow: -1}, errText: "must not be negative"},
+		{name: "no window", cfg: ThrottleConfig{Mode: ThrottleModeDigest}, errText: "windowSeconds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.errText == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errText)
+			}
+		})
+	}
+}
+
+func TestThrottleConfig_Applies(t *testing.T) {
+	cfg := ThrottleConfig{Mode: ThrottleModeDigest, WindowSeconds: 60}
+	assert.True(t, cfg.Applies(PriorityHigh))
+	assert.False(t, cfg.Applies(PriorityCritical))
+
+	cfg.IncludeCritical = true
+	assert.True(t, cfg.Applies(PriorityCritical))
+}
+
+func TestThrottleKey(t *testing.T) {
+	user, group := "alice", "role:OPERATOR"
+
+	n := NewNotification(uuid.New(), ChannelSSE, PriorityHigh, "Title", "Body", nil)
+	assert.Equal(t, "SSE|all|HIGH", ThrottleKey(n))
+
+	n.SetTarget(nil, &group)
+	assert.Equal(t, "SSE|group:role:OPERATOR|HIGH", ThrottleKey(n))
+
+	n.SetTarget(&user, &group)
+	assert.Equal(t, "SSE|user:alice|HIGH", ThrottleKey(n))
+}
+
+func TestNotification_ThrottleTransitions(t *testing.T) {
+	until := time.Now().Add(time.Minute)
+
+	n := NewNotification(uuid.New(), ChannelSSE, PriorityHigh, "Title", "Body", nil)
+	require.NoError(t, n.Hold(until))
+	assert.Equal(t, StatusHeld, n.Status)
+	assert.False(t, n.IsTerminal())
+	assert.ErrorIs(t, n.MarkSent(), ErrInvalidTransition)
+
+	digestID := uuid.New()
+	require.NoError(t, n.MarkDigested(digestID))
+	assert.Equal(t, StatusDigested, n.Status)
+	assert.Equal(t, &digestID, n.DigestID)
+	assert.True(t, n.IsTerminal())
+
+	n = NewNotification(uuid.New(), ChannelSSE, PriorityHigh, "Title", "Body", nil)
+	require.NoError(t, n.Suppress())
+	assert.True(t, n.IsTerminal())
+	assert.ErrorIs(t, n.Hold(until), ErrInvalidTransition)
+}
+
+func TestNewDigest(t *testing.T) {
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	until := start.Add(5 * time.Minute)
+	var held []*Notification
+	add := func(title string, offset time.Duration) *Notification {
+		n := NewNotification(uuid.New(), ChannelSSE, PriorityHigh, title, "Body", nil)
+		n.CreatedAt = start.Add(offset)
+		require.NoError(t, n.Hold(until))
+		held = append(held, n)
+		return n
+	}
+	add("Temperature high", 0)
+	add("Pressure low", time.Second)
+	lastTemp := add("Temperature high", 2*time.Second)
+	for i := 0; i < 5; i++ {
+		add("Sensor "+string(rune('A'+i)), time.Duration(3+i)*time.Second)
+	}
+
+	d := NewDigest(held)
+
+	assert.Equal(t, 8, d.Count)
+	assert.Equal(t, PriorityHigh, d.Priority)
+	assert.Equal(t, start, d.WindowStart)
+	assert.Equal(t, until, d.WindowEnd)
+	assert.Len(t, d.NotificationIDs, 8)
+	require.Len(t, d.Top, 5)
+	assert.Equal(t, DigestEntry{Title: "Temperature high", Count: 2, LastNotificationID: lastTemp.NotificationID, LastAt: lastTemp.CreatedAt}, d.Top[0])
+	assert.Equal(t, "Sensor E", d.Top[1].Title, "ties are broken by recency")
+	assert.Equal(t, "8 HIGH notifications", d.Title())
+	assert.Equal(t, "2× Temperature high\n1× Sensor E\n1× Sensor D\n1× Sensor C\n1× Sensor B\nand 2 more", d.Body())
+}
@@ -19,8 +19,21 @@ type Evaluation struct {
 	Evidence     json.RawMessage `json:"evidence"`
 	EventIDs     []uuid.UUID     `json:"eventIds,omitempty"`
 	TraceID      *string         `json:"traceId,omitempty"`
+	// TrustStatus is set when the rule tags low-trust events; the action of
+	// a PENDING evaluation waits for LowTrustEventIDs to become trusted
+	TrustStatus      TrustStatus `json:"trustStatus,omitempty"`
+	LowTrustEventIDs []uuid.UUID `json:"lowTrustEventIds,omitempty"`
 }
 
+// TrustStatus tracks an evaluation made with low-trust events
+type TrustStatus string
+
+const (
+	TrustStatusPending  TrustStatus = "PENDING"
+	TrustStatusTrusted  TrustStatus = "TRUSTED"
+	TrustStatusRejected TrustStatus = "REJECTED"
+)
+
 // NewEvaluation creates a new Evaluation from a rule
 func NewEvaluation(r *Rule, matched bool, evidence json.RawMessage, eventIDs []uuid.UUID) *Evaluation {
 	return &Evaluation{
@@ -35,6 +48,13 @@ func NewEvaluation(r *Rule, matched bool, evidence json.RawMessage, eventIDs []u
 	}
 }
 
+// TagLowTrust marks the evaluation as made with eventIDs below the rule's
+// minimum trust level, holding its action until they are trusted
+func (e *Evaluation) TagLowTrust(eventIDs []uuid.UUID) {
+	e.TrustStatus = TrustStatusPending
+	e.LowTrustEventIDs = eventIDs
+}
+
 // SetTraceID sets the trace ID for distributed tracing
 func (e *Evaluation) SetTraceID(traceID string) {
 	e.TraceID = &traceID
@@ -92,6 +112,20 @@ type TimeWindowEvidence struct {
 	Matched      bool        `json:"matched"`
 }
 
+// ExpressionEvidence represents evidence for expression rule evaluation
+type ExpressionEvidence struct {
+	EventID     uuid.UUID              `json:"eventId"`
+	Expression  string                 `json:"expression"`
+	GroupBy     string                 `json:"groupBy,omitempty"`
+	Group       string                 `json:"group,omitempty"`
+	WindowStart *time.Time             `json:"windowStart,omitempty"`
+	WindowEnd   *time.Time             `json:"windowEnd,omitempty"`
+	Values      map[string]interface{} `json:"values,omitempty"`
+	Aggregates  map[string]float64     `json:"aggregates,omitempty"`
+	EventSample []uuid.UUID            `json:"eventSample,omitempty"`
+	Matched     bool                   `json:"matched"`
+}
+
 // EvaluationFilter represents filters for querying evaluations
 type EvaluationFilter struct {
 	RuleID    *uuid.UUID
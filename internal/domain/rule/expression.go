@@ -0,0 +1,251 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+)
+
+// Expression group-by modes
+const (
+	GroupByNone   = ""
+	GroupBySource = "source"
+	GroupByKey    = "key"
+)
+
+// ExpressionConfig defines configuration for expression rules.
+//
+// Expression is a govaluate boolean expression. Payload fields must be
+// declared in Fields and referenced by name; dotted paths are bracketed,
+// e.g. "[metrics.temperature] > 80". The built-in variables event_type,
+// source_id and key describe the event itself.
+//
+// Aggregate functions run over the events in Window, grouped by GroupBy:
+// count(), rate() (events per second), rate('field') (share of events where
+// a boolean field is true), sum, avg, min, max and distinct, each taking a
+// quoted declared field such as avg('temperature').
+type ExpressionConfig struct {
+	Expression string   `json:"expression"`
+	Fields     []string `json:"fields,omitempty"`
+	Window     string   `json:"window,omitempty"`  // e.g., "5m", required by aggregates
+	GroupBy    string   `json:"groupBy,omitempty"` // "", "source" or "key"
+	EventType  string   `json:"eventType,omitempty"`
+}
+
+// ExpressionBuiltins are variables available to every expression
+var ExpressionBuiltins = []string{"event_type", "source_id", "key"}
+
+// ExpressionFunctions lists the supported aggregate functions
+var ExpressionFunctions = []string{"avg", "count", "distinct", "max", "min", "rate", "sum"}
+
+// ExpressionCall is one aggregate function call in an expression
+type ExpressionCall struct {
+	Function string
+	Field    string
+}
+
+// Label renders the call as written, e.g. "avg(temperature)".
+func (c ExpressionCall) Label() string {
+	return c.Function + "(" + c.Field + ")"
+}
+
+// ParsedExpression is a validated expression config
+type ParsedExpression struct {
+	Config ExpressionConfig
+	Window time.Duration
+	// Vars are the payload fields and built-ins the expression reads.
+	Vars  []string
+	Calls []ExpressionCall
+}
+
+// ParseExpressionConfig validates cfg and returns its parsed form
+func ParseExpressionConfig(cfg ExpressionConfig) (*ParsedExpression, error) {
+	if strings.TrimSpace(cfg.Expression) == "" {
+		return nil, errors.New("expression is required")
+	}
+	declared := map[string]bool{}
+	for _, field := range cfg.Fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			return nil, errors.New("fields must not contain empty names")
+		}
+		for _, builtin := range ExpressionBuiltins {
+			if field == builtin {
+				return nil, fmt.Errorf("field %q shadows a built-in variable", field)
+			}
+		}
+		declared[field] = true
+	}
+
+	switch cfg.GroupBy {
+	case GroupByNone, GroupBySource, GroupByKey:
+	default:
+		return nil, fmt.Errorf("invalid groupBy %q: must be %q or %q", cfg.GroupBy, GroupBySource, GroupByKey)
+	}
+
+	calls, err := scanExpressionCalls(cfg.Expression)
+	if err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if call.Field != "" && !declared[call.Field] {
+			return nil, fmt.Errorf("unknown field %q in %s: declare it in fields", call.Field, call.Label())
+		}
+	}
+
+	parsed := &ParsedExpression{Config: cfg, Calls: calls}
+	if cfg.Window != "" {
+		window, err := time.ParseDuration(cfg.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window %q", cfg.Window)
+		}
+		parsed.Window = window
+	}
+	if len(calls) > 0 && parsed.Window == 0 {
+		return nil, errors.New("window is required when aggregate functions are used")
+	}
+	if cfg.GroupBy != GroupByNone && parsed.Window == 0 {
+		return nil, errors.New("groupBy requires a window")
+	}
+
+	expr, err := CompileExpression(cfg.Expression, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range expr.Vars() {
+		if !declared[name] && !isExpressionBuiltin(name) {
+			return nil, fmt.Errorf("unknown field %q: declare it in fields or use one of %s", name, strings.Join(ExpressionBuiltins, ", "))
+		}
+		parsed.Vars = append(parsed.Vars, name)
+	}
+	return parsed, nil
+}
+
+// CompileExpression parses expression with fns bound to the aggregate
+// function names. Missing functions are bound to stubs, so a nil map only
+// checks syntax.
+func CompileExpression(expression string, fns map[string]govaluate.ExpressionFunction) (*govaluate.EvaluableExpression, error) {
+	bound := make(map[string]govaluate.ExpressionFunction, len(ExpressionFunctions))
+	for _, name := range ExpressionFunctions {
+		if fn, ok := fns[name]; ok {
+			bound[name] = fn
+			continue
+		}
+		bound[name] = func(...interface{}) (interface{}, error) { return 0.0, nil }
+	}
+	expr, err := govaluate.NewEvaluableExpressionWithFunctions(expression, bound)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %v", err)
+	}
+	return expr, nil
+}
+
+func isExpressionBuiltin(name string) bool {
+	for _, builtin := range ExpressionBuiltins {
+		if name == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+func isExpressionFunction(name string) bool {
+	i := sort.SearchStrings(ExpressionFunctions, name)
+	return i < len(ExpressionFunctions) && ExpressionFunctions[i] == name
+}
+
+// scanExpressionCalls finds function calls outside string literals and
+// bracketed variables, checking names and arguments before govaluate sees
+// them so errors name the offending call.
+func scanExpressionCalls(expression string) ([]ExpressionCall, error) {
+	var calls []ExpressionCall
+	n := len(expression)
+	for i := 0; i < n; {
+		c := expression[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, errors.New("invalid expression: unterminated string")
+			}
+			i += end + 2
+		case c == '[':
+			end := strings.IndexByte(expression[i+1:], ']')
+			if end < 0 {
+				return nil, errors.New("invalid expression: unterminated [")
+			}
+			i += end + 2
+		case isIdentStart(c):
+			start := i
+			for i < n && isIdentPart(expression[i]) {
+				i++
+			}
+			name := expression[start:i]
+			j := skipSpaces(expression, i)
+			if j >= n || expression[j] != '(' || strings.EqualFold(name, "IN") {
+				continue
+			}
+			if !isExpressionFunction(name) {
+				return nil, fmt.Errorf("unknown function %q: supported functions are %s", name, strings.Join(ExpressionFunctions, ", "))
+			}
+			call, next, err := scanCallArgs(expression, name, j+1)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, call)
+			i = next
+		default:
+			i++
+		}
+	}
+	return calls, nil
+}
+
+// scanCallArgs reads ")" or "'field')" starting after the opening paren.
+func scanCallArgs(expression, name string, i int) (ExpressionCall, int, error) {
+	call := ExpressionCall{Function: name}
+	i = skipSpaces(expression, i)
+	if i < len(expression) && (expression[i] == '\'' || expression[i] == '"') {
+		quote := expression[i]
+		end := strings.IndexByte(expression[i+1:], quote)
+		if end < 0 {
+			return call, 0, errors.New("invalid expression: unterminated string")
+		}
+		call.Field = expression[i+1 : i+1+end]
+		i = skipSpaces(expression, i+end+2)
+	}
+	if i >= len(expression) || expression[i] != ')' {
+		return call, 0, fmt.Errorf("%s() takes a single quoted field name", name)
+	}
+	switch name {
+	case "count":
+		if call.Field != "" {
+			return call, 0, errors.New("count() takes no arguments")
+		}
+	case "rate":
+	default:
+		if call.Field == "" {
+			return call, 0, fmt.Errorf("%s() requires a quoted field name", name)
+		}
+	}
+	return call, i + 1, nil
+}
+
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
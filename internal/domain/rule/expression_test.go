@@ -0,0 +1,71 @@
+package rule
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpressionConfig(t *testing.T) {
+	t.Run("valid aggregate expression", func(t *testing.T) {
+		parsed, err := ParseExpressionConfig(ExpressionConfig{
+			Expression: "[metrics.temperature] > 80 && avg('metrics.temperature') > 75 && count() >= 3 && source_id != 'lab'",
+			Fields:     []string{"metrics.temperature"},
+			Window:     "5m",
+			GroupBy:    GroupBySource,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, parsed.Window)
+		assert.ElementsMatch(t, []string{"metrics.temperature", "source_id"}, parsed.Vars)
+		assert.Equal(t, []ExpressionCall{
+			{Function: "avg", Field: "metrics.temperature"},
+			{Function: "count"},
+		}, parsed.Calls)
+	})
+
+	t.Run("function names inside strings are ignored", func(t *testing.T) {
+		_, err := ParseExpressionConfig(ExpressionConfig{
+			Expression: "status == 'median(x)' || status IN ('a', 'b')",
+			Fields:     []string{"status"},
+		})
+		require.NoError(t, err)
+	})
+
+	tests := []struct {
+		name     string
+		cfg      ExpressionConfig
+		errorMsg string
+	}{
+		{"empty", ExpressionConfig{}, "expression is required"},
+		{"unknown field", ExpressionConfig{Expression: "pressure > 3", Fields: []string{"temperature"}}, `unknown field "pressure": declare it in fields or use one of event_type, source_id, key`},
+		{"unknown aggregate field", ExpressionConfig{Expression: "max('pressure') > 3", Window: "1m"}, `unknown field "pressure" in max(pressure): declare it in fields`},
+		{"unknown function", ExpressionConfig{Expression: "median('temperature') > 3", Fields: []string{"temperature"}, Window: "1m"}, `unknown function "median": supported functions are avg, count, distinct, max, min, rate, sum`},
+		{"unquoted argument", ExpressionConfig{Expression: "avg(temperature) > 3", Fields: []string{"temperature"}, Window: "1m"}, "avg() takes a single quoted field name"},
+		{"count with argument", ExpressionConfig{Expression: "count('temperature') > 3", Fields: []string{"temperature"}, Window: "1m"}, "count() takes no arguments"},
+		{"aggregate without window", ExpressionConfig{Expression: "count() > 3"}, "window is required when aggregate functions are used"},
+		{"invalid window", ExpressionConfig{Expression: "count() > 3", Window: "soon"}, `invalid window "soon"`},
+		{"invalid groupBy", ExpressionConfig{Expression: "count() > 3", Window: "1m", GroupBy: "line"}, `invalid groupBy "line": must be "source" or "key"`},
+		{"shadowed builtin", ExpressionConfig{Expression: "key == 'a'", Fields: []string{"key"}}, `field "key" shadows a built-in variable`},
+		{"syntax error", ExpressionConfig{Expression: "temperature >", Fields: []string{"temperature"}}, "invalid expression: Unexpected end of expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpressionConfig(tt.cfg)
+			require.Error(t, err)
+			assert.Equal(t, tt.errorMsg, err.Error())
+		})
+	}
+}
+
+func TestRule_Validate_Expression(t *testing.T) {
+	valid := NewRule("Hot", RuleTypeExpression, json.RawMessage(`{"expression":"temperature > 80","fields":["temperature"]}`), ActionTypeNotify, json.RawMessage(`{}`))
+	require.NoError(t, valid.Validate())
+
+	invalid := NewRule("Hot", RuleTypeExpression, json.RawMessage(`{"expression":"temp > 80","fields":["temperature"]}`), ActionTypeNotify, json.RawMessage(`{}`))
+	err := invalid.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid expression config: unknown field "temp"`)
+}
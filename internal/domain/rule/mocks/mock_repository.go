@@ -198,3 +198,33 @@ func (mr *MockRepositoryMockRecorder) ListMatchedEvaluations(ctx, since, limit i
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMatchedEvaluations", reflect.TypeOf((*MockRepository)(nil).ListMatchedEvaluations), ctx, since, limit)
 }
+
+// ListPendingEvaluations mocks base method.
+func (m *MockRepository) ListPendingEvaluations(ctx context.Context, eventIDs []uuid.UUID) ([]*rule.Evaluation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingEvaluations", ctx, eventIDs)
+	ret0, _ := ret[0].([]*rule.Evaluation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingEvaluations indicates an expected call of ListPendingEvaluations.
+func (mr *MockRepositoryMockRecorder) ListPendingEvaluations(ctx, eventIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingEvaluations", reflect.TypeOf((*MockRepository)(nil).ListPendingEvaluations), ctx, eventIDs)
+}
+
+// ResolveEvaluationTrust mocks base method.
+func (m *MockRepository) ResolveEvaluationTrust(ctx context.Context, evaluationID uuid.UUID, status rule.TrustStatus) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveEvaluationTrust", ctx, evaluationID, status)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveEvaluationTrust indicates an expected call of ResolveEvaluationTrust.
+func (mr *MockRepositoryMockRecorder) ResolveEvaluationTrust(ctx, evaluationID, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveEvaluationTrust", reflect.TypeOf((*MockRepository)(nil).ResolveEvaluationTrust), ctx, evaluationID, status)
+}
@@ -25,4 +25,10 @@ type Repository interface {
 	GetEvaluationByID(ctx context.Context, evaluationID uuid.UUID) (*Evaluation, error)
 	ListEvaluationsByRule(ctx context.Context, ruleID uuid.UUID, limit int) ([]*Evaluation, error)
 	ListMatchedEvaluations(ctx context.Context, since time.Time, limit int) ([]*Evaluation, error)
+	// ListPendingEvaluations returns PENDING evaluations with any of
+	// eventIDs among their low-trust events
+	ListPendingEvaluations(ctx context.Context, eventIDs []uuid.UUID) ([]*Evaluation, error)
+	// ResolveEvaluationTrust moves a PENDING evaluation to status and reports
+	// whether it was still pending, so only one caller acts on it
+	ResolveEvaluationTrust(ctx context.Context, evaluationID uuid.UUID, status TrustStatus) (bool, error)
 }
@@ -3,6 +3,7 @@ package rule
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ const (
 	RuleTypeMissingField     RuleType = "MISSING_FIELD"
 	RuleTypeStreamDisconnect RuleType = "STREAM_DISCONNECT"
 	RuleTypeTimeWindow       RuleType = "TIME_WINDOW"
+	RuleTypeExpression       RuleType = "EXPRESSION"
 )
 
 // RuleStatus represents the status of a rule
@@ -37,6 +39,22 @@ const (
 	ActionTypeEscalate ActionType = "ESCALATE"
 )
 
+// LowTrustAction decides what a rule does with events below its minimum
+// trust level
+type LowTrustAction string
+
+const (
+	// LowTrustActionSkip leaves low-trust events out until their batch
+	// signature is verified
+	LowTrustActionSkip LowTrustAction = "SKIP"
+	// LowTrustActionTag evaluates low-trust events but holds the action of a
+	// match until all its events are trusted
+	LowTrustActionTag LowTrustAction = "TAG"
+)
+
+// MaxTrustLevel is the highest trust level (T3, signature verified)
+const MaxTrustLevel = 3
+
 // Rule represents a versioned rule definition
 type Rule struct {
 	ID             int64           `json:"id"`
@@ -50,6 +68,8 @@ type Rule struct {
 	ActionConfig   json.RawMessage `json:"actionConfig"`
 	ScopeFactoryID *string         `json:"scopeFactoryId,omitempty"`
 	ScopeLineID    *string         `json:"scopeLineId,omitempty"`
+	MinTrustLevel  int             `json:"minTrustLevel"`
+	LowTrustAction LowTrustAction  `json:"lowTrustAction,omitempty"`
 	EffectiveFrom  time.Time       `json:"effectiveFrom"`
 	EffectiveUntil *time.Time      `json:"effectiveUntil,omitempty"`
 	Status         RuleStatus      `json:"status"`
@@ -153,6 +173,8 @@ func (r *Rule) CreateNewVersion() *Rule {
 		ActionConfig:   r.ActionConfig,
 		ScopeFactoryID: r.ScopeFactoryID,
 		ScopeLineID:    r.ScopeLineID,
+		MinTrustLevel:  r.MinTrustLevel,
+		LowTrustAction: r.LowTrustAction,
 		EffectiveFrom:  now,
 		Status:         RuleStatusActive,
 		CreatedAt:      now,
@@ -174,6 +196,18 @@ func (r *Rule) IsEffective(t time.Time) bool {
 	return true
 }
 
+// Trusts reports whether events at trust level are at or above the rule's
+// minimum
+func (r *Rule) Trusts(level int) bool {
+	return level >= r.MinTrustLevel
+}
+
+// TagsLowTrust reports whether the rule evaluates low-trust events instead
+// of skipping them
+func (r *Rule) TagsLowTrust() bool {
+	return r.LowTrustAction == LowTrustActionTag
+}
+
 // Activate activates the rule
 func (r *Rule) Activate() {
 	r.Status = RuleStatusActive
@@ -206,7 +240,7 @@ func (r *Rule) Validate() error {
 
 	// Validate rule type
 	switch r.RuleType {
-	case RuleTypeThreshold, RuleTypeRepeated, RuleTypeMissingField, RuleTypeStreamDisconnect, RuleTypeTimeWindow:
+	case RuleTypeThreshold, RuleTypeRepeated, RuleTypeMissingField, RuleTypeStreamDisconnect, RuleTypeTimeWindow, RuleTypeExpression:
 		// Valid
 	default:
 		return errors.New("invalid ruleType")
@@ -220,6 +254,16 @@ func (r *Rule) Validate() error {
 		return errors.New("invalid actionType")
 	}
 
+	if r.MinTrustLevel < 0 || r.MinTrustLevel > MaxTrustLevel {
+		return fmt.Errorf("minTrustLevel must be between 0 and %d", MaxTrustLevel)
+	}
+	switch r.LowTrustAction {
+	case "", LowTrustActionSkip, LowTrustActionTag:
+		// Valid
+	default:
+		return errors.New("invalid lowTrustAction")
+	}
+
 	// Validate config is valid JSON
 	var js json.RawMessage
 	if err := json.Unmarshal(r.Config, &js); err != nil {
@@ -231,6 +275,16 @@ func (r *Rule) Validate() error {
 		return errors.New("actionConfig must be valid JSON")
 	}
 
+	if r.RuleType == RuleTypeExpression {
+		var cfg ExpressionConfig
+		if err := json.Unmarshal(r.Config, &cfg); err != nil {
+			return errors.New("config must be a valid expression config")
+		}
+		if _, err := ParseExpressionConfig(cfg); err != nil {
+			return fmt.Errorf("invalid expression config: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -260,6 +260,26 @@ func TestRule_Validate(t *testing.T) {
 			expectError: true,
 			errorMsg:    "actionConfig must be valid JSON",
 		},
+		{
+			name: "minTrustLevel out of range",
+			rule: func() *Rule {
+				r := NewRule("Test", RuleTypeThreshold, json.RawMessage(`{}`), ActionTypeNotify, json.RawMessage(`{}`))
+				r.MinTrustLevel = 4
+				return r
+			},
+			expectError: true,
+			errorMsg:    "minTrustLevel must be between 0 and 3",
+		},
+		{
+			name: "invalid lowTrustAction",
+			rule: func() *Rule {
+				r := NewRule("Test", RuleTypeThreshold, json.RawMessage(`{}`), ActionTypeNotify, json.RawMessage(`{}`))
+				r.LowTrustAction = LowTrustAction("DROP")
+				return r
+			},
+			expectError: true,
+			errorMsg:    "invalid lowTrustAction",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,228 @@
+package trust
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignatureCheckStatus is the offline verification outcome of one signature
+type SignatureCheckStatus string
+
+const (
+	SignatureCheckVerified SignatureCheckStatus = "VERIFIED"
+	SignatureCheckFailed   SignatureCheckStatus = "FAILED"
+	// SignatureCheckUnverifiable means the batch hash checked out but the
+	// signature could not be checked: HMAC signatures need the shared
+	// secret, asymmetric ones the source's public key.
+	SignatureCheckUnverifiable SignatureCheckStatus = "UNVERIFIABLE"
+)
+
+// SignatureReport is the offline verification result of one batch signature
+type SignatureReport struct {
+	BatchID      uuid.UUID            `json:"batchId"`
+	KeyID        string               `json:"keyId"`
+	SignatureAlg string               `json:"signatureAlg"`
+	Status       SignatureCheckStatus `json:"status"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// BundleReport is the result of VerifyEvidenceBundle
+type BundleReport struct {
+	BundleID       uuid.UUID         `json:"bundleId"`
+	Valid          bool              `json:"valid"`
+	IntegrityValid bool              `json:"integrityValid"`
+	HashChainValid bool              `json:"hashChainValid"`
+	Signatures     []SignatureReport `json:"signatures,omitempty"`
+	Timestamp      *TimestampReport  `json:"timestamp,omitempty"`
+	Errors         []string          `json:"errors,omitempty"`
+}
+
+// TimestampStatus is whether a timestamp token's authority is trusted
+type TimestampStatus string
+
+const (
+	// TimestampVerified means the authority's certificate chains to the
+	// trusted roots
+	TimestampVerified TimestampStatus = "VERIFIED"
+	// TimestampUntrusted means the token's signature is valid but nothing
+	// vouches for its authority: no trusted roots were given or its
+	// certificate does not chain to them
+	TimestampUntrusted TimestampStatus = "UNTRUSTED"
+)
+
+// TimestampReport describes a bundle's RFC 3161 timestamp token whose
+// signature over the bundle hash is valid
+type TimestampReport struct {
+	GenTime      time.Time       `json:"genTime"`
+	SerialNumber string          `json:"serialNumber"`
+	Authority    string          `json:"authority"` // subject of the TSA certificate
+	Status       TimestampStatus `json:"status"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// VerifyEvidenceBundle verifies an exported evidence bundle without access
+// to the server. It checks the bundle hash, every hash chain entry and the
+// links between consecutive entries, that chained events have an entry,
+// that each signed batch hash is the root of its events (from the bundle's
+// chain entries or inclusion proofs), and each signature with keys, the
+// public keys the auditor trusts for the sources, and that a timestamp
+// token stamps the bundle hash by an authority chaining to roots, the CA
+// certificates of the time-stamping authorities the auditor trusts. With
+// nil roots the token's authority is reported as untrusted. Event hashes
+// are taken from the chain entries: stored payloads are normalised JSON and
+// cannot reproduce the hash computed at ingestion.
+func VerifyEvidenceBundle(bundle *EvidenceBundle, keys []*SourceKey, roots *x509.CertPool) *BundleReport {
+	report := &BundleReport{
+		BundleID:       bundle.BundleID,
+		IntegrityValid: bundle.VerifyIntegrity(roots),
+		HashChainValid: true,
+	}
+	if !bundle.hashMatches() {
+		report.Errors = append(report.Errors, "bundle hash does not match its content")
+	} else if len(bundle.TimestampToken) > 0 {
+		token, err := bundle.VerifyTimestamp(roots)
+		if err != nil {
+			report.Errors = append(report.Errors, "timestamp token: "+err.Error())
+		}
+		if token != nil {
+			report.Timestamp = &TimestampReport{
+				GenTime:      token.GenTime,
+				SerialNumber: token.SerialNumber.String(),
+				Authority:    token.Signer().Subject.String(),
+				Status:       TimestampVerified,
+			}
+			switch {
+			case err != nil:
+				report.Timestamp.Status = TimestampUntrusted
+				report.Timestamp.Error = err.Error()
+			case roots == nil:
+				report.Timestamp.Status = TimestampUntrusted
+				report.Timestamp.Error = "no trusted timestamp authorities given"
+			}
+		}
+	}
+
+	entries := make(map[uuid.UUID]*HashChainEntry, len(bundle.HashChain))
+	bySource := make(map[string][]*HashChainEntry)
+	for i := range bundle.HashChain {
+		entry := &bundle.HashChain[i]
+		entries[entry.EventID] = entry
+		bySource[entry.SourceID] = append(bySource[entry.SourceID], entry)
+		if !entry.Verify() {
+			report.HashChainValid = false
+			report.Errors = append(report.Errors, fmt.Sprintf("chain entry %s/%d has an invalid chain hash", entry.SourceID, entry.SequenceNum))
+		}
+	}
+	for sourceID, chain := range bySource {
+		sort.Slice(chain, func(i, j int) bool { return chain[i].SequenceNum < chain[j].SequenceNum })
+		for i := 1; i < len(chain); i++ {
+			if chain[i].SequenceNum == chain[i-1].SequenceNum+1 && chain[i].PrevHash != chain[i-1].ChainHash {
+				report.HashChainValid = false
+				report.Errors = append(report.Errors, fmt.Sprintf("chain entry %s/%d does not link to its predecessor", sourceID, chain[i].SequenceNum))
+			}
+		}
+	}
+	for _, event := range bundle.Events {
+		if event.TrustLevel >= TrustLevelT2 && entries[event.EventID] == nil {
+			report.HashChainValid = false
+			report.Errors = append(report.Errors, fmt.Sprintf("event %s claims %s but has no chain entry", event.EventID, event.TrustLevel))
+		}
+	}
+
+	keysByID := make(map[string]*SourceKey, len(keys))
+	for _, key := range keys {
+		keysByID[key.KeyID] = key
+	}
+	for i := range bundle.Signatures {
+		sigReport := verifyBundleSignature(bundle, &bundle.Signatures[i], entries, keysByID)
+		if sigReport.Status == SignatureCheckFailed {
+			report.Errors = append(report.Errors, fmt.Sprintf("batch %s: %s", sigReport.BatchID, sigReport.Error))
+		}
+		report.Signatures = append(report.Signatures, sigReport)
+	}
+
+	report.Valid = len(report.Errors) == 0
+	return report
+}
+
+func verifyBundleSignature(bundle *EvidenceBundle, sig *BatchSignature, entries map[uuid.UUID]*HashChainEntry, keys map[string]*SourceKey) SignatureReport {
+	r := SignatureReport{BatchID: sig.BatchID, KeyID: sig.KeyID, SignatureAlg: sig.SignatureAlg}
+	fail := func(err error) SignatureReport {
+		r.Status = SignatureCheckFailed
+		r.Error = err.Error()
+		return r
+	}
+
+	if err := verifyBundleBatchHash(bundle, sig, entries); err != nil {
+		return fail(err)
+	}
+
+	if sig.SignatureAlg == "" || sig.SignatureAlg == SignatureAlgHMACSHA256 {
+		r.Status = SignatureCheckUnverifiable
+		r.Error = "HMAC signatures can only be verified with the shared secret"
+		return r
+	}
+	key := keys[sig.KeyID]
+	if key == nil {
+		r.Status = SignatureCheckUnverifiable
+		r.Error = "public key " + sig.KeyID + " not provided"
+		return r
+	}
+	if err := sig.VerifyWith(key); err != nil {
+		return fail(err)
+	}
+	r.Status = SignatureCheckVerified
+	return r
+}
+
+// verifyBundleBatchHash checks that the batch events the bundle holds are
+// in the signing source's chain, then recomputes the batch hash when the
+// bundle holds the chain entries of every batch event, and otherwise checks
+// the inclusion proof of every batch event the bundle holds
+func verifyBundleBatchHash(bundle *EvidenceBundle, sig *BatchSignature, entries map[uuid.UUID]*HashChainEntry) error {
+	hashes := make([]string, 0, len(sig.EventIDs))
+	batchEntries := make([]*HashChainEntry, 0, len(sig.EventIDs))
+	for _, eventID := range sig.EventIDs {
+		if entry := entries[eventID]; entry != nil {
+			hashes = append(hashes, entry.EventHash)
+			batchEntries = append(batchEntries, entry)
+		}
+	}
+	if err := sig.CheckEventSources(batchEntries); err != nil {
+		return err
+	}
+	if len(hashes) == len(sig.EventIDs) {
+		return sig.CheckBatchHash(hashes)
+	}
+
+	proofs := make(map[uuid.UUID]*InclusionProof)
+	for i := range bundle.InclusionProofs {
+		if p := &bundle.InclusionProofs[i]; p.BatchID == sig.BatchID {
+			proofs[p.EventID] = p
+		}
+	}
+	for _, eventID := range sig.EventIDs {
+		entry := entries[eventID]
+		if entry == nil {
+			continue
+		}
+		p := proofs[eventID]
+		if p == nil {
+			return fmt.Errorf("event %s has no inclusion proof", eventID)
+		}
+		switch {
+		case p.EventHash != entry.EventHash:
+			return fmt.Errorf("inclusion proof of event %s is for another event hash", eventID)
+		case p.BatchHash != sig.BatchHash || p.HashScheme != sig.Scheme():
+			return fmt.Errorf("inclusion proof of event %s is for another batch hash", eventID)
+		}
+		if err := p.VerifyRoot(); err != nil {
+			return fmt.Errorf("event %s: %w", eventID, err)
+		}
+	}
+	return nil
+}
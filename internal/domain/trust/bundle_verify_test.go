@@ -0,0 +1,241 @@
+package trust
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
+)
+
+// signedBatch is a source chain of four events signed as one Merkle batch
+type signedBatch struct {
+	key     *SourceKey
+	events  []EventEvidence
+	entries []HashChainEntry
+	sig     *BatchSignature
+	hashes  []string
+}
+
+func newSignedBatch(t *testing.T) *signedBatch {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signedAt := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	key, err := NewSourceKey("key-ed", "gateway-001", SignatureAlgEd25519, pub, signedAt.Add(-time.Hour), nil)
+	require.NoError(t, err)
+
+	b := &signedBatch{key: key}
+	var latest *HashChainEntry
+	var eventIDs []uuid.UUID
+	for i := 0; i < 4; i++ {
+		event := EventEvidence{
+			EventID:    uuid.New(),
+			SourceID:   "gateway-001",
+			SourceType: "GW",
+			EventType:  "SENSOR_READING",
+			Payload:    json.RawMessage(`{"value":1}`),
+			TsServer:   signedAt,
+			TrustLevel: TrustLevelT3,
+		}
+		entry := ExtendChain(latest, "gateway-001", []ChainLink{{Event: &event, EventHash: ComputeChainHash(event.EventID.String(), "")}})[0]
+		latest = entry
+		b.events = append(b.events, event)
+		b.entries = append(b.entries, *entry)
+		b.hashes = append(b.hashes, entry.EventHash)
+		eventIDs = append(eventIDs, event.EventID)
+	}
+	root := ComputeMerkleRoot(b.hashes)
+	b.sig = NewBatchSignature("gateway-001", eventIDs, root, hex.EncodeToString(ed25519.Sign(priv, []byte(root))), SignatureAlgEd25519, "key-ed", signedAt)
+	b.sig.MarkVerified()
+	return b
+}
+
+// eventBundle exports the bundle of event i, which holds only that event's
+// chain entry and therefore an inclusion proof
+func (b *signedBatch) eventBundle(t *testing.T, i int) *EvidenceBundle {
+	t.Helper()
+	bundle := NewEvidenceBundle("EVENT", b.events[i].EventID)
+	bundle.Events = []EventEvidence{b.events[i]}
+	bundle.HashChain = []HashChainEntry{b.entries[i]}
+	bundle.Signatures = []BatchSignature{*b.sig}
+	proof, err := NewInclusionProof(b.sig, b.hashes, i)
+	require.NoError(t, err)
+	bundle.InclusionProofs = []InclusionProof{*proof}
+	require.NoError(t, bundle.Finalize())
+	return exportAndImport(t, bundle)
+}
+
+func exportAndImport(t *testing.T, bundle *EvidenceBundle) *EvidenceBundle {
+	t.Helper()
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var imported EvidenceBundle
+	require.NoError(t, json.Unmarshal(data, &imported))
+	return &imported
+}
+
+func TestVerifyEvidenceBundle(t *testing.T) {
+	b := newSignedBatch(t)
+
+	t.Run("verifies event bundle with inclusion proof", func(t *testing.T) {
+		report := VerifyEvidenceBundle(b.eventBundle(t, 2), []*SourceKey{b.key}, nil)
+		assert.True(t, report.Valid, report.Errors)
+		assert.True(t, report.IntegrityValid)
+		assert.True(t, report.HashChainValid)
+		require.Len(t, report.Signatures, 1)
+		assert.Equal(t, SignatureCheckVerified, report.Signatures[0].Status)
+	})
+
+	t.Run("verifies batch bundle from its chain entries", func(t *testing.T) {
+		bundle := NewEvidenceBundle("BATCH", b.sig.BatchID)
+		bundle.Events = b.events
+		bundle.HashChain = b.entries
+		bundle.Signatures = []BatchSignature{*b.sig}
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(exportAndImport(t, bundle), []*SourceKey{b.key}, nil)
+		assert.True(t, report.Valid, report.Errors)
+		assert.Equal(t, SignatureCheckVerified, report.Signatures[0].Status)
+	})
+
+	t.Run("reports missing public key as unverifiable", func(t *testing.T) {
+		report := VerifyEvidenceBundle(b.eventBundle(t, 0), nil, nil)
+		assert.True(t, report.Valid)
+		assert.Equal(t, SignatureCheckUnverifiable, report.Signatures[0].Status)
+	})
+
+	t.Run("rejects key revoked before signing", func(t *testing.T) {
+		revoked := *b.key
+		require.NoError(t, revoked.Revoke(b.sig.SignedAt.Add(-time.Minute), "compromised"))
+		report := VerifyEvidenceBundle(b.eventBundle(t, 0), []*SourceKey{&revoked}, nil)
+		assert.False(t, report.Valid)
+		assert.Equal(t, SignatureCheckFailed, report.Signatures[0].Status)
+		assert.Contains(t, report.Signatures[0].Error, "revoked")
+	})
+
+	t.Run("detects edited bundle", func(t *testing.T) {
+		bundle := b.eventBundle(t, 1)
+		bundle.Events[0].Payload = json.RawMessage(`{"value":2}`)
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.False(t, report.Valid)
+		assert.False(t, report.IntegrityValid)
+	})
+
+	t.Run("detects event swapped into batch", func(t *testing.T) {
+		bundle := b.eventBundle(t, 1)
+		bundle.HashChain[0] = *NewHashChainEntry(bundle.HashChain[0].EventID, "gateway-001", 2, "forged", b.entries[0].ChainHash)
+		bundle.InclusionProofs[0].EventHash = "forged"
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.False(t, report.Valid)
+		assert.Equal(t, SignatureCheckFailed, report.Signatures[0].Status)
+	})
+
+	t.Run("detects missing inclusion proof", func(t *testing.T) {
+		bundle := b.eventBundle(t, 3)
+		bundle.InclusionProofs = nil
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.False(t, report.Valid)
+		assert.Contains(t, report.Signatures[0].Error, "no inclusion proof")
+	})
+
+	t.Run("detects broken chain link", func(t *testing.T) {
+		bundle := NewEvidenceBundle("BATCH", b.sig.BatchID)
+		bundle.Events = b.events
+		bundle.HashChain = append([]HashChainEntry(nil), b.entries...)
+		bundle.HashChain[2] = *NewHashChainEntry(b.entries[2].EventID, "gateway-001", 3, b.entries[2].EventHash, "wrongPrevHash")
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.False(t, report.Valid)
+		assert.False(t, report.HashChainValid)
+	})
+
+	t.Run("rejects a batch of another source's events", func(t *testing.T) {
+		bundle := b.eventBundle(t, 0)
+		bundle.Signatures[0].SourceID = "gateway-002"
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.False(t, report.Valid)
+		assert.Equal(t, SignatureCheckFailed, report.Signatures[0].Status)
+		assert.Contains(t, report.Signatures[0].Error, ErrBatchSourceMismatch.Error())
+	})
+	t.Run("reports HMAC signature as unverifiable", func(t *testing.T) {
+		sig := NewBatchSignature("gateway-001", b.sig.EventIDs, ComputeBatchHash(b.hashes), CreateHMAC(ComputeBatchHash(b.hashes), []byte("secret")), SignatureAlgHMACSHA256, "key-001", b.sig.SignedAt)
+		sig.HashScheme = BatchHashSchemeFlat
+		bundle := NewEvidenceBundle("BATCH", sig.BatchID)
+		bundle.Events = b.events
+		bundle.HashChain = b.entries
+		bundle.Signatures = []BatchSignature{*sig}
+		require.NoError(t, bundle.Finalize())
+
+		report := VerifyEvidenceBundle(bundle, nil, nil)
+		assert.True(t, report.Valid, report.Errors)
+		assert.Equal(t, SignatureCheckUnverifiable, report.Signatures[0].Status)
+	})
+	t.Run("verifies timestamp token over the bundle hash", func(t *testing.T) {
+		tsa, err := tsptest.NewAuthority()
+		require.NoError(t, err)
+		stamp := func(bundle *EvidenceBundle) []byte {
+			digest, err := hex.DecodeString(bundle.BundleHash)
+			require.NoError(t, err)
+			token, err := tsa.Token(digest, nil)
+			require.NoError(t, err)
+			return token
+		}
+
+		bundle := b.eventBundle(t, 0)
+		bundle.TimestampToken = stamp(bundle)
+		bundle = exportAndImport(t, bundle)
+		report := VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, tsa.Roots())
+		assert.True(t, report.Valid, report.Errors)
+		require.NotNil(t, report.Timestamp)
+		assert.Equal(t, "1", report.Timestamp.SerialNumber)
+		assert.Equal(t, "CN=Test TSA", report.Timestamp.Authority)
+		assert.Equal(t, TimestampVerified, report.Timestamp.Status)
+		_, err = bundle.VerifyTimestamp(tsa.Roots())
+		assert.NoError(t, err)
+
+		// Without trusted roots nothing vouches for the authority
+		report = VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, nil)
+		assert.True(t, report.Valid, report.Errors)
+		require.NotNil(t, report.Timestamp)
+		assert.Equal(t, TimestampUntrusted, report.Timestamp.Status)
+
+		untrusted, err := tsptest.NewAuthority()
+		require.NoError(t, err)
+		report = VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, untrusted.Roots())
+		assert.False(t, report.Valid)
+		assert.False(t, report.IntegrityValid)
+		require.NotNil(t, report.Timestamp)
+		assert.Equal(t, TimestampUntrusted, report.Timestamp.Status)
+		assert.Contains(t, report.Errors[0], "timestamp authority is not trusted")
+		token, err := bundle.VerifyTimestamp(untrusted.Roots())
+		assert.ErrorIs(t, err, tsp.ErrUntrustedAuthority)
+		assert.NotNil(t, token)
+
+		other := b.eventBundle(t, 1)
+		bundle.TimestampToken = stamp(other)
+		report = VerifyEvidenceBundle(bundle, []*SourceKey{b.key}, tsa.Roots())
+		assert.False(t, report.Valid)
+		assert.False(t, report.IntegrityValid)
+		assert.Nil(t, report.Timestamp)
+		assert.Contains(t, report.Errors[0], "timestamp token")
+
+		_, err = other.VerifyTimestamp(nil)
+		assert.ErrorIs(t, err, ErrNotTimestamped)
+	})
+}
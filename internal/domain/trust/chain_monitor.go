@@ -0,0 +1,157 @@
+package trust
+
+import (
+	"fmt"
+	"time"
+)
+
+// ChainBreakKind classifies a hash chain break
+type ChainBreakKind string
+
+const (
+	// ChainBreakHashMismatch is an entry whose chain hash does not cover its
+	// event hash and previous hash
+	ChainBreakHashMismatch ChainBreakKind = "HASH_MISMATCH"
+	// ChainBreakLinkMismatch is an entry whose previous hash is not the chain
+	// hash of the entry before it
+	ChainBreakLinkMismatch ChainBreakKind = "LINK_MISMATCH"
+	// ChainBreakSequenceGap is a missing sequence number
+	ChainBreakSequenceGap ChainBreakKind = "SEQUENCE_GAP"
+	// ChainBreakTruncated is a chain whose head fell below the verified
+	// sequence: its tail was deleted
+	ChainBreakTruncated ChainBreakKind = "TRUNCATED"
+)
+
+// ChainHead is the latest entry of a source's hash chain
+type ChainHead struct {
+	SourceID    string
+	SequenceNum int64
+	LastEntryAt time.Time
+}
+
+// ChainVerificationState is how far the chain verifier has checked a
+// source's hash chain. LastChainHash is the chain hash at VerifiedThrough,
+// which the next entry must link to.
+type ChainVerificationState struct {
+	SourceID        string    `json:"sourceId"`
+	VerifiedThrough int64     `json:"verifiedThrough"`
+	LastChainHash   string    `json:"lastChainHash"`
+	LastVerifiedAt  time.Time `json:"lastVerifiedAt"`
+}
+
+// Advance checks entries, in sequence order and following the verified
+// prefix, and moves the state past them. Breaks are recorded and checking
+// continues from the stored hashes, so every break is reported once.
+func (s *ChainVerificationState) Advance(entries []*HashChainEntry, at time.Time) []ChainBreak {
+	var breaks []ChainBreak
+	for _, entry := range entries {
+		expected := s.VerifiedThrough + 1
+		switch {
+		case entry.SequenceNum > expected:
+			breaks = append(breaks, ChainBreak{
+				SourceID:     s.SourceID,
+				BreakAt:      expected,
+				Kind:         ChainBreakSequenceGap,
+				ExpectedHash: s.LastChainHash,
+				ActualHash:   entry.PrevHash,
+				DetectedAt:   at,
+			})
+		case entry.PrevHash != s.LastChainHash:
+			breaks = append(breaks, ChainBreak{
+				SourceID:     s.SourceID,
+				BreakAt:      entry.SequenceNum,
+				Kind:         ChainBreakLinkMismatch,
+				ExpectedHash: s.LastChainHash,
+				ActualHash:   entry.PrevHash,
+				DetectedAt:   at,
+			})
+		}
+		if !entry.Verify() {
+			breaks = append(breaks, ChainBreak{
+				SourceID:     s.SourceID,
+				BreakAt:      entry.SequenceNum,
+				Kind:         ChainBreakHashMismatch,
+				ExpectedHash: ComputeChainHash(entry.EventHash, entry.PrevHash),
+				ActualHash:   entry.ChainHash,
+				DetectedAt:   at,
+			})
+		}
+		s.VerifiedThrough = entry.SequenceNum
+		s.LastChainHash = entry.ChainHash
+	}
+	s.LastVerifiedAt = at
+	return breaks
+}
+
+// Truncation returns the break of a chain whose head, 0 when the source
+// has no entries left, is below the verified sequence, or nil
+func (s *ChainVerificationState) Truncation(head int64, at time.Time) *ChainBreak {
+	if head >= s.VerifiedThrough {
+		return nil
+	}
+	return &ChainBreak{
+		SourceID:     s.SourceID,
+		BreakAt:      head + 1,
+		Kind:         ChainBreakTruncated,
+		ExpectedHash: s.LastChainHash,
+		DetectedAt:   at,
+	}
+}
+
+// Describe summarises the break for operators
+func (b *ChainBreak) Describe() string {
+	switch b.Kind {
+	case ChainBreakSequenceGap:
+		return fmt.Sprintf("sequence %d of source %s is missing", b.BreakAt, b.SourceID)
+	case ChainBreakLinkMismatch:
+		return fmt.Sprintf("entry %d of source %s does not link to the entry before it", b.BreakAt, b.SourceID)
+	case ChainBreakTruncated:
+		return fmt.Sprintf("entries of source %s from sequence %d on were deleted", b.SourceID, b.BreakAt)
+	default:
+		return fmt.Sprintf("entry %d of source %s does not match its chain hash", b.BreakAt, b.SourceID)
+	}
+}
+
+// ChainCoverage is how much of a source's hash chain the chain verifier
+// has checked
+type ChainCoverage struct {
+	SourceID          string     `json:"sourceId"`
+	HeadSequence      int64      `json:"headSequence"`
+	VerifiedThrough   int64      `json:"verifiedThrough"`
+	UnverifiedEntries int64      `json:"unverifiedEntries"`
+	MissingEntries    int64      `json:"missingEntries,omitempty"` // verified, then deleted
+	Coverage          float64    `json:"coverage"`                 // verified fraction of the chain
+	LastEntryAt       time.Time  `json:"lastEntryAt"`
+	LastVerifiedAt    *time.Time `json:"lastVerifiedAt,omitempty"`
+	BreakCount        int        `json:"breakCount"`
+}
+
+// NewChainCoverage computes the coverage of head from the verifier's state,
+// which is nil when the source has not been checked yet. A head below the
+// verified sequence, SequenceNum 0 when the source has no entries left,
+// counts the deleted entries as missing from the verified chain.
+func NewChainCoverage(head ChainHead, state *ChainVerificationState, breakCount int) ChainCoverage {
+	c := ChainCoverage{
+		SourceID:     head.SourceID,
+		HeadSequence: head.SequenceNum,
+		LastEntryAt:  head.LastEntryAt,
+		BreakCount:   breakCount,
+	}
+	if state != nil {
+		c.VerifiedThrough = state.VerifiedThrough
+		at := state.LastVerifiedAt
+		c.LastVerifiedAt = &at
+	}
+	if c.VerifiedThrough > c.HeadSequence {
+		c.MissingEntries = c.VerifiedThrough - c.HeadSequence
+		c.Coverage = float64(c.HeadSequence) / float64(c.VerifiedThrough)
+		return c
+	}
+	c.UnverifiedEntries = c.HeadSequence - c.VerifiedThrough
+	if c.HeadSequence > 0 {
+		c.Coverage = float64(c.VerifiedThrough) / float64(c.HeadSequence)
+	} else {
+		c.Coverage = 1
+	}
+	return c
+}
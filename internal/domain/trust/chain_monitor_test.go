@@ -0,0 +1,98 @@
+package trust
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildChain(n int) []*HashChainEntry {
+	links := make([]ChainLink, n)
+	for i := range links {
+		links[i] = ChainLink{Event: &EventEvidence{EventID: uuid.New()}, EventHash: ComputeChainHash(uuid.NewString(), "")}
+	}
+	return ExtendChain(nil, "gateway-001", links)
+}
+
+func TestChainVerificationState_Advance(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("verifies an intact chain incrementally", func(t *testing.T) {
+		chain := buildChain(5)
+		state := &ChainVerificationState{SourceID: "gateway-001"}
+
+		assert.Empty(t, state.Advance(chain[:2], at))
+		assert.Equal(t, int64(2), state.VerifiedThrough)
+		assert.Equal(t, chain[1].ChainHash, state.LastChainHash)
+		assert.Empty(t, state.Advance(chain[2:], at))
+		assert.Equal(t, int64(5), state.VerifiedThrough)
+		assert.Equal(t, at, state.LastVerifiedAt)
+	})
+
+	t.Run("reports each kind of break once", func(t *testing.T) {
+		chain := buildChain(6)
+		chain[1].EventHash = "tampered"
+		chain[3].PrevHash = "forged"
+		chain[3].ChainHash = ComputeChainHash(chain[3].EventHash, chain[3].PrevHash)
+		state := &ChainVerificationState{SourceID: "gateway-001"}
+
+		breaks := state.Advance([]*HashChainEntry{chain[0], chain[1], chain[2], chain[3], chain[5]}, at)
+		require.Len(t, breaks, 3)
+		assert.Equal(t, ChainBreakHashMismatch, breaks[0].Kind)
+		assert.Equal(t, int64(2), breaks[0].BreakAt)
+		assert.Equal(t, ChainBreakLinkMismatch, breaks[1].Kind)
+		assert.Equal(t, int64(4), breaks[1].BreakAt)
+		assert.Equal(t, chain[2].ChainHash, breaks[1].ExpectedHash)
+		assert.Equal(t, ChainBreakSequenceGap, breaks[2].Kind)
+		assert.Equal(t, int64(5), breaks[2].BreakAt)
+		assert.Equal(t, "sequence 5 of source gateway-001 is missing", breaks[2].Describe())
+		assert.Equal(t, int64(6), state.VerifiedThrough)
+	})
+
+	t.Run("reports a head below the verified sequence", func(t *testing.T) {
+		chain := buildChain(5)
+		state := &ChainVerificationState{SourceID: "gateway-001"}
+		state.Advance(chain, at)
+
+		assert.Nil(t, state.Truncation(5, at))
+		b := state.Truncation(3, at)
+		require.NotNil(t, b)
+		assert.Equal(t, ChainBreakTruncated, b.Kind)
+		assert.Equal(t, int64(4), b.BreakAt)
+		assert.Equal(t, chain[4].ChainHash, b.ExpectedHash)
+		assert.Equal(t, "entries of source gateway-001 from sequence 4 on were deleted", b.Describe())
+		assert.Equal(t, int64(1), state.Truncation(0, at).BreakAt)
+	})
+}
+
+func TestNewChainCoverage(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	head := ChainHead{SourceID: "gateway-001", SequenceNum: 8, LastEntryAt: at}
+
+	c := NewChainCoverage(head, nil, 0)
+	assert.Equal(t, int64(8), c.UnverifiedEntries)
+	assert.Zero(t, c.Coverage)
+	assert.Nil(t, c.LastVerifiedAt)
+
+	c = NewChainCoverage(head, &ChainVerificationState{SourceID: "gateway-001", VerifiedThrough: 6, LastVerifiedAt: at}, 2)
+	assert.Equal(t, int64(2), c.UnverifiedEntries)
+	assert.Equal(t, 0.75, c.Coverage)
+	assert.Equal(t, 2, c.BreakCount)
+	require.NotNil(t, c.LastVerifiedAt)
+
+	c = NewChainCoverage(ChainHead{SourceID: "gateway-002"}, nil, 0)
+	assert.Equal(t, 1.0, c.Coverage, "an empty chain is fully covered")
+
+	c = NewChainCoverage(ChainHead{SourceID: "gateway-001", SequenceNum: 6}, &ChainVerificationState{SourceID: "gateway-001", VerifiedThrough: 8}, 1)
+	assert.Equal(t, int64(8), c.VerifiedThrough)
+	assert.Equal(t, int64(2), c.MissingEntries)
+	assert.Zero(t, c.UnverifiedEntries)
+	assert.Equal(t, 0.75, c.Coverage, "a truncated chain is not fully covered")
+
+	c = NewChainCoverage(ChainHead{SourceID: "gateway-001"}, &ChainVerificationState{SourceID: "gateway-001", VerifiedThrough: 8}, 1)
+	assert.Equal(t, int64(8), c.MissingEntries)
+	assert.Zero(t, c.Coverage, "an emptied chain is not covered")
+}
@@ -0,0 +1,209 @@
+package trust
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Signature algorithms of batch signatures. HMAC-SHA256 uses a secret shared
+// with the server; the asymmetric algorithms use public keys from the key
+// registry, so the server cannot produce T3 evidence itself.
+const (
+	SignatureAlgHMACSHA256 = "HMAC-SHA256"
+	SignatureAlgEd25519    = "Ed25519"
+	SignatureAlgECDSAP256  = "ECDSA-P256-SHA256"
+)
+
+var (
+	ErrUnsupportedSignatureAlg = errors.New("unsupported signature algorithm")
+	ErrKeyExists               = errors.New("signing key already exists")
+	ErrKeyNotActive            = errors.New("signing key not active")
+	ErrKeyRevoked              = errors.New("signing key revoked")
+)
+
+// IsSupportedSignatureAlg reports whether alg can be verified
+func IsSupportedSignatureAlg(alg string) bool {
+	switch alg {
+	case SignatureAlgHMACSHA256, SignatureAlgEd25519, SignatureAlgECDSAP256:
+		return true
+	}
+	return false
+}
+
+// SourceKey is a public key a source signs batches with. It verifies
+// signatures made from NotBefore until NotAfter, and none made at or after
+// RevokedAt, which may lie in the past when a key was compromised earlier.
+type SourceKey struct {
+	ID               int64      `json:"id"`
+	KeyID            string     `json:"keyId"`
+	SourceID         string     `json:"sourceId"`
+	Algorithm        string     `json:"algorithm"`
+	PublicKey        []byte     `json:"publicKey"` // PKIX DER
+	NotBefore        time.Time  `json:"notBefore"`
+	NotAfter         *time.Time `json:"notAfter,omitempty"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	RevocationReason *string    `json:"revocationReason,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+}
+
+// NewSourceKey creates a registry key. publicKey is PKIX DER, or the raw 32
+// bytes of an Ed25519 key.
+func NewSourceKey(keyID, sourceID, algorithm string, publicKey []byte, notBefore time.Time, notAfter *time.Time) (*SourceKey, error) {
+	k := &SourceKey{
+		KeyID:     keyID,
+		SourceID:  sourceID,
+		Algorithm: algorithm,
+		NotBefore: notBefore.UTC(),
+		NotAfter:  notAfter,
+		CreatedAt: time.Now().UTC(),
+	}
+	if algorithm == SignatureAlgEd25519 && len(publicKey) == ed25519.PublicKeySize {
+		der, err := x509.MarshalPKIXPublicKey(ed25519.PublicKey(publicKey))
+		if err != nil {
+			return nil, err
+		}
+		publicKey = der
+	}
+	k.PublicKey = publicKey
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Validate checks the key's fields and that its public key matches its
+// algorithm
+func (k *SourceKey) Validate() error {
+	if k.KeyID == "" {
+		return errors.New("keyId is required")
+	}
+	if k.SourceID == "" {
+		return errors.New("sourceId is required")
+	}
+	if k.NotBefore.IsZero() {
+		return errors.New("notBefore is required")
+	}
+	if k.NotAfter != nil && !k.NotAfter.After(k.NotBefore) {
+		return errors.New("notAfter must be after notBefore")
+	}
+	if _, err := ParsePublicKey(k.Algorithm, k.PublicKey); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidAt returns ErrKeyNotActive or ErrKeyRevoked when the key cannot
+// verify a signature made at t
+func (k *SourceKey) ValidAt(t time.Time) error {
+	if k.RevokedAt != nil && !t.Before(*k.RevokedAt) {
+		return fmt.Errorf("%w as of %s", ErrKeyRevoked, k.RevokedAt.UTC().Format(time.RFC3339))
+	}
+	if t.Before(k.NotBefore) {
+		return fmt.Errorf("%w before %s", ErrKeyNotActive, k.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if k.NotAfter != nil && !t.Before(*k.NotAfter) {
+		return fmt.Errorf("%w after %s", ErrKeyNotActive, k.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Revoke revokes the key as of at. A key can only be revoked again with an
+// earlier time.
+func (k *SourceKey) Revoke(at time.Time, reason string) error {
+	at = at.UTC()
+	if k.RevokedAt != nil && !at.Before(*k.RevokedAt) {
+		return fmt.Errorf("%w as of %s", ErrKeyRevoked, k.RevokedAt.Format(time.RFC3339))
+	}
+	k.RevokedAt = &at
+	k.RevocationReason = &reason
+	return nil
+}
+
+// Verify checks a hex-encoded signature of batchHash made with the key
+func (k *SourceKey) Verify(batchHash, signature string) bool {
+	pub, err := ParsePublicKey(k.Algorithm, k.PublicKey)
+	if err != nil {
+		return false
+	}
+	return VerifySignature(k.Algorithm, pub, batchHash, signature)
+}
+
+// VerifyWith checks the signature with key, which must be the source's
+// key of the signature's algorithm, valid when the batch was signed
+func (b *BatchSignature) VerifyWith(key *SourceKey) error {
+	if key == nil {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, b.KeyID)
+	}
+	switch {
+	case key.KeyID != b.KeyID:
+		return fmt.Errorf("batch is signed with key %s, not %s", b.KeyID, key.KeyID)
+	case key.SourceID != b.SourceID:
+		return fmt.Errorf("key %s belongs to source %s", key.KeyID, key.SourceID)
+	case key.Algorithm != b.SignatureAlg:
+		return fmt.Errorf("key %s is for %s, batch is signed with %s", key.KeyID, key.Algorithm, b.SignatureAlg)
+	}
+	if err := key.ValidAt(b.SignedAt); err != nil {
+		return err
+	}
+	if !key.Verify(b.BatchHash, b.Signature) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// ParsePublicKey parses a PKIX DER public key of an asymmetric algorithm
+func ParsePublicKey(algorithm string, der []byte) (crypto.PublicKey, error) {
+	if len(der) == 0 {
+		return nil, errors.New("publicKey is required")
+	}
+	switch algorithm {
+	case SignatureAlgEd25519, SignatureAlgECDSAP256:
+	case SignatureAlgHMACSHA256:
+		return nil, fmt.Errorf("%w: %s keys are shared secrets, not public keys", ErrUnsupportedSignatureAlg, algorithm)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedSignatureAlg, algorithm)
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		if algorithm == SignatureAlgEd25519 {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if algorithm == SignatureAlgECDSAP256 && key.Curve == elliptic.P256() {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("public key does not match algorithm %s", algorithm)
+}
+
+// VerifySignature checks a hex-encoded signature of batchHash. Ed25519 signs
+// the batch hash string; ECDSA P-256 signs its SHA-256 digest with an ASN.1
+// DER signature.
+func VerifySignature(algorithm string, publicKey crypto.PublicKey, batchHash, signature string) bool {
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	switch algorithm {
+	case SignatureAlgEd25519:
+		key, ok := publicKey.(ed25519.PublicKey)
+		return ok && ed25519.Verify(key, []byte(batchHash), sig)
+	case SignatureAlgECDSAP256:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		digest := sha256.Sum256([]byte(batchHash))
+		return ok && ecdsa.VerifyASN1(key, digest[:], sig)
+	}
+	return false
+}
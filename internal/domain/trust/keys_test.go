@@ -0,0 +1,111 @@
+package trust
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceKey_VerifyEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	batchHash := ComputeBatchHash([]string{"eventHash1", "eventHash2"})
+
+	// Raw keys are stored as PKIX DER.
+	key, err := NewSourceKey("key-ed", "gateway-001", SignatureAlgEd25519, pub, time.Now(), nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	assert.Equal(t, der, key.PublicKey)
+
+	signature := hex.EncodeToString(ed25519.Sign(priv, []byte(batchHash)))
+	assert.True(t, key.Verify(batchHash, signature))
+	assert.False(t, key.Verify("otherBatchHash", signature))
+	assert.False(t, key.Verify(batchHash, "not-hex"))
+}
+
+func TestSourceKey_VerifyECDSAP256(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	require.NoError(t, err)
+	batchHash := ComputeBatchHash([]string{"eventHash1"})
+
+	key, err := NewSourceKey("key-ec", "gateway-001", SignatureAlgECDSAP256, der, time.Now(), nil)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte(batchHash))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	require.NoError(t, err)
+	assert.True(t, key.Verify(batchHash, hex.EncodeToString(sig)))
+	assert.False(t, key.Verify("otherBatchHash", hex.EncodeToString(sig)))
+}
+
+func TestNewSourceKey_Validation(t *testing.T) {
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p384DER, err := x509.MarshalPKIXPublicKey(&p384.PublicKey)
+	require.NoError(t, err)
+	now := time.Now()
+	before := now.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		keyID     string
+		algorithm string
+		publicKey []byte
+		notAfter  *time.Time
+		wantErr   error
+	}{
+		{name: "missing key ID", algorithm: SignatureAlgEd25519, publicKey: edPub},
+		{name: "HMAC is not a public key algorithm", keyID: "k", algorithm: SignatureAlgHMACSHA256, publicKey: []byte("secret"), wantErr: ErrUnsupportedSignatureAlg},
+		{name: "unknown algorithm", keyID: "k", algorithm: "RSA", publicKey: edPub, wantErr: ErrUnsupportedSignatureAlg},
+		{name: "key of another curve", keyID: "k", algorithm: SignatureAlgECDSAP256, publicKey: p384DER},
+		{name: "garbage key", keyID: "k", algorithm: SignatureAlgECDSAP256, publicKey: []byte("garbage")},
+		{name: "window ends before it starts", keyID: "k", algorithm: SignatureAlgEd25519, publicKey: edPub, notAfter: &before},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSourceKey(tt.keyID, "gateway-001", tt.algorithm, tt.publicKey, now, tt.notAfter)
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSourceKey_ValidAt(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(30 * 24 * time.Hour)
+	key, err := NewSourceKey("key-ed", "gateway-001", SignatureAlgEd25519, pub, start, &end)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, key.ValidAt(start.Add(-time.Second)), ErrKeyNotActive)
+	assert.NoError(t, key.ValidAt(start))
+	assert.ErrorIs(t, key.ValidAt(end), ErrKeyNotActive)
+
+	revokedAt := start.Add(10 * 24 * time.Hour)
+	require.NoError(t, key.Revoke(revokedAt, "compromised"))
+	assert.NoError(t, key.ValidAt(revokedAt.Add(-time.Second)), "signatures made before revocation stay valid")
+	assert.ErrorIs(t, key.ValidAt(revokedAt), ErrKeyRevoked)
+
+	// Revocation can only move earlier.
+	assert.ErrorIs(t, key.Revoke(revokedAt.Add(time.Hour), "later"), ErrKeyRevoked)
+	require.NoError(t, key.Revoke(start.Add(time.Hour), "compromised earlier"))
+	assert.ErrorIs(t, key.ValidAt(revokedAt.Add(-time.Second)), ErrKeyRevoked)
+	assert.Equal(t, "compromised earlier", *key.RevocationReason)
+}
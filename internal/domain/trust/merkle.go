@@ -0,0 +1,285 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Batch hash schemes. A Merkle root lets a single event be proven part of a
+// signed batch with a logarithmic inclusion path; the legacy flat hash
+// needs every event hash of the batch.
+const (
+	BatchHashSchemeFlat   = "FLAT_SHA256"
+	BatchHashSchemeMerkle = "MERKLE_SHA256"
+)
+
+// Domain separation prefixes of Merkle leaves and nodes (RFC 6962), so an
+// inner node can never be passed off as a leaf
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+var (
+	ErrUnsupportedBatchHashScheme = errors.New("unsupported batch hash scheme")
+	ErrBatchHashMismatch          = errors.New("batch hash does not match the batch events")
+	ErrBatchSourceMismatch        = errors.New("batch event belongs to another source")
+)
+
+// IsSupportedBatchHashScheme reports whether scheme can be verified
+func IsSupportedBatchHashScheme(scheme string) bool {
+	return scheme == BatchHashSchemeFlat || scheme == BatchHashSchemeMerkle
+}
+
+// ComputeBatchRoot computes the batch hash of eventHashes, in batch order,
+// under scheme
+func ComputeBatchRoot(scheme string, eventHashes []string) (string, error) {
+	switch scheme {
+	case BatchHashSchemeFlat:
+		return ComputeBatchHash(eventHashes), nil
+	case BatchHashSchemeMerkle:
+		return ComputeMerkleRoot(eventHashes), nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedBatchHashScheme, scheme)
+}
+
+// ComputeMerkleRoot computes the RFC 6962 Merkle tree hash of eventHashes:
+// leaves are SHA-256(0x00 || eventHash) and nodes SHA-256(0x01 || left ||
+// right), splitting at the largest power of two below the leaf count.
+func ComputeMerkleRoot(eventHashes []string) string {
+	if len(eventHashes) == 0 {
+		empty := sha256.Sum256(nil)
+		return hex.EncodeToString(empty[:])
+	}
+	return hex.EncodeToString(merkleTreeHash(eventHashes))
+}
+
+func merkleTreeHash(eventHashes []string) []byte {
+	if len(eventHashes) == 1 {
+		return merkleLeaf(eventHashes[0])
+	}
+	k := merkleSplit(len(eventHashes))
+	return merkleNode(merkleTreeHash(eventHashes[:k]), merkleTreeHash(eventHashes[k:]))
+}
+
+func merkleLeaf(eventHash string) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write([]byte(eventHash))
+	return h.Sum(nil)
+}
+
+func merkleNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// merkleSplit returns the largest power of two smaller than n
+func merkleSplit(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// MerkleProofStep is a sibling hash on the path from a leaf to the root.
+// Left is true when the sibling is the left child.
+type MerkleProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"`
+}
+
+// MerkleProof is the inclusion path of one leaf, ordered from the leaf up
+type MerkleProof struct {
+	LeafIndex int               `json:"leafIndex"`
+	TreeSize  int               `json:"treeSize"`
+	Path      []MerkleProofStep `json:"path"`
+}
+
+// BuildMerkleProof builds the inclusion path of eventHashes[index]
+func BuildMerkleProof(eventHashes []string, index int) (*MerkleProof, error) {
+	if index < 0 || index >= len(eventHashes) {
+		return nil, fmt.Errorf("leaf index %d out of range for %d leaves", index, len(eventHashes))
+	}
+	proof := &MerkleProof{LeafIndex: index, TreeSize: len(eventHashes)}
+	var walk func(leaves []string, i int)
+	walk = func(leaves []string, i int) {
+		if len(leaves) == 1 {
+			return
+		}
+		k := merkleSplit(len(leaves))
+		if i < k {
+			walk(leaves[:k], i)
+			proof.Path = append(proof.Path, MerkleProofStep{Hash: hex.EncodeToString(merkleTreeHash(leaves[k:]))})
+		} else {
+			walk(leaves[k:], i-k)
+			proof.Path = append(proof.Path, MerkleProofStep{Hash: hex.EncodeToString(merkleTreeHash(leaves[:k])), Left: true})
+		}
+	}
+	walk(eventHashes, index)
+	return proof, nil
+}
+
+// Root computes the root the path leads to from eventHash
+func (p *MerkleProof) Root(eventHash string) (string, error) {
+	node := merkleLeaf(eventHash)
+	for i, step := range p.Path {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil || len(sibling) != sha256.Size {
+			return "", fmt.Errorf("invalid hash at proof step %d", i)
+		}
+		if step.Left {
+			node = merkleNode(sibling, node)
+		} else {
+			node = merkleNode(node, sibling)
+		}
+	}
+	return hex.EncodeToString(node), nil
+}
+
+// InclusionProof proves that an event is part of a signed batch. Merkle
+// batches carry the inclusion path; legacy flat batches carry every event
+// hash of the batch.
+type InclusionProof struct {
+	EventID            uuid.UUID          `json:"eventId"`
+	EventHash          string             `json:"eventHash"`
+	BatchID            uuid.UUID          `json:"batchId"`
+	SourceID           string             `json:"sourceId"`
+	HashScheme         string             `json:"hashScheme"`
+	BatchHash          string             `json:"batchHash"` // Signed root
+	Signature          string             `json:"signature"`
+	SignatureAlg       string             `json:"signatureAlg"`
+	KeyID              string             `json:"keyId"`
+	SignedAt           time.Time          `json:"signedAt"`
+	VerificationStatus VerificationStatus `json:"verificationStatus"`
+	Merkle             *MerkleProof       `json:"merkle,omitempty"`
+	BatchEventHashes   []string           `json:"batchEventHashes,omitempty"`
+	SourceKey          *SourceKey         `json:"sourceKey,omitempty"`
+}
+
+// NewInclusionProof builds the proof of eventHashes[index] in sig, whose
+// event hashes are given in batch order
+func NewInclusionProof(sig *BatchSignature, eventHashes []string, index int) (*InclusionProof, error) {
+	if len(eventHashes) != len(sig.EventIDs) {
+		return nil, fmt.Errorf("batch has %d events, got %d hashes", len(sig.EventIDs), len(eventHashes))
+	}
+	if index < 0 || index >= len(eventHashes) {
+		return nil, fmt.Errorf("event index %d out of range", index)
+	}
+	p := &InclusionProof{
+		EventID:            sig.EventIDs[index],
+		EventHash:          eventHashes[index],
+		BatchID:            sig.BatchID,
+		SourceID:           sig.SourceID,
+		HashScheme:         sig.Scheme(),
+		BatchHash:          sig.BatchHash,
+		Signature:          sig.Signature,
+		SignatureAlg:       sig.SignatureAlg,
+		KeyID:              sig.KeyID,
+		SignedAt:           sig.SignedAt,
+		VerificationStatus: sig.VerificationStatus,
+	}
+	switch p.HashScheme {
+	case BatchHashSchemeMerkle:
+		merkle, err := BuildMerkleProof(eventHashes, index)
+		if err != nil {
+			return nil, err
+		}
+		p.Merkle = merkle
+	case BatchHashSchemeFlat:
+		p.BatchEventHashes = append([]string(nil), eventHashes...)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedBatchHashScheme, p.HashScheme)
+	}
+	return p, nil
+}
+
+// VerifyRoot checks that the event hash leads to the signed batch hash
+func (p *InclusionProof) VerifyRoot() error {
+	var root string
+	switch p.HashScheme {
+	case BatchHashSchemeMerkle:
+		if p.Merkle == nil {
+			return errors.New("merkle proof missing")
+		}
+		var err error
+		if root, err = p.Merkle.Root(p.EventHash); err != nil {
+			return err
+		}
+	case BatchHashSchemeFlat:
+		found := false
+		for _, h := range p.BatchEventHashes {
+			found = found || h == p.EventHash
+		}
+		if !found {
+			return errors.New("event hash not in batch")
+		}
+		root = ComputeBatchHash(p.BatchEventHashes)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedBatchHashScheme, p.HashScheme)
+	}
+	if root != p.BatchHash {
+		return errors.New("proof does not lead to the signed batch hash")
+	}
+	return nil
+}
+
+// Verify checks the inclusion path and the batch signature with key, which
+// must be the source's registered public key
+func (p *InclusionProof) Verify(key *SourceKey) error {
+	if err := p.VerifyRoot(); err != nil {
+		return err
+	}
+	sig := &BatchSignature{
+		SourceID:     p.SourceID,
+		BatchHash:    p.BatchHash,
+		Signature:    p.Signature,
+		SignatureAlg: p.SignatureAlg,
+		KeyID:        p.KeyID,
+		SignedAt:     p.SignedAt,
+	}
+	return sig.VerifyWith(key)
+}
+
+// Scheme returns the batch hash scheme, FLAT_SHA256 for batches recorded
+// before Merkle hashing
+func (b *BatchSignature) Scheme() string {
+	if b.HashScheme == "" {
+		return BatchHashSchemeFlat
+	}
+	return b.HashScheme
+}
+
+// CheckEventSources checks that the chain entries of the batch events are
+// all in the signing source's chain: a source's key only vouches for its
+// own events
+func (b *BatchSignature) CheckEventSources(entries []*HashChainEntry) error {
+	for _, entry := range entries {
+		if entry.SourceID != b.SourceID {
+			return fmt.Errorf("%w: event %s is from source %s, not %s", ErrBatchSourceMismatch, entry.EventID, entry.SourceID, b.SourceID)
+		}
+	}
+	return nil
+}
+
+// CheckBatchHash checks that the signed batch hash is the root of
+// eventHashes, given in the order of EventIDs
+func (b *BatchSignature) CheckBatchHash(eventHashes []string) error {
+	root, err := ComputeBatchRoot(b.Scheme(), eventHashes)
+	if err != nil {
+		return err
+	}
+	if root != b.BatchHash {
+		return ErrBatchHashMismatch
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package trust
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventHashes(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = ComputeChainHash(fmt.Sprintf("event-%d", i), "")
+	}
+	return hashes
+}
+
+func TestComputeMerkleRoot(t *testing.T) {
+	hashes := eventHashes(3)
+
+	// RFC 6962: three leaves split into a pair and a single leaf.
+	expected := merkleNode(merkleNode(merkleLeaf(hashes[0]), merkleLeaf(hashes[1])), merkleLeaf(hashes[2]))
+	assert.Equal(t, fmt.Sprintf("%x", expected), ComputeMerkleRoot(hashes))
+
+	assert.NotEqual(t, ComputeMerkleRoot(hashes), ComputeMerkleRoot([]string{hashes[1], hashes[0], hashes[2]}), "order matters")
+	assert.NotEqual(t, ComputeBatchHash(hashes), ComputeMerkleRoot(hashes))
+	assert.Len(t, ComputeMerkleRoot(nil), 64)
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		hashes := eventHashes(n)
+		root := ComputeMerkleRoot(hashes)
+		for i := 0; i < n; i++ {
+			proof, err := BuildMerkleProof(hashes, i)
+			require.NoError(t, err)
+			got, err := proof.Root(hashes[i])
+			require.NoError(t, err)
+			require.Equal(t, root, got, "leaf %d of %d", i, n)
+		}
+	}
+
+	hashes := eventHashes(8)
+	proof, err := BuildMerkleProof(hashes, 5)
+	require.NoError(t, err)
+	assert.Len(t, proof.Path, 3)
+
+	t.Run("other leaf does not lead to root", func(t *testing.T) {
+		got, err := proof.Root(hashes[4])
+		require.NoError(t, err)
+		assert.NotEqual(t, ComputeMerkleRoot(hashes), got)
+	})
+
+	t.Run("rejects malformed step", func(t *testing.T) {
+		bad := *proof
+		bad.Path = append([]MerkleProofStep{{Hash: "zz"}}, proof.Path[1:]...)
+		_, err := bad.Root(hashes[5])
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects out of range leaf", func(t *testing.T) {
+		_, err := BuildMerkleProof(hashes, 8)
+		assert.Error(t, err)
+	})
+}
+
+func TestInclusionProof_VerifyRoot(t *testing.T) {
+	hashes := eventHashes(5)
+	eventIDs := make([]uuid.UUID, len(hashes))
+	for i := range eventIDs {
+		eventIDs[i] = uuid.New()
+	}
+
+	t.Run("merkle batch", func(t *testing.T) {
+		sig := NewBatchSignature("gateway-001", eventIDs, ComputeMerkleRoot(hashes), "sig", SignatureAlgEd25519, "key-ed", time.Now())
+		require.NoError(t, sig.CheckBatchHash(hashes))
+
+		proof, err := NewInclusionProof(sig, hashes, 2)
+		require.NoError(t, err)
+		assert.Equal(t, eventIDs[2], proof.EventID)
+		assert.Empty(t, proof.BatchEventHashes)
+		assert.NoError(t, proof.VerifyRoot())
+
+		proof.EventHash = hashes[3]
+		assert.Error(t, proof.VerifyRoot())
+	})
+
+	t.Run("legacy flat batch", func(t *testing.T) {
+		sig := NewBatchSignature("gateway-001", eventIDs, ComputeBatchHash(hashes), "sig", SignatureAlgHMACSHA256, "key-001", time.Now())
+		sig.HashScheme = ""
+		assert.Equal(t, BatchHashSchemeFlat, sig.Scheme())
+		require.NoError(t, sig.CheckBatchHash(hashes))
+
+		proof, err := NewInclusionProof(sig, hashes, 4)
+		require.NoError(t, err)
+		assert.Nil(t, proof.Merkle)
+		assert.Equal(t, hashes, proof.BatchEventHashes)
+		assert.NoError(t, proof.VerifyRoot())
+
+		proof.BatchEventHashes = hashes[:4]
+		assert.Error(t, proof.VerifyRoot())
+	})
+
+	t.Run("detects mismatched batch hash", func(t *testing.T) {
+		sig := NewBatchSignature("gateway-001", eventIDs, ComputeMerkleRoot(hashes[:4]), "sig", SignatureAlgEd25519, "key-ed", time.Now())
+		assert.ErrorIs(t, sig.CheckBatchHash(hashes), ErrBatchHashMismatch)
+	})
+}
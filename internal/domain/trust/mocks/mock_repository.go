@@ -14,6 +14,22 @@ type MockRepository struct {
 	mock.Mock
 }
 
+func (m *MockRepository) ListChainedEvents(ctx context.Context, filter trust.EventRangeFilter, limit int) ([]trust.EventEvidence, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]trust.EventEvidence), args.Error(1)
+}
+
+func (m *MockRepository) AppendToChain(ctx context.Context, sourceID string, links []trust.ChainLink) ([]*trust.HashChainEntry, error) {
+	args := m.Called(ctx, sourceID, links)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*trust.HashChainEntry), args.Error(1)
+}
+
 func (m *MockRepository) InsertHashChainEntry(ctx context.Context, entry *trust.HashChainEntry) error {
 	args := m.Called(ctx, entry)
 	return args.Error(0)
@@ -2,6 +2,7 @@ package trust
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,6 +13,7 @@ type Repository interface {
 	InsertEvent(ctx context.Context, event *EventEvidence) error
 	GetEvent(ctx context.Context, eventID uuid.UUID) (*EventEvidence, error)
 	GetEvents(ctx context.Context, eventIDs []uuid.UUID) ([]EventEvidence, error)
+	ListChainedEvents(ctx context.Context, filter EventRangeFilter, limit int) ([]EventEvidence, error)
 
 	// Hash Chain operations
 	InsertHashChainEntry(ctx context.Context, entry *HashChainEntry) error
@@ -19,6 +21,11 @@ type Repository interface {
 	GetLatestChainEntry(ctx context.Context, sourceID string) (*HashChainEntry, error)
 	GetChainEntriesForSource(ctx context.Context, sourceID string, fromSeq, toSeq int64) ([]*HashChainEntry, error)
 	GetChainEntriesForEvents(ctx context.Context, eventIDs []uuid.UUID) ([]*HashChainEntry, error)
+	// AppendToChain stores the events of links at trust level T2, or at their
+	// lower trust ceiling, and extends sourceID's hash chain with them in one
+	// transaction. Appends to the same source are serialised, so concurrent
+	// appends never fork the chain.
+	AppendToChain(ctx context.Context, sourceID string, links []ChainLink) ([]*HashChainEntry, error)
 	
 	// Batch Signature operations
 	InsertBatchSignature(ctx context.Context, sig *BatchSignature) error
@@ -65,6 +72,45 @@ type KeyStore interface {
 	GetKeyForSource(ctx context.Context, sourceID string) (keyID string, key []byte, err error)
 }
 
+// KeyRegistry persists the public keys sources sign batches with
+type KeyRegistry interface {
+	CreateSourceKey(ctx context.Context, key *SourceKey) error
+	GetSourceKey(ctx context.Context, keyID string) (*SourceKey, error)
+	ListSourceKeys(ctx context.Context, sourceID string) ([]*SourceKey, error)
+	RevokeSourceKey(ctx context.Context, keyID string, revokedAt time.Time, reason string) error
+}
+
+// ChainMonitor persists the chain verifier's progress and the breaks it
+// finds
+type ChainMonitor interface {
+	ListChainHeads(ctx context.Context) ([]ChainHead, error)
+	ListChainVerificationStates(ctx context.Context) ([]*ChainVerificationState, error)
+	// SaveChainVerification stores state with breaks in one transaction.
+	// Breaks already recorded for the same source, sequence and kind are
+	// skipped; the new ones get their ID set. A state behind the stored one
+	// is not saved.
+	SaveChainVerification(ctx context.Context, state *ChainVerificationState, breaks []*ChainBreak) error
+	SetChainBreakNotification(ctx context.Context, breakIDs []int64, notificationID uuid.UUID) error
+	// ListChainBreaks returns the latest breaks of a source, or of all
+	// sources when sourceID is empty
+	ListChainBreaks(ctx context.Context, sourceID string, limit int) ([]*ChainBreak, error)
+	// ListUnescalatedChainBreaks returns breaks not yet linked to an
+	// escalation, ordered by source and position in its chain
+	ListUnescalatedChainBreaks(ctx context.Context, limit int) ([]*ChainBreak, error)
+	CountChainBreaks(ctx context.Context) (map[string]int, error)
+}
+
+// SchemaRegistry persists the payload schemas of event types
+type SchemaRegistry interface {
+	// CreateEventSchema fails with ErrSchemaConflict when the event type
+	// already has the version
+	CreateEventSchema(ctx context.Context, schema *EventSchema) error
+	GetEventSchema(ctx context.Context, eventType, schemaVersion string) (*EventSchema, error)
+	// ListEventSchemas returns the schemas of an event type, or of all
+	// event types when eventType is empty, oldest first
+	ListEventSchemas(ctx context.Context, eventType string) ([]*EventSchema, error)
+}
+
 // HashChainFilter represents filters for querying hash chain entries
 type HashChainFilter struct {
 	SourceID      *string
@@ -73,6 +119,22 @@ type HashChainFilter struct {
 	EventIDs      []uuid.UUID
 }
 
+// EventRangeFilter selects hash-chained events by server timestamp, ordered
+// by (ts_server, event_id). Since is inclusive, Until exclusive; After
+// resumes a previous page.
+type EventRangeFilter struct {
+	Since    time.Time
+	Until    time.Time
+	SourceID *string
+	After    *EventCursor
+}
+
+// EventCursor is the position of an event in server time order
+type EventCursor struct {
+	TsServer time.Time
+	EventID  uuid.UUID
+}
+
 // SignatureFilter represents filters for querying batch signatures
 type SignatureFilter struct {
 	SourceID           *string
@@ -0,0 +1,553 @@
+package trust
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FlagSchemaInvalid marks events whose payload failed validation against
+// their registered schema. Flagged events never rise above T1.
+const FlagSchemaInvalid = "SCHEMA_INVALID"
+
+// InvalidPayloadAction decides what ingest does with a payload that fails
+// validation against its schema
+type InvalidPayloadAction string
+
+const (
+	// InvalidPayloadReject refuses the event, and with it its whole batch
+	InvalidPayloadReject InvalidPayloadAction = "REJECT"
+	// InvalidPayloadFlag stores the event with its validation errors and
+	// caps its trust level at T1
+	InvalidPayloadFlag InvalidPayloadAction = "FLAG"
+)
+
+// SchemaCompatibility is the check a new schema version must pass against
+// the latest registered version of its event type
+type SchemaCompatibility string
+
+const (
+	// SchemaCompatibilityBackward requires every payload valid under the
+	// latest version to stay valid under the new one
+	SchemaCompatibilityBackward SchemaCompatibility = "BACKWARD"
+	// SchemaCompatibilityNone registers the version without a check
+	SchemaCompatibilityNone SchemaCompatibility = "NONE"
+)
+
+var (
+	ErrSchemaNotFound     = errors.New("event schema not found")
+	ErrSchemaConflict     = errors.New("event schema version already registered with a different document")
+	ErrSchemaIncompatible = errors.New("event schema is incompatible with the latest version")
+	ErrInvalidSchema      = errors.New("invalid event schema")
+	ErrPayloadInvalid     = errors.New("payload does not match its event schema")
+)
+
+// EventSchema is a JSON Schema document registered for the payloads of one
+// event type at one schema version
+type EventSchema struct {
+	ID            int64                `json:"id"`
+	EventType     string               `json:"eventType"`
+	SchemaVersion string               `json:"schemaVersion"`
+	Schema        json.RawMessage      `json:"schema"`
+	OnInvalid     InvalidPayloadAction `json:"onInvalid"`
+	Compatibility SchemaCompatibility  `json:"compatibility"`
+	CreatedBy     string               `json:"createdBy"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+// NewEventSchema creates a registry entry. onInvalid defaults to REJECT and
+// compatibility to BACKWARD.
+func NewEventSchema(eventType, schemaVersion string, document json.RawMessage, onInvalid InvalidPayloadAction, compatibility SchemaCompatibility, createdBy string) (*EventSchema, error) {
+	if onInvalid == "" {
+		onInvalid = InvalidPayloadReject
+	}
+	if compatibility == "" {
+		compatibility = SchemaCompatibilityBackward
+	}
+	s := &EventSchema{
+		EventType:     eventType,
+		SchemaVersion: schemaVersion,
+		Schema:        document,
+		OnInvalid:     onInvalid,
+		Compatibility: compatibility,
+		CreatedBy:     createdBy,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	s.Schema = compact.Bytes()
+	return s, nil
+}
+
+// Validate checks the entry and that its document is a supported schema
+func (s *EventSchema) Validate() error {
+	if s.EventType == "" {
+		return errors.New("eventType is required")
+	}
+	if s.SchemaVersion == "" {
+		return errors.New("schemaVersion is required")
+	}
+	switch s.OnInvalid {
+	case InvalidPayloadReject, InvalidPayloadFlag:
+	default:
+		return fmt.Errorf("invalid onInvalid %q: must be %q or %q", s.OnInvalid, InvalidPayloadReject, InvalidPayloadFlag)
+	}
+	switch s.Compatibility {
+	case SchemaCompatibilityBackward, SchemaCompatibilityNone:
+	default:
+		return fmt.Errorf("invalid compatibility %q: must be %q or %q", s.Compatibility, SchemaCompatibilityBackward, SchemaCompatibilityNone)
+	}
+	_, err := s.Compile()
+	return err
+}
+
+// SameDocument reports whether other registers the same schema with the
+// same settings, so registering it again changes nothing
+func (s *EventSchema) SameDocument(other *EventSchema) bool {
+	if s.OnInvalid != other.OnInvalid {
+		return false
+	}
+	var a, b interface{}
+	if json.Unmarshal(s.Schema, &a) != nil || json.Unmarshal(other.Schema, &b) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb)
+}
+
+// Compile parses the entry's document
+func (s *EventSchema) Compile() (*Schema, error) {
+	return ParseSchema(s.Schema)
+}
+
+// Schema is a compiled JSON Schema. It supports the validation keywords
+// type, properties, required, additionalProperties, items, enum, minimum,
+// maximum, minLength, maxLength, pattern, minItems and maxItems; other
+// keywords are rejected so that no document silently validates less than
+// it states.
+type Schema struct {
+	Types                []string
+	Properties           map[string]*Schema
+	Required             []string
+	AdditionalProperties *bool
+	Items                *Schema
+	Enum                 []string // canonical JSON of each value
+	Minimum              *float64
+	Maximum              *float64
+	MinLength            *int
+	MaxLength            *int
+	Pattern              string
+	MinItems             *int
+	MaxItems             *int
+
+	pattern *regexp.Regexp
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// schemaAnnotations are keywords that do not constrain payloads
+var schemaAnnotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true, "title": true,
+	"description": true, "default": true, "examples": true, "format": true,
+}
+
+// ParseSchema compiles a JSON Schema document
+func ParseSchema(document json.RawMessage) (*Schema, error) {
+	s, err := parseSchema(document, "$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return s, nil
+}
+
+func parseSchema(document json.RawMessage, path string) (*Schema, error) {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(document, &keywords); err != nil || keywords == nil {
+		return nil, fmt.Errorf("%s: schema must be an object", path)
+	}
+	s := &Schema{}
+	for keyword, raw := range keywords {
+		var err error
+		switch keyword {
+		case "type":
+			var one string
+			if json.Unmarshal(raw, &one) == nil {
+				s.Types = []string{one}
+			} else if err = json.Unmarshal(raw, &s.Types); err != nil {
+				return nil, fmt.Errorf("%s: type must be a string or an array of strings", path)
+			}
+			for _, t := range s.Types {
+				if !schemaTypes[t] {
+					return nil, fmt.Errorf("%s: unknown type %q", path, t)
+				}
+			}
+		case "properties":
+			var props map[string]json.RawMessage
+			if err = json.Unmarshal(raw, &props); err != nil {
+				return nil, fmt.Errorf("%s: properties must be an object", path)
+			}
+			s.Properties = make(map[string]*Schema, len(props))
+			for name, prop := range props {
+				if s.Properties[name], err = parseSchema(prop, path+"."+name); err != nil {
+					return nil, err
+				}
+			}
+		case "required":
+			if err = json.Unmarshal(raw, &s.Required); err != nil {
+				return nil, fmt.Errorf("%s: required must be an array of strings", path)
+			}
+		case "additionalProperties":
+			var allowed bool
+			if err = json.Unmarshal(raw, &allowed); err != nil {
+				return nil, fmt.Errorf("%s: additionalProperties must be a boolean", path)
+			}
+			s.AdditionalProperties = &allowed
+		case "items":
+			if s.Items, err = parseSchema(raw, path+"[]"); err != nil {
+				return nil, err
+			}
+		case "enum":
+			var values []interface{}
+			if err = json.Unmarshal(raw, &values); err != nil || len(values) == 0 {
+				return nil, fmt.Errorf("%s: enum must be a non-empty array", path)
+			}
+			for _, v := range values {
+				s.Enum = append(s.Enum, canonicalJSON(v))
+			}
+		case "minimum", "maximum":
+			var n float64
+			if err = json.Unmarshal(raw, &n); err != nil {
+				return nil, fmt.Errorf("%s: %s must be a number", path, keyword)
+			}
+			if keyword == "minimum" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			var n int
+			if err = json.Unmarshal(raw, &n); err != nil || n < 0 {
+				return nil, fmt.Errorf("%s: %s must be a non-negative integer", path, keyword)
+			}
+			switch keyword {
+			case "minLength":
+				s.MinLength = &n
+			case "maxLength":
+				s.MaxLength = &n
+			case "minItems":
+				s.MinItems = &n
+			case "maxItems":
+				s.MaxItems = &n
+			}
+		case "pattern":
+			if err = json.Unmarshal(raw, &s.Pattern); err != nil {
+				return nil, fmt.Errorf("%s: pattern must be a string", path)
+			}
+			if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+				return nil, fmt.Errorf("%s: invalid pattern: %v", path, err)
+			}
+		default:
+			if !schemaAnnotations[keyword] {
+				return nil, fmt.Errorf("%s: unsupported keyword %q", path, keyword)
+			}
+		}
+	}
+	sort.Strings(s.Types)
+	return s, nil
+}
+
+func canonicalJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// ValidatePayload validates a JSON payload and returns its violations, each
+// prefixed with the path of the offending value
+func (s *Schema) ValidatePayload(payload json.RawMessage) []string {
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return []string{"$: payload is not valid JSON"}
+	}
+	var errs []string
+	s.validate(value, "$", &errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *[]string) {
+	if len(s.Types) > 0 && !s.allowsType(jsonType(value), value) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.Types, " or "), jsonType(value)))
+		return
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if allowed == canonicalJSON(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: value is not one of the allowed values", path))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := s.Properties[name]; ok {
+				prop.validate(v[name], path+"."+name, errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, fmt.Sprintf("%s: unexpected property %q", path, name))
+			}
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %d items", path, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %d items", path, *s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at least %d characters", path, *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			*errs = append(*errs, fmt.Sprintf("%s: expected at most %d characters", path, *s.MaxLength))
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			*errs = append(*errs, fmt.Sprintf("%s: does not match pattern %q", path, s.Pattern))
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at least %v", path, *s.Minimum))
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: must be at most %v", path, *s.Maximum))
+		}
+	}
+}
+
+func (s *Schema) allowsType(t string, value interface{}) bool {
+	for _, allowed := range s.Types {
+		if allowed == t {
+			return true
+		}
+		if allowed == "integer" && t == "number" {
+			if n := value.(float64); n == math.Trunc(n) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Schema) hasType(t string) bool {
+	if len(s.Types) == 0 {
+		return true
+	}
+	for _, allowed := range s.Types {
+		if allowed == t {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// FieldTypes returns the types a dotted payload field path may have under
+// the schema. An empty result means any type. ok is false when the schema
+// rules the field out.
+func (s *Schema) FieldTypes(path string) (types []string, ok bool) {
+	current := s
+	for _, part := range strings.Split(path, ".") {
+		if !current.hasType("object") {
+			return nil, false
+		}
+		prop, found := current.Properties[part]
+		if !found {
+			if current.AdditionalProperties != nil && !*current.AdditionalProperties {
+				return nil, false
+			}
+			return nil, true
+		}
+		current = prop
+	}
+	return current.Types, true
+}
+
+// CheckCompatible reports why payloads valid under prev might be rejected
+// by next. It returns nil when next is backward compatible with prev.
+func CheckCompatible(prev, next *Schema) error {
+	var issues []string
+	checkCompatible(prev, next, "$", &issues)
+	if len(issues) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrSchemaIncompatible, strings.Join(issues, "; "))
+}
+
+func checkCompatible(prev, next *Schema, path string, issues *[]string) {
+	if len(next.Types) > 0 {
+		if len(prev.Types) == 0 {
+			*issues = append(*issues, fmt.Sprintf("%s: type restricted to %s", path, strings.Join(next.Types, " or ")))
+		}
+		for _, t := range prev.Types {
+			if !next.hasType(t) && !(t == "integer" && next.hasType("number")) {
+				*issues = append(*issues, fmt.Sprintf("%s: type %s removed", path, t))
+			}
+		}
+	}
+	if len(next.Enum) > 0 {
+		if len(prev.Enum) == 0 {
+			*issues = append(*issues, fmt.Sprintf("%s: enum added", path))
+		}
+		allowed := make(map[string]bool, len(next.Enum))
+		for _, v := range next.Enum {
+			allowed[v] = true
+		}
+		for _, v := range prev.Enum {
+			if !allowed[v] {
+				*issues = append(*issues, fmt.Sprintf("%s: enum value %s removed", path, v))
+			}
+		}
+	}
+
+	prevRequired := make(map[string]bool, len(prev.Required))
+	for _, name := range prev.Required {
+		prevRequired[name] = true
+	}
+	for _, name := range next.Required {
+		if !prevRequired[name] {
+			*issues = append(*issues, fmt.Sprintf("%s: property %q became required", path, name))
+		}
+	}
+	if next.AdditionalProperties != nil && !*next.AdditionalProperties {
+		if prev.AdditionalProperties == nil || *prev.AdditionalProperties {
+			*issues = append(*issues, fmt.Sprintf("%s: additional properties disallowed", path))
+		}
+		for name := range prev.Properties {
+			if _, ok := next.Properties[name]; !ok {
+				*issues = append(*issues, fmt.Sprintf("%s: property %q removed", path, name))
+			}
+		}
+	}
+	names := make([]string, 0, len(prev.Properties))
+	for name := range prev.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prop, ok := next.Properties[name]; ok {
+			checkCompatible(prev.Properties[name], prop, path+"."+name, issues)
+		}
+	}
+	// A property prev only allowed as an additional one could hold any value
+	if prev.AdditionalProperties == nil || *prev.AdditionalProperties {
+		added := make([]string, 0, len(next.Properties))
+		for name := range next.Properties {
+			if _, ok := prev.Properties[name]; !ok {
+				added = append(added, name)
+			}
+		}
+		sort.Strings(added)
+		for _, name := range added {
+			checkCompatible(&Schema{}, next.Properties[name], path+"."+name, issues)
+		}
+	}
+	if next.Items != nil {
+		if prev.Items == nil {
+			*issues = append(*issues, fmt.Sprintf("%s: items constrained", path))
+		} else {
+			checkCompatible(prev.Items, next.Items, path+"[]", issues)
+		}
+	}
+
+	if tightenedFloat(prev.Minimum, next.Minimum, false) || tightenedFloat(prev.Maximum, next.Maximum, true) {
+		*issues = append(*issues, fmt.Sprintf("%s: numeric bounds tightened", path))
+	}
+	if tightenedInt(prev.MinLength, next.MinLength, false) || tightenedInt(prev.MaxLength, next.MaxLength, true) {
+		*issues = append(*issues, fmt.Sprintf("%s: length bounds tightened", path))
+	}
+	if tightenedInt(prev.MinItems, next.MinItems, false) || tightenedInt(prev.MaxItems, next.MaxItems, true) {
+		*issues = append(*issues, fmt.Sprintf("%s: item count bounds tightened", path))
+	}
+	if next.Pattern != "" && next.Pattern != prev.Pattern {
+		*issues = append(*issues, fmt.Sprintf("%s: pattern changed", path))
+	}
+}
+
+// tightenedFloat reports whether next bounds values more strictly than
+// prev; upper selects the direction of the bound
+func tightenedFloat(prev, next *float64, upper bool) bool {
+	if next == nil {
+		return false
+	}
+	if prev == nil {
+		return true
+	}
+	if upper {
+		return *next < *prev
+	}
+	return *next > *prev
+}
+
+func tightenedInt(prev, next *int, upper bool) bool {
+	if next == nil {
+		return false
+	}
+	if prev == nil {
+		return true
+	}
+	if upper {
+		return *next < *prev
+	}
+	return *next > *prev
+}
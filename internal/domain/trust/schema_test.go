@@ -0,0 +1,170 @@
+package trust
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const readingSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["temperature", "unit"],
+	"properties": {
+		"temperature": {"type": "number", "minimum": -50, "maximum": 150},
+		"unit": {"type": "string", "enum": ["C", "F"]},
+		"sensor": {
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"id": {"type": "string", "pattern": "^s-[0-9]+$"}, "battery": {"type": "integer"}}
+		},
+		"tags": {"type": "array", "items": {"type": "string", "maxLength": 8}, "maxItems": 3}
+	}
+}`
+
+func mustParseSchema(t *testing.T, document string) *Schema {
+	t.Helper()
+	s, err := ParseSchema(json.RawMessage(document))
+	require.NoError(t, err)
+	return s
+}
+
+func TestParseSchema(t *testing.T) {
+	mustParseSchema(t, readingSchema)
+
+	for name, document := range map[string]string{
+		"not an object":       `[]`,
+		"unknown type":        `{"type": "decimal"}`,
+		"unsupported keyword": `{"oneOf": [{"type": "string"}]}`,
+		"nested unsupported":  `{"properties": {"a": {"$ref": "#/defs/a"}}}`,
+		"bad pattern":         `{"pattern": "("}`,
+		"empty enum":          `{"enum": []}`,
+		"negative length":     `{"maxLength": -1}`,
+	} {
+		_, err := ParseSchema(json.RawMessage(document))
+		assert.ErrorIs(t, err, ErrInvalidSchema, name)
+	}
+}
+
+func TestSchema_ValidatePayload(t *testing.T) {
+	s := mustParseSchema(t, readingSchema)
+
+	assert.Empty(t, s.ValidatePayload(json.RawMessage(`{"temperature": 21.5, "unit": "C", "sensor": {"id": "s-1", "battery": 80}, "tags": ["lab"], "extra": true}`)))
+	assert.Equal(t, []string{`$: missing required property "unit"`}, s.ValidatePayload(json.RawMessage(`{"temperature": 21}`)))
+	assert.Equal(t, []string{
+		"$.sensor.battery: expected integer, got number",
+		`$.sensor.id: does not match pattern "^s-[0-9]+$"`,
+		`$.sensor: unexpected property "mode"`,
+		"$.tags: expected at most 3 items",
+		"$.tags[1]: expected at most 8 characters",
+		"$.temperature: must be at most 150",
+		"$.unit: value is not one of the allowed values",
+	}, s.ValidatePayload(json.RawMessage(`{
+		"temperature": 200, "unit": "K",
+		"sensor": {"id": "x", "battery": 0.5, "mode": "eco"},
+		"tags": ["a", "overlylong", "c", "d"]
+	}`)))
+	assert.Equal(t, []string{"$: expected object, got string"}, s.ValidatePayload(json.RawMessage(`"hot"`)))
+	assert.Equal(t, []string{"$: payload is not valid JSON"}, s.ValidatePayload(json.RawMessage(`{`)))
+}
+
+func TestCheckCompatible(t *testing.T) {
+	prev := mustParseSchema(t, readingSchema)
+	assert.NoError(t, CheckCompatible(prev, prev))
+
+	widened := mustParseSchema(t, `{
+		"type": "object",
+		"required": ["temperature"],
+		"properties": {
+			"temperature": {"type": ["number", "string"]},
+			"unit": {"type": "string", "enum": ["C", "F", "K"]},
+			"humidity": {}
+		}
+	}`)
+	assert.NoError(t, CheckCompatible(prev, widened))
+
+	// Payloads conforming to an open object may already carry any value
+	// under a property the next version starts declaring.
+	open := mustParseSchema(t, `{"type": "object"}`)
+	typed := mustParseSchema(t, `{"type": "object", "properties": {"x": {"type": "string"}}}`)
+	err := CheckCompatible(open, typed)
+	require.ErrorIs(t, err, ErrSchemaIncompatible)
+	assert.Contains(t, err.Error(), "$.x: type restricted to string")
+	assert.NoError(t, CheckCompatible(open, mustParseSchema(t, `{"type": "object", "properties": {"x": {}}}`)))
+
+	narrowed := mustParseSchema(t, `{
+		"type": "object",
+		"required": ["temperature", "unit", "humidity"],
+		"additionalProperties": false,
+		"properties": {
+			"temperature": {"type": "integer", "maximum": 100},
+			"unit": {"type": "string", "enum": ["C"]},
+			"humidity": {"type": "number"}
+		}
+	}`)
+	err = CheckCompatible(prev, narrowed)
+	require.ErrorIs(t, err, ErrSchemaIncompatible)
+	for _, issue := range []string{
+		`property "humidity" became required`,
+		"additional properties disallowed",
+		`property "sensor" removed`,
+		"$.temperature: type number removed",
+		"$.temperature: numeric bounds tightened",
+		`$.unit: enum value "F" removed`,
+	} {
+		assert.Contains(t, err.Error(), issue)
+	}
+}
+
+func TestSchema_FieldTypes(t *testing.T) {
+	s := mustParseSchema(t, readingSchema)
+
+	types, ok := s.FieldTypes("temperature")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"number"}, types)
+	types, ok = s.FieldTypes("sensor.battery")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"integer"}, types)
+
+	types, ok = s.FieldTypes("humidity")
+	assert.True(t, ok, "open objects allow undeclared fields")
+	assert.Empty(t, types)
+	_, ok = s.FieldTypes("sensor.mode")
+	assert.False(t, ok, "closed objects rule out undeclared fields")
+	_, ok = s.FieldTypes("unit.code")
+	assert.False(t, ok, "strings have no fields")
+}
+
+func TestNewEventSchema(t *testing.T) {
+	s, err := NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(readingSchema), "", "", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, InvalidPayloadReject, s.OnInvalid)
+	assert.Equal(t, SchemaCompatibilityBackward, s.Compatibility)
+
+	spaced, err := NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(readingSchema+"\n"), InvalidPayloadReject, "", "other")
+	require.NoError(t, err)
+	assert.True(t, s.SameDocument(spaced))
+	flagged, err := NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(readingSchema), InvalidPayloadFlag, "", "admin")
+	require.NoError(t, err)
+	assert.False(t, s.SameDocument(flagged))
+
+	_, err = NewEventSchema("", "1.0.0", json.RawMessage(readingSchema), "", "", "admin")
+	assert.Error(t, err)
+	_, err = NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(readingSchema), "DROP", "", "admin")
+	assert.Error(t, err)
+	_, err = NewEventSchema("SENSOR_READING", "1.0.0", json.RawMessage(`{"allOf": []}`), "", "", "admin")
+	assert.ErrorIs(t, err, ErrInvalidSchema)
+}
+
+func TestEventEvidence_TrustCeiling(t *testing.T) {
+	event := &EventEvidence{}
+	assert.Equal(t, TrustLevelT3, event.CapTrust(TrustLevelT3))
+	assert.Empty(t, event.TrustFlags())
+
+	event.ValidationErrors = []string{"$.unit: value is not one of the allowed values"}
+	assert.Equal(t, TrustLevelT1, event.CapTrust(TrustLevelT3))
+	assert.Equal(t, TrustLevelT0, event.CapTrust(TrustLevelT0))
+	assert.Equal(t, []string{FlagSchemaInvalid}, event.TrustFlags())
+}
@@ -3,6 +3,7 @@ package trust
 import (
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -10,6 +11,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
 )
 
 // TrustLevel represents the trust level of an event
@@ -133,6 +136,31 @@ func (e *HashChainEntry) Verify() bool {
 	return e.ChainHash == expectedChainHash
 }
 
+// ChainLink is an event to append to its source's hash chain
+type ChainLink struct {
+	Event     *EventEvidence
+	EventHash string
+}
+
+// ExtendChain builds the entries appending links, in order, after latest,
+// which is nil for an empty chain
+func ExtendChain(latest *HashChainEntry, sourceID string, links []ChainLink) []*HashChainEntry {
+	prevHash := ""
+	sequenceNum := int64(1)
+	if latest != nil {
+		prevHash = latest.ChainHash
+		sequenceNum = latest.SequenceNum + 1
+	}
+	entries := make([]*HashChainEntry, 0, len(links))
+	for _, link := range links {
+		entry := NewHashChainEntry(link.Event.EventID, sourceID, sequenceNum, link.EventHash, prevHash)
+		entries = append(entries, entry)
+		prevHash = entry.ChainHash
+		sequenceNum++
+	}
+	return entries
+}
+
 // BatchSignature represents a batch of events with signature
 type BatchSignature struct {
 	ID                 int64              `json:"id"`
@@ -140,8 +168,9 @@ type BatchSignature struct {
 	SourceID           string             `json:"sourceId"`
 	EventIDs           []uuid.UUID        `json:"eventIds"`
 	BatchHash          string             `json:"batchHash"`    // Hash of all events in batch
-	Signature          string             `json:"signature"`    // HMAC-SHA256 signature
-	SignatureAlg       string             `json:"signatureAlg"` // Algorithm: HMAC-SHA256
+	HashScheme         string             `json:"hashScheme"`   // MERKLE_SHA256, or FLAT_SHA256 for legacy batches
+	Signature          string             `json:"signature"`    // Hex-encoded signature of BatchHash
+	SignatureAlg       string             `json:"signatureAlg"` // Algorithm: HMAC-SHA256, Ed25519 or ECDSA-P256-SHA256
 	KeyID              string             `json:"keyId"`        // Key identifier used for signing
 	SignedAt           time.Time          `json:"signedAt"`     // When the signature was created (gateway time)
 	VerifiedAt         *time.Time         `json:"verifiedAt,omitempty"`
@@ -157,6 +186,7 @@ func NewBatchSignature(sourceID string, eventIDs []uuid.UUID, batchHash, signatu
 		SourceID:           sourceID,
 		EventIDs:           eventIDs,
 		BatchHash:          batchHash,
+		HashScheme:         BatchHashSchemeMerkle,
 		Signature:          signature,
 		SignatureAlg:       signatureAlg,
 		KeyID:              keyID,
@@ -166,7 +196,8 @@ func NewBatchSignature(sourceID string, eventIDs []uuid.UUID, batchHash, signatu
 	}
 }
 
-// ComputeBatchHash computes the hash of a batch of event hashes
+// ComputeBatchHash computes the legacy flat hash of a batch of event hashes.
+// New batches are hashed with ComputeMerkleRoot.
 func ComputeBatchHash(eventHashes []string) string {
 	combined := ""
 	for _, h := range eventHashes {
@@ -224,7 +255,8 @@ type EvidenceBundle struct {
 	HashChain []HashChainEntry `json:"hashChain,omitempty"`
 
 	// Signature evidence
-	Signatures []BatchSignature `json:"signatures,omitempty"`
+	Signatures      []BatchSignature `json:"signatures,omitempty"`
+	InclusionProofs []InclusionProof `json:"inclusionProofs,omitempty"`
 
 	// Rule & action evidence (for ACTION bundle)
 	Rules   []RuleEvidence   `json:"rules,omitempty"`
@@ -235,6 +267,10 @@ type EvidenceBundle struct {
 
 	// Bundle integrity
 	BundleHash string `json:"bundleHash"`
+	// TimestampToken is an RFC 3161 timestamp token (DER) over the bundle
+	// hash, set when a time-stamping authority is configured. Like the
+	// bundle hash, it is not covered by the hash.
+	TimestampToken []byte `json:"timestampToken,omitempty"`
 }
 
 // EventEvidence represents event data in evidence bundle
@@ -251,6 +287,35 @@ type EventEvidence struct {
 	Payload        json.RawMessage `json:"payload"`
 	SchemaVersion  string          `json:"schemaVersion"`
 	TrustLevel     TrustLevel      `json:"trustLevel"`
+	// ValidationErrors lists how the payload violates its registered
+	// schema. It is not part of the event hash.
+	ValidationErrors []string `json:"validationErrors,omitempty"`
+}
+
+// TrustCeiling returns the highest trust level the event can reach. A
+// payload that failed schema validation stays at T1 whatever the chain and
+// signature evidence.
+func (e *EventEvidence) TrustCeiling() TrustLevel {
+	if len(e.ValidationErrors) > 0 {
+		return TrustLevelT1
+	}
+	return TrustLevelT3
+}
+
+// CapTrust lowers level to the event's trust ceiling
+func (e *EventEvidence) CapTrust(level TrustLevel) TrustLevel {
+	if ceiling := e.TrustCeiling(); level > ceiling {
+		return ceiling
+	}
+	return level
+}
+
+// TrustFlags returns the trust metadata flags the event is stored with
+func (e *EventEvidence) TrustFlags() []string {
+	if len(e.ValidationErrors) > 0 {
+		return []string{FlagSchemaInvalid}
+	}
+	return []string{}
 }
 
 // RuleEvidence represents rule data in evidence bundle
@@ -260,6 +325,7 @@ type RuleEvidence struct {
 	Name           string          `json:"name"`
 	RuleType       string          `json:"ruleType"`
 	Config         json.RawMessage `json:"config"`
+	MinTrustLevel  TrustLevel      `json:"minTrustLevel"`
 	EffectiveFrom  time.Time       `json:"effectiveFrom"`
 	EffectiveUntil *time.Time      `json:"effectiveUntil,omitempty"`
 }
@@ -284,15 +350,31 @@ type VerificationSummary struct {
 	SignatureValid     *bool        `json:"signatureValid,omitempty"`
 	ChainBreaks        []ChainBreak `json:"chainBreaks,omitempty"`
 	VerificationErrors []string     `json:"verificationErrors,omitempty"`
+	// LowTrustInputs lists the bundle's events that are not signature
+	// verified (below T3)
+	LowTrustInputs []LowTrustInput `json:"lowTrustInputs,omitempty"`
+}
+
+// LowTrustInput flags an event of an evidence bundle below T3.
+// BelowRuleMinimum is set when it is also below the minimum trust level of
+// a rule in the bundle.
+type LowTrustInput struct {
+	EventID          uuid.UUID  `json:"eventId"`
+	SourceID         string     `json:"sourceId"`
+	TrustLevel       TrustLevel `json:"trustLevel"`
+	BelowRuleMinimum bool       `json:"belowRuleMinimum"`
 }
 
 // ChainBreak represents a break in the hash chain
 type ChainBreak struct {
-	SourceID     string    `json:"sourceId"`
-	BreakAt      int64     `json:"breakAt"` // Sequence number where break occurred
-	ExpectedHash string    `json:"expectedHash"`
-	ActualHash   string    `json:"actualHash"`
-	DetectedAt   time.Time `json:"detectedAt"`
+	ID             int64          `json:"id,omitempty"`
+	SourceID       string         `json:"sourceId"`
+	BreakAt        int64          `json:"breakAt"` // Sequence number where break occurred
+	Kind           ChainBreakKind `json:"kind"`
+	ExpectedHash   string         `json:"expectedHash"`
+	ActualHash     string         `json:"actualHash"`
+	DetectedAt     time.Time      `json:"detectedAt"`
+	NotificationID *uuid.UUID     `json:"notificationId,omitempty"`
 }
 
 // NewEvidenceBundle creates a new evidence bundle
@@ -310,6 +392,7 @@ func (b *EvidenceBundle) ComputeBundleHash() (string, error) {
 	// Create a copy without the bundle hash for hashing
 	copy := *b
 	copy.BundleHash = ""
+	copy.TimestampToken = nil
 
 	data, err := json.Marshal(copy)
 	if err != nil {
@@ -330,18 +413,54 @@ func (b *EvidenceBundle) Finalize() error {
 	return nil
 }
 
-// VerifyIntegrity verifies the bundle integrity
-func (b *EvidenceBundle) VerifyIntegrity() bool {
-	savedHash := b.BundleHash
-	b.BundleHash = ""
+// VerifyIntegrity verifies the bundle integrity: the bundle hash and, when
+// the bundle is timestamped, that the token is a valid stamp of that hash
+// by an authority chaining to roots. With nil roots any authority passes;
+// VerifyEvidenceBundle reports such tokens as untrusted.
+func (b *EvidenceBundle) VerifyIntegrity(roots *x509.CertPool) bool {
+	if !b.hashMatches() {
+		return false
+	}
+	if len(b.TimestampToken) > 0 {
+		if _, err := b.VerifyTimestamp(roots); err != nil {
+			return false
+		}
+	}
+	return true
+}
 
+func (b *EvidenceBundle) hashMatches() bool {
 	expectedHash, err := b.ComputeBundleHash()
-	b.BundleHash = savedHash
-
 	if err != nil {
 		return false
 	}
-	return savedHash == expectedHash
+	return b.BundleHash == expectedHash
+}
+
+// VerifyTimestamp checks that the bundle's timestamp token stamps the
+// bundle hash and is signed by its time-stamping authority, whose
+// certificate must chain to roots unless roots is nil. A token with a valid
+// signature by an untrusted authority is returned with an error wrapping
+// tsp.ErrUntrustedAuthority.
+func (b *EvidenceBundle) VerifyTimestamp(roots *x509.CertPool) (*tsp.Token, error) {
+	if len(b.TimestampToken) == 0 {
+		return nil, ErrNotTimestamped
+	}
+	digest, err := hex.DecodeString(b.BundleHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle hash: %w", err)
+	}
+	token, err := tsp.ParseToken(b.TimestampToken)
+	if err != nil {
+		return nil, err
+	}
+	if err := token.Verify(digest, roots); err != nil {
+		if errors.Is(err, tsp.ErrUntrustedAuthority) {
+			return token, err
+		}
+		return nil, err
+	}
+	return token, nil
 }
 
 // TrustMetadata represents trust metadata attached to an event
@@ -364,6 +483,16 @@ type TrustVerificationResult struct {
 	VerifiedAt     time.Time  `json:"verifiedAt"`
 }
 
+// BatchVerification is the outcome of a batch signature check with the
+// batch's events at their trust level after it. PreviousLevels holds the
+// levels the events had before.
+type BatchVerification struct {
+	Batch          *BatchSignature
+	Verified       bool
+	Events         []EventEvidence
+	PreviousLevels map[uuid.UUID]TrustLevel
+}
+
 // Errors
 var (
 	ErrEventNotFound          = errors.New("event not found")
@@ -372,4 +501,5 @@ var (
 	ErrKeyNotFound            = errors.New("signing key not found")
 	ErrInvalidBundleType      = errors.New("invalid bundle type")
 	ErrBatchSignatureNotFound = errors.New("batch signature not found")
+	ErrNotTimestamped         = errors.New("bundle has no timestamp token")
 )
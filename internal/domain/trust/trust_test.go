@@ -168,6 +168,36 @@ func TestHashChainEntry_Verify(t *testing.T) {
 	})
 }
 
+func TestExtendChain(t *testing.T) {
+	sourceID := "gateway-001"
+	links := []ChainLink{
+		{Event: &EventEvidence{EventID: uuid.New()}, EventHash: "eventHash1"},
+		{Event: &EventEvidence{EventID: uuid.New()}, EventHash: "eventHash2"},
+	}
+
+	t.Run("starts an empty chain at genesis", func(t *testing.T) {
+		entries := ExtendChain(nil, sourceID, links)
+		require.Len(t, entries, 2)
+		assert.Equal(t, int64(1), entries[0].SequenceNum)
+		assert.Empty(t, entries[0].PrevHash)
+		assert.Equal(t, int64(2), entries[1].SequenceNum)
+		assert.Equal(t, entries[0].ChainHash, entries[1].PrevHash)
+	})
+
+	t.Run("continues after the latest entry", func(t *testing.T) {
+		latest := NewHashChainEntry(uuid.New(), sourceID, 41, "eventHash0", "")
+		entries := ExtendChain(latest, sourceID, links)
+		require.Len(t, entries, 2)
+		for i, entry := range entries {
+			assert.Equal(t, links[i].Event.EventID, entry.EventID)
+			assert.Equal(t, int64(42+i), entry.SequenceNum)
+			assert.True(t, entry.Verify())
+		}
+		assert.Equal(t, latest.ChainHash, entries[0].PrevHash)
+		assert.Equal(t, entries[0].ChainHash, entries[1].PrevHash)
+	})
+}
+
 func TestComputeBatchHash(t *testing.T) {
 	// Use realistic SHA-256 format (64 hex characters) for test data
 	eventHashes := []string{
@@ -330,7 +360,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 		assert.NotEmpty(t, bundle.BundleHash)
 
 		// Verify should succeed
-		assert.True(t, bundle.VerifyIntegrity())
+		assert.True(t, bundle.VerifyIntegrity(nil))
 	})
 
 	t.Run("tampered event should fail verification", func(t *testing.T) {
@@ -354,7 +384,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 
 		// Tampering should fail verification
 		bundle.Events[0].ClientRecordID = "tampered"
-		assert.False(t, bundle.VerifyIntegrity())
+		assert.False(t, bundle.VerifyIntegrity(nil))
 	})
 
 	t.Run("tampered bundle hash should fail verification", func(t *testing.T) {
@@ -378,7 +408,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 
 		// Tamper with BundleHash directly
 		bundle.BundleHash = "tampered-hash-value"
-		assert.False(t, bundle.VerifyIntegrity())
+		assert.False(t, bundle.VerifyIntegrity(nil))
 	})
 
 	t.Run("tampered hash chain should fail verification", func(t *testing.T) {
@@ -414,7 +444,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 
 		// Tamper with HashChain
 		bundle.HashChain[0].ChainHash = "tampered"
-		assert.False(t, bundle.VerifyIntegrity())
+		assert.False(t, bundle.VerifyIntegrity(nil))
 	})
 
 	t.Run("tampered signature should fail verification", func(t *testing.T) {
@@ -451,7 +481,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 
 		// Tamper with Signature
 		bundle.Signatures[0].Signature = "tampered-signature"
-		assert.False(t, bundle.VerifyIntegrity())
+		assert.False(t, bundle.VerifyIntegrity(nil))
 	})
 
 	t.Run("non-finalized bundle should fail verification", func(t *testing.T) {
@@ -472,7 +502,7 @@ func TestEvidenceBundle_FinalizeAndVerify(t *testing.T) {
 
 		// Do NOT call Finalize() - BundleHash remains empty
 		assert.Empty(t, bundle.BundleHash)
-		assert.False(t, bundle.VerifyIntegrity())
+		assert.False(t, bundle.VerifyIntegrity(nil))
 	})
 }
 
@@ -2,6 +2,7 @@ package user
 
 import (
 	"errors"
+	"net/mail"
 	"regexp"
 	"strings"
 	"time"
@@ -45,6 +46,7 @@ type User struct {
 	Role         Role       `json:"role"`
 	Type         Type       `json:"type"`
 	OwnerUserID  *uuid.UUID `json:"ownerUserId,omitempty"`
+	Email        *string    `json:"email,omitempty"`
 	Status       Status     `json:"status"`
 	CreatedAt    time.Time  `json:"createdAt"`
 	UpdatedAt    time.Time  `json:"updatedAt"`
@@ -54,6 +56,20 @@ func (u *User) IsActive() bool {
 	return u.Status == StatusActive
 }
 
+// NormalizeEmail trims and lowercases an email address.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks that email is a single bare address.
+func ValidateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return errors.New("invalid email address")
+	}
+	return nil
+}
+
 func NormalizeUsername(username string) string {
 	return strings.ToLower(strings.TrimSpace(username))
 }
@@ -40,3 +40,14 @@ func TestValidatePassword(t *testing.T) {
 		t.Fatalf("expected error for containing username")
 	}
 }
+
+func TestValidateEmail(t *testing.T) {
+	if err := ValidateEmail("alice@example.com"); err != nil {
+		t.Fatalf("expected valid email, got %v", err)
+	}
+	for _, email := range []string{"", "alice", "Alice <alice@example.com>", "a@example.com, b@example.com"} {
+		if err := ValidateEmail(email); err == nil {
+			t.Fatalf("expected error for %q", email)
+		}
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"strings"
 )
 
-// StaticKeyStore is a simple in-memory keystore.
+// StaticKeyStore is a simple in-memory keystore of shared secrets, used for
+// webhook signing and legacy HMAC-SHA256 batch signatures. Public keys of
+// asymmetric batch signatures live in the trust.KeyRegistry.
 type StaticKeyStore struct {
 	keys          map[string][]byte
 	defaultKeyID  string
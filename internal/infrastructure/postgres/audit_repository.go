@@ -2,6 +2,8 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -10,6 +12,12 @@ import (
 	"github.com/execution-hub/execution-hub/internal/domain/audit"
 )
 
+// auditColumns are scanned by scanAudit. Entries written before the audit
+// chain existed have no chain fields.
+const auditColumns = `id, audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at, COALESCE(sequence_num, 0), COALESCE(prev_hash, ''), COALESCE(entry_hash, '')`
+
+const selectAuditChainHeadSQL = `SELECT ` + auditColumns + ` FROM audit_logs WHERE sequence_num IS NOT NULL ORDER BY sequence_num DESC LIMIT 1`
+
 // AuditRepository implements audit.Repository.
 type AuditRepository struct {
 	pool *pgxpool.Pool
@@ -19,25 +27,53 @@ func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
 	return &AuditRepository{pool: pool}
 }
 
+// Create holds a transaction-scoped advisory lock while it reads the chain
+// head and inserts the entry linked to it, so the audit chain stays linear
+// across server instances.
 func (r *AuditRepository) Create(ctx context.Context, entry *audit.AuditLog) error {
-	_, err := r.pool.Exec(ctx, `
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('audit_chain', 0))`); err != nil {
+		return err
+	}
+	if err := appendAuditChain(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// appendAuditChain links entry to the chain head and inserts it. The caller
+// holds the audit chain lock.
+func appendAuditChain(ctx context.Context, tx pgx.Tx, entry *audit.AuditLog) error {
+	head, err := scanAudit(tx.QueryRow(ctx, selectAuditChainHeadSQL))
+	if err != nil {
+		return err
+	}
+	if err := entry.LinkTo(head); err != nil {
+		return err
+	}
+	return tx.QueryRow(ctx, `
 		INSERT INTO audit_logs
-		(audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22)
-	`, entry.AuditID, entry.EntityType, entry.EntityID, entry.Action, entry.Actor, entry.ActorRoles, entry.ActorIP, entry.UserAgent, entry.OldValues, entry.NewValues, entry.Diff, entry.Reason, entry.RiskLevel, entry.Tags, entry.Signature, entry.TraceID, entry.SessionID, entry.RequestMethod, entry.RequestPath, entry.ResponseStatus, entry.DurationMs, entry.CreatedAt)
-	return err
+		(audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at, sequence_num, prev_hash, entry_hash)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25)
+		RETURNING id
+	`, entry.AuditID, entry.EntityType, entry.EntityID, entry.Action, entry.Actor, entry.ActorRoles, entry.ActorIP, entry.UserAgent, entry.OldValues, entry.NewValues, entry.Diff, entry.Reason, entry.RiskLevel, entry.Tags, entry.Signature, entry.TraceID, entry.SessionID, entry.RequestMethod, entry.RequestPath, entry.ResponseStatus, entry.DurationMs, entry.CreatedAt, entry.SequenceNum, entry.PrevHash, entry.EntryHash).Scan(&entry.ID)
 }
 
 func (r *AuditRepository) GetByID(ctx context.Context, auditID uuid.UUID) (*audit.AuditLog, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at
+		SELECT `+auditColumns+`
 		FROM audit_logs WHERE audit_id=$1
 	`, auditID)
 	return scanAudit(row)
 }
 
 func (r *AuditRepository) Query(ctx context.Context, filter audit.QueryFilter, cursor *audit.Cursor, limit int) ([]*audit.AuditLog, *audit.Cursor, error) {
-	query := `SELECT id, audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at FROM audit_logs`
+	query := `SELECT ` + auditColumns + ` FROM audit_logs`
 	args := []interface{}{}
 	idx := 1
 	if filter.EntityType != nil {
@@ -123,7 +159,7 @@ func (r *AuditRepository) Query(ctx context.Context, filter audit.QueryFilter, c
 
 func (r *AuditRepository) GetByEntityID(ctx context.Context, entityType audit.EntityType, entityID string) ([]*audit.AuditLog, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at
+		SELECT `+auditColumns+`
 		FROM audit_logs WHERE entity_type=$1 AND entity_id=$2 ORDER BY created_at DESC
 	`, entityType, entityID)
 	if err != nil {
@@ -220,9 +256,461 @@ func (r *AuditRepository) VerifySignature(ctx context.Context, auditID uuid.UUID
 	return audit.VerifyAuditLogSignature(log, key)
 }
 
+func (r *AuditRepository) GetChainHead(ctx context.Context) (*audit.AuditLog, error) {
+	return scanAudit(r.pool.QueryRow(ctx, selectAuditChainHeadSQL))
+}
+
+// ListChain merges the chain fields of archived entries into the rows of
+// the range; a row restored from an archive takes its entry's place.
+func (r *AuditRepository) ListChain(ctx context.Context, fromSeq, toSeq int64, limit int) ([]*audit.AuditLog, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_logs WHERE sequence_num >= $1 AND sequence_num <= $2
+		ORDER BY sequence_num ASC LIMIT $3
+	`, fromSeq, toSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logs []*audit.AuditLog
+	for rows.Next() {
+		log, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stubRows, err := r.pool.Query(ctx, `
+		SELECT e.audit_id, e.sequence_num, e.prev_hash, e.entry_hash, e.archive_id
+		FROM audit_archive_entries e
+		WHERE e.sequence_num >= $1 AND e.sequence_num <= $2
+		AND NOT EXISTS (SELECT 1 FROM audit_logs l WHERE l.sequence_num = e.sequence_num)
+		ORDER BY e.sequence_num ASC LIMIT $3
+	`, fromSeq, toSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer stubRows.Close()
+	var stubs []*audit.AuditLog
+	for stubRows.Next() {
+		var log audit.AuditLog
+		var archiveID int64
+		if err := stubRows.Scan(&log.AuditID, &log.SequenceNum, &log.PrevHash, &log.EntryHash, &archiveID); err != nil {
+			return nil, err
+		}
+		log.ArchiveID = &archiveID
+		stubs = append(stubs, &log)
+	}
+	if err := stubRows.Err(); err != nil {
+		return nil, err
+	}
+	if len(stubs) == 0 {
+		return logs, nil
+	}
+
+	merged := make([]*audit.AuditLog, 0, len(logs)+len(stubs))
+	for len(merged) < limit && (len(logs) > 0 || len(stubs) > 0) {
+		if len(stubs) == 0 || (len(logs) > 0 && logs[0].SequenceNum <= stubs[0].SequenceNum) {
+			merged, logs = append(merged, logs[0]), logs[1:]
+		} else {
+			merged, stubs = append(merged, stubs[0]), stubs[1:]
+		}
+	}
+	return merged, nil
+}
+
+func (r *AuditRepository) CreateCheckpoint(ctx context.Context, checkpoint *audit.Checkpoint) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO audit_checkpoints (sequence_num, entry_hash, signature_alg, signature, created_at, timestamp_token)
+		VALUES ($1,$2,$3,$4,$5,$6)
+		RETURNING id
+	`, checkpoint.SequenceNum, checkpoint.EntryHash, checkpoint.SignatureAlg, checkpoint.Signature, checkpoint.CreatedAt, checkpoint.TimestampToken).Scan(&checkpoint.ID)
+}
+
+func (r *AuditRepository) GetCheckpoint(ctx context.Context, id int64) (*audit.Checkpoint, error) {
+	c, err := scanAuditCheckpoint(r.pool.QueryRow(ctx, `
+		SELECT id, sequence_num, entry_hash, signature_alg, signature, created_at, timestamp_token
+		FROM audit_checkpoints WHERE id=$1
+	`, id))
+	if err == pgx.ErrNoRows {
+		return nil, audit.ErrCheckpointNotFound
+	}
+	return c, err
+}
+
+func (r *AuditRepository) ListCheckpoints(ctx context.Context) ([]*audit.Checkpoint, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, sequence_num, entry_hash, signature_alg, signature, created_at, timestamp_token
+		FROM audit_checkpoints ORDER BY sequence_num ASC, id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var checkpoints []*audit.Checkpoint
+	for rows.Next() {
+		c, err := scanAuditCheckpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, c)
+	}
+	return checkpoints, rows.Err()
+}
+
+// ListAfter pages by id: entries are inserted under the audit chain lock,
+// so ids are assigned in commit order and a cursor never skips an entry
+// committed after it was read.
+func (r *AuditRepository) ListAfter(ctx context.Context, cursor *audit.Cursor, limit int) ([]*audit.AuditLog, error) {
+	var afterID int64
+	if cursor != nil {
+		afterID = cursor.ID
+	}
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_logs WHERE id > $1 ORDER BY id ASC LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logs []*audit.AuditLog
+	for rows.Next() {
+		log, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+func (r *AuditRepository) GetSinkCursor(ctx context.Context, sink string) (*audit.Cursor, error) {
+	var c audit.Cursor
+	err := r.pool.QueryRow(ctx, `SELECT cursor_created_at, cursor_id FROM audit_sink_cursors WHERE sink_name=$1`, sink).Scan(&c.CreatedAt, &c.ID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *AuditRepository) SaveSinkCursor(ctx context.Context, sink string, cursor *audit.Cursor) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO audit_sink_cursors (sink_name, cursor_created_at, cursor_id, updated_at)
+		VALUES ($1,$2,$3,NOW())
+		ON CONFLICT (sink_name) DO UPDATE SET cursor_created_at=$2, cursor_id=$3, updated_at=NOW()
+	`, sink, cursor.CreatedAt, cursor.ID)
+	return err
+}
+
+func (r *AuditRepository) ListRetentionPolicies(ctx context.Context) ([]*audit.RetentionPolicy, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, entity_type, risk_level, retention_days, created_at, updated_at
+		FROM audit_retention_policies ORDER BY id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var policies []*audit.RetentionPolicy
+	for rows.Next() {
+		var p audit.RetentionPolicy
+		if err := rows.Scan(&p.ID, &p.EntityType, &p.RiskLevel, &p.RetentionDays, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, &p)
+	}
+	return policies, rows.Err()
+}
+
+func (r *AuditRepository) SaveRetentionPolicy(ctx context.Context, policy *audit.RetentionPolicy) error {
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO audit_retention_policies (entity_type, risk_level, retention_days, created_at, updated_at)
+		VALUES ($1,$2,$3,NOW(),NOW())
+		ON CONFLICT ((COALESCE(entity_type, '')), (COALESCE(risk_level, '')))
+		DO UPDATE SET retention_days=EXCLUDED.retention_days, updated_at=NOW()
+		RETURNING id, created_at, updated_at
+	`, policy.EntityType, policy.RiskLevel, policy.RetentionDays).Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+}
+
+func (r *AuditRepository) DeleteRetentionPolicy(ctx context.Context, id int64) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM audit_retention_policies WHERE id=$1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return audit.ErrRetentionPolicyNotFound
+	}
+	return nil
+}
+
+func (r *AuditRepository) CreateLegalHold(ctx context.Context, hold *audit.LegalHold) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO audit_legal_holds (id, entity_type, entity_id, trace_id, reason, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+	`, hold.ID, hold.EntityType, hold.EntityID, hold.TraceID, hold.Reason, hold.CreatedBy, hold.CreatedAt)
+	return err
+}
+
+func (r *AuditRepository) ReleaseLegalHold(ctx context.Context, id uuid.UUID, releasedBy string, releasedAt time.Time) (*audit.LegalHold, error) {
+	hold, err := scanLegalHold(r.pool.QueryRow(ctx, `
+		UPDATE audit_legal_holds SET released_at=$2, released_by=$3
+		WHERE id=$1 AND released_at IS NULL
+		RETURNING `+legalHoldColumns+`
+	`, id, releasedAt, releasedBy))
+	if err == pgx.ErrNoRows {
+		return nil, audit.ErrLegalHoldNotFound
+	}
+	return hold, err
+}
+
+func (r *AuditRepository) ListLegalHolds(ctx context.Context, includeReleased bool) ([]*audit.LegalHold, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+legalHoldColumns+`
+		FROM audit_legal_holds WHERE $1 OR released_at IS NULL
+		ORDER BY created_at DESC
+	`, includeReleased)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var holds []*audit.LegalHold
+	for rows.Next() {
+		hold, err := scanLegalHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+	return holds, rows.Err()
+}
+
+func (r *AuditRepository) ListExpiring(ctx context.Context, before time.Time, afterID int64, limit int) ([]*audit.AuditLog, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_logs WHERE created_at < $1 AND id > $2 AND restored_from IS NULL
+		ORDER BY id ASC LIMIT $3
+	`, before, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var logs []*audit.AuditLog
+	for rows.Next() {
+		log, err := scanAudit(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+// ArchiveLogs holds the audit chain lock, so restores cannot interleave,
+// and a share lock on the legal holds, so no hold is placed on the logs
+// between the check and the delete. The purge entry is appended in the same
+// transaction, so logs are never removed without a record of it.
+func (r *AuditRepository) ArchiveLogs(ctx context.Context, archive *audit.Archive, logs []*audit.AuditLog, purge *audit.AuditLog) error {
+	ids := make([]int64, 0, len(logs))
+	var seqs []int64
+	var auditIDs []string
+	var prevHashes, entryHashes []string
+	for _, log := range logs {
+		ids = append(ids, log.ID)
+		if log.SequenceNum > 0 {
+			seqs = append(seqs, log.SequenceNum)
+			auditIDs = append(auditIDs, log.AuditID.String())
+			prevHashes = append(prevHashes, log.PrevHash)
+			entryHashes = append(entryHashes, log.EntryHash)
+		}
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('audit_chain', 0))`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `LOCK TABLE audit_legal_holds IN SHARE MODE`); err != nil {
+		return err
+	}
+	var held bool
+	if err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM audit_logs l JOIN audit_legal_holds h ON h.released_at IS NULL
+			AND ((h.entity_type = l.entity_type AND h.entity_id = l.entity_id) OR h.trace_id = l.trace_id)
+			WHERE l.id = ANY($1)
+		)
+	`, ids).Scan(&held); err != nil {
+		return err
+	}
+	if held {
+		return audit.ErrLegalHoldConflict
+	}
+
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO audit_archives (name, entry_count, first_sequence, last_sequence, oldest_at, newest_at, sha256, signature_alg, signature, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
+		RETURNING id
+	`, archive.Name, archive.EntryCount, archive.FirstSequence, archive.LastSequence, archive.OldestAt, archive.NewestAt, archive.SHA256, archive.SignatureAlg, archive.Signature, archive.CreatedBy, archive.CreatedAt).Scan(&archive.ID); err != nil {
+		return err
+	}
+	if len(seqs) > 0 {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO audit_archive_entries (sequence_num, audit_id, prev_hash, entry_hash, archive_id)
+			SELECT s, a, p, e, $5 FROM unnest($1::BIGINT[], $2::UUID[], $3::TEXT[], $4::TEXT[]) AS t(s, a, p, e)
+		`, seqs, auditIDs, prevHashes, entryHashes, archive.ID); err != nil {
+			return err
+		}
+	}
+	tag, err := tx.Exec(ctx, `DELETE FROM audit_logs WHERE id = ANY($1) AND restored_from IS NULL`, ids)
+	if err != nil {
+		return err
+	}
+	if int(tag.RowsAffected()) != len(ids) {
+		return fmt.Errorf("archived %d audit logs but %d were deleted", len(ids), tag.RowsAffected())
+	}
+	if err := appendAuditChain(ctx, tx, purge); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *AuditRepository) GetArchive(ctx context.Context, id int64) (*audit.Archive, error) {
+	a, err := scanAuditArchive(r.pool.QueryRow(ctx, `SELECT `+archiveColumns+` FROM audit_archives WHERE id=$1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, audit.ErrArchiveNotFound
+	}
+	return a, err
+}
+
+func (r *AuditRepository) ListArchives(ctx context.Context) ([]*audit.Archive, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+archiveColumns+` FROM audit_archives ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var archives []*audit.Archive
+	for rows.Next() {
+		a, err := scanAuditArchive(rows)
+		if err != nil {
+			return nil, err
+		}
+		archives = append(archives, a)
+	}
+	return archives, rows.Err()
+}
+
+func (r *AuditRepository) ListArchiveChain(ctx context.Context, id int64) (map[int64]string, error) {
+	rows, err := r.pool.Query(ctx, `SELECT sequence_num, entry_hash FROM audit_archive_entries WHERE archive_id=$1`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	chain := make(map[int64]string)
+	for rows.Next() {
+		var seq int64
+		var hash string
+		if err := rows.Scan(&seq, &hash); err != nil {
+			return nil, err
+		}
+		chain[seq] = hash
+	}
+	return chain, rows.Err()
+}
+
+// RestoreArchive inserts the logs with their original ids, so sink cursors
+// do not deliver them again
+func (r *AuditRepository) RestoreArchive(ctx context.Context, id int64, logs []*audit.AuditLog) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('audit_chain', 0))`); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx, `UPDATE audit_archives SET restored_at=NOW() WHERE id=$1 AND restored_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("audit archive %d is missing or already restored", id)
+	}
+	for _, e := range logs {
+		var seq *int64
+		if e.SequenceNum > 0 {
+			seq = &e.SequenceNum
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO audit_logs
+			(id, audit_id, entity_type, entity_id, action, actor, actor_roles, actor_ip, user_agent, old_values, new_values, diff, reason, risk_level, tags, signature, trace_id, session_id, request_method, request_path, response_status, duration_ms, created_at, sequence_num, prev_hash, entry_hash, restored_from)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,NULLIF($25,''),NULLIF($26,''),$27)
+		`, e.ID, e.AuditID, e.EntityType, e.EntityID, e.Action, e.Actor, e.ActorRoles, e.ActorIP, e.UserAgent, e.OldValues, e.NewValues, e.Diff, e.Reason, e.RiskLevel, e.Tags, e.Signature, e.TraceID, e.SessionID, e.RequestMethod, e.RequestPath, e.ResponseStatus, e.DurationMs, e.CreatedAt, seq, e.PrevHash, e.EntryHash, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *AuditRepository) UnrestoreArchive(ctx context.Context, id int64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM audit_logs WHERE restored_from=$1`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE audit_archives SET restored_at=NULL WHERE id=$1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+const legalHoldColumns = `id, entity_type, entity_id, trace_id, reason, created_by, created_at, released_at, COALESCE(released_by, '')`
+
+func scanLegalHold(row pgx.Row) (*audit.LegalHold, error) {
+	var h audit.LegalHold
+	if err := row.Scan(&h.ID, &h.EntityType, &h.EntityID, &h.TraceID, &h.Reason, &h.CreatedBy, &h.CreatedAt, &h.ReleasedAt, &h.ReleasedBy); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+const archiveColumns = `id, name, entry_count, first_sequence, last_sequence, oldest_at, newest_at, sha256, signature_alg, signature, created_by, created_at, restored_at`
+
+func scanAuditArchive(row pgx.Row) (*audit.Archive, error) {
+	var a audit.Archive
+	if err := row.Scan(&a.ID, &a.Name, &a.EntryCount, &a.FirstSequence, &a.LastSequence, &a.OldestAt, &a.NewestAt, &a.SHA256, &a.SignatureAlg, &a.Signature, &a.CreatedBy, &a.CreatedAt, &a.RestoredAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func scanAuditCheckpoint(row pgx.Row) (*audit.Checkpoint, error) {
+	var c audit.Checkpoint
+	if err := row.Scan(&c.ID, &c.SequenceNum, &c.EntryHash, &c.SignatureAlg, &c.Signature, &c.CreatedAt, &c.TimestampToken); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
 func scanAudit(row pgx.Row) (*audit.AuditLog, error) {
 	var log audit.AuditLog
-	if err := row.Scan(&log.ID, &log.AuditID, &log.EntityType, &log.EntityID, &log.Action, &log.Actor, &log.ActorRoles, &log.ActorIP, &log.UserAgent, &log.OldValues, &log.NewValues, &log.Diff, &log.Reason, &log.RiskLevel, &log.Tags, &log.Signature, &log.TraceID, &log.SessionID, &log.RequestMethod, &log.RequestPath, &log.ResponseStatus, &log.DurationMs, &log.CreatedAt); err != nil {
+	if err := row.Scan(&log.ID, &log.AuditID, &log.EntityType, &log.EntityID, &log.Action, &log.Actor, &log.ActorRoles, &log.ActorIP, &log.UserAgent, &log.OldValues, &log.NewValues, &log.Diff, &log.Reason, &log.RiskLevel, &log.Tags, &log.Signature, &log.TraceID, &log.SessionID, &log.RequestMethod, &log.RequestPath, &log.ResponseStatus, &log.DurationMs, &log.CreatedAt, &log.SequenceNum, &log.PrevHash, &log.EntryHash); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -111,15 +111,15 @@ func (r *CollabRepository) ListParticipants(ctx context.Context, sessionID uuid.
 func (r *CollabRepository) CreateStep(ctx context.Context, step *collab.Step) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO collab_steps
-		(step_id, session_id, step_key, name, status, required_capabilities, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
-	`, step.StepID, step.SessionID, step.StepKey, step.Name, step.Status, step.RequiredCapabilities, step.DependsOn, step.LeaseTTLSeconds, step.ConsensusPolicy, step.CreatedAt, step.UpdatedAt, step.ResolvedAt)
+		(step_id, session_id, step_key, name, status, required_capabilities, min_trust_score, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	`, step.StepID, step.SessionID, step.StepKey, step.Name, step.Status, step.RequiredCapabilities, step.MinTrustScore, step.DependsOn, step.LeaseTTLSeconds, step.ConsensusPolicy, step.CreatedAt, step.UpdatedAt, step.ResolvedAt)
 	return err
 }
 
 func (r *CollabRepository) GetStepByID(ctx context.Context, stepID uuid.UUID) (*collab.Step, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, step_id, session_id, step_key, name, status, required_capabilities, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at
+		SELECT id, step_id, session_id, step_key, name, status, required_capabilities, min_trust_score, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at
 		FROM collab_steps
 		WHERE step_id=$1
 	`, stepID)
@@ -128,7 +128,7 @@ func (r *CollabRepository) GetStepByID(ctx context.Context, stepID uuid.UUID) (*
 
 func (r *CollabRepository) ListStepsBySession(ctx context.Context, sessionID uuid.UUID) ([]*collab.Step, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, step_id, session_id, step_key, name, status, required_capabilities, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at
+		SELECT id, step_id, session_id, step_key, name, status, required_capabilities, min_trust_score, depends_on, lease_ttl_seconds, consensus_policy, created_at, updated_at, resolved_at
 		FROM collab_steps
 		WHERE session_id=$1
 		ORDER BY created_at ASC, step_key ASC
@@ -414,7 +414,7 @@ func scanCollabParticipant(row pgx.Row) (*collab.Participant, error) {
 func scanCollabStep(row pgx.Row) (*collab.Step, error) {
 	var s collab.Step
 	var consensusPolicy json.RawMessage
-	if err := row.Scan(&s.ID, &s.StepID, &s.SessionID, &s.StepKey, &s.Name, &s.Status, &s.RequiredCapabilities, &s.DependsOn, &s.LeaseTTLSeconds, &consensusPolicy, &s.CreatedAt, &s.UpdatedAt, &s.ResolvedAt); err != nil {
+	if err := row.Scan(&s.ID, &s.StepID, &s.SessionID, &s.StepKey, &s.Name, &s.Status, &s.RequiredCapabilities, &s.MinTrustScore, &s.DependsOn, &s.LeaseTTLSeconds, &consensusPolicy, &s.CreatedAt, &s.UpdatedAt, &s.ResolvedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// NotificationPreferenceRepository implements notification.PreferenceRepository.
+type NotificationPreferenceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewNotificationPreferenceRepository(pool *pgxpool.Pool) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{pool: pool}
+}
+
+func (r *NotificationPreferenceRepository) Get(ctx context.Context, targetType notification.TargetType, targetID string) (*notification.Preference, error) {
+	row := r.pool.QueryRow(ctx, `
+		SELECT id, target_type, target_id, channels, min_priority, quiet_hours, mutes, throttle, created_at, updated_at
+		FROM notification_preferences WHERE target_type=$1 AND target_id=$2
+	`, targetType, targetID)
+	return scanPreference(row)
+}
+
+func (r *NotificationPreferenceRepository) Upsert(ctx context.Context, p *notification.Preference) error {
+	channels, err := marshalNullable(&p.Channels)
+	if err != nil {
+		return err
+	}
+	minPriority, err := marshalNullable(&p.MinPriority)
+	if err != nil {
+		return err
+	}
+	quietHours, err := marshalNullable(p.QuietHours)
+	if err != nil {
+		return err
+	}
+	mutes, err := marshalNullable(&p.Mutes)
+	if err != nil {
+		return err
+	}
+	throttle, err := marshalNullable(p.Throttle)
+	if err != nil {
+		return err
+	}
+	return r.pool.QueryRow(ctx, `
+		INSERT INTO notification_preferences (target_type, target_id, channels, min_priority, quiet_hours, mutes, throttle, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		ON CONFLICT (target_type, target_id) DO UPDATE
+		SET channels=EXCLUDED.channels, min_priority=EXCLUDED.min_priority, quiet_hours=EXCLUDED.quiet_hours,
+			mutes=EXCLUDED.mutes, throttle=EXCLUDED.throttle, updated_at=EXCLUDED.updated_at
+		RETURNING id, created_at
+	`, p.TargetType, p.TargetID, channels, minPriority, quietHours, mutes, throttle, p.CreatedAt, p.UpdatedAt).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *NotificationPreferenceRepository) Delete(ctx context.Context, targetType notification.TargetType, targetID string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM notification_preferences WHERE target_type=$1 AND target_id=$2`, targetType, targetID)
+	return err
+}
+
+func scanPreference(row pgx.Row) (*notification.Preference, error) {
+	var p notification.Preference
+	var channels, minPriority, quietHours, mutes, throttle []byte
+	if err := row.Scan(&p.ID, &p.TargetType, &p.TargetID, &channels, &minPriority, &quietHours, &mutes, &throttle, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	columns := []struct {
+		raw []byte
+		dst interface{}
+	}{
+		{channels, &p.Channels},
+		{minPriority, &p.MinPriority},
+		{quietHours, &p.QuietHours},
+		{mutes, &p.Mutes},
+		{throttle, &p.Throttle},
+	}
+	for _, c := range columns {
+		if len(c.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(c.raw, c.dst); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+// marshalNullable encodes v as JSON, or NULL when v is a nil pointer.
+func marshalNullable[T any](v *T) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -23,15 +23,15 @@ func NewNotificationRepository(pool *pgxpool.Pool) *NotificationRepository {
 func (r *NotificationRepository) Create(ctx context.Context, n *notification.Notification) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO notifications
-		(notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
-	`, n.NotificationID, n.ActionID, n.DedupeKey, n.Channel, n.Priority, n.Title, n.Body, n.Payload, n.Status, n.TargetUserID, n.TargetGroup, n.RetryCount, n.MaxRetries, n.LastError, n.ExpiresAt, n.CreatedAt, n.SentAt, n.DeliveredAt, n.FailedAt, n.TraceID)
+		(notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29)
+	`, n.NotificationID, n.ActionID, n.RuleID, n.WorkflowID, n.DedupeKey, n.Channel, n.RoutedFrom, n.Priority, n.Title, n.Body, n.Payload, n.Status, n.TargetUserID, n.TargetGroup, n.RetryCount, n.MaxRetries, n.LastError, n.ExpiresAt, n.CreatedAt, n.SentAt, n.DeliveredAt, n.FailedAt, n.NextAttemptAt, n.DeadLetteredAt, n.DeferReason, n.ThrottleKey, n.HeldUntil, n.DigestID, n.TraceID)
 	return err
 }
 
 func (r *NotificationRepository) GetByID(ctx context.Context, notificationID uuid.UUID) (*notification.Notification, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
 		FROM notifications WHERE notification_id=$1
 	`, notificationID)
 	return scanNotification(row)
@@ -39,7 +39,7 @@ func (r *NotificationRepository) GetByID(ctx context.Context, notificationID uui
 
 func (r *NotificationRepository) GetByActionID(ctx context.Context, actionID uuid.UUID) ([]*notification.Notification, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
 		FROM notifications WHERE action_id=$1 ORDER BY created_at ASC
 	`, actionID)
 	if err != nil {
@@ -59,7 +59,7 @@ func (r *NotificationRepository) GetByActionID(ctx context.Context, actionID uui
 
 func (r *NotificationRepository) FindByDedupeKey(ctx context.Context, dedupeKey string, since time.Time) (*notification.Notification, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
 		FROM notifications WHERE dedupe_key=$1 AND created_at >= $2
 		ORDER BY created_at DESC LIMIT 1
 	`, dedupeKey, since)
@@ -67,7 +67,7 @@ func (r *NotificationRepository) FindByDedupeKey(ctx context.Context, dedupeKey
 }
 
 func (r *NotificationRepository) List(ctx context.Context, filter notification.Filter, limit, offset int) ([]*notification.Notification, error) {
-	query := `SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id FROM notifications`
+	query := `SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id FROM notifications`
 	args := []interface{}{}
 	idx := 1
 	if filter.ActionID != nil {
@@ -132,9 +132,9 @@ func (r *NotificationRepository) List(ctx context.Context, filter notification.F
 func (r *NotificationRepository) Update(ctx context.Context, n *notification.Notification) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE notifications
-		SET dedupe_key=$1, channel=$2, priority=$3, title=$4, body=$5, payload=$6, status=$7, target_user_id=$8, target_group=$9, retry_count=$10, max_retries=$11, last_error=$12, expires_at=$13, sent_at=$14, delivered_at=$15, failed_at=$16, trace_id=$17
-		WHERE notification_id=$18
-	`, n.DedupeKey, n.Channel, n.Priority, n.Title, n.Body, n.Payload, n.Status, n.TargetUserID, n.TargetGroup, n.RetryCount, n.MaxRetries, n.LastError, n.ExpiresAt, n.SentAt, n.DeliveredAt, n.FailedAt, n.TraceID, n.NotificationID)
+		SET dedupe_key=$1, channel=$2, routed_from=$3, priority=$4, title=$5, body=$6, payload=$7, status=$8, target_user_id=$9, target_group=$10, retry_count=$11, max_retries=$12, last_error=$13, expires_at=$14, sent_at=$15, delivered_at=$16, failed_at=$17, next_attempt_at=$18, dead_lettered_at=$19, defer_reason=$20, throttle_key=$21, held_until=$22, digest_id=$23, trace_id=$24
+		WHERE notification_id=$25
+	`, n.DedupeKey, n.Channel, n.RoutedFrom, n.Priority, n.Title, n.Body, n.Payload, n.Status, n.TargetUserID, n.TargetGroup, n.RetryCount, n.MaxRetries, n.LastError, n.ExpiresAt, n.SentAt, n.DeliveredAt, n.FailedAt, n.NextAttemptAt, n.DeadLetteredAt, n.DeferReason, n.ThrottleKey, n.HeldUntil, n.DigestID, n.TraceID, n.NotificationID)
 	return err
 }
 
@@ -146,15 +146,15 @@ func (r *NotificationRepository) UpdateStatus(ctx context.Context, notificationI
 func (r *NotificationRepository) RecordAttempt(ctx context.Context, attempt *notification.DeliveryAttempt) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO notification_attempts
-		(notification_id, attempt_number, status, attempted_at, response_code, response_body, error_message, duration_ms)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-	`, attempt.NotificationID, attempt.AttemptNumber, attempt.Status, attempt.AttemptedAt, attempt.ResponseCode, attempt.ResponseBody, attempt.ErrorMessage, attempt.DurationMs)
+		(notification_id, attempt_number, status, attempted_at, response_code, response_body, error_message, duration_ms, decision, permanent, backoff_ms, next_attempt_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+	`, attempt.NotificationID, attempt.AttemptNumber, attempt.Status, attempt.AttemptedAt, attempt.ResponseCode, attempt.ResponseBody, attempt.ErrorMessage, attempt.DurationMs, attempt.Decision, attempt.Permanent, attempt.BackoffMs, attempt.NextAttemptAt)
 	return err
 }
 
 func (r *NotificationRepository) GetAttempts(ctx context.Context, notificationID uuid.UUID) ([]*notification.DeliveryAttempt, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, notification_id, attempt_number, status, attempted_at, response_code, response_body, error_message, duration_ms
+		SELECT id, notification_id, attempt_number, status, attempted_at, response_code, response_body, error_message, duration_ms, decision, permanent, backoff_ms, next_attempt_at
 		FROM notification_attempts WHERE notification_id=$1 ORDER BY attempted_at ASC
 	`, notificationID)
 	if err != nil {
@@ -164,7 +164,7 @@ func (r *NotificationRepository) GetAttempts(ctx context.Context, notificationID
 	var out []*notification.DeliveryAttempt
 	for rows.Next() {
 		var a notification.DeliveryAttempt
-		if err := rows.Scan(&a.ID, &a.NotificationID, &a.AttemptNumber, &a.Status, &a.AttemptedAt, &a.ResponseCode, &a.ResponseBody, &a.ErrorMessage, &a.DurationMs); err != nil {
+		if err := rows.Scan(&a.ID, &a.NotificationID, &a.AttemptNumber, &a.Status, &a.AttemptedAt, &a.ResponseCode, &a.ResponseBody, &a.ErrorMessage, &a.DurationMs, &a.Decision, &a.Permanent, &a.BackoffMs, &a.NextAttemptAt); err != nil {
 			return nil, err
 		}
 		out = append(out, &a)
@@ -183,8 +183,9 @@ func (r *NotificationRepository) SaveAttemptToDLQ(ctx context.Context, attempt *
 
 func (r *NotificationRepository) ListPendingNotifications(ctx context.Context, limit int) ([]*notification.Notification, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id
-		FROM notifications WHERE status='PENDING' ORDER BY created_at ASC LIMIT $1
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
+		FROM notifications WHERE status='PENDING' AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+		ORDER BY created_at ASC LIMIT $1
 	`, limit)
 	if err != nil {
 		return nil, err
@@ -203,10 +204,11 @@ func (r *NotificationRepository) ListPendingNotifications(ctx context.Context, l
 
 func (r *NotificationRepository) ListRetryableNotifications(ctx context.Context, limit int) ([]*notification.Notification, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, notification_id, action_id, dedupe_key, channel, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, trace_id
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
 		FROM notifications
 		WHERE status='FAILED' AND retry_count < max_retries AND (expires_at IS NULL OR expires_at > NOW())
-		ORDER BY created_at ASC LIMIT $1
+		  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+		ORDER BY next_attempt_at ASC NULLS FIRST, created_at ASC LIMIT $1
 	`, limit)
 	if err != nil {
 		return nil, err
@@ -227,7 +229,7 @@ func (r *NotificationRepository) ExpireNotifications(ctx context.Context) (int64
 	res, err := r.pool.Exec(ctx, `
 		UPDATE notifications
 		SET status='EXPIRED'
-		WHERE status NOT IN ('DELIVERED','EXPIRED') AND expires_at IS NOT NULL AND expires_at < NOW()
+		WHERE status NOT IN ('DELIVERED','EXPIRED','DEAD_LETTER','DISCARDED','DIGESTED','SUPPRESSED') AND expires_at IS NOT NULL AND expires_at < NOW()
 	`)
 	if err != nil {
 		return 0, err
@@ -235,10 +237,64 @@ func (r *NotificationRepository) ExpireNotifications(ctx context.Context) (int64
 	return res.RowsAffected(), nil
 }
 
+// CountByThrottleKey counts notifications of throttleKey created since that
+// were not held or suppressed by throttling.
+func (r *NotificationRepository) CountByThrottleKey(ctx context.Context, throttleKey string, since time.Time) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications
+		WHERE throttle_key=$1 AND created_at >= $2 AND status NOT IN ('HELD','DIGESTED','SUPPRESSED')
+	`, throttleKey, since).Scan(&count)
+	return count, err
+}
+
+func (r *NotificationRepository) ListHeld(ctx context.Context, throttleKey string) ([]*notification.Notification, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
+		FROM notifications WHERE throttle_key=$1 AND status='HELD' ORDER BY created_at ASC
+	`, throttleKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*notification.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// ListDueHeld lists held notifications whose digest window closed before
+// the given time, grouped by throttle key.
+func (r *NotificationRepository) ListDueHeld(ctx context.Context, before time.Time, limit int) ([]*notification.Notification, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, notification_id, action_id, rule_id, workflow_id, dedupe_key, channel, routed_from, priority, title, body, payload, status, target_user_id, target_group, retry_count, max_retries, last_error, expires_at, created_at, sent_at, delivered_at, failed_at, next_attempt_at, dead_lettered_at, defer_reason, throttle_key, held_until, digest_id, trace_id
+		FROM notifications WHERE status='HELD' AND held_until <= $1
+		ORDER BY throttle_key, created_at ASC LIMIT $2
+	`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*notification.Notification
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
 func scanNotification(row pgx.Row) (*notification.Notification, error) {
 	var n notification.Notification
 	var payload []byte
-	if err := row.Scan(&n.ID, &n.NotificationID, &n.ActionID, &n.DedupeKey, &n.Channel, &n.Priority, &n.Title, &n.Body, &payload, &n.Status, &n.TargetUserID, &n.TargetGroup, &n.RetryCount, &n.MaxRetries, &n.LastError, &n.ExpiresAt, &n.CreatedAt, &n.SentAt, &n.DeliveredAt, &n.FailedAt, &n.TraceID); err != nil {
+	if err := row.Scan(&n.ID, &n.NotificationID, &n.ActionID, &n.RuleID, &n.WorkflowID, &n.DedupeKey, &n.Channel, &n.RoutedFrom, &n.Priority, &n.Title, &n.Body, &payload, &n.Status, &n.TargetUserID, &n.TargetGroup, &n.RetryCount, &n.MaxRetries, &n.LastError, &n.ExpiresAt, &n.CreatedAt, &n.SentAt, &n.DeliveredAt, &n.FailedAt, &n.NextAttemptAt, &n.DeadLetteredAt, &n.DeferReason, &n.ThrottleKey, &n.HeldUntil, &n.DigestID, &n.TraceID); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
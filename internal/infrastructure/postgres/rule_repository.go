@@ -24,15 +24,15 @@ func NewRuleRepository(pool *pgxpool.Pool) *RuleRepository {
 func (r *RuleRepository) Create(ctx context.Context, rl *rule.Rule) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO rules
-		(rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
-	`, rl.RuleID, rl.Version, rl.Name, rl.Description, rl.RuleType, rl.Config, rl.ActionType, rl.ActionConfig, rl.ScopeFactoryID, rl.ScopeLineID, rl.EffectiveFrom, rl.EffectiveUntil, rl.Status, rl.CreatedAt, rl.CreatedBy, rl.UpdatedAt, rl.UpdatedBy)
+		(rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,COALESCE(NULLIF($12, ''), 'SKIP'),$13,$14,$15,$16,$17,$18,$19)
+	`, rl.RuleID, rl.Version, rl.Name, rl.Description, rl.RuleType, rl.Config, rl.ActionType, rl.ActionConfig, rl.ScopeFactoryID, rl.ScopeLineID, rl.MinTrustLevel, string(rl.LowTrustAction), rl.EffectiveFrom, rl.EffectiveUntil, rl.Status, rl.CreatedAt, rl.CreatedBy, rl.UpdatedAt, rl.UpdatedBy)
 	return err
 }
 
 func (r *RuleRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) (*rule.Rule, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
+		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
 		FROM rules WHERE rule_id=$1 ORDER BY version DESC LIMIT 1
 	`, ruleID)
 	return scanRule(row)
@@ -40,7 +40,7 @@ func (r *RuleRepository) GetByRuleID(ctx context.Context, ruleID uuid.UUID) (*ru
 
 func (r *RuleRepository) GetByRuleIDAndVersion(ctx context.Context, ruleID uuid.UUID, version int) (*rule.Rule, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
+		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
 		FROM rules WHERE rule_id=$1 AND version=$2
 	`, ruleID, version)
 	return scanRule(row)
@@ -48,14 +48,14 @@ func (r *RuleRepository) GetByRuleIDAndVersion(ctx context.Context, ruleID uuid.
 
 func (r *RuleRepository) GetByID(ctx context.Context, id int64) (*rule.Rule, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
+		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
 		FROM rules WHERE id=$1
 	`, id)
 	return scanRule(row)
 }
 
 func (r *RuleRepository) ListActiveRules(ctx context.Context, filter rule.Filter) ([]*rule.Rule, error) {
-	query := `SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by FROM rules`
+	query := `SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by FROM rules`
 	args := []interface{}{}
 	idx := 1
 	if filter.RuleType != nil {
@@ -100,7 +100,7 @@ func (r *RuleRepository) ListActiveRules(ctx context.Context, filter rule.Filter
 
 func (r *RuleRepository) ListVersions(ctx context.Context, ruleID uuid.UUID) ([]*rule.Rule, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
+		SELECT id, rule_id, version, name, description, rule_type, config, action_type, action_config, scope_factory_id, scope_line_id, min_trust_level, low_trust_action, effective_from, effective_until, status, created_at, created_by, updated_at, updated_by
 		FROM rules WHERE rule_id=$1 ORDER BY version DESC
 	`, ruleID)
 	if err != nil {
@@ -126,17 +126,21 @@ func (r *RuleRepository) UpdateStatus(ctx context.Context, id int64, status rule
 }
 
 func (r *RuleRepository) CreateEvaluation(ctx context.Context, eval *rule.Evaluation) error {
+	lowTrust := eval.LowTrustEventIDs
+	if lowTrust == nil {
+		lowTrust = []uuid.UUID{}
+	}
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO rule_evaluations
-		(evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-	`, eval.EvaluationID, eval.RuleID, eval.RuleVersion, eval.RuleType, eval.Matched, eval.EvaluatedAt, eval.Evidence, eval.EventIDs, eval.TraceID)
+		(evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id, trust_status, low_trust_event_ids)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,NULLIF($10, ''),$11)
+	`, eval.EvaluationID, eval.RuleID, eval.RuleVersion, eval.RuleType, eval.Matched, eval.EvaluatedAt, eval.Evidence, eval.EventIDs, eval.TraceID, string(eval.TrustStatus), lowTrust)
 	return err
 }
 
 func (r *RuleRepository) GetEvaluationByID(ctx context.Context, evaluationID uuid.UUID) (*rule.Evaluation, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id
+		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id, trust_status, low_trust_event_ids
 		FROM rule_evaluations WHERE evaluation_id=$1
 	`, evaluationID)
 	return scanEvaluation(row)
@@ -144,7 +148,7 @@ func (r *RuleRepository) GetEvaluationByID(ctx context.Context, evaluationID uui
 
 func (r *RuleRepository) ListEvaluationsByRule(ctx context.Context, ruleID uuid.UUID, limit int) ([]*rule.Evaluation, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id
+		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id, trust_status, low_trust_event_ids
 		FROM rule_evaluations WHERE rule_id=$1 ORDER BY evaluated_at DESC LIMIT $2
 	`, ruleID, limit)
 	if err != nil {
@@ -164,7 +168,7 @@ func (r *RuleRepository) ListEvaluationsByRule(ctx context.Context, ruleID uuid.
 
 func (r *RuleRepository) ListMatchedEvaluations(ctx context.Context, since time.Time, limit int) ([]*rule.Evaluation, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id
+		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id, trust_status, low_trust_event_ids
 		FROM rule_evaluations WHERE matched=true AND evaluated_at >= $1 ORDER BY evaluated_at DESC LIMIT $2
 	`, since, limit)
 	if err != nil {
@@ -182,6 +186,37 @@ func (r *RuleRepository) ListMatchedEvaluations(ctx context.Context, since time.
 	return out, rows.Err()
 }
 
+func (r *RuleRepository) ListPendingEvaluations(ctx context.Context, eventIDs []uuid.UUID) ([]*rule.Evaluation, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, evaluation_id, rule_id, rule_version, rule_type, matched, evaluated_at, evidence, event_ids, trace_id, trust_status, low_trust_event_ids
+		FROM rule_evaluations WHERE trust_status='PENDING' AND low_trust_event_ids && $1::UUID[]
+		ORDER BY evaluated_at ASC
+	`, eventIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*rule.Evaluation
+	for rows.Next() {
+		eval, err := scanEvaluation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, eval)
+	}
+	return out, rows.Err()
+}
+
+func (r *RuleRepository) ResolveEvaluationTrust(ctx context.Context, evaluationID uuid.UUID, status rule.TrustStatus) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE rule_evaluations SET trust_status=$1 WHERE evaluation_id=$2 AND trust_status='PENDING'
+	`, status, evaluationID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func scanRule(row pgx.Row) (*rule.Rule, error) {
 	var rl rule.Rule
 	var desc *string
@@ -192,7 +227,7 @@ func scanRule(row pgx.Row) (*rule.Rule, error) {
 	var updatedBy *string
 	var cfg json.RawMessage
 	var actionCfg json.RawMessage
-	if err := row.Scan(&rl.ID, &rl.RuleID, &rl.Version, &rl.Name, &desc, &rl.RuleType, &cfg, &rl.ActionType, &actionCfg, &scopeFactory, &scopeLine, &rl.EffectiveFrom, &effectiveUntil, &rl.Status, &rl.CreatedAt, &createdBy, &rl.UpdatedAt, &updatedBy); err != nil {
+	if err := row.Scan(&rl.ID, &rl.RuleID, &rl.Version, &rl.Name, &desc, &rl.RuleType, &cfg, &rl.ActionType, &actionCfg, &scopeFactory, &scopeLine, &rl.MinTrustLevel, &rl.LowTrustAction, &rl.EffectiveFrom, &effectiveUntil, &rl.Status, &rl.CreatedAt, &createdBy, &rl.UpdatedAt, &updatedBy); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -216,7 +251,8 @@ func scanEvaluation(row pgx.Row) (*rule.Evaluation, error) {
 	var evidence json.RawMessage
 	var eventIDs []uuid.UUID
 	var traceID *string
-	if err := row.Scan(&eval.ID, &eval.EvaluationID, &eval.RuleID, &eval.RuleVersion, &eval.RuleType, &eval.Matched, &eval.EvaluatedAt, &evidence, &eventIDs, &traceID); err != nil {
+	var trustStatus *string
+	if err := row.Scan(&eval.ID, &eval.EvaluationID, &eval.RuleID, &eval.RuleVersion, &eval.RuleType, &eval.Matched, &eval.EvaluatedAt, &evidence, &eventIDs, &traceID, &trustStatus, &eval.LowTrustEventIDs); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -225,5 +261,8 @@ func scanEvaluation(row pgx.Row) (*rule.Evaluation, error) {
 	eval.Evidence = evidence
 	eval.EventIDs = eventIDs
 	eval.TraceID = traceID
+	if trustStatus != nil {
+		eval.TrustStatus = rule.TrustStatus(*trustStatus)
+	}
 	return &eval, nil
 }
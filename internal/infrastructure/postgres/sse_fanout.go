@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/rs/zerolog"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+	"github.com/execution-hub/execution-hub/internal/infrastructure/sse"
+)
+
+const (
+	sseNotifyChannel = "sse_events"
+	// sseAppendLockID serialises appends so that sequence order matches
+	// commit order and listeners never skip a late-committing event
+	sseAppendLockID = 0x5e5e0001
+	sseBatchSize    = 500
+)
+
+// SSEFanout implements sse.Fanout on Postgres. Published envelopes are
+// appended to sse_events, whose sequence numbers them across instances,
+// and announced with NOTIFY; every instance LISTENs and reads new rows in
+// order. Rows are kept for retention so a restarted instance can refill its
+// replay buffers.
+type SSEFanout struct {
+	pool         *pgxpool.Pool
+	retention    time.Duration
+	pollInterval time.Duration
+	logger       zerolog.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewSSEFanout(pool *pgxpool.Pool, retention time.Duration, logger zerolog.Logger) *SSEFanout {
+	if retention <= 0 {
+		retention = sse.DefaultReplayTTL
+	}
+	return &SSEFanout{
+		pool:         pool,
+		retention:    retention,
+		pollInterval: 5 * time.Second,
+		logger:       logger.With().Str("component", "sse_fanout").Logger(),
+	}
+}
+
+func (f *SSEFanout) Publish(ctx context.Context, envelope *sse.Envelope) error {
+	message, err := json.Marshal(envelope.Message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sse message: %w", err)
+	}
+	tx, err := f.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, int64(sseAppendLockID)); err != nil {
+		return err
+	}
+	var seq int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO sse_events (stream, message) VALUES ($1,$2) RETURNING seq
+	`, envelope.Stream, message).Scan(&seq); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, sseNotifyChannel, strconv.FormatInt(seq, 10)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	envelope.Seq = uint64(seq)
+	return nil
+}
+
+// Subscribe replays the retained events to handler, then delivers new ones
+// as they are announced. Only one handler can be subscribed.
+func (f *SSEFanout) Subscribe(handler func(*sse.Envelope)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cancel != nil {
+		return errors.New("sse fanout already has a subscriber")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go f.listen(ctx, handler)
+	return nil
+}
+
+func (f *SSEFanout) Close() error {
+	f.mu.Lock()
+	cancel, done := f.cancel, f.done
+	f.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-done
+	return nil
+}
+
+// listen holds a LISTEN connection and reads new events on every
+// notification, and at least every pollInterval in case one was missed
+// while reconnecting.
+func (f *SSEFanout) listen(ctx context.Context, handler func(*sse.Envelope)) {
+	defer close(f.done)
+	var last int64
+	lastPrune := time.Time{}
+	for ctx.Err() == nil {
+		err := f.listenOnce(ctx, &last, &lastPrune, handler)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		f.logger.Warn().Err(err).Msg("sse listener failed, reconnecting")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (f *SSEFanout) listenOnce(ctx context.Context, last *int64, lastPrune *time.Time, handler func(*sse.Envelope)) error {
+	conn, err := f.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+sseNotifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		if err := f.readSince(ctx, last, handler); err != nil {
+			return err
+		}
+		if time.Since(*lastPrune) > f.retention/4 {
+			if err := f.prune(ctx); err != nil {
+				f.logger.Warn().Err(err).Msg("failed to prune sse events")
+			}
+			*lastPrune = time.Now()
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, f.pollInterval)
+		_, err := conn.Conn().WaitForNotification(waitCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+}
+
+// readSince hands the events after *last to handler in sequence order
+func (f *SSEFanout) readSince(ctx context.Context, last *int64, handler func(*sse.Envelope)) error {
+	for {
+		rows, err := f.pool.Query(ctx, `
+			SELECT seq, stream, message FROM sse_events
+			WHERE seq > $1 AND created_at >= $2
+			ORDER BY seq ASC LIMIT $3
+		`, *last, time.Now().Add(-f.retention), sseBatchSize)
+		if err != nil {
+			return err
+		}
+		var batch []*sse.Envelope
+		for rows.Next() {
+			var seq int64
+			var stream string
+			var message []byte
+			if err := rows.Scan(&seq, &stream, &message); err != nil {
+				rows.Close()
+				return err
+			}
+			var msg notification.SSEMessage
+			if err := json.Unmarshal(message, &msg); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to decode sse event %d: %w", seq, err)
+			}
+			batch = append(batch, &sse.Envelope{Seq: uint64(seq), Stream: stream, Message: &msg})
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, envelope := range batch {
+			handler(envelope)
+			*last = int64(envelope.Seq)
+		}
+		if len(batch) < sseBatchSize {
+			return nil
+		}
+	}
+}
+
+func (f *SSEFanout) prune(ctx context.Context) error {
+	_, err := f.pool.Exec(ctx, `DELETE FROM sse_events WHERE created_at < $1`, time.Now().Add(-f.retention))
+	return err
+}
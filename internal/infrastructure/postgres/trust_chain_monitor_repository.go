@@ -0,0 +1,158 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// TrustChainMonitorRepository implements trust.ChainMonitor.
+type TrustChainMonitorRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTrustChainMonitorRepository(pool *pgxpool.Pool) *TrustChainMonitorRepository {
+	return &TrustChainMonitorRepository{pool: pool}
+}
+
+const chainBreakColumns = `id, source_id, break_at, kind, expected_hash, actual_hash, detected_at, notification_id`
+
+func (r *TrustChainMonitorRepository) ListChainHeads(ctx context.Context) ([]trust.ChainHead, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source_id, MAX(sequence_num), MAX(created_at)
+		FROM trust_hash_chain_entries
+		GROUP BY source_id
+		ORDER BY source_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var heads []trust.ChainHead
+	for rows.Next() {
+		var h trust.ChainHead
+		if err := rows.Scan(&h.SourceID, &h.SequenceNum, &h.LastEntryAt); err != nil {
+			return nil, err
+		}
+		heads = append(heads, h)
+	}
+	return heads, rows.Err()
+}
+
+func (r *TrustChainMonitorRepository) ListChainVerificationStates(ctx context.Context) ([]*trust.ChainVerificationState, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source_id, verified_through, last_chain_hash, last_verified_at
+		FROM trust_chain_verifications ORDER BY source_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var states []*trust.ChainVerificationState
+	for rows.Next() {
+		var s trust.ChainVerificationState
+		if err := rows.Scan(&s.SourceID, &s.VerifiedThrough, &s.LastChainHash, &s.LastVerifiedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, &s)
+	}
+	return states, rows.Err()
+}
+
+func (r *TrustChainMonitorRepository) SaveChainVerification(ctx context.Context, state *trust.ChainVerificationState, breaks []*trust.ChainBreak) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	for _, b := range breaks {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO trust_chain_breaks (source_id, break_at, kind, expected_hash, actual_hash, detected_at)
+			VALUES ($1,$2,$3,$4,$5,$6)
+			ON CONFLICT (source_id, break_at, kind) DO NOTHING
+			RETURNING id
+		`, b.SourceID, b.BreakAt, b.Kind, b.ExpectedHash, b.ActualHash, b.DetectedAt).Scan(&b.ID)
+		if err != nil && err != pgx.ErrNoRows {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO trust_chain_verifications (source_id, verified_through, last_chain_hash, last_verified_at)
+		VALUES ($1,$2,$3,$4)
+		ON CONFLICT (source_id) DO UPDATE
+		SET verified_through=EXCLUDED.verified_through,
+			last_chain_hash=EXCLUDED.last_chain_hash,
+			last_verified_at=EXCLUDED.last_verified_at
+		WHERE trust_chain_verifications.verified_through <= EXCLUDED.verified_through
+	`, state.SourceID, state.VerifiedThrough, state.LastChainHash, state.LastVerifiedAt); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *TrustChainMonitorRepository) SetChainBreakNotification(ctx context.Context, breakIDs []int64, notificationID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE trust_chain_breaks SET notification_id=$2 WHERE id = ANY($1)`, breakIDs, notificationID)
+	return err
+}
+
+func (r *TrustChainMonitorRepository) ListChainBreaks(ctx context.Context, sourceID string, limit int) ([]*trust.ChainBreak, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+chainBreakColumns+` FROM trust_chain_breaks
+		WHERE ($1 = '' OR source_id=$1)
+		ORDER BY detected_at DESC, id DESC
+		LIMIT $2
+	`, sourceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanChainBreaks(rows)
+}
+
+func (r *TrustChainMonitorRepository) ListUnescalatedChainBreaks(ctx context.Context, limit int) ([]*trust.ChainBreak, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+chainBreakColumns+` FROM trust_chain_breaks
+		WHERE notification_id IS NULL
+		ORDER BY source_id, break_at, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanChainBreaks(rows)
+}
+
+func scanChainBreaks(rows pgx.Rows) ([]*trust.ChainBreak, error) {
+	defer rows.Close()
+	var breaks []*trust.ChainBreak
+	for rows.Next() {
+		var b trust.ChainBreak
+		if err := rows.Scan(&b.ID, &b.SourceID, &b.BreakAt, &b.Kind, &b.ExpectedHash, &b.ActualHash, &b.DetectedAt, &b.NotificationID); err != nil {
+			return nil, err
+		}
+		breaks = append(breaks, &b)
+	}
+	return breaks, rows.Err()
+}
+
+func (r *TrustChainMonitorRepository) CountChainBreaks(ctx context.Context) (map[string]int, error) {
+	rows, err := r.pool.Query(ctx, `SELECT source_id, COUNT(*) FROM trust_chain_breaks GROUP BY source_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string]int{}
+	for rows.Next() {
+		var sourceID string
+		var n int
+		if err := rows.Scan(&sourceID, &n); err != nil {
+			return nil, err
+		}
+		counts[sourceID] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// TrustKeyRepository implements trust.KeyRegistry.
+type TrustKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTrustKeyRepository(pool *pgxpool.Pool) *TrustKeyRepository {
+	return &TrustKeyRepository{pool: pool}
+}
+
+const sourceKeyColumns = `id, key_id, source_id, algorithm, public_key, not_before, not_after, revoked_at, revocation_reason, created_at`
+
+func (r *TrustKeyRepository) CreateSourceKey(ctx context.Context, k *trust.SourceKey) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO trust_source_keys (key_id, source_id, algorithm, public_key, not_before, not_after, revoked_at, revocation_reason, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		ON CONFLICT (key_id) DO NOTHING
+		RETURNING id
+	`, k.KeyID, k.SourceID, k.Algorithm, k.PublicKey, k.NotBefore, k.NotAfter, k.RevokedAt, k.RevocationReason, k.CreatedAt).Scan(&k.ID)
+	if err == pgx.ErrNoRows {
+		return trust.ErrKeyExists
+	}
+	return err
+}
+
+func (r *TrustKeyRepository) GetSourceKey(ctx context.Context, keyID string) (*trust.SourceKey, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+sourceKeyColumns+` FROM trust_source_keys WHERE key_id=$1`, keyID)
+	return scanSourceKey(row)
+}
+
+func (r *TrustKeyRepository) ListSourceKeys(ctx context.Context, sourceID string) ([]*trust.SourceKey, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+sourceKeyColumns+` FROM trust_source_keys
+		WHERE ($1 = '' OR source_id=$1)
+		ORDER BY source_id, not_before
+	`, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []*trust.SourceKey
+	for rows.Next() {
+		k, err := scanSourceKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeSourceKey sets the revocation time, keeping an earlier one
+func (r *TrustKeyRepository) RevokeSourceKey(ctx context.Context, keyID string, revokedAt time.Time, reason string) error {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE trust_source_keys SET revoked_at=$2, revocation_reason=$3
+		WHERE key_id=$1 AND (revoked_at IS NULL OR revoked_at > $2)
+	`, keyID, revokedAt, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		existing, err := r.GetSourceKey(ctx, keyID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return trust.ErrKeyNotFound
+		}
+		return trust.ErrKeyRevoked
+	}
+	return nil
+}
+
+func scanSourceKey(row pgx.Row) (*trust.SourceKey, error) {
+	var k trust.SourceKey
+	if err := row.Scan(&k.ID, &k.KeyID, &k.SourceID, &k.Algorithm, &k.PublicKey, &k.NotBefore, &k.NotAfter, &k.RevokedAt, &k.RevocationReason, &k.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
@@ -12,20 +12,10 @@ import (
 	"github.com/execution-hub/execution-hub/internal/domain/trust"
 )
 
-// TrustRepository implements trust.Repository.
-type TrustRepository struct {
-	pool *pgxpool.Pool
-}
-
-func NewTrustRepository(pool *pgxpool.Pool) *TrustRepository {
-	return &TrustRepository{pool: pool}
-}
-
-func (r *TrustRepository) InsertEvent(ctx context.Context, event *trust.EventEvidence) error {
-	_, err := r.pool.Exec(ctx, `
+const insertEventSQL = `
 		INSERT INTO events
-		(event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+		(event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level, validation_errors)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
 		ON CONFLICT (event_id) DO UPDATE
 		SET client_record_id=EXCLUDED.client_record_id,
 			source_type=EXCLUDED.source_type,
@@ -37,14 +27,32 @@ func (r *TrustRepository) InsertEvent(ctx context.Context, event *trust.EventEvi
 			event_type=EXCLUDED.event_type,
 			payload=EXCLUDED.payload,
 			schema_version=EXCLUDED.schema_version,
-			trust_level=EXCLUDED.trust_level
-	`, event.EventID, event.ClientRecordID, event.SourceType, event.SourceID, event.TsDevice, event.TsGateway, event.TsServer, event.Key, event.EventType, event.Payload, event.SchemaVersion, event.TrustLevel)
+			trust_level=EXCLUDED.trust_level,
+			validation_errors=EXCLUDED.validation_errors
+	`
+
+const insertHashChainEntrySQL = `
+		INSERT INTO trust_hash_chain_entries
+		(event_id, source_id, sequence_num, event_hash, prev_hash, chain_hash, trust_level, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
+
+// TrustRepository implements trust.Repository.
+type TrustRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTrustRepository(pool *pgxpool.Pool) *TrustRepository {
+	return &TrustRepository{pool: pool}
+}
+
+func (r *TrustRepository) InsertEvent(ctx context.Context, event *trust.EventEvidence) error {
+	_, err := r.pool.Exec(ctx, insertEventSQL, event.EventID, event.ClientRecordID, event.SourceType, event.SourceID, event.TsDevice, event.TsGateway, event.TsServer, event.Key, event.EventType, event.Payload, event.SchemaVersion, event.TrustLevel, event.ValidationErrors)
 	return err
 }
 
 func (r *TrustRepository) GetEvent(ctx context.Context, eventID uuid.UUID) (*trust.EventEvidence, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level
+		SELECT event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level, validation_errors
 		FROM events WHERE event_id=$1
 	`, eventID)
 	return scanEvent(row)
@@ -55,7 +63,7 @@ func (r *TrustRepository) GetEvents(ctx context.Context, eventIDs []uuid.UUID) (
 		return nil, nil
 	}
 	rows, err := r.pool.Query(ctx, `
-		SELECT event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level
+		SELECT event_id, client_record_id, source_type, source_id, ts_device, ts_gateway, ts_server, key, event_type, payload, schema_version, trust_level, validation_errors
 		FROM events WHERE event_id = ANY($1)
 	`, eventIDs)
 	if err != nil {
@@ -75,15 +83,96 @@ func (r *TrustRepository) GetEvents(ctx context.Context, eventIDs []uuid.UUID) (
 	return out, rows.Err()
 }
 
+func (r *TrustRepository) ListChainedEvents(ctx context.Context, filter trust.EventRangeFilter, limit int) ([]trust.EventEvidence, error) {
+	query := `
+		SELECT e.event_id, e.client_record_id, e.source_type, e.source_id, e.ts_device, e.ts_gateway, e.ts_server, e.key, e.event_type, e.payload, e.schema_version, e.trust_level, e.validation_errors
+		FROM events e JOIN trust_hash_chain_entries h ON h.event_id = e.event_id
+		WHERE e.ts_server >= $1 AND e.ts_server < $2`
+	args := []interface{}{filter.Since, filter.Until}
+	idx := 3
+	if filter.SourceID != nil {
+		query += " AND e.source_id=$" + itoa(idx)
+		args = append(args, *filter.SourceID)
+		idx++
+	}
+	if filter.After != nil {
+		query += " AND (e.ts_server, e.event_id) > ($" + itoa(idx) + ", $" + itoa(idx+1) + ")"
+		args = append(args, filter.After.TsServer, filter.After.EventID)
+		idx += 2
+	}
+	query += " ORDER BY e.ts_server ASC, e.event_id ASC LIMIT $" + itoa(idx)
+	args = append(args, limit)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []trust.EventEvidence
+	for rows.Next() {
+		ev, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		if ev != nil {
+			out = append(out, *ev)
+		}
+	}
+	return out, rows.Err()
+}
+
 func (r *TrustRepository) InsertHashChainEntry(ctx context.Context, entry *trust.HashChainEntry) error {
-	_, err := r.pool.Exec(ctx, `
-		INSERT INTO trust_hash_chain_entries
-		(event_id, source_id, sequence_num, event_hash, prev_hash, chain_hash, trust_level, created_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-	`, entry.EventID, entry.SourceID, entry.SequenceNum, entry.EventHash, entry.PrevHash, entry.ChainHash, entry.TrustLevel, entry.CreatedAt)
+	_, err := r.pool.Exec(ctx, insertHashChainEntrySQL, entry.EventID, entry.SourceID, entry.SequenceNum, entry.EventHash, entry.PrevHash, entry.ChainHash, entry.TrustLevel, entry.CreatedAt)
 	return err
 }
 
+// AppendToChain holds a transaction-scoped advisory lock on the source
+// while it reads the chain head and inserts the events and entries, so
+// appends to one source are serialised across server instances.
+func (r *TrustRepository) AppendToChain(ctx context.Context, sourceID string, links []trust.ChainLink) ([]*trust.HashChainEntry, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended('trust_chain:' || $1, 0))`, sourceID); err != nil {
+		return nil, err
+	}
+	latest, err := scanHashChain(tx.QueryRow(ctx, `
+		SELECT id, event_id, source_id, sequence_num, event_hash, prev_hash, chain_hash, trust_level, created_at
+		FROM trust_hash_chain_entries WHERE source_id=$1 ORDER BY sequence_num DESC LIMIT 1
+	`, sourceID))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := trust.ExtendChain(latest, sourceID, links)
+	for i, link := range links {
+		event := link.Event
+		event.TrustLevel = event.CapTrust(trust.TrustLevelT2)
+		if _, err := tx.Exec(ctx, insertEventSQL, event.EventID, event.ClientRecordID, event.SourceType, event.SourceID, event.TsDevice, event.TsGateway, event.TsServer, event.Key, event.EventType, event.Payload, event.SchemaVersion, event.TrustLevel, event.ValidationErrors); err != nil {
+			return nil, err
+		}
+		entry := entries[i]
+		entry.TrustLevel = event.TrustLevel
+		if err := tx.QueryRow(ctx, insertHashChainEntrySQL+` RETURNING id`, entry.EventID, entry.SourceID, entry.SequenceNum, entry.EventHash, entry.PrevHash, entry.ChainHash, entry.TrustLevel, entry.CreatedAt).Scan(&entry.ID); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO trust_metadata (event_id, trust_level, flags, updated_at)
+			VALUES ($1,$2,$3,NOW())
+			ON CONFLICT (event_id) DO UPDATE SET trust_level=$2, flags=$3, updated_at=NOW()
+		`, event.EventID, event.TrustLevel, event.TrustFlags()); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
 func (r *TrustRepository) GetHashChainEntry(ctx context.Context, eventID uuid.UUID) (*trust.HashChainEntry, error) {
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, event_id, source_id, sequence_num, event_hash, prev_hash, chain_hash, trust_level, created_at
@@ -143,15 +232,15 @@ func (r *TrustRepository) GetChainEntriesForEvents(ctx context.Context, eventIDs
 func (r *TrustRepository) InsertBatchSignature(ctx context.Context, sig *trust.BatchSignature) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO trust_batch_signatures
-		(batch_id, source_id, event_ids, batch_hash, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
-	`, sig.BatchID, sig.SourceID, sig.EventIDs, sig.BatchHash, sig.Signature, sig.SignatureAlg, sig.KeyID, sig.SignedAt, sig.VerifiedAt, sig.VerificationStatus, sig.VerificationError, sig.CreatedAt)
+		(batch_id, source_id, event_ids, batch_hash, hash_scheme, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+	`, sig.BatchID, sig.SourceID, sig.EventIDs, sig.BatchHash, sig.Scheme(), sig.Signature, sig.SignatureAlg, sig.KeyID, sig.SignedAt, sig.VerifiedAt, sig.VerificationStatus, sig.VerificationError, sig.CreatedAt)
 	return err
 }
 
 func (r *TrustRepository) GetBatchSignature(ctx context.Context, batchID uuid.UUID) (*trust.BatchSignature, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, batch_id, source_id, event_ids, batch_hash, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
+		SELECT id, batch_id, source_id, event_ids, batch_hash, hash_scheme, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
 		FROM trust_batch_signatures WHERE batch_id=$1
 	`, batchID)
 	return scanBatchSignature(row)
@@ -159,7 +248,7 @@ func (r *TrustRepository) GetBatchSignature(ctx context.Context, batchID uuid.UU
 
 func (r *TrustRepository) GetBatchSignaturesBySource(ctx context.Context, sourceID string, limit int) ([]*trust.BatchSignature, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, batch_id, source_id, event_ids, batch_hash, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
+		SELECT id, batch_id, source_id, event_ids, batch_hash, hash_scheme, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
 		FROM trust_batch_signatures WHERE source_id=$1 ORDER BY signed_at DESC LIMIT $2
 	`, sourceID, limit)
 	if err != nil {
@@ -179,7 +268,7 @@ func (r *TrustRepository) GetBatchSignaturesBySource(ctx context.Context, source
 
 func (r *TrustRepository) GetBatchSignatureForEvent(ctx context.Context, eventID uuid.UUID) (*trust.BatchSignature, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, batch_id, source_id, event_ids, batch_hash, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
+		SELECT id, batch_id, source_id, event_ids, batch_hash, hash_scheme, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
 		FROM trust_batch_signatures WHERE $1 = ANY(event_ids) ORDER BY signed_at DESC LIMIT 1
 	`, eventID)
 	return scanBatchSignature(row)
@@ -261,11 +350,11 @@ func (r *TrustRepository) GetEvidenceBundleData(ctx context.Context, bundleType
 		// Include rule/evaluation evidence when present.
 		if action.RuleID != uuid.Nil {
 			row = r.pool.QueryRow(ctx, `
-				SELECT rule_id, version, name, rule_type, config, effective_from, effective_until
+				SELECT rule_id, version, name, rule_type, config, min_trust_level, effective_from, effective_until
 				FROM rules WHERE rule_id=$1 AND version=$2
 			`, action.RuleID, action.RuleVersion)
 			var re trust.RuleEvidence
-			if err := row.Scan(&re.RuleID, &re.Version, &re.Name, &re.RuleType, &re.Config, &re.EffectiveFrom, &re.EffectiveUntil); err == nil {
+			if err := row.Scan(&re.RuleID, &re.Version, &re.Name, &re.RuleType, &re.Config, &re.MinTrustLevel, &re.EffectiveFrom, &re.EffectiveUntil); err == nil {
 				data.Rules = append(data.Rules, re)
 			}
 		}
@@ -357,11 +446,11 @@ func (r *TrustRepository) GetEvidenceBundleData(ctx context.Context, bundleType
 		// Load rule evidence.
 		for key := range ruleKeys {
 			row = r.pool.QueryRow(ctx, `
-				SELECT rule_id, version, name, rule_type, config, effective_from, effective_until
+				SELECT rule_id, version, name, rule_type, config, min_trust_level, effective_from, effective_until
 				FROM rules WHERE rule_id=$1 AND version=$2
 			`, key.id, key.version)
 			var re trust.RuleEvidence
-			if err := row.Scan(&re.RuleID, &re.Version, &re.Name, &re.RuleType, &re.Config, &re.EffectiveFrom, &re.EffectiveUntil); err == nil {
+			if err := row.Scan(&re.RuleID, &re.Version, &re.Name, &re.RuleType, &re.Config, &re.MinTrustLevel, &re.EffectiveFrom, &re.EffectiveUntil); err == nil {
 				data.Rules = append(data.Rules, re)
 			}
 		}
@@ -419,7 +508,7 @@ func (r *TrustRepository) GetEvidenceBundleData(ctx context.Context, bundleType
 		return data, nil
 	case "BATCH":
 		row := r.pool.QueryRow(ctx, `
-			SELECT id, batch_id, source_id, event_ids, batch_hash, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
+			SELECT id, batch_id, source_id, event_ids, batch_hash, hash_scheme, signature, signature_alg, key_id, signed_at, verified_at, verification_status, verification_error, created_at
 			FROM trust_batch_signatures WHERE batch_id=$1
 		`, subjectID)
 		sig, err := scanBatchSignature(row)
@@ -462,7 +551,7 @@ func (r *TrustRepository) GetEvidenceBundleData(ctx context.Context, bundleType
 func scanEvent(row pgx.Row) (*trust.EventEvidence, error) {
 	var ev trust.EventEvidence
 	var payload json.RawMessage
-	if err := row.Scan(&ev.EventID, &ev.ClientRecordID, &ev.SourceType, &ev.SourceID, &ev.TsDevice, &ev.TsGateway, &ev.TsServer, &ev.Key, &ev.EventType, &payload, &ev.SchemaVersion, &ev.TrustLevel); err != nil {
+	if err := row.Scan(&ev.EventID, &ev.ClientRecordID, &ev.SourceType, &ev.SourceID, &ev.TsDevice, &ev.TsGateway, &ev.TsServer, &ev.Key, &ev.EventType, &payload, &ev.SchemaVersion, &ev.TrustLevel, &ev.ValidationErrors); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
@@ -487,7 +576,7 @@ func scanHashChain(row pgx.Row) (*trust.HashChainEntry, error) {
 
 func scanBatchSignature(row pgx.Row) (*trust.BatchSignature, error) {
 	var s trust.BatchSignature
-	if err := row.Scan(&s.ID, &s.BatchID, &s.SourceID, &s.EventIDs, &s.BatchHash, &s.Signature, &s.SignatureAlg, &s.KeyID, &s.SignedAt, &s.VerifiedAt, &s.VerificationStatus, &s.VerificationError, &s.CreatedAt); err != nil {
+	if err := row.Scan(&s.ID, &s.BatchID, &s.SourceID, &s.EventIDs, &s.BatchHash, &s.HashScheme, &s.Signature, &s.SignatureAlg, &s.KeyID, &s.SignedAt, &s.VerifiedAt, &s.VerificationStatus, &s.VerificationError, &s.CreatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
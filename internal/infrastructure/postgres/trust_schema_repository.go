@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/execution-hub/execution-hub/internal/domain/trust"
+)
+
+// TrustSchemaRepository implements trust.SchemaRegistry.
+type TrustSchemaRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewTrustSchemaRepository(pool *pgxpool.Pool) *TrustSchemaRepository {
+	return &TrustSchemaRepository{pool: pool}
+}
+
+const eventSchemaColumns = `id, event_type, schema_version, schema, on_invalid, compatibility, created_by, created_at`
+
+func (r *TrustSchemaRepository) CreateEventSchema(ctx context.Context, s *trust.EventSchema) error {
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO trust_event_schemas (event_type, schema_version, schema, on_invalid, compatibility, created_by, created_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		ON CONFLICT (event_type, schema_version) DO NOTHING
+		RETURNING id
+	`, s.EventType, s.SchemaVersion, s.Schema, s.OnInvalid, s.Compatibility, s.CreatedBy, s.CreatedAt).Scan(&s.ID)
+	if err == pgx.ErrNoRows {
+		return trust.ErrSchemaConflict
+	}
+	return err
+}
+
+func (r *TrustSchemaRepository) GetEventSchema(ctx context.Context, eventType, schemaVersion string) (*trust.EventSchema, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+eventSchemaColumns+` FROM trust_event_schemas WHERE event_type=$1 AND schema_version=$2`, eventType, schemaVersion)
+	return scanEventSchema(row)
+}
+
+func (r *TrustSchemaRepository) ListEventSchemas(ctx context.Context, eventType string) ([]*trust.EventSchema, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+eventSchemaColumns+` FROM trust_event_schemas
+		WHERE ($1 = '' OR event_type=$1)
+		ORDER BY event_type, created_at, id
+	`, eventType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var schemas []*trust.EventSchema
+	for rows.Next() {
+		s, err := scanEventSchema(rows)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, rows.Err()
+}
+
+func scanEventSchema(row pgx.Row) (*trust.EventSchema, error) {
+	var s trust.EventSchema
+	if err := row.Scan(&s.ID, &s.EventType, &s.SchemaVersion, &s.Schema, &s.OnInvalid, &s.Compatibility, &s.CreatedBy, &s.CreatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
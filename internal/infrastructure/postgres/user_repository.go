@@ -22,24 +22,24 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
 	_, err := r.pool.Exec(ctx, `
 		INSERT INTO users
-		(user_id, username, password_hash, role, user_type, owner_user_id, status, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
-	`, u.UserID, u.Username, u.PasswordHash, u.Role, u.Type, u.OwnerUserID, u.Status, u.CreatedAt, u.UpdatedAt)
+		(user_id, username, password_hash, role, user_type, owner_user_id, email, status, created_at, updated_at)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+	`, u.UserID, u.Username, u.PasswordHash, u.Role, u.Type, u.OwnerUserID, u.Email, u.Status, u.CreatedAt, u.UpdatedAt)
 	return err
 }
 
 func (r *UserRepository) Update(ctx context.Context, u *user.User) error {
 	_, err := r.pool.Exec(ctx, `
 		UPDATE users
-		SET username=$1, password_hash=$2, role=$3, user_type=$4, owner_user_id=$5, status=$6, updated_at=$7
-		WHERE user_id=$8
-	`, u.Username, u.PasswordHash, u.Role, u.Type, u.OwnerUserID, u.Status, u.UpdatedAt, u.UserID)
+		SET username=$1, password_hash=$2, role=$3, user_type=$4, owner_user_id=$5, email=$6, status=$7, updated_at=$8
+		WHERE user_id=$9
+	`, u.Username, u.PasswordHash, u.Role, u.Type, u.OwnerUserID, u.Email, u.Status, u.UpdatedAt, u.UserID)
 	return err
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, userID uuid.UUID) (*user.User, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, status, created_at, updated_at
+		SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, email, status, created_at, updated_at
 		FROM users WHERE user_id=$1
 	`, userID)
 	return scanUser(row)
@@ -47,14 +47,14 @@ func (r *UserRepository) GetByID(ctx context.Context, userID uuid.UUID) (*user.U
 
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*user.User, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, status, created_at, updated_at
+		SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, email, status, created_at, updated_at
 		FROM users WHERE username=$1
 	`, username)
 	return scanUser(row)
 }
 
 func (r *UserRepository) List(ctx context.Context, filter user.Filter, limit, offset int) ([]*user.User, error) {
-	query := `SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, status, created_at, updated_at FROM users`
+	query := `SELECT id, user_id, username, password_hash, role, user_type, owner_user_id, email, status, created_at, updated_at FROM users`
 	args := []interface{}{}
 	idx := 1
 	if filter.Role != nil {
@@ -113,7 +113,7 @@ func (r *UserRepository) Count(ctx context.Context) (int, error) {
 func scanUser(row pgx.Row) (*user.User, error) {
 	var u user.User
 	var ownerID *uuid.UUID
-	if err := row.Scan(&u.ID, &u.UserID, &u.Username, &u.PasswordHash, &u.Role, &u.Type, &ownerID, &u.Status, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.UserID, &u.Username, &u.PasswordHash, &u.Role, &u.Type, &ownerID, &u.Email, &u.Status, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, nil
 		}
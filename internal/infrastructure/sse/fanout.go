@@ -0,0 +1,73 @@
+package sse
+
+import (
+	"context"
+	"sync"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+// Stream names the audience of a message: StreamAll, a user or a group.
+const StreamAll = "all"
+
+// UserStream returns the stream of a user
+func UserStream(userID string) string {
+	return "user:" + userID
+}
+
+// GroupStream returns the stream of a group
+func GroupStream(group string) string {
+	return "group:" + group
+}
+
+// Envelope is a message addressed to a stream, as carried by a Fanout
+type Envelope struct {
+	Seq     uint64                   `json:"seq"`
+	Stream  string                   `json:"stream"`
+	Message *notification.SSEMessage `json:"message"`
+}
+
+// Fanout carries hub messages between server instances. Publish numbers
+// each envelope with a sequence that increases across all instances and
+// hands it to the subscribed handlers of every instance in that order.
+type Fanout interface {
+	Publish(ctx context.Context, envelope *Envelope) error
+	// Subscribe registers handler for every envelope published from now on;
+	// implementations backed by storage may first replay retained ones.
+	Subscribe(handler func(*Envelope)) error
+	Close() error
+}
+
+// MemoryFanout delivers envelopes to the hubs of a single process, numbered
+// by an in-process counter
+type MemoryFanout struct {
+	mu       sync.Mutex
+	seq      uint64
+	handlers []func(*Envelope)
+}
+
+func NewMemoryFanout() *MemoryFanout {
+	return &MemoryFanout{}
+}
+
+func (f *MemoryFanout) Publish(_ context.Context, envelope *Envelope) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	envelope.Seq = f.seq
+	for _, handler := range f.handlers {
+		handler(envelope)
+	}
+	return nil
+}
+
+func (f *MemoryFanout) Subscribe(handler func(*Envelope)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers = append(f.handlers, handler)
+	return nil
+}
+
+func (f *MemoryFanout) Close() error {
+	return nil
+}
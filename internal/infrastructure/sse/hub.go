@@ -2,27 +2,132 @@ package sse
 
 import (
 	"context"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
 
 	"github.com/execution-hub/execution-hub/internal/domain/notification"
 )
 
-// Hub manages SSE clients.
+const (
+	// DefaultReplayBufferSize is the number of recent messages kept per
+	// stream for Last-Event-ID resume
+	DefaultReplayBufferSize = 256
+	// DefaultReplayTTL bounds how long a message stays resumable
+	DefaultReplayTTL = time.Hour
+)
+
+// Hub manages SSE clients. Broadcasts go through a Fanout, which numbers
+// them and delivers them to the hub of every server instance. Each hub
+// keeps the recent messages of every stream so that a reconnecting client
+// can resume after its Last-Event-ID. A client whose queue is full is
+// disconnected rather than silently losing messages; it resumes from the
+// replay buffer when it reconnects.
 type Hub struct {
-	mu      sync.RWMutex
-	clients map[string]*notification.SSEClient
+	mu         sync.RWMutex
+	clients    map[string]*notification.SSEClient
+	streams    map[string]*replayBuffer
+	lastSeq    uint64
+	bufferSize int
+	ttl        time.Duration
+
+	fanout    Fanout
+	subscribe sync.Once
+	now       func() time.Time
+	logger    zerolog.Logger
+	stats     counters
 }
 
+// counters are the hub's backpressure metrics
+type counters struct {
+	published     atomic.Uint64
+	publishErrors atomic.Uint64
+	delivered     atomic.Uint64
+	dropped       atomic.Uint64
+	evicted       atomic.Uint64
+	replayed      atomic.Uint64
+	gaps          atomic.Uint64
+}
+
+// NewHub creates a hub for a single server instance
 func NewHub() *Hub {
+	return NewDurableHub(NewMemoryFanout(), DefaultReplayBufferSize, DefaultReplayTTL, zerolog.Nop())
+}
+
+// NewDurableHub creates a hub sharing messages through fanout, keeping up
+// to bufferSize messages per stream for at most ttl
+func NewDurableHub(fanout Fanout, bufferSize int, ttl time.Duration, logger zerolog.Logger) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = DefaultReplayBufferSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultReplayTTL
+	}
 	return &Hub{
-		clients: make(map[string]*notification.SSEClient),
+		clients:    make(map[string]*notification.SSEClient),
+		streams:    make(map[string]*replayBuffer),
+		bufferSize: bufferSize,
+		ttl:        ttl,
+		fanout:     fanout,
+		now:        time.Now,
+		logger:     logger.With().Str("component", "sse_hub").Logger(),
 	}
 }
 
+// Register adds a fresh client; it receives messages published from now on.
 func (h *Hub) Register(client *notification.SSEClient) {
+	h.ensureSubscribed()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client.ClientID] = client
+}
+
+// Resume registers client and queues the buffered messages of its streams
+// published after lastEventID. It returns the number of replayed messages
+// and false when messages after lastEventID are no longer buffered, in
+// which case the client should resynchronise through the REST API.
+func (h *Hub) Resume(client *notification.SSEClient, lastEventID uint64) (int, bool) {
+	h.ensureSubscribed()
 	h.mu.Lock()
 	defer h.mu.Unlock()
+
+	complete := lastEventID <= h.lastSeq
+	var replay []bufferedMessage
+	for _, stream := range clientStreams(client) {
+		b := h.streams[stream]
+		if b == nil {
+			continue
+		}
+		if lastEventID < b.evicted {
+			complete = false
+		}
+		replay = append(replay, b.since(lastEventID)...)
+	}
+	sort.Slice(replay, func(i, j int) bool { return replay[i].seq < replay[j].seq })
+
+	// The handler has not started reading, so the queue can still grow to
+	// hold the replay.
+	if free := cap(client.MessageChan) - len(client.MessageChan); len(replay) > free {
+		queue := make(chan *notification.SSEMessage, len(replay)+cap(client.MessageChan))
+		for len(client.MessageChan) > 0 {
+			queue <- <-client.MessageChan
+		}
+		client.MessageChan = queue
+	}
+	for _, m := range replay {
+		client.MessageChan <- m.msg
+	}
 	h.clients[client.ClientID] = client
+
+	h.stats.replayed.Add(uint64(len(replay)))
+	if !complete {
+		h.stats.gaps.Add(1)
+	}
+	return len(replay), complete
 }
 
 func (h *Hub) Unregister(clientID string) {
@@ -47,54 +152,56 @@ func (h *Hub) GetClientCount() int {
 }
 
 func (h *Hub) BroadcastToAll(message *notification.SSEMessage) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	for _, c := range h.clients {
-		trySend(c, message)
-	}
+	h.publish(StreamAll, message)
 }
 
 func (h *Hub) BroadcastToUser(userID string, message *notification.SSEMessage) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	for _, c := range h.clients {
-		if c.UserID != nil && *c.UserID == userID {
-			trySend(c, message)
-		}
-	}
+	h.publish(UserStream(userID), message)
 }
 
 func (h *Hub) BroadcastToGroup(group string, message *notification.SSEMessage) {
-	h.mu.RLock()
-	defer h.mu.RUnlock()
-	for _, c := range h.clients {
-		for _, g := range c.Groups {
-			if g == group {
-				trySend(c, message)
-				break
-			}
-		}
-	}
+	h.publish(GroupStream(group), message)
 }
 
+// SendToClient queues message for one client. Direct messages are not
+// buffered for resume.
 func (h *Hub) SendToClient(clientID string, message *notification.SSEMessage) error {
 	h.mu.RLock()
 	c := h.clients[clientID]
-	h.mu.RUnlock()
+	defer h.mu.RUnlock()
 	if c == nil {
 		return notification.ErrClientNotFound
 	}
 	if !trySend(c, message) {
+		h.stats.dropped.Add(1)
 		return notification.ErrChannelFull
 	}
+	h.stats.delivered.Add(1)
 	return nil
 }
 
+// Start subscribes the hub to its fanout and prunes expired replay buffers
+// until ctx is done
 func (h *Hub) Start(ctx context.Context) {
-	_ = ctx
+	h.ensureSubscribed()
+	go func() {
+		ticker := time.NewTicker(h.ttl / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				h.prune()
+			}
+		}
+	}()
 }
 
 func (h *Hub) Stop() {
+	if err := h.fanout.Close(); err != nil {
+		h.logger.Warn().Err(err).Msg("failed to close sse fanout")
+	}
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	for id, c := range h.clients {
@@ -103,6 +210,150 @@ func (h *Hub) Stop() {
 	}
 }
 
+// ClientStats reports the queue of one client
+type ClientStats struct {
+	ClientID string  `json:"clientId"`
+	UserID   *string `json:"userId,omitempty"`
+	Queued   int     `json:"queued"`
+	Capacity int     `json:"capacity"`
+}
+
+// Stats reports hub activity. Dropped counts messages a client's full queue
+// could not take; every such client is disconnected and counted as evicted.
+type Stats struct {
+	Clients        []ClientStats `json:"clients"`
+	Streams        int           `json:"streams"`
+	BufferedEvents int           `json:"bufferedEvents"`
+	LastEventID    uint64        `json:"lastEventId"`
+	Published      uint64        `json:"published"`
+	PublishErrors  uint64        `json:"publishErrors"`
+	Delivered      uint64        `json:"delivered"`
+	Dropped        uint64        `json:"dropped"`
+	Evicted        uint64        `json:"evicted"`
+	Replayed       uint64        `json:"replayed"`
+	Gaps           uint64        `json:"gaps"`
+}
+
+// Stats returns a snapshot of the hub's clients and counters
+func (h *Hub) Stats() Stats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	s := Stats{
+		Clients:       make([]ClientStats, 0, len(h.clients)),
+		Streams:       len(h.streams),
+		LastEventID:   h.lastSeq,
+		Published:     h.stats.published.Load(),
+		PublishErrors: h.stats.publishErrors.Load(),
+		Delivered:     h.stats.delivered.Load(),
+		Dropped:       h.stats.dropped.Load(),
+		Evicted:       h.stats.evicted.Load(),
+		Replayed:      h.stats.replayed.Load(),
+		Gaps:          h.stats.gaps.Load(),
+	}
+	for _, b := range h.streams {
+		s.BufferedEvents += len(b.entries)
+	}
+	for _, c := range h.clients {
+		s.Clients = append(s.Clients, ClientStats{
+			ClientID: c.ClientID,
+			UserID:   c.UserID,
+			Queued:   len(c.MessageChan),
+			Capacity: cap(c.MessageChan),
+		})
+	}
+	sort.Slice(s.Clients, func(i, j int) bool { return s.Clients[i].ClientID < s.Clients[j].ClientID })
+	return s
+}
+
+func (h *Hub) ensureSubscribed() {
+	h.subscribe.Do(func() {
+		if err := h.fanout.Subscribe(h.deliver); err != nil {
+			h.logger.Error().Err(err).Msg("failed to subscribe to sse fanout")
+		}
+	})
+}
+
+func (h *Hub) publish(stream string, message *notification.SSEMessage) {
+	h.ensureSubscribed()
+	if err := h.fanout.Publish(context.Background(), &Envelope{Stream: stream, Message: message}); err != nil {
+		h.stats.publishErrors.Add(1)
+		h.logger.Error().Err(err).Str("stream", stream).Str("event", message.Event).Msg("failed to publish sse message")
+		return
+	}
+	h.stats.published.Add(1)
+}
+
+// deliver buffers an envelope from the fanout and queues it for the
+// clients of its stream
+func (h *Hub) deliver(envelope *Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if envelope.Seq <= h.lastSeq {
+		return
+	}
+	h.lastSeq = envelope.Seq
+
+	msg := *envelope.Message
+	msg.ID = strconv.FormatUint(envelope.Seq, 10)
+	b := h.streams[envelope.Stream]
+	if b == nil {
+		b = &replayBuffer{}
+		h.streams[envelope.Stream] = b
+	}
+	b.add(envelope.Seq, &msg, h.bufferSize)
+
+	for id, c := range h.clients {
+		if !subscribed(c, envelope.Stream) {
+			continue
+		}
+		if trySend(c, &msg) {
+			h.stats.delivered.Add(1)
+			continue
+		}
+		h.stats.dropped.Add(1)
+		h.stats.evicted.Add(1)
+		h.logger.Warn().
+			Str("client_id", id).
+			Uint64("event_id", envelope.Seq).
+			Msg("sse client queue full, disconnecting")
+		c.Close()
+		delete(h.clients, id)
+	}
+}
+
+// prune drops buffered messages older than the TTL and empty streams
+func (h *Hub) prune() {
+	cutoff := h.now().Add(-h.ttl)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stream, b := range h.streams {
+		b.prune(cutoff)
+		if len(b.entries) == 0 {
+			delete(h.streams, stream)
+		}
+	}
+}
+
+func clientStreams(c *notification.SSEClient) []string {
+	streams := []string{StreamAll}
+	if c.UserID != nil {
+		streams = append(streams, UserStream(*c.UserID))
+	}
+	for _, g := range c.Groups {
+		streams = append(streams, GroupStream(g))
+	}
+	return streams
+}
+
+func subscribed(c *notification.SSEClient, stream string) bool {
+	for _, s := range clientStreams(c) {
+		if s == stream {
+			return true
+		}
+	}
+	return false
+}
+
 func trySend(c *notification.SSEClient, msg *notification.SSEMessage) bool {
 	select {
 	case c.MessageChan <- msg:
@@ -111,3 +362,39 @@ func trySend(c *notification.SSEClient, msg *notification.SSEMessage) bool {
 		return false
 	}
 }
+
+type bufferedMessage struct {
+	seq uint64
+	msg *notification.SSEMessage
+}
+
+// replayBuffer holds the recent messages of one stream in sequence order.
+// evicted is the highest sequence dropped from it.
+type replayBuffer struct {
+	entries []bufferedMessage
+	evicted uint64
+}
+
+func (b *replayBuffer) add(seq uint64, msg *notification.SSEMessage, size int) {
+	b.entries = append(b.entries, bufferedMessage{seq: seq, msg: msg})
+	if over := len(b.entries) - size; over > 0 {
+		b.evicted = b.entries[over-1].seq
+		b.entries = append([]bufferedMessage(nil), b.entries[over:]...)
+	}
+}
+
+func (b *replayBuffer) since(seq uint64) []bufferedMessage {
+	i := sort.Search(len(b.entries), func(i int) bool { return b.entries[i].seq > seq })
+	return b.entries[i:]
+}
+
+func (b *replayBuffer) prune(before time.Time) {
+	i := 0
+	for i < len(b.entries) && b.entries[i].msg.Timestamp.Before(before) {
+		i++
+	}
+	if i > 0 {
+		b.evicted = b.entries[i-1].seq
+		b.entries = b.entries[i:]
+	}
+}
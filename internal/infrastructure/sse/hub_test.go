@@ -0,0 +1,185 @@
+package sse
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/internal/domain/notification"
+)
+
+func newMessage(t *testing.T, text string) *notification.SSEMessage {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{"text": text})
+	require.NoError(t, err)
+	return notification.NewSSEMessage("notification", data)
+}
+
+func drain(c *notification.SSEClient) []*notification.SSEMessage {
+	var out []*notification.SSEMessage
+	for {
+		select {
+		case msg, ok := <-c.MessageChan:
+			if !ok {
+				return out
+			}
+			out = append(out, msg)
+		default:
+			return out
+		}
+	}
+}
+
+func ids(msgs []*notification.SSEMessage) []string {
+	out := make([]string, 0, len(msgs))
+	for _, m := range msgs {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+func TestHub_MonotonicIDsAndRouting(t *testing.T) {
+	hub := NewHub()
+	alice := "alice"
+	aliceClient := notification.NewSSEClient("a", &alice, []string{"role:ADMIN"})
+	bobClient := notification.NewSSEClient("b", nil, nil)
+	hub.Register(aliceClient)
+	hub.Register(bobClient)
+
+	hub.BroadcastToUser("alice", newMessage(t, "one"))
+	hub.BroadcastToGroup("role:ADMIN", newMessage(t, "two"))
+	hub.BroadcastToAll(newMessage(t, "three"))
+	hub.BroadcastToUser("carol", newMessage(t, "four"))
+
+	assert.Equal(t, []string{"1", "2", "3"}, ids(drain(aliceClient)))
+	assert.Equal(t, []string{"3"}, ids(drain(bobClient)))
+	assert.Equal(t, uint64(4), hub.Stats().LastEventID)
+}
+
+func TestHub_Resume(t *testing.T) {
+	hub := NewDurableHub(NewMemoryFanout(), 3, time.Hour, zerolog.Nop())
+	alice := "alice"
+	for i := 0; i < 4; i++ {
+		hub.BroadcastToUser("alice", newMessage(t, "user"))
+	}
+	hub.BroadcastToAll(newMessage(t, "all"))
+	hub.BroadcastToUser("bob", newMessage(t, "other"))
+
+	t.Run("replays after last event", func(t *testing.T) {
+		client := notification.NewSSEClient("a1", &alice, nil)
+		replayed, complete := hub.Resume(client, 2)
+		assert.True(t, complete)
+		assert.Equal(t, 3, replayed)
+		assert.Equal(t, []string{"3", "4", "5"}, ids(drain(client)))
+	})
+
+	t.Run("reports evicted messages", func(t *testing.T) {
+		client := notification.NewSSEClient("a2", &alice, nil)
+		replayed, complete := hub.Resume(client, 0)
+		assert.False(t, complete)
+		assert.Equal(t, []string{"2", "3", "4", "5"}, ids(drain(client)))
+		assert.Equal(t, 4, replayed)
+	})
+
+	t.Run("reports unknown future id", func(t *testing.T) {
+		client := notification.NewSSEClient("a3", &alice, nil)
+		_, complete := hub.Resume(client, 99)
+		assert.False(t, complete)
+	})
+
+	t.Run("grows the queue for long replays", func(t *testing.T) {
+		client := &notification.SSEClient{ClientID: "a4", UserID: &alice, MessageChan: make(chan *notification.SSEMessage, 1)}
+		replayed, _ := hub.Resume(client, 0)
+		assert.Equal(t, 4, replayed)
+		assert.Len(t, drain(client), 4)
+	})
+
+	stats := hub.Stats()
+	assert.Equal(t, uint64(11), stats.Replayed)
+	assert.Equal(t, uint64(3), stats.Gaps)
+}
+
+func TestHub_RegisterFreshClient(t *testing.T) {
+	hub := NewDurableHub(NewMemoryFanout(), 2, time.Hour, zerolog.Nop())
+	for i := 0; i < 3; i++ {
+		hub.BroadcastToAll(newMessage(t, "before"))
+	}
+
+	// A connection without Last-Event-ID starts at the buffer head: nothing
+	// is replayed and the evicted first message is not reported as a gap.
+	client := notification.NewSSEClient("fresh", nil, nil)
+	hub.Register(client)
+	assert.Empty(t, drain(client))
+
+	hub.BroadcastToAll(newMessage(t, "after"))
+	assert.Equal(t, []string{"4"}, ids(drain(client)))
+	stats := hub.Stats()
+	assert.Zero(t, stats.Replayed)
+	assert.Zero(t, stats.Gaps)
+}
+
+func TestHub_SlowClientIsEvicted(t *testing.T) {
+	hub := NewHub()
+	client := &notification.SSEClient{ClientID: "slow", MessageChan: make(chan *notification.SSEMessage, 1)}
+	hub.Register(client)
+
+	hub.BroadcastToAll(newMessage(t, "one"))
+	hub.BroadcastToAll(newMessage(t, "two"))
+
+	assert.Nil(t, hub.GetClient("slow"))
+	assert.Equal(t, []string{"1"}, ids(drain(client)))
+	_, open := <-client.MessageChan
+	assert.False(t, open)
+
+	stats := hub.Stats()
+	assert.Equal(t, uint64(1), stats.Delivered)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, uint64(1), stats.Evicted)
+
+	// The evicted client loses nothing: it resumes from the buffer.
+	again := notification.NewSSEClient("slow", nil, nil)
+	_, complete := hub.Resume(again, 1)
+	assert.True(t, complete)
+	assert.Equal(t, []string{"2"}, ids(drain(again)))
+}
+
+func TestHub_SharedFanout(t *testing.T) {
+	fanout := NewMemoryFanout()
+	first := NewDurableHub(fanout, 10, time.Hour, zerolog.Nop())
+	second := NewDurableHub(fanout, 10, time.Hour, zerolog.Nop())
+	alice := "alice"
+	client := notification.NewSSEClient("a", &alice, nil)
+	second.Resume(client, 0)
+	first.Register(notification.NewSSEClient("other", nil, nil))
+
+	first.BroadcastToUser("alice", newMessage(t, "from first"))
+	second.BroadcastToUser("alice", newMessage(t, "from second"))
+
+	assert.Equal(t, []string{"1", "2"}, ids(drain(client)))
+
+	// A client moving to the other instance resumes from the same IDs.
+	moved := notification.NewSSEClient("a", &alice, nil)
+	_, complete := first.Resume(moved, 1)
+	assert.True(t, complete)
+	assert.Equal(t, []string{"2"}, ids(drain(moved)))
+}
+
+func TestHub_Prune(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	hub := NewDurableHub(NewMemoryFanout(), 10, time.Minute, zerolog.Nop())
+	hub.now = func() time.Time { return now }
+	old := newMessage(t, "old")
+	old.Timestamp = now.Add(-2 * time.Minute)
+	hub.BroadcastToAll(old)
+	hub.BroadcastToUser("alice", newMessage(t, "new"))
+
+	hub.prune()
+
+	stats := hub.Stats()
+	assert.Equal(t, 1, stats.Streams)
+	assert.Equal(t, 1, stats.BufferedEvents)
+}
@@ -9,6 +9,8 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
@@ -16,6 +18,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -27,13 +30,21 @@ import (
 	"github.com/execution-hub/execution-hub/internal/application/executor"
 	"github.com/execution-hub/execution-hub/internal/application/notification"
 	"github.com/execution-hub/execution-hub/internal/application/orchestrator"
+	"github.com/execution-hub/execution-hub/internal/application/rule"
 	"github.com/execution-hub/execution-hub/internal/application/task"
 	"github.com/execution-hub/execution-hub/internal/application/trust"
 	"github.com/execution-hub/execution-hub/internal/application/user"
 	"github.com/execution-hub/execution-hub/internal/application/workflow"
+	domainAudit "github.com/execution-hub/execution-hub/internal/domain/audit"
+	domainRule "github.com/execution-hub/execution-hub/internal/domain/rule"
+	domainTrust "github.com/execution-hub/execution-hub/internal/domain/trust"
+	domainUser "github.com/execution-hub/execution-hub/internal/domain/user"
 	"github.com/execution-hub/execution-hub/internal/infrastructure/keystore"
 	"github.com/execution-hub/execution-hub/internal/infrastructure/postgres"
 	"github.com/execution-hub/execution-hub/internal/infrastructure/sse"
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rs/zerolog"
 )
@@ -220,6 +231,30 @@ func TestSSEDeliveryIntegration(t *testing.T) {
 	case <-time.After(5 * time.Second):
 		t.Fatalf("SSE message not received")
 	}
+
+	// A fresh connection without Last-Event-ID is not sent the buffered
+	// notification.
+	freshCtx, freshCancel := context.WithTimeout(context.Background(), time.Second)
+	defer freshCancel()
+	freshReq, err := http.NewRequestWithContext(freshCtx, http.MethodGet, server.URL+"/v1/notifications/sse?client_id=fresh-client&user_id=alice", nil)
+	if err != nil {
+		t.Fatalf("fresh sse request: %v", err)
+	}
+	freshResp, err := client.Do(freshReq)
+	if err != nil {
+		t.Fatalf("fresh sse connect: %v", err)
+	}
+	defer freshResp.Body.Close()
+	reader := bufio.NewReader(freshResp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(line, "data: ") {
+			t.Fatalf("fresh connection received a replayed event: %q", line)
+		}
+	}
 }
 
 type createWorkflowResponse struct {
@@ -232,6 +267,648 @@ type createTaskResponse struct {
 	Status string `json:"status"`
 }
 
+func TestTrustChainConcurrentAppendIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	trustSvc := trust.NewService(postgres.NewTrustRepository(pool), &keystore.StaticKeyStore{}, zerolog.Nop())
+	sources := []string{"gateway-001", "gateway-002"}
+	const workers, rounds = 8, 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			sourceID := sources[w%len(sources)]
+			for r := 0; r < rounds; r++ {
+				inputs := make([]trust.HashChainInput, r%3+1)
+				for i := range inputs {
+					inputs[i] = trust.HashChainInput{
+						EventID:       uuid.New(),
+						SourceID:      sourceID,
+						SourceType:    "GW",
+						EventType:     "SENSOR_READING",
+						Payload:       json.RawMessage(fmt.Sprintf(`{"worker": %d, "round": %d}`, w, r)),
+						SchemaVersion: "1.0.0",
+					}
+				}
+				if _, err := trustSvc.IngestBatch(ctx, inputs); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("ingest: %v", err)
+	}
+
+	var perWorker int64
+	for r := 0; r < rounds; r++ {
+		perWorker += int64(r%3 + 1)
+	}
+	perSource := perWorker * workers / int64(len(sources))
+	for _, sourceID := range sources {
+		result, err := trustSvc.VerifyHashChain(ctx, sourceID, 1, perSource)
+		if err != nil {
+			t.Fatalf("verify %s: %v", sourceID, err)
+		}
+		if !result.IsValid || int64(result.EntriesChecked) != perSource {
+			t.Fatalf("chain %s: valid=%v entries=%d, want %d contiguous entries", sourceID, result.IsValid, result.EntriesChecked, perSource)
+		}
+		var maxSeq, count int64
+		if err := pool.QueryRow(ctx, `
+			SELECT COALESCE(MAX(sequence_num), 0), COUNT(DISTINCT sequence_num)
+			FROM trust_hash_chain_entries WHERE source_id = $1
+		`, sourceID).Scan(&maxSeq, &count); err != nil {
+			t.Fatalf("count %s: %v", sourceID, err)
+		}
+		if maxSeq != perSource || count != perSource {
+			t.Fatalf("chain %s: max sequence %d, %d distinct, want %d", sourceID, maxSeq, count, perSource)
+		}
+	}
+}
+
+func TestAuditChainIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	tsa, err := tsptest.NewAuthority()
+	if err != nil {
+		t.Fatalf("tsa: %v", err)
+	}
+	tsaServer := httptest.NewServer(tsa)
+	defer tsaServer.Close()
+	auditSvc := audit.NewService(postgres.NewAuditRepository(pool), zerolog.Nop(), mustDecodeHex(t, auditKeyHex))
+	auditSvc.SetTimestamper(tsp.NewClient(tsaServer.URL, tsa.Roots(), 0))
+	auditSvc.SetTimestampRoots(tsa.Roots())
+	const workers, rounds = 8, 5
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := auditSvc.LogSync(ctx, &domainAudit.AuditEntry{
+					EntityType: domainAudit.EntityTypeRule,
+					EntityID:   fmt.Sprintf("rule-%d", w),
+					Action:     domainAudit.ActionUpdate,
+					Actor:      "alice",
+					OldValues:  map[string]interface{}{"version": r, "name": "rule"},
+					NewValues:  map[string]interface{}{"version": r + 1, "name": "rule", "threshold": 1.5},
+				}); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("log: %v", err)
+	}
+
+	const total = workers * rounds
+	report, err := auditSvc.VerifyChain(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !report.Valid || report.EntriesChecked != total {
+		t.Fatalf("chain: valid=%v entries=%d issues=%v, want %d valid entries", report.Valid, report.EntriesChecked, report.Issues, total)
+	}
+
+	checkpoint, err := auditSvc.CreateCheckpoint(ctx)
+	if err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if checkpoint.SequenceNum != total {
+		t.Fatalf("checkpoint at %d, want %d", checkpoint.SequenceNum, total)
+	}
+	stored, err := auditSvc.ListCheckpoints(ctx)
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("stored checkpoints: %v %v", stored, err)
+	}
+	if _, err := stored[0].VerifyTimestamp(tsa.Roots()); err != nil {
+		t.Fatalf("stored checkpoint timestamp: %v", err)
+	}
+	report, err = auditSvc.VerifyChain(ctx, 0, 0)
+	if err != nil || report.CheckpointsUsed != 1 || report.TimestampsVerified != 1 {
+		t.Fatalf("checkpointed chain: %+v %v", report, err)
+	}
+
+	if _, err := pool.Exec(ctx, `DELETE FROM audit_logs WHERE sequence_num IN (10, $1)`, total); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	report, err = auditSvc.VerifyChain(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	found := map[domainAudit.ChainIssueType]bool{}
+	for _, issue := range report.Issues {
+		found[issue.Type] = true
+	}
+	if report.Valid || !found[domainAudit.ChainIssueGap] || !found[domainAudit.ChainIssueTruncated] {
+		t.Fatalf("deleted entries not detected: %+v", report.Issues)
+	}
+}
+
+func TestAuditSinkIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	newService := func() *audit.Service {
+		svc := audit.NewService(postgres.NewAuditRepository(pool), zerolog.Nop(), nil)
+		sink, err := audit.NewFileSink(audit.FileSinkConfig{Path: path, Format: domainAudit.ExportFormatCEF})
+		if err != nil {
+			t.Fatalf("file sink: %v", err)
+		}
+		t.Cleanup(func() { _ = sink.Close() })
+		if err := svc.AddSink(sink); err != nil {
+			t.Fatalf("add sink: %v", err)
+		}
+		return svc
+	}
+	logEntries := func(svc *audit.Service, n int) {
+		for i := 0; i < n; i++ {
+			if err := svc.LogSync(ctx, &domainAudit.AuditEntry{EntityType: domainAudit.EntityTypeUser, EntityID: fmt.Sprintf("user-%d", i), Action: domainAudit.ActionLogin, Actor: "alice"}); err != nil {
+				t.Fatalf("log: %v", err)
+			}
+		}
+	}
+	lines := func() int {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read sink file: %v", err)
+		}
+		return strings.Count(string(data), "\n")
+	}
+
+	svc := newService()
+	logEntries(svc, 3)
+	if err := svc.DeliverSinks(ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if got := lines(); got != 3 {
+		t.Fatalf("sink has %d records, want 3", got)
+	}
+
+	// A restarted service resumes from the persisted cursor
+	svc = newService()
+	logEntries(svc, 2)
+	if err := svc.DeliverSinks(ctx); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if got := lines(); got != 5 {
+		t.Fatalf("sink has %d records after restart, want 5", got)
+	}
+}
+
+func TestAuditRetentionIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	repo := postgres.NewAuditRepository(pool)
+	svc := audit.NewService(repo, zerolog.Nop(), mustDecodeHex(t, auditKeyHex))
+	store, err := audit.NewDirArchiveStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("archive store: %v", err)
+	}
+	svc.SetArchiveStore(store)
+
+	// rule-0 and rule-1 are a year old, rule-1 under legal hold; rule-2 is new
+	for i, age := range []int{365, 365, 0} {
+		log, err := domainAudit.NewAuditLog(&domainAudit.AuditEntry{EntityType: domainAudit.EntityTypeRule, EntityID: fmt.Sprintf("rule-%d", i), Action: domainAudit.ActionUpdate, Actor: "alice"})
+		if err != nil {
+			t.Fatalf("new log: %v", err)
+		}
+		log.CreatedAt = log.CreatedAt.AddDate(0, 0, -age)
+		if err := repo.Create(ctx, log); err != nil {
+			t.Fatalf("create log: %v", err)
+		}
+	}
+	if err := svc.SaveRetentionPolicy(ctx, &domainAudit.RetentionPolicy{RetentionDays: 90}, "alice"); err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+	entityType, entityID := domainAudit.EntityTypeRule, "rule-1"
+	if err := svc.CreateLegalHold(ctx, &domainAudit.LegalHold{EntityType: &entityType, EntityID: &entityID, Reason: "litigation", CreatedBy: "alice"}); err != nil {
+		t.Fatalf("legal hold: %v", err)
+	}
+
+	run, err := svc.ApplyRetention(ctx, "alice")
+	if err != nil {
+		t.Fatalf("retention: %v", err)
+	}
+	if run.Purged != 1 || run.Held != 1 || run.Archive == nil {
+		t.Fatalf("retention run: %+v, want 1 purged and 1 held", run)
+	}
+	purged := "rule-0"
+	countRule0 := func() int64 {
+		n, err := repo.Count(ctx, domainAudit.QueryFilter{EntityID: &purged})
+		if err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		return n
+	}
+	if n := countRule0(); n != 0 {
+		t.Fatalf("%d purged logs remain", n)
+	}
+	purges, err := repo.GetByEntityID(ctx, domainAudit.EntityTypeAuditLog, run.Archive.Name)
+	if err != nil || len(purges) != 1 || purges[0].Action != domainAudit.ActionPurge {
+		t.Fatalf("purge entry: %+v %v", purges, err)
+	}
+	verifyChain := func(step string) {
+		report, err := svc.VerifyChain(ctx, 0, 0)
+		if err != nil {
+			t.Fatalf("verify chain %s: %v", step, err)
+		}
+		if !report.Valid {
+			t.Fatalf("chain invalid %s: %v", step, report.Issues)
+		}
+	}
+	verifyChain("after purge")
+
+	report, err := svc.VerifyArchive(ctx, run.Archive.ID)
+	if err != nil || !report.Valid {
+		t.Fatalf("verify archive: %v %+v", err, report)
+	}
+	if _, err := svc.RestoreArchive(ctx, run.Archive.ID, "alice"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if n := countRule0(); n != 1 {
+		t.Fatalf("%d restored logs, want 1", n)
+	}
+	verifyChain("after restore")
+
+	if err := svc.UnrestoreArchive(ctx, run.Archive.ID, "alice"); err != nil {
+		t.Fatalf("unrestore: %v", err)
+	}
+	if n := countRule0(); n != 0 {
+		t.Fatalf("%d logs remain after unrestore", n)
+	}
+	verifyChain("after unrestore")
+}
+
+func TestRuleTrustPolicyIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	secret := []byte("batch-secret")
+	t.Setenv("SIGNING_KEYS", "key-001:"+hex.EncodeToString(secret))
+	keyStore, err := keystore.NewFromEnv()
+	if err != nil {
+		t.Fatalf("keystore: %v", err)
+	}
+	logger := zerolog.Nop()
+	ruleRepo := postgres.NewRuleRepository(pool)
+	trustRepo := postgres.NewTrustRepository(pool)
+	actionRepo := postgres.NewActionRepository(pool)
+	trustSvc := trust.NewService(trustRepo, keyStore, logger)
+	engine := rule.NewEngine(ruleRepo, actionRepo, action.NewService(actionRepo, ruleRepo, logger), logger)
+	engine.SetTrustLookup(trustSvc)
+	trustSvc.AddEventListener(engine)
+	trustSvc.AddVerificationListener(engine)
+
+	ruleSvc := rule.NewService(ruleRepo, trustRepo, logger)
+	config := json.RawMessage(`{"field":"temperature","operator":">","threshold":80}`)
+	skip := domainRule.NewRule("gated", domainRule.RuleTypeThreshold, config, domainRule.ActionTypeNotify, json.RawMessage(`{"title":"hot"}`))
+	skip.MinTrustLevel = 3
+	tag := domainRule.NewRule("tagged", domainRule.RuleTypeThreshold, config, domainRule.ActionTypeNotify, json.RawMessage(`{"title":"hot"}`))
+	tag.MinTrustLevel = 3
+	tag.LowTrustAction = domainRule.LowTrustActionTag
+	for _, r := range []*domainRule.Rule{skip, tag} {
+		r.EffectiveFrom = time.Now().Add(-time.Hour)
+		if err := ruleSvc.CreateRule(ctx, r); err != nil {
+			t.Fatalf("create rule: %v", err)
+		}
+	}
+	stored, err := ruleRepo.GetByRuleID(ctx, skip.RuleID)
+	if err != nil || stored.MinTrustLevel != 3 || stored.LowTrustAction != domainRule.LowTrustActionSkip {
+		t.Fatalf("stored rule: %+v %v", stored, err)
+	}
+
+	countActions := func() int {
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM actions WHERE rule_id = ANY($1)`, []uuid.UUID{skip.RuleID, tag.RuleID}).Scan(&n); err != nil {
+			t.Fatalf("count actions: %v", err)
+		}
+		return n
+	}
+
+	eventID := uuid.New()
+	entries, err := trustSvc.IngestBatch(ctx, []trust.HashChainInput{{
+		EventID:       eventID,
+		SourceID:      "gateway-001",
+		SourceType:    "GW",
+		EventType:     "SENSOR_READING",
+		Payload:       json.RawMessage(`{"temperature": 95}`),
+		SchemaVersion: "1.0.0",
+	}})
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if n := countActions(); n != 0 {
+		t.Fatalf("%d actions fired on untrusted data", n)
+	}
+	pending, err := ruleRepo.ListPendingEvaluations(ctx, []uuid.UUID{eventID})
+	if err != nil || len(pending) != 1 || pending[0].RuleID != tag.RuleID {
+		t.Fatalf("pending evaluations: %+v %v", pending, err)
+	}
+
+	batchHash := domainTrust.ComputeMerkleRoot([]string{entries[0].EventHash})
+	sig, err := trustSvc.RegisterBatchSignature(ctx, trust.BatchSignatureInput{
+		SourceID:  "gateway-001",
+		EventIDs:  []uuid.UUID{eventID},
+		BatchHash: batchHash,
+		Signature: domainTrust.CreateHMAC(batchHash, secret),
+		KeyID:     "key-001",
+		SignedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("register signature: %v", err)
+	}
+	if _, err := trustSvc.VerifyBatchSignature(ctx, sig.BatchID); err != nil {
+		t.Fatalf("verify signature: %v", err)
+	}
+
+	if n := countActions(); n != 2 {
+		t.Fatalf("%d actions after verification, want one per rule", n)
+	}
+	resolved, err := ruleRepo.GetEvaluationByID(ctx, pending[0].EvaluationID)
+	if err != nil || resolved.TrustStatus != domainRule.TrustStatusTrusted {
+		t.Fatalf("resolved evaluation: %+v %v", resolved, err)
+	}
+	if ok, err := ruleRepo.ResolveEvaluationTrust(ctx, resolved.EvaluationID, domainRule.TrustStatusRejected); err != nil || ok {
+		t.Fatalf("resolving twice: %v %v", ok, err)
+	}
+}
+
+func TestUserEmailIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	repo := postgres.NewUserRepository(pool)
+	email := "bob@example.com"
+	now := time.Now().UTC()
+	created := &domainUser.User{
+		UserID:       uuid.New(),
+		Username:     "bob",
+		PasswordHash: "hash",
+		Role:         domainUser.RoleOperator,
+		Type:         domainUser.TypeHuman,
+		Email:        &email,
+		Status:       domainUser.StatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if err := repo.Create(ctx, created); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	stored, err := repo.GetByID(ctx, created.UserID)
+	if err != nil || stored == nil {
+		t.Fatalf("get user: %+v %v", stored, err)
+	}
+	if stored.Email == nil || *stored.Email != email {
+		t.Fatalf("stored email: %v", stored.Email)
+	}
+
+	updated := "robert@example.com"
+	stored.Email = &updated
+	if err := repo.Update(ctx, stored); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+	stored, err = repo.GetByUsername(ctx, "bob")
+	if err != nil || stored == nil || stored.Email == nil || *stored.Email != updated {
+		t.Fatalf("updated email: %+v %v", stored, err)
+	}
+}
+
+func TestEventSchemaIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	logger := zerolog.Nop()
+	schemaRepo := postgres.NewTrustSchemaRepository(pool)
+	trustRepo := postgres.NewTrustRepository(pool)
+	trustSvc := trust.NewService(trustRepo, &keystore.StaticKeyStore{}, logger)
+	trustSvc.SetSchemaRegistry(schemaRepo)
+
+	document := json.RawMessage(`{"type":"object","required":["temperature"],"properties":{"temperature":{"type":"number"}}}`)
+	if _, err := trustSvc.RegisterSchema(ctx, trust.EventSchemaInput{EventType: "SENSOR_READING", SchemaVersion: "1.0.0", Schema: document, OnInvalid: domainTrust.InvalidPayloadFlag, CreatedBy: "admin"}); err != nil {
+		t.Fatalf("register schema: %v", err)
+	}
+	if err := schemaRepo.CreateEventSchema(ctx, &domainTrust.EventSchema{EventType: "SENSOR_READING", SchemaVersion: "1.0.0", Schema: document, OnInvalid: domainTrust.InvalidPayloadFlag, Compatibility: domainTrust.SchemaCompatibilityBackward, CreatedBy: "admin", CreatedAt: time.Now()}); !errors.Is(err, domainTrust.ErrSchemaConflict) {
+		t.Fatalf("duplicate version: %v", err)
+	}
+	stricter := json.RawMessage(`{"type":"object","required":["temperature","unit"],"properties":{"temperature":{"type":"number"},"unit":{"type":"string"}}}`)
+	if _, err := trustSvc.RegisterSchema(ctx, trust.EventSchemaInput{EventType: "SENSOR_READING", SchemaVersion: "2.0.0", Schema: stricter, CreatedBy: "admin"}); !errors.Is(err, domainTrust.ErrSchemaIncompatible) {
+		t.Fatalf("incompatible version: %v", err)
+	}
+
+	valid, flagged := uuid.New(), uuid.New()
+	entries, err := trustSvc.IngestBatch(ctx, []trust.HashChainInput{
+		{EventID: valid, SourceID: "gateway-001", SourceType: "GW", EventType: "SENSOR_READING", Payload: json.RawMessage(`{"temperature": 21}`), SchemaVersion: "1.0.0"},
+		{EventID: flagged, SourceID: "gateway-001", SourceType: "GW", EventType: "SENSOR_READING", Payload: json.RawMessage(`{"temperature": "hot"}`), SchemaVersion: "1.0.0"},
+	})
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if entries[0].TrustLevel != domainTrust.TrustLevelT2 || entries[1].TrustLevel != domainTrust.TrustLevelT1 {
+		t.Fatalf("entry trust levels: %d %d", entries[0].TrustLevel, entries[1].TrustLevel)
+	}
+	stored, err := trustRepo.GetEvent(ctx, flagged)
+	if err != nil || len(stored.ValidationErrors) != 1 || stored.TrustLevel != domainTrust.TrustLevelT1 {
+		t.Fatalf("flagged event: %+v %v", stored, err)
+	}
+	metadata, err := trustSvc.GetTrustMetadata(ctx, flagged)
+	if err != nil || len(metadata.Flags) != 1 || metadata.Flags[0] != domainTrust.FlagSchemaInvalid {
+		t.Fatalf("flagged metadata: %+v %v", metadata, err)
+	}
+
+	ruleSvc := rule.NewService(postgres.NewRuleRepository(pool), trustRepo, logger)
+	ruleSvc.SetSchemaRegistry(schemaRepo)
+	mistyped := domainRule.NewRule("nested", domainRule.RuleTypeThreshold, json.RawMessage(`{"field":"temperature.raw","operator":">","threshold":80,"eventType":"SENSOR_READING"}`), domainRule.ActionTypeNotify, json.RawMessage(`{"title":"hot"}`))
+	if err := ruleSvc.CreateRule(ctx, mistyped); err == nil {
+		t.Fatalf("rule reading a field the schema rules out was created")
+	}
+}
+
+func TestTrustChainMonitorIntegration(t *testing.T) {
+	dsn := testDatabaseURL(t)
+	ctx := context.Background()
+	pool, err := postgres.NewPool(ctx, dsn)
+	if err != nil {
+		t.Fatalf("db pool: %v", err)
+	}
+	defer pool.Close()
+	if err := postgres.RunMigrations(ctx, pool, filepath.Join(repoRoot(t), "internal", "migrations")); err != nil {
+		t.Fatalf("migrations: %v", err)
+	}
+	if err := resetDatabase(ctx, pool); err != nil {
+		t.Fatalf("reset db: %v", err)
+	}
+
+	logger := zerolog.Nop()
+	notificationRepo := postgres.NewNotificationRepository(pool)
+	notificationSvc := notification.NewService(notificationRepo, postgres.NewActionRepository(pool), postgres.NewRuleRepository(pool), sse.NewHub(), logger)
+	monitorRepo := postgres.NewTrustChainMonitorRepository(pool)
+	trustSvc := trust.NewService(postgres.NewTrustRepository(pool), &keystore.StaticKeyStore{}, logger)
+	trustSvc.SetChainMonitor(monitorRepo)
+	trustSvc.SetChainBreakEscalation(notificationSvc, trust.EscalationTarget{})
+
+	inputs := make([]trust.HashChainInput, 5)
+	for i := range inputs {
+		inputs[i] = trust.HashChainInput{EventID: uuid.New(), SourceID: "gateway-001", SourceType: "GW", EventType: "SENSOR_READING", Payload: json.RawMessage(fmt.Sprintf(`{"reading": %d}`, i))}
+	}
+	if _, err := trustSvc.IngestBatch(ctx, inputs[:3]); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	sweep, err := trustSvc.VerifyChains(ctx)
+	if err != nil || sweep.EntriesChecked != 3 || len(sweep.Breaks) != 0 {
+		t.Fatalf("first sweep: %+v %v", sweep, err)
+	}
+
+	if _, err := trustSvc.IngestBatch(ctx, inputs[3:]); err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE trust_hash_chain_entries SET event_hash = 'tampered' WHERE source_id = 'gateway-001' AND sequence_num = 4`); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	sweep, err = trustSvc.VerifyChains(ctx)
+	if err != nil || sweep.EntriesChecked != 2 || len(sweep.Breaks) != 1 {
+		t.Fatalf("second sweep: %+v %v", sweep, err)
+	}
+
+	breaks, err := trustSvc.ListChainBreaks(ctx, "gateway-001", 0)
+	if err != nil || len(breaks) != 1 || breaks[0].BreakAt != 4 || breaks[0].Kind != domainTrust.ChainBreakHashMismatch {
+		t.Fatalf("breaks: %+v %v", breaks, err)
+	}
+	if breaks[0].NotificationID == nil {
+		t.Fatalf("break was not escalated")
+	}
+	escalation, err := notificationRepo.GetByID(ctx, *breaks[0].NotificationID)
+	if err != nil || escalation == nil || !strings.Contains(escalation.Body, "entry 4 of source gateway-001") {
+		t.Fatalf("escalation: %+v %v", escalation, err)
+	}
+
+	// Breaks are recorded once, even when a source is checked again
+	if _, err := pool.Exec(ctx, `DELETE FROM trust_chain_verifications`); err != nil {
+		t.Fatalf("reset verification: %v", err)
+	}
+	if sweep, err = trustSvc.VerifyChains(ctx); err != nil || len(sweep.Breaks) != 0 {
+		t.Fatalf("repeated sweep: %+v %v", sweep, err)
+	}
+	coverage, err := trustSvc.GetChainCoverage(ctx)
+	if err != nil || len(coverage) != 1 || coverage[0].Coverage != 1 || coverage[0].BreakCount != 1 {
+		t.Fatalf("coverage: %+v %v", coverage, err)
+	}
+
+	// A break left without an escalation is escalated by the next sweep
+	if _, err := pool.Exec(ctx, `UPDATE trust_chain_breaks SET notification_id = NULL`); err != nil {
+		t.Fatalf("unlink escalation: %v", err)
+	}
+	if _, err := trustSvc.VerifyChains(ctx); err != nil {
+		t.Fatalf("retry sweep: %v", err)
+	}
+	breaks, err = trustSvc.ListChainBreaks(ctx, "gateway-001", 0)
+	if err != nil || len(breaks) != 1 || breaks[0].NotificationID == nil {
+		t.Fatalf("break was not escalated again: %+v %v", breaks, err)
+	}
+
+	// Deleting the tail leaves the head below the verified sequence
+	if _, err := pool.Exec(ctx, `DELETE FROM trust_hash_chain_entries WHERE source_id = 'gateway-001' AND sequence_num = 5`); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	sweep, err = trustSvc.VerifyChains(ctx)
+	if err != nil || len(sweep.Breaks) != 1 || sweep.Breaks[0].Kind != domainTrust.ChainBreakTruncated || sweep.Breaks[0].BreakAt != 5 {
+		t.Fatalf("truncation sweep: %+v %v", sweep, err)
+	}
+	if sweep.Breaks[0].NotificationID == nil {
+		t.Fatalf("truncation was not escalated")
+	}
+}
+
 func postJSON(t *testing.T, client *http.Client, url string, body interface{}, out interface{}) {
 	t.Helper()
 	data, err := json.Marshal(body)
@@ -338,6 +1015,10 @@ func newTestServer(t *testing.T) (*httptest.Server, func()) {
 	notificationSvc := notification.NewService(notificationRepo, actionRepo, ruleRepo, sseHub, logger)
 	auditSvc := audit.NewService(auditRepo, logger, mustDecodeHex(t, auditKeyHex))
 	trustSvc := trust.NewService(trustRepo, keyStore, logger)
+	trustSvc.SetKeyRegistry(postgres.NewTrustKeyRepository(pool))
+	trustSvc.SetSchemaRegistry(postgres.NewTrustSchemaRepository(pool))
+	trustSvc.SetChainMonitor(postgres.NewTrustChainMonitorRepository(pool))
+	trustSvc.SetChainBreakEscalation(notificationSvc, trust.EscalationTarget{})
 	workflowSvc := workflow.NewService(workflowRepo, logger)
 	executorSvc := executor.NewService(execRepo, logger)
 	userSvc := user.NewService(userRepo, logger)
@@ -355,7 +1036,7 @@ func newTestServer(t *testing.T) (*httptest.Server, func()) {
 
 	taskSvc := task.NewService(taskRepo, stepRepo, workflowRepo, actionSvc, auditSvc, orchestratorSvc, logger)
 	approvalSvc := approval.NewService(approvalRepo, userRepo, taskRepo, stepRepo, workflowRepo, taskSvc, actionSvc, auditSvc, sseHub, logger)
-	apiServer := httpapi.NewServer(workflowSvc, taskSvc, executorSvc, actionSvc, notificationSvc, auditSvc, trustSvc, authSvc, userSvc, approvalSvc, sseHub, "exec_hub_session", false)
+	apiServer := httpapi.NewServer(workflowSvc, taskSvc, executorSvc, actionSvc, notificationSvc, auditSvc, trustSvc, authSvc, userSvc, approvalSvc, nil, nil, sseHub, "exec_hub_session", false)
 	server := httptest.NewServer(apiServer.Router())
 
 	cleanup := func() {
@@ -396,11 +1077,21 @@ func resetDatabase(ctx context.Context, pool *pgxpool.Pool) error {
 			workflow_definitions,
 			executors,
 			audit_logs,
+			audit_checkpoints,
+			audit_sink_cursors,
+			audit_retention_policies,
+			audit_legal_holds,
+			audit_archive_entries,
+			audit_archives,
 			rule_evaluations,
 			rules,
 			trust_hash_chain_entries,
 			trust_batch_signatures,
 			trust_metadata,
+			trust_source_keys,
+			trust_event_schemas,
+			trust_chain_verifications,
+			trust_chain_breaks,
 			events,
 			approval_decisions,
 			approvals,
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_events_ts_server ON events(ts_server, event_id);
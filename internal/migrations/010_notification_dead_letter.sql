@@ -0,0 +1,12 @@
+ALTER TABLE notifications
+  ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_retry_due ON notifications(next_attempt_at) WHERE status='FAILED';
+CREATE INDEX IF NOT EXISTS idx_notifications_dead_lettered_at ON notifications(dead_lettered_at DESC) WHERE status='DEAD_LETTER';
+
+ALTER TABLE notification_attempts
+  ADD COLUMN IF NOT EXISTS decision TEXT,
+  ADD COLUMN IF NOT EXISTS permanent BOOLEAN NOT NULL DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS backoff_ms BIGINT,
+  ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ;
@@ -0,0 +1,17 @@
+ALTER TABLE notifications
+  ADD COLUMN IF NOT EXISTS throttle_key TEXT,
+  ADD COLUMN IF NOT EXISTS held_until TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS digest_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_throttle_key_created_at ON notifications(throttle_key, created_at DESC) WHERE throttle_key IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_notifications_held_until ON notifications(held_until) WHERE status='HELD';
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  id BIGSERIAL PRIMARY KEY,
+  target_type TEXT NOT NULL,
+  target_id TEXT NOT NULL,
+  throttle JSONB,
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (target_type, target_id)
+);
@@ -0,0 +1,11 @@
+ALTER TABLE notifications
+  ADD COLUMN IF NOT EXISTS rule_id UUID,
+  ADD COLUMN IF NOT EXISTS workflow_id UUID,
+  ADD COLUMN IF NOT EXISTS routed_from TEXT,
+  ADD COLUMN IF NOT EXISTS defer_reason TEXT;
+
+ALTER TABLE notification_preferences
+  ADD COLUMN IF NOT EXISTS channels JSONB,
+  ADD COLUMN IF NOT EXISTS min_priority JSONB,
+  ADD COLUMN IF NOT EXISTS quiet_hours JSONB,
+  ADD COLUMN IF NOT EXISTS mutes JSONB;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS sse_events (
+  seq BIGSERIAL PRIMARY KEY,
+  stream TEXT NOT NULL,
+  message JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_sse_events_created_at ON sse_events(created_at);
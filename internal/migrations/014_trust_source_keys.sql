@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS trust_source_keys (
+  id BIGSERIAL PRIMARY KEY,
+  key_id TEXT NOT NULL UNIQUE,
+  source_id TEXT NOT NULL,
+  algorithm TEXT NOT NULL,
+  public_key BYTEA NOT NULL,
+  not_before TIMESTAMPTZ NOT NULL,
+  not_after TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  revocation_reason TEXT,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_trust_source_keys_source ON trust_source_keys(source_id);
@@ -0,0 +1,3 @@
+-- Batches recorded before Merkle hashing keep the flat scheme
+ALTER TABLE trust_batch_signatures
+  ADD COLUMN IF NOT EXISTS hash_scheme TEXT NOT NULL DEFAULT 'FLAT_SHA256';
@@ -0,0 +1,18 @@
+-- Entries written before the audit chain existed stay unchained
+ALTER TABLE audit_logs
+  ADD COLUMN IF NOT EXISTS sequence_num BIGINT,
+  ADD COLUMN IF NOT EXISTS prev_hash TEXT,
+  ADD COLUMN IF NOT EXISTS entry_hash TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_sequence ON audit_logs(sequence_num);
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints (
+  id BIGSERIAL PRIMARY KEY,
+  sequence_num BIGINT NOT NULL,
+  entry_hash TEXT NOT NULL,
+  signature_alg TEXT NOT NULL,
+  signature TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_checkpoints_sequence ON audit_checkpoints(sequence_num);
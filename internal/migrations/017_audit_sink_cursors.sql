@@ -0,0 +1,8 @@
+-- Position of each audit sink in the audit log; entries after it are
+-- delivered again after a crash
+CREATE TABLE IF NOT EXISTS audit_sink_cursors (
+  sink_name TEXT PRIMARY KEY,
+  cursor_created_at TIMESTAMPTZ NOT NULL,
+  cursor_id BIGINT NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,61 @@
+-- Retention per entity type and/or risk level; NULL matches any
+CREATE TABLE IF NOT EXISTS audit_retention_policies (
+  id BIGSERIAL PRIMARY KEY,
+  entity_type TEXT,
+  risk_level TEXT,
+  retention_days INT NOT NULL CHECK (retention_days > 0),
+  created_at TIMESTAMPTZ NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_retention_scope
+  ON audit_retention_policies ((COALESCE(entity_type, '')), (COALESCE(risk_level, '')));
+
+-- A hold names either an entity or a trace
+CREATE TABLE IF NOT EXISTS audit_legal_holds (
+  id UUID PRIMARY KEY,
+  entity_type TEXT,
+  entity_id TEXT,
+  trace_id TEXT,
+  reason TEXT NOT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  released_at TIMESTAMPTZ,
+  released_by TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_legal_holds_active ON audit_legal_holds(created_at) WHERE released_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS audit_archives (
+  id BIGSERIAL PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  entry_count INT NOT NULL,
+  first_sequence BIGINT NOT NULL,
+  last_sequence BIGINT NOT NULL,
+  oldest_at TIMESTAMPTZ NOT NULL,
+  newest_at TIMESTAMPTZ NOT NULL,
+  sha256 TEXT NOT NULL,
+  signature_alg TEXT NOT NULL,
+  signature TEXT NOT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  restored_at TIMESTAMPTZ
+);
+
+-- Chain fields of archived entries, so the audit chain stays verifiable
+-- without the archived rows
+CREATE TABLE IF NOT EXISTS audit_archive_entries (
+  sequence_num BIGINT PRIMARY KEY,
+  audit_id UUID NOT NULL,
+  prev_hash TEXT NOT NULL,
+  entry_hash TEXT NOT NULL,
+  archive_id BIGINT NOT NULL REFERENCES audit_archives(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_archive_entries_archive ON audit_archive_entries(archive_id);
+
+-- Rows restored from an archive for queries
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS restored_from BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_restored_from ON audit_logs(restored_from) WHERE restored_from IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_logs(created_at);
@@ -0,0 +1,12 @@
+-- Minimum event trust level per rule and what to do with events below it
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS min_trust_level SMALLINT NOT NULL DEFAULT 0
+  CHECK (min_trust_level BETWEEN 0 AND 3);
+ALTER TABLE rules ADD COLUMN IF NOT EXISTS low_trust_action TEXT NOT NULL DEFAULT 'SKIP'
+  CHECK (low_trust_action IN ('SKIP', 'TAG'));
+
+-- Evaluations made with low-trust events hold their action while PENDING
+ALTER TABLE rule_evaluations ADD COLUMN IF NOT EXISTS trust_status TEXT;
+ALTER TABLE rule_evaluations ADD COLUMN IF NOT EXISTS low_trust_event_ids UUID[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_rule_eval_low_trust_pending
+  ON rule_evaluations USING GIN (low_trust_event_ids) WHERE trust_status = 'PENDING';
@@ -0,0 +1,15 @@
+-- JSON Schema documents for event payloads, per event type and version
+CREATE TABLE IF NOT EXISTS trust_event_schemas (
+  id BIGSERIAL PRIMARY KEY,
+  event_type TEXT NOT NULL,
+  schema_version TEXT NOT NULL,
+  schema JSONB NOT NULL,
+  on_invalid TEXT NOT NULL CHECK (on_invalid IN ('REJECT', 'FLAG')),
+  compatibility TEXT NOT NULL CHECK (compatibility IN ('BACKWARD', 'NONE')),
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (event_type, schema_version)
+);
+
+-- Violations of a flagged event's payload against its schema
+ALTER TABLE events ADD COLUMN IF NOT EXISTS validation_errors TEXT[];
@@ -0,0 +1,22 @@
+-- Progress of the scheduled hash chain verifier per source
+CREATE TABLE IF NOT EXISTS trust_chain_verifications (
+  source_id TEXT PRIMARY KEY,
+  verified_through BIGINT NOT NULL,
+  last_chain_hash TEXT NOT NULL,
+  last_verified_at TIMESTAMPTZ NOT NULL
+);
+
+-- Breaks found by the verifier, each recorded once
+CREATE TABLE IF NOT EXISTS trust_chain_breaks (
+  id BIGSERIAL PRIMARY KEY,
+  source_id TEXT NOT NULL,
+  break_at BIGINT NOT NULL,
+  kind TEXT NOT NULL,
+  expected_hash TEXT NOT NULL,
+  actual_hash TEXT NOT NULL,
+  detected_at TIMESTAMPTZ NOT NULL,
+  notification_id UUID,
+  UNIQUE (source_id, break_at, kind)
+);
+
+CREATE INDEX IF NOT EXISTS idx_trust_chain_breaks_detected ON trust_chain_breaks(detected_at DESC);
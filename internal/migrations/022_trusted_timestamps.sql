@@ -0,0 +1,2 @@
+-- RFC 3161 timestamp tokens of audit checkpoints, when a TSA is configured
+ALTER TABLE audit_checkpoints ADD COLUMN IF NOT EXISTS timestamp_token BYTEA;
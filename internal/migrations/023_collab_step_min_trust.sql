@@ -0,0 +1,2 @@
+-- Minimum participant trust score to claim a collaboration step
+ALTER TABLE collab_steps ADD COLUMN IF NOT EXISTS min_trust_score INT NOT NULL DEFAULT 0;
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -14,6 +15,7 @@ import (
 
 	"github.com/execution-hub/execution-hub/internal/p2p/consensus"
 	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+	"github.com/execution-hub/execution-hub/internal/p2p/state"
 )
 
 // Server provides HTTP endpoints for P2P runtime.
@@ -40,13 +42,20 @@ func (s *Server) Router() http.Handler {
 		r.Post("/raft/join", s.raftJoin)
 		r.Post("/raft/remove", s.raftRemove)
 
+		r.Get("/sessions", s.listSessions)
 		r.Get("/sessions/{sessionId}", s.getSession)
 		r.Get("/sessions/{sessionId}/participants", s.listParticipants)
+		r.Get("/sessions/{sessionId}/steps", s.listSteps)
 		r.Get("/sessions/{sessionId}/steps/open", s.listOpenSteps)
 		r.Get("/sessions/{sessionId}/events", s.listEvents)
 
 		r.Get("/steps/{stepId}", s.getStep)
 		r.Get("/steps/{stepId}/artifacts", s.listArtifacts)
+		r.Get("/steps/{stepId}/offers", s.listOffers)
+
+		r.Get("/templates", s.listTemplates)
+		r.Get("/templates/{templateId}", s.getTemplate)
+		r.Get("/templates/{templateId}/versions", s.listTemplateVersions)
 	})
 
 	return r
@@ -93,6 +102,24 @@ func (s *Server) submitTx(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r, 100, 500)
+	query := r.URL.Query()
+	filter := state.SessionFilter{
+		Status:     query.Get("status"),
+		WorkflowID: query.Get("workflow_id"),
+	}
+	sessions, next, err := s.node.Machine().ListSessions(filter, limit, query.Get("cursor"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"sessions":    sessions,
+		"next_cursor": next,
+	})
+}
+
 func (s *Server) getSession(w http.ResponseWriter, r *http.Request) {
 	sessionID := strings.TrimSpace(chi.URLParam(r, "sessionId"))
 	session, ok := s.node.Machine().GetSession(sessionID)
@@ -109,11 +136,42 @@ func (s *Server) listParticipants(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "NOT_FOUND", "session not found", nil)
 		return
 	}
-	limit, offset := parseLimitOffset(r, 100, 500)
-	participants := s.node.Machine().ListParticipants(sessionID, limit, offset)
+	limit := parseLimit(r, 100, 500)
+	query := r.URL.Query()
+	filter := state.ParticipantFilter{
+		Type:       query.Get("type"),
+		Capability: query.Get("capability"),
+	}
+	participants, next, err := s.node.Machine().ListParticipants(sessionID, filter, limit, query.Get("cursor"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
 	respondJSON(w, http.StatusOK, map[string]any{
 		"session_id":   sessionID,
 		"participants": participants,
+		"next_cursor":  next,
+	})
+}
+
+func (s *Server) listSteps(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimSpace(chi.URLParam(r, "sessionId"))
+	if _, ok := s.node.Machine().GetSession(sessionID); !ok {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "session not found", nil)
+		return
+	}
+	limit := parseLimit(r, 100, 500)
+	query := r.URL.Query()
+	filter := state.StepFilter{Status: query.Get("status")}
+	steps, next, err := s.node.Machine().ListSteps(sessionID, filter, limit, query.Get("cursor"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"session_id":  sessionID,
+		"steps":       steps,
+		"next_cursor": next,
 	})
 }
 
@@ -123,19 +181,20 @@ func (s *Server) listOpenSteps(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "NOT_FOUND", "session not found", nil)
 		return
 	}
-	limit, offset := parseLimitOffset(r, 100, 500)
+	limit := parseLimit(r, 100, 500)
 	var participantID *string
 	if raw := strings.TrimSpace(r.URL.Query().Get("participant_id")); raw != "" {
 		participantID = &raw
 	}
-	steps, err := s.node.Machine().ListOpenSteps(sessionID, participantID, time.Now().UTC(), limit, offset)
+	steps, next, err := s.node.Machine().ListOpenSteps(sessionID, participantID, time.Now().UTC(), limit, r.URL.Query().Get("cursor"))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
 		return
 	}
 	respondJSON(w, http.StatusOK, map[string]any{
-		"session_id": sessionID,
-		"steps":      steps,
+		"session_id":  sessionID,
+		"steps":       steps,
+		"next_cursor": next,
 	})
 }
 
@@ -145,11 +204,31 @@ func (s *Server) listEvents(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusNotFound, "NOT_FOUND", "session not found", nil)
 		return
 	}
-	limit, offset := parseLimitOffset(r, 100, 500)
-	events := s.node.Machine().ListEvents(sessionID, limit, offset)
+	limit := parseLimit(r, 100, 500)
+	query := r.URL.Query()
+	filter := state.EventFilter{
+		Type:   query.Get("type"),
+		StepID: query.Get("step_id"),
+		Actor:  query.Get("actor"),
+	}
+	var err error
+	if filter.Since, err = parseTimeParam(r, "since"); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
+	if filter.Until, err = parseTimeParam(r, "until"); err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
+	events, next, err := s.node.Machine().ListEvents(sessionID, filter, limit, query.Get("cursor"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
 	respondJSON(w, http.StatusOK, map[string]any{
-		"session_id": sessionID,
-		"events":     events,
+		"session_id":  sessionID,
+		"events":      events,
+		"next_cursor": next,
 	})
 }
 
@@ -177,6 +256,66 @@ func (s *Server) listArtifacts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *Server) listOffers(w http.ResponseWriter, r *http.Request) {
+	stepID := strings.TrimSpace(chi.URLParam(r, "stepId"))
+	_, ok := s.node.Machine().GetStep(stepID)
+	if !ok {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "step not found", nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"step_id": stepID,
+		"offers":  s.node.Machine().ListStepOffers(stepID),
+	})
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r, 100, 500)
+	query := r.URL.Query()
+	filter := state.TemplateFilter{Status: query.Get("status")}
+	templates, next, err := s.node.Machine().ListTemplates(filter, limit, query.Get("cursor"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "INVALID_PARAM", err.Error(), nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"templates":   templates,
+		"next_cursor": next,
+	})
+}
+
+func (s *Server) getTemplate(w http.ResponseWriter, r *http.Request) {
+	templateID := strings.TrimSpace(chi.URLParam(r, "templateId"))
+	version := 0
+	if raw := strings.TrimSpace(r.URL.Query().Get("version")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			respondError(w, http.StatusBadRequest, "INVALID_PARAM", "version must be a positive integer", nil)
+			return
+		}
+		version = parsed
+	}
+	template, ok := s.node.Machine().GetTemplate(templateID, version)
+	if !ok {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "template not found", nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, template)
+}
+
+func (s *Server) listTemplateVersions(w http.ResponseWriter, r *http.Request) {
+	templateID := strings.TrimSpace(chi.URLParam(r, "templateId"))
+	versions := s.node.Machine().ListTemplateVersions(templateID)
+	if len(versions) == 0 {
+		respondError(w, http.StatusNotFound, "NOT_FOUND", "template not found", nil)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]any{
+		"template_id": templateID,
+		"versions":    versions,
+	})
+}
+
 func (s *Server) stateStats(w http.ResponseWriter, _ *http.Request) {
 	respondJSON(w, http.StatusOK, s.node.Machine().StateStats(time.Now().UTC()))
 }
@@ -262,29 +401,33 @@ func decodeBody(r *http.Request, v any) error {
 	return dec.Decode(v)
 }
 
-func parseLimitOffset(r *http.Request, defaultLimit, maxLimit int) (int, int) {
+func parseLimit(r *http.Request, defaultLimit, maxLimit int) int {
 	limit := defaultLimit
-	offset := 0
 	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
 		if parsed, err := strconv.Atoi(raw); err == nil {
 			limit = parsed
 		}
 	}
-	if raw := strings.TrimSpace(r.URL.Query().Get("offset")); raw != "" {
-		if parsed, err := strconv.Atoi(raw); err == nil {
-			offset = parsed
-		}
-	}
 	if limit <= 0 {
 		limit = defaultLimit
 	}
 	if limit > maxLimit {
 		limit = maxLimit
 	}
-	if offset < 0 {
-		offset = 0
+	return limit
+}
+
+func parseTimeParam(r *http.Request, key string) (*time.Time, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be RFC3339", key)
 	}
-	return limit, offset
+	parsed = parsed.UTC()
+	return &parsed, nil
 }
 
 func respondJSON(w http.ResponseWriter, status int, payload any) {
@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"context"
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -26,6 +27,9 @@ type Config struct {
 	Bootstrap      bool
 	SnapshotRetain int
 	ApplyTimeout   time.Duration
+	// SchedulerKey is the public key STEP_ASSIGN transactions must be
+	// signed with. It must be the same on every node.
+	SchedulerKey ed25519.PublicKey
 }
 
 // Node wraps Raft + deterministic state machine.
@@ -72,6 +76,7 @@ func NewNode(cfg Config) (*Node, error) {
 	}
 
 	machine := state.NewMachine()
+	machine.SetSchedulerKey(cfg.SchedulerKey)
 	fsm := &fsm{machine: machine}
 
 	logStore, err := raftboltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft-log.bolt"))
@@ -126,11 +131,14 @@ func NewNode(cfg Config) (*Node, error) {
 	return n, nil
 }
 
-// ApplyTx replicates one signed transaction through Raft.
+// ApplyTx admits and replicates one signed transaction through Raft.
 func (n *Node) ApplyTx(ctx context.Context, tx protocol.Tx) error {
 	if err := tx.Verify(); err != nil {
 		return err
 	}
+	if err := n.machine.Admit(tx); err != nil {
+		return err
+	}
 	data, err := json.Marshal(tx)
 	if err != nil {
 		return err
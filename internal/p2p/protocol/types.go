@@ -23,6 +23,13 @@ const (
 	OpDecisionOpen    Operation = "DECISION_OPEN"
 	OpVoteCast        Operation = "VOTE_CAST"
 	OpStepResolve     Operation = "STEP_RESOLVE"
+	OpClaimRenew      Operation = "CLAIM_RENEW"
+	OpStepAssign      Operation = "STEP_ASSIGN"
+	OpAssignAccept    Operation = "ASSIGN_ACCEPT"
+	OpAssignDecline   Operation = "ASSIGN_DECLINE"
+
+	OpTemplateRegister  Operation = "TEMPLATE_REGISTER"
+	OpTemplateDeprecate Operation = "TEMPLATE_DEPRECATE"
 )
 
 var validOps = map[Operation]struct{}{
@@ -35,6 +42,13 @@ var validOps = map[Operation]struct{}{
 	OpDecisionOpen:    {},
 	OpVoteCast:        {},
 	OpStepResolve:     {},
+	OpClaimRenew:      {},
+	OpStepAssign:      {},
+	OpAssignAccept:    {},
+	OpAssignDecline:   {},
+
+	OpTemplateRegister:  {},
+	OpTemplateDeprecate: {},
 }
 
 // Tx is the signed, replicated command envelope.
@@ -159,23 +173,45 @@ func DecodePayload[T any](raw json.RawMessage) (T, error) {
 }
 
 // SessionStep defines a deterministic step definition in session create tx.
+// RequiredCapabilities use the capability grammar (e.g. "code.review",
+// "python>=3.11", "?gpu") and MinTrustScore gates who may claim the step.
+// MaxLeaseSeconds caps how long one claim may be held across renewals and
+// RenewAudit selects which CLAIM_RENEW txs are recorded as events.
 type SessionStep struct {
 	StepID               string   `json:"step_id"`
 	StepKey              string   `json:"step_key"`
 	Name                 string   `json:"name"`
 	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+	MinTrustScore        int      `json:"min_trust_score,omitempty"`
 	DependsOn            []string `json:"depends_on,omitempty"`
 	LeaseTTLSeconds      int      `json:"lease_ttl_seconds,omitempty"`
+	MaxLeaseSeconds      int      `json:"max_lease_seconds,omitempty"`
+	RenewAudit           string   `json:"renew_audit,omitempty"`
 }
 
+// SessionCreatePayload either lists steps inline or instantiates a
+// registered template. TemplateVersion 0 selects the latest active version.
 type SessionCreatePayload struct {
-	SessionID  string          `json:"session_id"`
-	WorkflowID string          `json:"workflow_id,omitempty"`
-	Name       string          `json:"name"`
-	Context    json.RawMessage `json:"context,omitempty"`
-	Steps      []SessionStep   `json:"steps"`
+	SessionID       string            `json:"session_id"`
+	WorkflowID      string            `json:"workflow_id,omitempty"`
+	Name            string            `json:"name"`
+	Context         json.RawMessage   `json:"context,omitempty"`
+	Steps           []SessionStep     `json:"steps"`
+	TemplateID      string            `json:"template_id,omitempty"`
+	TemplateVersion int               `json:"template_version,omitempty"`
+	Params          map[string]string `json:"params,omitempty"`
+	// CapabilityMatching selects how step requirements are matched against
+	// participant capabilities. Empty means CapabilityMatchingExact, which is
+	// how sessions created before structured matching are replayed.
+	CapabilityMatching string `json:"capability_matching,omitempty"`
 }
 
+// Capability matching modes for SessionCreatePayload.CapabilityMatching.
+const (
+	CapabilityMatchingExact      = "EXACT"
+	CapabilityMatchingStructured = "STRUCTURED"
+)
+
 type ParticipantJoinPayload struct {
 	ParticipantID string   `json:"participant_id"`
 	SessionID     string   `json:"session_id"`
@@ -192,6 +228,39 @@ type StepClaimPayload struct {
 	LeaseSeconds  int    `json:"lease_seconds,omitempty"`
 }
 
+// ClaimRenewPayload extends the active claim held by ParticipantID.
+// ClaimID is optional and, when set, must match the active claim.
+type ClaimRenewPayload struct {
+	StepID        string `json:"step_id"`
+	ParticipantID string `json:"participant_id"`
+	ClaimID       string `json:"claim_id,omitempty"`
+	LeaseSeconds  int    `json:"lease_seconds,omitempty"`
+}
+
+// StepAssignPayload offers a step to one participant. The offer holds the
+// step until it is accepted, declined or TimeoutSeconds elapse.
+type StepAssignPayload struct {
+	OfferID        string `json:"offer_id"`
+	StepID         string `json:"step_id"`
+	ParticipantID  string `json:"participant_id"`
+	Policy         string `json:"policy,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// AssignAcceptPayload turns a pending offer into an active claim.
+type AssignAcceptPayload struct {
+	OfferID       string `json:"offer_id"`
+	ParticipantID string `json:"participant_id"`
+	ClaimID       string `json:"claim_id"`
+	LeaseSeconds  int    `json:"lease_seconds,omitempty"`
+}
+
+type AssignDeclinePayload struct {
+	OfferID       string `json:"offer_id"`
+	ParticipantID string `json:"participant_id"`
+	Reason        string `json:"reason,omitempty"`
+}
+
 type StepReleasePayload struct {
 	StepID        string `json:"step_id"`
 	ParticipantID string `json:"participant_id"`
@@ -236,3 +305,43 @@ type StepResolvePayload struct {
 	StepID        string  `json:"step_id"`
 	ParticipantID *string `json:"participant_id,omitempty"`
 }
+
+// TemplateParam declares one substitution parameter. Placeholders use the
+// form {{name}} in template names, step fields and context string values.
+type TemplateParam struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Default     *string `json:"default,omitempty"`
+}
+
+// TemplateStep is a step blueprint. Step IDs are derived per session at
+// instantiation, and DependsOn references step keys within the template.
+type TemplateStep struct {
+	StepKey              string   `json:"step_key"`
+	Name                 string   `json:"name"`
+	RequiredCapabilities []string `json:"required_capabilities,omitempty"`
+	MinTrustScore        int      `json:"min_trust_score,omitempty"`
+	DependsOn            []string `json:"depends_on,omitempty"`
+	LeaseTTLSeconds      int      `json:"lease_ttl_seconds,omitempty"`
+	MaxLeaseSeconds      int      `json:"max_lease_seconds,omitempty"`
+	RenewAudit           string   `json:"renew_audit,omitempty"`
+}
+
+// TemplateRegisterPayload registers a new template version. Version 0
+// assigns the next version; explicit versions must be exactly the next one.
+type TemplateRegisterPayload struct {
+	TemplateID  string          `json:"template_id"`
+	Version     int             `json:"version,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Params      []TemplateParam `json:"params,omitempty"`
+	Context     json.RawMessage `json:"context,omitempty"`
+	Steps       []TemplateStep  `json:"steps"`
+}
+
+type TemplateDeprecatePayload struct {
+	TemplateID string `json:"template_id"`
+	Version    int    `json:"version"`
+	Reason     string `json:"reason,omitempty"`
+}
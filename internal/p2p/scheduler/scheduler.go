@@ -0,0 +1,259 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
+	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+	"github.com/execution-hub/execution-hub/internal/p2p/state"
+)
+
+// Policy selects which candidate receives a step offer.
+type Policy string
+
+const (
+	PolicyRoundRobin      Policy = "ROUND_ROBIN"
+	PolicyLeastLoaded     Policy = "LEAST_LOADED"
+	PolicyHighestTrust    Policy = "HIGHEST_TRUST"
+	PolicyCapabilityScore Policy = "CAPABILITY_SCORE"
+)
+
+// ParsePolicy normalizes a policy name.
+func ParsePolicy(raw string) (Policy, error) {
+	policy := Policy(strings.ToUpper(strings.TrimSpace(raw)))
+	switch policy {
+	case "":
+		return PolicyLeastLoaded, nil
+	case PolicyRoundRobin, PolicyLeastLoaded, PolicyHighestTrust, PolicyCapabilityScore:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported scheduler policy: %s", raw)
+	}
+}
+
+// Node is the subset of consensus.Node the scheduler needs.
+type Node interface {
+	IsLeader() bool
+	Machine() *state.Machine
+	ApplyTx(ctx context.Context, tx protocol.Tx) error
+}
+
+// Config controls the scheduler loop.
+type Config struct {
+	Policy       Policy
+	Interval     time.Duration
+	OfferTimeout time.Duration
+	Actor        string
+	PrivateKey   ed25519.PrivateKey
+	Now          func() time.Time
+}
+
+func (c Config) normalized() (Config, error) {
+	policy, err := ParsePolicy(string(c.Policy))
+	if err != nil {
+		return c, err
+	}
+	c.Policy = policy
+	c.Actor = strings.TrimSpace(c.Actor)
+	if c.Actor == "" {
+		return c, errors.New("actor is required")
+	}
+	if len(c.PrivateKey) != ed25519.PrivateKeySize {
+		return c, errors.New("invalid private key")
+	}
+	if c.Interval <= 0 {
+		c.Interval = 2 * time.Second
+	}
+	if c.OfferTimeout <= 0 {
+		c.OfferTimeout = 60 * time.Second
+	}
+	if c.OfferTimeout < time.Second || c.OfferTimeout > time.Hour {
+		return c, errors.New("offer timeout must be between 1s and 1h")
+	}
+	if c.Now == nil {
+		c.Now = func() time.Time { return time.Now().UTC() }
+	}
+	return c, nil
+}
+
+// Scheduler runs on the Raft leader and offers claimable steps to
+// participants through signed STEP_ASSIGN txs. Each step is offered to one
+// candidate at a time; once an offer is declined or expires the next
+// candidate is tried. Steps whose candidates are exhausted stay open for
+// manual claims.
+type Scheduler struct {
+	node Node
+	cfg  Config
+
+	mu         sync.Mutex
+	roundRobin map[string]int
+}
+
+func New(node Node, cfg Config) (*Scheduler, error) {
+	if node == nil {
+		return nil, errors.New("node is required")
+	}
+	cfg, err := cfg.normalized()
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{node: node, cfg: cfg, roundRobin: map[string]int{}}, nil
+}
+
+// Run ticks until ctx is cancelled. Followers skip ticks.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Tick(ctx); err != nil {
+				log.Printf("scheduler tick failed: %v", err)
+			}
+		}
+	}
+}
+
+// Tick issues offers for every active session and returns how many were applied.
+func (s *Scheduler) Tick(ctx context.Context) (int, error) {
+	if !s.node.IsLeader() {
+		return 0, nil
+	}
+	machine := s.node.Machine()
+	offered := 0
+	cursor := ""
+	for {
+		sessions, next, err := machine.ListSessions(state.SessionFilter{Status: state.SessionStatusActive}, 500, cursor)
+		if err != nil {
+			return offered, err
+		}
+		for _, session := range sessions {
+			n, err := s.scheduleSession(ctx, session.SessionID)
+			offered += n
+			if err != nil {
+				return offered, err
+			}
+		}
+		if next == "" {
+			return offered, nil
+		}
+		cursor = next
+	}
+}
+
+func (s *Scheduler) scheduleSession(ctx context.Context, sessionID string) (int, error) {
+	now := s.cfg.Now()
+	view, err := s.node.Machine().AssignmentView(sessionID, now)
+	if err != nil {
+		return 0, err
+	}
+	offered := 0
+	for _, step := range view.Steps {
+		candidates := make([]state.Participant, 0, len(view.Participants))
+		for _, participant := range view.Participants {
+			if view.Offered[step.StepID][participant.ParticipantID] {
+				continue
+			}
+			if state.CanTake(participant, step) {
+				candidates = append(candidates, participant)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		chosen := s.pick(sessionID, step, candidates, view.Load)
+		tx, err := s.assignTx(sessionID, step, chosen, view.Attempts[step.StepID]+1, now)
+		if err != nil {
+			return offered, err
+		}
+		if err := s.node.ApplyTx(ctx, tx); err != nil {
+			if ctx.Err() != nil {
+				return offered, ctx.Err()
+			}
+			log.Printf("scheduler offer %s rejected: %v", step.StepID, err)
+			continue
+		}
+		view.Load[chosen.ParticipantID]++
+		offered++
+	}
+	return offered, nil
+}
+
+// pick selects one candidate by policy; ties fall back to join order.
+func (s *Scheduler) pick(sessionID string, step state.Step, candidates []state.Participant, load map[string]int) state.Participant {
+	switch s.cfg.Policy {
+	case PolicyRoundRobin:
+		s.mu.Lock()
+		i := s.roundRobin[sessionID] % len(candidates)
+		s.roundRobin[sessionID]++
+		s.mu.Unlock()
+		return candidates[i]
+	case PolicyHighestTrust:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].TrustScore > candidates[j].TrustScore
+		})
+	case PolicyCapabilityScore:
+		scores := make(map[string]int, len(candidates))
+		for _, participant := range candidates {
+			scores[participant.ParticipantID] = capability.Match(participant.Capabilities, step.RequiredCapabilities).Score
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := candidates[i], candidates[j]
+			if scores[a.ParticipantID] != scores[b.ParticipantID] {
+				return scores[a.ParticipantID] > scores[b.ParticipantID]
+			}
+			return load[a.ParticipantID] < load[b.ParticipantID]
+		})
+	default:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return load[candidates[i].ParticipantID] < load[candidates[j].ParticipantID]
+		})
+	}
+	return candidates[0]
+}
+
+// assignTx builds the signed offer. IDs derive from the step and attempt so
+// a retried or duplicated tick is deduplicated by the state machine.
+func (s *Scheduler) assignTx(sessionID string, step state.Step, participant state.Participant, attempt int, now time.Time) (protocol.Tx, error) {
+	offerID := fmt.Sprintf("offer:%s:%d", step.StepID, attempt)
+	payload, err := json.Marshal(protocol.StepAssignPayload{
+		OfferID:        offerID,
+		StepID:         step.StepID,
+		ParticipantID:  participant.ParticipantID,
+		Policy:         string(s.cfg.Policy),
+		TimeoutSeconds: int(s.cfg.OfferTimeout / time.Second),
+	})
+	if err != nil {
+		return protocol.Tx{}, err
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return protocol.Tx{}, err
+	}
+	tx := protocol.Tx{
+		TxID:      "tx-" + offerID,
+		SessionID: sessionID,
+		Nonce:     hex.EncodeToString(nonce),
+		Timestamp: now,
+		Actor:     s.cfg.Actor,
+		Op:        protocol.OpStepAssign,
+		Payload:   payload,
+	}
+	if err := tx.Sign(s.cfg.PrivateKey); err != nil {
+		return protocol.Tx{}, err
+	}
+	return tx, nil
+}
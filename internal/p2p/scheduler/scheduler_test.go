@@ -0,0 +1,191 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+	"github.com/execution-hub/execution-hub/internal/p2p/state"
+)
+
+type fakeNode struct {
+	machine *state.Machine
+	leader  bool
+}
+
+func (n *fakeNode) IsLeader() bool          { return n.leader }
+func (n *fakeNode) Machine() *state.Machine { return n.machine }
+func (n *fakeNode) ApplyTx(_ context.Context, tx protocol.Tx) error {
+	return n.machine.ApplyTx(tx)
+}
+
+func TestSchedulerOffersNextCandidateAfterTimeout(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	base := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	now := base
+	schedulerPub, schedulerPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate scheduler key: %v", err)
+	}
+	node := &fakeNode{machine: state.NewMachine(), leader: true}
+	node.machine.SetSchedulerKey(schedulerPub)
+	apply := func(txID string, op protocol.Operation, payload any) {
+		t.Helper()
+		raw, _ := json.Marshal(payload)
+		now = now.Add(time.Second)
+		tx := protocol.Tx{TxID: txID, SessionID: "s-1", Nonce: txID, Timestamp: now, Actor: "actor:test", Op: op, Payload: raw}
+		if err := tx.Sign(priv); err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if err := node.machine.ApplyTx(tx); err != nil {
+			t.Fatalf("apply %s: %v", txID, err)
+		}
+	}
+	apply("tx-1", protocol.OpSessionCreate, protocol.SessionCreatePayload{
+		SessionID:          "s-1",
+		Name:               "Scheduled",
+		Steps:              []protocol.SessionStep{{StepID: "step-1", StepKey: "review", RequiredCapabilities: []string{"code.review"}}},
+		CapabilityMatching: protocol.CapabilityMatchingStructured,
+	})
+	apply("tx-2", protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-low", SessionID: "s-1", Type: "AGENT", Ref: "agent:low", Capabilities: []string{"code.*"}, TrustScore: 10})
+	apply("tx-3", protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-high", SessionID: "s-1", Type: "AGENT", Ref: "agent:high", Capabilities: []string{"code.review"}, TrustScore: 90})
+	apply("tx-4", protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-none", SessionID: "s-1", Type: "AGENT", Ref: "agent:none", Capabilities: []string{"docs"}, TrustScore: 99})
+
+	sched, err := New(node, Config{
+		Policy:       PolicyHighestTrust,
+		OfferTimeout: 30 * time.Second,
+		Actor:        "scheduler:test",
+		PrivateKey:   schedulerPriv,
+		Now:          func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("new scheduler: %v", err)
+	}
+
+	if n, err := sched.Tick(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected one offer, got %d err=%v", n, err)
+	}
+	offers := node.machine.ListStepOffers("step-1")
+	if len(offers) != 1 || offers[0].ParticipantID != "p-high" || offers[0].Status != state.OfferStatusPending {
+		t.Fatalf("unexpected first offer: %+v", offers)
+	}
+	if n, _ := sched.Tick(context.Background()); n != 0 {
+		t.Fatalf("expected no duplicate offer while pending, got %d", n)
+	}
+	if err := node.machine.ApplyTx(signed(t, priv, "tx-forged", now.Add(time.Second), protocol.OpStepAssign,
+		protocol.StepAssignPayload{OfferID: "o-forged", StepID: "step-1", ParticipantID: "p-low"})); err == nil {
+		t.Fatalf("expected assignment not signed by the scheduler to be rejected")
+	}
+	if err := node.machine.ApplyTx(signed(t, priv, "tx-steal", now.Add(time.Second), protocol.OpStepClaim,
+		protocol.StepClaimPayload{ClaimID: "c-steal", StepID: "step-1", ParticipantID: "p-low"})); err == nil {
+		t.Fatalf("expected claim by non-offered participant to be rejected")
+	}
+
+	now = now.Add(31 * time.Second)
+	if n, err := sched.Tick(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected re-offer after timeout, got %d err=%v", n, err)
+	}
+	offers = node.machine.ListStepOffers("step-1")
+	if len(offers) != 2 || offers[0].Status != state.OfferStatusExpired || offers[1].ParticipantID != "p-low" || offers[1].Attempt != 2 {
+		t.Fatalf("unexpected offers after timeout: %+v", offers)
+	}
+
+	now = now.Add(time.Second)
+	if err := node.machine.ApplyTx(signed(t, priv, "tx-accept", now, protocol.OpAssignAccept,
+		protocol.AssignAcceptPayload{OfferID: offers[1].OfferID, ParticipantID: "p-low", ClaimID: "c-1"})); err != nil {
+		t.Fatalf("accept offer: %v", err)
+	}
+	step, _ := node.machine.GetStep("step-1")
+	if step.Status != state.StepStatusClaimed {
+		t.Fatalf("expected step claimed after accept, got %s", step.Status)
+	}
+	if n, _ := sched.Tick(context.Background()); n != 0 {
+		t.Fatalf("expected no offers for claimed step, got %d", n)
+	}
+
+	// Releasing resets the step: the participant whose offer timed out
+	// before the claim is the best match again.
+	now = now.Add(time.Second)
+	if err := node.machine.ApplyTx(signed(t, priv, "tx-release", now, protocol.OpStepRelease,
+		protocol.StepReleasePayload{StepID: "step-1", ParticipantID: "p-low"})); err != nil {
+		t.Fatalf("release claim: %v", err)
+	}
+	now = now.Add(time.Second)
+	if n, err := sched.Tick(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected re-offer after release, got %d err=%v", n, err)
+	}
+	offers = node.machine.ListStepOffers("step-1")
+	if len(offers) != 3 || offers[2].ParticipantID != "p-high" || offers[2].Attempt != 3 {
+		t.Fatalf("unexpected offers after release: %+v", offers)
+	}
+	if err := node.machine.ApplyTx(signed(t, priv, "tx-decline", now, protocol.OpAssignDecline,
+		protocol.AssignDeclinePayload{OfferID: offers[2].OfferID, ParticipantID: "p-high"})); err != nil {
+		t.Fatalf("decline offer: %v", err)
+	}
+	now = now.Add(time.Second)
+	if n, err := sched.Tick(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected offer to next candidate after decline, got %d err=%v", n, err)
+	}
+	offers = node.machine.ListStepOffers("step-1")
+	if len(offers) != 4 || offers[3].ParticipantID != "p-low" {
+		t.Fatalf("expected declined participant to be skipped until the next reset: %+v", offers)
+	}
+
+	node.leader = false
+	if n, _ := sched.Tick(context.Background()); n != 0 {
+		t.Fatalf("expected follower to skip scheduling")
+	}
+}
+
+func TestPickPolicies(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	step := state.Step{StepID: "s", RequiredCapabilities: []string{"code.review"}}
+	candidates := func() []state.Participant {
+		return []state.Participant{
+			{ParticipantID: "a", Capabilities: []string{"*"}, TrustScore: 50},
+			{ParticipantID: "b", Capabilities: []string{"code.review"}, TrustScore: 10},
+			{ParticipantID: "c", Capabilities: []string{"code.*"}, TrustScore: 80},
+		}
+	}
+	load := map[string]int{"a": 2, "b": 1}
+	cases := []struct {
+		policy Policy
+		want   []string
+	}{
+		{PolicyLeastLoaded, []string{"c"}},
+		{PolicyHighestTrust, []string{"c"}},
+		{PolicyCapabilityScore, []string{"b"}},
+		{PolicyRoundRobin, []string{"a", "b", "c", "a"}},
+	}
+	for _, tc := range cases {
+		sched, err := New(&fakeNode{machine: state.NewMachine()}, Config{Policy: tc.policy, Actor: "scheduler", PrivateKey: priv})
+		if err != nil {
+			t.Fatalf("new scheduler: %v", err)
+		}
+		for i, want := range tc.want {
+			if got := sched.pick("session", step, candidates(), load); got.ParticipantID != want {
+				t.Fatalf("%s pick %d: expected %s, got %s", tc.policy, i, want, got.ParticipantID)
+			}
+		}
+	}
+	if _, err := ParsePolicy("random"); err == nil {
+		t.Fatalf("expected unknown policy to be rejected")
+	}
+}
+
+func signed(t *testing.T, priv ed25519.PrivateKey, txID string, at time.Time, op protocol.Operation, payload any) protocol.Tx {
+	t.Helper()
+	raw, _ := json.Marshal(payload)
+	tx := protocol.Tx{TxID: txID, SessionID: "s-1", Nonce: txID, Timestamp: at, Actor: "actor:test", Op: op, Payload: raw}
+	if err := tx.Sign(priv); err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return tx
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
+	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+)
+
+// Admit checks a transaction before it is submitted to the log. Rules that
+// were introduced after transactions were already committed belong here
+// rather than in ApplyTx: every node replays the log through ApplyTx, so a
+// stricter apply rule would reject old entries and diverge rebuilt nodes.
+func (m *Machine) Admit(tx protocol.Tx) error {
+	switch tx.Op {
+	case protocol.OpSessionCreate:
+		payload, err := protocol.DecodePayload[protocol.SessionCreatePayload](tx.Payload)
+		if err != nil {
+			return err
+		}
+		switch strings.ToUpper(strings.TrimSpace(payload.CapabilityMatching)) {
+		case "", protocol.CapabilityMatchingExact, protocol.CapabilityMatchingStructured:
+		default:
+			return fmt.Errorf("capability_matching must be %s or %s", protocol.CapabilityMatchingExact, protocol.CapabilityMatchingStructured)
+		}
+		if strings.TrimSpace(payload.TemplateID) != "" {
+			m.mu.RLock()
+			payload, err = m.instantiateTemplateLocked(payload)
+			m.mu.RUnlock()
+			if err != nil {
+				return err
+			}
+		}
+		for _, step := range payload.Steps {
+			if err := capability.ValidateRequirements(step.RequiredCapabilities); err != nil {
+				return fmt.Errorf("step %s: %w", strings.TrimSpace(step.StepKey), err)
+			}
+		}
+	case protocol.OpParticipantJoin:
+		payload, err := protocol.DecodePayload[protocol.ParticipantJoinPayload](tx.Payload)
+		if err != nil {
+			return err
+		}
+		return capability.ValidateGrants(payload.Capabilities)
+	case protocol.OpTemplateRegister:
+		payload, err := protocol.DecodePayload[protocol.TemplateRegisterPayload](tx.Payload)
+		if err != nil {
+			return err
+		}
+		// Parameterized requirements are checked once substituted at session create.
+		for _, step := range payload.Steps {
+			for _, required := range step.RequiredCapabilities {
+				if templateParamPattern.MatchString(required) || strings.TrimSpace(required) == "" {
+					continue
+				}
+				if _, err := capability.ParseRequirement(required); err != nil {
+					return fmt.Errorf("step %s: %w", strings.TrimSpace(step.StepKey), err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
+	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+)
+
+const (
+	OfferStatusPending  = "PENDING"
+	OfferStatusAccepted = "ACCEPTED"
+	OfferStatusDeclined = "DECLINED"
+	OfferStatusExpired  = "EXPIRED"
+)
+
+const (
+	defaultOfferTimeoutSeconds = 60
+	maxOfferTimeoutSeconds     = 3600
+)
+
+// Offer is one scheduler assignment of a step to a participant. While an
+// offer is pending only the offered participant may claim the step.
+type Offer struct {
+	OfferID       string     `json:"offerId"`
+	StepID        string     `json:"stepId"`
+	SessionID     string     `json:"sessionId"`
+	ParticipantID string     `json:"participantId"`
+	Policy        string     `json:"policy,omitempty"`
+	Attempt       int        `json:"attempt"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	ClaimID       string     `json:"claimId,omitempty"`
+	Reason        string     `json:"reason,omitempty"`
+	Actor         string     `json:"actor"`
+	TxID          string     `json:"txId"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	RespondedAt   *time.Time `json:"respondedAt,omitempty"`
+}
+
+// AssignmentView is a consistent read of one session for the scheduler.
+type AssignmentView struct {
+	SessionID string
+	// Steps are claimable and have no pending offer, in session order.
+	Steps []Step
+	// Participants are in join order.
+	Participants []Participant
+	// Load counts active claims plus pending offers per participant.
+	Load map[string]int
+	// Offered lists participants whose offer of each step timed out or was
+	// declined since the step last became claimable again.
+	Offered map[string]map[string]bool
+	// Attempts counts offers made so far per step.
+	Attempts map[string]int
+}
+
+// CanTake reports whether participant may be offered or claim step.
+func CanTake(participant Participant, step Step) bool {
+	return meetsMinTrust(participant, step) && step.satisfiedBy(participant.Capabilities)
+}
+
+// satisfiedBy matches grants against the step's requirements using the
+// session's matching mode. Exact matching is the rule STEP_CLAIM and
+// STEP_HANDOFF entries committed before structured matching were applied
+// under, so replaying them must keep using it.
+func (s Step) satisfiedBy(grants []string) bool {
+	if s.CapabilityMatching == protocol.CapabilityMatchingStructured {
+		return capability.Satisfies(grants, s.RequiredCapabilities)
+	}
+	return hasCapabilities(grants, s.RequiredCapabilities)
+}
+
+// meetsMinTrust ignores steps without a minimum so participants with a
+// negative score stay eligible for steps created before minimums existed.
+func meetsMinTrust(participant Participant, step Step) bool {
+	return step.MinTrustScore <= 0 || participant.TrustScore >= step.MinTrustScore
+}
+
+func hasCapabilities(actual, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	if len(actual) == 0 {
+		return false
+	}
+	have := map[string]struct{}{}
+	for _, capability := range actual {
+		capability = strings.TrimSpace(capability)
+		if capability == "" {
+			continue
+		}
+		have[capability] = struct{}{}
+	}
+	for _, capability := range required {
+		capability = strings.TrimSpace(capability)
+		if capability == "" {
+			continue
+		}
+		if _, ok := have[capability]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Machine) applyStepAssignLocked(tx protocol.Tx, at time.Time) error {
+	if m.schedulerKey == "" {
+		return errors.New("scheduler identity is not configured")
+	}
+	if strings.TrimSpace(tx.PublicKey) != m.schedulerKey {
+		return errors.New("step_assign must be signed by the scheduler")
+	}
+	payload, err := protocol.DecodePayload[protocol.StepAssignPayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	offerID := strings.TrimSpace(payload.OfferID)
+	stepID := strings.TrimSpace(payload.StepID)
+	participantID := strings.TrimSpace(payload.ParticipantID)
+	if offerID == "" || stepID == "" || participantID == "" {
+		return errors.New("offer_id, step_id and participant_id are required")
+	}
+	if _, exists := m.s.Offers[offerID]; exists {
+		return fmt.Errorf("offer already exists: %s", offerID)
+	}
+	timeout := payload.TimeoutSeconds
+	if timeout < 0 || timeout > maxOfferTimeoutSeconds {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", maxOfferTimeoutSeconds)
+	}
+	if timeout == 0 {
+		timeout = defaultOfferTimeoutSeconds
+	}
+	step, ok := m.s.Steps[stepID]
+	if !ok {
+		return fmt.Errorf("step not found: %s", stepID)
+	}
+	participant, ok := m.s.Participants[participantID]
+	if !ok {
+		return fmt.Errorf("participant not found: %s", participantID)
+	}
+	if participant.SessionID != step.SessionID {
+		return errors.New("participant does not belong to step session")
+	}
+	if !m.claimableLocked(step, at) {
+		return errors.New("step is not claimable")
+	}
+	if pendingID, _ := m.findPendingOfferByStepLocked(stepID, at); pendingID != "" {
+		return fmt.Errorf("step already has a pending offer: %s", pendingID)
+	}
+	if !CanTake(participant, step) {
+		return errors.New("participant does not satisfy step requirements")
+	}
+	offer := Offer{
+		OfferID:       offerID,
+		StepID:        stepID,
+		SessionID:     step.SessionID,
+		ParticipantID: participantID,
+		Policy:        strings.ToUpper(strings.TrimSpace(payload.Policy)),
+		Attempt:       len(m.s.OffersByStep[stepID]) + 1,
+		Status:        OfferStatusPending,
+		ExpiresAt:     at.Add(time.Duration(timeout) * time.Second),
+		Actor:         tx.Actor,
+		TxID:          tx.TxID,
+		CreatedAt:     at,
+		UpdatedAt:     at,
+	}
+	m.s.Offers[offerID] = offer
+	m.s.OffersByStep[stepID] = append(m.s.OffersByStep[stepID], offerID)
+	m.appendEventLocked(step.SessionID, &step.StepID, string(protocol.OpStepAssign), tx.Actor, payload, at, tx.TxID)
+	return nil
+}
+
+func (m *Machine) applyAssignAcceptLocked(tx protocol.Tx, at time.Time) error {
+	payload, err := protocol.DecodePayload[protocol.AssignAcceptPayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	offerID := strings.TrimSpace(payload.OfferID)
+	participantID := strings.TrimSpace(payload.ParticipantID)
+	claimID := strings.TrimSpace(payload.ClaimID)
+	if offerID == "" || participantID == "" || claimID == "" {
+		return errors.New("offer_id, participant_id and claim_id are required")
+	}
+	offer, err := m.pendingOfferForLocked(offerID, participantID)
+	if err != nil {
+		return err
+	}
+	step, ok := m.s.Steps[offer.StepID]
+	if !ok {
+		return fmt.Errorf("step not found: %s", offer.StepID)
+	}
+	participant, ok := m.s.Participants[participantID]
+	if !ok {
+		return fmt.Errorf("participant not found: %s", participantID)
+	}
+	if !m.claimableLocked(step, at) {
+		return errors.New("step is not claimable")
+	}
+	if !CanTake(participant, step) {
+		return errors.New("participant does not satisfy step requirements")
+	}
+	if _, exists := m.s.Claims[claimID]; exists {
+		return fmt.Errorf("claim already exists: %s", claimID)
+	}
+	m.startClaimLocked(step, participantID, claimID, payload.LeaseSeconds, at)
+	m.closeOfferLocked(offerID, OfferStatusAccepted, claimID, "", at)
+	m.appendEventLocked(step.SessionID, &step.StepID, string(protocol.OpAssignAccept), tx.Actor, payload, at, tx.TxID)
+	return nil
+}
+
+func (m *Machine) applyAssignDeclineLocked(tx protocol.Tx, at time.Time) error {
+	payload, err := protocol.DecodePayload[protocol.AssignDeclinePayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	offerID := strings.TrimSpace(payload.OfferID)
+	participantID := strings.TrimSpace(payload.ParticipantID)
+	if offerID == "" || participantID == "" {
+		return errors.New("offer_id and participant_id are required")
+	}
+	offer, err := m.pendingOfferForLocked(offerID, participantID)
+	if err != nil {
+		return err
+	}
+	m.closeOfferLocked(offerID, OfferStatusDeclined, "", strings.TrimSpace(payload.Reason), at)
+	m.appendEventLocked(offer.SessionID, &offer.StepID, string(protocol.OpAssignDecline), tx.Actor, payload, at, tx.TxID)
+	return nil
+}
+
+func (m *Machine) pendingOfferForLocked(offerID, participantID string) (Offer, error) {
+	offer, ok := m.s.Offers[offerID]
+	if !ok {
+		return Offer{}, fmt.Errorf("offer not found: %s", offerID)
+	}
+	if offer.ParticipantID != participantID {
+		return Offer{}, errors.New("offer belongs to another participant")
+	}
+	if offer.Status != OfferStatusPending {
+		return Offer{}, fmt.Errorf("offer is %s", offer.Status)
+	}
+	return offer, nil
+}
+
+func (m *Machine) closeOfferLocked(offerID, status, claimID, reason string, at time.Time) {
+	offer := m.s.Offers[offerID]
+	offer.Status = status
+	offer.ClaimID = claimID
+	offer.Reason = reason
+	offer.UpdatedAt = at
+	offer.RespondedAt = &at
+	m.s.Offers[offerID] = offer
+}
+
+// expireOffersLocked closes pending offers whose timeout elapsed so the
+// scheduler can offer the step to the next candidate.
+func (m *Machine) expireOffersLocked(at time.Time, txID string) {
+	expiredIDs := make([]string, 0)
+	for offerID, offer := range m.s.Offers {
+		if offer.Status == OfferStatusPending && !offer.ExpiresAt.After(at) {
+			expiredIDs = append(expiredIDs, offerID)
+		}
+	}
+	sort.Strings(expiredIDs)
+	for _, offerID := range expiredIDs {
+		offer := m.s.Offers[offerID]
+		offer.Status = OfferStatusExpired
+		offer.UpdatedAt = at
+		m.s.Offers[offerID] = offer
+		m.appendEventLocked(offer.SessionID, &offer.StepID, "ASSIGN_EXPIRED", "system", map[string]any{
+			"offerId":       offer.OfferID,
+			"participantId": offer.ParticipantID,
+			"expiresAt":     offer.ExpiresAt,
+		}, at, txID)
+	}
+}
+
+func (m *Machine) findPendingOfferByStepLocked(stepID string, at time.Time) (string, Offer) {
+	for _, offerID := range m.s.OffersByStep[stepID] {
+		offer := m.s.Offers[offerID]
+		if offer.Status == OfferStatusPending && offer.ExpiresAt.After(at) {
+			return offerID, offer
+		}
+	}
+	return "", Offer{}
+}
+
+// claimableLocked reports whether step is open (or its claim lapsed) with
+// resolved dependencies and no active claim.
+func (m *Machine) claimableLocked(step Step, at time.Time) bool {
+	if step.Status != StepStatusOpen && step.Status != StepStatusClaimed {
+		return false
+	}
+	if !depsResolved(step, m.s.Steps) {
+		return false
+	}
+	activeID, _ := m.findActiveClaimByStepLocked(step.StepID, at)
+	return activeID == ""
+}
+
+// stepResetAtLocked returns when the step's latest claim was released or
+// lapsed, or the zero time if it was never claimed. Offers made before then
+// no longer bar their participant from the step.
+func (m *Machine) stepResetAtLocked(stepID string, at time.Time) time.Time {
+	var resetAt time.Time
+	for _, claim := range m.s.Claims {
+		if claim.StepID != stepID {
+			continue
+		}
+		var endedAt time.Time
+		switch {
+		case claim.Status == ClaimStatusReleased:
+			endedAt = claim.UpdatedAt
+		case claim.Status == ClaimStatusExpired, !claim.LeaseUntil.After(at):
+			endedAt = claim.LeaseUntil
+		default:
+			continue
+		}
+		if endedAt.After(resetAt) {
+			resetAt = endedAt
+		}
+	}
+	return resetAt
+}
+
+// ListStepOffers returns every offer made for a step, oldest first.
+func (m *Machine) ListStepOffers(stepID string) []Offer {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := m.s.OffersByStep[strings.TrimSpace(stepID)]
+	out := make([]Offer, 0, len(ids))
+	for _, offerID := range ids {
+		out = append(out, m.s.Offers[offerID])
+	}
+	return out
+}
+
+// AssignmentView returns the scheduler's view of one session at the given time.
+func (m *Machine) AssignmentView(sessionID string, at time.Time) (AssignmentView, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sessionID = strings.TrimSpace(sessionID)
+	if _, ok := m.s.Sessions[sessionID]; !ok {
+		return AssignmentView{}, fmt.Errorf("session not found: %s", sessionID)
+	}
+	view := AssignmentView{
+		SessionID: sessionID,
+		Load:      map[string]int{},
+		Offered:   map[string]map[string]bool{},
+		Attempts:  map[string]int{},
+	}
+	for _, participantID := range m.s.ParticipantOrderBySession[sessionID] {
+		if participant, ok := m.s.Participants[participantID]; ok {
+			view.Participants = append(view.Participants, cloneParticipant(participant))
+		}
+	}
+	for _, stepID := range m.s.StepOrderBySession[sessionID] {
+		step, ok := m.s.Steps[stepID]
+		if !ok {
+			continue
+		}
+		if activeID, claim := m.findActiveClaimByStepLocked(stepID, at); activeID != "" {
+			view.Load[claim.ParticipantID]++
+		}
+		offered := map[string]bool{}
+		pending := false
+		resetAt := m.stepResetAtLocked(stepID, at)
+		for _, offerID := range m.s.OffersByStep[stepID] {
+			offer := m.s.Offers[offerID]
+			live := offer.Status == OfferStatusPending && offer.ExpiresAt.After(at)
+			if live {
+				pending = true
+				view.Load[offer.ParticipantID]++
+			}
+			if !live && offer.Status != OfferStatusAccepted && !offer.CreatedAt.Before(resetAt) {
+				offered[offer.ParticipantID] = true
+			}
+		}
+		view.Offered[stepID] = offered
+		view.Attempts[stepID] = len(m.s.OffersByStep[stepID])
+		if !pending && m.claimableLocked(step, at) {
+			view.Steps = append(view.Steps, cloneStep(step))
+		}
+	}
+	return view, nil
+}
@@ -0,0 +1,245 @@
+package state
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 500
+	cursorPrefix     = "p2p:"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SessionFilter narrows ListSessions results. Empty fields match everything.
+type SessionFilter struct {
+	Status     string
+	WorkflowID string
+}
+
+func (f SessionFilter) matches(session Session) bool {
+	if status := strings.TrimSpace(f.Status); status != "" && !strings.EqualFold(session.Status, status) {
+		return false
+	}
+	if workflowID := strings.TrimSpace(f.WorkflowID); workflowID != "" && session.WorkflowID != workflowID {
+		return false
+	}
+	return true
+}
+
+// ParticipantFilter narrows ListParticipants results.
+type ParticipantFilter struct {
+	Type       string
+	Capability string
+}
+
+func (f ParticipantFilter) matches(participant Participant) bool {
+	if participantType := strings.TrimSpace(f.Type); participantType != "" && !strings.EqualFold(participant.Type, participantType) {
+		return false
+	}
+	if required := strings.TrimSpace(f.Capability); required != "" && !capability.Satisfies(participant.Capabilities, []string{required}) {
+		return false
+	}
+	return true
+}
+
+// StepFilter narrows ListSteps results.
+type StepFilter struct {
+	Status string
+}
+
+func (f StepFilter) matches(step Step) bool {
+	if status := strings.TrimSpace(f.Status); status != "" && !strings.EqualFold(step.Status, status) {
+		return false
+	}
+	return true
+}
+
+// EventFilter narrows ListEvents results. Since is inclusive, Until exclusive.
+type EventFilter struct {
+	Type   string
+	StepID string
+	Actor  string
+	Since  *time.Time
+	Until  *time.Time
+}
+
+func (f EventFilter) matches(event Event) bool {
+	if eventType := strings.TrimSpace(f.Type); eventType != "" && !strings.EqualFold(event.Type, eventType) {
+		return false
+	}
+	if stepID := strings.TrimSpace(f.StepID); stepID != "" && (event.StepID == nil || *event.StepID != stepID) {
+		return false
+	}
+	if actor := strings.TrimSpace(f.Actor); actor != "" && event.Actor != actor {
+		return false
+	}
+	if f.Since != nil && event.CreatedAt.Before(*f.Since) {
+		return false
+	}
+	if f.Until != nil && !event.CreatedAt.Before(*f.Until) {
+		return false
+	}
+	return true
+}
+
+// encodeCursor encodes a position in an append-only ordering (session
+// order, join order, step order or the event log). New entries only ever
+// extend those orderings, so a cursor keeps pointing at the same boundary
+// while writes land between page requests.
+func encodeCursor(pos int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(pos)))
+}
+
+// decodeCursor returns -1 for an empty cursor.
+func decodeCursor(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return -1, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	value, ok := strings.CutPrefix(string(decoded), cursorPrefix)
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+	pos, err := strconv.Atoi(value)
+	if err != nil || pos < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return pos, nil
+}
+
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		return maxPageLimit
+	}
+	return limit
+}
+
+// pageAscending collects up to limit matching positions after the cursor
+// position and returns the cursor for the following page, if any.
+func pageAscending(total, after, limit int, match func(int) bool) ([]int, string) {
+	limit = normalizeLimit(limit)
+	out := make([]int, 0)
+	for i := after + 1; i < total; i++ {
+		if !match(i) {
+			continue
+		}
+		if len(out) == limit {
+			return out, encodeCursor(out[len(out)-1])
+		}
+		out = append(out, i)
+	}
+	return out, ""
+}
+
+// pageDescending walks positions newest first, starting before the cursor.
+func pageDescending(total, before, limit int, match func(int) bool) ([]int, string) {
+	limit = normalizeLimit(limit)
+	start := total - 1
+	if before >= 0 && before-1 < start {
+		start = before - 1
+	}
+	out := make([]int, 0)
+	for i := start; i >= 0; i-- {
+		if !match(i) {
+			continue
+		}
+		if len(out) == limit {
+			return out, encodeCursor(out[len(out)-1])
+		}
+		out = append(out, i)
+	}
+	return out, ""
+}
+
+// rankKey orders ranked results: higher score first, then higher trust,
+// then original position. Cursors encode the full key so pages stay stable
+// while unrelated steps open or close.
+type rankKey struct {
+	score int
+	trust int
+	pos   int
+}
+
+func (k rankKey) less(o rankKey) bool {
+	if k.score != o.score {
+		return k.score > o.score
+	}
+	if k.trust != o.trust {
+		return k.trust > o.trust
+	}
+	return k.pos < o.pos
+}
+
+const rankCursorPrefix = cursorPrefix + "rank:"
+
+func encodeRankCursor(k rankKey) string {
+	value := fmt.Sprintf("%s%d:%d:%d", rankCursorPrefix, k.score, k.trust, k.pos)
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// decodeRankCursor returns nil for an empty cursor.
+func decodeRankCursor(raw string) (*rankKey, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	value, ok := strings.CutPrefix(string(decoded), rankCursorPrefix)
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return nil, ErrInvalidCursor
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		nums[i] = n
+	}
+	if nums[2] < 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &rankKey{score: nums[0], trust: nums[1], pos: nums[2]}, nil
+}
+
+// pageRanked sorts keys and returns up to limit entries after the cursor key.
+func pageRanked(keys []rankKey, after *rankKey, limit int) ([]rankKey, string) {
+	limit = normalizeLimit(limit)
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+	out := make([]rankKey, 0)
+	for _, k := range keys {
+		if after != nil && !after.less(k) {
+			continue
+		}
+		if len(out) == limit {
+			return out, encodeRankCursor(out[len(out)-1])
+		}
+		out = append(out, k)
+	}
+	return out, ""
+}
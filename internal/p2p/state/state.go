@@ -1,6 +1,8 @@
 package state
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/execution-hub/execution-hub/internal/domain/capability"
 	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
 )
 
@@ -34,17 +37,26 @@ const (
 
 	VoteChoiceApprove = "APPROVE"
 	VoteChoiceReject  = "REJECT"
+
+	// RenewAudit policies decide which CLAIM_RENEW txs append an event.
+	RenewAuditNone    = "NONE"
+	RenewAuditClamped = "CLAMPED"
+	RenewAuditAll     = "ALL"
+
+	defaultLeaseSeconds = 900
 )
 
 type Session struct {
-	SessionID   string          `json:"sessionId"`
-	WorkflowID  string          `json:"workflowId,omitempty"`
-	Name        string          `json:"name"`
-	Status      string          `json:"status"`
-	Context     json.RawMessage `json:"context,omitempty"`
-	CreatedAt   time.Time       `json:"createdAt"`
-	UpdatedAt   time.Time       `json:"updatedAt"`
-	LastEventID string          `json:"lastEventId,omitempty"`
+	SessionID       string          `json:"sessionId"`
+	WorkflowID      string          `json:"workflowId,omitempty"`
+	TemplateID      string          `json:"templateId,omitempty"`
+	TemplateVersion int             `json:"templateVersion,omitempty"`
+	Name            string          `json:"name"`
+	Status          string          `json:"status"`
+	Context         json.RawMessage `json:"context,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+	LastEventID     string          `json:"lastEventId,omitempty"`
 }
 
 type Participant struct {
@@ -65,8 +77,12 @@ type Step struct {
 	Name                 string          `json:"name"`
 	Status               string          `json:"status"`
 	RequiredCapabilities []string        `json:"requiredCapabilities,omitempty"`
+	MinTrustScore        int             `json:"minTrustScore,omitempty"`
+	CapabilityMatching   string          `json:"capabilityMatching,omitempty"`
 	DependsOn            []string        `json:"dependsOn,omitempty"`
 	LeaseTTLSeconds      int             `json:"leaseTtlSeconds"`
+	MaxLeaseSeconds      int             `json:"maxLeaseSeconds,omitempty"`
+	RenewAudit           string          `json:"renewAudit,omitempty"`
 	ConsensusPolicy      json.RawMessage `json:"consensusPolicy,omitempty"`
 	CreatedAt            time.Time       `json:"createdAt"`
 	UpdatedAt            time.Time       `json:"updatedAt"`
@@ -74,13 +90,15 @@ type Step struct {
 }
 
 type Claim struct {
-	ClaimID       string    `json:"claimId"`
-	StepID        string    `json:"stepId"`
-	ParticipantID string    `json:"participantId"`
-	Status        string    `json:"status"`
-	LeaseUntil    time.Time `json:"leaseUntil"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	ClaimID       string     `json:"claimId"`
+	StepID        string     `json:"stepId"`
+	ParticipantID string     `json:"participantId"`
+	Status        string     `json:"status"`
+	LeaseUntil    time.Time  `json:"leaseUntil"`
+	RenewCount    int        `json:"renewCount,omitempty"`
+	LastRenewedAt *time.Time `json:"lastRenewedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
 }
 
 type Artifact struct {
@@ -130,26 +148,36 @@ type Event struct {
 }
 
 type snapshot struct {
-	Sessions                map[string]Session             `json:"sessions"`
-	Participants            map[string]Participant         `json:"participants"`
-	ParticipantsBySession   map[string]string              `json:"participantsBySession"`
-	Steps                   map[string]Step                `json:"steps"`
-	StepOrderBySession      map[string][]string            `json:"stepOrderBySession"`
-	Claims                  map[string]Claim               `json:"claims"`
-	ArtifactsByStep         map[string][]Artifact          `json:"artifactsByStep"`
-	Decisions               map[string]Decision            `json:"decisions"`
-	DecisionByStep          map[string]string              `json:"decisionByStep"`
-	VotesByDecision         map[string]map[string]Vote     `json:"votesByDecision"`
-	EventsBySession         map[string][]Event             `json:"eventsBySession"`
-	AppliedTx               map[string]bool                `json:"appliedTx"`
-	StepKeysBySession       map[string]map[string]struct{} `json:"-"`
-	DecisionByStepFinalized map[string]bool                `json:"decisionByStepFinalized,omitempty"`
+	Sessions                  map[string]Session             `json:"sessions"`
+	SessionOrder              []string                       `json:"sessionOrder"`
+	Participants              map[string]Participant         `json:"participants"`
+	ParticipantsBySession     map[string]string              `json:"participantsBySession"`
+	ParticipantOrderBySession map[string][]string            `json:"participantOrderBySession"`
+	Steps                     map[string]Step                `json:"steps"`
+	StepOrderBySession        map[string][]string            `json:"stepOrderBySession"`
+	Claims                    map[string]Claim               `json:"claims"`
+	ArtifactsByStep           map[string][]Artifact          `json:"artifactsByStep"`
+	Decisions                 map[string]Decision            `json:"decisions"`
+	DecisionByStep            map[string]string              `json:"decisionByStep"`
+	VotesByDecision           map[string]map[string]Vote     `json:"votesByDecision"`
+	EventsBySession           map[string][]Event             `json:"eventsBySession"`
+	AppliedTx                 map[string]bool                `json:"appliedTx"`
+	StepKeysBySession         map[string]map[string]struct{} `json:"-"`
+	DecisionByStepFinalized   map[string]bool                `json:"decisionByStepFinalized,omitempty"`
+	TemplatesByID             map[string][]Template          `json:"templatesById"`
+	TemplateOrder             []string                       `json:"templateOrder"`
+	Offers                    map[string]Offer               `json:"offers"`
+	OffersByStep              map[string][]string            `json:"offersByStep"`
 }
 
 // Machine is the deterministic collaboration state machine.
 type Machine struct {
 	mu sync.RWMutex
 	s  snapshot
+
+	// schedulerKey is the base64 public key STEP_ASSIGN must be signed with.
+	// It is cluster configuration, so every node must be given the same key.
+	schedulerKey string
 }
 
 func NewMachine() *Machine {
@@ -158,22 +186,39 @@ func NewMachine() *Machine {
 	return m
 }
 
+// SetSchedulerKey sets the public key of the assignment scheduler. Until it
+// is set, STEP_ASSIGN transactions are rejected.
+func (m *Machine) SetSchedulerKey(pub ed25519.PublicKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.schedulerKey = ""
+	if len(pub) == ed25519.PublicKeySize {
+		m.schedulerKey = base64.StdEncoding.EncodeToString(pub)
+	}
+}
+
 func emptySnapshot() snapshot {
 	return snapshot{
-		Sessions:                map[string]Session{},
-		Participants:            map[string]Participant{},
-		ParticipantsBySession:   map[string]string{},
-		Steps:                   map[string]Step{},
-		StepOrderBySession:      map[string][]string{},
-		Claims:                  map[string]Claim{},
-		ArtifactsByStep:         map[string][]Artifact{},
-		Decisions:               map[string]Decision{},
-		DecisionByStep:          map[string]string{},
-		VotesByDecision:         map[string]map[string]Vote{},
-		EventsBySession:         map[string][]Event{},
-		AppliedTx:               map[string]bool{},
-		StepKeysBySession:       map[string]map[string]struct{}{},
-		DecisionByStepFinalized: map[string]bool{},
+		Sessions:                  map[string]Session{},
+		SessionOrder:              []string{},
+		Participants:              map[string]Participant{},
+		ParticipantsBySession:     map[string]string{},
+		ParticipantOrderBySession: map[string][]string{},
+		Steps:                     map[string]Step{},
+		StepOrderBySession:        map[string][]string{},
+		Claims:                    map[string]Claim{},
+		ArtifactsByStep:           map[string][]Artifact{},
+		Decisions:                 map[string]Decision{},
+		DecisionByStep:            map[string]string{},
+		VotesByDecision:           map[string]map[string]Vote{},
+		EventsBySession:           map[string][]Event{},
+		AppliedTx:                 map[string]bool{},
+		StepKeysBySession:         map[string]map[string]struct{}{},
+		DecisionByStepFinalized:   map[string]bool{},
+		TemplatesByID:             map[string][]Template{},
+		TemplateOrder:             []string{},
+		Offers:                    map[string]Offer{},
+		OffersByStep:              map[string][]string{},
 	}
 }
 
@@ -207,12 +252,18 @@ func (m *Machine) normalizeSnapshot(s *snapshot) {
 	if s.Sessions == nil {
 		s.Sessions = map[string]Session{}
 	}
+	if len(s.SessionOrder) != len(s.Sessions) {
+		s.SessionOrder = rebuildSessionOrder(s.Sessions)
+	}
 	if s.Participants == nil {
 		s.Participants = map[string]Participant{}
 	}
 	if s.ParticipantsBySession == nil {
 		s.ParticipantsBySession = map[string]string{}
 	}
+	if s.ParticipantOrderBySession == nil {
+		s.ParticipantOrderBySession = rebuildParticipantOrder(s.Participants)
+	}
 	if s.Steps == nil {
 		s.Steps = map[string]Step{}
 	}
@@ -250,6 +301,59 @@ func (m *Machine) normalizeSnapshot(s *snapshot) {
 	if s.DecisionByStepFinalized == nil {
 		s.DecisionByStepFinalized = map[string]bool{}
 	}
+	if s.TemplatesByID == nil {
+		s.TemplatesByID = map[string][]Template{}
+	}
+	if s.TemplateOrder == nil {
+		s.TemplateOrder = []string{}
+	}
+	if s.Offers == nil {
+		s.Offers = map[string]Offer{}
+	}
+	if s.OffersByStep == nil {
+		s.OffersByStep = map[string][]string{}
+	}
+}
+
+// rebuildSessionOrder restores creation order for snapshots taken before
+// the order index was persisted.
+func rebuildSessionOrder(sessions map[string]Session) []string {
+	out := make([]string, 0, len(sessions))
+	for sessionID := range sessions {
+		out = append(out, sessionID)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := sessions[out[i]], sessions[out[j]]
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.SessionID < b.SessionID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return out
+}
+
+// rebuildParticipantOrder restores per-session join order for snapshots
+// taken before the order index was persisted.
+func rebuildParticipantOrder(participants map[string]Participant) map[string][]string {
+	bySession := map[string][]Participant{}
+	for _, participant := range participants {
+		bySession[participant.SessionID] = append(bySession[participant.SessionID], participant)
+	}
+	out := make(map[string][]string, len(bySession))
+	for sessionID, items := range bySession {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].JoinedAt.Equal(items[j].JoinedAt) {
+				return items[i].ParticipantID < items[j].ParticipantID
+			}
+			return items[i].JoinedAt.Before(items[j].JoinedAt)
+		})
+		ids := make([]string, 0, len(items))
+		for _, participant := range items {
+			ids = append(ids, participant.ParticipantID)
+		}
+		out[sessionID] = ids
+	}
+	return out
 }
 
 func (m *Machine) copySnapshotLocked() snapshot {
@@ -257,12 +361,16 @@ func (m *Machine) copySnapshotLocked() snapshot {
 	for k, v := range m.s.Sessions {
 		out.Sessions[k] = v
 	}
+	out.SessionOrder = append([]string(nil), m.s.SessionOrder...)
 	for k, v := range m.s.Participants {
 		out.Participants[k] = cloneParticipant(v)
 	}
 	for k, v := range m.s.ParticipantsBySession {
 		out.ParticipantsBySession[k] = v
 	}
+	for k, v := range m.s.ParticipantOrderBySession {
+		out.ParticipantOrderBySession[k] = append([]string(nil), v...)
+	}
 	for k, v := range m.s.Steps {
 		out.Steps[k] = cloneStep(v)
 	}
@@ -304,6 +412,20 @@ func (m *Machine) copySnapshotLocked() snapshot {
 	for k, v := range m.s.DecisionByStepFinalized {
 		out.DecisionByStepFinalized[k] = v
 	}
+	for k, v := range m.s.TemplatesByID {
+		versions := make([]Template, 0, len(v))
+		for _, template := range v {
+			versions = append(versions, cloneTemplate(template))
+		}
+		out.TemplatesByID[k] = versions
+	}
+	out.TemplateOrder = append([]string(nil), m.s.TemplateOrder...)
+	for k, v := range m.s.Offers {
+		out.Offers[k] = v
+	}
+	for k, v := range m.s.OffersByStep {
+		out.OffersByStep[k] = append([]string(nil), v...)
+	}
 	return out
 }
 
@@ -330,6 +452,7 @@ func (m *Machine) ApplyTx(tx protocol.Tx) error {
 	}
 	at := tx.Timestamp.UTC()
 	m.expireClaimsLocked(at, tx.TxID)
+	m.expireOffersLocked(at, tx.TxID)
 
 	var err error
 	switch tx.Op {
@@ -341,8 +464,16 @@ func (m *Machine) ApplyTx(tx protocol.Tx) error {
 		err = m.applyStepClaimLocked(tx, at)
 	case protocol.OpStepRelease:
 		err = m.applyStepReleaseLocked(tx, at)
+	case protocol.OpClaimRenew:
+		err = m.applyClaimRenewLocked(tx, at)
 	case protocol.OpStepHandoff:
 		err = m.applyStepHandoffLocked(tx, at)
+	case protocol.OpStepAssign:
+		err = m.applyStepAssignLocked(tx, at)
+	case protocol.OpAssignAccept:
+		err = m.applyAssignAcceptLocked(tx, at)
+	case protocol.OpAssignDecline:
+		err = m.applyAssignDeclineLocked(tx, at)
 	case protocol.OpArtifactAdd:
 		err = m.applyArtifactAddLocked(tx, at)
 	case protocol.OpDecisionOpen:
@@ -351,6 +482,10 @@ func (m *Machine) ApplyTx(tx protocol.Tx) error {
 		err = m.applyVoteCastLocked(tx, at)
 	case protocol.OpStepResolve:
 		err = m.applyStepResolveLocked(tx, at)
+	case protocol.OpTemplateRegister:
+		err = m.applyTemplateRegisterLocked(tx, at)
+	case protocol.OpTemplateDeprecate:
+		err = m.applyTemplateDeprecateLocked(tx, at)
 	default:
 		err = fmt.Errorf("unsupported op: %s", tx.Op)
 	}
@@ -373,6 +508,12 @@ func (m *Machine) applySessionCreateLocked(tx protocol.Tx, at time.Time) error {
 	if _, ok := m.s.Sessions[sessionID]; ok {
 		return fmt.Errorf("session already exists: %s", sessionID)
 	}
+	if strings.TrimSpace(payload.TemplateID) != "" {
+		payload, err = m.instantiateTemplateLocked(payload)
+		if err != nil {
+			return err
+		}
+	}
 	name := strings.TrimSpace(payload.Name)
 	if name == "" {
 		return errors.New("name is required")
@@ -380,16 +521,23 @@ func (m *Machine) applySessionCreateLocked(tx protocol.Tx, at time.Time) error {
 	if len(payload.Steps) == 0 {
 		return errors.New("steps are required")
 	}
+	matching := protocol.CapabilityMatchingExact
+	if strings.EqualFold(strings.TrimSpace(payload.CapabilityMatching), protocol.CapabilityMatchingStructured) {
+		matching = protocol.CapabilityMatchingStructured
+	}
 	session := Session{
-		SessionID:  sessionID,
-		WorkflowID: strings.TrimSpace(payload.WorkflowID),
-		Name:       name,
-		Status:     SessionStatusActive,
-		Context:    payload.Context,
-		CreatedAt:  at,
-		UpdatedAt:  at,
+		SessionID:       sessionID,
+		WorkflowID:      strings.TrimSpace(payload.WorkflowID),
+		TemplateID:      payload.TemplateID,
+		TemplateVersion: payload.TemplateVersion,
+		Name:            name,
+		Status:          SessionStatusActive,
+		Context:         payload.Context,
+		CreatedAt:       at,
+		UpdatedAt:       at,
 	}
 	m.s.Sessions[sessionID] = session
+	m.s.SessionOrder = append(m.s.SessionOrder, sessionID)
 	if _, ok := m.s.StepKeysBySession[sessionID]; !ok {
 		m.s.StepKeysBySession[sessionID] = map[string]struct{}{}
 	}
@@ -411,7 +559,20 @@ func (m *Machine) applySessionCreateLocked(tx protocol.Tx, at time.Time) error {
 		}
 		ttl := raw.LeaseTTLSeconds
 		if ttl <= 0 {
-			ttl = 900
+			ttl = defaultLeaseSeconds
+		}
+		if raw.MaxLeaseSeconds < 0 {
+			return errors.New("max_lease_seconds must not be negative")
+		}
+		if raw.MaxLeaseSeconds > 0 && raw.MaxLeaseSeconds < ttl {
+			return fmt.Errorf("max_lease_seconds must be >= lease_ttl_seconds for step: %s", stepKey)
+		}
+		renewAudit, err := normalizeRenewAudit(raw.RenewAudit)
+		if err != nil {
+			return err
+		}
+		if raw.MinTrustScore < 0 {
+			return errors.New("min_trust_score must not be negative")
 		}
 		step := Step{
 			StepID:               stepID,
@@ -420,8 +581,12 @@ func (m *Machine) applySessionCreateLocked(tx protocol.Tx, at time.Time) error {
 			Name:                 strings.TrimSpace(raw.Name),
 			Status:               StepStatusOpen,
 			RequiredCapabilities: uniqueNonEmpty(raw.RequiredCapabilities),
+			MinTrustScore:        raw.MinTrustScore,
+			CapabilityMatching:   matching,
 			DependsOn:            uniqueNonEmpty(raw.DependsOn),
 			LeaseTTLSeconds:      ttl,
+			MaxLeaseSeconds:      raw.MaxLeaseSeconds,
+			RenewAudit:           renewAudit,
 			CreatedAt:            at,
 			UpdatedAt:            at,
 		}
@@ -488,6 +653,7 @@ func (m *Machine) applyParticipantJoinLocked(tx protocol.Tx, at time.Time) error
 	}
 	m.s.Participants[participantID] = p
 	m.s.ParticipantsBySession[sessionRefKey] = participantID
+	m.s.ParticipantOrderBySession[sessionID] = append(m.s.ParticipantOrderBySession[sessionID], participantID)
 	m.appendEventLocked(sessionID, nil, string(protocol.OpParticipantJoin), tx.Actor, payload, at, tx.TxID)
 	return nil
 }
@@ -517,9 +683,12 @@ func (m *Machine) applyStepClaimLocked(tx protocol.Tx, at time.Time) error {
 	if !depsResolved(step, m.s.Steps) {
 		return errors.New("step dependencies are not resolved")
 	}
-	if !hasCapabilities(participant.Capabilities, step.RequiredCapabilities) {
+	if !step.satisfiedBy(participant.Capabilities) {
 		return errors.New("participant capabilities do not satisfy requirements")
 	}
+	if !meetsMinTrust(participant, step) {
+		return errors.New("participant trust score below step minimum")
+	}
 	if step.Status != StepStatusOpen {
 		return errors.New("step is not OPEN")
 	}
@@ -529,17 +698,25 @@ func (m *Machine) applyStepClaimLocked(tx protocol.Tx, at time.Time) error {
 	if _, exists := m.s.Claims[claimID]; exists {
 		return fmt.Errorf("claim already exists: %s", claimID)
 	}
-	leaseSeconds := payload.LeaseSeconds
-	if leaseSeconds <= 0 {
-		leaseSeconds = step.LeaseTTLSeconds
+	offerID, offer := m.findPendingOfferByStepLocked(step.StepID, at)
+	if offerID != "" && offer.ParticipantID != participant.ParticipantID {
+		return errors.New("step is offered to another participant")
 	}
-	if leaseSeconds <= 0 {
-		leaseSeconds = 900
+	m.startClaimLocked(step, participant.ParticipantID, claimID, payload.LeaseSeconds, at)
+	if offerID != "" {
+		m.closeOfferLocked(offerID, OfferStatusAccepted, claimID, "", at)
 	}
+	m.appendEventLocked(step.SessionID, &step.StepID, string(protocol.OpStepClaim), tx.Actor, payload, at, tx.TxID)
+	return nil
+}
+
+// startClaimLocked records a new active claim and marks the step CLAIMED.
+func (m *Machine) startClaimLocked(step Step, participantID, claimID string, requestedLease int, at time.Time) Claim {
+	leaseSeconds := leaseSecondsFor(step, requestedLease)
 	claim := Claim{
 		ClaimID:       claimID,
 		StepID:        step.StepID,
-		ParticipantID: participant.ParticipantID,
+		ParticipantID: participantID,
 		Status:        ClaimStatusActive,
 		LeaseUntil:    at.Add(time.Duration(leaseSeconds) * time.Second),
 		CreatedAt:     at,
@@ -549,7 +726,68 @@ func (m *Machine) applyStepClaimLocked(tx protocol.Tx, at time.Time) error {
 	step.Status = StepStatusClaimed
 	step.UpdatedAt = at
 	m.s.Steps[step.StepID] = step
-	m.appendEventLocked(step.SessionID, &step.StepID, string(protocol.OpStepClaim), tx.Actor, payload, at, tx.TxID)
+	return claim
+}
+
+func (m *Machine) applyClaimRenewLocked(tx protocol.Tx, at time.Time) error {
+	payload, err := protocol.DecodePayload[protocol.ClaimRenewPayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	stepID := strings.TrimSpace(payload.StepID)
+	participantID := strings.TrimSpace(payload.ParticipantID)
+	if stepID == "" || participantID == "" {
+		return errors.New("step_id and participant_id are required")
+	}
+	step, ok := m.s.Steps[stepID]
+	if !ok {
+		return fmt.Errorf("step not found: %s", stepID)
+	}
+	participant, ok := m.s.Participants[participantID]
+	if !ok {
+		return fmt.Errorf("participant not found: %s", participantID)
+	}
+	claimID, claim := m.findActiveClaimByStepAndParticipantLocked(stepID, participantID, at)
+	if claimID == "" {
+		return errors.New("active claim not found for participant")
+	}
+	if expected := strings.TrimSpace(payload.ClaimID); expected != "" && expected != claimID {
+		return fmt.Errorf("claim is not active: %s", expected)
+	}
+	leaseUntil := at.Add(time.Duration(leaseSecondsFor(step, payload.LeaseSeconds)) * time.Second)
+	clamped := false
+	if step.MaxLeaseSeconds > 0 {
+		maxUntil := claim.CreatedAt.Add(time.Duration(step.MaxLeaseSeconds) * time.Second)
+		if !claim.LeaseUntil.Before(maxUntil) {
+			return errors.New("claim lease already at step maximum")
+		}
+		if leaseUntil.After(maxUntil) {
+			leaseUntil = maxUntil
+			clamped = true
+		}
+	}
+	previousUntil := claim.LeaseUntil
+	if leaseUntil.After(claim.LeaseUntil) {
+		claim.LeaseUntil = leaseUntil
+	}
+	renewedAt := at
+	claim.RenewCount++
+	claim.LastRenewedAt = &renewedAt
+	claim.UpdatedAt = at
+	m.s.Claims[claimID] = claim
+	participant.LastSeenAt = at
+	m.s.Participants[participantID] = participant
+
+	if step.RenewAudit == RenewAuditAll || (step.RenewAudit == RenewAuditClamped && clamped) {
+		m.appendEventLocked(step.SessionID, &step.StepID, string(protocol.OpClaimRenew), tx.Actor, map[string]any{
+			"claimId":            claimID,
+			"participantId":      participantID,
+			"previousLeaseUntil": previousUntil,
+			"leaseUntil":         claim.LeaseUntil,
+			"renewCount":         claim.RenewCount,
+			"clamped":            clamped,
+		}, at, tx.TxID)
+	}
 	return nil
 }
 
@@ -611,9 +849,12 @@ func (m *Machine) applyStepHandoffLocked(tx protocol.Tx, at time.Time) error {
 	if fromParticipant.SessionID != step.SessionID || toParticipant.SessionID != step.SessionID {
 		return errors.New("participant does not belong to step session")
 	}
-	if !hasCapabilities(toParticipant.Capabilities, step.RequiredCapabilities) {
+	if !step.satisfiedBy(toParticipant.Capabilities) {
 		return errors.New("target participant capabilities do not satisfy requirements")
 	}
+	if !meetsMinTrust(toParticipant, step) {
+		return errors.New("target participant trust score below step minimum")
+	}
 	activeID, active := m.findActiveClaimByStepAndParticipantLocked(stepID, fromParticipantID, at)
 	if activeID == "" {
 		return errors.New("source participant has no active claim")
@@ -625,13 +866,7 @@ func (m *Machine) applyStepHandoffLocked(tx protocol.Tx, at time.Time) error {
 	active.UpdatedAt = at
 	m.s.Claims[activeID] = active
 
-	leaseSeconds := payload.LeaseSeconds
-	if leaseSeconds <= 0 {
-		leaseSeconds = step.LeaseTTLSeconds
-	}
-	if leaseSeconds <= 0 {
-		leaseSeconds = 900
-	}
+	leaseSeconds := leaseSecondsFor(step, payload.LeaseSeconds)
 	newClaim := Claim{
 		ClaimID:       newClaimID,
 		StepID:        step.StepID,
@@ -1046,6 +1281,34 @@ func (m *Machine) allStepsResolvedLocked(sessionID string) bool {
 	return true
 }
 
+// leaseSecondsFor resolves the lease for a new claim, falling back to the
+// step TTL and never exceeding the step maximum.
+func leaseSecondsFor(step Step, requested int) int {
+	leaseSeconds := requested
+	if leaseSeconds <= 0 {
+		leaseSeconds = step.LeaseTTLSeconds
+	}
+	if leaseSeconds <= 0 {
+		leaseSeconds = defaultLeaseSeconds
+	}
+	if step.MaxLeaseSeconds > 0 && leaseSeconds > step.MaxLeaseSeconds {
+		leaseSeconds = step.MaxLeaseSeconds
+	}
+	return leaseSeconds
+}
+
+func normalizeRenewAudit(raw string) (string, error) {
+	policy := strings.ToUpper(strings.TrimSpace(raw))
+	switch policy {
+	case "":
+		return RenewAuditClamped, nil
+	case RenewAuditNone, RenewAuditClamped, RenewAuditAll:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("renew_audit must be %s, %s or %s", RenewAuditNone, RenewAuditClamped, RenewAuditAll)
+	}
+}
+
 func sessionRef(sessionID, ref string) string {
 	return strings.ToLower(strings.TrimSpace(sessionID) + "::" + strings.TrimSpace(ref))
 }
@@ -1103,33 +1366,6 @@ func depsResolved(step Step, all map[string]Step) bool {
 	return true
 }
 
-func hasCapabilities(actual, required []string) bool {
-	if len(required) == 0 {
-		return true
-	}
-	if len(actual) == 0 {
-		return false
-	}
-	have := map[string]struct{}{}
-	for _, capability := range actual {
-		capability = strings.TrimSpace(capability)
-		if capability == "" {
-			continue
-		}
-		have[capability] = struct{}{}
-	}
-	for _, capability := range required {
-		capability = strings.TrimSpace(capability)
-		if capability == "" {
-			continue
-		}
-		if _, ok := have[capability]; !ok {
-			return false
-		}
-	}
-	return true
-}
-
 type decisionPolicy struct {
 	MinApprovals    int `json:"min_approvals"`
 	Quorum          int `json:"quorum"`
@@ -1185,26 +1421,6 @@ func evaluateDecision(policyRaw json.RawMessage, votes map[string]Vote) (string,
 	return DecisionStatusPending, ""
 }
 
-func pageWindow(total, limit, offset int) (int, int) {
-	if limit <= 0 {
-		limit = 100
-	}
-	if limit > 500 {
-		limit = 500
-	}
-	if offset < 0 {
-		offset = 0
-	}
-	if offset >= total {
-		return total, total
-	}
-	end := offset + limit
-	if end > total {
-		end = total
-	}
-	return offset, end
-}
-
 func cloneSession(in Session) Session {
 	if in.Context != nil {
 		in.Context = append([]byte(nil), in.Context...)
@@ -1247,36 +1463,79 @@ func (m *Machine) GetSession(sessionID string) (Session, bool) {
 	return cloneSession(session), true
 }
 
-func (m *Machine) ListParticipants(sessionID string, limit, offset int) []Participant {
+// ListSessions returns sessions in creation order.
+func (m *Machine) ListSessions(filter SessionFilter, limit int, cursor string) ([]Session, string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	sessionID = strings.TrimSpace(sessionID)
-	out := make([]Participant, 0)
-	for _, participant := range m.s.Participants {
-		if participant.SessionID != sessionID {
-			continue
-		}
-		out = append(out, cloneParticipant(participant))
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
 	}
-	sort.SliceStable(out, func(i, j int) bool {
-		if out[i].JoinedAt.Equal(out[j].JoinedAt) {
-			return out[i].ParticipantID < out[j].ParticipantID
-		}
-		return out[i].JoinedAt.Before(out[j].JoinedAt)
+	order := m.s.SessionOrder
+	idx, next := pageAscending(len(order), after, limit, func(i int) bool {
+		session, ok := m.s.Sessions[order[i]]
+		return ok && filter.matches(session)
 	})
-	start, end := pageWindow(len(out), limit, offset)
-	return append([]Participant(nil), out[start:end]...)
+	out := make([]Session, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, cloneSession(m.s.Sessions[order[i]]))
+	}
+	return out, next, nil
+}
+
+// ListParticipants returns session participants in join order.
+func (m *Machine) ListParticipants(sessionID string, filter ParticipantFilter, limit int, cursor string) ([]Participant, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	order := m.s.ParticipantOrderBySession[strings.TrimSpace(sessionID)]
+	idx, next := pageAscending(len(order), after, limit, func(i int) bool {
+		participant, ok := m.s.Participants[order[i]]
+		return ok && filter.matches(participant)
+	})
+	out := make([]Participant, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, cloneParticipant(m.s.Participants[order[i]]))
+	}
+	return out, next, nil
 }
 
-func (m *Machine) ListOpenSteps(sessionID string, participantID *string, at time.Time, limit, offset int) ([]Step, error) {
+// ListSteps returns session steps in definition order.
+func (m *Machine) ListSteps(sessionID string, filter StepFilter, limit int, cursor string) ([]Step, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	order := m.s.StepOrderBySession[strings.TrimSpace(sessionID)]
+	idx, next := pageAscending(len(order), after, limit, func(i int) bool {
+		step, ok := m.s.Steps[order[i]]
+		return ok && filter.matches(step)
+	})
+	out := make([]Step, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, cloneStep(m.s.Steps[order[i]]))
+	}
+	return out, next, nil
+}
+
+// ListOpenSteps returns claimable steps in session order. When a participant
+// is given, only steps it may claim (and not offered to someone else) are
+// returned, ranked by capability match score and then by the step's trust
+// requirement so the best-fit work comes first.
+func (m *Machine) ListOpenSteps(sessionID string, participantID *string, at time.Time, limit int, cursor string) ([]Step, string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	sessionID = strings.TrimSpace(sessionID)
 	if sessionID == "" {
-		return nil, errors.New("session_id is required")
+		return nil, "", errors.New("session_id is required")
 	}
 	if _, ok := m.s.Sessions[sessionID]; !ok {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+		return nil, "", fmt.Errorf("session not found: %s", sessionID)
 	}
 	var participant *Participant
 	if participantID != nil {
@@ -1284,44 +1543,53 @@ func (m *Machine) ListOpenSteps(sessionID string, participantID *string, at time
 		if pid != "" {
 			p, ok := m.s.Participants[pid]
 			if !ok {
-				return nil, fmt.Errorf("participant not found: %s", pid)
+				return nil, "", fmt.Errorf("participant not found: %s", pid)
 			}
 			if p.SessionID != sessionID {
-				return nil, errors.New("participant not found in session")
+				return nil, "", errors.New("participant not found in session")
 			}
 			cp := cloneParticipant(p)
 			participant = &cp
 		}
 	}
-	stepIDs := append([]string(nil), m.s.StepOrderBySession[sessionID]...)
-	out := make([]Step, 0, len(stepIDs))
-	for _, stepID := range stepIDs {
-		step, ok := m.s.Steps[stepID]
-		if !ok {
-			continue
+	order := m.s.StepOrderBySession[sessionID]
+	if participant == nil {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
 		}
-		stepStatus := step.Status
-		if stepStatus == StepStatusClaimed {
-			if activeID, _ := m.findActiveClaimByStepLocked(step.StepID, at); activeID == "" {
-				stepStatus = StepStatusOpen
-			}
+		idx, next := pageAscending(len(order), after, limit, func(i int) bool {
+			step, ok := m.s.Steps[order[i]]
+			return ok && m.claimableLocked(step, at)
+		})
+		out := make([]Step, 0, len(idx))
+		for _, i := range idx {
+			out = append(out, cloneStep(m.s.Steps[order[i]]))
 		}
-		if stepStatus != StepStatusOpen {
-			continue
-		}
-		if !depsResolved(step, m.s.Steps) {
-			continue
-		}
-		if participant != nil && !hasCapabilities(participant.Capabilities, step.RequiredCapabilities) {
+		return out, next, nil
+	}
+	after, err := decodeRankCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	keys := make([]rankKey, 0, len(order))
+	for i, stepID := range order {
+		step, ok := m.s.Steps[stepID]
+		if !ok || !m.claimableLocked(step, at) || !CanTake(*participant, step) {
 			continue
 		}
-		if activeID, _ := m.findActiveClaimByStepLocked(step.StepID, at); activeID != "" {
+		if offerID, offer := m.findPendingOfferByStepLocked(stepID, at); offerID != "" && offer.ParticipantID != participant.ParticipantID {
 			continue
 		}
-		out = append(out, cloneStep(step))
+		match := capability.Match(participant.Capabilities, step.RequiredCapabilities)
+		keys = append(keys, rankKey{score: match.Score, trust: step.MinTrustScore, pos: i})
+	}
+	page, next := pageRanked(keys, after, limit)
+	out := make([]Step, 0, len(page))
+	for _, k := range page {
+		out = append(out, cloneStep(m.s.Steps[order[k.pos]]))
 	}
-	start, end := pageWindow(len(out), limit, offset)
-	return append([]Step(nil), out[start:end]...), nil
+	return out, next, nil
 }
 
 func (m *Machine) GetStep(stepID string) (Step, bool) {
@@ -1355,23 +1623,23 @@ func (m *Machine) ListArtifacts(stepID string) []Artifact {
 	return out
 }
 
-func (m *Machine) ListEvents(sessionID string, limit, offset int) []Event {
+// ListEvents returns session events newest first, in commit order.
+func (m *Machine) ListEvents(sessionID string, filter EventFilter, limit int, cursor string) ([]Event, string, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	sessionID = strings.TrimSpace(sessionID)
-	items := append([]Event(nil), m.s.EventsBySession[sessionID]...)
-	sort.SliceStable(items, func(i, j int) bool {
-		if items[i].CreatedAt.Equal(items[j].CreatedAt) {
-			return items[i].EventID > items[j].EventID
-		}
-		return items[i].CreatedAt.After(items[j].CreatedAt)
+	before, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	items := m.s.EventsBySession[strings.TrimSpace(sessionID)]
+	idx, next := pageDescending(len(items), before, limit, func(i int) bool {
+		return filter.matches(items[i])
 	})
-	start, end := pageWindow(len(items), limit, offset)
-	out := make([]Event, 0, end-start)
-	for _, event := range items[start:end] {
-		out = append(out, cloneEvent(event))
+	out := make([]Event, 0, len(idx))
+	for _, i := range idx {
+		out = append(out, cloneEvent(items[i]))
 	}
-	return out
+	return out, next, nil
 }
 
 type Stats struct {
@@ -1386,6 +1654,9 @@ type Stats struct {
 	PendingDecisions int `json:"pendingDecisions"`
 	Votes            int `json:"votes"`
 	Events           int `json:"events"`
+	Templates        int `json:"templates"`
+	Offers           int `json:"offers"`
+	PendingOffers    int `json:"pendingOffers"`
 	AppliedTx        int `json:"appliedTx"`
 }
 
@@ -1398,6 +1669,8 @@ func (m *Machine) StateStats(at time.Time) Stats {
 		Steps:        len(m.s.Steps),
 		Claims:       len(m.s.Claims),
 		Decisions:    len(m.s.Decisions),
+		Templates:    len(m.s.TemplatesByID),
+		Offers:       len(m.s.Offers),
 		AppliedTx:    len(m.s.AppliedTx),
 	}
 	for _, step := range m.s.Steps {
@@ -1416,6 +1689,11 @@ func (m *Machine) StateStats(at time.Time) Stats {
 			stats.ActiveClaims++
 		}
 	}
+	for _, offer := range m.s.Offers {
+		if offer.Status == OfferStatusPending && offer.ExpiresAt.After(at) {
+			stats.PendingOffers++
+		}
+	}
 	for _, artifacts := range m.s.ArtifactsByStep {
 		stats.Artifacts += len(artifacts)
 	}
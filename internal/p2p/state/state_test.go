@@ -29,7 +29,7 @@ func TestMachineEndToEnd(t *testing.T) {
 	mustApply(t, m, signedTx(t, priv, "tx-003", "session-1", "actor:bob", base.Add(2*time.Second),
 		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-bob", SessionID: "session-1", Type: "HUMAN", Ref: "user:bob", Capabilities: []string{"review"}}))
 
-	open, err := m.ListOpenSteps("session-1", ptr("p-bob"), base.Add(3*time.Second), 100, 0)
+	open, _, err := m.ListOpenSteps("session-1", ptr("p-bob"), base.Add(3*time.Second), 100, "")
 	if err != nil {
 		t.Fatalf("list open steps: %v", err)
 	}
@@ -48,7 +48,7 @@ func TestMachineEndToEnd(t *testing.T) {
 	mustApply(t, m, signedTx(t, priv, "tx-008", "session-1", "actor:alice", base.Add(7*time.Second),
 		protocol.OpStepResolve, protocol.StepResolvePayload{StepID: "step-1", ParticipantID: ptr("p-alice")}))
 
-	open2, err := m.ListOpenSteps("session-1", ptr("p-bob"), base.Add(8*time.Second), 100, 0)
+	open2, _, err := m.ListOpenSteps("session-1", ptr("p-bob"), base.Add(8*time.Second), 100, "")
 	if err != nil {
 		t.Fatalf("list open steps after resolve: %v", err)
 	}
@@ -69,7 +69,10 @@ func TestMachineEndToEnd(t *testing.T) {
 		t.Fatalf("expected completed session, got %s", session.Status)
 	}
 
-	events := m.ListEvents("session-1", 100, 0)
+	events, _, err := m.ListEvents("session-1", EventFilter{}, 100, "")
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
 	if len(events) < 10 {
 		t.Fatalf("expected timeline events, got %d", len(events))
 	}
@@ -112,12 +115,138 @@ func TestMachineClaimExpiresOnNextTx(t *testing.T) {
 
 func TestListOpenStepsRequiresExistingSession(t *testing.T) {
 	m := NewMachine()
-	_, err := m.ListOpenSteps("missing-session", nil, time.Now().UTC(), 100, 0)
+	_, _, err := m.ListOpenSteps("missing-session", nil, time.Now().UTC(), 100, "")
 	if err == nil {
 		t.Fatalf("expected session not found error")
 	}
 }
 
+func TestListEventsCursorStableAcrossAppends(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+
+	mustApply(t, m, signedTx(t, priv, "tx-c1", "session-cur", "actor:admin", base,
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID: "session-cur",
+			Name:      "Cursor Session",
+			Steps:     []protocol.SessionStep{{StepID: "c-s1", StepKey: "build"}},
+		}))
+	for i, ref := range []string{"user:a", "user:b", "user:c"} {
+		mustApply(t, m, signedTx(t, priv, "tx-cj"+ref, "session-cur", "actor:"+ref, base.Add(time.Duration(i+1)*time.Second),
+			protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-" + ref, SessionID: "session-cur", Type: "AGENT", Ref: ref}))
+	}
+
+	page1, next, err := m.ListEvents("session-cur", EventFilter{}, 2, "")
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(page1) != 2 || next == "" {
+		t.Fatalf("expected first page of 2 with cursor, got %d next=%q", len(page1), next)
+	}
+
+	mustApply(t, m, signedTx(t, priv, "tx-c9", "session-cur", "actor:user:d", base.Add(10*time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-d", SessionID: "session-cur", Type: "HUMAN", Ref: "user:d"}))
+
+	page2, next2, err := m.ListEvents("session-cur", EventFilter{}, 2, next)
+	if err != nil {
+		t.Fatalf("list events page 2: %v", err)
+	}
+	if len(page2) != 2 || next2 != "" {
+		t.Fatalf("expected final page of 2, got %d next=%q", len(page2), next2)
+	}
+	if page2[1].Type != string(protocol.OpSessionCreate) {
+		t.Fatalf("expected oldest event last, got %s", page2[1].Type)
+	}
+	seen := map[string]bool{}
+	for _, event := range append(page1, page2...) {
+		if seen[event.EventID] {
+			t.Fatalf("duplicate event across pages: %s", event.EventID)
+		}
+		seen[event.EventID] = true
+	}
+
+	joins, _, err := m.ListEvents("session-cur", EventFilter{Type: string(protocol.OpParticipantJoin), Since: ptr(base.Add(2 * time.Second))}, 100, "")
+	if err != nil {
+		t.Fatalf("list filtered events: %v", err)
+	}
+	if len(joins) != 3 {
+		t.Fatalf("expected 3 joins since t+2s, got %d", len(joins))
+	}
+
+	if _, _, err := m.ListEvents("session-cur", EventFilter{}, 2, "not-a-cursor"); err == nil {
+		t.Fatalf("expected invalid cursor error")
+	}
+}
+
+func TestListParticipantsAndSessionsFilters(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 1, 1, 3, 0, 0, 0, time.UTC)
+
+	for i, id := range []string{"sess-a", "sess-b"} {
+		mustApply(t, m, signedTx(t, priv, "tx-f"+id, id, "actor:admin", base.Add(time.Duration(i)*time.Second),
+			protocol.OpSessionCreate, protocol.SessionCreatePayload{
+				SessionID:  id,
+				WorkflowID: "wf-" + id,
+				Name:       id,
+				Steps:      []protocol.SessionStep{{StepID: id + "-s1", StepKey: "build"}},
+			}))
+	}
+	mustApply(t, m, signedTx(t, priv, "tx-fp1", "sess-a", "actor:a", base.Add(3*time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "fp-1", SessionID: "sess-a", Type: "AGENT", Ref: "agent:1", Capabilities: []string{"lint"}}))
+	mustApply(t, m, signedTx(t, priv, "tx-fp2", "sess-a", "actor:b", base.Add(4*time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "fp-2", SessionID: "sess-a", Type: "HUMAN", Ref: "user:2"}))
+	mustApply(t, m, signedTx(t, priv, "tx-fp3", "sess-b", "actor:c", base.Add(5*time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "fp-3", SessionID: "sess-b", Type: "AGENT", Ref: "agent:3", Capabilities: []string{"lint"}}))
+
+	agents, _, err := m.ListParticipants("sess-a", ParticipantFilter{Type: "agent", Capability: "lint"}, 100, "")
+	if err != nil {
+		t.Fatalf("list participants: %v", err)
+	}
+	if len(agents) != 1 || agents[0].ParticipantID != "fp-1" {
+		t.Fatalf("unexpected filtered participants: %+v", agents)
+	}
+
+	first, next, err := m.ListParticipants("sess-a", ParticipantFilter{}, 1, "")
+	if err != nil || len(first) != 1 || first[0].ParticipantID != "fp-1" || next == "" {
+		t.Fatalf("unexpected first participant page: %+v next=%q err=%v", first, next, err)
+	}
+	second, next, err := m.ListParticipants("sess-a", ParticipantFilter{}, 1, next)
+	if err != nil || len(second) != 1 || second[0].ParticipantID != "fp-2" || next != "" {
+		t.Fatalf("unexpected second participant page: %+v next=%q err=%v", second, next, err)
+	}
+
+	sessions, _, err := m.ListSessions(SessionFilter{WorkflowID: "wf-sess-b", Status: SessionStatusActive}, 100, "")
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != "sess-b" {
+		t.Fatalf("unexpected filtered sessions: %+v", sessions)
+	}
+
+	steps, _, err := m.ListSteps("sess-a", StepFilter{Status: StepStatusResolved}, 100, "")
+	if err != nil {
+		t.Fatalf("list steps: %v", err)
+	}
+	if len(steps) != 0 {
+		t.Fatalf("expected no resolved steps, got %+v", steps)
+	}
+
+	raw, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	restored := NewMachine()
+	if err := restored.Unmarshal(raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	all, _, err := restored.ListSessions(SessionFilter{}, 100, "")
+	if err != nil || len(all) != 2 || all[0].SessionID != "sess-a" {
+		t.Fatalf("unexpected restored session order: %+v err=%v", all, err)
+	}
+}
+
 func mustKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
 	t.Helper()
 	pub, priv, err := ed25519.GenerateKey(rand.Reader)
@@ -162,3 +291,234 @@ func signedTx(t *testing.T, priv ed25519.PrivateKey, txID, sessionID, actor stri
 	}
 	return tx
 }
+
+func TestSessionCreateFromTemplate(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 1, 1, 4, 0, 0, 0, time.UTC)
+	lang := "go"
+
+	mustApply(t, m, signedTx(t, priv, "tx-t1", "", "actor:admin", base,
+		protocol.OpTemplateRegister, protocol.TemplateRegisterPayload{
+			TemplateID: "tpl-review",
+			Name:       "Review {{repo}}",
+			Params: []protocol.TemplateParam{
+				{Name: "repo", Required: true},
+				{Name: "lang", Default: &lang},
+			},
+			Context: rawJSON(`{"repo":"{{repo}}","retries":3}`),
+			Steps: []protocol.TemplateStep{
+				{StepKey: "build", Name: "Build {{repo}}", RequiredCapabilities: []string{"{{lang}}"}},
+				{StepKey: "review", DependsOn: []string{"build"}},
+			},
+		}))
+
+	if err := m.ApplyTx(signedTx(t, priv, "tx-t2", "", "actor:admin", base.Add(time.Second),
+		protocol.OpTemplateRegister, protocol.TemplateRegisterPayload{
+			TemplateID: "tpl-review",
+			Version:    5,
+			Name:       "skip",
+			Steps:      []protocol.TemplateStep{{StepKey: "a"}},
+		})); err == nil {
+		t.Fatalf("expected non-sequential version to be rejected")
+	}
+	if err := m.ApplyTx(signedTx(t, priv, "tx-t3", "", "actor:admin", base.Add(time.Second),
+		protocol.OpTemplateRegister, protocol.TemplateRegisterPayload{
+			TemplateID: "tpl-bad",
+			Name:       "{{missing}}",
+			Steps:      []protocol.TemplateStep{{StepKey: "a"}},
+		})); err == nil {
+		t.Fatalf("expected undeclared placeholder to be rejected")
+	}
+	if err := m.ApplyTx(signedTx(t, priv, "tx-t4", "s-missing", "actor:admin", base.Add(2*time.Second),
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{SessionID: "s-missing", TemplateID: "tpl-review"})); err == nil {
+		t.Fatalf("expected missing required param to be rejected")
+	}
+
+	mustApply(t, m, signedTx(t, priv, "tx-t5", "s-tpl", "actor:admin", base.Add(3*time.Second),
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID:  "s-tpl",
+			TemplateID: "tpl-review",
+			Params:     map[string]string{"repo": "hub"},
+		}))
+
+	session, ok := m.GetSession("s-tpl")
+	if !ok {
+		t.Fatalf("session not found")
+	}
+	if session.Name != "Review hub" || session.TemplateVersion != 1 || session.WorkflowID != "tpl-review" {
+		t.Fatalf("unexpected session from template: %+v", session)
+	}
+	if string(session.Context) != `{"repo":"hub","retries":3}` {
+		t.Fatalf("unexpected substituted context: %s", session.Context)
+	}
+	step, ok := m.GetStep("s-tpl:build")
+	if !ok {
+		t.Fatalf("expected derived step id")
+	}
+	if step.Name != "Build hub" || len(step.RequiredCapabilities) != 1 || step.RequiredCapabilities[0] != "go" {
+		t.Fatalf("unexpected substituted step: %+v", step)
+	}
+
+	mustApply(t, m, signedTx(t, priv, "tx-t6", "", "actor:admin", base.Add(4*time.Second),
+		protocol.OpTemplateDeprecate, protocol.TemplateDeprecatePayload{TemplateID: "tpl-review", Version: 1, Reason: "superseded"}))
+	if err := m.ApplyTx(signedTx(t, priv, "tx-t7", "s-dep", "actor:admin", base.Add(5*time.Second),
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID:  "s-dep",
+			TemplateID: "tpl-review",
+			Params:     map[string]string{"repo": "hub"},
+		})); err == nil {
+		t.Fatalf("expected deprecated template to be rejected")
+	}
+	tpl, ok := m.GetTemplate("tpl-review", 1)
+	if !ok || tpl.Status != TemplateStatusDeprecated || tpl.DeprecationReason != "superseded" {
+		t.Fatalf("unexpected deprecated template: %+v", tpl)
+	}
+}
+
+func TestClaimRenewExtendsLeaseUpToStepMaximum(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 1, 1, 5, 0, 0, 0, time.UTC)
+
+	mustApply(t, m, signedTx(t, priv, "tx-r1", "session-renew", "actor:admin", base,
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID: "session-renew",
+			Name:      "Renew Session",
+			Steps: []protocol.SessionStep{
+				{StepID: "r-s1", StepKey: "train", LeaseTTLSeconds: 60, MaxLeaseSeconds: 150},
+			},
+		}))
+	mustApply(t, m, signedTx(t, priv, "tx-r2", "session-renew", "actor:a", base.Add(time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "r-p1", SessionID: "session-renew", Type: "AGENT", Ref: "agent:a"}))
+	mustApply(t, m, signedTx(t, priv, "tx-r3", "session-renew", "actor:a", base.Add(2*time.Second),
+		protocol.OpStepClaim, protocol.StepClaimPayload{ClaimID: "r-claim", StepID: "r-s1", ParticipantID: "r-p1"}))
+	eventsBefore, _, _ := m.ListEvents("session-renew", EventFilter{}, 100, "")
+
+	mustApply(t, m, signedTx(t, priv, "tx-r4", "session-renew", "actor:a", base.Add(50*time.Second),
+		protocol.OpClaimRenew, protocol.ClaimRenewPayload{StepID: "r-s1", ParticipantID: "r-p1", ClaimID: "r-claim"}))
+	if events, _, _ := m.ListEvents("session-renew", EventFilter{}, 100, ""); len(events) != len(eventsBefore) {
+		t.Fatalf("expected unclamped renewal to skip event log, got %d events", len(events))
+	}
+	participants, _, _ := m.ListParticipants("session-renew", ParticipantFilter{}, 10, "")
+	if !participants[0].LastSeenAt.Equal(base.Add(50 * time.Second)) {
+		t.Fatalf("expected renewal to touch lastSeenAt, got %s", participants[0].LastSeenAt)
+	}
+
+	// Past the original 60s lease; the renewed claim must still hold.
+	mustApply(t, m, signedTx(t, priv, "tx-r5", "session-renew", "actor:a", base.Add(100*time.Second),
+		protocol.OpClaimRenew, protocol.ClaimRenewPayload{StepID: "r-s1", ParticipantID: "r-p1", LeaseSeconds: 600}))
+	clamped, _, _ := m.ListEvents("session-renew", EventFilter{Type: string(protocol.OpClaimRenew)}, 100, "")
+	if len(clamped) != 1 {
+		t.Fatalf("expected one clamped renewal event, got %d", len(clamped))
+	}
+	if err := m.ApplyTx(signedTx(t, priv, "tx-r6", "session-renew", "actor:a", base.Add(120*time.Second),
+		protocol.OpClaimRenew, protocol.ClaimRenewPayload{StepID: "r-s1", ParticipantID: "r-p1"})); err == nil {
+		t.Fatalf("expected renewal at step maximum to be rejected")
+	}
+	stats := m.StateStats(base.Add(153 * time.Second))
+	if stats.ActiveClaims != 0 {
+		t.Fatalf("expected claim to lapse at claim time + max lease, got %d active", stats.ActiveClaims)
+	}
+}
+
+func TestListOpenStepsRanksByCapabilityMatchAndTrust(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	mustApply(t, m, signedTx(t, priv, "tx-r1", "s-rank", "actor:admin", base,
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID:          "s-rank",
+			Name:               "Ranking",
+			CapabilityMatching: protocol.CapabilityMatchingStructured,
+			Steps: []protocol.SessionStep{
+				{StepID: "generic", StepKey: "generic"},
+				{StepID: "wild", StepKey: "wild", RequiredCapabilities: []string{"code.lint"}},
+				{StepID: "exact", StepKey: "exact", RequiredCapabilities: []string{"code.review"}},
+				{StepID: "trusted", StepKey: "trusted", RequiredCapabilities: []string{"code.review"}, MinTrustScore: 50},
+				{StepID: "python", StepKey: "python", RequiredCapabilities: []string{"python>=3.11"}},
+				{StepID: "gated", StepKey: "gated", MinTrustScore: 90},
+			},
+		}))
+	mustApply(t, m, signedTx(t, priv, "tx-r2", "s-rank", "actor:a", base.Add(time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-a", SessionID: "s-rank", Type: "AGENT", Ref: "agent:a",
+			Capabilities: []string{"code.review", "code.*", "python@3.10"}, TrustScore: 60}))
+
+	open, next, err := m.ListOpenSteps("s-rank", ptr("p-a"), base.Add(2*time.Second), 2, "")
+	if err != nil {
+		t.Fatalf("list open steps: %v", err)
+	}
+	if len(open) != 2 || open[0].StepID != "trusted" || open[1].StepID != "exact" || next == "" {
+		t.Fatalf("unexpected first ranked page: %+v next=%q", open, next)
+	}
+	rest, next, err := m.ListOpenSteps("s-rank", ptr("p-a"), base.Add(2*time.Second), 2, next)
+	if err != nil {
+		t.Fatalf("list open steps page 2: %v", err)
+	}
+	if len(rest) != 2 || rest[0].StepID != "wild" || rest[1].StepID != "generic" || next != "" {
+		t.Fatalf("unexpected second ranked page: %+v next=%q", rest, next)
+	}
+
+	if err := m.ApplyTx(signedTx(t, priv, "tx-r3", "s-rank", "actor:a", base.Add(3*time.Second),
+		protocol.OpStepClaim, protocol.StepClaimPayload{ClaimID: "c-py", StepID: "python", ParticipantID: "p-a"})); err == nil {
+		t.Fatalf("expected version constraint to reject claim")
+	}
+	if err := m.ApplyTx(signedTx(t, priv, "tx-r4", "s-rank", "actor:a", base.Add(3*time.Second),
+		protocol.OpStepClaim, protocol.StepClaimPayload{ClaimID: "c-gate", StepID: "gated", ParticipantID: "p-a"})); err == nil {
+		t.Fatalf("expected trust minimum to reject claim")
+	}
+	legacyJoin := signedTx(t, priv, "tx-r5", "s-rank", "actor:b", base.Add(4*time.Second),
+		protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-b", SessionID: "s-rank", Type: "AGENT", Ref: "agent:b",
+			Capabilities: []string{"code.*@1"}})
+	if err := m.Admit(legacyJoin); err == nil {
+		t.Fatalf("expected malformed capability grant to be rejected at admission")
+	}
+	// Entries committed before grants were validated still replay.
+	mustApply(t, m, legacyJoin)
+	if err := m.Admit(signedTx(t, priv, "tx-r6", "s-rank", "actor:a", base.Add(5*time.Second),
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{SessionID: "s-bad", Name: "bad",
+			Steps: []protocol.SessionStep{{StepID: "bad", StepKey: "bad", RequiredCapabilities: []string{"code.review>>1"}}}})); err == nil {
+		t.Fatalf("expected malformed capability requirement to be rejected at admission")
+	}
+}
+
+func TestStepClaimKeepsExactMatchingForLegacySessions(t *testing.T) {
+	m := NewMachine()
+	_, priv := mustKey(t)
+	base := time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC)
+
+	mustApply(t, m, signedTx(t, priv, "tx-l1", "s-legacy", "actor:admin", base,
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID: "s-legacy",
+			Name:      "Legacy",
+			Steps:     []protocol.SessionStep{{StepID: "legacy-review", StepKey: "review", RequiredCapabilities: []string{"code.review"}}},
+		}))
+	mustApply(t, m, signedTx(t, priv, "tx-l2", "s-structured", "actor:admin", base,
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{
+			SessionID:          "s-structured",
+			Name:               "Structured",
+			Steps:              []protocol.SessionStep{{StepID: "structured-review", StepKey: "review", RequiredCapabilities: []string{"code.review"}}},
+			CapabilityMatching: protocol.CapabilityMatchingStructured,
+		}))
+	for _, sessionID := range []string{"s-legacy", "s-structured"} {
+		mustApply(t, m, signedTx(t, priv, "tx-join-"+sessionID, sessionID, "actor:a", base.Add(time.Second),
+			protocol.OpParticipantJoin, protocol.ParticipantJoinPayload{ParticipantID: "p-" + sessionID, SessionID: sessionID, Type: "AGENT", Ref: "agent:a",
+				Capabilities: []string{"code.*"}, TrustScore: -5}))
+	}
+
+	// A wildcard grant never satisfied a claim under the original exact rule;
+	// replaying such an entry must still reject it.
+	if err := m.ApplyTx(signedTx(t, priv, "tx-l3", "s-legacy", "actor:a", base.Add(2*time.Second),
+		protocol.OpStepClaim, protocol.StepClaimPayload{ClaimID: "c-legacy", StepID: "legacy-review", ParticipantID: "p-s-legacy"})); err == nil {
+		t.Fatalf("expected exact matching to reject wildcard grant in legacy session")
+	}
+	mustApply(t, m, signedTx(t, priv, "tx-l4", "s-structured", "actor:a", base.Add(2*time.Second),
+		protocol.OpStepClaim, protocol.StepClaimPayload{ClaimID: "c-structured", StepID: "structured-review", ParticipantID: "p-s-structured"}))
+
+	if err := m.Admit(signedTx(t, priv, "tx-l5", "s-odd", "actor:admin", base.Add(3*time.Second),
+		protocol.OpSessionCreate, protocol.SessionCreatePayload{SessionID: "s-odd", Name: "odd", CapabilityMatching: "fuzzy",
+			Steps: []protocol.SessionStep{{StepID: "odd", StepKey: "odd"}}})); err == nil {
+		t.Fatalf("expected unknown capability_matching to be rejected at admission")
+	}
+}
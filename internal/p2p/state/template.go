@@ -0,0 +1,521 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/execution-hub/execution-hub/internal/p2p/protocol"
+)
+
+const (
+	TemplateStatusActive     = "ACTIVE"
+	TemplateStatusDeprecated = "DEPRECATED"
+)
+
+var templateParamPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+type TemplateParam struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Default     *string `json:"default,omitempty"`
+}
+
+type TemplateStep struct {
+	StepKey              string   `json:"stepKey"`
+	Name                 string   `json:"name"`
+	RequiredCapabilities []string `json:"requiredCapabilities,omitempty"`
+	MinTrustScore        int      `json:"minTrustScore,omitempty"`
+	DependsOn            []string `json:"dependsOn,omitempty"`
+	LeaseTTLSeconds      int      `json:"leaseTtlSeconds,omitempty"`
+	MaxLeaseSeconds      int      `json:"maxLeaseSeconds,omitempty"`
+	RenewAudit           string   `json:"renewAudit,omitempty"`
+}
+
+// Template is one immutable version of a session step graph.
+type Template struct {
+	TemplateID        string          `json:"templateId"`
+	Version           int             `json:"version"`
+	Name              string          `json:"name"`
+	Description       string          `json:"description,omitempty"`
+	Status            string          `json:"status"`
+	Params            []TemplateParam `json:"params,omitempty"`
+	Context           json.RawMessage `json:"context,omitempty"`
+	Steps             []TemplateStep  `json:"steps"`
+	RegisteredBy      string          `json:"registeredBy"`
+	TxID              string          `json:"txId"`
+	CreatedAt         time.Time       `json:"createdAt"`
+	UpdatedAt         time.Time       `json:"updatedAt"`
+	DeprecatedAt      *time.Time      `json:"deprecatedAt,omitempty"`
+	DeprecationReason string          `json:"deprecationReason,omitempty"`
+}
+
+// TemplateFilter narrows ListTemplates results by the latest version status.
+type TemplateFilter struct {
+	Status string
+}
+
+func (f TemplateFilter) matches(template Template) bool {
+	if status := strings.TrimSpace(f.Status); status != "" && !strings.EqualFold(template.Status, status) {
+		return false
+	}
+	return true
+}
+
+func cloneTemplate(in Template) Template {
+	if in.Context != nil {
+		in.Context = append([]byte(nil), in.Context...)
+	}
+	params := make([]TemplateParam, 0, len(in.Params))
+	for _, param := range in.Params {
+		if param.Default != nil {
+			def := *param.Default
+			param.Default = &def
+		}
+		params = append(params, param)
+	}
+	in.Params = params
+	steps := make([]TemplateStep, 0, len(in.Steps))
+	for _, step := range in.Steps {
+		step.RequiredCapabilities = append([]string(nil), step.RequiredCapabilities...)
+		step.DependsOn = append([]string(nil), step.DependsOn...)
+		steps = append(steps, step)
+	}
+	in.Steps = steps
+	return in
+}
+
+func (m *Machine) applyTemplateRegisterLocked(tx protocol.Tx, at time.Time) error {
+	payload, err := protocol.DecodePayload[protocol.TemplateRegisterPayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	templateID := strings.TrimSpace(payload.TemplateID)
+	if templateID == "" {
+		return errors.New("template_id is required")
+	}
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	versions := m.s.TemplatesByID[templateID]
+	nextVersion := len(versions) + 1
+	version := payload.Version
+	if version == 0 {
+		version = nextVersion
+	}
+	if version != nextVersion {
+		return fmt.Errorf("template version must be %d", nextVersion)
+	}
+	params, declared, err := normalizeTemplateParams(payload.Params)
+	if err != nil {
+		return err
+	}
+	steps, err := normalizeTemplateSteps(payload.Steps)
+	if err != nil {
+		return err
+	}
+	if len(payload.Context) > 0 && !json.Valid(payload.Context) {
+		return errors.New("context must be valid JSON")
+	}
+	template := Template{
+		TemplateID:   templateID,
+		Version:      version,
+		Name:         name,
+		Description:  strings.TrimSpace(payload.Description),
+		Status:       TemplateStatusActive,
+		Params:       params,
+		Context:      append([]byte(nil), payload.Context...),
+		Steps:        steps,
+		RegisteredBy: strings.TrimSpace(tx.Actor),
+		TxID:         tx.TxID,
+		CreatedAt:    at,
+		UpdatedAt:    at,
+	}
+	if len(template.Context) == 0 {
+		template.Context = nil
+	}
+	if err := checkTemplatePlaceholders(template, declared); err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		m.s.TemplateOrder = append(m.s.TemplateOrder, templateID)
+	}
+	m.s.TemplatesByID[templateID] = append(versions, template)
+	return nil
+}
+
+func (m *Machine) applyTemplateDeprecateLocked(tx protocol.Tx, at time.Time) error {
+	payload, err := protocol.DecodePayload[protocol.TemplateDeprecatePayload](tx.Payload)
+	if err != nil {
+		return err
+	}
+	templateID := strings.TrimSpace(payload.TemplateID)
+	if templateID == "" || payload.Version <= 0 {
+		return errors.New("template_id and version are required")
+	}
+	versions := m.s.TemplatesByID[templateID]
+	if payload.Version > len(versions) {
+		return fmt.Errorf("template not found: %s@%d", templateID, payload.Version)
+	}
+	template := versions[payload.Version-1]
+	if template.Status == TemplateStatusDeprecated {
+		return errors.New("template version already deprecated")
+	}
+	deprecatedAt := at
+	template.Status = TemplateStatusDeprecated
+	template.DeprecatedAt = &deprecatedAt
+	template.DeprecationReason = strings.TrimSpace(payload.Reason)
+	template.UpdatedAt = at
+	versions[payload.Version-1] = template
+	return nil
+}
+
+// instantiateTemplateLocked expands a template reference in a session
+// create payload into concrete steps. Step IDs are "<session_id>:<step_key>".
+func (m *Machine) instantiateTemplateLocked(payload protocol.SessionCreatePayload) (protocol.SessionCreatePayload, error) {
+	templateID := strings.TrimSpace(payload.TemplateID)
+	if len(payload.Steps) > 0 {
+		return payload, errors.New("steps must be empty when template_id is set")
+	}
+	template, err := m.resolveTemplateLocked(templateID, payload.TemplateVersion)
+	if err != nil {
+		return payload, err
+	}
+	values, err := resolveTemplateParams(template.Params, payload.Params)
+	if err != nil {
+		return payload, err
+	}
+	sessionID := strings.TrimSpace(payload.SessionID)
+	steps := make([]protocol.SessionStep, 0, len(template.Steps))
+	for _, raw := range template.Steps {
+		stepKey := substituteParams(raw.StepKey, values)
+		dependsOn := make([]string, 0, len(raw.DependsOn))
+		for _, dep := range raw.DependsOn {
+			dependsOn = append(dependsOn, substituteParams(dep, values))
+		}
+		capabilities := make([]string, 0, len(raw.RequiredCapabilities))
+		for _, required := range raw.RequiredCapabilities {
+			capabilities = append(capabilities, substituteParams(required, values))
+		}
+		steps = append(steps, protocol.SessionStep{
+			StepID:               sessionID + ":" + stepKey,
+			StepKey:              stepKey,
+			Name:                 substituteParams(raw.Name, values),
+			RequiredCapabilities: capabilities,
+			MinTrustScore:        raw.MinTrustScore,
+			DependsOn:            dependsOn,
+			LeaseTTLSeconds:      raw.LeaseTTLSeconds,
+			MaxLeaseSeconds:      raw.MaxLeaseSeconds,
+			RenewAudit:           raw.RenewAudit,
+		})
+	}
+	if strings.TrimSpace(payload.Name) == "" {
+		payload.Name = substituteParams(template.Name, values)
+	}
+	if strings.TrimSpace(payload.WorkflowID) == "" {
+		payload.WorkflowID = template.TemplateID
+	}
+	context := payload.Context
+	if len(context) == 0 {
+		context = template.Context
+	}
+	if len(context) > 0 {
+		substituted, err := substituteJSONParams(context, values)
+		if err != nil {
+			return payload, err
+		}
+		payload.Context = substituted
+	}
+	payload.TemplateID = template.TemplateID
+	payload.TemplateVersion = template.Version
+	payload.Params = values
+	payload.Steps = steps
+	return payload, nil
+}
+
+func (m *Machine) resolveTemplateLocked(templateID string, version int) (Template, error) {
+	versions := m.s.TemplatesByID[templateID]
+	if len(versions) == 0 {
+		return Template{}, fmt.Errorf("template not found: %s", templateID)
+	}
+	if version < 0 || version > len(versions) {
+		return Template{}, fmt.Errorf("template not found: %s@%d", templateID, version)
+	}
+	if version > 0 {
+		template := versions[version-1]
+		if template.Status != TemplateStatusActive {
+			return Template{}, fmt.Errorf("template version is deprecated: %s@%d", templateID, version)
+		}
+		return template, nil
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].Status == TemplateStatusActive {
+			return versions[i], nil
+		}
+	}
+	return Template{}, fmt.Errorf("template has no active version: %s", templateID)
+}
+
+func normalizeTemplateParams(in []protocol.TemplateParam) ([]TemplateParam, map[string]struct{}, error) {
+	out := make([]TemplateParam, 0, len(in))
+	declared := map[string]struct{}{}
+	for _, raw := range in {
+		name := strings.TrimSpace(raw.Name)
+		if name == "" {
+			return nil, nil, errors.New("param name is required")
+		}
+		if !templateParamPattern.MatchString("{{" + name + "}}") {
+			return nil, nil, fmt.Errorf("invalid param name: %s", name)
+		}
+		if _, exists := declared[name]; exists {
+			return nil, nil, fmt.Errorf("duplicate param: %s", name)
+		}
+		declared[name] = struct{}{}
+		param := TemplateParam{
+			Name:        name,
+			Description: strings.TrimSpace(raw.Description),
+			Required:    raw.Required,
+		}
+		if raw.Default != nil {
+			def := *raw.Default
+			param.Default = &def
+		}
+		out = append(out, param)
+	}
+	return out, declared, nil
+}
+
+func normalizeTemplateSteps(in []protocol.TemplateStep) ([]TemplateStep, error) {
+	if len(in) == 0 {
+		return nil, errors.New("steps are required")
+	}
+	out := make([]TemplateStep, 0, len(in))
+	keys := map[string]struct{}{}
+	for _, raw := range in {
+		stepKey := strings.TrimSpace(raw.StepKey)
+		if stepKey == "" {
+			return nil, errors.New("step_key is required")
+		}
+		if _, exists := keys[stepKey]; exists {
+			return nil, fmt.Errorf("duplicate step_key in template: %s", stepKey)
+		}
+		keys[stepKey] = struct{}{}
+		renewAudit, err := normalizeRenewAudit(raw.RenewAudit)
+		if err != nil {
+			return nil, err
+		}
+		if raw.MinTrustScore < 0 {
+			return nil, errors.New("min_trust_score must not be negative")
+		}
+		step := TemplateStep{
+			StepKey:              stepKey,
+			Name:                 strings.TrimSpace(raw.Name),
+			RequiredCapabilities: uniqueNonEmpty(raw.RequiredCapabilities),
+			MinTrustScore:        raw.MinTrustScore,
+			DependsOn:            uniqueNonEmpty(raw.DependsOn),
+			LeaseTTLSeconds:      raw.LeaseTTLSeconds,
+			MaxLeaseSeconds:      raw.MaxLeaseSeconds,
+			RenewAudit:           renewAudit,
+		}
+		if step.Name == "" {
+			step.Name = step.StepKey
+		}
+		out = append(out, step)
+	}
+	for _, step := range out {
+		for _, dep := range step.DependsOn {
+			if _, ok := keys[dep]; !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step_key: %s", step.StepKey, dep)
+			}
+		}
+	}
+	if cyclic := findDependencyCycle(out); cyclic != "" {
+		return nil, fmt.Errorf("dependency cycle at step_key: %s", cyclic)
+	}
+	return out, nil
+}
+
+func findDependencyCycle(steps []TemplateStep) string {
+	deps := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		deps[step.StepKey] = step.DependsOn
+	}
+	const (
+		visiting = 1
+		done     = 2
+	)
+	marks := map[string]int{}
+	var visit func(key string) bool
+	visit = func(key string) bool {
+		switch marks[key] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		marks[key] = visiting
+		for _, dep := range deps[key] {
+			if visit(dep) {
+				return true
+			}
+		}
+		marks[key] = done
+		return false
+	}
+	for _, step := range steps {
+		if visit(step.StepKey) {
+			return step.StepKey
+		}
+	}
+	return ""
+}
+
+func checkTemplatePlaceholders(template Template, declared map[string]struct{}) error {
+	fields := []string{template.Name, string(template.Context)}
+	for _, step := range template.Steps {
+		fields = append(fields, step.StepKey, step.Name)
+		fields = append(fields, step.RequiredCapabilities...)
+		fields = append(fields, step.DependsOn...)
+	}
+	for _, field := range fields {
+		for _, match := range templateParamPattern.FindAllStringSubmatch(field, -1) {
+			if _, ok := declared[match[1]]; !ok {
+				return fmt.Errorf("undeclared template param: %s", match[1])
+			}
+		}
+	}
+	return nil
+}
+
+func resolveTemplateParams(params []TemplateParam, supplied map[string]string) (map[string]string, error) {
+	known := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		known[param.Name] = struct{}{}
+	}
+	unknown := make([]string, 0)
+	for name := range supplied {
+		if _, ok := known[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown template param: %s", strings.Join(unknown, ", "))
+	}
+	values := make(map[string]string, len(params))
+	for _, param := range params {
+		if value, ok := supplied[param.Name]; ok {
+			values[param.Name] = value
+			continue
+		}
+		if param.Default != nil {
+			values[param.Name] = *param.Default
+			continue
+		}
+		if param.Required {
+			return nil, fmt.Errorf("missing required template param: %s", param.Name)
+		}
+		values[param.Name] = ""
+	}
+	return values, nil
+}
+
+func substituteParams(in string, values map[string]string) string {
+	return templateParamPattern.ReplaceAllStringFunc(in, func(match string) string {
+		name := templateParamPattern.FindStringSubmatch(match)[1]
+		return values[name]
+	})
+}
+
+// substituteJSONParams replaces placeholders inside JSON string values only,
+// so parameter values can never change the document structure.
+func substituteJSONParams(raw json.RawMessage, values map[string]string) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, errors.New("context must be valid JSON")
+	}
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch typed := v.(type) {
+		case string:
+			return substituteParams(typed, values)
+		case []any:
+			for i := range typed {
+				typed[i] = walk(typed[i])
+			}
+			return typed
+		case map[string]any:
+			for k, item := range typed {
+				typed[k] = walk(item)
+			}
+			return typed
+		default:
+			return v
+		}
+	}
+	return json.Marshal(walk(doc))
+}
+
+// GetTemplate returns one template version; version 0 returns the latest.
+func (m *Machine) GetTemplate(templateID string, version int) (Template, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	versions := m.s.TemplatesByID[strings.TrimSpace(templateID)]
+	if len(versions) == 0 || version < 0 || version > len(versions) {
+		return Template{}, false
+	}
+	if version == 0 {
+		version = len(versions)
+	}
+	return cloneTemplate(versions[version-1]), true
+}
+
+// ListTemplateVersions returns every version of one template, oldest first.
+func (m *Machine) ListTemplateVersions(templateID string) []Template {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	versions := m.s.TemplatesByID[strings.TrimSpace(templateID)]
+	out := make([]Template, 0, len(versions))
+	for _, template := range versions {
+		out = append(out, cloneTemplate(template))
+	}
+	return out
+}
+
+// ListTemplates returns the latest version of each template in
+// registration order.
+func (m *Machine) ListTemplates(filter TemplateFilter, limit int, cursor string) ([]Template, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	after, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	order := m.s.TemplateOrder
+	latest := func(i int) (Template, bool) {
+		versions := m.s.TemplatesByID[order[i]]
+		if len(versions) == 0 {
+			return Template{}, false
+		}
+		return versions[len(versions)-1], true
+	}
+	idx, next := pageAscending(len(order), after, limit, func(i int) bool {
+		template, ok := latest(i)
+		return ok && filter.matches(template)
+	})
+	out := make([]Template, 0, len(idx))
+	for _, i := range idx {
+		template, _ := latest(i)
+		out = append(out, cloneTemplate(template))
+	}
+	return out, next, nil
+}
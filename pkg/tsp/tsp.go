@@ -0,0 +1,486 @@
+// Package tsp implements the client side of the RFC 3161 Time-Stamp
+// Protocol: obtaining timestamp tokens over SHA-256 digests from a
+// time-stamping authority (TSA) over HTTP, and verifying tokens offline.
+//
+// A token is a CMS SignedData (RFC 5652) whose content is the TSTInfo: the
+// digest that was stamped and the time the TSA stamped it. Verification
+// checks that the token covers the digest and that its signature was made
+// by the TSA certificate it carries; given trusted roots, it also checks
+// that certificate chains to them and was valid when the token was made.
+package tsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"time"
+
+	// Register the digests CMS signed attributes may use
+	_ "crypto/sha512"
+)
+
+// Content types of the protocol's HTTP transport (RFC 3161 section 3.4)
+const (
+	ContentTypeQuery = "application/timestamp-query"
+	ContentTypeReply = "application/timestamp-reply"
+)
+
+const (
+	// DefaultTimeout bounds a request to the TSA
+	DefaultTimeout = 10 * time.Second
+	// maxResponseBytes bounds the TSA responses read
+	maxResponseBytes = 1 << 20
+)
+
+// Verification errors
+var (
+	ErrMalformed          = errors.New("malformed timestamp token")
+	ErrRejected           = errors.New("timestamp request rejected")
+	ErrDigestMismatch     = errors.New("timestamp token covers another digest")
+	ErrInvalidSignature   = errors.New("invalid timestamp token signature")
+	ErrUntrustedAuthority = errors.New("timestamp authority is not trusted")
+)
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidRSAEncryption   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECPublicKey     = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"tag:0,optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       accuracy         `asn1:"optional"`
+	Ordering       bool             `asn1:"optional,default:false"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"explicit,optional,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+// Token is a parsed timestamp token
+type Token struct {
+	// Raw is the DER encoded token, which is what gets stored
+	Raw          []byte
+	GenTime      time.Time
+	SerialNumber *big.Int
+	Policy       asn1.ObjectIdentifier
+	Nonce        *big.Int
+	// HashedMessage is the stamped digest
+	HashedMessage []byte
+	// Certificates are the certificates the token carries; the TSA's is
+	// among them when it was requested
+	Certificates []*x509.Certificate
+
+	hashAlgorithm asn1.ObjectIdentifier
+	content       []byte
+	signer        signerInfo
+}
+
+// ParseToken parses a DER encoded timestamp token
+func ParseToken(der []byte) (*Token, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: not a CMS content info", ErrMalformed)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("%w: content is not signed data", ErrMalformed)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("%w: signed data: %v", ErrMalformed, err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, fmt.Errorf("%w: content is not a TSTInfo", ErrMalformed)
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("%w: expected one signer, got %d", ErrMalformed, len(sd.SignerInfos))
+	}
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("%w: TSTInfo: %v", ErrMalformed, err)
+	}
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		var err error
+		if certs, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+			return nil, fmt.Errorf("%w: certificates: %v", ErrMalformed, err)
+		}
+	}
+	return &Token{
+		Raw:           append([]byte(nil), der...),
+		GenTime:       info.GenTime.UTC(),
+		SerialNumber:  info.SerialNumber,
+		Policy:        info.Policy,
+		Nonce:         info.Nonce,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		Certificates:  certs,
+		hashAlgorithm: info.MessageImprint.HashAlgorithm.Algorithm,
+		content:       sd.EncapContentInfo.EContent,
+		signer:        sd.SignerInfos[0],
+	}, nil
+}
+
+// ParseResponse parses a DER encoded TSA response and returns its token. A
+// response rejecting the request returns an error wrapping ErrRejected.
+func ParseResponse(der []byte) (*Token, error) {
+	var resp timeStampResp
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil || len(rest) > 0 {
+		return nil, fmt.Errorf("%w: not a timestamp response", ErrMalformed)
+	}
+	// 0 is granted, 1 granted with modifications
+	if resp.Status.Status > 1 {
+		return nil, fmt.Errorf("%w: status %d %v", ErrRejected, resp.Status.Status, resp.Status.StatusString)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("%w: response has no token", ErrMalformed)
+	}
+	return ParseToken(resp.TimeStampToken.FullBytes)
+}
+
+// Signer returns the certificate the token was signed with, or nil when
+// the token does not carry it
+func (t *Token) Signer() *x509.Certificate {
+	var ias issuerAndSerialNumber
+	_, ianErr := asn1.Unmarshal(t.signer.SID.FullBytes, &ias)
+	for _, cert := range t.Certificates {
+		switch {
+		case ianErr == nil && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0:
+			return cert
+		// [0] SubjectKeyIdentifier
+		case t.signer.SID.Class == asn1.ClassContextSpecific && t.signer.SID.Tag == 0 && len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, t.signer.SID.Bytes):
+			return cert
+		}
+	}
+	return nil
+}
+
+// Verify checks that the token stamps the SHA-256 digest and was signed by
+// a timestamping certificate valid at the token's time. With roots, that
+// certificate must also chain to one of them.
+func (t *Token) Verify(digest []byte, roots *x509.CertPool) error {
+	if !t.hashAlgorithm.Equal(oidSHA256) || !bytes.Equal(t.HashedMessage, digest) {
+		return ErrDigestMismatch
+	}
+	cert := t.Signer()
+	if cert == nil {
+		return fmt.Errorf("%w: token does not carry the signer certificate", ErrInvalidSignature)
+	}
+	if err := t.checkSignature(cert); err != nil {
+		return err
+	}
+
+	timestamping := false
+	for _, usage := range cert.ExtKeyUsage {
+		timestamping = timestamping || usage == x509.ExtKeyUsageTimeStamping
+	}
+	if !timestamping {
+		return fmt.Errorf("%w: signer certificate is not for timestamping", ErrUntrustedAuthority)
+	}
+	if t.GenTime.Before(cert.NotBefore) || t.GenTime.After(cert.NotAfter) {
+		return fmt.Errorf("%w: signer certificate is not valid at %s", ErrUntrustedAuthority, t.GenTime.Format(time.RFC3339))
+	}
+	if roots == nil {
+		return nil
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range t.Certificates {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrustedAuthority, err)
+	}
+	return nil
+}
+
+// checkSignature checks the CMS signature over the signed attributes and
+// that those attributes cover the TSTInfo
+func (t *Token) checkSignature(cert *x509.Certificate) error {
+	si := t.signer
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return fmt.Errorf("%w: no signed attributes", ErrInvalidSignature)
+	}
+	hash, ok := digestHash(si.DigestAlgorithm.Algorithm)
+	if !ok {
+		return fmt.Errorf("%w: unsupported digest algorithm %v", ErrInvalidSignature, si.DigestAlgorithm.Algorithm)
+	}
+	// The signature covers the attributes DER encoded as a SET, not with
+	// their implicit [0] tag
+	signed := append([]byte{0x31}, si.SignedAttrs.FullBytes[1:]...)
+	var attrs []attribute
+	if _, err := asn1.UnmarshalWithParams(signed, &attrs, "set"); err != nil {
+		return fmt.Errorf("%w: signed attributes: %v", ErrMalformed, err)
+	}
+	var digest []byte
+	var contentType asn1.ObjectIdentifier
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+				return fmt.Errorf("%w: message digest attribute: %v", ErrMalformed, err)
+			}
+		case attr.Type.Equal(oidContentType):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &contentType); err != nil {
+				return fmt.Errorf("%w: content type attribute: %v", ErrMalformed, err)
+			}
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return fmt.Errorf("%w: signed content type is not TSTInfo", ErrInvalidSignature)
+	}
+	h := hash.New()
+	h.Write(t.content)
+	if !bytes.Equal(digest, h.Sum(nil)) {
+		return fmt.Errorf("%w: signed digest does not match the TSTInfo", ErrInvalidSignature)
+	}
+
+	alg, ok := signatureAlgorithm(si.SignatureAlgorithm.Algorithm, hash)
+	if !ok {
+		return fmt.Errorf("%w: unsupported signature algorithm %v", ErrInvalidSignature, si.SignatureAlgorithm.Algorithm)
+	}
+	if err := cert.CheckSignature(alg, signed, si.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+func digestHash(oid asn1.ObjectIdentifier) (crypto.Hash, bool) {
+	switch {
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, true
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, true
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// signatureAlgorithm maps a CMS signature algorithm, which for RSA and
+// ECDSA may name just the key type, and the signer's digest to x509's
+func signatureAlgorithm(oid asn1.ObjectIdentifier, hash crypto.Hash) (x509.SignatureAlgorithm, bool) {
+	byHash := func(sha256, sha384, sha512 x509.SignatureAlgorithm) (x509.SignatureAlgorithm, bool) {
+		switch hash {
+		case crypto.SHA256:
+			return sha256, true
+		case crypto.SHA384:
+			return sha384, true
+		case crypto.SHA512:
+			return sha512, true
+		}
+		return x509.UnknownSignatureAlgorithm, false
+	}
+	switch {
+	case oid.Equal(oidRSAEncryption):
+		return byHash(x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA)
+	case oid.Equal(oidECPublicKey):
+		return byHash(x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512)
+	case oid.Equal(oidSHA256WithRSA):
+		return x509.SHA256WithRSA, true
+	case oid.Equal(oidSHA384WithRSA):
+		return x509.SHA384WithRSA, true
+	case oid.Equal(oidSHA512WithRSA):
+		return x509.SHA512WithRSA, true
+	case oid.Equal(oidECDSAWithSHA256):
+		return x509.ECDSAWithSHA256, true
+	case oid.Equal(oidECDSAWithSHA384):
+		return x509.ECDSAWithSHA384, true
+	case oid.Equal(oidECDSAWithSHA512):
+		return x509.ECDSAWithSHA512, true
+	case oid.Equal(oidEd25519):
+		return x509.PureEd25519, true
+	}
+	return x509.UnknownSignatureAlgorithm, false
+}
+
+// Client requests timestamp tokens from a TSA
+type Client struct {
+	url   string
+	roots *x509.CertPool
+	http  *http.Client
+}
+
+// NewClient creates a client of the TSA at url. Tokens are checked against
+// roots, or only for a valid signature when roots is nil. A zero timeout
+// uses DefaultTimeout.
+func NewClient(url string, roots *x509.CertPool, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Client{url: url, roots: roots, http: &http.Client{Timeout: timeout}}
+}
+
+// LoadRoots reads the PEM encoded CA certificates of a file into a pool
+func LoadRoots(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in %s", path)
+	}
+	return pool, nil
+}
+
+// URL returns the TSA URL
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Timestamp obtains a verified token over the SHA-256 digest
+func (c *Client) Timestamp(ctx context.Context, digest []byte) (*Token, error) {
+	if len(digest) != sha256.Size {
+		return nil, fmt.Errorf("expected a SHA-256 digest, got %d bytes", len(digest))
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	query, err := asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode timestamp request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create timestamp request: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentTypeQuery)
+	req.Header.Set("Accept", ContentTypeReply)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach timestamp authority: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("timestamp authority returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timestamp response: %w", err)
+	}
+
+	token, err := ParseResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if token.Nonce == nil || token.Nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("%w: response nonce does not match the request", ErrMalformed)
+	}
+	if err := token.Verify(digest, c.roots); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
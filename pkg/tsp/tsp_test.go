@@ -0,0 +1,124 @@
+package tsp_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+	"github.com/execution-hub/execution-hub/pkg/tsp/tsptest"
+)
+
+func newAuthority(t *testing.T) (*tsptest.Authority, *httptest.Server) {
+	t.Helper()
+	tsa, err := tsptest.NewAuthority()
+	require.NoError(t, err)
+	srv := httptest.NewServer(tsa)
+	t.Cleanup(srv.Close)
+	return tsa, srv
+}
+
+func TestClient_Timestamp(t *testing.T) {
+	ctx := context.Background()
+	tsa, srv := newAuthority(t)
+	digest := sha256.Sum256([]byte("bundle"))
+	client := tsp.NewClient(srv.URL, tsa.Roots(), 0)
+
+	token, err := client.Timestamp(ctx, digest[:])
+	require.NoError(t, err)
+	assert.Equal(t, digest[:], token.HashedMessage)
+	assert.Equal(t, tsptest.Policy, token.Policy)
+	assert.Equal(t, tsa.Certificate, token.Signer())
+	assert.WithinDuration(t, time.Now(), token.GenTime, time.Minute)
+
+	// Stored tokens verify offline
+	parsed, err := tsp.ParseToken(token.Raw)
+	require.NoError(t, err)
+	require.NoError(t, parsed.Verify(digest[:], tsa.Roots()))
+	require.NoError(t, parsed.Verify(digest[:], nil))
+	other := sha256.Sum256([]byte("other"))
+	assert.ErrorIs(t, parsed.Verify(other[:], nil), tsp.ErrDigestMismatch)
+
+	t.Run("untrusted authority", func(t *testing.T) {
+		_, err := tsp.NewClient(srv.URL, x509.NewCertPool(), 0).Timestamp(ctx, digest[:])
+		assert.ErrorIs(t, err, tsp.ErrUntrustedAuthority)
+	})
+
+	t.Run("rejected request", func(t *testing.T) {
+		tsa.SetStatus(2)
+		defer tsa.SetStatus(0)
+		_, err := client.Timestamp(ctx, digest[:])
+		assert.ErrorIs(t, err, tsp.ErrRejected)
+	})
+
+	t.Run("not a SHA-256 digest", func(t *testing.T) {
+		_, err := client.Timestamp(ctx, []byte("short"))
+		assert.Error(t, err)
+	})
+}
+
+func TestToken_Verify(t *testing.T) {
+	tsa, _ := newAuthority(t)
+	digest := sha256.Sum256([]byte("checkpoint"))
+
+	t.Run("tampered token", func(t *testing.T) {
+		der, err := tsa.Token(digest[:], big.NewInt(7))
+		require.NoError(t, err)
+		token, err := tsp.ParseToken(der)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), token.Nonce.Int64())
+
+		// Flip a byte of the signature, which ends the token
+		der[len(der)-1] ^= 0xff
+		tampered, err := tsp.ParseToken(der)
+		require.NoError(t, err)
+		assert.ErrorIs(t, tampered.Verify(digest[:], nil), tsp.ErrInvalidSignature)
+	})
+
+	t.Run("certificate not valid at the token's time", func(t *testing.T) {
+		tsa.SetNow(func() time.Time { return time.Now().Add(48 * time.Hour) })
+		defer tsa.SetNow(time.Now)
+		der, err := tsa.Token(digest[:], nil)
+		require.NoError(t, err)
+		token, err := tsp.ParseToken(der)
+		require.NoError(t, err)
+		assert.ErrorIs(t, token.Verify(digest[:], nil), tsp.ErrUntrustedAuthority)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := tsp.ParseToken([]byte("not a token"))
+		assert.ErrorIs(t, err, tsp.ErrMalformed)
+		_, err = tsp.ParseResponse([]byte{0x30, 0x00})
+		assert.ErrorIs(t, err, tsp.ErrMalformed)
+	})
+}
+
+func TestLoadRoots(t *testing.T) {
+	tsa, err := tsptest.NewAuthority()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "roots.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tsa.CA.Raw}), 0o600))
+
+	roots, err := tsp.LoadRoots(path)
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("bundle"))
+	der, err := tsa.Token(digest[:], nil)
+	require.NoError(t, err)
+	token, err := tsp.ParseToken(der)
+	require.NoError(t, err)
+	assert.NoError(t, token.Verify(digest[:], roots))
+
+	require.NoError(t, os.WriteFile(path, []byte("not pem"), 0o600))
+	_, err = tsp.LoadRoots(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,335 @@
+// Package tsptest provides a stand-in RFC 3161 time-stamping authority for
+// tests. It issues its own CA and timestamping certificate and answers
+// timestamp requests over HTTP:
+//
+//	tsa, err := tsptest.NewAuthority()
+//	srv := httptest.NewServer(tsa)
+//	client := tsp.NewClient(srv.URL, tsa.Roots(), 0)
+package tsptest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/execution-hub/execution-hub/pkg/tsp"
+)
+
+var (
+	oidSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	// Policy is the TSA policy of the tokens the authority issues
+	Policy = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"tag:0,optional"`
+}
+
+type pkiStatusInfo struct {
+	Status int
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// Authority is a stand-in TSA. Its zero value is not usable; create one
+// with NewAuthority.
+type Authority struct {
+	// Certificate is the timestamping certificate tokens are signed with
+	Certificate *x509.Certificate
+	// CA issued Certificate
+	CA *x509.Certificate
+
+	key crypto.Signer
+
+	mu      sync.Mutex
+	serial  int64
+	now     func() time.Time
+	status  int
+	digests [][]byte
+}
+
+// NewAuthority creates an authority with a fresh CA and ECDSA P-256
+// timestamping certificate
+func NewAuthority() (*Authority, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	notBefore := time.Now().Add(-time.Hour)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test TSA Root"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &Authority{Certificate: cert, CA: ca, key: key, now: time.Now}, nil
+}
+
+// Roots returns a pool holding the authority's CA
+func (a *Authority) Roots() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.CA)
+	return pool
+}
+
+// SetNow sets the clock tokens are stamped with
+func (a *Authority) SetNow(now func() time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.now = now
+}
+
+// SetStatus sets the PKI status the authority answers with; a status above
+// 1 rejects requests
+func (a *Authority) SetStatus(status int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.status = status
+}
+
+// Digests returns the digests the authority stamped over HTTP
+func (a *Authority) Digests() [][]byte {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([][]byte(nil), a.digests...)
+}
+
+// ServeHTTP answers an RFC 3161 timestamp request
+func (a *Authority) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("Content-Type") != tsp.ContentTypeQuery {
+		http.Error(w, "expected a timestamp query", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req timeStampReq
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	status := a.status
+	a.mu.Unlock()
+	resp := timeStampResp{Status: pkiStatusInfo{Status: status}}
+	if status <= 1 {
+		token, err := a.Token(req.MessageImprint.HashedMessage, req.Nonce)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+		a.mu.Lock()
+		a.digests = append(a.digests, req.MessageImprint.HashedMessage)
+		a.mu.Unlock()
+	}
+	out, err := asn1.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", tsp.ContentTypeReply)
+	_, _ = w.Write(out)
+}
+
+// Token issues a DER encoded token over the SHA-256 digest. nonce may be
+// nil.
+func (a *Authority) Token(digest []byte, nonce *big.Int) ([]byte, error) {
+	if len(digest) != sha256.Size {
+		return nil, errors.New("expected a SHA-256 digest")
+	}
+	a.mu.Lock()
+	a.serial++
+	serial := a.serial
+	genTime := a.now().UTC().Truncate(time.Second)
+	a.mu.Unlock()
+
+	sha256ID := pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	content, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         Policy,
+		MessageImprint: messageImprint{HashAlgorithm: sha256ID, HashedMessage: digest},
+		SerialNumber:   big.NewInt(serial),
+		GenTime:        genTime,
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentDigest := sha256.Sum256(content)
+	attrs, err := marshalAttributes(
+		attributeOf(oidContentType, oidTSTInfo),
+		attributeOf(oidMessageDigest, contentDigest[:]),
+	)
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(attrs)
+	signature, err := a.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	// Signed attributes are signed as a SET and embedded as [0] IMPLICIT
+	embedded := append([]byte{0xa0}, attrs[1:]...)
+
+	econtent, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256ID},
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: oidTSTInfo,
+			EContent:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: econtent},
+		},
+		Certificates: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.Certificate.Raw},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: a.Certificate.RawIssuer}, SerialNumber: a.Certificate.SerialNumber},
+			DigestAlgorithm:    sha256ID,
+			SignedAttrs:        asn1.RawValue{FullBytes: embedded},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+func attributeOf(oid asn1.ObjectIdentifier, value interface{}) attribute {
+	return attribute{Type: oid, Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: mustMarshal(value)}}
+}
+
+func marshalAttributes(attrs ...attribute) ([]byte, error) {
+	out, err := asn1.MarshalWithParams(attrs, "set")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode signed attributes: %w", err)
+	}
+	return out, nil
+}
+
+func mustMarshal(value interface{}) []byte {
+	der, err := asn1.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
@@ -0,0 +1,176 @@
+// Package webhooksig signs and verifies Execution Hub webhook deliveries.
+//
+// Each signed delivery carries two headers:
+//
+//	X-Webhook-Timestamp: 1767225600
+//	X-Webhook-Signature: v1=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd
+//
+// The signature is the hex HMAC-SHA256 of "<timestamp>.<raw body>" under
+// the endpoint's signing secret. While a secret is being rotated the header
+// carries one v1 entry per secret, so receivers holding either secret can
+// verify. Receivers should reject stale timestamps and, for full replay
+// protection, signatures they have already accepted; Verifier does both.
+package webhooksig
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header names
+const (
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+	// HeaderKeyID names the current signing key; it is informational.
+	HeaderKeyID = "X-Webhook-Key-Id"
+)
+
+const (
+	scheme = "v1"
+	// DefaultTolerance is the accepted clock skew between signer and
+	// receiver.
+	DefaultTolerance = 5 * time.Minute
+	// maxBodyBytes bounds the body VerifyRequest reads.
+	maxBodyBytes = 10 << 20
+)
+
+// Verification errors
+var (
+	ErrMissingHeader    = errors.New("missing webhook signature headers")
+	ErrInvalidHeader    = errors.New("invalid webhook signature header")
+	ErrTimestampSkew    = errors.New("webhook timestamp outside tolerance")
+	ErrNoValidSignature = errors.New("no valid webhook signature")
+	ErrReplayed         = errors.New("webhook delivery already received")
+)
+
+// Sign returns the hex signature of body at timestamp under secret
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeader returns the X-Webhook-Signature value with one entry per
+// secret, in order
+func SignatureHeader(timestamp int64, body []byte, secrets ...[]byte) string {
+	parts := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		parts = append(parts, scheme+"="+Sign(secret, timestamp, body))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Verify checks that header holds a signature of body at timestamp under
+// any of secrets, and that timestamp is within tolerance of now. A zero
+// tolerance uses DefaultTolerance.
+func Verify(header, timestamp string, body []byte, secrets [][]byte, tolerance time.Duration, now time.Time) error {
+	if header == "" || timestamp == "" {
+		return ErrMissingHeader
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidHeader
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	skew := now.Sub(time.Unix(ts, 0))
+	if skew > tolerance || skew < -tolerance {
+		return ErrTimestampSkew
+	}
+
+	var candidates [][]byte
+	for _, part := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrInvalidHeader
+		}
+		if name != scheme {
+			continue // unknown schemes are skipped for forward compatibility
+		}
+		sig, err := hex.DecodeString(value)
+		if err != nil {
+			return ErrInvalidHeader
+		}
+		candidates = append(candidates, sig)
+	}
+	for _, secret := range secrets {
+		expected, _ := hex.DecodeString(Sign(secret, ts, body))
+		for _, sig := range candidates {
+			if hmac.Equal(sig, expected) {
+				return nil
+			}
+		}
+	}
+	return ErrNoValidSignature
+}
+
+// Verifier checks signed requests and rejects replays of deliveries it has
+// already accepted within the tolerance window. It is safe for concurrent
+// use.
+type Verifier struct {
+	// Secrets are the endpoint's current secrets; list both during
+	// rotation.
+	Secrets   [][]byte
+	Tolerance time.Duration
+	// Now defaults to time.Now.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// VerifyRequest verifies r and returns its body. The request body is
+// replaced so handlers can read it again.
+func (v *Verifier) VerifyRequest(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	tolerance := v.Tolerance
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	timestamp := r.Header.Get(HeaderTimestamp)
+	if err := Verify(r.Header.Get(HeaderSignature), timestamp, body, v.Secrets, tolerance, now); err != nil {
+		return nil, err
+	}
+	// Deliveries are identified by timestamp and body rather than by
+	// signature, so replaying one entry of a dual-signed header is caught.
+	digest := sha256.Sum256(append([]byte(timestamp+"."), body...))
+	key := hex.EncodeToString(digest[:])
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen == nil {
+		v.seen = make(map[string]time.Time)
+	}
+	for key, at := range v.seen {
+		if now.Sub(at) > 2*tolerance {
+			delete(v.seen, key)
+		}
+	}
+	if _, dup := v.seen[key]; dup {
+		return nil, ErrReplayed
+	}
+	v.seen[key] = now
+	return body, nil
+}
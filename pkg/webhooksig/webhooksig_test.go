@@ -0,0 +1,88 @@
+package webhooksig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	current := []byte("current-secret")
+	previous := []byte("previous-secret")
+	body := []byte(`{"notification_id":"n-1"}`)
+	now := time.Unix(1767225600, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	single := SignatureHeader(now.Unix(), body, current)
+	dual := SignatureHeader(now.Unix(), body, current, previous)
+	assert.Equal(t, "v1="+Sign(current, now.Unix(), body), single)
+
+	tests := []struct {
+		name    string
+		header  string
+		ts      string
+		body    []byte
+		secrets [][]byte
+		now     time.Time
+		err     error
+	}{
+		{name: "valid", header: single, ts: ts, body: body, secrets: [][]byte{current}, now: now},
+		{name: "receiver holds both secrets", header: single, ts: ts, body: body, secrets: [][]byte{previous, current}, now: now},
+		{name: "dual-signed, receiver not rotated yet", header: dual, ts: ts, body: body, secrets: [][]byte{previous}, now: now},
+		{name: "unknown schemes skipped", header: "v0=abc," + single, ts: ts, body: body, secrets: [][]byte{current}, now: now},
+		{name: "wrong secret", header: single, ts: ts, body: body, secrets: [][]byte{[]byte("other")}, now: now, err: ErrNoValidSignature},
+		{name: "tampered body", header: single, ts: ts, body: []byte(`{}`), secrets: [][]byte{current}, now: now, err: ErrNoValidSignature},
+		{name: "tampered timestamp", header: single, ts: strconv.FormatInt(now.Unix()+1, 10), body: body, secrets: [][]byte{current}, now: now, err: ErrNoValidSignature},
+		{name: "stale", header: single, ts: ts, body: body, secrets: [][]byte{current}, now: now.Add(6 * time.Minute), err: ErrTimestampSkew},
+		{name: "future", header: single, ts: ts, body: body, secrets: [][]byte{current}, now: now.Add(-6 * time.Minute), err: ErrTimestampSkew},
+		{name: "missing", ts: ts, body: body, secrets: [][]byte{current}, now: now, err: ErrMissingHeader},
+		{name: "malformed", header: "v1", ts: ts, body: body, secrets: [][]byte{current}, now: now, err: ErrInvalidHeader},
+		{name: "bad timestamp", header: single, ts: "yesterday", body: body, secrets: [][]byte{current}, now: now, err: ErrInvalidHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.header, tt.ts, tt.body, tt.secrets, 0, tt.now)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestVerifier_VerifyRequest(t *testing.T) {
+	secret := []byte("secret")
+	previous := []byte("previous")
+	now := time.Unix(1767225600, 0)
+	body := `{"title":"Line down"}`
+	verifier := &Verifier{Secrets: [][]byte{secret, previous}, Now: func() time.Time { return now }}
+
+	newRequest := func(signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(body))
+		r.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		r.Header.Set(HeaderSignature, signature)
+		return r
+	}
+
+	header := SignatureHeader(now.Unix(), []byte(body), secret, previous)
+	r := newRequest(header)
+	got, err := verifier.VerifyRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got))
+	again := make([]byte, len(body))
+	n, _ := r.Body.Read(again)
+	assert.Equal(t, body, string(again[:n]))
+
+	_, err = verifier.VerifyRequest(newRequest(header))
+	assert.ErrorIs(t, err, ErrReplayed)
+
+	// Replaying only the second entry of the dual-signed header is caught too.
+	_, err = verifier.VerifyRequest(newRequest("v1=" + Sign(previous, now.Unix(), []byte(body))))
+	assert.ErrorIs(t, err, ErrReplayed)
+
+	_, err = verifier.VerifyRequest(newRequest("v1=" + Sign([]byte("forged"), now.Unix(), []byte(body))))
+	assert.ErrorIs(t, err, ErrNoValidSignature)
+}
@@ -29,6 +29,7 @@ type options struct {
 	sessionName string
 	contextJSON string
 	stepsJSON   string
+	matching    string
 
 	defaultStepID           string
 	defaultStepKey          string
@@ -46,6 +47,7 @@ type options struct {
 	stepID string
 
 	claimID string
+	offerID string
 
 	newClaimID        string
 	fromParticipantID string
@@ -66,12 +68,18 @@ type options struct {
 	voteID  string
 	choice  string
 	comment string
+
+	templateID      string
+	templateVersion int
+	templateJSON    string
+	paramsJSON      string
+	reason          string
 }
 
 func main() {
 	var opt options
 
-	flag.StringVar(&opt.op, "op", "", "operation: session-create|participant-join|step-claim|step-release|step-handoff|artifact-add|decision-open|vote-cast|step-resolve")
+	flag.StringVar(&opt.op, "op", "", "operation: session-create|participant-join|step-claim|claim-renew|step-assign|assign-accept|assign-decline|step-release|step-handoff|artifact-add|decision-open|vote-cast|step-resolve|template-register|template-deprecate")
 	flag.StringVar(&opt.sessionID, "session-id", "smoke-session", "session identifier")
 	flag.StringVar(&opt.actor, "actor", "smoke", "actor string")
 	flag.StringVar(&opt.txID, "tx-id", "", "tx identifier; auto-generated when empty")
@@ -83,6 +91,7 @@ func main() {
 	flag.StringVar(&opt.sessionName, "session-name", "Smoke Session", "session name for session-create")
 	flag.StringVar(&opt.contextJSON, "context-json", "", "session context JSON for session-create")
 	flag.StringVar(&opt.stepsJSON, "steps-json", "", "session steps JSON array for session-create")
+	flag.StringVar(&opt.matching, "capability-matching", "", "capability matching for session-create: EXACT|STRUCTURED; empty means EXACT")
 
 	flag.StringVar(&opt.defaultStepID, "default-step-id", "smoke-step-1", "default step_id when steps-json is empty")
 	flag.StringVar(&opt.defaultStepKey, "default-step-key", "draft", "default step_key when steps-json is empty")
@@ -99,6 +108,7 @@ func main() {
 
 	flag.StringVar(&opt.stepID, "step-id", "", "step identifier")
 	flag.StringVar(&opt.claimID, "claim-id", "", "claim identifier")
+	flag.StringVar(&opt.offerID, "offer-id", "", "offer identifier for step-assign/assign-accept/assign-decline")
 	flag.StringVar(&opt.newClaimID, "new-claim-id", "", "new claim identifier for step-handoff")
 	flag.StringVar(&opt.fromParticipantID, "from-participant-id", "", "source participant for step-handoff")
 	flag.StringVar(&opt.toParticipantID, "to-participant-id", "", "target participant for step-handoff")
@@ -118,6 +128,12 @@ func main() {
 	flag.StringVar(&opt.voteID, "vote-id", "", "vote identifier")
 	flag.StringVar(&opt.choice, "choice", "APPROVE", "vote choice: APPROVE|REJECT")
 	flag.StringVar(&opt.comment, "comment", "", "comment for vote or handoff")
+
+	flag.StringVar(&opt.templateID, "template-id", "", "template identifier for template ops or session-create")
+	flag.IntVar(&opt.templateVersion, "template-version", 0, "template version; 0 means next (register) or latest active (session-create)")
+	flag.StringVar(&opt.templateJSON, "template-json", "", "template register payload JSON")
+	flag.StringVar(&opt.paramsJSON, "params-json", "", "template params JSON object for session-create")
+	flag.StringVar(&opt.reason, "reason", "", "reason for template-deprecate or assign-decline")
 	flag.Parse()
 
 	op, err := parseOperation(opt.op)
@@ -183,6 +199,14 @@ func parseOperation(raw string) (protocol.Operation, error) {
 		return protocol.OpParticipantJoin, nil
 	case "step-claim", "step_claim":
 		return protocol.OpStepClaim, nil
+	case "claim-renew", "claim_renew":
+		return protocol.OpClaimRenew, nil
+	case "step-assign", "step_assign":
+		return protocol.OpStepAssign, nil
+	case "assign-accept", "assign_accept":
+		return protocol.OpAssignAccept, nil
+	case "assign-decline", "assign_decline":
+		return protocol.OpAssignDecline, nil
 	case "step-release", "step_release":
 		return protocol.OpStepRelease, nil
 	case "step-handoff", "step_handoff":
@@ -195,6 +219,10 @@ func parseOperation(raw string) (protocol.Operation, error) {
 		return protocol.OpVoteCast, nil
 	case "step-resolve", "step_resolve":
 		return protocol.OpStepResolve, nil
+	case "template-register", "template_register":
+		return protocol.OpTemplateRegister, nil
+	case "template-deprecate", "template_deprecate":
+		return protocol.OpTemplateDeprecate, nil
 	default:
 		return "", fmt.Errorf("unsupported op: %q", raw)
 	}
@@ -216,6 +244,26 @@ func buildPayload(op protocol.Operation, opt options) (json.RawMessage, string,
 			return nil, "", err
 		}
 
+		if templateID := strings.TrimSpace(opt.templateID); templateID != "" {
+			var params map[string]string
+			if strings.TrimSpace(opt.paramsJSON) != "" {
+				if err := json.Unmarshal([]byte(opt.paramsJSON), &params); err != nil {
+					return nil, "", fmt.Errorf("invalid params-json: %w", err)
+				}
+			}
+			raw, err := json.Marshal(protocol.SessionCreatePayload{
+				SessionID:          sessionID,
+				WorkflowID:         strings.TrimSpace(opt.workflowID),
+				Name:               sessionName,
+				Context:            contextRaw,
+				TemplateID:         templateID,
+				TemplateVersion:    opt.templateVersion,
+				Params:             params,
+				CapabilityMatching: strings.TrimSpace(opt.matching),
+			})
+			return raw, sessionID, err
+		}
+
 		var steps []protocol.SessionStep
 		if strings.TrimSpace(opt.stepsJSON) != "" {
 			if err := json.Unmarshal([]byte(opt.stepsJSON), &steps); err != nil {
@@ -251,11 +299,12 @@ func buildPayload(op protocol.Operation, opt options) (json.RawMessage, string,
 		}
 
 		raw, err := json.Marshal(protocol.SessionCreatePayload{
-			SessionID:  sessionID,
-			WorkflowID: strings.TrimSpace(opt.workflowID),
-			Name:       sessionName,
-			Context:    contextRaw,
-			Steps:      steps,
+			SessionID:          sessionID,
+			WorkflowID:         strings.TrimSpace(opt.workflowID),
+			Name:               sessionName,
+			Context:            contextRaw,
+			Steps:              steps,
+			CapabilityMatching: strings.TrimSpace(opt.matching),
 		})
 		return raw, sessionID, err
 
@@ -308,6 +357,68 @@ func buildPayload(op protocol.Operation, opt options) (json.RawMessage, string,
 		})
 		return raw, strings.TrimSpace(opt.sessionID), err
 
+	case protocol.OpClaimRenew:
+		stepID := strings.TrimSpace(opt.stepID)
+		participantID := strings.TrimSpace(opt.participantID)
+		if stepID == "" || participantID == "" {
+			return nil, "", errors.New("step-id and participant-id are required for claim-renew")
+		}
+		raw, err := json.Marshal(protocol.ClaimRenewPayload{
+			StepID:        stepID,
+			ParticipantID: participantID,
+			ClaimID:       strings.TrimSpace(opt.claimID),
+			LeaseSeconds:  opt.leaseSeconds,
+		})
+		return raw, strings.TrimSpace(opt.sessionID), err
+
+	case protocol.OpStepAssign:
+		stepID := strings.TrimSpace(opt.stepID)
+		participantID := strings.TrimSpace(opt.participantID)
+		if stepID == "" || participantID == "" {
+			return nil, "", errors.New("step-id and participant-id are required for step-assign")
+		}
+		offerID := strings.TrimSpace(opt.offerID)
+		if offerID == "" {
+			offerID = autoID("offer", time.Now().UTC())
+		}
+		raw, err := json.Marshal(protocol.StepAssignPayload{
+			OfferID:       offerID,
+			StepID:        stepID,
+			ParticipantID: participantID,
+		})
+		return raw, strings.TrimSpace(opt.sessionID), err
+
+	case protocol.OpAssignAccept:
+		offerID := strings.TrimSpace(opt.offerID)
+		participantID := strings.TrimSpace(opt.participantID)
+		if offerID == "" || participantID == "" {
+			return nil, "", errors.New("offer-id and participant-id are required for assign-accept")
+		}
+		claimID := strings.TrimSpace(opt.claimID)
+		if claimID == "" {
+			claimID = autoID("claim", time.Now().UTC())
+		}
+		raw, err := json.Marshal(protocol.AssignAcceptPayload{
+			OfferID:       offerID,
+			ParticipantID: participantID,
+			ClaimID:       claimID,
+			LeaseSeconds:  opt.leaseSeconds,
+		})
+		return raw, strings.TrimSpace(opt.sessionID), err
+
+	case protocol.OpAssignDecline:
+		offerID := strings.TrimSpace(opt.offerID)
+		participantID := strings.TrimSpace(opt.participantID)
+		if offerID == "" || participantID == "" {
+			return nil, "", errors.New("offer-id and participant-id are required for assign-decline")
+		}
+		raw, err := json.Marshal(protocol.AssignDeclinePayload{
+			OfferID:       offerID,
+			ParticipantID: participantID,
+			Reason:        strings.TrimSpace(opt.reason),
+		})
+		return raw, strings.TrimSpace(opt.sessionID), err
+
 	case protocol.OpStepRelease:
 		stepID := strings.TrimSpace(opt.stepID)
 		participantID := strings.TrimSpace(opt.participantID)
@@ -453,6 +564,38 @@ func buildPayload(op protocol.Operation, opt options) (json.RawMessage, string,
 			ParticipantID: participantID,
 		})
 		return raw, strings.TrimSpace(opt.sessionID), err
+
+	case protocol.OpTemplateRegister:
+		if strings.TrimSpace(opt.templateJSON) == "" {
+			return nil, "", errors.New("template-json is required for template-register")
+		}
+		var payload protocol.TemplateRegisterPayload
+		if err := json.Unmarshal([]byte(opt.templateJSON), &payload); err != nil {
+			return nil, "", fmt.Errorf("invalid template-json: %w", err)
+		}
+		if templateID := strings.TrimSpace(opt.templateID); templateID != "" {
+			payload.TemplateID = templateID
+		}
+		if opt.templateVersion > 0 {
+			payload.Version = opt.templateVersion
+		}
+		if strings.TrimSpace(payload.TemplateID) == "" {
+			return nil, "", errors.New("template_id is required for template-register")
+		}
+		raw, err := json.Marshal(payload)
+		return raw, "", err
+
+	case protocol.OpTemplateDeprecate:
+		templateID := strings.TrimSpace(opt.templateID)
+		if templateID == "" || opt.templateVersion <= 0 {
+			return nil, "", errors.New("template-id and template-version are required for template-deprecate")
+		}
+		raw, err := json.Marshal(protocol.TemplateDeprecatePayload{
+			TemplateID: templateID,
+			Version:    opt.templateVersion,
+			Reason:     strings.TrimSpace(opt.reason),
+		})
+		return raw, "", err
 	}
 	return nil, "", fmt.Errorf("unsupported op: %s", op)
 }